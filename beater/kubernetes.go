@@ -0,0 +1,361 @@
+// Tencent is pleased to support the open source community by making bkunifylogbeat 蓝鲸日志采集器 available.
+//
+// Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+//
+// bkunifylogbeat 蓝鲸日志采集器 is licensed under the MIT License.
+//
+// License for bkunifylogbeat 蓝鲸日志采集器:
+// --------------------------------------------------------------------
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+// documentation files (the "Software"), to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software,
+// and to permit persons to whom the Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all copies or substantial
+// portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+// LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN
+// NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+// WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+// SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package beater
+
+import (
+	"bufio"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	cfg "github.com/TencentBlueKing/bkunifylogbeat/config"
+	"github.com/TencentBlueKing/collector-go-sdk/v2/bkbeat/bkmonitoring"
+	"github.com/TencentBlueKing/collector-go-sdk/v2/bkbeat/logp"
+	"github.com/elastic/beats/libbeat/monitoring"
+)
+
+var (
+	kubernetesPods        = bkmonitoring.NewInt("kubernetes_pods", monitoring.Gauge)
+	kubernetesWatchFailed = bkmonitoring.NewInt("kubernetes_watch_failed")
+)
+
+// kubernetesRetryWait: 访问API Server失败后的重试间隔
+const kubernetesRetryWait = 5 * time.Second
+
+// kubernetesPod: pod中用于生成采集任务的字段
+type kubernetesPod struct {
+	Metadata struct {
+		Name            string            `json:"name"`
+		Namespace       string            `json:"namespace"`
+		UID             string            `json:"uid"`
+		ResourceVersion string            `json:"resourceVersion"`
+		Labels          map[string]string `json:"labels"`
+	} `json:"metadata"`
+	Spec struct {
+		NodeName   string `json:"nodeName"`
+		Containers []struct {
+			Name  string `json:"name"`
+			Image string `json:"image"`
+		} `json:"containers"`
+	} `json:"spec"`
+}
+
+type kubernetesPodList struct {
+	Metadata struct {
+		ResourceVersion string `json:"resourceVersion"`
+	} `json:"metadata"`
+	Items []*kubernetesPod `json:"items"`
+}
+
+type kubernetesWatchEvent struct {
+	Type   string          `json:"type"`
+	Object json.RawMessage `json:"object"`
+}
+
+// kubernetesDiscovery: 通过list+watch维护本节点上的pod，pod变化时回调onChange
+type kubernetesDiscovery struct {
+	config   cfg.KubernetesConfig
+	host     string
+	token    string
+	client   *http.Client
+	onChange func()
+
+	mutex sync.Mutex
+	pods  map[string]*kubernetesPod
+	done  chan struct{}
+}
+
+// newKubernetesDiscovery: 未配置Host时使用集群内的API Server地址及service account
+func newKubernetesDiscovery(config cfg.KubernetesConfig, onChange func()) (*kubernetesDiscovery, error) {
+	if config.Node == "" {
+		config.Node = os.Getenv("NODE_NAME")
+	}
+	if config.Node == "" {
+		return nil, fmt.Errorf("kubernetes node is required, set kubernetes.node or NODE_NAME")
+	}
+	host := config.Host
+	if host == "" {
+		serviceHost, servicePort := os.Getenv("KUBERNETES_SERVICE_HOST"), os.Getenv("KUBERNETES_SERVICE_PORT")
+		if serviceHost == "" || servicePort == "" {
+			return nil, fmt.Errorf("kubernetes host is required when running out of cluster")
+		}
+		host = "https://" + net.JoinHostPort(serviceHost, servicePort)
+	}
+
+	d := &kubernetesDiscovery{
+		config:   config,
+		host:     strings.TrimSuffix(host, "/"),
+		client:   &http.Client{},
+		onChange: onChange,
+		pods:     make(map[string]*kubernetesPod),
+		done:     make(chan struct{}),
+	}
+	if token, err := ioutil.ReadFile(config.TokenFile); err == nil {
+		d.token = strings.TrimSpace(string(token))
+	}
+	if ca, err := ioutil.ReadFile(config.CAFile); err == nil {
+		pool := x509.NewCertPool()
+		pool.AppendCertsFromPEM(ca)
+		d.client.Transport = &http.Transport{
+			Proxy:           http.ProxyFromEnvironment,
+			TLSClientConfig: &tls.Config{RootCAs: pool},
+		}
+	}
+	return d, nil
+}
+
+// Start 开始list+watch
+func (d *kubernetesDiscovery) Start() {
+	go d.run()
+}
+
+// Stop 停止watch
+func (d *kubernetesDiscovery) Stop() {
+	close(d.done)
+}
+
+func (d *kubernetesDiscovery) run() {
+	for {
+		resourceVersion, err := d.list()
+		if err == nil {
+			err = d.watch(resourceVersion)
+		}
+		select {
+		case <-d.done:
+			return
+		default:
+		}
+		if err != nil {
+			kubernetesWatchFailed.Add(1)
+			logp.L.Errorf("kubernetes discovery failed, err=>%v", err)
+			select {
+			case <-d.done:
+				return
+			case <-time.After(kubernetesRetryWait):
+			}
+		}
+	}
+}
+
+// podsURL: 本节点上满足label_selector的pod
+func (d *kubernetesDiscovery) podsURL(params url.Values) string {
+	params.Set("fieldSelector", "spec.nodeName="+d.config.Node)
+	if d.config.LabelSelector != "" {
+		params.Set("labelSelector", d.config.LabelSelector)
+	}
+	return d.host + "/api/v1/pods?" + params.Encode()
+}
+
+func (d *kubernetesDiscovery) get(rawURL string) (*http.Response, error) {
+	req, err := http.NewRequest(http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	if d.token != "" {
+		req.Header.Set("Authorization", "Bearer "+d.token)
+	}
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("kubernetes api status %d", resp.StatusCode)
+	}
+	return resp, nil
+}
+
+// list: 全量获取pod，返回用于watch的resourceVersion
+func (d *kubernetesDiscovery) list() (string, error) {
+	resp, err := d.get(d.podsURL(url.Values{}))
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	var list kubernetesPodList
+	if err = json.NewDecoder(resp.Body).Decode(&list); err != nil {
+		return "", err
+	}
+
+	pods := make(map[string]*kubernetesPod)
+	for _, pod := range list.Items {
+		if d.accept(pod) {
+			pods[pod.Metadata.UID] = pod
+		}
+	}
+	d.mutex.Lock()
+	d.pods = pods
+	d.mutex.Unlock()
+	kubernetesPods.Set(int64(len(pods)))
+	d.onChange()
+	return list.Metadata.ResourceVersion, nil
+}
+
+// watch: 增量处理pod变化，连接断开时返回，由run重新list
+func (d *kubernetesDiscovery) watch(resourceVersion string) error {
+	resp, err := d.get(d.podsURL(url.Values{
+		"watch":           []string{"true"},
+		"resourceVersion": []string{resourceVersion},
+		"timeoutSeconds":  []string{"300"},
+	}))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	finished := make(chan struct{})
+	defer close(finished)
+	go func() {
+		// 停止时关闭连接以结束阻塞的读取
+		select {
+		case <-d.done:
+			resp.Body.Close()
+		case <-finished:
+		}
+	}()
+
+	decoder := json.NewDecoder(bufio.NewReader(resp.Body))
+	for {
+		var event kubernetesWatchEvent
+		if err = decoder.Decode(&event); err != nil {
+			return nil
+		}
+		if event.Type == "ERROR" {
+			return fmt.Errorf("kubernetes watch error: %s", event.Object)
+		}
+		pod := &kubernetesPod{}
+		if err = json.Unmarshal(event.Object, pod); err != nil {
+			return err
+		}
+		if d.update(event.Type, pod) {
+			d.onChange()
+		}
+	}
+}
+
+// update 更新pod列表，返回采集任务是否需要变化
+func (d *kubernetesDiscovery) update(eventType string, pod *kubernetesPod) bool {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	uid := pod.Metadata.UID
+	origin, exists := d.pods[uid]
+	if eventType == "DELETED" || !d.accept(pod) {
+		if !exists {
+			return false
+		}
+		delete(d.pods, uid)
+		kubernetesPods.Set(int64(len(d.pods)))
+		return true
+	}
+	d.pods[uid] = pod
+	kubernetesPods.Set(int64(len(d.pods)))
+	// 只有容器或标签变化时才需要重新生成任务
+	if exists && reflect.DeepEqual(origin.Spec.Containers, pod.Spec.Containers) &&
+		reflect.DeepEqual(origin.Metadata.Labels, pod.Metadata.Labels) {
+		return false
+	}
+	return true
+}
+
+// accept: pod是否在namespaces范围内
+func (d *kubernetesDiscovery) accept(pod *kubernetesPod) bool {
+	if len(d.config.Namespaces) == 0 {
+		return true
+	}
+	for _, namespace := range d.config.Namespaces {
+		if namespace == pod.Metadata.Namespace {
+			return true
+		}
+	}
+	return false
+}
+
+// Tasks 按任务模板为每个pod生成采集任务配置
+func (d *kubernetesDiscovery) Tasks(config cfg.KubernetesConfig) map[string]*cfg.TaskConfig {
+	d.mutex.Lock()
+	pods := make([]*kubernetesPod, 0, len(d.pods))
+	for _, pod := range d.pods {
+		pods = append(pods, pod)
+	}
+	d.mutex.Unlock()
+	sort.Slice(pods, func(i, j int) bool { return pods[i].Metadata.UID < pods[j].Metadata.UID })
+
+	tasks := make(map[string]*cfg.TaskConfig)
+	for _, pod := range pods {
+		for _, template := range config.Tasks {
+			task, err := cfg.CreateTaskConfig(kubernetesTaskVars(config.LogPath, template, pod))
+			if err != nil {
+				logp.L.Errorf("create kubernetes task failed, pod=>%s/%s, err=>%v",
+					pod.Metadata.Namespace, pod.Metadata.Name, err)
+				continue
+			}
+			tasks[task.ID] = task
+		}
+	}
+	return tasks
+}
+
+// kubernetesTaskVars: 在任务模板上设置pod各容器的日志路径(CRI目录结构：<namespace>_<pod>_<uid>/<container>/*.log)及pod元数据
+func kubernetesTaskVars(logPath string, template map[string]interface{}, pod *kubernetesPod) map[string]interface{} {
+	vars := make(map[string]interface{}, len(template)+2)
+	for key, value := range template {
+		vars[key] = value
+	}
+	podDir := fmt.Sprintf("%s_%s_%s", pod.Metadata.Namespace, pod.Metadata.Name, pod.Metadata.UID)
+	paths := make([]string, 0, len(pod.Spec.Containers))
+	containers := make([]map[string]interface{}, 0, len(pod.Spec.Containers))
+	for _, container := range pod.Spec.Containers {
+		paths = append(paths, filepath.Join(logPath, podDir, container.Name, "*.log"))
+		containers = append(containers, map[string]interface{}{"name": container.Name, "image": container.Image})
+	}
+	vars["type"] = "log"
+	vars["paths"] = paths
+
+	extMeta := map[string]interface{}{}
+	if origin, ok := template["ext_meta"].(map[string]interface{}); ok {
+		for key, value := range origin {
+			extMeta[key] = value
+		}
+	}
+	extMeta["kubernetes"] = map[string]interface{}{
+		"namespace":  pod.Metadata.Namespace,
+		"pod":        pod.Metadata.Name,
+		"uid":        pod.Metadata.UID,
+		"node":       pod.Spec.NodeName,
+		"labels":     pod.Metadata.Labels,
+		"containers": containers,
+	}
+	vars["ext_meta"] = extMeta
+	return vars
+}
@@ -0,0 +1,78 @@
+// Tencent is pleased to support the open source community by making bkunifylogbeat 蓝鲸日志采集器 available.
+//
+// Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+//
+// bkunifylogbeat 蓝鲸日志采集器 is licensed under the MIT License.
+//
+// License for bkunifylogbeat 蓝鲸日志采集器:
+// --------------------------------------------------------------------
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+// documentation files (the "Software"), to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software,
+// and to permit persons to whom the Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all copies or substantial
+// portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+// LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN
+// NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+// WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+// SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package beater
+
+import (
+	"encoding/json"
+	"testing"
+
+	cfg "github.com/TencentBlueKing/bkunifylogbeat/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func mockKubernetesPod(t *testing.T, raw string) *kubernetesPod {
+	pod := &kubernetesPod{}
+	assert.NoError(t, json.Unmarshal([]byte(raw), pod))
+	return pod
+}
+
+// TestKubernetesDiscovery: 测试pod变化及按模板生成采集任务
+func TestKubernetesDiscovery(t *testing.T) {
+	d, err := newKubernetesDiscovery(cfg.KubernetesConfig{
+		Host:       "http://127.0.0.1:1",
+		Node:       "node-1",
+		Namespaces: []string{"default"},
+	}, func() {})
+	assert.NoError(t, err)
+
+	pod := mockKubernetesPod(t, `{
+		"metadata": {"name": "web-0", "namespace": "default", "uid": "u1", "labels": {"app": "web"}},
+		"spec": {"nodeName": "node-1", "containers": [{"name": "nginx", "image": "nginx:1.19"}]}
+	}`)
+	assert.True(t, d.update("ADDED", pod))
+	assert.False(t, d.update("MODIFIED", pod))
+	assert.False(t, d.update("ADDED", mockKubernetesPod(t, `{"metadata": {"namespace": "kube-system", "uid": "u2"}}`)))
+
+	config := cfg.KubernetesConfig{
+		LogPath: "/var/log/pods",
+		Tasks: []map[string]interface{}{
+			{"dataid": 999990001, "ext_meta": map[string]interface{}{"env": "prod"}},
+		},
+	}
+	tasks := d.Tasks(config)
+	assert.Len(t, tasks, 1)
+	for _, task := range tasks {
+		assert.Equal(t, 999990001, task.DataID)
+		settings := struct {
+			Paths []string `config:"paths"`
+		}{}
+		assert.NoError(t, task.RawConfig.Unpack(&settings))
+		assert.Equal(t, []string{"/var/log/pods/default_web-0_u1/nginx/*.log"}, settings.Paths)
+		extMeta := task.ExtMeta.(map[string]interface{})
+		assert.Equal(t, "prod", extMeta["env"])
+		assert.Equal(t, "web-0", extMeta["kubernetes"].(map[string]interface{})["pod"])
+	}
+
+	assert.True(t, d.update("DELETED", pod))
+	assert.Len(t, d.Tasks(config), 0)
+}
@@ -47,20 +47,24 @@ var (
 // 1. 读取采集任务
 // 2. 管理任务生命周期：创建、删除
 type Manager struct {
-	tasks       map[string]*task.Task
-	tasksConfig map[string]*cfg.TaskConfig
-	config      cfg.Config
-	wg          sync.WaitGroup
-	beatDone    chan struct{}
+	tasks         map[string]*task.Task
+	tasksConfig   map[string]*cfg.TaskConfig
+	configHistory map[int][]*cfg.TaskConfig // 按dataid记录被替换的历史配置，用于回滚
+	config        cfg.Config
+	wg            sync.WaitGroup
+	mutex         sync.Mutex
+	beatDone      chan struct{}
+	kubernetes    *kubernetesDiscovery // 自动发现的pod采集任务，未开启时为nil
 }
 
 // create new manager
 func NewManager(config cfg.Config, beatDone chan struct{}) (*Manager, error) {
 	m := &Manager{
-		config:      config,
-		beatDone:    beatDone,
-		tasks:       make(map[string]*task.Task),
-		tasksConfig: make(map[string]*cfg.TaskConfig),
+		config:        config,
+		beatDone:      beatDone,
+		tasks:         make(map[string]*task.Task),
+		tasksConfig:   make(map[string]*cfg.TaskConfig),
+		configHistory: make(map[int][]*cfg.TaskConfig),
 	}
 
 	return m, nil
@@ -72,6 +76,9 @@ func (m *Manager) Start() error {
 	logp.L.Info("start manager")
 
 	task.SetResourceLimit(m.config.MaxCpuLimit, m.config.CpuCheckTimes)
+	task.SetMetricsCardinality(m.config.MaxMetricsCardinality)
+	task.SetEventRateAlpha(m.config.EventRateAlpha)
+	task.SetRegexCacheTTL(m.config.RegexCacheTTL)
 
 	// Task
 	lastStates := registrar.ResetStates(Registrar.GetStates())
@@ -84,11 +91,43 @@ func (m *Manager) Start() error {
 		taskStarted.Add(1)
 	}
 
+	// Kubernetes: pod变化时按当前配置重新加载任务
+	if m.config.Kubernetes.Enabled {
+		m.kubernetes, err = newKubernetesDiscovery(m.config.Kubernetes, m.refresh)
+		if err != nil {
+			logp.L.Errorf("error creating kubernetes discovery, err=>%v", err)
+		} else {
+			m.kubernetes.Start()
+		}
+	}
+
 	return nil
 }
 
+// refresh 自动发现的任务变化时，以当前配置重新加载
+func (m *Manager) refresh() {
+	m.mutex.Lock()
+	config := m.config
+	m.mutex.Unlock()
+	m.Reload(config)
+}
+
+// getTasks 配置文件中的任务及自动发现的任务
+func (m *Manager) getTasks(config cfg.Config) map[string]*cfg.TaskConfig {
+	tasks := cfg.GetTasks(config)
+	if m.kubernetes != nil {
+		for taskID, taskConfig := range m.kubernetes.Tasks(config.Kubernetes) {
+			tasks[taskID] = taskConfig
+		}
+	}
+	return tasks
+}
+
 // Close manager when program quit
 func (m *Manager) Stop() error {
+	if m.kubernetes != nil {
+		m.kubernetes.Stop()
+	}
 	for _, t := range m.tasks {
 		t.Stop()
 		m.wg.Done()
@@ -100,12 +139,17 @@ func (m *Manager) Stop() error {
 
 // Reload : diff config, create, remove, update jobs
 func (m *Manager) Reload(config cfg.Config) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
 	logp.L.Infof("[Reload]update config, current tasks=>%d", len(m.tasks))
 
 	task.SetResourceLimit(config.MaxCpuLimit, config.CpuCheckTimes)
+	task.SetMetricsCardinality(config.MaxMetricsCardinality)
+	task.SetEventRateAlpha(config.EventRateAlpha)
+	task.SetRegexCacheTTL(config.RegexCacheTTL)
 
 	lastStates := registrar.ResetStates(Registrar.GetStates())
-	tasks := cfg.GetTasks(config)
+	tasks := m.getTasks(config)
 
 	reloadTasks := make(map[string]*cfg.TaskConfig)
 	removeTasks := make(map[string]*cfg.TaskConfig)
@@ -128,6 +172,21 @@ func (m *Manager) Reload(config cfg.Config) {
 				logp.L.Infof("load modified secondary config file: %s", taskID)
 				addTasks[taskID] = taskConfig
 			}
+		} else if originTaskID, ok := m.findFilterOnlyChange(taskConfig, tasks); ok {
+			// 只调整了过滤条件时热更新，避免重启采集插件丢失处理中的事件
+			if err := m.tasks[originTaskID].UpdateFilters(taskConfig); err != nil {
+				logp.L.Errorf("update filters fail, restart task, taskID=>%s, err=>%v", originTaskID, err)
+				addTasks[taskID] = taskConfig
+				continue
+			}
+			logp.L.Infof("update filters of secondary config file: %s => %s", originTaskID, taskID)
+			m.pushConfigHistory(m.tasksConfig[originTaskID], config.MaxHistoryDepth)
+			m.tasks[taskID] = m.tasks[originTaskID]
+			m.tasksConfig[taskID] = taskConfig
+			delete(m.tasks, originTaskID)
+			delete(m.tasksConfig, originTaskID)
+			delete(removeTasks, originTaskID)
+			reloadTasks[taskID] = taskConfig
 		} else {
 			logp.L.Infof("load new secondary config file: %s", taskID)
 			addTasks[taskID] = taskConfig
@@ -137,7 +196,16 @@ func (m *Manager) Reload(config cfg.Config) {
 	logp.L.Infof("[Reload]originTasks=>%d, removeTasks=>%d, addTasks=>%d",
 		len(tasks), len(removeTasks), len(addTasks))
 
-	//step 3：清理任务信息
+	//step 3：同一dataid的配置被替换时，保留旧配置用于回滚
+	for _, taskConfig := range addTasks {
+		for _, removeConfig := range removeTasks {
+			if removeConfig.DataID == taskConfig.DataID {
+				m.pushConfigHistory(removeConfig, config.MaxHistoryDepth)
+			}
+		}
+	}
+
+	//step 4：清理任务信息
 	var err error
 	var isReloadRegistrar bool
 
@@ -152,7 +220,7 @@ func (m *Manager) Reload(config cfg.Config) {
 		}
 	}
 
-	//step 4：新增的任务需要启动采集、存量任务需要重新加载配置
+	//step 5：新增的任务需要启动采集、存量任务需要重新加载配置
 	if len(addTasks) > 0 {
 		for _, taskConfig := range addTasks {
 			err = m.startTask(taskConfig, lastStates)
@@ -174,7 +242,7 @@ func (m *Manager) Reload(config cfg.Config) {
 		}
 	}
 
-	//step 5: 重设beats配置
+	//step 6: 重设beats配置
 	m.config = config
 }
 
@@ -187,7 +255,7 @@ func (m *Manager) startTask(config *cfg.TaskConfig, lastStates []file.State) err
 	taskInst := task.NewTask(config, m.beatDone)
 	err = taskInst.Start(lastStates)
 	if err != nil {
-		logp.L.Errorf("start task err, taskid=>%s err=>%v", taskInst.ID, err)
+		logp.L.Errorf("start task err, taskid=>%s err=>%v", taskInst.GetID(), err)
 		taskError.Add(1)
 		return err
 	}
@@ -229,3 +297,111 @@ func (m *Manager) reloadTask(taskID string) error {
 	}
 	return nil
 }
+
+// pushConfigHistory: 记录历史配置，超过maxDepth时丢弃最早的配置
+func (m *Manager) pushConfigHistory(config *cfg.TaskConfig, maxDepth int) {
+	if maxDepth <= 0 {
+		return
+	}
+	history := append(m.configHistory[config.DataID], config)
+	if len(history) > maxDepth {
+		history = history[len(history)-maxDepth:]
+	}
+	m.configHistory[config.DataID] = history
+}
+
+// popConfigHistory: 取出steps次替换之前的配置，并丢弃该配置及之后的历史
+func (m *Manager) popConfigHistory(dataID int, steps int) (*cfg.TaskConfig, error) {
+	history, ok := m.configHistory[dataID]
+	if !ok {
+		return nil, fmt.Errorf("dataid(%d) has no config history", dataID)
+	}
+	if steps <= 0 || steps > len(history) {
+		return nil, fmt.Errorf("rollback steps(%d) out of range, dataid=>%d, history=>%d", steps, dataID, len(history))
+	}
+	target := history[len(history)-steps]
+	m.configHistory[dataID] = history[:len(history)-steps]
+	return target, nil
+}
+
+// findFilterOnlyChange: 查找同一dataid下只有过滤条件不同的运行中任务，新配置中仍然存在的任务不参与查找
+func (m *Manager) findFilterOnlyChange(config *cfg.TaskConfig, tasks map[string]*cfg.TaskConfig) (string, bool) {
+	for taskID, originTaskConfig := range m.tasksConfig {
+		if _, ok := tasks[taskID]; ok {
+			continue
+		}
+		if originTaskConfig.DataID == config.DataID && originTaskConfig.SameExceptFilters(config) {
+			return taskID, true
+		}
+	}
+	return "", false
+}
+
+// DryRunFilter 使用运行中任务的过滤配置对样例日志试运行，返回各条件组及条件的匹配情况
+func (m *Manager) DryRunFilter(taskID string, text string) (*task.DryRunResult, error) {
+	m.mutex.Lock()
+	config, ok := m.tasksConfig[taskID]
+	m.mutex.Unlock()
+	if !ok {
+		return nil, fmt.Errorf("task is not exists, taskID=>%s", taskID)
+	}
+	return task.DryRunFilter(config, text)
+}
+
+// GetConfigHistory 获取dataid的历史配置，按替换时间从早到晚排列
+func (m *Manager) GetConfigHistory(dataID int) []*cfg.TaskConfig {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	history := m.configHistory[dataID]
+	return append([]*cfg.TaskConfig{}, history...)
+}
+
+// RollbackConfig 将dataid的任务回滚到steps次替换之前的配置
+// 注意：回滚只作用于运行中的任务，下次reload时仍会以配置文件为准
+func (m *Manager) RollbackConfig(dataID int, steps int) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	history := m.configHistory[dataID]
+	target, err := m.popConfigHistory(dataID, steps)
+	if err != nil {
+		return err
+	}
+
+	// 回滚失败时恢复历史，并以原配置重新启动已移除的任务
+	var removed []*cfg.TaskConfig
+	restore := func() {
+		m.configHistory[dataID] = history
+		lastStates := registrar.ResetStates(Registrar.GetStates())
+		for _, config := range removed {
+			if err := m.startTask(config, lastStates); err != nil {
+				logp.L.Errorf("restore task fail, taskID=>%s, err=>%v", config.ID, err)
+				continue
+			}
+			taskStarted.Add(1)
+		}
+	}
+
+	for taskID, taskConfig := range m.tasksConfig {
+		if taskConfig.DataID != dataID {
+			continue
+		}
+		err := m.removeTask(taskID)
+		if err != nil {
+			restore()
+			return fmt.Errorf("remove task fail, taskID=>%s, err=>%v", taskID, err)
+		}
+		removed = append(removed, taskConfig)
+		taskStop.Add(1)
+	}
+
+	lastStates := registrar.ResetStates(Registrar.GetStates())
+	err = m.startTask(target, lastStates)
+	if err != nil {
+		restore()
+		return fmt.Errorf("start task fail, taskID=>%s, err=>%v", target.ID, err)
+	}
+	logp.L.Infof("rollback task config, dataid=>%d, taskID=>%s", dataID, target.ID)
+	taskReload.Add(1)
+	return nil
+}
@@ -0,0 +1,84 @@
+// Tencent is pleased to support the open source community by making bkunifylogbeat 蓝鲸日志采集器 available.
+//
+// Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+//
+// bkunifylogbeat 蓝鲸日志采集器 is licensed under the MIT License.
+//
+// License for bkunifylogbeat 蓝鲸日志采集器:
+// --------------------------------------------------------------------
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+// documentation files (the "Software"), to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software,
+// and to permit persons to whom the Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all copies or substantial
+// portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+// LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN
+// NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+// WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+// SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package beater
+
+import (
+	"testing"
+
+	cfg "github.com/TencentBlueKing/bkunifylogbeat/config"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestConfigHistory: 测试历史配置按max_history_depth保留最近的配置
+func TestConfigHistory(t *testing.T) {
+	m, err := NewManager(cfg.Config{}, nil)
+	assert.NoError(t, err)
+
+	for _, id := range []string{"v1", "v2", "v3", "v4"} {
+		m.pushConfigHistory(&cfg.TaskConfig{ID: id, DataID: 999990001}, 3)
+	}
+	m.pushConfigHistory(&cfg.TaskConfig{ID: "other", DataID: 999990002}, 3)
+	// max_history_depth为0时不记录
+	m.pushConfigHistory(&cfg.TaskConfig{ID: "none", DataID: 999990003}, 0)
+
+	history := m.GetConfigHistory(999990001)
+	assert.Len(t, history, 3)
+	assert.Equal(t, "v2", history[0].ID)
+	assert.Equal(t, "v4", history[2].ID)
+	assert.Len(t, m.GetConfigHistory(999990002), 1)
+	assert.Len(t, m.GetConfigHistory(999990003), 0)
+
+	// 返回副本，修改不影响管理器中的历史
+	history[0] = nil
+	assert.Equal(t, "v2", m.GetConfigHistory(999990001)[0].ID)
+}
+
+// TestRollbackConfig: 测试回滚N步时选择的配置，以及步数越界和未知dataid
+func TestRollbackConfig(t *testing.T) {
+	m, err := NewManager(cfg.Config{}, nil)
+	assert.NoError(t, err)
+	for _, id := range []string{"v1", "v2", "v3"} {
+		m.pushConfigHistory(&cfg.TaskConfig{ID: id, DataID: 999990001}, 10)
+	}
+
+	// 越界及未知dataid不修改历史
+	for _, steps := range []int{-1, 0, 4} {
+		assert.Error(t, m.RollbackConfig(999990001, steps), steps)
+	}
+	assert.Error(t, m.RollbackConfig(999990002, 1))
+	assert.Len(t, m.GetConfigHistory(999990001), 3)
+
+	// 回滚2步取v2，v2及之后的历史被丢弃
+	target, err := m.popConfigHistory(999990001, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, "v2", target.ID)
+	history := m.GetConfigHistory(999990001)
+	assert.Len(t, history, 1)
+	assert.Equal(t, "v1", history[0].ID)
+
+	target, err = m.popConfigHistory(999990001, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, "v1", target.ID)
+	_, err = m.popConfigHistory(999990001, 1)
+	assert.Error(t, err)
+}
@@ -42,11 +42,22 @@ type Config struct {
 	MaxCpuLimit int `config:"max_cpu_limit"` // 最大CPU限制，仅在某些极端情况下开启
 	// CpuCheckTimes
 	CpuCheckTimes int `config:"cpu_check_times"` // 1秒内检测多少次CPU, 可选值，[1-10]
+	// max metrics cardinality
+	MaxMetricsCardinality int `config:"max_metrics_cardinality"` // 按dataid区分的指标序列上限，超出部分聚合到_overflow
+	// event rate alpha
+	EventRateAlpha float64 `config:"event_rate_alpha"` // 事件速率EMA平滑系数，越大越灵敏，越小越平滑
+	// max history depth
+	MaxHistoryDepth int `config:"max_history_depth"` // 每个dataid保留的历史配置数，用于回滚
+	// regex cache ttl
+	RegexCacheTTL time.Duration `config:"regex_cache_ttl"` // 正则缓存淘汰时间，超过该时间未使用的正则会被清理
 
 	// SecConfigs sec config path and pattern
 	SecConfigs []SecConfigItem `config:"multi_config"`
 
 	Registry Registry `config:"registry"`
+
+	// 自动发现本节点上的kubernetes pod并生成采集任务
+	Kubernetes KubernetesConfig `config:"kubernetes"`
 }
 
 // 从配置目录
@@ -61,6 +72,21 @@ type Registry struct {
 	GcFrequency  time.Duration `config:"gc_frequency"`
 }
 
+// KubernetesConfig: 通过API Server发现Node上满足Namespaces及LabelSelector的pod，按Tasks模板为每个pod生成采集任务，
+// 任务的paths为pod各容器在LogPath下的日志文件，ext_meta.kubernetes为pod元数据。
+// Host为空时使用集群内的service account配置，Node为空时取NODE_NAME环境变量
+type KubernetesConfig struct {
+	Enabled       bool                     `config:"enabled"`
+	Host          string                   `config:"host"`
+	TokenFile     string                   `config:"token_file"`
+	CAFile        string                   `config:"ca_file"`
+	Node          string                   `config:"node"`
+	Namespaces    []string                 `config:"namespaces"`
+	LabelSelector string                   `config:"label_selector"`
+	LogPath       string                   `config:"log_path"`
+	Tasks         []map[string]interface{} `config:"tasks"`
+}
+
 //默认配置
 type Factory = func(rawConfig *beat.Config) (*beat.Config, error)
 
@@ -85,15 +111,23 @@ func Register(name string, factory Factory) error {
 // Parse用于主配置解析
 func Parse(cfg *beat.Config) (Config, error) {
 	config := Config{
-		MaxBytes:      1024 * 512,
-		Maxline:       10,
-		BufferTimeout: 1,
-		MaxCpuLimit:   -1,
-		CpuCheckTimes: 10,
+		MaxBytes:        1024 * 512,
+		Maxline:         10,
+		BufferTimeout:   1,
+		MaxCpuLimit:     -1,
+		CpuCheckTimes:   10,
+		EventRateAlpha:  0.3,
+		MaxHistoryDepth: 5,
+		RegexCacheTTL:   10 * time.Minute,
 		Registry: Registry{
 			FlushTimeout: 1 * time.Second,
 			GcFrequency:  1 * time.Minute,
 		},
+		Kubernetes: KubernetesConfig{
+			TokenFile: "/var/run/secrets/kubernetes.io/serviceaccount/token",
+			CAFile:    "/var/run/secrets/kubernetes.io/serviceaccount/ca.crt",
+			LogPath:   "/var/log/pods",
+		},
 	}
 	err := cfg.Unpack(&config)
 	if err != nil {
@@ -0,0 +1,389 @@
+// Tencent is pleased to support the open source community by making bkunifylogbeat 蓝鲸日志采集器 available.
+//
+// Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+//
+// bkunifylogbeat 蓝鲸日志采集器 is licensed under the MIT License.
+//
+// License for bkunifylogbeat 蓝鲸日志采集器:
+// --------------------------------------------------------------------
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+// documentation files (the "Software"), to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software,
+// and to permit persons to whom the Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all copies or substantial
+// portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+// LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN
+// NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+// WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+// SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// exportNumberPattern: 导出数值比较时先判断列内容是否为数值，与strconv.ParseFloat解析失败视为不匹配的语义一致
+const exportNumberPattern = `^\s*[-+]?(\d+\.?\d*|\.\d+)([eE][-+]?\d+)?\s*$`
+
+// ExportAsVectorConfig 将过滤条件转换为Vector remap(VRL)配置，便于迁移到Vector
+// 条件组之间为OR，组内为AND，所有组都不满足时abort丢弃事件；无法等价转换的条件返回错误，避免导出的配置放行与任务不同的事件
+func (config *TaskConfig) ExportAsVectorConfig() ([]byte, error) {
+	if err := config.checkExport(); err != nil {
+		return nil, err
+	}
+	groups := make([]string, 0, len(config.Filters))
+	for _, f := range config.Filters {
+		exprs := make([]string, 0, len(f.Conditions)+1)
+		if f.MinColumnCount > 0 {
+			exprs = append(exprs, fmt.Sprintf("length(words) >= %d", f.MinColumnCount))
+		}
+		for _, c := range f.Conditions {
+			expr, err := config.vectorExpr(c)
+			if err != nil {
+				return nil, err
+			}
+			exprs = append(exprs, expr)
+		}
+		groups = append(groups, wrapExprs(exprs, " && ", "true"))
+	}
+
+	var source strings.Builder
+	source.WriteString("text = string!(.data)\n")
+	if config.Delimiter != "" {
+		source.WriteString(fmt.Sprintf("words = split(text, %s, limit: %d)\n", strconv.Quote(config.Delimiter), config.maxIndex()+1))
+	} else {
+		source.WriteString("words = []\n")
+	}
+	if config.LogfmtParse {
+		source.WriteString("pairs = parse_logfmt(text) ?? {}\n")
+	}
+	source.WriteString(fmt.Sprintf("if !(%s) {\n  abort\n}\n", joinExprs(groups, " || ", "true")))
+
+	var out strings.Builder
+	out.WriteString(fmt.Sprintf("[transforms.bkunifylogbeat_filter_%d]\n", config.DataID))
+	out.WriteString("type = \"remap\"\n")
+	out.WriteString(fmt.Sprintf("inputs = [\"bkunifylogbeat_%d\"]\n", config.DataID))
+	out.WriteString("drop_on_abort = true\n")
+	out.WriteString("source = '''\n" + source.String() + "'''\n")
+	return []byte(out.String()), nil
+}
+
+// ExportAsLogstashConfig 将过滤条件转换为Logstash filter配置，所有条件组都不满足时drop；无法等价转换的条件返回错误
+func (config *TaskConfig) ExportAsLogstashConfig() (string, error) {
+	if err := config.checkExport(); err != nil {
+		return "", err
+	}
+	groups := make([]string, 0, len(config.Filters))
+	for _, f := range config.Filters {
+		exprs := make([]string, 0, len(f.Conditions)+1)
+		if f.MinColumnCount > 0 {
+			exprs = append(exprs, fmt.Sprintf("[@metadata][words][%d]", f.MinColumnCount-1))
+		}
+		for _, c := range f.Conditions {
+			expr, err := config.logstashExpr(c)
+			if err != nil {
+				return "", err
+			}
+			exprs = append(exprs, expr)
+		}
+		groups = append(groups, wrapExprs(exprs, " and ", "[data]"))
+	}
+
+	var out strings.Builder
+	out.WriteString("filter {\n")
+	if config.Delimiter != "" {
+		// mutate split不支持限制切分段数，使用ruby按与任务相同的段数切分，最后一段为剩余的全部内容
+		out.WriteString(fmt.Sprintf("  ruby { code => 'event.set(\"[@metadata][words]\", event.get(\"data\").to_s.split(Regexp.new(Regexp.escape(%s)), %d))' }\n",
+			strconv.Quote(config.Delimiter), config.maxIndex()+1))
+	}
+	if config.LogfmtParse {
+		out.WriteString("  kv { source => \"data\" target => \"[@metadata][pairs]\" }\n")
+	}
+	out.WriteString(fmt.Sprintf("  if !(%s) {\n    drop { }\n  }\n", joinExprs(groups, " or ", "[data]")))
+	out.WriteString("}\n")
+	return out.String(), nil
+}
+
+// checkExport: 检查任务级配置能否导出，csv及正则切分无法用单一分隔符表达，过滤表达式没有对应的语法
+func (config *TaskConfig) checkExport() error {
+	if !config.HasFilter {
+		return fmt.Errorf("task has no filter to export")
+	}
+	if config.SplitMode == "csv" || config.SplitPattern() != "" {
+		return fmt.Errorf("split_mode, split_regex, split_on_class and delimiters can not be exported")
+	}
+	if strings.ContainsAny(config.Delimiter, `'\`) {
+		return fmt.Errorf("delimiter(%s) can not be exported", config.Delimiter)
+	}
+	for i, f := range config.Filters {
+		if f.Expr != "" {
+			return fmt.Errorf("filters[%d] expr(%s) can not be exported", i, f.Expr)
+		}
+	}
+	return nil
+}
+
+// checkExportCondition: 检查单个条件能否导出，改变比较内容或比较方式的选项没有对应的语法
+func checkExportCondition(c ConditionConfig) error {
+	var option string
+	switch {
+	case c.Path != "":
+		option = "path"
+	case c.PadTo > 0 || c.TruncateTo > 0:
+		option = "pad_to/truncate_to"
+	case c.Tokenize:
+		option = "tokenize"
+	case c.SoftFail:
+		option = "soft_fail"
+	case c.Locale != "":
+		option = "locale"
+	case c.IPNormalize:
+		option = "ip_normalize"
+	case c.FieldType != "" && c.FieldType != "string" && !(numericOperations[c.Op] && c.FieldType == "float"):
+		option = "field_type"
+	default:
+		return nil
+	}
+	return fmt.Errorf("condition with %s on index(%d), key(%s) can not be exported", option, c.Index, c.Key)
+}
+
+// exportWholeLine: index小于等于0时对整行执行比较的操作符，其余操作符按key做包含匹配，与任务的过滤语义一致
+var exportWholeLine = map[string]bool{
+	"contains":  true,
+	"ncontains": true,
+	"regex":     true,
+	"nregex":    true,
+}
+
+// vectorExpr: 单个条件(或嵌套条件组)转换为VRL表达式
+func (config *TaskConfig) vectorExpr(c ConditionConfig) (string, error) {
+	if c.IsGroup() {
+		children, sep := c.All, " && "
+		if len(c.Any) > 0 {
+			children, sep = c.Any, " || "
+		}
+		exprs := make([]string, 0, len(children))
+		for _, child := range children {
+			expr, err := config.vectorExpr(child)
+			if err != nil {
+				return "", err
+			}
+			exprs = append(exprs, expr)
+		}
+		return exportNegate(c, wrapExprs(exprs, sep, "true")), nil
+	}
+	if err := checkExportCondition(c); err != nil {
+		return "", err
+	}
+
+	key, subject, guard := c.Key, "text", ""
+	switch {
+	case config.LogfmtParse && c.Index <= 0 && !c.IsFieldCondition():
+		parts := strings.SplitN(c.Key, ":", 2)
+		key = parts[1]
+		subject = fmt.Sprintf("(to_string(pairs.%s) ?? \"\")", strconv.Quote(parts[0]))
+		guard = fmt.Sprintf("exists(pairs.%s)", strconv.Quote(parts[0]))
+	case c.Index > 0:
+		subject = fmt.Sprintf("words[%d]", c.Index-1)
+		guard = fmt.Sprintf("length(words) >= %d", c.Index)
+	}
+	// ignore_case时任务将key及比较内容都转为小写
+	if c.IgnoreCase {
+		key = strings.ToLower(key)
+		subject = "downcase(" + subject + ")"
+	}
+
+	var expr string
+	if c.Index <= 0 && !exportWholeLine[c.Op] && guard == "" {
+		expr = fmt.Sprintf("contains(%s, %s)", subject, strconv.Quote(key))
+	} else {
+		var err error
+		if expr, err = vectorOperation(c.Op, subject, key); err != nil {
+			return "", fmt.Errorf("condition on index(%d), key(%s) can not be exported, err=>%v", c.Index, c.Key, err)
+		}
+	}
+	if guard != "" {
+		expr = "(" + guard + " && " + expr + ")"
+	}
+	return exportNegate(c, expr), nil
+}
+
+// vectorOperation: 操作符转换为VRL表达式，subject为参与比较的内容
+func vectorOperation(op, subject, key string) (string, error) {
+	switch op {
+	case "=":
+		return fmt.Sprintf("%s == %s", subject, strconv.Quote(key)), nil
+	case "!=", "neq":
+		return fmt.Sprintf("%s != %s", subject, strconv.Quote(key)), nil
+	case "contains":
+		return fmt.Sprintf("contains(%s, %s)", subject, strconv.Quote(key)), nil
+	case "ncontains":
+		return fmt.Sprintf("!contains(%s, %s)", subject, strconv.Quote(key)), nil
+	case "regex", "nregex":
+		// VRL的原始字符串不支持转义单引号
+		if strings.Contains(key, "'") {
+			return "", fmt.Errorf("regex with single quote is not supported")
+		}
+		expr := fmt.Sprintf("match(%s, r'%s')", subject, key)
+		if op == "nregex" {
+			expr = "!" + expr
+		}
+		return expr, nil
+	case "gt", "gte", "lt", "lte":
+		value, err := strconv.ParseFloat(strings.TrimSpace(key), 64)
+		if err != nil {
+			return "", fmt.Errorf("key is not a valid number")
+		}
+		number := strconv.FormatFloat(value, 'f', -1, 64)
+		if !strings.Contains(number, ".") {
+			number += ".0"
+		}
+		operators := map[string]string{"gt": ">", "gte": ">=", "lt": "<", "lte": "<="}
+		return fmt.Sprintf("(match(%s, r'%s') && to_float!(strip_whitespace(%s)) %s %s)",
+			subject, exportNumberPattern, subject, operators[op], number), nil
+	}
+	return "", fmt.Errorf("op(%s) is not supported", op)
+}
+
+// logstashExpr: 单个条件(或嵌套条件组)转换为Logstash条件表达式
+func (config *TaskConfig) logstashExpr(c ConditionConfig) (string, error) {
+	if c.IsGroup() {
+		children, sep := c.All, " and "
+		if len(c.Any) > 0 {
+			children, sep = c.Any, " or "
+		}
+		exprs := make([]string, 0, len(children))
+		for _, child := range children {
+			expr, err := config.logstashExpr(child)
+			if err != nil {
+				return "", err
+			}
+			exprs = append(exprs, expr)
+		}
+		return exportNegate(c, wrapExprs(exprs, sep, "[data]")), nil
+	}
+	if err := checkExportCondition(c); err != nil {
+		return "", err
+	}
+
+	key, subject, guard := c.Key, "[data]", ""
+	switch {
+	case config.LogfmtParse && c.Index <= 0 && !c.IsFieldCondition():
+		parts := strings.SplitN(c.Key, ":", 2)
+		key = parts[1]
+		subject = fmt.Sprintf("[@metadata][pairs][%s]", parts[0])
+		guard = subject
+	case c.Index > 0:
+		subject = fmt.Sprintf("[@metadata][words][%d]", c.Index-1)
+		guard = subject
+	}
+
+	op := c.Op
+	if c.Index <= 0 && !exportWholeLine[c.Op] && guard == "" {
+		op = "contains"
+	}
+	expr, err := logstashOperation(op, subject, key, c.IgnoreCase)
+	if err != nil {
+		return "", fmt.Errorf("condition on index(%d), key(%s) can not be exported, err=>%v", c.Index, c.Key, err)
+	}
+	if guard != "" {
+		expr = "(" + guard + " and " + expr + ")"
+	}
+	return exportNegate(c, expr), nil
+}
+
+// logstashOperation: 操作符转换为Logstash条件表达式，subject为参与比较的字段
+// Logstash条件中无法将字符串字段按数值比较，数值比较不支持导出；忽略大小写时转换为(?i)正则匹配
+func logstashOperation(op, subject, key string, ignoreCase bool) (string, error) {
+	if ignoreCase {
+		pattern := strings.ReplaceAll(regexp.QuoteMeta(key), "/", `\/`)
+		switch op {
+		case "=":
+			return fmt.Sprintf(`%s =~ /(?i)\A%s\z/`, subject, pattern), nil
+		case "!=", "neq":
+			return fmt.Sprintf(`%s !~ /(?i)\A%s\z/`, subject, pattern), nil
+		case "contains":
+			return fmt.Sprintf("%s =~ /(?i)%s/", subject, pattern), nil
+		case "ncontains":
+			return fmt.Sprintf("%s !~ /(?i)%s/", subject, pattern), nil
+		}
+		return "", fmt.Errorf("op(%s) with ignore_case is not supported", op)
+	}
+	switch op {
+	case "=":
+		return fmt.Sprintf("%s == %s", subject, strconv.Quote(key)), nil
+	case "!=", "neq":
+		return fmt.Sprintf("%s != %s", subject, strconv.Quote(key)), nil
+	case "contains":
+		return fmt.Sprintf("%s in %s", strconv.Quote(key), subject), nil
+	case "ncontains":
+		return fmt.Sprintf("%s not in %s", strconv.Quote(key), subject), nil
+	case "regex":
+		return fmt.Sprintf("%s =~ /%s/", subject, strings.ReplaceAll(key, "/", `\/`)), nil
+	case "nregex":
+		return fmt.Sprintf("%s !~ /%s/", subject, strings.ReplaceAll(key, "/", `\/`)), nil
+	}
+	return "", fmt.Errorf("op(%s) is not supported", op)
+}
+
+// exportNegate: 配置negate时对表达式取反
+func exportNegate(c ConditionConfig, expr string) string {
+	if !c.Negate {
+		return expr
+	}
+	return "!(" + expr + ")"
+}
+
+// maxIndex: 切分的最大列序号，与任务切分日志时的取值一致，最后一列为剩余的全部内容
+func (config *TaskConfig) maxIndex() int {
+	maxIndex := config.SampleByIndex
+	var walk func(conditions []ConditionConfig)
+	walk = func(conditions []ConditionConfig) {
+		for _, c := range conditions {
+			if c.Index > maxIndex {
+				maxIndex = c.Index
+			}
+			walk(c.Any)
+			walk(c.All)
+		}
+	}
+	filters := append([]FilterConfig{}, config.Filters...)
+	for _, rule := range config.PriorityRules {
+		filters = append(filters, rule.Filter)
+	}
+	for _, f := range filters {
+		walk(f.Conditions)
+		if f.MinColumnCount > maxIndex {
+			maxIndex = f.MinColumnCount
+		}
+	}
+	for _, index := range config.Dedup.Indexes {
+		if index > maxIndex {
+			maxIndex = index
+		}
+	}
+	return maxIndex
+}
+
+// joinExprs: 拼接表达式，空表达式时使用always(恒真表达式)
+func joinExprs(exprs []string, sep, always string) string {
+	if len(exprs) == 0 {
+		return always
+	}
+	return strings.Join(exprs, sep)
+}
+
+// wrapExprs: 拼接表达式，多个表达式时加括号以保证与外层运算的优先级
+func wrapExprs(exprs []string, sep, always string) string {
+	if len(exprs) > 1 {
+		return "(" + strings.Join(exprs, sep) + ")"
+	}
+	return joinExprs(exprs, sep, always)
+}
@@ -0,0 +1,181 @@
+// Tencent is pleased to support the open source community by making bkunifylogbeat 蓝鲸日志采集器 available.
+//
+// Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+//
+// bkunifylogbeat 蓝鲸日志采集器 is licensed under the MIT License.
+//
+// License for bkunifylogbeat 蓝鲸日志采集器:
+// --------------------------------------------------------------------
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+// documentation files (the "Software"), to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software,
+// and to permit persons to whom the Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all copies or substantial
+// portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+// LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN
+// NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+// WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+// SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+//TestExportFilter: 测试过滤条件导出为Vector/Logstash配置
+func TestExportFilter(t *testing.T) {
+	vars := map[string]interface{}{
+		"dataid":    "999990001",
+		"delimiter": "|",
+		"filters": []FilterConfig{
+			{
+				Conditions: []ConditionConfig{
+					{Index: 1, Key: "debug", Op: "!="},
+					{Index: 2, Key: "test", Op: "="},
+				},
+			},
+			{
+				Conditions: []ConditionConfig{
+					{Index: -1, Key: "panic", Op: "="},
+				},
+			},
+		},
+	}
+	taskConfig, err := CreateTaskConfig(vars)
+	if err != nil {
+		panic(err)
+	}
+
+	vector, err := taskConfig.ExportAsVectorConfig()
+	assert.Nil(t, err)
+	assert.Contains(t, string(vector), `words = split(text, "|", limit: 3)`)
+	assert.Contains(t, string(vector), `if !(((length(words) >= 1 && words[0] != "debug") && (length(words) >= 2 && words[1] == "test")) || contains(text, "panic"))`)
+
+	logstash, err := taskConfig.ExportAsLogstashConfig()
+	assert.Nil(t, err)
+	assert.Contains(t, logstash, `split(Regexp.new(Regexp.escape("|")), 3)`)
+	assert.Contains(t, logstash, `if !((([@metadata][words][0] and [@metadata][words][0] != "debug") and ([@metadata][words][1] and [@metadata][words][1] == "test")) or "panic" in [data])`)
+}
+
+//TestExportFilterOperations: 测试contains、regex、数值比较及嵌套条件组的导出
+func TestExportFilterOperations(t *testing.T) {
+	vars := map[string]interface{}{
+		"dataid":    "999990001",
+		"delimiter": "|",
+		"filters": []FilterConfig{
+			{
+				Conditions: []ConditionConfig{
+					{Index: 1, Key: "err", Op: "contains"},
+					{Index: 2, Key: "^GET /", Op: "regex"},
+					{Any: []ConditionConfig{
+						{Index: 3, Key: "500", Op: "gte"},
+						{Index: 3, Key: "404", Op: "="},
+					}},
+				},
+			},
+		},
+	}
+	taskConfig, err := CreateTaskConfig(vars)
+	assert.NoError(t, err)
+
+	vector, err := taskConfig.ExportAsVectorConfig()
+	assert.NoError(t, err)
+	assert.Contains(t, string(vector), `(length(words) >= 1 && contains(words[0], "err"))`)
+	assert.Contains(t, string(vector), `(length(words) >= 2 && match(words[1], r'^GET /'))`)
+	assert.Contains(t, string(vector), `((length(words) >= 3 && (match(words[2], r'`+exportNumberPattern+`') && to_float!(strip_whitespace(words[2])) >= 500.0)) || (length(words) >= 3 && words[2] == "404"))`)
+
+	// Logstash条件中无法按数值比较
+	_, err = taskConfig.ExportAsLogstashConfig()
+	assert.Error(t, err)
+
+	vars["filters"] = []FilterConfig{
+		{
+			Conditions: []ConditionConfig{
+				{Index: 1, Key: "err", Op: "ncontains"},
+				{Index: 2, Key: "^GET /api", Op: "nregex"},
+			},
+		},
+	}
+	taskConfig, err = CreateTaskConfig(vars)
+	assert.NoError(t, err)
+	logstash, err := taskConfig.ExportAsLogstashConfig()
+	assert.NoError(t, err)
+	assert.Contains(t, logstash, `([@metadata][words][0] and "err" not in [@metadata][words][0])`)
+	assert.Contains(t, logstash, `([@metadata][words][1] and [@metadata][words][1] !~ /^GET \/api/)`)
+}
+
+//TestExportFilterUnsupported: 测试无法等价转换的条件导出失败，而不是放宽过滤条件
+func TestExportFilterUnsupported(t *testing.T) {
+	for _, c := range []ConditionConfig{
+		{Index: 1, Key: "10.0.0.0/8", Op: "cidr"},
+		{Index: 1, Key: "a", Op: "=", Tokenize: true},
+		{Index: 1, Key: "10", Op: "=", FieldType: "int"},
+		{Any: []ConditionConfig{{Index: 1, Key: "Mon", Op: "weekday_in"}}},
+	} {
+		taskConfig, err := CreateTaskConfig(map[string]interface{}{
+			"dataid":    "999990001",
+			"delimiter": "|",
+			"filters":   []FilterConfig{{Conditions: []ConditionConfig{c}}},
+		})
+		assert.NoError(t, err)
+		_, err = taskConfig.ExportAsVectorConfig()
+		assert.Error(t, err, c.Op)
+		_, err = taskConfig.ExportAsLogstashConfig()
+		assert.Error(t, err, c.Op)
+	}
+}
+
+//TestExportFilterNegate: 测试negate及ignore_case条件按任务语义导出
+func TestExportFilterNegate(t *testing.T) {
+	taskConfig, err := CreateTaskConfig(map[string]interface{}{
+		"dataid":    "999990001",
+		"delimiter": "|",
+		"filters": []FilterConfig{
+			{
+				Conditions: []ConditionConfig{
+					{Index: 1, Key: "health", Op: "=", Negate: true},
+					{Index: 2, Key: "Error", Op: "contains", IgnoreCase: true},
+					{Any: []ConditionConfig{
+						{Index: 3, Key: "GET", Op: "="},
+						{Index: 3, Key: "POST", Op: "="},
+					}, Negate: true},
+				},
+			},
+		},
+	})
+	assert.NoError(t, err)
+
+	vector, err := taskConfig.ExportAsVectorConfig()
+	assert.NoError(t, err)
+	assert.Contains(t, string(vector), `!((length(words) >= 1 && words[0] == "health"))`)
+	assert.Contains(t, string(vector), `(length(words) >= 2 && contains(downcase(words[1]), "error"))`)
+	assert.Contains(t, string(vector), `!(((length(words) >= 3 && words[2] == "GET") || (length(words) >= 3 && words[2] == "POST")))`)
+
+	logstash, err := taskConfig.ExportAsLogstashConfig()
+	assert.NoError(t, err)
+	assert.Contains(t, logstash, `!(([@metadata][words][0] and [@metadata][words][0] == "health"))`)
+	assert.Contains(t, logstash, `([@metadata][words][1] and [@metadata][words][1] =~ /(?i)Error/)`)
+	assert.Contains(t, logstash, `!((([@metadata][words][2] and [@metadata][words][2] == "GET") or ([@metadata][words][2] and [@metadata][words][2] == "POST")))`)
+
+	// 未指定index时对整行忽略大小写匹配
+	taskConfig, err = CreateTaskConfig(map[string]interface{}{
+		"dataid":    "999990001",
+		"delimiter": "|",
+		"filters": []FilterConfig{
+			{Conditions: []ConditionConfig{{Key: "a.b", Op: "=", IgnoreCase: true, Negate: true}}},
+		},
+	})
+	assert.NoError(t, err)
+	vector, err = taskConfig.ExportAsVectorConfig()
+	assert.NoError(t, err)
+	assert.Contains(t, string(vector), `!(contains(downcase(text), "a.b"))`)
+	logstash, err = taskConfig.ExportAsLogstashConfig()
+	assert.NoError(t, err)
+	assert.Contains(t, logstash, `!([data] =~ /(?i)a\.b/)`)
+}
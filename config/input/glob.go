@@ -0,0 +1,194 @@
+// Tencent is pleased to support the open source community by making bkunifylogbeat 蓝鲸日志采集器 available.
+//
+// Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+//
+// bkunifylogbeat 蓝鲸日志采集器 is licensed under the MIT License.
+//
+// License for bkunifylogbeat 蓝鲸日志采集器:
+// --------------------------------------------------------------------
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+// documentation files (the "Software"), to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software,
+// and to permit persons to whom the Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all copies or substantial
+// portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+// LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN
+// NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+// WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+// SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package input
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// defaultRecursiveGlobDepth: **最多展开的目录层数，与filebeat的recursive_glob一致
+const defaultRecursiveGlobDepth = 8
+
+// hasRecursiveGlob 路径中是否包含**
+func hasRecursiveGlob(paths []string) bool {
+	for _, path := range paths {
+		if strings.Contains(path, "**") {
+			return true
+		}
+	}
+	return false
+}
+
+// expandRecursiveGlob 将路径中的**展开为0到maxDepth层的*，每个路径只允许一个**
+func expandRecursiveGlob(path string, maxDepth int) ([]string, error) {
+	count := strings.Count(path, "**")
+	if count == 0 {
+		return []string{path}, nil
+	}
+	if count > 1 {
+		return nil, fmt.Errorf("path(%s) contains more than one **", path)
+	}
+	index := strings.Index(path, "**")
+	prefix, suffix := path[:index], path[index+2:]
+	if (prefix != "" && !isPathSeparator(prefix[len(prefix)-1])) || (suffix != "" && !isPathSeparator(suffix[0])) {
+		return nil, fmt.Errorf("path(%s) must use ** as a whole directory level", path)
+	}
+	// /a/**/b展开为/a/b、/a/*/b、/a/*/*/b...
+	separator := "/"
+	if suffix != "" {
+		separator, suffix = suffix[:1], suffix[1:]
+	}
+	paths := make([]string, 0, maxDepth+1)
+	for depth := 0; depth <= maxDepth; depth++ {
+		parts := make([]string, 0, depth+1)
+		for i := 0; i < depth; i++ {
+			parts = append(parts, "*")
+		}
+		if suffix != "" {
+			parts = append(parts, suffix)
+		}
+		if len(parts) == 0 {
+			continue
+		}
+		paths = append(paths, prefix+strings.Join(parts, separator))
+	}
+	return paths, nil
+}
+
+func isPathSeparator(c byte) bool {
+	return c == '/' || c == filepath.Separator
+}
+
+// excludeDirPattern 将目录的glob模式转换为exclude_files使用的正则：
+// 包含/时按目录路径前缀匹配，否则匹配路径中任意一级目录名
+func excludeDirPattern(pattern string) (string, error) {
+	pattern = strings.TrimSuffix(pattern, "/")
+	if pattern == "" {
+		return "", fmt.Errorf("exclude_dirs pattern is empty")
+	}
+	if _, err := filepath.Match(pattern, ""); err != nil {
+		return "", fmt.Errorf("exclude_dirs pattern(%s) is invalid, err=>%v", pattern, err)
+	}
+	var builder strings.Builder
+	if strings.Contains(pattern, "/") {
+		builder.WriteString("^")
+	} else {
+		builder.WriteString("(^|/)")
+	}
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; c {
+		case '*':
+			builder.WriteString("[^/]*")
+		case '?':
+			builder.WriteString("[^/]")
+		case '[':
+			end := strings.IndexByte(pattern[i:], ']')
+			class := pattern[i+1 : i+end]
+			if strings.HasPrefix(class, "^") || strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			builder.WriteString("[" + class + "]")
+			i += end
+		case '\\':
+			if i+1 < len(pattern) {
+				i++
+			}
+			builder.WriteString(regexp.QuoteMeta(pattern[i : i+1]))
+		default:
+			builder.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	builder.WriteString("/")
+	return builder.String(), nil
+}
+
+// maxSymlinkScanDirs: 检测符号链接环路时最多扫描的目录数，避免配置解析耗时过长
+const maxSymlinkScanDirs = 10000
+
+// globStaticPrefix 返回路径中第一个通配符之前的目录
+func globStaticPrefix(path string) string {
+	index := strings.IndexAny(path, "*?[")
+	if index < 0 {
+		return filepath.Dir(path)
+	}
+	return filepath.Dir(path[:index+1])
+}
+
+// findSymlinkLoops 在root下maxDepth层内查找指向自身或上级目录的目录符号链接
+func findSymlinkLoops(root string, maxDepth int) []string {
+	realRoot, err := filepath.EvalSymlinks(root)
+	if err != nil {
+		return nil
+	}
+	var loops []string
+	scanned := 0
+	var walk func(dir, realDir string, depth int)
+	walk = func(dir, realDir string, depth int) {
+		if depth >= maxDepth || scanned >= maxSymlinkScanDirs {
+			return
+		}
+		scanned++
+		entries, err := ioutil.ReadDir(dir)
+		if err != nil {
+			return
+		}
+		for _, entry := range entries {
+			path := filepath.Join(dir, entry.Name())
+			realPath := filepath.Join(realDir, entry.Name())
+			if entry.Mode()&os.ModeSymlink != 0 {
+				target, err := filepath.EvalSymlinks(path)
+				if err != nil {
+					continue
+				}
+				info, err := os.Stat(target)
+				if err != nil || !info.IsDir() {
+					continue
+				}
+				if isSubPath(target, realDir) {
+					loops = append(loops, path)
+					continue
+				}
+				realPath = target
+			} else if !entry.IsDir() {
+				continue
+			}
+			walk(path, realPath, depth+1)
+		}
+	}
+	walk(root, realRoot, 0)
+	return loops
+}
+
+// isSubPath parent是否为path本身或其上级目录
+func isSubPath(parent, path string) bool {
+	rel, err := filepath.Rel(parent, path)
+	if err != nil {
+		return false
+	}
+	return rel == "." || (rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)))
+}
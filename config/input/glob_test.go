@@ -0,0 +1,101 @@
+// Tencent is pleased to support the open source community by making bkunifylogbeat 蓝鲸日志采集器 available.
+//
+// Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+//
+// bkunifylogbeat 蓝鲸日志采集器 is licensed under the MIT License.
+//
+// License for bkunifylogbeat 蓝鲸日志采集器:
+// --------------------------------------------------------------------
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+// documentation files (the "Software"), to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software,
+// and to permit persons to whom the Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all copies or substantial
+// portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+// LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN
+// NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+// WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+// SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package input
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// 测试**按层数展开
+func TestExpandRecursiveGlob(t *testing.T) {
+	paths, err := expandRecursiveGlob("/data/apps/**/logs/*.log", 2)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"/data/apps/logs/*.log", "/data/apps/*/logs/*.log", "/data/apps/*/*/logs/*.log"}, paths)
+
+	paths, err = expandRecursiveGlob("/data/**", 2)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"/data/*", "/data/*/*"}, paths)
+
+	paths, err = expandRecursiveGlob("/data/*.log", 2)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"/data/*.log"}, paths)
+
+	_, err = expandRecursiveGlob("/data/**/a/**/*.log", 2)
+	assert.Error(t, err)
+	_, err = expandRecursiveGlob("/data/a**/*.log", 2)
+	assert.Error(t, err)
+}
+
+// 测试exclude_dirs转换为正则
+func TestExcludeDirPattern(t *testing.T) {
+	pattern, err := excludeDirPattern("tmp*")
+	assert.NoError(t, err)
+	re := regexp.MustCompile(pattern)
+	assert.True(t, re.MatchString("/data/apps/web/tmp/a.log"))
+	assert.True(t, re.MatchString("/data/apps/web/tmp1/logs/a.log"))
+	assert.False(t, re.MatchString("/data/apps/web/logs/tmp.log"))
+
+	pattern, err = excludeDirPattern("/data/apps/*/archive/")
+	assert.NoError(t, err)
+	re = regexp.MustCompile(pattern)
+	assert.True(t, re.MatchString("/data/apps/web/archive/2021/a.log"))
+	assert.False(t, re.MatchString("/data/apps/web/logs/archive/a.log"))
+
+	pattern, err = excludeDirPattern("[!a]ld")
+	assert.NoError(t, err)
+	re = regexp.MustCompile(pattern)
+	assert.True(t, re.MatchString("/data/old/a.log"))
+	assert.False(t, re.MatchString("/data/ald/a.log"))
+
+	_, err = excludeDirPattern("[abc")
+	assert.Error(t, err)
+}
+
+// 测试目录符号链接环路检测
+func TestFindSymlinkLoops(t *testing.T) {
+	dir, err := ioutil.TempDir("", "symlinks")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	assert.NoError(t, os.MkdirAll(filepath.Join(dir, "apps", "web", "logs"), 0755))
+	assert.NoError(t, os.MkdirAll(filepath.Join(dir, "releases", "v2", "logs"), 0755))
+	// current指向其他目录，不是环路
+	assert.NoError(t, os.Symlink(filepath.Join(dir, "releases", "v2"), filepath.Join(dir, "apps", "current")))
+	// 指向上级目录形成环路
+	assert.NoError(t, os.Symlink(filepath.Join(dir, "apps"), filepath.Join(dir, "apps", "web", "parent")))
+	assert.NoError(t, os.Symlink(".", filepath.Join(dir, "releases", "v2", "self")))
+
+	loops := findSymlinkLoops(filepath.Join(dir, "apps"), 8)
+	assert.Equal(t, []string{
+		filepath.Join(dir, "apps", "current", "self"),
+		filepath.Join(dir, "apps", "web", "parent"),
+	}, loops)
+
+	assert.Equal(t, "/data/apps", globStaticPrefix("/data/apps/**/logs/*.log"))
+	assert.Equal(t, "/data", globStaticPrefix("/data/app*/**/*.log"))
+}
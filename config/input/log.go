@@ -24,10 +24,13 @@ package input
 
 import (
 	"fmt"
+	"regexp"
+	"strings"
 	"time"
 
 	cfg "github.com/TencentBlueKing/bkunifylogbeat/config"
 	"github.com/TencentBlueKing/collector-go-sdk/v2/bkbeat/beat"
+	"github.com/TencentBlueKing/collector-go-sdk/v2/bkbeat/logp"
 	"github.com/dustin/go-humanize"
 )
 
@@ -39,82 +42,142 @@ type LogConfig struct {
 	IgnoreOlder   time.Duration `config:"ignore_older"`
 
 	CleanInactive time.Duration `config:"clean_inactive" validate:"min=0"`
+
+	// 是否跟随符号链接，默认跟随
+	Symlinks     bool     `config:"symlinks"`
+	Paths        []string `config:"paths"`
+	ExcludeFiles []string `config:"exclude_files"`
+	// 排除的目录，glob模式，包含/时按目录路径匹配，否则匹配任意一级目录名
+	ExcludeDirs []string `config:"exclude_dirs"`
+	// **的展开由采集器处理，以支持配置展开层数
+	RecursiveGlob struct {
+		Enabled  bool `config:"enabled"`
+		MaxDepth int  `config:"max_depth" validate:"min=0"`
+	} `config:"recursive_glob"`
+}
+
+var logDefaultConfig = beat.MapStr{
+	"enabled":         true,
+	"scan_frequency":  10 * time.Second,
+	"harvester_limit": 1000,
+	"exclude_files":   []string{".gz$", ".bz2$", ".tgz$", ".tbz$", ".zip$", ".7z$", ".bak$", ".backup", ".swp$"},
+
+	// close
+	"close_inactive": 2 * time.Minute,
+
+	// clean: 如果文件删除，则清除registry文件
+	"clean_removed": true,
+
+	// 监听文件变更时间
+	"ignore_older": 168 * time.Hour,
+
+	// harvester
+	"tail_files": true,
+	"encoding":   "utf-8",
+	"symlinks":   true,
+	"max_bytes":  200 * humanize.KByte,
 }
 
 func init() {
-	config := beat.MapStr{
-		"enabled":         true,
-		"scan_frequency":  10 * time.Second,
-		"harvester_limit": 1000,
-		"exclude_files":   []string{".gz$", ".bz2$", ".tgz$", ".tbz$", ".zip$", ".7z$", ".bak$", ".backup", ".swp$"},
-
-		// close
-		"close_inactive": 2 * time.Minute,
-
-		// clean: 如果文件删除，则清除registry文件
-		"clean_removed": true,
-
-		// 监听文件变更时间
-		"ignore_older": 168 * time.Hour,
-
-		// harvester
-		"tail_files": true,
-		"encoding":   "utf-8",
-		"symlinks":   true,
-		"max_bytes":  200 * humanize.KByte,
+	err := cfg.Register("log", initLogConfig)
+	if err != nil {
+		panic(err)
 	}
-	err := cfg.Register("log", func(rawConfig *beat.Config) (*beat.Config, error) {
-		var err error
-		defaultConfig := beat.MapStr{}
-
-		fields := rawConfig.GetFields()
-		for key, value := range config {
-			isExists := false
-			for _, field := range fields {
-				if key == field {
-					isExists = true
-					break
-				}
-			}
-			if !isExists {
-				defaultConfig[string(key)] = value
-			}
-		}
+}
 
-		// 特殊配置处理
-		logConfig := &LogConfig{
-			CloseInactive: 5 * time.Minute,
-			IgnoreOlder:   168 * time.Hour,
+// initLogConfig: 补充文件采集的默认配置，并修正不合理的配置
+func initLogConfig(rawConfig *beat.Config) (*beat.Config, error) {
+	var err error
+	defaultConfig := beat.MapStr{}
+
+	fields := rawConfig.GetFields()
+	for key, value := range logDefaultConfig {
+		isExists := false
+		for _, field := range fields {
+			if key == field {
+				isExists = true
+				break
+			}
 		}
-		err = rawConfig.Unpack(&logConfig)
-		if err != nil {
-			return nil, fmt.Errorf("error parsing raw config => %v", err)
+		if !isExists {
+			defaultConfig[string(key)] = value
 		}
+	}
 
-		// FD释放（close_inactive）配置不能超过5分钟
-		if logConfig.CloseInactive > 5*time.Minute {
-			defaultConfig["close_inactive"] = 5 * time.Minute
-		}
+	// 特殊配置处理
+	logConfig := &LogConfig{
+		CloseInactive: 5 * time.Minute,
+		IgnoreOlder:   168 * time.Hour,
+	}
+	logConfig.Symlinks = true
+	logConfig.RecursiveGlob.Enabled = true
+	logConfig.RecursiveGlob.MaxDepth = defaultRecursiveGlobDepth
+	err = rawConfig.Unpack(&logConfig)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing raw config => %v", err)
+	}
 
-		if logConfig.CleanInactive > 0 {
-			// 2. 如果配置了CleanInactive，那么必须大于 IgnoreOlder + ScanFrequency
-			if logConfig.CleanInactive < logConfig.IgnoreOlder + logConfig.ScanFrequency {
-				defaultConfig["clean_inactive"] = logConfig.IgnoreOlder + logConfig.ScanFrequency + 1*time.Hour
+	// 路径中的**按max_depth展开，并关闭filebeat自身的展开；跟随符号链接时排除形成环路的目录链接
+	var excludes []string
+	if logConfig.RecursiveGlob.Enabled && hasRecursiveGlob(logConfig.Paths) {
+		paths := make([]string, 0, len(logConfig.Paths))
+		for _, path := range logConfig.Paths {
+			expanded, err := expandRecursiveGlob(path, logConfig.RecursiveGlob.MaxDepth)
+			if err != nil {
+				return nil, err
+			}
+			paths = append(paths, expanded...)
+			if logConfig.Symlinks && len(expanded) > 1 {
+				root := globStaticPrefix(path)
+				depth := logConfig.RecursiveGlob.MaxDepth + strings.Count(path[len(root):strings.Index(path, "**")], "/")
+				for _, loop := range findSymlinkLoops(root, depth) {
+					logp.L.Warnf("symlink(%s) points to its parent directory, excluded from path(%s)", loop, path)
+					excludes = append(excludes, "^"+regexp.QuoteMeta(loop)+"/")
+				}
 			}
-		} else {
-			// 如果没有配置CleanInactive，那么给一个默认值，半年
-			// 对于长时间未写的文件，采集进度保留半年，半年后如果再次写入会出现将整个文件重新读取现象。
-			// 可适当调大，但是更建议对业务日志本身做处理，增加轮转机制，而不是一直写同一个日志文件
-			defaultConfig["clean_inactive"] = logConfig.IgnoreOlder + logConfig.ScanFrequency + 180 * 24 * time.Hour
 		}
+		defaultConfig["paths"] = paths
+		defaultConfig["recursive_glob"] = beat.MapStr{"enabled": false}
+	}
 
-		err = rawConfig.Merge(defaultConfig)
+	// exclude_dirs转换为exclude_files的正则
+	for _, dir := range logConfig.ExcludeDirs {
+		pattern, err := excludeDirPattern(dir)
 		if err != nil {
 			return nil, err
 		}
-		return rawConfig, nil
-	})
+		excludes = append(excludes, pattern)
+	}
+
+	// 追加在exclude_files之后，未配置exclude_files时保留默认的排除规则
+	if len(excludes) > 0 {
+		excludeFiles := logConfig.ExcludeFiles
+		if _, ok := defaultConfig["exclude_files"]; ok {
+			excludeFiles = logDefaultConfig["exclude_files"].([]string)
+		}
+		defaultConfig["exclude_files"] = append(append([]string{}, excludeFiles...), excludes...)
+	}
+
+	// FD释放（close_inactive）配置不能超过5分钟
+	if logConfig.CloseInactive > 5*time.Minute {
+		defaultConfig["close_inactive"] = 5 * time.Minute
+	}
+
+	if logConfig.CleanInactive > 0 {
+		// 2. 如果配置了CleanInactive，那么必须大于 IgnoreOlder + ScanFrequency
+		if logConfig.CleanInactive < logConfig.IgnoreOlder + logConfig.ScanFrequency {
+			defaultConfig["clean_inactive"] = logConfig.IgnoreOlder + logConfig.ScanFrequency + 1*time.Hour
+		}
+	} else {
+		// 如果没有配置CleanInactive，那么给一个默认值，半年
+		// 对于长时间未写的文件，采集进度保留半年，半年后如果再次写入会出现将整个文件重新读取现象。
+		// 可适当调大，但是更建议对业务日志本身做处理，增加轮转机制，而不是一直写同一个日志文件
+		defaultConfig["clean_inactive"] = logConfig.IgnoreOlder + logConfig.ScanFrequency + 180 * 24 * time.Hour
+	}
+
+	err = rawConfig.Merge(defaultConfig)
 	if err != nil {
-		panic(err)
+		return nil, err
 	}
+	return rawConfig, nil
 }
@@ -141,3 +141,33 @@ func TestNewTaskConfig(t *testing.T) {
 	assert.Equal(t, "744h0m0s", taskConfig["ignore_older"].(string))
 	assert.Equal(t, "745h0m0s", taskConfig["clean_inactive"].(string))
 }
+
+// 测试**展开及exclude_dirs合并到exclude_files
+func TestRecursiveGlobConfig(t *testing.T) {
+	config, err := mockTaskConfig(map[string]interface{}{
+		"dataid":         "999990001",
+		"paths":          []string{"/data/apps/**/logs/*.log", "/var/log/messages"},
+		"recursive_glob": map[string]interface{}{"max_depth": 1},
+		"exclude_dirs":   []string{"tmp"},
+	})
+	assert.NoError(t, err)
+
+	settings := struct {
+		Paths         []string `config:"paths"`
+		ExcludeFiles  []string `config:"exclude_files"`
+		RecursiveGlob struct {
+			Enabled bool `config:"enabled"`
+		} `config:"recursive_glob"`
+	}{}
+	assert.NoError(t, config.RawConfig.Unpack(&settings))
+	assert.Equal(t, []string{"/data/apps/logs/*.log", "/data/apps/*/logs/*.log", "/var/log/messages"}, settings.Paths)
+	assert.False(t, settings.RecursiveGlob.Enabled)
+	assert.Contains(t, settings.ExcludeFiles, ".gz$")
+	assert.Equal(t, "(^|/)tmp/", settings.ExcludeFiles[len(settings.ExcludeFiles)-1])
+
+	_, err = mockTaskConfig(map[string]interface{}{
+		"dataid": "999990001",
+		"paths":  []string{"/data/**/a/**/*.log"},
+	})
+	assert.Error(t, err)
+}
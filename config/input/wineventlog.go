@@ -36,17 +36,20 @@ type WinEventLogConfig struct {
 }
 
 func init() {
-	err := cfg.Register("winlog", func(rawConfig *beat.Config) (*beat.Config, error) {
-		defaultConfig := beat.MapStr{}
-		defaultConfig["scan_frequency"] = 1 * time.Hour
-		err := rawConfig.Merge(defaultConfig)
+	// winlog为原有类型名，wineventlog为兼容winlogbeat的别名
+	for _, name := range []string{"winlog", "wineventlog"} {
+		err := cfg.Register(name, func(rawConfig *beat.Config) (*beat.Config, error) {
+			defaultConfig := beat.MapStr{}
+			defaultConfig["scan_frequency"] = 1 * time.Hour
+			err := rawConfig.Merge(defaultConfig)
+			if err != nil {
+				return nil, err
+			}
+
+			return rawConfig, nil
+		})
 		if err != nil {
-			return nil, err
+			panic(err)
 		}
-
-		return rawConfig, nil
-	})
-	if err != nil {
-		panic(err)
 	}
 }
@@ -1 +1,50 @@
+// Tencent is pleased to support the open source community by making bkunifylogbeat 蓝鲸日志采集器 available.
+//
+// Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+//
+// bkunifylogbeat 蓝鲸日志采集器 is licensed under the MIT License.
+//
+// License for bkunifylogbeat 蓝鲸日志采集器:
+// --------------------------------------------------------------------
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+// documentation files (the "Software"), to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software,
+// and to permit persons to whom the Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all copies or substantial
+// portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+// LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN
+// NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+// WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+// SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
 package input
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// 测试windows事件日志采集配置，wineventlog与winlog使用相同的默认配置
+func TestWinEventLogConfig(t *testing.T) {
+	for _, inputType := range []string{"winlog", "wineventlog"} {
+		config, err := mockTaskConfig(map[string]interface{}{
+			"dataid": "999990001",
+			"type":   inputType,
+			"event_logs": []map[string]interface{}{
+				{"name": "Security", "event_id": "4624,4625"},
+			},
+		})
+		assert.NoError(t, err)
+
+		settings := struct {
+			ScanFrequency time.Duration `config:"scan_frequency"`
+		}{}
+		assert.NoError(t, config.RawConfig.Unpack(&settings))
+		assert.Equal(t, time.Hour, settings.ScanFrequency)
+	}
+}
@@ -24,27 +24,386 @@ package config
 
 import (
 	"fmt"
+	"net"
 	"path/filepath"
+	"regexp"
 	"sort"
 	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
 
 	"github.com/TencentBlueKing/bkunifylogbeat/utils"
 	"github.com/TencentBlueKing/collector-go-sdk/v2/bkbeat/beat"
 	"github.com/TencentBlueKing/collector-go-sdk/v2/bkbeat/logp"
 	"github.com/elastic/beats/libbeat/common"
+	"github.com/elastic/beats/libbeat/common/fmtstr"
+	"github.com/elastic/beats/libbeat/common/transport/tlscommon"
 	"github.com/elastic/beats/libbeat/processors"
+	"golang.org/x/text/encoding/htmlindex"
+	"golang.org/x/text/language"
 )
 
-// ConditionConfig: 用于条件表达式，目前支持=、!=
+// ConditionConfig: 用于条件表达式，目前支持=、!=、neq、contains、ncontains、gt、gte、lt、lte、regex、nregex、cidr、window_agg_gt、scanf、bloom_file、s3_allow、s3_deny、weekday_in、exists、not_exists
+// regex/nregex: key为正则表达式，index小于等于0时对整行匹配
+// cidr: key为逗号分隔的CIDR列表，列内容为IP且在任意网段内时视为匹配
+// exists/not_exists: key为事件字段名(支持a.b形式的嵌套字段)，判断字段是否存在，不解析data
+// bloom_file: key为布隆过滤器文件路径(格式见utils.BloomFilter)，列内容可能在集合中时视为匹配
 type ConditionConfig struct {
 	Index int    `config:"index"`
 	Key   string `config:"key"`
 	Op    string `config:"op"`
+
+	// 嵌套条件组：Any中任意一个子条件满足，或All中全部子条件满足，配置后不需要index、key及op
+	// 如(col1 == "ERROR" OR col1 == "FATAL") AND col5 == "payment"
+	Any []ConditionConfig `config:"any"`
+	All []ConditionConfig `config:"all"`
+
+	// json_parse开启时按JSON路径取值，如$.level、$.kubernetes.namespace、$.items.0，配置后忽略index
+	Path string `config:"path"`
+
+	// 比较前将列内容及key转为小写，用于=、!=、neq、contains、ncontains
+	IgnoreCase bool `config:"ignore_case"`
+
+	// 对条件(包括嵌套条件组)的结果取反，用于丢弃命中某规则的日志，如健康检查日志
+	Negate bool `config:"negate"`
+
+	// 定长字段规整：先左补齐到PadTo个字符，再截断到TruncateTo个字符，为0时不处理
+	PadTo      int    `config:"pad_to"`
+	PadChar    string `config:"pad_char"`
+	TruncateTo int    `config:"truncate_to"`
+
+	// 滑动窗口聚合，用于window_agg_gt
+	WindowAgg WindowAggConfig `config:"window_agg"`
+
+	// 按空白字符将列内容切分为多个词，任意一个词满足条件即视为匹配
+	Tokenize bool `config:"tokenize"`
+
+	// scanf: key为仅包含%s占位符的格式，提取的第一个占位符内容需等于ScanfExpect
+	ScanfExpect string `config:"scanf_expect"`
+
+	// 字段类型：string(默认)、int、float、bool，=和!=按类型比较
+	FieldType string `config:"field_type"`
+	// int类型的进制：0(默认，按0x、0前缀自动识别)、8、10、16，key按同样的进制解析
+	NumericBase int `config:"numeric_base"`
+
+	// 列序号超出切分结果时跳过该条件(视为未求值)而不是判定条件组失败，用于可选列；
+	// 注意条件组内全部条件都开启soft_fail时，列数不足的日志总会通过该条件组
+	SoftFail bool `config:"soft_fail"`
+
+	// 按语言规则比较字符串(如de、sv)，忽略大小写及重音，用于=、!=及index小于等于0的包含匹配
+	Locale string `config:"locale"`
+
+	// =、!=比较前将key及列内容规整为标准IP形式，使::ffff:192.0.2.1与192.0.2.1视为同一地址
+	IPNormalize bool `config:"ip_normalize"`
+
+	// weekday_in: key为逗号分隔的星期列表(如Mon,Tue,Wed)，列内容按TimeFormat解析为时间，
+	// 在Timezone时区下的星期在列表中时视为匹配；TimeFormat默认为RFC3339，Timezone默认为本地时区
+	TimeFormat string `config:"time_format"`
+	Timezone   string `config:"timezone"`
+
+	// s3_allow/s3_deny: 列内容在(不在)S3名单中时视为匹配
+	S3List S3ListConfig `config:"s3_list"`
+}
+
+// S3ListConfig: S3上按行存放的名单文件，每隔RefreshInterval重新下载
+type S3ListConfig struct {
+	Bucket          string        `config:"bucket"`
+	Key             string        `config:"key"`
+	Region          string        `config:"region"`
+	RefreshInterval time.Duration `config:"refresh_interval"`
+}
+
+// IsGroup 是否为嵌套条件组
+func (c ConditionConfig) IsGroup() bool {
+	return len(c.Any) > 0 || len(c.All) > 0
+}
+
+// IsFieldCondition 是否为按事件字段判断的条件，不依赖data的解析结果
+func (c ConditionConfig) IsFieldCondition() bool {
+	return c.Op == "exists" || c.Op == "not_exists"
+}
+
+// WindowAggConfig: 滑动窗口聚合配置，对最近WindowSize个事件的数值做min、max、sum、avg聚合
+type WindowAggConfig struct {
+	AggFunc    string `config:"agg_func"`
+	WindowSize int    `config:"window_size"`
+}
+
+// filterOperations: 过滤条件支持的操作符
+var filterOperations = map[string]bool{
+	"=":             true,
+	"!=":            true,
+	"neq":           true,
+	"contains":      true,
+	"ncontains":     true,
+	"window_agg_gt": true,
+	"scanf":         true,
+	"bloom_file":    true,
+	"s3_allow":      true,
+	"s3_deny":       true,
+	"weekday_in":    true,
+	"regex":         true,
+	"nregex":        true,
+	"gt":            true,
+	"gte":           true,
+	"lt":            true,
+	"lte":           true,
+	"cidr":          true,
+	"exists":        true,
+	"not_exists":    true,
+}
+
+// numericOperations: 按数值比较的操作符，field_type为int时按numeric_base解析，否则按浮点数解析
+var numericOperations = map[string]bool{
+	"gt":  true,
+	"gte": true,
+	"lt":  true,
+	"lte": true,
+}
+
+// PriorityRule: 事件优先级规则
+type PriorityRule struct {
+	Priority int          `config:"priority"`
+	Filter   FilterConfig `config:"filter"`
+}
+
+// ConditionalProcessor: 条件处理器，事件满足If且来源文件满足Path时执行Then，否则执行Else。
+// If与filters使用相同的条件及表达式，为空时视为满足；Path为来源文件的通配符，*可以匹配/，如*/access.log
+type ConditionalProcessor struct {
+	Path string                  `config:"path"`
+	If   FilterConfig            `config:"if"`
+	Then processors.PluginConfig `config:"then"`
+	Else processors.PluginConfig `config:"else"`
+}
+
+// PathPattern 将Path通配符转换为正则，Path为空时返回nil
+func (c ConditionalProcessor) PathPattern() (*regexp.Regexp, error) {
+	if c.Path == "" {
+		return nil, nil
+	}
+	var b strings.Builder
+	b.WriteString("^")
+	for _, r := range c.Path {
+		switch r {
+		case '*':
+			b.WriteString(".*")
+		case '?':
+			b.WriteString(".")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	b.WriteString("$")
+	return regexp.Compile(b.String())
+}
+
+// LogMetricsConfig: 从日志中提取指标，按Interval周期以DataID发送指标事件，Metrics为空时不启用
+type LogMetricsConfig struct {
+	DataID   int           `config:"dataid"`
+	Interval time.Duration `config:"interval"`
+	// 是否丢弃原始日志，只发送指标
+	DropRaw bool `config:"drop_raw"`
+	// 单个指标的维度组合上限，超出的维度组合不再统计
+	MaxSeries int               `config:"max_series"`
+	Metrics   []LogMetricConfig `config:"metrics"`
+}
+
+// LogMetricConfig: 单个指标，Type为counter时统计匹配Pattern的行数；为histogram时统计value捕获组
+// (没有名为value的捕获组时使用第一个捕获组)的数值分布，Buckets为升序的桶上限。
+// 除value外的命名捕获组作为维度，如(?P<status>\d{3})
+type LogMetricConfig struct {
+	Name    string    `config:"name"`
+	Type    string    `config:"type"`
+	Pattern string    `config:"pattern"`
+	Buckets []float64 `config:"buckets"`
+}
+
+// MMRingBufferConfig: 内存映射环形缓冲区配置，FilePath为空时不启用
+type MMRingBufferConfig struct {
+	FilePath   string `config:"file_path"`
+	BufferSize int64  `config:"buffer_size"`
+}
+
+// GRPCOutputConfig: 将通过过滤的事件推送到远端日志汇聚服务(见task/logpb/logevent.proto)，Target为空时不启用
+type GRPCOutputConfig struct {
+	Target     string            `config:"target"` // host:port
+	TLS        *tlscommon.Config `config:"ssl"`
+	BufferSize int               `config:"buffer_size"` // 断线重连期间本地缓存的事件数
+}
+
+// KafkaOutputConfig: 打包后的事件直接写入Kafka，不再经过采集器的默认发送链路，Hosts为空时不启用。
+// KeyField为分区key所在的字段，先从打包事件中查找，再从第一条日志中查找，为空时随机分区；
+// RequiredAcks为-1(全部副本)、0或1；配置Username时开启SASL PLAIN认证
+type KafkaOutputConfig struct {
+	Hosts         []string          `config:"hosts"`
+	Topic         string            `config:"topic"`
+	KeyField      string            `config:"key_field"`
+	RequiredAcks  int               `config:"required_acks"`
+	Compression   string            `config:"compression"`
+	Version       string            `config:"version"`
+	ClientID      string            `config:"client_id"`
+	Username      string            `config:"username"`
+	Password      string            `config:"password"`
+	SASLMechanism string            `config:"sasl.mechanism"`
+	TLS           *tlscommon.Config `config:"ssl"`
+	Timeout       time.Duration     `config:"timeout"`
+	// 已发送未确认的最大事件数，达到时阻塞发送
+	BufferSize int `config:"buffer_size"`
+}
+
+// Enabled 是否配置了Kafka输出
+func (c KafkaOutputConfig) Enabled() bool {
+	return len(c.Hosts) > 0
+}
+
+// PulsarOutputConfig: 打包后的事件直接写入Pulsar，不再经过采集器的默认发送链路，URL为空时不启用。
+// URL形如pulsar://host:6650或pulsar+ssl://host:6651，配置Token时使用token认证；
+// 打包事件累积到BatchingMaxMessages条或等待BatchingMaxPublishDelay后合并为一条批量消息发送，分区topic按批次轮询分区
+type PulsarOutputConfig struct {
+	URL                     string            `config:"url"`
+	Topic                   string            `config:"topic"`
+	Token                   string            `config:"token"`
+	TLS                     *tlscommon.Config `config:"ssl"`
+	BatchingMaxMessages     int               `config:"batching_max_messages"`
+	BatchingMaxPublishDelay time.Duration     `config:"batching_max_publish_delay"`
+	Timeout                 time.Duration     `config:"timeout"`
+	// 已发送未确认的最大事件数，达到时阻塞发送
+	BufferSize int `config:"buffer_size"`
+}
+
+// Enabled 是否配置了Pulsar输出
+func (c PulsarOutputConfig) Enabled() bool {
+	return c.URL != ""
+}
+
+// ElasticsearchOutputConfig: 独立部署时打包事件直接通过bulk接口写入Elasticsearch，Hosts为空时不启用。
+// Index支持%{[field]}引用打包事件及日志字段、%{+yyyy.MM.dd}引用日期(UTC)，与kafka_output不能同时配置
+type ElasticsearchOutputConfig struct {
+	Hosts         []string          `config:"hosts"`
+	Index         string            `config:"index"`
+	Username      string            `config:"username"`
+	Password      string            `config:"password"`
+	APIKey        string            `config:"api_key"`
+	TLS           *tlscommon.Config `config:"ssl"`
+	BulkMaxSize   int               `config:"bulk_max_size"`
+	FlushInterval time.Duration     `config:"flush_interval"`
+	Timeout       time.Duration     `config:"timeout"`
+	// 429及5xx时的最大退避间隔
+	MaxBackoff time.Duration `config:"max_backoff"`
+}
+
+// Enabled 是否配置了Elasticsearch输出
+func (c ElasticsearchOutputConfig) Enabled() bool {
+	return len(c.Hosts) > 0
+}
+
+// GRPCSenderConfig: 打包事件通过gRPC双向流发送到第三方采集服务(见task/logpb/eventpackage.proto)，Hosts为空时不启用。
+// Hosts为多个host:port时在客户端按LoadBalance负载均衡，也可以配置单个dns:///name:port由DNS解析出全部地址；
+// Streams为并发流数量，为0时与地址数一致，每个流在建立时按负载均衡策略选择服务端
+type GRPCSenderConfig struct {
+	Hosts       []string          `config:"hosts"`
+	LoadBalance string            `config:"load_balance"`
+	Streams     int               `config:"streams"`
+	Token       string            `config:"token"`
+	TLS         *tlscommon.Config `config:"ssl"`
+	// 已发送未确认的最大打包事件数，达到时阻塞发送
+	MaxInFlight int           `config:"max_in_flight"`
+	MaxBackoff  time.Duration `config:"max_backoff"`
+}
+
+// Enabled 是否配置了gRPC发送
+func (c GRPCSenderConfig) Enabled() bool {
+	return len(c.Hosts) > 0
+}
+
+// ContainersConfig: docker类型任务的容器选择条件，IDs(支持前缀)、Names、Labels之间为且的关系，为空时不限制；
+// Stream为all、stdout或stderr
+type ContainersConfig struct {
+	Path   string            `config:"path"`
+	IDs    []string          `config:"ids"`
+	Names  []string          `config:"names"`
+	Labels map[string]string `config:"labels"`
+	Stream string            `config:"stream"`
+}
+
+// DefaultContainersPath: docker json-file日志的默认目录
+const DefaultContainersPath = "/var/lib/docker/containers"
+
+// Paths 返回容器日志文件的采集路径，只按IDs缩小范围，名称及标签在采集后按容器元数据过滤
+func (c ContainersConfig) Paths() []string {
+	if len(c.IDs) == 0 {
+		return []string{filepath.Join(c.Path, "*", "*-json.log")}
+	}
+	paths := make([]string, 0, len(c.IDs))
+	for _, id := range c.IDs {
+		paths = append(paths, filepath.Join(c.Path, id+"*", "*-json.log"))
+	}
+	return paths
+}
+
+// AuditdConfig: auditd类型任务的审计日志路径及记录重组，同一审计事件的多条记录合并为一个事件，
+// 收到EOE记录、同一文件出现新的事件序号或超过Timeout没有新记录时发送
+type AuditdConfig struct {
+	Paths   []string      `config:"paths"`
+	Timeout time.Duration `config:"timeout"`
+}
+
+// DefaultAuditLogPath: auditd日志的默认路径
+const DefaultAuditLogPath = "/var/log/audit/audit.log"
+
+// GzipBackfillConfig: 首次部署时回填已轮转压缩的.gz文件，Paths为.gz文件的glob路径，
+// 按修改时间从旧到新逐个读取一次，只处理修改时间在MaxAge内的文件，MaxAge为0时不限制
+type GzipBackfillConfig struct {
+	Enabled bool          `config:"enabled"`
+	Paths   []string      `config:"paths"`
+	MaxAge  time.Duration `config:"max_age" validate:"min=0"`
+}
+
+// ScheduleConfig: 采集时间计划，Windows形如"00:00-06:00"，Cron为5段式表达式，满足任意一个即视为在计划内
+type ScheduleConfig struct {
+	Windows  []string `config:"windows"`
+	Cron     string   `config:"cron"`
+	Timezone string   `config:"timezone"`
+}
+
+// Enabled 是否配置了时间计划
+func (c ScheduleConfig) Enabled() bool {
+	return len(c.Windows) > 0 || c.Cron != ""
+}
+
+// DedupConfig: 重复日志过滤，按整行或Indexes指定列的哈希值去重，Window内重复出现的日志被丢弃，
+// 最多记录Size个哈希值，超出时按LRU淘汰；Window为0时只按容量淘汰
+type DedupConfig struct {
+	Enabled bool          `config:"enabled"`
+	Indexes []int         `config:"indexes"`
+	Size    int           `config:"size"`
+	Window  time.Duration `config:"window"`
+}
+
+// MultilineConfig: 任务管道中的多行合并，与采集插件自身的multiline相互独立，Pattern为空时不启用。
+// 行是否匹配为Pattern匹配结果与Negate的异或：Match为after时匹配的行追加到上一行之后；
+// 为before时匹配的行与之后的行合并，直到遇到不匹配的行；合并达到MaxLines行或超过Timeout没有新行时发送
+type MultilineConfig struct {
+	Pattern  string        `config:"pattern"`
+	Negate   bool          `config:"negate"`
+	Match    string        `config:"match"`
+	MaxLines int           `config:"max_lines"`
+	Timeout  time.Duration `config:"timeout"`
+}
+
+// Enabled 是否配置了多行合并
+func (c MultilineConfig) Enabled() bool {
+	return c.Pattern != ""
 }
 
 // FilterConfig line filter config
 type FilterConfig struct {
 	Conditions []ConditionConfig `config:"conditions"`
+	// 切分后的列数小于该值时条件组直接判定失败，用于显式处理截断或格式错误的日志，0为不限制
+	MinColumnCount int `config:"min_column_count"`
+	// 过滤表达式，与Conditions为AND关系，语法见utils.CompileExpr，如：col(3) == "ERROR" && strings.Contains(line, "timeout")
+	Expr string `config:"expr"`
 }
 
 //condition配置
@@ -67,19 +426,109 @@ type TaskConfig struct {
 	// Processor
 	Processors processors.PluginConfig `config:"processors"`
 	Delimiter  string                  `config:"delimiter"`
-	Filters    []FilterConfig          `config:"filters"`
-	HasFilter  bool
+	// 字符类分隔符，如[\s|]，配置后替代delimiter进行切分
+	SplitOnClass string `config:"split_on_class"`
+	// 正则分隔符，如\s+，配置后替代delimiter进行切分，不能匹配空字符串
+	SplitRegex string `config:"split_regex"`
+	// 多个可选分隔符，支持多字符，任意一个都视为分隔符，同一位置优先匹配较长的分隔符
+	Delimiters []string `config:"delimiters"`
+	// 切分方式：默认按分隔符切分；csv按CSV规则切分，引号内的分隔符不参与切分，分隔符为delimiter(默认为逗号)
+	SplitMode string         `config:"split_mode"`
+	Filters   []FilterConfig `config:"filters"`
+	// 按logfmt(key=value)格式解析data，index小于等于0的条件key写为"logfmt_key:expected_value"
+	LogfmtParse bool `config:"logfmt_parse"`
+	// 按JSON解析data，条件通过path(如$.kubernetes.namespace)取值后比较
+	JSONParse bool `config:"json_parse"`
+	HasFilter   bool
+	// 优先级规则：按顺序匹配，第一个命中规则的优先级写入_priority字段，数值越小优先级越高
+	PriorityRules []PriorityRule `config:"priority_rules"`
+	// 在processors之后按顺序执行的条件处理器
+	ConditionalProcessors []ConditionalProcessor `config:"conditional_processors"`
+	// 过滤条件不短路，全部求值并按条件统计命中数，用于分析过滤规则
+	EagerEval bool `config:"eager_eval"`
+	// 按DropSampleRate的概率记录被过滤丢弃的事件，日志级别为DropLogLevel：debug(默认)、info、warn
+	DropSampleRate float64 `config:"drop_sample_rate"`
+	DropLogLevel   string  `config:"drop_log_level"`
+	// 采集时间计划，计划外的事件直接丢弃，用于仅在低峰期采集的大量日志
+	Schedule ScheduleConfig `config:"schedule"`
+	// 通过过滤的事件按SampleRate的比例转发，取值(0, 1)，0或1为不采样；SampleByIndex大于0时按该列内容的哈希值采样，
+	// 同一列内容(如trace_id)的事件要么全部转发，要么全部丢弃
+	SampleRate    float64 `config:"sample_rate"`
+	SampleByIndex int     `config:"sample_by_index"`
+	// 通过过滤的事件速率上限(条/秒)，0为不限制；超出部分按RateLimitMode处理：drop(默认)丢弃，delay阻塞等待
+	MaxEventsPerSecond int    `config:"max_events_per_second"`
+	RateLimitMode      string `config:"rate_limit_mode"`
+	// 通过过滤的事件去重，用于应对故障期间大量重复的错误日志
+	Dedup DedupConfig `config:"dedup"`
+	// 在过滤之前按来源文件合并多行日志，用于采集插件不支持multiline的场景
+	Multiline MultilineConfig `config:"pipeline_multiline"`
 	// Sender
-	CanPackage   bool        `config:"package"`
-	PackageCount int         `config:"package_count"`
-	ExtMeta      interface{} `config:"ext_meta"`
+	CanPackage   bool `config:"package"`
+	PackageCount int  `config:"package_count"`
+	// 打包缓存的data字节数达到该值时提前发送，0为不限制
+	PackageFlushBytes int         `config:"package_flush_bytes"`
+	ExtMeta           interface{} `config:"ext_meta"`
 
 	// Output
 	RemovePathPrefix string `config:"remove_path_prefix"` // 去除路径前缀
 	IsContainerStd   bool   `config:"is_container_std"`   // 是否为容器标准输出日志
 	OutputFormat     string `config:"output_format"`      // 输出格式，为了兼容老版采集器的输出格式
+	MessageFormat    string `config:"message_format"`     // 事件结构格式：raw、json_wrapped、kv_pairs
+	AnnotateLatency  bool   `config:"annotate_latency"`   // 是否在事件中附加_filter_latency_us(过滤处理耗时)
+	// 按message_format序列化时的字段顺序，未列出的字段按key排序排在后面
+	FieldOrder []string `config:"field_order"`
+	// 字段投影：include只保留ProjectFields中的字段，exclude移除ProjectFields中的字段，为空时不处理
+	ProjectFields []string `config:"project_fields"`
+	ProjectMode   string   `config:"project_mode"`
+	// data的原始字符编码，如gbk、gb18030、big5，在过滤之前转换为UTF-8；已是有效UTF-8的内容不转换
+	SourceEncoding string `config:"source_encoding"`
+	// data超过MaxEventBytes字节的事件在过滤之前处理，0为不限制：drop(默认)丢弃；truncate截断data并标记_truncated字段；
+	// split切分为多个事件并附加_part_id、_part_index及_part_total字段
+	MaxEventBytes int    `config:"max_event_bytes"`
+	OversizeMode  string `config:"oversize_mode"`
+	// 事件序列化后超过MaxOutputBytes时的处理方式：drop(默认)、truncate_data、truncate_fields，0为不限制
+	// truncate_fields按TruncateFields的顺序依次移除字段，处理后仍超出时丢弃事件
+	MaxOutputBytes   int      `config:"max_output_bytes"`
+	TruncateStrategy string   `config:"truncate_strategy"`
+	TruncateFields   []string `config:"truncate_fields"`
+
+	// 从日志中提取指标，周期发送到单独的dataid
+	LogMetrics LogMetricsConfig `config:"log_metrics"`
+
+	// 通过内存映射环形缓冲区将过滤后的事件同步给非Go进程
+	MMRingBuffer MMRingBufferConfig `config:"mmap_ring_buffer"`
+
+	// 通过gRPC流将过滤后的事件同步推送到远端
+	GRPCOutput GRPCOutputConfig `config:"grpc_output"`
+
+	// 旁路输出的事件格式，key为mmap_ring_buffer或grpc_output：raw(默认)保持原有格式；
+	// json只输出序列化后的字段；text只输出data
+	OutputFormats map[string]string `config:"output_formats"`
+
+	// 直接写入Kafka
+	KafkaOutput KafkaOutputConfig `config:"kafka_output"`
+
+	// 直接写入Pulsar
+	PulsarOutput PulsarOutputConfig `config:"pulsar_output"`
+
+	// 直接写入Elasticsearch
+	ElasticsearchOutput ElasticsearchOutputConfig `config:"elasticsearch_output"`
+
+	// 通过gRPC发送到第三方采集服务
+	GRPCSender GRPCSenderConfig `config:"grpc_sender"`
+
+	// type为docker时采集的容器
+	Containers ContainersConfig `config:"containers"`
+
+	// type为auditd时的审计日志配置
+	Auditd AuditdConfig `config:"auditd"`
+
+	// 回填轮转后压缩的历史日志
+	GzipBackfill GzipBackfillConfig `config:"gzip_backfill"`
 
 	RawConfig *beat.Config
+	// 忽略filters后的配置hash值，用于判断是否只有过滤条件发生变化
+	filterlessID string
 }
 
 // 创建采集任务配置
@@ -91,6 +540,38 @@ func NewTaskConfig(rawConfig *beat.Config) (*TaskConfig, error) {
 		PackageCount: 10,
 		ExtMeta:      nil,
 		OutputFormat: "v2",
+		Dedup:        DedupConfig{Size: 10000},
+		Multiline:    MultilineConfig{Match: "after", MaxLines: 500, Timeout: 5 * time.Second},
+		LogMetrics:   LogMetricsConfig{Interval: time.Minute, MaxSeries: 1000},
+		Containers:   ContainersConfig{Path: DefaultContainersPath, Stream: "all"},
+		Auditd:       AuditdConfig{Paths: []string{DefaultAuditLogPath}, Timeout: 2 * time.Second},
+		KafkaOutput: KafkaOutputConfig{
+			RequiredAcks:  -1,
+			Compression:   "none",
+			Version:       "1.0.0",
+			ClientID:      "bkunifylogbeat",
+			SASLMechanism: "PLAIN",
+			Timeout:       10 * time.Second,
+			BufferSize:    1024,
+		},
+		PulsarOutput: PulsarOutputConfig{
+			BatchingMaxMessages:     1000,
+			BatchingMaxPublishDelay: 10 * time.Millisecond,
+			Timeout:                 10 * time.Second,
+			BufferSize:              1024,
+		},
+		ElasticsearchOutput: ElasticsearchOutputConfig{
+			Index:         "bkunifylogbeat-%{[dataid]}-%{+yyyy.MM.dd}",
+			BulkMaxSize:   500,
+			FlushInterval: 1 * time.Second,
+			Timeout:       30 * time.Second,
+			MaxBackoff:    60 * time.Second,
+		},
+		GRPCSender: GRPCSenderConfig{
+			LoadBalance: "round_robin",
+			MaxInFlight: 1024,
+			MaxBackoff:  60 * time.Second,
+		},
 	}
 	err := rawConfig.Unpack(&config)
 	if err != nil {
@@ -105,49 +586,589 @@ func NewTaskConfig(rawConfig *beat.Config) (*TaskConfig, error) {
 		return nil, fmt.Errorf("error init config: %v", err)
 	}
 
+	// MessageFormat
+	switch config.MessageFormat {
+	case "", "raw", "json_wrapped", "kv_pairs":
+	default:
+		return nil, fmt.Errorf("message_format must be raw, json_wrapped or kv_pairs")
+	}
+
+	// OutputFormats
+	for output, format := range config.OutputFormats {
+		switch output {
+		case "mmap_ring_buffer", "grpc_output":
+		default:
+			return nil, fmt.Errorf("output_formats key(%s) must be mmap_ring_buffer or grpc_output", output)
+		}
+		switch format {
+		case "raw", "json", "text":
+		default:
+			return nil, fmt.Errorf("output_formats %s must be raw, json or text", output)
+		}
+	}
+
+	// Containers
+	switch config.Containers.Stream {
+	case "all", "stdout", "stderr":
+	default:
+		return nil, fmt.Errorf("containers stream must be all, stdout or stderr")
+	}
+
+	// Auditd
+	if config.Type == "auditd" && config.Auditd.Timeout <= 0 {
+		return nil, fmt.Errorf("auditd timeout must be positive")
+	}
+
+	// KafkaOutput
+	if config.KafkaOutput.Enabled() {
+		if config.KafkaOutput.Topic == "" {
+			return nil, fmt.Errorf("kafka_output topic is required")
+		}
+		switch config.KafkaOutput.RequiredAcks {
+		case -1, 0, 1:
+		default:
+			return nil, fmt.Errorf("kafka_output required_acks must be -1, 0 or 1")
+		}
+		if config.KafkaOutput.BufferSize <= 0 {
+			return nil, fmt.Errorf("kafka_output buffer_size must be positive")
+		}
+	}
+
+	// PulsarOutput
+	if config.PulsarOutput.Enabled() {
+		if !strings.HasPrefix(config.PulsarOutput.URL, "pulsar://") && !strings.HasPrefix(config.PulsarOutput.URL, "pulsar+ssl://") {
+			return nil, fmt.Errorf("pulsar_output url must start with pulsar:// or pulsar+ssl://")
+		}
+		if config.PulsarOutput.Topic == "" {
+			return nil, fmt.Errorf("pulsar_output topic is required")
+		}
+		if config.PulsarOutput.BatchingMaxMessages <= 0 || config.PulsarOutput.BatchingMaxPublishDelay <= 0 {
+			return nil, fmt.Errorf("pulsar_output batching_max_messages and batching_max_publish_delay must be positive")
+		}
+		if config.PulsarOutput.BufferSize <= 0 {
+			return nil, fmt.Errorf("pulsar_output buffer_size must be positive")
+		}
+	}
+
+	// 直接发送的输出只能配置一个
+	outputs := 0
+	for _, enabled := range []bool{
+		config.KafkaOutput.Enabled(), config.PulsarOutput.Enabled(), config.ElasticsearchOutput.Enabled(), config.GRPCSender.Enabled(),
+	} {
+		if enabled {
+			outputs++
+		}
+	}
+	if outputs > 1 {
+		return nil, fmt.Errorf("only one of kafka_output, pulsar_output, elasticsearch_output and grpc_sender can be used")
+	}
+
+	// ElasticsearchOutput
+	if config.ElasticsearchOutput.Enabled() {
+		if _, err := fmtstr.CompileEvent(config.ElasticsearchOutput.Index); err != nil {
+			return nil, fmt.Errorf("elasticsearch_output index(%s) is invalid, err=>%v", config.ElasticsearchOutput.Index, err)
+		}
+		if config.ElasticsearchOutput.BulkMaxSize <= 0 {
+			return nil, fmt.Errorf("elasticsearch_output bulk_max_size must be positive")
+		}
+	}
+
+	// GRPCSender
+	if config.GRPCSender.Enabled() {
+		switch config.GRPCSender.LoadBalance {
+		case "round_robin", "pick_first":
+		default:
+			return nil, fmt.Errorf("grpc_sender load_balance must be round_robin or pick_first")
+		}
+		if config.GRPCSender.Streams < 0 || config.GRPCSender.MaxInFlight <= 0 {
+			return nil, fmt.Errorf("grpc_sender streams must not be negative and max_in_flight must be positive")
+		}
+	}
+
+	// GzipBackfill
+	if config.GzipBackfill.Enabled && len(config.GzipBackfill.Paths) == 0 {
+		return nil, fmt.Errorf("gzip_backfill paths is required")
+	}
+
+	// ProjectMode
+	switch config.ProjectMode {
+	case "", "include", "exclude":
+	default:
+		return nil, fmt.Errorf("project_mode must be include or exclude")
+	}
+
+	// Schedule
+	if config.Schedule.Enabled() {
+		if _, err = utils.NewSchedule(config.Schedule.Windows, config.Schedule.Cron, config.Schedule.Timezone); err != nil {
+			return nil, err
+		}
+	}
+
+	// Sample
+	if config.SampleRate < 0 || config.SampleRate > 1 {
+		return nil, fmt.Errorf("sample_rate must be between 0 and 1")
+	}
+	if config.SampleByIndex > 0 && !config.splittable() {
+		return nil, fmt.Errorf("sample_by_index requires delimiter or split_on_class")
+	}
+
+	// RateLimit
+	if config.MaxEventsPerSecond < 0 {
+		return nil, fmt.Errorf("max_events_per_second must not be negative")
+	}
+	switch config.RateLimitMode {
+	case "", "drop", "delay":
+	default:
+		return nil, fmt.Errorf("rate_limit_mode must be drop or delay")
+	}
+
+	// Dedup
+	if config.Dedup.Enabled {
+		if config.Dedup.Size <= 0 {
+			return nil, fmt.Errorf("dedup.size must be positive")
+		}
+		if config.Dedup.Window < 0 {
+			return nil, fmt.Errorf("dedup.window must not be negative")
+		}
+		for _, index := range config.Dedup.Indexes {
+			if index <= 0 {
+				return nil, fmt.Errorf("dedup.indexes must be positive")
+			}
+		}
+		if len(config.Dedup.Indexes) > 0 && !config.splittable() {
+			return nil, fmt.Errorf("dedup.indexes requires delimiter or split_on_class")
+		}
+	}
+
+	// DropSampleRate
+	if config.DropSampleRate < 0 || config.DropSampleRate > 1 {
+		return nil, fmt.Errorf("drop_sample_rate must be between 0 and 1")
+	}
+	switch config.DropLogLevel {
+	case "", "debug", "info", "warn":
+	default:
+		return nil, fmt.Errorf("drop_log_level must be debug, info or warn")
+	}
+
+	// Multiline
+	if config.Multiline.Enabled() {
+		if _, err = regexp.Compile(config.Multiline.Pattern); err != nil {
+			return nil, fmt.Errorf("pipeline_multiline pattern(%s) compile failed, err=>%v", config.Multiline.Pattern, err)
+		}
+		switch config.Multiline.Match {
+		case "after", "before":
+		default:
+			return nil, fmt.Errorf("pipeline_multiline match must be after or before")
+		}
+		if config.Multiline.MaxLines <= 0 || config.Multiline.Timeout <= 0 {
+			return nil, fmt.Errorf("pipeline_multiline max_lines and timeout must be greater than 0")
+		}
+	}
+
+	// LogMetrics
+	if len(config.LogMetrics.Metrics) > 0 {
+		if err = config.LogMetrics.check(); err != nil {
+			return nil, err
+		}
+	}
+
+	// SourceEncoding
+	if config.SourceEncoding != "" {
+		if _, err = htmlindex.Get(config.SourceEncoding); err != nil {
+			return nil, fmt.Errorf("source_encoding(%s) is not supported", config.SourceEncoding)
+		}
+	}
+
+	// MaxEventBytes
+	if config.MaxEventBytes < 0 {
+		return nil, fmt.Errorf("max_event_bytes must not be negative")
+	}
+	switch config.OversizeMode {
+	case "", "drop", "truncate", "split":
+	default:
+		return nil, fmt.Errorf("oversize_mode must be drop, truncate or split")
+	}
+
+	// TruncateStrategy
+	switch config.TruncateStrategy {
+	case "", "drop", "truncate_data":
+	case "truncate_fields":
+		if len(config.TruncateFields) == 0 {
+			return nil, fmt.Errorf("truncate_fields is required when truncate_strategy is truncate_fields")
+		}
+	default:
+		return nil, fmt.Errorf("truncate_strategy must be drop, truncate_data or truncate_fields")
+	}
+
 	// Filter
 	config.HasFilter = false
-	if len(config.Delimiter) == 1 {
+	if config.SplitOnClass != "" {
+		err = checkSplitClass(config.SplitOnClass)
+		if err != nil {
+			return nil, err
+		}
+	}
+	err = config.checkSplitPattern()
+	if err != nil {
+		return nil, err
+	}
+	switch config.SplitMode {
+	case "":
+	case "csv":
+		if config.SplitPattern() != "" {
+			return nil, fmt.Errorf("split_mode csv can not be used with split_on_class, split_regex or delimiters")
+		}
+		if len(config.Delimiter) > 1 {
+			return nil, fmt.Errorf("split_mode csv requires a single character delimiter")
+		}
+	default:
+		return nil, fmt.Errorf("split_mode must be csv or empty")
+	}
+	if config.splittable() {
 		for _, f := range config.Filters {
-			// op must be "=" or "!="
-			for _, condition := range f.Conditions {
-				if condition.Op != "=" && condition.Op != "!=" {
-					return nil, fmt.Errorf("op must = or !=")
-				}
+			err = config.checkFilter(f)
+			if err != nil {
+				return nil, err
 			}
 			config.HasFilter = true
 		}
 	}
 
-	// sort conditions
-	if config.HasFilter {
-		for _, f := range config.Filters {
-			sort.Sort(ConditionSortByIndex(f.Conditions))
-			// uniq filter index
-			lastIndex := 0
-			for idx, condition := range f.Conditions {
-				if idx != 0 && lastIndex == condition.Index {
-					return nil, fmt.Errorf("filter has duplicate index")
-				}
-				lastIndex = condition.Index
+	// PriorityRules
+	if len(config.PriorityRules) > 0 {
+		if !config.splittable() {
+			return nil, fmt.Errorf("priority_rules requires delimiter, split_on_class, logfmt_parse or json_parse")
+		}
+		for _, rule := range config.PriorityRules {
+			err = config.checkFilter(rule.Filter)
+			if err != nil {
+				return nil, err
 			}
 		}
 	}
+
+	// ConditionalProcessors
+	for _, p := range config.ConditionalProcessors {
+		if len(p.Then) == 0 {
+			return nil, fmt.Errorf("conditional_processors then is required")
+		}
+		if _, err = p.PathPattern(); err != nil {
+			return nil, fmt.Errorf("conditional_processors path(%s) is not valid, err=>%v", p.Path, err)
+		}
+		if len(p.If.Conditions) > 0 && !config.splittable() {
+			return nil, fmt.Errorf("conditional_processors conditions requires delimiter, split_on_class, logfmt_parse or json_parse")
+		}
+		if err = config.checkFilter(p.If); err != nil {
+			return nil, err
+		}
+	}
+
 	//根据任务配置获取hash值
 	err, config.ID = utils.HashRawConfig(config.RawConfig)
 	if err != nil {
 		return nil, err
 	}
 	config.ID = fmt.Sprintf("%s_%s", strconv.Itoa(config.DataID), config.ID)
+	err, config.filterlessID = utils.HashRawConfigExcept(config.RawConfig, "filters")
+	if err != nil {
+		return nil, err
+	}
 
 	return config, nil
 }
 
+// check: 校验日志指标配置
+func (c LogMetricsConfig) check() error {
+	if c.DataID == 0 {
+		return fmt.Errorf("log_metrics dataid is required")
+	}
+	if c.Interval <= 0 || c.MaxSeries <= 0 {
+		return fmt.Errorf("log_metrics interval and max_series must be greater than 0")
+	}
+	names := make(map[string]bool)
+	for _, m := range c.Metrics {
+		if m.Name == "" || names[m.Name] {
+			return fmt.Errorf("log_metrics name(%s) is empty or duplicated", m.Name)
+		}
+		names[m.Name] = true
+		re, err := regexp.Compile(m.Pattern)
+		if err != nil {
+			return fmt.Errorf("log_metrics %s pattern compile failed, err=>%v", m.Name, err)
+		}
+		switch m.Type {
+		case "counter":
+		case "histogram":
+			if re.NumSubexp() == 0 {
+				return fmt.Errorf("log_metrics %s histogram pattern requires a capture group", m.Name)
+			}
+			if len(m.Buckets) == 0 || !sort.Float64sAreSorted(m.Buckets) {
+				return fmt.Errorf("log_metrics %s histogram buckets must be ascending", m.Name)
+			}
+		default:
+			return fmt.Errorf("log_metrics %s type must be counter or histogram", m.Name)
+		}
+	}
+	return nil
+}
+
+// splittable: 配置了日志解析方式时才能按条件过滤
+func (config *TaskConfig) splittable() bool {
+	return len(config.Delimiter) == 1 || config.SplitPattern() != "" || config.SplitMode == "csv" || config.LogfmtParse || config.JSONParse
+}
+
+// SplitPattern 按正则切分时使用的表达式，来源为split_on_class、split_regex或delimiters，未配置时返回空字符串
+func (config *TaskConfig) SplitPattern() string {
+	if config.SplitOnClass != "" {
+		return config.SplitOnClass
+	}
+	if config.SplitRegex != "" {
+		return config.SplitRegex
+	}
+	if len(config.Delimiters) == 0 {
+		return ""
+	}
+	// 正则按从左到右的顺序选择分支，较长的分隔符放在前面
+	delimiters := append([]string{}, config.Delimiters...)
+	sort.SliceStable(delimiters, func(i, j int) bool {
+		return len(delimiters[i]) > len(delimiters[j])
+	})
+	quoted := make([]string, 0, len(delimiters))
+	for _, delimiter := range delimiters {
+		quoted = append(quoted, regexp.QuoteMeta(delimiter))
+	}
+	return strings.Join(quoted, "|")
+}
+
+// checkSplitPattern: 校验正则分隔符及多个分隔符，三种正则切分方式只能配置一种
+func (config *TaskConfig) checkSplitPattern() error {
+	configured := 0
+	for _, set := range []bool{config.SplitOnClass != "", config.SplitRegex != "", len(config.Delimiters) > 0} {
+		if set {
+			configured++
+		}
+	}
+	if configured > 1 {
+		return fmt.Errorf("split_on_class, split_regex and delimiters can not be used together")
+	}
+	for _, delimiter := range config.Delimiters {
+		if delimiter == "" {
+			return fmt.Errorf("delimiters must not contain empty string")
+		}
+	}
+	if config.SplitRegex != "" {
+		re, err := regexp.Compile(config.SplitRegex)
+		if err != nil {
+			return fmt.Errorf("split_regex(%s) compile failed, err=>%v", config.SplitRegex, err)
+		}
+		if re.MatchString("") {
+			return fmt.Errorf("split_regex(%s) must not match empty string", config.SplitRegex)
+		}
+	}
+	return nil
+}
+
+// splitClassSample: 用于校验字符类分隔符的测试字符串
+const splitClassSample = "a b\tc|d,e;f:g=h/i-j_k.l#m"
+
+// checkSplitClass: 字符类分隔符必须为正则中括号形式，并且能够切分测试字符串
+func checkSplitClass(class string) error {
+	if !strings.HasPrefix(class, "[") || !strings.HasSuffix(class, "]") {
+		return fmt.Errorf("split_on_class must be a bracket expression, like [\\s|]")
+	}
+	re, err := regexp.Compile(class)
+	if err != nil {
+		return fmt.Errorf("split_on_class(%s) compile failed, err=>%v", class, err)
+	}
+	if len(re.Split(splitClassSample, -1)) < 2 {
+		return fmt.Errorf("split_on_class(%s) does not match any delimiter", class)
+	}
+	return nil
+}
+
+// isTypedValue: 校验值是否能按字段类型解析
+func isTypedValue(fieldType, value string, base int) bool {
+	var err error
+	switch fieldType {
+	case "int":
+		_, err = strconv.ParseInt(value, base, 64)
+	case "float":
+		_, err = strconv.ParseFloat(value, 64)
+	case "bool":
+		_, err = strconv.ParseBool(value)
+	}
+	return err == nil
+}
+
+// checkFilter: 校验条件组配置，并按index对条件排序
+func (config *TaskConfig) checkFilter(f FilterConfig) error {
+	if f.MinColumnCount < 0 {
+		return fmt.Errorf("min_column_count must not be negative")
+	}
+	if f.Expr != "" {
+		if _, err := utils.CompileExpr(f.Expr); err != nil {
+			return err
+		}
+	}
+	for _, condition := range f.Conditions {
+		if err := config.checkCondition(condition); err != nil {
+			return err
+		}
+	}
+
+	// sort conditions，同一列允许多个条件(如gte与lt组成区间)，稳定排序保持这些条件的配置顺序
+	sort.Stable(ConditionSortByIndex(f.Conditions))
+	return nil
+}
+
+// checkCondition: 校验单个条件，嵌套条件组递归校验子条件
+func (config *TaskConfig) checkCondition(condition ConditionConfig) error {
+	if condition.IsGroup() {
+		if condition.Op != "" {
+			return fmt.Errorf("condition group must not have op")
+		}
+		if len(condition.Any) > 0 && len(condition.All) > 0 {
+			return fmt.Errorf("any and all can not be used in the same condition")
+		}
+		for _, child := range append(condition.Any, condition.All...) {
+			if err := config.checkCondition(child); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if !filterOperations[condition.Op] {
+		return fmt.Errorf("op(%s) is not supported", condition.Op)
+	}
+	if condition.Op == "window_agg_gt" {
+		if condition.WindowAgg.WindowSize <= 0 {
+			return fmt.Errorf("window_agg.window_size must be greater than 0")
+		}
+		switch condition.WindowAgg.AggFunc {
+		case "min", "max", "sum", "avg":
+		default:
+			return fmt.Errorf("window_agg.agg_func must be min, max, sum or avg")
+		}
+	}
+	switch condition.FieldType {
+	case "", "string":
+	case "int", "float", "bool":
+		if condition.Op != "=" && condition.Op != "!=" && !(numericOperations[condition.Op] && condition.FieldType != "bool") {
+			return fmt.Errorf("field_type(%s) does not support op(%s)", condition.FieldType, condition.Op)
+		}
+		if !isTypedValue(condition.FieldType, condition.Key, condition.NumericBase) {
+			return fmt.Errorf("key(%s) is not a valid %s", condition.Key, condition.FieldType)
+		}
+	default:
+		return fmt.Errorf("field_type must be string, int, float or bool")
+	}
+	switch condition.NumericBase {
+	case 0, 8, 10, 16:
+	default:
+		return fmt.Errorf("numeric_base must be 0, 8, 10 or 16")
+	}
+	if condition.Locale != "" {
+		if condition.Op != "=" && condition.Op != "!=" {
+			return fmt.Errorf("locale only supports = and !=")
+		}
+		if condition.FieldType != "" && condition.FieldType != "string" {
+			return fmt.Errorf("locale only supports string field_type")
+		}
+		if _, err := language.Parse(condition.Locale); err != nil {
+			return fmt.Errorf("locale(%s) is not valid, err=>%v", condition.Locale, err)
+		}
+	}
+	if condition.IPNormalize {
+		if condition.Op != "=" && condition.Op != "!=" {
+			return fmt.Errorf("ip_normalize only supports = and !=")
+		}
+		if net.ParseIP(strings.TrimSpace(condition.Key)) == nil {
+			return fmt.Errorf("key(%s) is not a valid ip", condition.Key)
+		}
+	}
+	if numericOperations[condition.Op] {
+		fieldType := condition.FieldType
+		if fieldType == "" || fieldType == "string" {
+			fieldType = "float"
+		}
+		if !isTypedValue(fieldType, condition.Key, condition.NumericBase) {
+			return fmt.Errorf("key(%s) is not a valid %s", condition.Key, fieldType)
+		}
+	}
+	if condition.IgnoreCase {
+		switch condition.Op {
+		case "=", "!=", "neq", "contains", "ncontains":
+		default:
+			return fmt.Errorf("ignore_case does not support op(%s)", condition.Op)
+		}
+		if (condition.FieldType != "" && condition.FieldType != "string") || condition.Locale != "" || condition.IPNormalize {
+			return fmt.Errorf("ignore_case can not be used with field_type, locale or ip_normalize")
+		}
+	}
+	if condition.Op == "cidr" {
+		if _, err := utils.ParseCIDRs(condition.Key); err != nil {
+			return err
+		}
+	}
+	if condition.Op == "regex" || condition.Op == "nregex" {
+		if _, err := regexp.Compile(condition.Key); err != nil {
+			return fmt.Errorf("key(%s) is not a valid regex, err=>%v", condition.Key, err)
+		}
+	}
+	if condition.Op == "weekday_in" {
+		if _, err := utils.ParseWeekdays(condition.Key); err != nil {
+			return err
+		}
+		if _, err := time.LoadLocation(condition.Timezone); err != nil {
+			return fmt.Errorf("timezone(%s) is not valid, err=>%v", condition.Timezone, err)
+		}
+	}
+	if condition.Op == "s3_allow" || condition.Op == "s3_deny" {
+		if condition.S3List.Bucket == "" || condition.S3List.Key == "" {
+			return fmt.Errorf("s3_list.bucket and s3_list.key are required for %s", condition.Op)
+		}
+	}
+	if condition.Op == "scanf" {
+		if _, err := utils.NewScanfFormat(condition.Key); err != nil {
+			return err
+		}
+	}
+	if condition.IsFieldCondition() {
+		if condition.Key == "" {
+			return fmt.Errorf("key is required for %s", condition.Op)
+		}
+		if condition.Path != "" || condition.Tokenize {
+			return fmt.Errorf("%s can not be used with path or tokenize", condition.Op)
+		}
+	}
+	if condition.Path != "" {
+		if !config.JSONParse {
+			return fmt.Errorf("path(%s) requires json_parse", condition.Path)
+		}
+		if strings.TrimPrefix(strings.TrimPrefix(condition.Path, "$"), ".") == "" {
+			return fmt.Errorf("path(%s) is not valid", condition.Path)
+		}
+	} else if config.LogfmtParse && condition.Index <= 0 && !condition.IsFieldCondition() && strings.Index(condition.Key, ":") <= 0 {
+		return fmt.Errorf("logfmt condition key must be logfmt_key:expected_value")
+	}
+	if condition.PadTo < 0 || condition.TruncateTo < 0 {
+		return fmt.Errorf("pad_to and truncate_to must not be negative")
+	}
+	if utf8.RuneCountInString(condition.PadChar) > 1 {
+		return fmt.Errorf("pad_char must be a single character")
+	}
+	return nil
+}
+
 // Same用于采集器reload时，比较同一dataid的任务是否有做调整
 func (sourceConfig *TaskConfig) Same(targetConfig *TaskConfig) bool {
 	return sourceConfig.ID == targetConfig.ID
 }
 
+// SameExceptFilters 用于采集器reload时，判断任务是否只调整了过滤条件，此时可以热更新过滤条件而不重启任务
+func (sourceConfig *TaskConfig) SameExceptFilters(targetConfig *TaskConfig) bool {
+	return sourceConfig.filterlessID != "" && sourceConfig.filterlessID == targetConfig.filterlessID
+}
+
 // GetTasks根据主配置定义的从配置目录，获取采集器定义的任务列表
 func GetTasks(config Config) map[string]*TaskConfig {
 	tasks := make(map[string]*TaskConfig)
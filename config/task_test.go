@@ -46,3 +46,71 @@ func TestTaskConfig_Same(t *testing.T) {
 	taskConfig3, _ := CreateTaskConfig(vars2)
 	assert.False(t, taskConfig1.Same(taskConfig3))
 }
+
+// TestTaskConfig_SameExceptFilters: 测试只调整过滤条件的判断
+func TestTaskConfig_SameExceptFilters(t *testing.T) {
+	vars := map[string]interface{}{
+		"dataid":    "999990001",
+		"delimiter": "|",
+		"filters": []FilterConfig{
+			{Conditions: []ConditionConfig{{Index: 1, Key: "ERROR", Op: "="}}},
+		},
+	}
+	taskConfig1, _ := CreateTaskConfig(vars)
+
+	vars["filters"] = []FilterConfig{
+		{Conditions: []ConditionConfig{{Index: 1, Key: "WARN", Op: "="}}},
+	}
+	taskConfig2, _ := CreateTaskConfig(vars)
+	assert.False(t, taskConfig1.Same(taskConfig2))
+	assert.True(t, taskConfig1.SameExceptFilters(taskConfig2))
+
+	vars["delimiter"] = ","
+	taskConfig3, _ := CreateTaskConfig(vars)
+	assert.False(t, taskConfig1.SameExceptFilters(taskConfig3))
+}
+
+//TestCheckFilterSameIndex: 测试同一列允许多个条件，且保持同一列条件的配置顺序
+func TestCheckFilterSameIndex(t *testing.T) {
+	config, err := CreateTaskConfig(map[string]interface{}{
+		"dataid":    "999990001",
+		"delimiter": "|",
+		"filters": []FilterConfig{
+			{
+				Conditions: []ConditionConfig{
+					{Index: 3, Key: "GET", Op: "="},
+					{Index: 2, Key: "200", Op: "gte", FieldType: "int"},
+					{Index: 2, Key: "300", Op: "lt", FieldType: "int"},
+				},
+			},
+		},
+	})
+	assert.NoError(t, err)
+	conditions := config.Filters[0].Conditions
+	assert.Equal(t, "gte", conditions[0].Op)
+	assert.Equal(t, "lt", conditions[1].Op)
+	assert.Equal(t, 3, conditions[2].Index)
+}
+
+// TestOutputFormats: 测试旁路输出格式的校验
+func TestOutputFormats(t *testing.T) {
+	config, err := CreateTaskConfig(map[string]interface{}{
+		"dataid":         "999990001",
+		"output_formats": map[string]string{"mmap_ring_buffer": "text", "grpc_output": "json"},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "text", config.OutputFormats["mmap_ring_buffer"])
+	assert.Equal(t, "json", config.OutputFormats["grpc_output"])
+
+	_, err = CreateTaskConfig(map[string]interface{}{
+		"dataid":         "999990001",
+		"output_formats": map[string]string{"kafka_output": "json"},
+	})
+	assert.Error(t, err)
+
+	_, err = CreateTaskConfig(map[string]interface{}{
+		"dataid":         "999990001",
+		"output_formats": map[string]string{"grpc_output": "xml"},
+	})
+	assert.Error(t, err)
+}
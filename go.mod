@@ -3,16 +3,22 @@ module github.com/TencentBlueKing/bkunifylogbeat
 go 1.14
 
 require (
+	github.com/Shopify/sarama v1.27.1
 	github.com/StackExchange/wmi v1.2.1 // indirect
 	github.com/TencentBlueKing/collector-go-sdk/v2 v2.0.0
 	github.com/andrewkroh/sys v0.0.0-20151128191922-287798fe3e43 // indirect
+	github.com/aws/aws-sdk-go v1.38.0
 	github.com/dustin/go-humanize v1.0.0
 	github.com/elastic/beats v7.1.1+incompatible
+	github.com/go-logfmt/logfmt v0.5.0
 	github.com/golang/groupcache v0.0.0-20200121045136-8c9f03a8e57e
+	github.com/golang/protobuf v1.4.3
 	github.com/pkg/errors v0.9.1
 	github.com/shirou/gopsutil v3.21.8+incompatible
 	github.com/stretchr/testify v1.6.1
-	github.com/tklauser/go-sysconf v0.3.9 // indirect
+	github.com/tklauser/go-sysconf v0.3.9
+	golang.org/x/text v0.3.6
+	google.golang.org/grpc v1.38.0
 )
 
 replace (
@@ -26,9 +26,19 @@ import (
 	// input type
 	_ "github.com/elastic/beats/filebeat/input/log"
 	_ "github.com/elastic/beats/filebeat/input/stdin"
-	_ "github.com/elastic/beats/filebeat/input/syslog"
 	_ "github.com/elastic/beats/filebeat/input/udp"
 
+	_ "github.com/TencentBlueKing/bkunifylogbeat/task/input/exec"
+	_ "github.com/TencentBlueKing/bkunifylogbeat/task/input/fifo"
+	_ "github.com/TencentBlueKing/bkunifylogbeat/task/input/grpcinput"
+	_ "github.com/TencentBlueKing/bkunifylogbeat/task/input/httppush"
+	_ "github.com/TencentBlueKing/bkunifylogbeat/task/input/journald"
+	_ "github.com/TencentBlueKing/bkunifylogbeat/task/input/kafka"
+	_ "github.com/TencentBlueKing/bkunifylogbeat/task/input/redis"
+	_ "github.com/TencentBlueKing/bkunifylogbeat/task/input/s3"
+	_ "github.com/TencentBlueKing/bkunifylogbeat/task/input/snmptrap"
+	_ "github.com/TencentBlueKing/bkunifylogbeat/task/input/socket"
+	_ "github.com/TencentBlueKing/bkunifylogbeat/task/input/syslog"
 	_ "github.com/TencentBlueKing/bkunifylogbeat/task/input/wineventlog"
 
 	// input config
@@ -36,4 +46,10 @@ import (
 
 	// formatter
 	_ "github.com/TencentBlueKing/bkunifylogbeat/task/formatter"
+
+	// processor
+	_ "github.com/TencentBlueKing/bkunifylogbeat/task/processors"
+	_ "github.com/elastic/beats/libbeat/processors/add_cloud_metadata"
+	_ "github.com/elastic/beats/libbeat/processors/add_host_metadata"
+	_ "github.com/elastic/beats/libbeat/processors/dissect"
 )
@@ -0,0 +1,273 @@
+// Tencent is pleased to support the open source community by making bkunifylogbeat 蓝鲸日志采集器 available.
+//
+// Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+//
+// bkunifylogbeat 蓝鲸日志采集器 is licensed under the MIT License.
+//
+// License for bkunifylogbeat 蓝鲸日志采集器:
+// --------------------------------------------------------------------
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+// documentation files (the "Software"), to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software,
+// and to permit persons to whom the Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all copies or substantial
+// portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+// LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN
+// NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+// WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+// SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package task
+
+import (
+	"encoding/hex"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	cfg "github.com/TencentBlueKing/bkunifylogbeat/config"
+	"github.com/TencentBlueKing/collector-go-sdk/v2/bkbeat/bkmonitoring"
+	"github.com/elastic/beats/filebeat/util"
+	"github.com/elastic/beats/libbeat/common"
+)
+
+var (
+	auditdFlushedTimeout = bkmonitoring.NewInt("auditd_flushed_timeout")
+	auditdInvalid        = bkmonitoring.NewInt("auditd_invalid")
+)
+
+// auditHeader: [node=xxx ]type=XXX msg=audit(秒.毫秒:序号):
+var auditHeader = regexp.MustCompile(`^(?:node=(\S+) )?type=(\S+) msg=audit\((\d+)\.(\d+):(\d+)\):\s*`)
+
+// auditRecord 一条审计记录
+type auditRecord struct {
+	node     string
+	typ      string
+	time     time.Time
+	sequence string
+	fields   common.MapStr
+	// 用户态程序发送的记录带有msg='...'，不会与其他记录组成同一事件
+	standalone bool
+}
+
+// parseAuditRecord 解析一行审计记录，格式不符时返回false
+func parseAuditRecord(line string) (auditRecord, bool) {
+	match := auditHeader.FindStringSubmatch(line)
+	if match == nil {
+		return auditRecord{}, false
+	}
+	sec, _ := strconv.ParseInt(match[3], 10, 64)
+	msec, _ := strconv.ParseInt(match[4], 10, 64)
+	record := auditRecord{
+		node:     match[1],
+		typ:      match[2],
+		time:     time.Unix(sec, msec*int64(time.Millisecond)),
+		sequence: match[5],
+		fields:   common.MapStr{},
+	}
+	body := line[len(match[0]):]
+	// ENRICHED格式在原始字段之后以0x1d分隔附加解释后的字段
+	if i := strings.IndexByte(body, 0x1d); i >= 0 {
+		body = body[:i] + " " + body[i+1:]
+	}
+	for key, value := range parseAuditKV(body) {
+		if key == "msg" {
+			record.standalone = true
+			for k, v := range parseAuditKV(value) {
+				record.fields[k] = v
+			}
+			continue
+		}
+		record.fields[key] = value
+	}
+	// proctitle未加引号时为十六进制编码，参数之间以NUL分隔
+	if title, ok := record.fields["proctitle"].(string); ok && record.typ == "PROCTITLE" {
+		if decoded, err := hex.DecodeString(title); err == nil {
+			record.fields["proctitle"] = strings.Replace(string(decoded), "\x00", " ", -1)
+		}
+	}
+	return record, true
+}
+
+// parseAuditKV 解析以空格分隔的key=value，value可以使用单引号或双引号
+func parseAuditKV(text string) map[string]string {
+	result := make(map[string]string)
+	for {
+		text = strings.TrimLeft(text, " ")
+		eq := strings.IndexByte(text, '=')
+		if eq <= 0 {
+			return result
+		}
+		key := text[:eq]
+		if space := strings.IndexByte(key, ' '); space >= 0 {
+			// 没有值的token，跳过
+			text = text[space:]
+			continue
+		}
+		text = text[eq+1:]
+		var value string
+		if len(text) > 0 && (text[0] == '"' || text[0] == '\'') {
+			end := strings.IndexByte(text[1:], text[0])
+			if end < 0 {
+				value, text = text[1:], ""
+			} else {
+				value, text = text[1:end+1], text[end+2:]
+			}
+		} else {
+			end := strings.IndexByte(text, ' ')
+			if end < 0 {
+				value, text = text, ""
+			} else {
+				value, text = text[:end], text[end:]
+			}
+		}
+		result[key] = value
+	}
+}
+
+// auditGroup 同一来源文件正在重组的审计事件
+type auditGroup struct {
+	first    *util.Data
+	last     *util.Data
+	sequence string
+	lines    []string
+	records  []auditRecord
+	updated  time.Time
+}
+
+// data 重组后的事件：时间取审计时间，采集进度取最后一条记录
+func (g *auditGroup) data() *util.Data {
+	data := *g.last
+	data.Event = g.first.Event
+	data.Event.Fields = g.first.Event.Fields.Clone()
+	data.Event.Timestamp = g.records[0].time
+	sequence, _ := strconv.ParseInt(g.sequence, 10, 64)
+	types := make([]string, 0, len(g.records))
+	records := make([]common.MapStr, 0, len(g.records))
+	for _, record := range g.records {
+		types = append(types, record.typ)
+		fields := record.fields.Clone()
+		fields["type"] = record.typ
+		records = append(records, fields)
+	}
+	audit := common.MapStr{
+		"sequence": sequence,
+		"type":     types[0],
+		"types":    types,
+		"records":  records,
+	}
+	if g.records[0].node != "" {
+		audit["node"] = g.records[0].node
+	}
+	data.Event.Fields["data"] = strings.Join(g.lines, "\n")
+	data.Event.Fields["audit"] = audit
+	return &data
+}
+
+// auditd: 按来源文件将同一序号的连续审计记录重组为一个事件，不是审计记录格式的行原样发送
+type auditd struct {
+	config cfg.AuditdConfig
+	mutex  sync.Mutex
+	groups map[string]*auditGroup
+	now    func() time.Time
+}
+
+func newAuditd(config cfg.AuditdConfig) *auditd {
+	return &auditd{
+		config: config,
+		groups: make(map[string]*auditGroup),
+		now:    time.Now,
+	}
+}
+
+// feed 处理一个采集事件，返回可以继续处理的事件，暂存的记录不返回
+func (a *auditd) feed(data *util.Data) []*util.Data {
+	source := data.GetState().Source
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	if data.Event.Fields == nil {
+		return append(a.flush(source), data)
+	}
+	text, ok := data.Event.Fields["data"].(string)
+	if !ok {
+		return append(a.flush(source), data)
+	}
+	record, ok := parseAuditRecord(text)
+	if !ok {
+		auditdInvalid.Add(1)
+		return append(a.flush(source), data)
+	}
+
+	var result []*util.Data
+	group := a.groups[source]
+	if group != nil && group.sequence != record.sequence {
+		result = a.flush(source)
+		group = nil
+	}
+	if group == nil {
+		group = &auditGroup{first: data, sequence: record.sequence}
+		a.groups[source] = group
+	}
+	group.last = data
+	group.lines = append(group.lines, text)
+	group.records = append(group.records, record)
+	group.updated = a.now()
+
+	if record.typ == "EOE" || (record.standalone && len(group.records) == 1) {
+		return append(result, a.flush(source)...)
+	}
+	return result
+}
+
+// flush 取出来源的重组事件，调用方需持有锁
+func (a *auditd) flush(source string) []*util.Data {
+	group, ok := a.groups[source]
+	if !ok {
+		return nil
+	}
+	delete(a.groups, source)
+	return []*util.Data{group.data()}
+}
+
+// expire 取出超过Timeout没有新记录的重组事件，all为true时取出全部
+func (a *auditd) expire(all bool) []*util.Data {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	var result []*util.Data
+	now := a.now()
+	for source, group := range a.groups {
+		if all || now.Sub(group.updated) >= a.config.Timeout {
+			if !all {
+				auditdFlushedTimeout.Add(1)
+			}
+			result = append(result, a.flush(source)...)
+		}
+	}
+	return result
+}
+
+// run 周期发送超时的重组事件，任务结束时退出
+func (a *auditd) run(done <-chan struct{}, handle func(*util.Data) bool) {
+	interval := a.config.Timeout / 2
+	if interval < 100*time.Millisecond {
+		interval = 100 * time.Millisecond
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			for _, data := range a.expire(false) {
+				handle(data)
+			}
+		}
+	}
+}
@@ -0,0 +1,94 @@
+// Tencent is pleased to support the open source community by making bkunifylogbeat 蓝鲸日志采集器 available.
+//
+// Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+//
+// bkunifylogbeat 蓝鲸日志采集器 is licensed under the MIT License.
+//
+// License for bkunifylogbeat 蓝鲸日志采集器:
+// --------------------------------------------------------------------
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+// documentation files (the "Software"), to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software,
+// and to permit persons to whom the Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all copies or substantial
+// portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+// LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN
+// NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+// WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+// SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package task
+
+import (
+	"testing"
+	"time"
+
+	"github.com/TencentBlueKing/bkunifylogbeat/config"
+	"github.com/TencentBlueKing/bkunifylogbeat/tests"
+	"github.com/elastic/beats/libbeat/common"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestParseAuditRecord: 测试审计记录头、引号字段、用户态msg及proctitle解码
+func TestParseAuditRecord(t *testing.T) {
+	record, ok := parseAuditRecord(`type=SYSCALL msg=audit(1364481363.243:24287): arch=c000003e syscall=2 success=no exit=-13 comm="cat" exe="/usr/bin/cat" key=(null)`)
+	assert.True(t, ok)
+	assert.Equal(t, "SYSCALL", record.typ)
+	assert.Equal(t, "24287", record.sequence)
+	assert.Equal(t, time.Unix(1364481363, 243000000), record.time)
+	assert.False(t, record.standalone)
+	assert.Equal(t, common.MapStr{
+		"arch": "c000003e", "syscall": "2", "success": "no", "exit": "-13",
+		"comm": "cat", "exe": "/usr/bin/cat", "key": "(null)",
+	}, record.fields)
+
+	record, ok = parseAuditRecord(`node=web1 type=USER_LOGIN msg=audit(1364481363.300:24300): pid=1 uid=0 msg='op=login acct="root" res=success'`)
+	assert.True(t, ok)
+	assert.Equal(t, "web1", record.node)
+	assert.True(t, record.standalone)
+	assert.Equal(t, common.MapStr{"pid": "1", "uid": "0", "op": "login", "acct": "root", "res": "success"}, record.fields)
+
+	record, _ = parseAuditRecord(`type=PROCTITLE msg=audit(1364481363.243:24287): proctitle=636174002F6574632F736861646F77`)
+	assert.Equal(t, "cat /etc/shadow", record.fields["proctitle"])
+
+	_, ok = parseAuditRecord("not an audit record")
+	assert.False(t, ok)
+}
+
+// TestAuditdReassemble: 测试多条记录按序号重组、EOE及超时发送
+func TestAuditdReassemble(t *testing.T) {
+	a := newAuditd(config.AuditdConfig{Timeout: time.Second})
+	now := time.Now()
+	a.now = func() time.Time { return now }
+
+	assert.Empty(t, a.feed(tests.MockLogEvent("/var/log/audit/audit.log", `type=SYSCALL msg=audit(1364481363.243:24287): syscall=2 comm="cat"`)))
+	assert.Empty(t, a.feed(tests.MockLogEvent("/var/log/audit/audit.log", `type=PATH msg=audit(1364481363.243:24287): item=0 name="/etc/shadow"`)))
+	result := a.feed(tests.MockLogEvent("/var/log/audit/audit.log", `type=EOE msg=audit(1364481363.243:24287): `))
+	assert.Len(t, result, 1)
+	audit := result[0].Event.Fields["audit"].(common.MapStr)
+	assert.Equal(t, int64(24287), audit["sequence"])
+	assert.Equal(t, "SYSCALL", audit["type"])
+	assert.Equal(t, []string{"SYSCALL", "PATH", "EOE"}, audit["types"])
+	assert.Equal(t, "/etc/shadow", audit["records"].([]common.MapStr)[1]["name"])
+	assert.Equal(t, time.Unix(1364481363, 243000000), result[0].Event.Timestamp)
+
+	// 用户态记录直接发送，新序号触发上一事件发送
+	result = a.feed(tests.MockLogEvent("/var/log/audit/audit.log", `type=USER_LOGIN msg=audit(1364481363.300:24300): pid=1 msg='op=login res=success'`))
+	assert.Len(t, result, 1)
+	assert.Empty(t, a.feed(tests.MockLogEvent("/var/log/audit/audit.log", `type=SYSCALL msg=audit(1364481364.000:24301): syscall=59`)))
+	result = a.feed(tests.MockLogEvent("/var/log/audit/audit.log", `type=SYSCALL msg=audit(1364481364.100:24302): syscall=59`))
+	assert.Len(t, result, 1)
+	assert.Equal(t, int64(24301), result[0].Event.Fields["audit"].(common.MapStr)["sequence"])
+
+	// 超时发送
+	assert.Empty(t, a.expire(false))
+	now = now.Add(time.Second)
+	assert.Len(t, a.expire(false), 1)
+
+	// 非审计格式的行原样发送
+	result = a.feed(tests.MockLogEvent("/var/log/audit/audit.log", "garbage"))
+	assert.Equal(t, "garbage", result[0].Event.Fields["data"])
+}
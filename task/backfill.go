@@ -0,0 +1,210 @@
+// Tencent is pleased to support the open source community by making bkunifylogbeat 蓝鲸日志采集器 available.
+//
+// Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+//
+// bkunifylogbeat 蓝鲸日志采集器 is licensed under the MIT License.
+//
+// License for bkunifylogbeat 蓝鲸日志采集器:
+// --------------------------------------------------------------------
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+// documentation files (the "Software"), to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software,
+// and to permit persons to whom the Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all copies or substantial
+// portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+// LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN
+// NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+// WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+// SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package task
+
+import (
+	"bufio"
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	cfg "github.com/TencentBlueKing/bkunifylogbeat/config"
+	"github.com/TencentBlueKing/collector-go-sdk/v2/bkbeat/bkmonitoring"
+	"github.com/TencentBlueKing/collector-go-sdk/v2/bkbeat/logp"
+	"github.com/elastic/beats/filebeat/input/file"
+	"github.com/elastic/beats/filebeat/util"
+	"github.com/elastic/beats/libbeat/beat"
+	"github.com/elastic/beats/libbeat/common"
+	commonFile "github.com/elastic/beats/libbeat/common/file"
+)
+
+// GzipFileStateType: 回填的.gz文件采集进度类型，Offset为解压后的字节数
+const GzipFileStateType = "gzip"
+
+var (
+	gzipBackfillFiles  = bkmonitoring.NewInt("gzip_backfill_files")
+	gzipBackfillFailed = bkmonitoring.NewInt("gzip_backfill_failed")
+)
+
+// gzipFile 待回填的文件
+type gzipFile struct {
+	path string
+	info os.FileInfo
+}
+
+// gzipBackfill: 按修改时间从旧到新读取一次.gz文件，按inode记录进度，轮转改名后不会重复读取
+type gzipBackfill struct {
+	config cfg.GzipBackfillConfig
+	states map[string]file.State
+	now    func() time.Time
+}
+
+func newGzipBackfill(config cfg.GzipBackfillConfig, lastStates []file.State) *gzipBackfill {
+	b := &gzipBackfill{
+		config: config,
+		states: make(map[string]file.State),
+		now:    time.Now,
+	}
+	for _, state := range lastStates {
+		if state.Type == GzipFileStateType {
+			b.states[state.Id] = state
+		}
+	}
+	return b
+}
+
+// files 返回匹配的文件，按修改时间从旧到新排序
+func (b *gzipBackfill) files() []gzipFile {
+	seen := make(map[string]bool)
+	var files []gzipFile
+	for _, pattern := range b.config.Paths {
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			logp.L.Errorf("gzip_backfill path(%s) is invalid, err=>%v", pattern, err)
+			continue
+		}
+		for _, path := range matches {
+			info, err := os.Stat(path)
+			if err != nil || !info.Mode().IsRegular() || seen[path] {
+				continue
+			}
+			if b.config.MaxAge > 0 && b.now().Sub(info.ModTime()) > b.config.MaxAge {
+				continue
+			}
+			seen[path] = true
+			files = append(files, gzipFile{path: path, info: info})
+		}
+	}
+	sort.SliceStable(files, func(i, j int) bool {
+		if files[i].info.ModTime().Equal(files[j].info.ModTime()) {
+			return files[i].path < files[j].path
+		}
+		return files[i].info.ModTime().Before(files[j].info.ModTime())
+	})
+	return files
+}
+
+// run 逐个回填文件，已读完的文件只重新发送进度以免被registrar清理；handle返回false时退出
+func (b *gzipBackfill) run(done <-chan struct{}, handle func(*util.Data) bool) {
+	for _, f := range b.files() {
+		select {
+		case <-done:
+			return
+		default:
+		}
+		osState := commonFile.GetOSState(f.info)
+		id := GzipFileStateType + "::" + osState.String()
+		state, ok := b.states[id]
+		if !ok {
+			state = file.State{Id: id, Type: GzipFileStateType, FileStateOS: osState}
+		}
+		state.Source = f.path
+		state.TTL = -1
+		state.Finished = false
+		if state.Meta["Done"] == "true" {
+			if !b.sendState(state, handle) {
+				return
+			}
+			continue
+		}
+		gzipBackfillFiles.Add(1)
+		if !b.read(f.path, state, done, handle) {
+			return
+		}
+	}
+}
+
+// read 从state.Offset继续读取文件，每行一个事件，读完后发送Done进度
+func (b *gzipBackfill) read(path string, state file.State, done <-chan struct{}, handle func(*util.Data) bool) bool {
+	f, err := os.Open(path)
+	if err != nil {
+		gzipBackfillFailed.Add(1)
+		logp.L.Errorf("open %s failed, err=>%v", path, err)
+		return true
+	}
+	defer f.Close()
+	gz, err := gzip.NewReader(bufio.NewReader(f))
+	if err != nil {
+		gzipBackfillFailed.Add(1)
+		logp.L.Errorf("read %s failed, err=>%v", path, err)
+		return true
+	}
+	defer gz.Close()
+	if state.Offset > 0 {
+		if _, err = io.CopyN(ioutil.Discard, gz, state.Offset); err != nil {
+			gzipBackfillFailed.Add(1)
+			logp.L.Errorf("seek %s to %d failed, err=>%v", path, state.Offset, err)
+			return true
+		}
+	}
+
+	reader := bufio.NewReader(gz)
+	for {
+		select {
+		case <-done:
+			return false
+		default:
+		}
+		line, err := reader.ReadString('\n')
+		if len(line) > 0 {
+			state.Offset += int64(len(line))
+			state.Timestamp = b.now()
+			text := strings.TrimRight(line, "\r\n")
+			if text != "" {
+				data := util.NewData()
+				data.SetState(state)
+				data.Event = beat.Event{
+					Timestamp: b.now(),
+					Fields:    common.MapStr{"data": text},
+				}
+				if !handle(data) {
+					return false
+				}
+			}
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			gzipBackfillFailed.Add(1)
+			logp.L.Errorf("read %s failed, err=>%v", path, err)
+			return true
+		}
+	}
+	state.Finished = true
+	state.Meta = map[string]string{"Done": "true"}
+	return b.sendState(state, handle)
+}
+
+// sendState 发送只有采集进度的事件
+func (b *gzipBackfill) sendState(state file.State, handle func(*util.Data) bool) bool {
+	state.Timestamp = b.now()
+	data := util.NewData()
+	data.SetState(state)
+	return handle(data)
+}
@@ -0,0 +1,94 @@
+// Tencent is pleased to support the open source community by making bkunifylogbeat 蓝鲸日志采集器 available.
+//
+// Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+//
+// bkunifylogbeat 蓝鲸日志采集器 is licensed under the MIT License.
+//
+// License for bkunifylogbeat 蓝鲸日志采集器:
+// --------------------------------------------------------------------
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+// documentation files (the "Software"), to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software,
+// and to permit persons to whom the Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all copies or substantial
+// portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+// LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN
+// NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+// WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+// SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package task
+
+import (
+	"compress/gzip"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	cfg "github.com/TencentBlueKing/bkunifylogbeat/config"
+	"github.com/elastic/beats/filebeat/input/file"
+	"github.com/elastic/beats/filebeat/util"
+	"github.com/stretchr/testify/assert"
+)
+
+// writeGzip: 写入gzip文件并设置修改时间
+func writeGzip(t *testing.T, path, content string, modTime time.Time) {
+	f, err := os.Create(path)
+	assert.NoError(t, err)
+	w := gzip.NewWriter(f)
+	_, err = w.Write([]byte(content))
+	assert.NoError(t, err)
+	assert.NoError(t, w.Close())
+	assert.NoError(t, f.Close())
+	assert.NoError(t, os.Chtimes(path, modTime, modTime))
+}
+
+// TestGzipBackfill: 测试按修改时间从旧到新读取、断点续读及已完成文件只发送进度
+func TestGzipBackfill(t *testing.T) {
+	dir, err := ioutil.TempDir("", "backfill")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	now := time.Now()
+	writeGzip(t, filepath.Join(dir, "app.log.1.gz"), "c\n", now.Add(-time.Hour))
+	writeGzip(t, filepath.Join(dir, "app.log.2.gz"), "a\r\n\nb", now.Add(-2*time.Hour))
+	writeGzip(t, filepath.Join(dir, "app.log.9.gz"), "too old\n", now.Add(-48*time.Hour))
+
+	config := cfg.GzipBackfillConfig{Enabled: true, Paths: []string{filepath.Join(dir, "*.gz")}, MaxAge: 24 * time.Hour}
+	var events []*util.Data
+	handle := func(data *util.Data) bool {
+		events = append(events, data)
+		return true
+	}
+	newGzipBackfill(config, nil).run(make(chan struct{}), handle)
+
+	var texts []string
+	var states []file.State
+	for _, data := range events {
+		if data.Event.Fields != nil {
+			texts = append(texts, data.Event.Fields["data"].(string))
+		} else {
+			states = append(states, data.GetState())
+		}
+	}
+	assert.Equal(t, []string{"a", "b", "c"}, texts)
+	assert.Len(t, states, 2)
+	assert.Equal(t, GzipFileStateType, states[0].Type)
+	assert.Equal(t, "true", states[0].Meta["Done"])
+	assert.Equal(t, int64(5), states[0].Offset)
+	assert.Equal(t, filepath.Join(dir, "app.log.2.gz"), events[1].GetState().Source)
+	assert.Equal(t, int64(3), events[0].GetState().Offset)
+
+	// 第一个文件已完成，第二个文件从offset继续
+	partial := events[len(events)-2].GetState()
+	partial.Offset = 0
+	events = nil
+	newGzipBackfill(config, []file.State{states[0], partial}).run(make(chan struct{}), handle)
+	assert.Len(t, events, 3)
+	assert.Nil(t, events[0].Event.Fields)
+	assert.Equal(t, "c", events[1].Event.Fields["data"])
+}
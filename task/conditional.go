@@ -0,0 +1,101 @@
+// Tencent is pleased to support the open source community by making bkunifylogbeat 蓝鲸日志采集器 available.
+//
+// Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+//
+// bkunifylogbeat 蓝鲸日志采集器 is licensed under the MIT License.
+//
+// License for bkunifylogbeat 蓝鲸日志采集器:
+// --------------------------------------------------------------------
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+// documentation files (the "Software"), to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software,
+// and to permit persons to whom the Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all copies or substantial
+// portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+// LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN
+// NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+// WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+// SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package task
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/TencentBlueKing/bkunifylogbeat/config"
+	"github.com/elastic/beats/libbeat/beat"
+	process "github.com/elastic/beats/libbeat/processors"
+)
+
+// conditionalProcessor: 编译后的条件处理器
+type conditionalProcessor struct {
+	path     *regexp.Regexp
+	filter   *filter
+	maxIndex int
+	then     *process.Processors
+	orElse   *process.Processors
+}
+
+// newConditionalProcessors: 编译条件处理器，条件与过滤条件共用编译逻辑
+func newConditionalProcessors(taskConfig *config.TaskConfig, done <-chan struct{}) ([]*conditionalProcessor, error) {
+	result := make([]*conditionalProcessor, 0, len(taskConfig.ConditionalProcessors))
+	for i, c := range taskConfig.ConditionalProcessors {
+		p := &conditionalProcessor{maxIndex: maxIndexOf(c.If.Conditions)}
+		if p.maxIndex < c.If.MinColumnCount {
+			p.maxIndex = c.If.MinColumnCount
+		}
+		var err error
+		if p.path, err = c.PathPattern(); err != nil {
+			return nil, fmt.Errorf("conditional_processors[%d] path is not valid, err=>%v", i, err)
+		}
+		if p.filter, err = newFilter(taskConfig, c.If, done); err != nil {
+			return nil, fmt.Errorf("conditional_processors[%d] if is not valid, err=>%v", i, err)
+		}
+		if p.filter.expr != nil && p.maxIndex < p.filter.expr.MaxColumn() {
+			p.maxIndex = p.filter.expr.MaxColumn()
+		}
+		if p.then, err = process.New(c.Then); err != nil {
+			return nil, fmt.Errorf("conditional_processors[%d] then is not valid, err=>%v", i, err)
+		}
+		if len(c.Else) > 0 {
+			if p.orElse, err = process.New(c.Else); err != nil {
+				return nil, fmt.Errorf("conditional_processors[%d] else is not valid, err=>%v", i, err)
+			}
+		}
+		result = append(result, p)
+	}
+	return result, nil
+}
+
+// matches: 来源文件及日志内容是否满足条件，data不是字符串时只有不含条件的If视为满足
+func (p *conditionalProcessor) matches(client *Processors, event *beat.Event, source string) bool {
+	if p.path != nil && !p.path.MatchString(source) {
+		return false
+	}
+	if len(p.filter.conditions) == 0 && p.filter.expr == nil && p.filter.minColumnCount == 0 {
+		return true
+	}
+	text, ok := event.Fields["data"].(string)
+	if !ok {
+		return false
+	}
+	l := client.splitLine(text, p.maxIndex)
+	l.fields = event.Fields
+	return p.filter.match(l)
+}
+
+// run: 按条件执行then或else，返回nil时丢弃事件
+func (p *conditionalProcessor) run(client *Processors, event *beat.Event, source string) *beat.Event {
+	chain := p.orElse
+	if p.matches(client, event, source) {
+		chain = p.then
+	}
+	if chain == nil {
+		return event
+	}
+	return chain.Run(event)
+}
@@ -0,0 +1,182 @@
+// Tencent is pleased to support the open source community by making bkunifylogbeat 蓝鲸日志采集器 available.
+//
+// Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+//
+// bkunifylogbeat 蓝鲸日志采集器 is licensed under the MIT License.
+//
+// License for bkunifylogbeat 蓝鲸日志采集器:
+// --------------------------------------------------------------------
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+// documentation files (the "Software"), to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software,
+// and to permit persons to whom the Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all copies or substantial
+// portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+// LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN
+// NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+// WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+// SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package task
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	cfg "github.com/TencentBlueKing/bkunifylogbeat/config"
+	"github.com/TencentBlueKing/collector-go-sdk/v2/bkbeat/bkmonitoring"
+	"github.com/elastic/beats/filebeat/util"
+	"github.com/elastic/beats/libbeat/common"
+)
+
+// containerMetaTTL: 容器元数据的缓存时间，容器改名或重建后在该时间内生效
+const containerMetaTTL = time.Minute
+
+var (
+	containerDecodeFailed = bkmonitoring.NewInt("container_decode_failed")
+)
+
+// containerMeta: 容器元数据，读取自容器目录下的config.v2.json
+type containerMeta struct {
+	ID     string
+	Name   string
+	Image  string
+	Labels map[string]string
+	loaded time.Time
+}
+
+// containers: docker类型任务的json-file日志处理，解析日志信封、按容器选择条件过滤并附加容器元数据
+type containers struct {
+	config cfg.ContainersConfig
+	mutex  sync.Mutex
+	cache  map[string]*containerMeta
+	now    func() time.Time
+}
+
+func newContainers(config cfg.ContainersConfig) *containers {
+	return &containers{
+		config: config,
+		cache:  make(map[string]*containerMeta),
+		now:    time.Now,
+	}
+}
+
+// meta 获取容器元数据，config.v2.json读取失败时只有ID
+func (c *containers) meta(id string) *containerMeta {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	now := c.now()
+	if m, ok := c.cache[id]; ok && now.Sub(m.loaded) < containerMetaTTL {
+		return m
+	}
+
+	m := &containerMeta{ID: id, loaded: now}
+	content, err := ioutil.ReadFile(filepath.Join(c.config.Path, id, "config.v2.json"))
+	if err == nil {
+		v := struct {
+			Name   string
+			Config struct {
+				Image  string
+				Labels map[string]string
+			}
+		}{}
+		if json.Unmarshal(content, &v) == nil {
+			m.Name = strings.TrimPrefix(v.Name, "/")
+			m.Image = v.Config.Image
+			m.Labels = v.Config.Labels
+		}
+	}
+	c.cache[id] = m
+	return m
+}
+
+// match 容器是否满足选择条件
+func (c *containers) match(m *containerMeta) bool {
+	if len(c.config.IDs) > 0 {
+		matched := false
+		for _, id := range c.config.IDs {
+			if strings.HasPrefix(m.ID, id) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	if len(c.config.Names) > 0 {
+		matched := false
+		for _, name := range c.config.Names {
+			if m.Name == strings.TrimPrefix(name, "/") {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	for key, value := range c.config.Labels {
+		if v, ok := m.Labels[key]; !ok || v != value {
+			return false
+		}
+	}
+	return true
+}
+
+// decode 解析json-file日志信封，data替换为日志内容，并附加stream及container字段；
+// 返回false表示容器或输出流不满足选择条件，事件需要丢弃
+func (c *containers) decode(data *util.Data) bool {
+	event := &data.Event
+	if event.Fields == nil {
+		return true
+	}
+	m := c.meta(filepath.Base(filepath.Dir(data.GetState().Source)))
+	if !c.match(m) {
+		return false
+	}
+
+	text, _ := event.Fields["data"].(string)
+	entry := struct {
+		Log    string    `json:"log"`
+		Stream string    `json:"stream"`
+		Time   time.Time `json:"time"`
+	}{}
+	if err := json.Unmarshal([]byte(text), &entry); err != nil {
+		// 非json-file格式的内容原样保留
+		containerDecodeFailed.Add(1)
+		return true
+	}
+	if c.config.Stream != "all" && c.config.Stream != entry.Stream {
+		return false
+	}
+	event.Fields["data"] = strings.TrimSuffix(entry.Log, "\n")
+	event.Fields["stream"] = entry.Stream
+	if !entry.Time.IsZero() {
+		event.Timestamp = entry.Time
+	}
+
+	container := common.MapStr{"id": m.ID}
+	if m.Name != "" {
+		container["name"] = m.Name
+	}
+	if m.Image != "" {
+		container["image"] = m.Image
+	}
+	if len(m.Labels) > 0 {
+		labels := common.MapStr{}
+		for key, value := range m.Labels {
+			labels[key] = value
+		}
+		container["labels"] = labels
+	}
+	event.Fields["container"] = container
+	return true
+}
@@ -0,0 +1,98 @@
+// Tencent is pleased to support the open source community by making bkunifylogbeat 蓝鲸日志采集器 available.
+//
+// Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+//
+// bkunifylogbeat 蓝鲸日志采集器 is licensed under the MIT License.
+//
+// License for bkunifylogbeat 蓝鲸日志采集器:
+// --------------------------------------------------------------------
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+// documentation files (the "Software"), to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software,
+// and to permit persons to whom the Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all copies or substantial
+// portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+// LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN
+// NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+// WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+// SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package task
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	cfg "github.com/TencentBlueKing/bkunifylogbeat/config"
+	"github.com/TencentBlueKing/bkunifylogbeat/tests"
+	"github.com/elastic/beats/libbeat/common"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestContainers: 测试json-file日志信封解析、容器选择条件及元数据
+func TestContainers(t *testing.T) {
+	dir, err := ioutil.TempDir("", "containers")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	for id, meta := range map[string]string{
+		"abc123": `{"Name":"/web","Config":{"Image":"nginx:1.19","Labels":{"app":"web"}}}`,
+		"def456": `{"Name":"/db","Config":{"Image":"mysql:5.7","Labels":{"app":"db"}}}`,
+	} {
+		assert.NoError(t, os.MkdirAll(filepath.Join(dir, id), 0755))
+		assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, id, "config.v2.json"), []byte(meta), 0644))
+	}
+	c := newContainers(cfg.ContainersConfig{Path: dir, Labels: map[string]string{"app": "web"}, Stream: "stdout"})
+
+	data := tests.MockLogEvent(filepath.Join(dir, "abc123", "abc123-json.log"),
+		`{"log":"GET / 200\n","stream":"stdout","time":"2021-06-01T10:00:00.000000001Z"}`)
+	assert.True(t, c.decode(data))
+	assert.Equal(t, "GET / 200", data.Event.Fields["data"])
+	assert.Equal(t, "stdout", data.Event.Fields["stream"])
+	assert.Equal(t, time.Date(2021, 6, 1, 10, 0, 0, 1, time.UTC), data.Event.Timestamp)
+	assert.Equal(t, common.MapStr{
+		"id":     "abc123",
+		"name":   "web",
+		"image":  "nginx:1.19",
+		"labels": common.MapStr{"app": "web"},
+	}, data.Event.Fields["container"])
+
+	// 输出流不匹配
+	data = tests.MockLogEvent(filepath.Join(dir, "abc123", "abc123-json.log"), `{"log":"oops\n","stream":"stderr"}`)
+	assert.False(t, c.decode(data))
+
+	// 标签不匹配
+	data = tests.MockLogEvent(filepath.Join(dir, "def456", "def456-json.log"), `{"log":"ready\n","stream":"stdout"}`)
+	assert.False(t, c.decode(data))
+
+	// 按名称选择
+	c = newContainers(cfg.ContainersConfig{Path: dir, Names: []string{"db"}, Stream: "all"})
+	assert.True(t, c.decode(data))
+	assert.Equal(t, "ready", data.Event.Fields["data"])
+}
+
+// TestContainersPaths: 测试docker类型任务按容器ID生成采集路径
+func TestContainersPaths(t *testing.T) {
+	config, err := cfg.CreateTaskConfig(map[string]interface{}{
+		"dataid": "999990001",
+		"type":   "docker",
+		"containers": map[string]interface{}{
+			"ids": []string{"abc"},
+		},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "docker", config.Type)
+	assert.Equal(t, []string{"/var/lib/docker/containers/abc*/*-json.log"}, config.Containers.Paths())
+
+	_, err = cfg.CreateTaskConfig(map[string]interface{}{
+		"dataid":     "999990001",
+		"type":       "docker",
+		"containers": map[string]interface{}{"stream": "both"},
+	})
+	assert.Error(t, err)
+}
@@ -0,0 +1,189 @@
+// Tencent is pleased to support the open source community by making bkunifylogbeat 蓝鲸日志采集器 available.
+//
+// Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+//
+// bkunifylogbeat 蓝鲸日志采集器 is licensed under the MIT License.
+//
+// License for bkunifylogbeat 蓝鲸日志采集器:
+// --------------------------------------------------------------------
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+// documentation files (the "Software"), to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software,
+// and to permit persons to whom the Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all copies or substantial
+// portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+// LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN
+// NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+// WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+// SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package task
+
+import (
+	"fmt"
+
+	"github.com/TencentBlueKing/bkunifylogbeat/config"
+	"github.com/elastic/beats/libbeat/common"
+)
+
+// DryRunResult: 过滤条件试运行结果，任意条件组满足时日志会被保留
+type DryRunResult struct {
+	Matched bool          `json:"matched"`
+	Filters []DryRunGroup `json:"filters"`
+}
+
+// DryRunGroup: 单个条件组的试运行结果
+type DryRunGroup struct {
+	Matched    bool              `json:"matched"`
+	Reason     string            `json:"reason"`
+	Conditions []DryRunCondition `json:"conditions"`
+}
+
+// DryRunCondition: 单个条件的试运行结果，Value为参与比较的内容，嵌套条件组的子条件在Children中
+type DryRunCondition struct {
+	Index    int               `json:"index"`
+	Key      string            `json:"key"`
+	Op       string            `json:"op"`
+	Value    string            `json:"value"`
+	Matched  bool              `json:"matched"`
+	Skipped  bool              `json:"skipped"`
+	Reason   string            `json:"reason"`
+	Children []DryRunCondition `json:"children,omitempty"`
+}
+
+// DryRunFilter 使用任务的过滤配置对样例日志求值，逐个返回条件组及条件的匹配情况，不影响运行中的任务
+// 所有条件都会求值，不做短路；有状态的条件(如window_agg_gt)只基于本次样例计算
+func DryRunFilter(taskConfig *config.TaskConfig, text string) (*DryRunResult, error) {
+	if !taskConfig.HasFilter {
+		return nil, fmt.Errorf("task has no filter to dry run")
+	}
+	// 只编译过滤条件及切分规则，不创建限速、去重、processors等与过滤无关的运行时状态
+	set, err := newFilterSet(taskConfig)
+	if err != nil {
+		return nil, err
+	}
+	defer close(set.done)
+	client := &Processors{taskConfig: taskConfig}
+	if pattern := taskConfig.SplitPattern(); pattern != "" {
+		client.splitClass, err = compileRegex(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("compile split pattern failed, err=>%v", err)
+		}
+	}
+
+	l := client.splitLine(text, set.maxIndex)
+	l.fields = common.MapStr{"data": text}
+	result := &DryRunResult{Filters: make([]DryRunGroup, 0, len(set.filters))}
+	for i, f := range set.filters {
+		item := DryRunGroup{Matched: true, Conditions: make([]DryRunCondition, 0, len(f.conditions))}
+		if len(l.words) < f.minColumnCount {
+			item.Matched = false
+			item.Reason = fmt.Sprintf("column count(%d) is less than min_column_count(%d)", len(l.words), f.minColumnCount)
+		}
+		for j, c := range f.conditions {
+			dc := c.dryRun(l)
+			if !dc.Skipped && !dc.Matched && item.Matched {
+				item.Matched = false
+				item.Reason = fmt.Sprintf("condition(%d) not matched", j)
+			}
+			item.Conditions = append(item.Conditions, dc)
+		}
+		if f.expr != nil {
+			dc := DryRunCondition{Op: "expr", Key: taskConfig.Filters[i].Expr, Matched: f.expr.Eval(l)}
+			if dc.Matched {
+				dc.Reason = "matched"
+			} else {
+				dc.Reason = "not matched"
+				if item.Matched {
+					item.Matched = false
+					item.Reason = "expr not matched"
+				}
+			}
+			item.Conditions = append(item.Conditions, dc)
+		}
+		if item.Matched {
+			item.Reason = "all conditions matched"
+			result.Matched = true
+		}
+		result.Filters = append(result.Filters, item)
+	}
+	return result, nil
+}
+
+// dryRun: 对单个条件求值并记录参与比较的内容及原因，不更新soft_fail等指标
+func (condition *condition) dryRun(l *line) DryRunCondition {
+	dc := DryRunCondition{Index: condition.Index, Key: condition.Key, Op: condition.Op}
+	if condition.children != nil {
+		// 与matchGroup语义一致，跳过的子条件不参与判断，any组内子条件全部跳过时视为满足
+		evaluated, decided := false, false
+		for _, child := range condition.children {
+			childResult := child.dryRun(l)
+			dc.Children = append(dc.Children, childResult)
+			if childResult.Skipped || decided {
+				continue
+			}
+			evaluated = true
+			if childResult.Matched == condition.any {
+				decided = true
+			}
+		}
+		matched := !condition.any || !evaluated
+		if decided {
+			matched = condition.any
+		}
+		dc.Matched = matched != condition.Negate
+		if condition.any {
+			dc.Reason = "any group"
+		} else {
+			dc.Reason = "all group"
+		}
+		if condition.Negate {
+			dc.Reason += " (negate)"
+		}
+		return dc
+	}
+
+	switch {
+	case condition.IsFieldCondition():
+		// 按事件字段判断，没有参与比较的内容
+	case condition.jsonPath != nil:
+		value, exist := lookupJSON(l.json, condition.jsonPath)
+		if !exist {
+			dc.Reason = fmt.Sprintf("path(%s) not found", condition.Path)
+		}
+		dc.Value = value
+	case condition.logfmtKey != "":
+		value, exist := l.pairs[condition.logfmtKey]
+		if !exist {
+			dc.Reason = fmt.Sprintf("logfmt key(%s) not found", condition.logfmtKey)
+		}
+		dc.Value = value
+	case condition.Index <= 0:
+		dc.Value = l.text
+	case len(l.words) < condition.Index:
+		if condition.SoftFail {
+			dc.Skipped = true
+			dc.Reason = fmt.Sprintf("soft_fail: index(%d) out of range(%d)", condition.Index, len(l.words))
+			return dc
+		}
+		dc.Reason = fmt.Sprintf("index(%d) out of range(%d)", condition.Index, len(l.words))
+	default:
+		dc.Value = normalizeWord(l.words[condition.Index-1], condition.ConditionConfig)
+	}
+
+	dc.Matched = condition.match(l)
+	if dc.Reason == "" {
+		if dc.Matched {
+			dc.Reason = "matched"
+		} else {
+			dc.Reason = "not matched"
+		}
+	}
+	if condition.Negate {
+		dc.Reason += " (negate)"
+	}
+	return dc
+}
@@ -0,0 +1,338 @@
+// Tencent is pleased to support the open source community by making bkunifylogbeat 蓝鲸日志采集器 available.
+//
+// Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+//
+// bkunifylogbeat 蓝鲸日志采集器 is licensed under the MIT License.
+//
+// License for bkunifylogbeat 蓝鲸日志采集器:
+// --------------------------------------------------------------------
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+// documentation files (the "Software"), to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software,
+// and to permit persons to whom the Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all copies or substantial
+// portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+// LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN
+// NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+// WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+// SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package task
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	cfg "github.com/TencentBlueKing/bkunifylogbeat/config"
+	"github.com/TencentBlueKing/collector-go-sdk/v2/bkbeat/beat"
+	"github.com/TencentBlueKing/collector-go-sdk/v2/bkbeat/bkmonitoring"
+	"github.com/TencentBlueKing/collector-go-sdk/v2/bkbeat/logp"
+	"github.com/elastic/beats/libbeat/common/fmtstr"
+	"github.com/elastic/beats/libbeat/common/transport/tlscommon"
+	"github.com/elastic/beats/libbeat/monitoring"
+)
+
+const esMinBackoff = 1 * time.Second
+
+var (
+	senderESSentTotal  = bkmonitoring.NewInt("sender_es_sent_total")
+	senderESErrorTotal = bkmonitoring.NewInt("sender_es_error_total")
+)
+
+// esEntry: 打包事件展开后的bulk请求行，docs为空表示仅需更新采集状态
+type esEntry struct {
+	state interface{}
+	docs  [][]byte
+}
+
+// esBulkItem: bulk响应中单个文档的结果
+type esBulkItem struct {
+	Status int             `json:"status"`
+	Error  json.RawMessage `json:"error"`
+}
+
+// esBulkResponse: bulk接口响应
+type esBulkResponse struct {
+	Errors bool                    `json:"errors"`
+	Items  []map[string]esBulkItem `json:"items"`
+}
+
+// esOutput: 替代beat.SendEvent将打包事件按日志展开后通过bulk接口写入Elasticsearch
+// 整批写入成功后才按发送顺序提交采集状态；429及5xx整批或按文档退避重试，其余文档错误丢弃并计入sender_es_error_total
+type esOutput struct {
+	taskID     string
+	config     cfg.ElasticsearchOutputConfig
+	index      *fmtstr.EventFormatString
+	client     *http.Client
+	hosts      []string
+	hostIndex  int
+	ack        PublisherFunc
+	input      chan *esEntry
+	done       <-chan struct{}
+	sentTotal  *monitoring.Int
+	errorTotal *monitoring.Int
+}
+
+// newESOutput 生成Elasticsearch输出实例，ack用于在写入成功后提交采集状态
+func newESOutput(taskConfig *cfg.TaskConfig, done <-chan struct{}, ack PublisherFunc) (*esOutput, error) {
+	config := taskConfig.ElasticsearchOutput
+	index, err := fmtstr.CompileEvent(config.Index)
+	if err != nil {
+		return nil, fmt.Errorf("compile elasticsearch_output index failed, err=>%v", err)
+	}
+	transport := &http.Transport{Proxy: http.ProxyFromEnvironment}
+	scheme := "http://"
+	if config.TLS != nil {
+		tlsConfig, err := tlscommon.LoadTLSConfig(config.TLS)
+		if err != nil {
+			return nil, fmt.Errorf("load elasticsearch_output tls config failed, err=>%v", err)
+		}
+		if tlsConfig != nil {
+			transport.TLSClientConfig = tlsConfig.BuildModuleConfig("")
+			scheme = "https://"
+		}
+	}
+	hosts := make([]string, 0, len(config.Hosts))
+	for _, host := range config.Hosts {
+		if !strings.Contains(host, "://") {
+			host = scheme + host
+		}
+		hosts = append(hosts, strings.TrimRight(host, "/"))
+	}
+	output := &esOutput{
+		taskID:     taskConfig.ID,
+		config:     config,
+		index:      index,
+		client:     &http.Client{Transport: transport, Timeout: config.Timeout},
+		hosts:      hosts,
+		ack:        ack,
+		input:      make(chan *esEntry),
+		done:       done,
+		sentTotal:  newIntWithDataID(taskConfig.DataID, "sender_es_sent_total"),
+		errorTotal: newIntWithDataID(taskConfig.DataID, "sender_es_error_total"),
+	}
+	go output.run()
+	return output, nil
+}
+
+// publish 作为Sender的PublisherFunc，任务停止时返回false
+func (o *esOutput) publish(event beat.Event) bool {
+	entry := &esEntry{state: event.Private}
+	if event.Fields != nil {
+		timestamp := event.Timestamp
+		if timestamp.IsZero() {
+			timestamp = time.Now()
+		}
+		for _, doc := range esDocuments(event.Fields, timestamp) {
+			line, err := o.encode(doc, timestamp)
+			if err != nil {
+				logp.L.Errorf("encode elasticsearch document failed, task_id:%s, err=>%v", o.taskID, err)
+				o.addError(1)
+				continue
+			}
+			entry.docs = append(entry.docs, line)
+		}
+	}
+	select {
+	case <-o.done:
+		return false
+	case o.input <- entry:
+		return true
+	}
+}
+
+// esDocuments: 每条日志生成一个文档，并带上打包事件的公共字段；非默认格式没有items时整包作为一个文档
+func esDocuments(fields beat.MapStr, timestamp time.Time) []beat.MapStr {
+	items, ok := fields["items"].([]beat.MapStr)
+	if !ok {
+		items = []beat.MapStr{nil}
+	}
+	docs := make([]beat.MapStr, 0, len(items))
+	for _, item := range items {
+		doc := beat.MapStr{"@timestamp": timestamp.UTC().Format(time.RFC3339Nano)}
+		for key, value := range fields {
+			if key != "items" || !ok {
+				doc[key] = value
+			}
+		}
+		for key, value := range item {
+			doc[key] = value
+		}
+		docs = append(docs, doc)
+	}
+	return docs
+}
+
+// encode: 生成文档的bulk请求行，索引名按模板计算并转为小写
+func (o *esOutput) encode(doc beat.MapStr, timestamp time.Time) ([]byte, error) {
+	index, err := o.index.Run(&beat.Event{Timestamp: timestamp, Fields: doc})
+	if err != nil {
+		return nil, err
+	}
+	action, err := json.Marshal(map[string]interface{}{
+		"index": map[string]string{"_index": strings.ToLower(index)},
+	})
+	if err != nil {
+		return nil, err
+	}
+	source, err := json.Marshal(doc)
+	if err != nil {
+		return nil, err
+	}
+	line := make([]byte, 0, len(action)+len(source)+2)
+	line = append(append(line, action...), '\n')
+	line = append(append(line, source...), '\n')
+	return line, nil
+}
+
+// run 按bulk_max_size或flush_interval批量写入，写入成功后按顺序提交采集状态
+func (o *esOutput) run() {
+	ticker := time.NewTicker(o.config.FlushInterval)
+	defer ticker.Stop()
+
+	var (
+		batch []*esEntry
+		docs  [][]byte
+	)
+	flush := func() bool {
+		if len(docs) > 0 && !o.bulk(docs) {
+			return false
+		}
+		for _, entry := range batch {
+			o.ack(beat.Event{Fields: nil, Private: entry.state})
+		}
+		batch, docs = nil, nil
+		return true
+	}
+
+	for {
+		select {
+		case <-o.done:
+			logp.L.Infof("elasticsearch output quit, task_id:%s", o.taskID)
+			return
+		case entry := <-o.input:
+			batch = append(batch, entry)
+			docs = append(docs, entry.docs...)
+			if len(docs) >= o.config.BulkMaxSize && !flush() {
+				return
+			}
+		case <-ticker.C:
+			if len(batch) > 0 && !flush() {
+				return
+			}
+		}
+	}
+}
+
+// bulk 写入直到全部文档成功或被丢弃，任务停止时返回false
+func (o *esOutput) bulk(docs [][]byte) bool {
+	backoff := esMinBackoff
+	if backoff > o.config.MaxBackoff {
+		backoff = o.config.MaxBackoff
+	}
+	for {
+		retry, err := o.send(docs)
+		if err != nil {
+			o.addError(len(docs))
+			logp.L.Errorf("elasticsearch bulk request failed, task_id:%s, host:%s, retry in %s, err=>%v",
+				o.taskID, o.hosts[o.hostIndex], backoff, err)
+			o.hostIndex = (o.hostIndex + 1) % len(o.hosts)
+			retry = docs
+		}
+		if len(retry) == 0 {
+			return true
+		}
+		docs = retry
+		select {
+		case <-o.done:
+			return false
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+		if backoff > o.config.MaxBackoff {
+			backoff = o.config.MaxBackoff
+		}
+	}
+}
+
+// send 发送一次bulk请求，返回需要重试(429)的文档；请求失败、429及5xx时返回错误，整批重试
+func (o *esOutput) send(docs [][]byte) ([][]byte, error) {
+	req, err := http.NewRequest(http.MethodPost, o.hosts[o.hostIndex]+"/_bulk", bytes.NewReader(bytes.Join(docs, nil)))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-ndjson")
+	if o.config.APIKey != "" {
+		req.Header.Set("Authorization", "ApiKey "+o.config.APIKey)
+	} else if o.config.Username != "" {
+		req.SetBasicAuth(o.config.Username, o.config.Password)
+	}
+
+	resp, err := o.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("status=>%d, body=>%s", resp.StatusCode, truncateBody(body))
+	}
+
+	result := esBulkResponse{}
+	if err = json.Unmarshal(body, &result); err != nil {
+		return nil, fmt.Errorf("decode bulk response failed, err=>%v", err)
+	}
+	if !result.Errors {
+		o.addSent(len(docs))
+		return nil, nil
+	}
+
+	var retry [][]byte
+	for i, item := range result.Items {
+		if i >= len(docs) {
+			break
+		}
+		for _, status := range item {
+			switch {
+			case status.Status == http.StatusTooManyRequests:
+				retry = append(retry, docs[i])
+			case status.Status >= 300:
+				o.addError(1)
+				logp.L.Errorf("elasticsearch document dropped, task_id:%s, status:%d, err=>%s",
+					o.taskID, status.Status, status.Error)
+			default:
+				o.addSent(1)
+			}
+		}
+	}
+	return retry, nil
+}
+
+// truncateBody: 错误日志中只保留响应体的前1KB
+func truncateBody(body []byte) string {
+	if len(body) > 1024 {
+		body = body[:1024]
+	}
+	return string(body)
+}
+
+func (o *esOutput) addSent(n int) {
+	o.sentTotal.Add(int64(n))
+	senderESSentTotal.Add(int64(n))
+}
+
+func (o *esOutput) addError(n int) {
+	o.errorTotal.Add(int64(n))
+	senderESErrorTotal.Add(int64(n))
+}
@@ -0,0 +1,122 @@
+// Tencent is pleased to support the open source community by making bkunifylogbeat 蓝鲸日志采集器 available.
+//
+// Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+//
+// bkunifylogbeat 蓝鲸日志采集器 is licensed under the MIT License.
+//
+// License for bkunifylogbeat 蓝鲸日志采集器:
+// --------------------------------------------------------------------
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+// documentation files (the "Software"), to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software,
+// and to permit persons to whom the Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all copies or substantial
+// portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+// LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN
+// NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+// WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+// SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package task
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	cfg "github.com/TencentBlueKing/bkunifylogbeat/config"
+	"github.com/TencentBlueKing/collector-go-sdk/v2/bkbeat/beat"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestESDocuments: 每条日志展开为一个文档，并带上打包事件的公共字段
+func TestESDocuments(t *testing.T) {
+	ts := time.Date(2021, 3, 4, 5, 6, 7, 0, time.UTC)
+	docs := esDocuments(beat.MapStr{
+		"dataid": 1,
+		"items":  []beat.MapStr{{"data": "a"}, {"data": "b"}},
+	}, ts)
+	assert.Equal(t, []beat.MapStr{
+		{"@timestamp": "2021-03-04T05:06:07Z", "dataid": 1, "data": "a"},
+		{"@timestamp": "2021-03-04T05:06:07Z", "dataid": 1, "data": "b"},
+	}, docs)
+
+	docs = esDocuments(beat.MapStr{"data": "c"}, ts)
+	assert.Equal(t, []beat.MapStr{{"@timestamp": "2021-03-04T05:06:07Z", "data": "c"}}, docs)
+}
+
+// TestESOutputRetry: 429的文档退避后重试，全部写入后提交采集状态
+func TestESOutputRetry(t *testing.T) {
+	var (
+		mutex    sync.Mutex
+		requests int
+		indexed  []string
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		requests++
+		var items []string
+		scanner := bufio.NewScanner(r.Body)
+		for scanner.Scan() {
+			action := scanner.Text()
+			assert.True(t, scanner.Scan())
+			assert.Contains(t, action, `"_index":"logs-1-2021.03.04"`)
+			doc := beat.MapStr{}
+			assert.NoError(t, json.Unmarshal(scanner.Bytes(), &doc))
+			data := doc["data"].(string)
+			// 首次请求时第二个文档返回429
+			if requests == 1 && data == "b" {
+				items = append(items, `{"index":{"status":429}}`)
+				continue
+			}
+			indexed = append(indexed, data)
+			items = append(items, `{"index":{"status":201}}`)
+		}
+		fmt.Fprintf(w, `{"errors":%v,"items":[%s]}`, requests == 1, strings.Join(items, ","))
+	}))
+	defer server.Close()
+
+	taskConfig := &cfg.TaskConfig{ID: "test"}
+	taskConfig.ElasticsearchOutput = cfg.ElasticsearchOutputConfig{
+		Hosts:         []string{server.URL},
+		Index:         "Logs-%{[dataid]}-%{+yyyy.MM.dd}",
+		BulkMaxSize:   2,
+		FlushInterval: time.Hour,
+		Timeout:       5 * time.Second,
+		MaxBackoff:    10 * time.Millisecond,
+	}
+	acked := make(chan interface{}, 2)
+	done := make(chan struct{})
+	defer close(done)
+	output, err := newESOutput(taskConfig, done, func(event beat.Event) bool {
+		acked <- event.Private
+		return true
+	})
+	assert.NoError(t, err)
+
+	ts := time.Date(2021, 3, 4, 5, 6, 7, 0, time.UTC)
+	assert.True(t, output.publish(beat.Event{Timestamp: ts, Private: 1, Fields: beat.MapStr{
+		"dataid": 1,
+		"items":  []beat.MapStr{{"data": "a"}, {"data": "b"}},
+	}}))
+	select {
+	case state := <-acked:
+		assert.Equal(t, 1, state)
+	case <-time.After(5 * time.Second):
+		t.Fatal("state is not acked")
+	}
+	mutex.Lock()
+	defer mutex.Unlock()
+	assert.Equal(t, 2, requests)
+	assert.Equal(t, []string{"a", "b"}, indexed)
+}
@@ -0,0 +1,871 @@
+// Tencent is pleased to support the open source community by making bkunifylogbeat 蓝鲸日志采集器 available.
+//
+// Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+//
+// bkunifylogbeat 蓝鲸日志采集器 is licensed under the MIT License.
+//
+// License for bkunifylogbeat 蓝鲸日志采集器:
+// --------------------------------------------------------------------
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+// documentation files (the "Software"), to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software,
+// and to permit persons to whom the Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all copies or substantial
+// portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+// LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN
+// NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+// WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+// SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package task
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+	"unicode/utf8"
+
+	"github.com/TencentBlueKing/bkunifylogbeat/config"
+	"github.com/TencentBlueKing/bkunifylogbeat/utils"
+	"github.com/TencentBlueKing/collector-go-sdk/v2/bkbeat/bkmonitoring"
+	"github.com/TencentBlueKing/collector-go-sdk/v2/bkbeat/logp"
+	"github.com/elastic/beats/libbeat/beat"
+	"github.com/elastic/beats/libbeat/common"
+	"github.com/elastic/beats/libbeat/monitoring"
+	"github.com/go-logfmt/logfmt"
+)
+
+var (
+	filterSoftFailTotal   = bkmonitoring.NewInt("filter_soft_fail_total")
+	filterShortLineTotal  = bkmonitoring.NewInt("filter_short_line_total")
+	filterDropSampleTotal = bkmonitoring.NewInt("filter_drop_sample_total")
+	filterDroppedTotal    = bkmonitoring.NewInt("filter_dropped_total")
+)
+
+// filterSet: 编译后的全部过滤组及切分所需的列数，配置重载时整体原子替换
+type filterSet struct {
+	filters []*filter
+	// 条件中最大的列序号
+	maxIndex int
+	// 关闭时停止该组条件内的后台任务
+	done chan struct{}
+}
+
+// newFilterSet: 根据任务配置编译过滤条件集合
+func newFilterSet(taskConfig *config.TaskConfig) (*filterSet, error) {
+	set := &filterSet{
+		maxIndex: maxConditionIndex(taskConfig),
+		done:     make(chan struct{}),
+	}
+	if taskConfig.HasFilter {
+		filters, err := newFilters(taskConfig, taskConfig.Filters, set.done)
+		if err != nil {
+			close(set.done)
+			return nil, fmt.Errorf("create filters failed, err=>%v", err)
+		}
+		set.filters = filters
+	}
+	return set, nil
+}
+
+// filter: 编译后的过滤组，组内条件为AND关系，组之间为OR关系
+type filter struct {
+	conditions []*condition
+	// eager模式下不短路，所有条件都会求值
+	eager bool
+	// 列数少于minColumnCount时条件组直接失败
+	minColumnCount int
+	shortLineTotal *monitoring.Int
+	// 过滤表达式，所有条件满足后求值，不满足时返回的条件下标为len(conditions)
+	expr        *utils.Expr
+	exprDropped *monitoring.Int
+}
+
+// condition: 编译后的过滤条件，保存条件运行时需要的状态
+type condition struct {
+	config.ConditionConfig
+	operation func(word string) bool
+	// logfmt模式下，index小于等于0的条件按logfmtKey取值后比较
+	logfmtKey string
+	// eager模式下条件命中次数
+	matched *monitoring.Int
+	// 事件被丢弃时该条件为所在条件组第一个不满足的条件的次数
+	dropped *monitoring.Int
+	// soft_fail条件被跳过的次数
+	softFailTotal *monitoring.Int
+	// 配置locale时按语言规则比较
+	collator *localeCollator
+	// index小于等于0时对整行执行operation，而不是按key做包含匹配
+	wholeLine bool
+	// json_parse模式下按路径取值
+	jsonPath []string
+	// 嵌套条件组：any为true时任意子条件满足即可，否则需要全部满足
+	children []*condition
+	any      bool
+}
+
+// newFilters: 根据任务配置编译过滤条件，有状态的条件在此初始化
+func newFilters(taskConfig *config.TaskConfig, filterConfigs []config.FilterConfig, done <-chan struct{}) ([]*filter, error) {
+	filters := make([]*filter, 0, len(filterConfigs))
+	for i, f := range filterConfigs {
+		compiled, err := newFilter(taskConfig, f, done)
+		if err != nil {
+			return nil, err
+		}
+		// 指标名包含条件组下标、条件下标及操作符，便于定位丢弃数据的规则
+		for j, cond := range compiled.conditions {
+			cond.dropped = newIntWithDataID(taskConfig.DataID, fmt.Sprintf("filter_condition_dropped_%d_%d_%s", i, j, metricOpName(cond)))
+		}
+		if compiled.expr != nil {
+			compiled.exprDropped = newIntWithDataID(taskConfig.DataID, fmt.Sprintf("filter_expr_dropped_%d", i))
+		}
+		if taskConfig.EagerEval {
+			compiled.eager = true
+			for j, cond := range compiled.conditions {
+				cond.matched = newIntWithDataID(taskConfig.DataID, fmt.Sprintf("filter_condition_matched_%d_%d", i, j))
+			}
+		}
+		filters = append(filters, compiled)
+	}
+	return filters, nil
+}
+
+// newFilter: 编译单个条件组，done关闭时停止条件内的后台任务
+func newFilter(taskConfig *config.TaskConfig, f config.FilterConfig, done <-chan struct{}) (*filter, error) {
+	compiled := &filter{minColumnCount: f.MinColumnCount}
+	if f.MinColumnCount > 0 {
+		compiled.shortLineTotal = newIntWithDataID(taskConfig.DataID, "filter_short_line_total")
+	}
+	if f.Expr != "" {
+		expr, err := utils.CompileExpr(f.Expr)
+		if err != nil {
+			return nil, err
+		}
+		compiled.expr = expr
+	}
+	for _, c := range f.Conditions {
+		cond, err := compileCondition(taskConfig, c, done)
+		if err != nil {
+			return nil, err
+		}
+		compiled.conditions = append(compiled.conditions, cond)
+	}
+	return compiled, nil
+}
+
+// compileCondition: 编译条件，嵌套条件组递归编译子条件
+func compileCondition(taskConfig *config.TaskConfig, c config.ConditionConfig, done <-chan struct{}) (*condition, error) {
+	if c.IsGroup() {
+		cond := &condition{ConditionConfig: c, any: len(c.Any) > 0}
+		children := c.All
+		if cond.any {
+			children = c.Any
+		}
+		for _, child := range children {
+			compiled, err := compileCondition(taskConfig, child, done)
+			if err != nil {
+				return nil, err
+			}
+			cond.children = append(cond.children, compiled)
+		}
+		return cond, nil
+	}
+
+	var logfmtKey string
+	if taskConfig.LogfmtParse && c.Index <= 0 && c.Path == "" && !c.IsFieldCondition() {
+		// "logfmt_key:expected_value"
+		parts := strings.SplitN(c.Key, ":", 2)
+		logfmtKey, c.Key = parts[0], parts[1]
+	}
+	cond, err := newCondition(c, done)
+	if err != nil {
+		return nil, err
+	}
+	cond.logfmtKey = logfmtKey
+	if c.Path != "" {
+		cond.jsonPath = strings.Split(strings.TrimPrefix(strings.TrimPrefix(c.Path, "$"), "."), ".")
+	}
+	if c.SoftFail {
+		cond.softFailTotal = newIntWithDataID(taskConfig.DataID, "filter_soft_fail_total")
+	}
+	return cond, nil
+}
+
+// newCondition: 编译单个过滤条件
+func newCondition(c config.ConditionConfig, done <-chan struct{}) (*condition, error) {
+	if c.IgnoreCase {
+		c.Key = strings.ToLower(c.Key)
+	}
+	cond := &condition{ConditionConfig: c}
+	if c.Locale != "" {
+		collator, err := getLocaleCollator(c.Locale)
+		if err != nil {
+			return nil, err
+		}
+		cond.collator = collator
+	}
+	switch c.Op {
+	case "window_agg_gt":
+		threshold, err := strconv.ParseFloat(c.Key, 64)
+		if err != nil {
+			return nil, fmt.Errorf("window_agg_gt key must be a number, key=>%s", c.Key)
+		}
+		window := newWindowAgg(c.WindowAgg)
+		cond.operation = func(word string) bool {
+			return window.greaterThan(word, threshold)
+		}
+	case "scanf":
+		format, err := utils.NewScanfFormat(c.Key)
+		if err != nil {
+			return nil, err
+		}
+		cond.operation = func(word string) bool {
+			captures, ok := format.Scan(word)
+			return ok && captures[0] == c.ScanfExpect
+		}
+	case "bloom_file":
+		watcher, err := newBloomWatcher(c.Key, done)
+		if err != nil {
+			return nil, err
+		}
+		cond.operation = watcher.test
+	case "gt", "gte", "lt", "lte":
+		operation, err := getNumericOperation(c.Op, c.FieldType, c.Key, c.NumericBase)
+		if err != nil {
+			return nil, err
+		}
+		cond.operation = operation
+	case "cidr":
+		networks, err := utils.ParseCIDRs(c.Key)
+		if err != nil {
+			return nil, err
+		}
+		cond.operation = func(word string) bool {
+			ip := net.ParseIP(strings.TrimSpace(word))
+			if ip == nil {
+				return false
+			}
+			for _, network := range networks {
+				if network.Contains(ip) {
+					return true
+				}
+			}
+			return false
+		}
+	case "regex", "nregex":
+		regex, err := compileRegex(c.Key)
+		if err != nil {
+			return nil, err
+		}
+		expect := c.Op == "regex"
+		cond.operation = func(word string) bool {
+			return regex.MatchString(word) == expect
+		}
+		cond.wholeLine = true
+	case "weekday_in":
+		weekdays, err := utils.ParseWeekdays(c.Key)
+		if err != nil {
+			return nil, err
+		}
+		// 空字符串时LoadLocation返回UTC，这里默认使用本地时区
+		location := time.Local
+		if c.Timezone != "" {
+			if location, err = time.LoadLocation(c.Timezone); err != nil {
+				return nil, err
+			}
+		}
+		layout := c.TimeFormat
+		if layout == "" {
+			layout = time.RFC3339
+		}
+		cond.operation = func(word string) bool {
+			t, err := time.ParseInLocation(layout, strings.TrimSpace(word), location)
+			if err != nil {
+				return false
+			}
+			return weekdays[t.In(location).Weekday()]
+		}
+	case "exists", "not_exists":
+		// 按事件字段判断，不需要operation
+		return cond, nil
+	case "s3_allow", "s3_deny":
+		list, err := newS3List(c.S3List, done)
+		if err != nil {
+			return nil, err
+		}
+		allow := c.Op == "s3_allow"
+		cond.operation = func(word string) bool {
+			return list.contains(word) == allow
+		}
+	case "=", "!=":
+		if c.IPNormalize {
+			key := normalizeIP(c.Key)
+			equal := c.Op == "="
+			cond.operation = func(word string) bool {
+				return (normalizeIP(word) == key) == equal
+			}
+			break
+		}
+		if cond.collator != nil {
+			equal := c.Op == "="
+			cond.operation = func(word string) bool {
+				return cond.collator.equal(word, c.Key) == equal
+			}
+			break
+		}
+		if c.FieldType != "" && c.FieldType != "string" {
+			operation, err := getTypedOperation(c.Op, c.FieldType, c.Key, c.NumericBase)
+			if err != nil {
+				return nil, err
+			}
+			cond.operation = operation
+			break
+		}
+		operationFunc := getOperation(c.Op)
+		cond.operation = func(word string) bool {
+			return operationFunc(word, c.Key)
+		}
+	case "contains", "ncontains":
+		operationFunc := getOperation(c.Op)
+		cond.operation = func(word string) bool {
+			return operationFunc(word, c.Key)
+		}
+		cond.wholeLine = true
+	default:
+		operationFunc := getOperation(c.Op)
+		if operationFunc == nil {
+			return nil, fmt.Errorf("op(%s) is not supported", c.Op)
+		}
+		cond.operation = func(word string) bool {
+			return operationFunc(word, c.Key)
+		}
+	}
+
+	if c.IgnoreCase {
+		operation := cond.operation
+		cond.operation = func(word string) bool {
+			return operation(strings.ToLower(word))
+		}
+	}
+
+	if c.Tokenize {
+		operation := cond.operation
+		cond.operation = func(word string) bool {
+			for _, token := range strings.Fields(word) {
+				if operation(token) {
+					return true
+				}
+			}
+			return false
+		}
+	}
+	return cond, nil
+}
+
+// filter: 兼容原采集器过滤方式
+func (client *Processors) filter(event *beat.Event, set *filterSet) *beat.Event {
+	var text string
+	var ok bool
+	if text, ok = event.Fields["data"].(string); !ok {
+		return event
+	}
+	l := client.splitLine(text, set.maxIndex)
+	l.fields = event.Fields
+	results := make([]int, 0, len(set.filters))
+	matched := false
+	for _, f := range set.filters {
+		result := f.evaluate(l)
+		results = append(results, result)
+		if result == filterMatched {
+			matched = true
+			// eager模式下所有条件组都参与求值，保证每个条件的命中统计完整
+			if !client.taskConfig.EagerEval {
+				break
+			}
+		}
+	}
+	if matched {
+		return event
+	}
+	filterDroppedTotal.Add(1)
+	for i, result := range results {
+		if result == len(set.filters[i].conditions) {
+			set.filters[i].exprDropped.Add(1)
+		} else if result >= 0 {
+			set.filters[i].conditions[result].dropped.Add(1)
+		}
+	}
+	if client.taskConfig.DropSampleRate > 0 && rand.Float64() < client.taskConfig.DropSampleRate {
+		client.logDropSample(text, set, results)
+	}
+	return nil
+}
+
+// metricOpName: 指标名中使用的操作符名称，避免=、!=等符号出现在指标名中
+func metricOpName(c *condition) string {
+	switch {
+	case c.children != nil:
+		return "group"
+	case c.Op == "=":
+		return "eq"
+	case c.Op == "!=":
+		return "neq"
+	}
+	return c.Op
+}
+
+// dropSampleMaxBytes: 抽样记录的丢弃事件data最大字节数
+const dropSampleMaxBytes = 200
+
+// logDropSample: 记录被丢弃事件的内容及各条件组失败的原因
+func (client *Processors) logDropSample(text string, set *filterSet, results []int) {
+	client.dropSampleTotal.Add(1)
+	filterDropSampleTotal.Add(1)
+
+	if len(text) > dropSampleMaxBytes {
+		text = text[:dropSampleMaxBytes]
+	}
+	failures := make([]string, 0, len(results))
+	for i, result := range results {
+		if result == filterShortLine {
+			failures = append(failures, fmt.Sprintf("filters[%d]:short_line", i))
+		} else if result == len(set.filters[i].conditions) {
+			failures = append(failures, fmt.Sprintf("filters[%d].expr", i))
+		} else {
+			failures = append(failures, fmt.Sprintf("filters[%d].conditions[%d]", i, result))
+		}
+	}
+	format := "filter dropped event, task_id:%s, dataid:%d, failed:%s, data:%q"
+	args := []interface{}{client.taskConfig.ID, client.taskConfig.DataID, strings.Join(failures, ","), text}
+	switch client.taskConfig.DropLogLevel {
+	case "info":
+		logp.L.Infof(format, args...)
+	case "warn":
+		logp.L.Warnf(format, args...)
+	default:
+		logp.L.Debugf(format, args...)
+	}
+}
+
+// priorityRule: 编译后的优先级规则
+type priorityRule struct {
+	priority int
+	filter   *filter
+}
+
+// newPriorityRules: 编译优先级规则
+func newPriorityRules(taskConfig *config.TaskConfig, done <-chan struct{}) ([]*priorityRule, error) {
+	rules := make([]*priorityRule, 0, len(taskConfig.PriorityRules))
+	for _, r := range taskConfig.PriorityRules {
+		f, err := newFilter(taskConfig, r.Filter, done)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, &priorityRule{priority: r.Priority, filter: f})
+	}
+	return rules, nil
+}
+
+// classify: 按顺序匹配优先级规则，将第一个命中规则的优先级写入_priority字段
+func (client *Processors) classify(event *beat.Event) {
+	text, ok := event.Fields["data"].(string)
+	if !ok {
+		return
+	}
+	l := client.parseLine(text)
+	l.fields = event.Fields
+	for _, rule := range client.priorityRules {
+		if rule.filter.match(l) {
+			event.Fields["_priority"] = rule.priority
+			return
+		}
+	}
+}
+
+// line: 按分隔符或logfmt解析后的日志内容，同一事件的多个条件组共用
+type line struct {
+	text  string
+	words []string
+	pairs map[string]string
+	json  interface{}
+	// 事件字段，exists/not_exists条件使用
+	fields common.MapStr
+}
+
+// parseLine: 按当前生效的过滤条件解析日志内容
+func (client *Processors) parseLine(text string) *line {
+	return client.splitLine(text, client.loadFilters().maxIndex)
+}
+
+// splitLine: 解析日志内容，maxIndex为条件中最大的列序号
+func (client *Processors) splitLine(text string, maxIndex int) *line {
+	l := &line{text: text}
+	// index为N时，数组切分最少需要分成N+1段
+	if client.taskConfig.SplitMode == "csv" {
+		sep := byte(',')
+		if client.taskConfig.Delimiter != "" {
+			sep = client.taskConfig.Delimiter[0]
+		}
+		l.words = utils.SplitCSV(text, sep, maxIndex+1)
+	} else if client.splitClass != nil {
+		l.words = client.splitClass.Split(text, maxIndex+1)
+	} else if client.taskConfig.Delimiter != "" {
+		l.words = strings.SplitN(text, client.taskConfig.Delimiter, maxIndex+1)
+	}
+	if client.taskConfig.LogfmtParse {
+		l.pairs = parseLogfmt(text)
+	}
+	if client.taskConfig.JSONParse {
+		l.json = parseJSON(text)
+	}
+	return l
+}
+
+// 条件组求值结果：全部满足，或因列数不足失败，其余为第一个不满足的条件下标
+const (
+	filterMatched   = -1
+	filterShortLine = -2
+)
+
+// match: 条件组内所有条件都满足时返回true
+func (f *filter) match(l *line) bool {
+	return f.evaluate(l) == filterMatched
+}
+
+// evaluate: 对条件组求值，返回filterMatched、filterShortLine或第一个不满足的条件下标
+func (f *filter) evaluate(l *line) int {
+	if len(l.words) < f.minColumnCount {
+		f.shortLineTotal.Add(1)
+		filterShortLineTotal.Add(1)
+		return filterShortLine
+	}
+	if f.eager {
+		result := filterMatched
+		for i, condition := range f.conditions {
+			if condition.skipped(l) {
+				continue
+			}
+			if condition.match(l) {
+				condition.matched.Add(1)
+			} else if result == filterMatched {
+				result = i
+			}
+		}
+		if f.expr != nil && !f.expr.Eval(l) && result == filterMatched {
+			result = len(f.conditions)
+		}
+		return result
+	}
+	for i, condition := range f.conditions {
+		if condition.skipped(l) {
+			continue
+		}
+		if !condition.match(l) {
+			return i
+		}
+	}
+	if f.expr != nil && !f.expr.Eval(l) {
+		return len(f.conditions)
+	}
+	return filterMatched
+}
+
+// Line 整行内容，用于过滤表达式求值
+func (l *line) Line() string {
+	return l.text
+}
+
+// Column 第index列内容，不存在时返回空字符串，用于过滤表达式求值
+func (l *line) Column(index int) string {
+	if index <= 0 || index > len(l.words) {
+		return ""
+	}
+	return l.words[index-1]
+}
+
+// skipped: soft_fail条件的列序号超出切分结果时跳过
+func (condition *condition) skipped(l *line) bool {
+	if !condition.SoftFail || condition.logfmtKey != "" || condition.Index <= 0 || len(l.words) >= condition.Index {
+		return false
+	}
+	condition.softFailTotal.Add(1)
+	filterSoftFailTotal.Add(1)
+	return true
+}
+
+// match: 判断单个条件是否满足，配置negate时取反
+func (condition *condition) match(l *line) bool {
+	return condition.test(l) != condition.Negate
+}
+
+// test: 对条件求值，不考虑negate
+func (condition *condition) test(l *line) bool {
+	if condition.children != nil {
+		return condition.matchGroup(l)
+	}
+	if condition.IsFieldCondition() {
+		exist, _ := l.fields.HasKey(condition.Key)
+		return exist == (condition.Op == "exists")
+	}
+	// JSON模式下按路径取值比较，路径不存在视为不匹配
+	if condition.jsonPath != nil {
+		value, exist := lookupJSON(l.json, condition.jsonPath)
+		return exist && condition.operation(value)
+	}
+	// logfmt模式下按key取值比较，key不存在视为不匹配
+	if condition.logfmtKey != "" {
+		value, exist := l.pairs[condition.logfmtKey]
+		return exist && condition.operation(value)
+	}
+	// 匹配第n列，如果n小于等于0，则变更为整个字符串包含
+	if condition.Index <= 0 {
+		if condition.wholeLine {
+			return condition.operation(l.text)
+		}
+		if condition.collator != nil {
+			return condition.collator.contains(l.text, condition.Key)
+		}
+		if condition.IgnoreCase {
+			return strings.Contains(strings.ToLower(l.text), condition.Key)
+		}
+		return strings.Contains(l.text, condition.Key)
+	}
+	if len(l.words) < condition.Index {
+		return false
+	}
+	return condition.operation(normalizeWord(l.words[condition.Index-1], condition.ConditionConfig))
+}
+
+// matchGroup: 对嵌套条件组求值，跳过的soft_fail子条件不参与判断
+func (condition *condition) matchGroup(l *line) bool {
+	evaluated := false
+	for _, child := range condition.children {
+		if child.skipped(l) {
+			continue
+		}
+		evaluated = true
+		if child.match(l) == condition.any {
+			return condition.any
+		}
+	}
+	// any组内子条件全部跳过时视为满足，与条件组的soft_fail语义一致
+	return !condition.any || !evaluated
+}
+
+// parseLogfmt: 将logfmt格式的日志解析为key-value，解析出错时保留已解析的部分
+func parseLogfmt(text string) map[string]string {
+	pairs := make(map[string]string)
+	decoder := logfmt.NewDecoder(strings.NewReader(text))
+	for decoder.ScanRecord() {
+		for decoder.ScanKeyval() {
+			pairs[string(decoder.Key())] = string(decoder.Value())
+		}
+	}
+	return pairs
+}
+
+// parseJSON: 将日志解析为JSON，数值保留原始文本，解析失败时返回nil
+func parseJSON(text string) interface{} {
+	var value interface{}
+	decoder := json.NewDecoder(strings.NewReader(text))
+	decoder.UseNumber()
+	if err := decoder.Decode(&value); err != nil {
+		return nil
+	}
+	return value
+}
+
+// lookupJSON: 按路径取值并转换为字符串，数组使用数字下标，对象及数组取值时返回JSON文本
+func lookupJSON(value interface{}, path []string) (string, bool) {
+	for _, key := range path {
+		switch v := value.(type) {
+		case map[string]interface{}:
+			var ok bool
+			if value, ok = v[key]; !ok {
+				return "", false
+			}
+		case []interface{}:
+			i, err := strconv.Atoi(key)
+			if err != nil || i < 0 || i >= len(v) {
+				return "", false
+			}
+			value = v[i]
+		default:
+			return "", false
+		}
+	}
+	switch v := value.(type) {
+	case nil:
+		return "", false
+	case string:
+		return v, true
+	case json.Number:
+		return v.String(), true
+	case bool:
+		return strconv.FormatBool(v), true
+	default:
+		data, err := json.Marshal(v)
+		if err != nil {
+			return "", false
+		}
+		return string(data), true
+	}
+}
+
+// normalizeWord: 定长协议日志字段规整，先左补齐再截断
+func normalizeWord(word string, condition config.ConditionConfig) string {
+	if condition.PadTo > 0 {
+		padChar := condition.PadChar
+		if padChar == "" {
+			padChar = " "
+		}
+		if n := condition.PadTo - utf8.RuneCountInString(word); n > 0 {
+			word = strings.Repeat(padChar, n) + word
+		}
+	}
+	if condition.TruncateTo > 0 {
+		runes := []rune(word)
+		if len(runes) > condition.TruncateTo {
+			word = string(runes[:condition.TruncateTo])
+		}
+	}
+	return word
+}
+
+// normalizeIP: 将IPv4、IPv4映射的IPv6及IPv6地址规整为标准形式，无法解析时原样返回
+func normalizeIP(word string) string {
+	word = strings.TrimSpace(word)
+	ip := net.ParseIP(word)
+	if ip == nil {
+		return word
+	}
+	return ip.To16().String()
+}
+
+// windowAgg: 滑动窗口聚合，使用环形缓冲区保存最近WindowSize个数值
+type windowAgg struct {
+	mutex   sync.Mutex
+	aggFunc string
+	values  []float64
+	pos     int
+	count   int
+	sum     float64
+}
+
+func newWindowAgg(c config.WindowAggConfig) *windowAgg {
+	return &windowAgg{
+		aggFunc: c.AggFunc,
+		values:  make([]float64, c.WindowSize),
+	}
+}
+
+// push: 写入新值，sum随写入增量维护，avg计算为O(1)
+func (w *windowAgg) push(value float64) {
+	if w.count == len(w.values) {
+		w.sum -= w.values[w.pos]
+	} else {
+		w.count++
+	}
+	w.values[w.pos] = value
+	w.sum += value
+	w.pos = (w.pos + 1) % len(w.values)
+}
+
+// aggregate: 计算当前窗口内的聚合值
+func (w *windowAgg) aggregate() float64 {
+	switch w.aggFunc {
+	case "sum":
+		return w.sum
+	case "avg":
+		return w.sum / float64(w.count)
+	}
+	result := w.values[0]
+	for i := 1; i < w.count; i++ {
+		if w.aggFunc == "min" && w.values[i] < result {
+			result = w.values[i]
+		}
+		if w.aggFunc == "max" && w.values[i] > result {
+			result = w.values[i]
+		}
+	}
+	return result
+}
+
+// greaterThan: 将当前值写入窗口，并判断窗口聚合值是否大于阈值
+func (w *windowAgg) greaterThan(word string, threshold float64) bool {
+	value, err := strconv.ParseFloat(strings.TrimSpace(word), 64)
+	if err != nil {
+		return false
+	}
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	w.push(value)
+	return w.aggregate() > threshold
+}
+
+// bloomReloadInterval: 布隆过滤器文件变更检查周期
+var bloomReloadInterval = 10 * time.Second
+
+// bloomWatcher: 从文件加载布隆过滤器，文件变更后重新加载并原子替换，匹配过程无锁
+type bloomWatcher struct {
+	path    string
+	modTime time.Time
+	bloom   atomic.Value
+}
+
+func newBloomWatcher(path string, done <-chan struct{}) (*bloomWatcher, error) {
+	w := &bloomWatcher{path: path}
+	if err := w.load(); err != nil {
+		return nil, fmt.Errorf("load bloom filter(%s) failed, err=>%v", path, err)
+	}
+	go w.watch(done)
+	return w, nil
+}
+
+// load: 文件修改时间变化时重新加载
+func (w *bloomWatcher) load() error {
+	info, err := os.Stat(w.path)
+	if err != nil {
+		return err
+	}
+	if info.ModTime().Equal(w.modTime) {
+		return nil
+	}
+	bloom, err := utils.LoadBloomFilter(w.path)
+	if err != nil {
+		return err
+	}
+	w.bloom.Store(bloom)
+	w.modTime = info.ModTime()
+	return nil
+}
+
+// watch: 周期检查文件，加载失败时继续使用旧的布隆过滤器
+func (w *bloomWatcher) watch(done <-chan struct{}) {
+	ticker := time.NewTicker(bloomReloadInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			if err := w.load(); err != nil {
+				logp.L.Errorf("reload bloom filter(%s) failed, err=>%v", w.path, err)
+			}
+		}
+	}
+}
+
+func (w *bloomWatcher) test(word string) bool {
+	return w.bloom.Load().(*utils.BloomFilter).Test([]byte(word))
+}
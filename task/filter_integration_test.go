@@ -0,0 +1,183 @@
+// Tencent is pleased to support the open source community by making bkunifylogbeat 蓝鲸日志采集器 available.
+//
+// Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+//
+// bkunifylogbeat 蓝鲸日志采集器 is licensed under the MIT License.
+//
+// License for bkunifylogbeat 蓝鲸日志采集器:
+// --------------------------------------------------------------------
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+// documentation files (the "Software"), to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software,
+// and to permit persons to whom the Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all copies or substantial
+// portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+// LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN
+// NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+// WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+// SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package task
+
+import (
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	cfg "github.com/TencentBlueKing/bkunifylogbeat/config"
+	"github.com/TencentBlueKing/bkunifylogbeat/tests"
+	"github.com/stretchr/testify/assert"
+)
+
+const syntheticEventCount = 10000
+
+// syntheticColumns: level(ERROR 20%, WARN 30%, INFO 50%)|module(order 50%, user 50%)|code
+var syntheticColumns = []tests.SyntheticColumn{
+	{Values: []string{"ERROR", "WARN", "INFO"}, Weights: []float64{0.2, 0.3, 0.5}},
+	{Values: []string{"order", "user"}},
+	{Values: []string{"200", "404", "500"}, Weights: []float64{0.8, 0.1, 0.1}},
+}
+
+func newSyntheticProcessors(filters []cfg.FilterConfig) *Processors {
+	vars := map[string]interface{}{
+		"dataid":    "999990001",
+		"delimiter": "|",
+		"filters":   filters,
+	}
+	config, err := cfg.CreateTaskConfig(vars)
+	if err != nil {
+		panic(err)
+	}
+	processors, err := NewProcessors(config)
+	if err != nil {
+		panic(err)
+	}
+	return processors
+}
+
+// runSynthetic: 并发处理合成事件，返回通过及丢弃的事件数
+func runSynthetic(processors func() *Processors, count, workers int) (int64, int64) {
+	generator := tests.NewSyntheticLogGenerator("/test.log", "|", syntheticColumns, 1)
+	var passed, dropped int64
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < count/workers; i++ {
+				data := generator.Next()
+				if processors().Run(&data.Event) != nil {
+					atomic.AddInt64(&passed, 1)
+				} else {
+					atomic.AddInt64(&dropped, 1)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	return passed, dropped
+}
+
+//TestFilterSyntheticRates: 测试各类条件在合成日志上的通过率
+func TestFilterSyntheticRates(t *testing.T) {
+	cases := []struct {
+		name    string
+		filters []cfg.FilterConfig
+		rate    float64
+	}{
+		{
+			name: "equal",
+			filters: []cfg.FilterConfig{
+				{Conditions: []cfg.ConditionConfig{{Index: 1, Key: "ERROR", Op: "="}}},
+			},
+			rate: 0.2,
+		},
+		{
+			name: "not equal",
+			filters: []cfg.FilterConfig{
+				{Conditions: []cfg.ConditionConfig{{Index: 1, Key: "INFO", Op: "!="}}},
+			},
+			rate: 0.5,
+		},
+		{
+			name: "and",
+			filters: []cfg.FilterConfig{
+				{Conditions: []cfg.ConditionConfig{
+					{Index: 1, Key: "ERROR", Op: "="},
+					{Index: 2, Key: "order", Op: "="},
+				}},
+			},
+			rate: 0.1,
+		},
+		{
+			name: "or",
+			filters: []cfg.FilterConfig{
+				{Conditions: []cfg.ConditionConfig{{Index: 1, Key: "ERROR", Op: "="}}},
+				{Conditions: []cfg.ConditionConfig{{Index: 1, Key: "WARN", Op: "="}}},
+			},
+			rate: 0.5,
+		},
+		{
+			name: "typed",
+			filters: []cfg.FilterConfig{
+				{Conditions: []cfg.ConditionConfig{{Index: 3, Key: "200", Op: "!=", FieldType: "int"}}},
+			},
+			rate: 0.2,
+		},
+		{
+			name: "contains",
+			filters: []cfg.FilterConfig{
+				{Conditions: []cfg.ConditionConfig{{Index: -1, Key: "|user|", Op: "="}}},
+			},
+			rate: 0.5,
+		},
+	}
+	for _, c := range cases {
+		processors := newSyntheticProcessors(c.filters)
+		passed, dropped := runSynthetic(func() *Processors { return processors }, syntheticEventCount, 4)
+		assert.Equal(t, int64(syntheticEventCount), passed+dropped, c.name)
+		assert.InDelta(t, c.rate, float64(passed)/syntheticEventCount, 0.02, c.name)
+	}
+}
+
+//TestFilterSyntheticReload: 测试处理过程中切换过滤配置，切换前后的事件分别按新旧配置过滤且不丢失
+func TestFilterSyntheticReload(t *testing.T) {
+	errorOnly := newSyntheticProcessors([]cfg.FilterConfig{
+		{Conditions: []cfg.ConditionConfig{{Index: 1, Key: "ERROR", Op: "="}}},
+	})
+	warnOnly := newSyntheticProcessors([]cfg.FilterConfig{
+		{Conditions: []cfg.ConditionConfig{{Index: 1, Key: "WARN", Op: "="}}},
+	})
+
+	var current atomic.Value
+	current.Store(errorOnly)
+	generator := tests.NewSyntheticLogGenerator("/test.log", "|", syntheticColumns, 2)
+	var total, wrong int64
+	var wg sync.WaitGroup
+	for w := 0; w < 4; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < syntheticEventCount/4; i++ {
+				processors := current.Load().(*Processors)
+				data := generator.Next()
+				level := strings.SplitN(data.Event.Fields["data"].(string), "|", 2)[0]
+				event := processors.Run(&data.Event)
+				expected := (processors == errorOnly && level == "ERROR") || (processors == warnOnly && level == "WARN")
+				if (event != nil) != expected {
+					atomic.AddInt64(&wrong, 1)
+				}
+				if atomic.AddInt64(&total, 1) == syntheticEventCount/2 {
+					current.Store(warnOnly)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int64(syntheticEventCount), total)
+	assert.Equal(t, int64(0), wrong)
+}
@@ -0,0 +1,190 @@
+// Tencent is pleased to support the open source community by making bkunifylogbeat 蓝鲸日志采集器 available.
+//
+// Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+//
+// bkunifylogbeat 蓝鲸日志采集器 is licensed under the MIT License.
+//
+// License for bkunifylogbeat 蓝鲸日志采集器:
+// --------------------------------------------------------------------
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+// documentation files (the "Software"), to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software,
+// and to permit persons to whom the Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all copies or substantial
+// portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+// LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN
+// NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+// WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+// SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package task
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	cfg "github.com/TencentBlueKing/bkunifylogbeat/config"
+	"github.com/TencentBlueKing/bkunifylogbeat/task/logpb"
+	"github.com/TencentBlueKing/collector-go-sdk/v2/bkbeat/bkmonitoring"
+	"github.com/TencentBlueKing/collector-go-sdk/v2/bkbeat/logp"
+	"github.com/elastic/beats/libbeat/beat"
+	"github.com/elastic/beats/libbeat/common/transport/tlscommon"
+	"github.com/elastic/beats/libbeat/monitoring"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+)
+
+const (
+	defaultGRPCBufferSize = 1024
+	grpcMinBackoff        = 1 * time.Second
+	grpcMaxBackoff        = 60 * time.Second
+)
+
+var (
+	filterGRPCSentTotal  = bkmonitoring.NewInt("filter_grpc_sent_total")
+	filterGRPCErrorTotal = bkmonitoring.NewInt("filter_grpc_error_total")
+)
+
+// grpcOutput: 将通过过滤的事件以流的方式推送到远端日志汇聚服务
+// 断线期间事件缓存在本地，缓存满时丢弃并计入filter_grpc_error_total
+type grpcOutput struct {
+	taskID     string
+	dataID     int
+	target     string
+	format     string
+	dialOption grpc.DialOption
+	buffer     chan *logpb.LogEvent
+	done       <-chan struct{}
+	sentTotal  *monitoring.Int
+	errorTotal *monitoring.Int
+}
+
+// newGRPCOutput 生成gRPC输出实例，连接在后台建立
+func newGRPCOutput(taskConfig *cfg.TaskConfig, done <-chan struct{}) (*grpcOutput, error) {
+	config := taskConfig.GRPCOutput
+	dialOption := grpc.WithInsecure()
+	if config.TLS != nil {
+		tlsConfig, err := tlscommon.LoadTLSConfig(config.TLS)
+		if err != nil {
+			return nil, fmt.Errorf("load grpc_output tls config failed, err=>%v", err)
+		}
+		if tlsConfig != nil {
+			dialOption = grpc.WithTransportCredentials(credentials.NewTLS(tlsConfig.BuildModuleConfig(config.Target)))
+		}
+	}
+	bufferSize := config.BufferSize
+	if bufferSize <= 0 {
+		bufferSize = defaultGRPCBufferSize
+	}
+	output := &grpcOutput{
+		taskID:     taskConfig.ID,
+		dataID:     taskConfig.DataID,
+		target:     config.Target,
+		format:     taskConfig.OutputFormats["grpc_output"],
+		dialOption: dialOption,
+		buffer:     make(chan *logpb.LogEvent, bufferSize),
+		done:       done,
+		sentTotal:  newIntWithDataID(taskConfig.DataID, "filter_grpc_sent_total"),
+		errorTotal: newIntWithDataID(taskConfig.DataID, "filter_grpc_error_total"),
+	}
+	go output.run()
+	return output, nil
+}
+
+// publish 将事件放入本地缓存，缓存满时直接丢弃，不阻塞采集
+// 格式为json时只发送Fields，text时只发送Data，raw(默认)两者都发送
+func (o *grpcOutput) publish(source string, event *beat.Event) {
+	logEvent := &logpb.LogEvent{
+		DataId:    int64(o.dataID),
+		Source:    source,
+		Timestamp: event.Timestamp.UnixNano(),
+	}
+	if o.format != "json" {
+		logEvent.Data, _ = event.Fields["data"].(string)
+	}
+	if o.format != "text" {
+		fields, err := json.Marshal(event.Fields)
+		if err != nil {
+			logp.L.Errorf("marshal event fields failed, task_id:%s, err=>%v", o.taskID, err)
+			o.addError()
+			return
+		}
+		logEvent.Fields = string(fields)
+	}
+	select {
+	case o.buffer <- logEvent:
+	default:
+		o.addError()
+	}
+}
+
+// run 建立推送流并持续发送，断线后按指数退避重连
+func (o *grpcOutput) run() {
+	var pending *logpb.LogEvent
+	backoff := grpcMinBackoff
+	for {
+		var err error
+		pending, err = o.stream(pending)
+		if err == nil {
+			return
+		}
+		o.addError()
+		logp.L.Errorf("grpc output stream failed, task_id:%s, target:%s, retry in %s, err=>%v",
+			o.taskID, o.target, backoff, err)
+		select {
+		case <-o.done:
+			return
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+		if backoff > grpcMaxBackoff {
+			backoff = grpcMaxBackoff
+		}
+	}
+}
+
+// stream 建立一次推送流并发送事件，返回发送失败的事件以便重连后重发，任务停止时返回nil错误
+func (o *grpcOutput) stream(pending *logpb.LogEvent) (*logpb.LogEvent, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	conn, err := grpc.DialContext(ctx, o.target, o.dialOption)
+	if err != nil {
+		return pending, err
+	}
+	defer conn.Close()
+	stream, err := logpb.NewLogAggregatorClient(conn).Push(ctx)
+	if err != nil {
+		return pending, err
+	}
+	logp.L.Infof("grpc output stream established, task_id:%s, target:%s", o.taskID, o.target)
+
+	for {
+		if pending != nil {
+			if err = stream.Send(pending); err != nil {
+				return pending, err
+			}
+			o.sentTotal.Add(1)
+			filterGRPCSentTotal.Add(1)
+			pending = nil
+		}
+		select {
+		case <-o.done:
+			if _, err = stream.CloseAndRecv(); err != nil {
+				logp.L.Errorf("close grpc output stream failed, task_id:%s, err=>%v", o.taskID, err)
+			}
+			return nil, nil
+		case pending = <-o.buffer:
+		}
+	}
+}
+
+func (o *grpcOutput) addError() {
+	o.errorTotal.Add(1)
+	filterGRPCErrorTotal.Add(1)
+}
@@ -0,0 +1,149 @@
+// Tencent is pleased to support the open source community by making bkunifylogbeat 蓝鲸日志采集器 available.
+//
+// Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+//
+// bkunifylogbeat 蓝鲸日志采集器 is licensed under the MIT License.
+//
+// License for bkunifylogbeat 蓝鲸日志采集器:
+// --------------------------------------------------------------------
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+// documentation files (the "Software"), to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software,
+// and to permit persons to whom the Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all copies or substantial
+// portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+// LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN
+// NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+// WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+// SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package task
+
+import (
+	"io"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	cfg "github.com/TencentBlueKing/bkunifylogbeat/config"
+	"github.com/TencentBlueKing/bkunifylogbeat/task/logpb"
+	"github.com/elastic/beats/libbeat/beat"
+	"github.com/elastic/beats/libbeat/common"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+)
+
+// mockLogAggregator: 记录收到的事件，每收到一条通知一次
+type mockLogAggregator struct {
+	mutex    sync.Mutex
+	events   []*logpb.LogEvent
+	received chan struct{}
+}
+
+func (a *mockLogAggregator) Push(stream logpb.LogAggregator_PushServer) error {
+	for {
+		event, err := stream.Recv()
+		if err == io.EOF {
+			return stream.SendAndClose(&logpb.PushResponse{})
+		}
+		if err != nil {
+			return err
+		}
+		a.mutex.Lock()
+		a.events = append(a.events, event)
+		a.mutex.Unlock()
+		a.received <- struct{}{}
+	}
+}
+
+// waitEvents: 等待收到n条事件
+func (a *mockLogAggregator) waitEvents(t *testing.T, n int) {
+	for i := 0; i < n; i++ {
+		select {
+		case <-a.received:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("event %d is not received", i)
+		}
+	}
+}
+
+// TestGRPCOutput: 服务不可用时事件缓存在本地，缓存满时丢弃，服务恢复后重连并按顺序发送
+func TestGRPCOutput(t *testing.T) {
+	// 先占用端口再释放，得到一个暂时没有服务的地址
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	target := listener.Addr().String()
+	listener.Close()
+
+	taskConfig := &cfg.TaskConfig{ID: "test", DataID: 999970001}
+	taskConfig.GRPCOutput = cfg.GRPCOutputConfig{Target: target, BufferSize: 2}
+	errorTotal := newIntWithDataID(taskConfig.DataID, "filter_grpc_error_total").Get()
+	done := make(chan struct{})
+	defer close(done)
+	output, err := newGRPCOutput(taskConfig, done)
+	assert.NoError(t, err)
+	sentTotal := output.sentTotal.Get()
+
+	for _, data := range []string{"a", "b", "c"} {
+		output.publish("/data/test.log", &beat.Event{
+			Timestamp: time.Unix(1600000000, 0),
+			Fields:    common.MapStr{"data": data},
+		})
+	}
+	// 未连接时不会从缓存中取出事件，第三条事件因缓存满被丢弃
+	assert.Len(t, output.buffer, 2)
+	// 等待丢弃及至少一次连接失败都计入错误数，保证之后走的是重连流程
+	for i := 0; output.errorTotal.Get() < errorTotal+2; i++ {
+		if i >= 500 {
+			t.Fatal("grpc output stream is not failed")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	aggregator := &mockLogAggregator{received: make(chan struct{}, 10)}
+	server := grpc.NewServer()
+	logpb.RegisterLogAggregatorServer(server, aggregator)
+	listener, err = net.Listen("tcp", target)
+	assert.NoError(t, err)
+	go func() { _ = server.Serve(listener) }()
+	defer server.Stop()
+
+	aggregator.waitEvents(t, 2)
+	output.publish("/data/test.log", &beat.Event{Fields: common.MapStr{"data": "d"}})
+	aggregator.waitEvents(t, 1)
+
+	aggregator.mutex.Lock()
+	defer aggregator.mutex.Unlock()
+	datas := make([]string, 0, len(aggregator.events))
+	for _, event := range aggregator.events {
+		datas = append(datas, event.Data)
+	}
+	assert.Equal(t, []string{"a", "b", "d"}, datas)
+	first := aggregator.events[0]
+	assert.Equal(t, int64(999970001), first.DataId)
+	assert.Equal(t, "/data/test.log", first.Source)
+	assert.Equal(t, time.Unix(1600000000, 0).UnixNano(), first.Timestamp)
+	assert.Equal(t, `{"data":"a"}`, first.Fields)
+	assert.Equal(t, sentTotal+3, output.sentTotal.Get())
+}
+
+// TestGRPCOutputFormat: json只发送Fields，text只发送Data
+func TestGRPCOutputFormat(t *testing.T) {
+	event := &beat.Event{Fields: common.MapStr{"data": "a"}}
+	for format, expected := range map[string]*logpb.LogEvent{
+		"":     {Data: "a", Fields: `{"data":"a"}`},
+		"raw":  {Data: "a", Fields: `{"data":"a"}`},
+		"json": {Fields: `{"data":"a"}`},
+		"text": {Data: "a"},
+	} {
+		output := &grpcOutput{taskID: "test", format: format, buffer: make(chan *logpb.LogEvent, 1)}
+		output.publish("/data/test.log", event)
+		logEvent := <-output.buffer
+		assert.Equal(t, expected.Data, logEvent.Data, format)
+		assert.Equal(t, expected.Fields, logEvent.Fields, format)
+	}
+}
@@ -0,0 +1,329 @@
+// Tencent is pleased to support the open source community by making bkunifylogbeat 蓝鲸日志采集器 available.
+//
+// Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+//
+// bkunifylogbeat 蓝鲸日志采集器 is licensed under the MIT License.
+//
+// License for bkunifylogbeat 蓝鲸日志采集器:
+// --------------------------------------------------------------------
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+// documentation files (the "Software"), to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software,
+// and to permit persons to whom the Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all copies or substantial
+// portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+// LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN
+// NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+// WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+// SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package task
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	cfg "github.com/TencentBlueKing/bkunifylogbeat/config"
+	"github.com/TencentBlueKing/bkunifylogbeat/task/logpb"
+	"github.com/TencentBlueKing/collector-go-sdk/v2/bkbeat/beat"
+	"github.com/TencentBlueKing/collector-go-sdk/v2/bkbeat/bkmonitoring"
+	"github.com/TencentBlueKing/collector-go-sdk/v2/bkbeat/logp"
+	"github.com/elastic/beats/libbeat/common/transport/tlscommon"
+	"github.com/elastic/beats/libbeat/monitoring"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/resolver"
+	"google.golang.org/grpc/resolver/manual"
+)
+
+var (
+	senderGRPCSentTotal  = bkmonitoring.NewInt("sender_grpc_sent_total")
+	senderGRPCAckedTotal = bkmonitoring.NewInt("sender_grpc_acked_total")
+	senderGRPCErrorTotal = bkmonitoring.NewInt("sender_grpc_error_total")
+)
+
+// grpcSenderEntry: 待确认的打包事件，payload为空表示仅需更新采集状态
+type grpcSenderEntry struct {
+	state   interface{}
+	payload []byte
+	done    bool
+}
+
+// grpcInFlight: 流上已发送未确认的打包事件
+type grpcInFlight struct {
+	seq   uint64
+	entry *grpcSenderEntry
+}
+
+// grpcSender: 替代beat.SendEvent将打包事件通过gRPC双向流发送到第三方采集服务
+// 多个流由客户端负载均衡分散到各服务端，服务端确认后才按发送顺序提交采集状态，断线时未确认的事件在其他流上重发
+type grpcSender struct {
+	taskID     string
+	dataID     int
+	config     cfg.GRPCSenderConfig
+	conn       *grpc.ClientConn
+	ack        PublisherFunc
+	input      chan *grpcSenderEntry
+	queue      chan *grpcSenderEntry
+	acked      chan *grpcSenderEntry
+	done       <-chan struct{}
+	wg         sync.WaitGroup
+	sentTotal  *monitoring.Int
+	ackedTotal *monitoring.Int
+	errorTotal *monitoring.Int
+}
+
+// newGRPCSender 生成gRPC发送实例，连接及流在后台建立
+func newGRPCSender(taskConfig *cfg.TaskConfig, done <-chan struct{}, ack PublisherFunc) (*grpcSender, error) {
+	config := taskConfig.GRPCSender
+	target, options, err := grpcSenderDialOptions(config)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := grpc.Dial(target, options...)
+	if err != nil {
+		return nil, fmt.Errorf("dial grpc_sender failed, err=>%v", err)
+	}
+	streams := config.Streams
+	if streams == 0 {
+		streams = len(config.Hosts)
+	}
+	sender := &grpcSender{
+		taskID:     taskConfig.ID,
+		dataID:     taskConfig.DataID,
+		config:     config,
+		conn:       conn,
+		ack:        ack,
+		input:      make(chan *grpcSenderEntry),
+		queue:      make(chan *grpcSenderEntry, config.MaxInFlight),
+		acked:      make(chan *grpcSenderEntry),
+		done:       done,
+		sentTotal:  newIntWithDataID(taskConfig.DataID, "sender_grpc_sent_total"),
+		ackedTotal: newIntWithDataID(taskConfig.DataID, "sender_grpc_acked_total"),
+		errorTotal: newIntWithDataID(taskConfig.DataID, "sender_grpc_error_total"),
+	}
+	for i := 0; i < streams; i++ {
+		sender.wg.Add(1)
+		go sender.worker()
+	}
+	go sender.run()
+	go func() {
+		sender.wg.Wait()
+		_ = sender.conn.Close()
+	}()
+	return sender, nil
+}
+
+// grpcSenderDialOptions: 多个地址时使用手动解析器提供全部地址，单个地址时由gRPC按target解析(支持dns:///)
+func grpcSenderDialOptions(config cfg.GRPCSenderConfig) (string, []grpc.DialOption, error) {
+	transport := grpc.WithInsecure()
+	if config.TLS != nil {
+		tlsConfig, err := tlscommon.LoadTLSConfig(config.TLS)
+		if err != nil {
+			return "", nil, fmt.Errorf("load grpc_sender tls config failed, err=>%v", err)
+		}
+		if tlsConfig != nil {
+			transport = grpc.WithTransportCredentials(credentials.NewTLS(tlsConfig.BuildModuleConfig("")))
+		}
+	}
+	options := []grpc.DialOption{
+		transport,
+		grpc.WithDefaultServiceConfig(fmt.Sprintf(`{"loadBalancingConfig":[{"%s":{}}]}`, config.LoadBalance)),
+	}
+
+	if len(config.Hosts) == 1 {
+		return config.Hosts[0], options, nil
+	}
+	addresses := make([]resolver.Address, 0, len(config.Hosts))
+	for _, host := range config.Hosts {
+		addresses = append(addresses, resolver.Address{Addr: host})
+	}
+	r := manual.NewBuilderWithScheme("bkunifylogbeat")
+	r.InitialState(resolver.State{Addresses: addresses})
+	options = append(options, grpc.WithResolvers(r))
+	return r.Scheme() + ":///" + strings.Join(config.Hosts, ","), options, nil
+}
+
+// publish 作为Sender的PublisherFunc，任务停止时返回false
+func (s *grpcSender) publish(event beat.Event) bool {
+	entry := &grpcSenderEntry{state: event.Private, done: event.Fields == nil}
+	if event.Fields != nil {
+		payload, err := json.Marshal(event.Fields)
+		if err != nil {
+			logp.L.Errorf("marshal grpc event failed, task_id:%s, err=>%v", s.taskID, err)
+			s.addError()
+			entry.done = true
+		}
+		entry.payload = payload
+	}
+	select {
+	case <-s.done:
+		return false
+	case s.input <- entry:
+		return true
+	}
+}
+
+// run 按发送顺序记录待确认事件，确认后提交采集状态
+func (s *grpcSender) run() {
+	var pending []*grpcSenderEntry
+	for {
+		// 未确认事件数达到上限时不再接收新事件，queue的容量与上限一致，放入时不会阻塞
+		var input chan *grpcSenderEntry
+		if len(pending) < s.config.MaxInFlight {
+			input = s.input
+		}
+		select {
+		case <-s.done:
+			return
+		case entry := <-input:
+			pending = append(pending, entry)
+			if !entry.done {
+				s.queue <- entry
+			}
+		case entry := <-s.acked:
+			entry.done = true
+		}
+
+		n := 0
+		for n < len(pending) && pending[n].done {
+			s.ack(beat.Event{Fields: nil, Private: pending[n].state})
+			n++
+		}
+		if n > 0 {
+			pending = append(pending[:0], pending[n:]...)
+		}
+	}
+}
+
+// worker 维护一个发送流，断线后按指数退避重建
+func (s *grpcSender) worker() {
+	defer s.wg.Done()
+	backoff := grpcMinBackoff
+	for {
+		err := s.stream()
+		if err == nil {
+			return
+		}
+		select {
+		case <-s.done:
+			return
+		default:
+		}
+		s.addError()
+		logp.L.Errorf("grpc sender stream failed, task_id:%s, retry in %s, err=>%v", s.taskID, backoff, err)
+		select {
+		case <-s.done:
+			return
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+		if backoff > s.config.MaxBackoff {
+			backoff = s.config.MaxBackoff
+		}
+	}
+}
+
+// stream 建立一次发送流，流异常时将未确认的事件放回队列并返回错误，任务停止时返回nil
+func (s *grpcSender) stream() error {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if s.config.Token != "" {
+		ctx = metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+s.config.Token)
+	}
+	// 任务停止时取消等待连接及接收
+	go func() {
+		select {
+		case <-s.done:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	stream, err := logpb.NewEventCollectorClient(s.conn).Send(ctx, grpc.WaitForReady(true))
+	if err != nil {
+		return err
+	}
+
+	var (
+		mutex    sync.Mutex
+		inFlight []grpcInFlight
+		seq      uint64
+	)
+	recvErr := make(chan error, 1)
+	go func() {
+		for {
+			ack, err := stream.Recv()
+			if err != nil {
+				recvErr <- err
+				return
+			}
+			mutex.Lock()
+			n := 0
+			for n < len(inFlight) && inFlight[n].seq <= ack.Seq {
+				n++
+			}
+			confirmed := inFlight[:n:n]
+			inFlight = inFlight[n:]
+			mutex.Unlock()
+			for _, item := range confirmed {
+				s.ackedTotal.Add(1)
+				senderGRPCAckedTotal.Add(1)
+				select {
+				case <-s.done:
+					recvErr <- nil
+					return
+				case s.acked <- item.entry:
+				}
+			}
+		}
+	}()
+	requeue := func() {
+		mutex.Lock()
+		defer mutex.Unlock()
+		for _, item := range inFlight {
+			s.queue <- item.entry
+		}
+		inFlight = nil
+	}
+
+	for {
+		select {
+		case <-s.done:
+			_ = stream.CloseSend()
+			return nil
+		case err = <-recvErr:
+			if err == nil {
+				return nil
+			}
+			requeue()
+			return err
+		case entry := <-s.queue:
+			seq++
+			mutex.Lock()
+			inFlight = append(inFlight, grpcInFlight{seq: seq, entry: entry})
+			mutex.Unlock()
+			err = stream.Send(&logpb.EventPackage{Seq: seq, DataId: int64(s.dataID), Payload: entry.payload})
+			if err != nil {
+				cancel()
+				<-recvErr
+				requeue()
+				return err
+			}
+			s.sentTotal.Add(1)
+			senderGRPCSentTotal.Add(1)
+		}
+	}
+}
+
+func (s *grpcSender) addError() {
+	s.errorTotal.Add(1)
+	senderGRPCErrorTotal.Add(1)
+}
@@ -0,0 +1,118 @@
+// Tencent is pleased to support the open source community by making bkunifylogbeat 蓝鲸日志采集器 available.
+//
+// Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+//
+// bkunifylogbeat 蓝鲸日志采集器 is licensed under the MIT License.
+//
+// License for bkunifylogbeat 蓝鲸日志采集器:
+// --------------------------------------------------------------------
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+// documentation files (the "Software"), to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software,
+// and to permit persons to whom the Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all copies or substantial
+// portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+// LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN
+// NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+// WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+// SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package task
+
+import (
+	"errors"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	cfg "github.com/TencentBlueKing/bkunifylogbeat/config"
+	"github.com/TencentBlueKing/bkunifylogbeat/task/logpb"
+	"github.com/TencentBlueKing/collector-go-sdk/v2/bkbeat/beat"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// mockEventCollector: 第一个流收到事件后不确认并断开，之后的流逐个确认
+type mockEventCollector struct {
+	mutex    sync.Mutex
+	streams  int
+	payloads []string
+	tokens   []string
+}
+
+func (c *mockEventCollector) Send(stream logpb.EventCollector_SendServer) error {
+	c.mutex.Lock()
+	c.streams++
+	first := c.streams == 1
+	md, _ := metadata.FromIncomingContext(stream.Context())
+	c.tokens = append(c.tokens, md.Get("authorization")...)
+	c.mutex.Unlock()
+
+	for {
+		pkg, err := stream.Recv()
+		if err != nil {
+			return err
+		}
+		if first {
+			return errors.New("collector restarting")
+		}
+		c.mutex.Lock()
+		c.payloads = append(c.payloads, string(pkg.Payload))
+		c.mutex.Unlock()
+		if err = stream.Send(&logpb.EventAck{Seq: pkg.Seq}); err != nil {
+			return err
+		}
+	}
+}
+
+// TestGRPCSender: 断线时未确认的事件重发，采集状态按发送顺序提交
+func TestGRPCSender(t *testing.T) {
+	collector := &mockEventCollector{}
+	server := grpc.NewServer()
+	logpb.RegisterEventCollectorServer(server, collector)
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	go func() { _ = server.Serve(listener) }()
+	defer server.Stop()
+
+	taskConfig := &cfg.TaskConfig{ID: "test", DataID: 1}
+	taskConfig.GRPCSender = cfg.GRPCSenderConfig{
+		Hosts:       []string{listener.Addr().String(), listener.Addr().String()},
+		LoadBalance: "round_robin",
+		Streams:     1,
+		Token:       "secret",
+		MaxInFlight: 10,
+		MaxBackoff:  10 * time.Millisecond,
+	}
+	acked := make(chan interface{}, 3)
+	done := make(chan struct{})
+	defer close(done)
+	sender, err := newGRPCSender(taskConfig, done, func(event beat.Event) bool {
+		acked <- event.Private
+		return true
+	})
+	assert.NoError(t, err)
+
+	assert.True(t, sender.publish(beat.Event{Fields: beat.MapStr{"data": "a"}, Private: 1}))
+	assert.True(t, sender.publish(beat.Event{Fields: nil, Private: 2}))
+	assert.True(t, sender.publish(beat.Event{Fields: beat.MapStr{"data": "b"}, Private: 3}))
+	for _, expected := range []interface{}{1, 2, 3} {
+		select {
+		case state := <-acked:
+			assert.Equal(t, expected, state)
+		case <-time.After(5 * time.Second):
+			t.Fatalf("state %v is not acked", expected)
+		}
+	}
+
+	collector.mutex.Lock()
+	defer collector.mutex.Unlock()
+	// 重发的事件可能排在新事件之后，采集状态仍按发送顺序提交
+	assert.ElementsMatch(t, []string{`{"data":"a"}`, `{"data":"b"}`}, collector.payloads)
+	assert.Equal(t, "Bearer secret", collector.tokens[0])
+}
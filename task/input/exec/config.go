@@ -0,0 +1,43 @@
+// Tencent is pleased to support the open source community by making bkunifylogbeat 蓝鲸日志采集器 available.
+//
+// Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+//
+// bkunifylogbeat 蓝鲸日志采集器 is licensed under the MIT License.
+//
+// License for bkunifylogbeat 蓝鲸日志采集器:
+// --------------------------------------------------------------------
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+// documentation files (the "Software"), to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software,
+// and to permit persons to whom the Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all copies or substantial
+// portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+// LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN
+// NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+// WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+// SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package exec
+
+import "time"
+
+var defaultConfig = config{
+	Interval: time.Minute,
+	Timeout:  30 * time.Second,
+}
+
+type config struct {
+	Command string   `config:"command" validate:"required"`
+	Args    []string `config:"args"`
+	// 额外的环境变量，形如KEY=VALUE，与采集器的环境变量合并
+	Env []string `config:"env"`
+	Dir string   `config:"dir"`
+	// 执行间隔及单次执行的超时时间，超时后结束进程
+	Interval time.Duration `config:"interval" validate:"min=0,nonzero"`
+	Timeout  time.Duration `config:"timeout" validate:"min=0,nonzero"`
+	// 是否同时采集标准错误输出
+	IncludeStderr bool `config:"include_stderr"`
+}
@@ -0,0 +1,202 @@
+// Tencent is pleased to support the open source community by making bkunifylogbeat 蓝鲸日志采集器 available.
+//
+// Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+//
+// bkunifylogbeat 蓝鲸日志采集器 is licensed under the MIT License.
+//
+// License for bkunifylogbeat 蓝鲸日志采集器:
+// --------------------------------------------------------------------
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+// documentation files (the "Software"), to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software,
+// and to permit persons to whom the Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all copies or substantial
+// portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+// LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN
+// NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+// WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+// SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package exec
+
+import (
+	"bufio"
+	"context"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/TencentBlueKing/collector-go-sdk/v2/bkbeat/bkmonitoring"
+	"github.com/elastic/beats/filebeat/channel"
+	"github.com/elastic/beats/filebeat/input"
+	"github.com/elastic/beats/filebeat/util"
+	"github.com/elastic/beats/libbeat/beat"
+	"github.com/elastic/beats/libbeat/common"
+	"github.com/elastic/beats/libbeat/logp"
+)
+
+var (
+	execRunTotal = bkmonitoring.NewInt("input_exec_run_total")
+	execFailed   = bkmonitoring.NewInt("input_exec_failed")
+	execTimeout  = bkmonitoring.NewInt("input_exec_timeout")
+)
+
+func init() {
+	err := input.Register("exec", NewInput)
+	if err != nil {
+		panic(err)
+	}
+}
+
+// Input 周期执行命令，输出的每一行作为一个事件进入任务的过滤及处理链
+type Input struct {
+	started bool
+	mutex   sync.Mutex
+	outlet  channel.Outleter
+
+	config config
+
+	done chan struct{}
+	wg   sync.WaitGroup
+}
+
+// NewInput: creates a new exec input
+func NewInput(
+	cfg *common.Config,
+	outletFactory channel.Connector,
+	context input.Context,
+) (input.Input, error) {
+	config := defaultConfig
+	err := cfg.Unpack(&config)
+	if err != nil {
+		return nil, err
+	}
+
+	outlet, err := outletFactory(cfg, context.DynamicFields)
+	if err != nil {
+		return nil, err
+	}
+	return &Input{
+		outlet: outlet,
+		config: config,
+		done:   make(chan struct{}),
+	}, nil
+}
+
+func (p *Input) Reload() {}
+
+// Run 启动执行协程，只在首次调用时生效
+func (p *Input) Run() {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if !p.started {
+		p.wg.Add(1)
+		go p.run()
+		p.started = true
+	}
+}
+
+// Stop 停止执行，正在执行的命令会被结束
+func (p *Input) Stop() {
+	logp.Info("Stopping exec input, command=%s", p.config.Command)
+	p.mutex.Lock()
+	select {
+	case <-p.done:
+	default:
+		close(p.done)
+	}
+	p.mutex.Unlock()
+
+	p.wg.Wait()
+	_ = p.outlet.Close()
+}
+
+// Wait stop the current server
+func (p *Input) Wait() {
+	p.Stop()
+}
+
+func (p *Input) run() {
+	defer p.wg.Done()
+	ticker := time.NewTicker(p.config.Interval)
+	defer ticker.Stop()
+	for {
+		p.execute()
+		select {
+		case <-p.done:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// execute: 执行一次命令，超时或任务停止时结束进程，已输出的行仍会发送
+func (p *Input) execute() {
+	ctx, cancel := context.WithTimeout(context.Background(), p.config.Timeout)
+	defer cancel()
+	go func() {
+		select {
+		case <-p.done:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	execRunTotal.Add(1)
+	cmd := exec.CommandContext(ctx, p.config.Command, p.config.Args...)
+	cmd.Dir = p.config.Dir
+	if len(p.config.Env) > 0 {
+		cmd.Env = append(os.Environ(), p.config.Env...)
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		execFailed.Add(1)
+		logp.Err("exec %s failed, err=>%v", p.config.Command, err)
+		return
+	}
+	if p.config.IncludeStderr {
+		// StdoutPipe之后cmd.Stdout为管道的写端，标准错误输出写入同一管道
+		cmd.Stderr = cmd.Stdout
+	}
+	if err = cmd.Start(); err != nil {
+		execFailed.Add(1)
+		logp.Err("exec %s failed, err=>%v", p.config.Command, err)
+		return
+	}
+
+	scanner := bufio.NewScanner(stdout)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if line == "" {
+			continue
+		}
+		p.publish(line)
+	}
+	err = cmd.Wait()
+	if ctx.Err() == context.DeadlineExceeded {
+		execTimeout.Add(1)
+		logp.Err("exec %s timeout after %v", p.config.Command, p.config.Timeout)
+	} else if err != nil {
+		execFailed.Add(1)
+		logp.Err("exec %s failed, err=>%v", p.config.Command, err)
+	}
+}
+
+func (p *Input) publish(line string) {
+	data := util.NewData()
+	data.Event = beat.Event{
+		Timestamp: time.Now(),
+		Fields: common.MapStr{
+			"data": line,
+			"exec": common.MapStr{"command": p.config.Command},
+		},
+	}
+	p.outlet.OnEvent(data)
+}
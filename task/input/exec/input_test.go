@@ -0,0 +1,64 @@
+// Tencent is pleased to support the open source community by making bkunifylogbeat 蓝鲸日志采集器 available.
+//
+// Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+//
+// bkunifylogbeat 蓝鲸日志采集器 is licensed under the MIT License.
+//
+// License for bkunifylogbeat 蓝鲸日志采集器:
+// --------------------------------------------------------------------
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+// documentation files (the "Software"), to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software,
+// and to permit persons to whom the Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all copies or substantial
+// portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+// LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN
+// NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+// WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+// SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package exec
+
+import (
+	"testing"
+	"time"
+
+	"github.com/elastic/beats/filebeat/util"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockOutlet struct {
+	events []*util.Data
+}
+
+func (o *mockOutlet) Close() error              { return nil }
+func (o *mockOutlet) Done() <-chan struct{}     { return nil }
+func (o *mockOutlet) OnEvent(d *util.Data) bool { o.events = append(o.events, d); return true }
+
+// TestExecute: 测试命令输出按行转换为事件，以及环境变量和超时
+func TestExecute(t *testing.T) {
+	outlet := &mockOutlet{}
+	config := defaultConfig
+	config.Command = "sh"
+	config.Args = []string{"-c", "echo $GREETING; echo; echo err >&2"}
+	config.Env = []string{"GREETING=hello"}
+	config.IncludeStderr = true
+	p := &Input{outlet: outlet, config: config, done: make(chan struct{})}
+
+	p.execute()
+	assert.Len(t, outlet.events, 2)
+	assert.Equal(t, "hello", outlet.events[0].Event.Fields["data"])
+	assert.Equal(t, "err", outlet.events[1].Event.Fields["data"])
+
+	// 超时后结束进程，已输出的行仍会发送
+	outlet.events = nil
+	p.config.Args = []string{"-c", "echo started; exec sleep 5"}
+	p.config.Timeout = 100 * time.Millisecond
+	start := time.Now()
+	p.execute()
+	assert.True(t, time.Since(start) < 2*time.Second)
+	assert.Len(t, outlet.events, 1)
+}
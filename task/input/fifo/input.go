@@ -0,0 +1,260 @@
+// Tencent is pleased to support the open source community by making bkunifylogbeat 蓝鲸日志采集器 available.
+//
+// Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+//
+// bkunifylogbeat 蓝鲸日志采集器 is licensed under the MIT License.
+//
+// License for bkunifylogbeat 蓝鲸日志采集器:
+// --------------------------------------------------------------------
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+// documentation files (the "Software"), to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software,
+// and to permit persons to whom the Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all copies or substantial
+// portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+// LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN
+// NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+// WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+// SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package fifo
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/TencentBlueKing/bkunifylogbeat/task/input/socket"
+	"github.com/TencentBlueKing/collector-go-sdk/v2/bkbeat/bkmonitoring"
+	"github.com/elastic/beats/filebeat/channel"
+	"github.com/elastic/beats/filebeat/input"
+	"github.com/elastic/beats/filebeat/util"
+	"github.com/elastic/beats/libbeat/beat"
+	"github.com/elastic/beats/libbeat/common"
+	"github.com/elastic/beats/libbeat/logp"
+)
+
+var (
+	fifoReceived = bkmonitoring.NewInt("input_fifo_received")
+	fifoDropped  = bkmonitoring.NewInt("input_fifo_dropped")
+)
+
+func init() {
+	err := input.Register("fifo", NewInput)
+	if err != nil {
+		panic(err)
+	}
+}
+
+// Input 读取命名管道，写入方全部关闭后继续等待新的写入方，管道被重新创建时重新打开
+type Input struct {
+	started bool
+	mutex   sync.Mutex
+	outlet  channel.Outleter
+
+	config config
+	framer socket.Framer
+	files  map[string]*os.File
+	done   chan struct{}
+	wg     sync.WaitGroup
+}
+
+// NewInput: creates a new fifo input
+func NewInput(
+	cfg *common.Config,
+	outletFactory channel.Connector,
+	context input.Context,
+) (input.Input, error) {
+	config := defaultConfig
+	err := cfg.Unpack(&config)
+	if err != nil {
+		return nil, err
+	}
+	if len(config.LineDelimiter) != 1 {
+		return nil, fmt.Errorf("fifo line_delimiter must be a single byte")
+	}
+	framer, err := socket.NewFramer("newline", config.LineDelimiter[0], 0, config.MaxMessageSize)
+	if err != nil {
+		return nil, err
+	}
+
+	outlet, err := outletFactory(cfg, context.DynamicFields)
+	if err != nil {
+		return nil, err
+	}
+	return &Input{
+		outlet: outlet,
+		config: config,
+		framer: framer,
+		files:  make(map[string]*os.File),
+		done:   make(chan struct{}),
+	}, nil
+}
+
+func (p *Input) Reload() {}
+
+// Run 每个管道启动一个读取协程，只在首次调用时生效
+func (p *Input) Run() {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if p.started {
+		return
+	}
+	p.started = true
+	for _, path := range p.config.Paths {
+		p.wg.Add(1)
+		go p.run(path)
+	}
+}
+
+// Stop 关闭所有管道，等待读取协程退出
+func (p *Input) Stop() {
+	logp.Info("Stopping fifo input, paths=%v", p.config.Paths)
+	p.mutex.Lock()
+	select {
+	case <-p.done:
+	default:
+		close(p.done)
+	}
+	for _, f := range p.files {
+		_ = f.Close()
+	}
+	p.mutex.Unlock()
+
+	p.wg.Wait()
+	_ = p.outlet.Close()
+}
+
+// Wait stop the current server
+func (p *Input) Wait() {
+	p.Stop()
+}
+
+// open 以非阻塞方式打开管道，没有写入方时也不会阻塞
+func (p *Input) open(path string) (*os.File, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if info.Mode()&os.ModeNamedPipe == 0 {
+		return nil, fmt.Errorf("%s is not a named pipe", path)
+	}
+	f, err := os.OpenFile(path, os.O_RDONLY|syscall.O_NONBLOCK, 0)
+	if err != nil {
+		return nil, err
+	}
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	if p.isDone() {
+		_ = f.Close()
+		return nil, io.EOF
+	}
+	p.files[path] = f
+	return f, nil
+}
+
+func (p *Input) close(path string, f *os.File) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	delete(p.files, path)
+	_ = f.Close()
+}
+
+func (p *Input) run(path string) {
+	defer p.wg.Done()
+	for !p.isDone() {
+		f, err := p.open(path)
+		if err != nil {
+			if !p.isDone() {
+				logp.Debug("fifo", "open %s failed, err=>%v", path, err)
+			}
+		} else {
+			p.read(path, f)
+			p.close(path, f)
+		}
+		select {
+		case <-p.done:
+			return
+		case <-time.After(p.config.RetryInterval):
+		}
+	}
+}
+
+// read 逐条读取消息，写入方关闭(EOF)后等待新的写入方，管道文件被替换时返回以重新打开
+func (p *Input) read(path string, f *os.File) {
+	reader := bufio.NewReaderSize(f, p.config.MaxMessageSize)
+	for {
+		message, err := p.framer(reader)
+		if err == socket.ErrMessageTooLarge {
+			fifoDropped.Add(1)
+			continue
+		}
+		p.publish(path, message)
+		if err == io.EOF {
+			if !p.sameFile(path, f) {
+				return
+			}
+			select {
+			case <-p.done:
+				return
+			case <-time.After(p.config.RetryInterval):
+			}
+			continue
+		}
+		if err != nil {
+			if !p.isDone() {
+				logp.Err("read %s failed, err=>%v", path, err)
+			}
+			return
+		}
+	}
+}
+
+// sameFile 路径是否仍指向已打开的管道
+func (p *Input) sameFile(path string, f *os.File) bool {
+	opened, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	current, err := os.Stat(path)
+	if err != nil {
+		return false
+	}
+	return os.SameFile(opened, current)
+}
+
+// publish: 发送一条消息，事件不带采集状态
+func (p *Input) publish(path string, message string) {
+	message = strings.TrimRight(message, p.config.LineDelimiter+"\r")
+	if message == "" {
+		return
+	}
+	fifoReceived.Add(1)
+	data := util.NewData()
+	data.Event = beat.Event{
+		Timestamp: time.Now(),
+		Fields: common.MapStr{
+			"data": message,
+			"fifo": common.MapStr{"path": path},
+		},
+	}
+	p.outlet.OnEvent(data)
+}
+
+func (p *Input) isDone() bool {
+	select {
+	case <-p.done:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,92 @@
+// Tencent is pleased to support the open source community by making bkunifylogbeat 蓝鲸日志采集器 available.
+//
+// Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+//
+// bkunifylogbeat 蓝鲸日志采集器 is licensed under the MIT License.
+//
+// License for bkunifylogbeat 蓝鲸日志采集器:
+// --------------------------------------------------------------------
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+// documentation files (the "Software"), to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software,
+// and to permit persons to whom the Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all copies or substantial
+// portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+// LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN
+// NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+// WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+// SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+// +build !windows
+
+package fifo
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/TencentBlueKing/bkunifylogbeat/task/input/socket"
+	"github.com/elastic/beats/filebeat/util"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockOutlet struct {
+	mutex  sync.Mutex
+	events []*util.Data
+}
+
+func (o *mockOutlet) Close() error          { return nil }
+func (o *mockOutlet) Done() <-chan struct{} { return nil }
+func (o *mockOutlet) OnEvent(d *util.Data) bool {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+	o.events = append(o.events, d)
+	return true
+}
+
+func (o *mockOutlet) texts() []string {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+	var texts []string
+	for _, d := range o.events {
+		texts = append(texts, d.Event.Fields["data"].(string))
+	}
+	return texts
+}
+
+// TestFIFO: 测试多个写入方先后写入、写入方关闭后继续读取及停止
+func TestFIFO(t *testing.T) {
+	dir, err := ioutil.TempDir("", "fifo")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "app.pipe")
+	assert.NoError(t, syscall.Mkfifo(path, 0600))
+
+	outlet := &mockOutlet{}
+	config := defaultConfig
+	config.Paths = []string{path}
+	config.RetryInterval = 10 * time.Millisecond
+	framer, err := socket.NewFramer("newline", '\n', 0, config.MaxMessageSize)
+	assert.NoError(t, err)
+	p := &Input{outlet: outlet, config: config, framer: framer, files: make(map[string]*os.File), done: make(chan struct{})}
+	p.Run()
+
+	for _, content := range []string{"first\nsecond\n", "third\r\nno newline"} {
+		w, err := os.OpenFile(path, os.O_WRONLY, 0)
+		assert.NoError(t, err)
+		_, err = w.WriteString(content)
+		assert.NoError(t, err)
+		assert.NoError(t, w.Close())
+		time.Sleep(100 * time.Millisecond)
+	}
+	p.Stop()
+	assert.Equal(t, []string{"first", "second", "third", "no newline"}, outlet.texts())
+}
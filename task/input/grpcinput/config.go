@@ -0,0 +1,45 @@
+// Tencent is pleased to support the open source community by making bkunifylogbeat 蓝鲸日志采集器 available.
+//
+// Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+//
+// bkunifylogbeat 蓝鲸日志采集器 is licensed under the MIT License.
+//
+// License for bkunifylogbeat 蓝鲸日志采集器:
+// --------------------------------------------------------------------
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+// documentation files (the "Software"), to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software,
+// and to permit persons to whom the Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all copies or substantial
+// portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+// LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN
+// NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+// WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+// SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package grpcinput
+
+import (
+	"github.com/dustin/go-humanize"
+	"github.com/elastic/beats/libbeat/common/transport/tlscommon"
+)
+
+var defaultConfig = config{
+	Host:                 ":50051",
+	MaxMessageSize:       4 * humanize.MiByte,
+	MaxConcurrentStreams: 100,
+}
+
+type config struct {
+	Host string `config:"host"`
+	// 配置后每个流需要在metadata中携带authorization: Bearer <token>或x-token: <token>
+	Token string `config:"token"`
+	// 单个LogBatch的最大字节数
+	MaxMessageSize int `config:"max_message_size" validate:"min=1"`
+	// 单个连接的最大并发流数
+	MaxConcurrentStreams uint32                  `config:"max_concurrent_streams" validate:"min=1"`
+	TLS                  *tlscommon.ServerConfig `config:"ssl"`
+}
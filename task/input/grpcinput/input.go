@@ -0,0 +1,226 @@
+// Tencent is pleased to support the open source community by making bkunifylogbeat 蓝鲸日志采集器 available.
+//
+// Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+//
+// bkunifylogbeat 蓝鲸日志采集器 is licensed under the MIT License.
+//
+// License for bkunifylogbeat 蓝鲸日志采集器:
+// --------------------------------------------------------------------
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+// documentation files (the "Software"), to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software,
+// and to permit persons to whom the Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all copies or substantial
+// portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+// LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN
+// NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+// WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+// SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package grpcinput
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/TencentBlueKing/bkunifylogbeat/task/logpb"
+	"github.com/TencentBlueKing/collector-go-sdk/v2/bkbeat/bkmonitoring"
+	"github.com/elastic/beats/filebeat/channel"
+	"github.com/elastic/beats/filebeat/input"
+	"github.com/elastic/beats/filebeat/util"
+	"github.com/elastic/beats/libbeat/beat"
+	"github.com/elastic/beats/libbeat/common"
+	"github.com/elastic/beats/libbeat/common/transport/tlscommon"
+	"github.com/elastic/beats/libbeat/logp"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+var (
+	grpcReceived     = bkmonitoring.NewInt("input_grpc_received")
+	grpcUnauthorized = bkmonitoring.NewInt("input_grpc_unauthorized")
+)
+
+func init() {
+	err := input.Register("grpc", NewInput)
+	if err != nil {
+		panic(err)
+	}
+}
+
+// Input 提供gRPC LogCollector服务接收应用推送的日志，服务定义见task/logpb/logrecord.proto
+// 每批日志全部进入任务的处理链后才回复PushAck，处理链阻塞时客户端随之等待
+type Input struct {
+	started bool
+	mutex   sync.Mutex
+	outlet  channel.Outleter
+
+	config config
+	server *grpc.Server
+	wg     sync.WaitGroup
+}
+
+// NewInput: creates a new grpc input
+func NewInput(
+	cfg *common.Config,
+	outletFactory channel.Connector,
+	context input.Context,
+) (input.Input, error) {
+	config := defaultConfig
+	err := cfg.Unpack(&config)
+	if err != nil {
+		return nil, err
+	}
+
+	options := []grpc.ServerOption{
+		grpc.MaxRecvMsgSize(config.MaxMessageSize),
+		grpc.MaxConcurrentStreams(config.MaxConcurrentStreams),
+	}
+	if config.TLS != nil {
+		tlsConfig, err := tlscommon.LoadTLSServerConfig(config.TLS)
+		if err != nil {
+			return nil, fmt.Errorf("load grpc tls config failed, err=>%v", err)
+		}
+		if tlsConfig != nil {
+			options = append(options, grpc.Creds(credentials.NewTLS(tlsConfig.BuildModuleConfig(config.Host))))
+		}
+	}
+
+	outlet, err := outletFactory(cfg, context.DynamicFields)
+	if err != nil {
+		return nil, err
+	}
+	p := &Input{
+		outlet: outlet,
+		config: config,
+	}
+	options = append(options, grpc.StreamInterceptor(p.authorize))
+	p.server = grpc.NewServer(options...)
+	logpb.RegisterLogCollectorServer(p.server, p)
+	return p, nil
+}
+
+func (p *Input) Reload() {}
+
+// Run 启动gRPC服务，只在首次调用时生效
+func (p *Input) Run() {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if p.started {
+		return
+	}
+	listener, err := net.Listen("tcp", p.config.Host)
+	if err != nil {
+		logp.Err("grpc input listen on %s failed, err=>%v", p.config.Host, err)
+		return
+	}
+	p.started = true
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		logp.Info("grpc input listening on %s", p.config.Host)
+		if err := p.server.Serve(listener); err != nil {
+			logp.Err("grpc input serve on %s failed, err=>%v", p.config.Host, err)
+		}
+	}()
+}
+
+// Stop 停止gRPC服务，未回复PushAck的批次由客户端重发
+func (p *Input) Stop() {
+	logp.Info("Stopping grpc input on %s", p.config.Host)
+	p.server.Stop()
+	p.wg.Wait()
+	_ = p.outlet.Close()
+}
+
+// Wait stop the current server
+func (p *Input) Wait() {
+	p.Stop()
+}
+
+// authorize: 在流建立时校验metadata中的token
+func (p *Input) authorize(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	if p.config.Token == "" {
+		return handler(srv, ss)
+	}
+	md, _ := metadata.FromIncomingContext(ss.Context())
+	var token string
+	if values := md.Get("x-token"); len(values) > 0 {
+		token = values[0]
+	}
+	if values := md.Get("authorization"); len(values) > 0 && strings.HasPrefix(values[0], "Bearer ") {
+		token = strings.TrimPrefix(values[0], "Bearer ")
+	}
+	if subtle.ConstantTimeCompare([]byte(token), []byte(p.config.Token)) != 1 {
+		grpcUnauthorized.Add(1)
+		return status.Error(codes.Unauthenticated, "invalid token")
+	}
+	return handler(srv, ss)
+}
+
+// Push 逐批接收日志，每批发送完成后回复已接收条数
+func (p *Input) Push(stream logpb.LogCollector_PushServer) error {
+	remote := ""
+	if pr, ok := peer.FromContext(stream.Context()); ok {
+		remote = pr.Addr.String()
+	}
+	for {
+		batch, err := stream.Recv()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		now := time.Now()
+		for _, record := range batch.Records {
+			data := util.NewData()
+			data.Event = beat.Event{
+				Timestamp: now,
+				Fields:    toFields(record, remote),
+			}
+			if record.Timestamp > 0 {
+				data.Event.Timestamp = time.Unix(0, record.Timestamp)
+			}
+			if !p.outlet.OnEvent(data) {
+				return status.Error(codes.Unavailable, "input is stopping")
+			}
+		}
+		grpcReceived.Add(int64(len(batch.Records)))
+		if err := stream.Send(&logpb.PushAck{Accepted: int64(len(batch.Records))}); err != nil {
+			return err
+		}
+	}
+}
+
+// toFields: 日志记录转换为事件字段
+func toFields(record *logpb.LogRecord, remote string) common.MapStr {
+	fields := common.MapStr{
+		"data": record.Data,
+		"grpc": common.MapStr{"remote_addr": remote},
+	}
+	if record.Source != "" {
+		fields["source"] = record.Source
+	}
+	if len(record.Labels) > 0 {
+		labels := common.MapStr{}
+		for k, v := range record.Labels {
+			labels[k] = v
+		}
+		fields["labels"] = labels
+	}
+	return fields
+}
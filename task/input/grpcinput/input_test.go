@@ -0,0 +1,91 @@
+// Tencent is pleased to support the open source community by making bkunifylogbeat 蓝鲸日志采集器 available.
+//
+// Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+//
+// bkunifylogbeat 蓝鲸日志采集器 is licensed under the MIT License.
+//
+// License for bkunifylogbeat 蓝鲸日志采集器:
+// --------------------------------------------------------------------
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+// documentation files (the "Software"), to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software,
+// and to permit persons to whom the Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all copies or substantial
+// portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+// LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN
+// NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+// WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+// SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package grpcinput
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/TencentBlueKing/bkunifylogbeat/task/logpb"
+	"github.com/elastic/beats/filebeat/util"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+type mockOutlet struct {
+	events []*util.Data
+}
+
+func (o *mockOutlet) Close() error              { return nil }
+func (o *mockOutlet) Done() <-chan struct{}     { return nil }
+func (o *mockOutlet) OnEvent(d *util.Data) bool { o.events = append(o.events, d); return true }
+
+// TestPush: 测试token校验、批次确认及事件字段
+func TestPush(t *testing.T) {
+	outlet := &mockOutlet{}
+	config := defaultConfig
+	config.Token = "secret"
+	p := &Input{outlet: outlet, config: config}
+	server := grpc.NewServer(grpc.StreamInterceptor(p.authorize))
+	logpb.RegisterLogCollectorServer(server, p)
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	go func() { _ = server.Serve(listener) }()
+	defer server.Stop()
+
+	conn, err := grpc.Dial(listener.Addr().String(), grpc.WithInsecure())
+	assert.NoError(t, err)
+	defer conn.Close()
+	client := logpb.NewLogCollectorClient(conn)
+
+	// 未携带token
+	stream, err := client.Push(context.Background())
+	assert.NoError(t, err)
+	_, err = stream.Recv()
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer secret")
+	stream, err = client.Push(ctx)
+	assert.NoError(t, err)
+	assert.NoError(t, stream.Send(&logpb.LogBatch{Records: []*logpb.LogRecord{
+		{Timestamp: 1e9, Data: "hello", Source: "app", Labels: map[string]string{"env": "prod"}},
+		{Data: "world"},
+	}}))
+	ack, err := stream.Recv()
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), ack.Accepted)
+	assert.NoError(t, stream.CloseSend())
+
+	assert.Len(t, outlet.events, 2)
+	event := outlet.events[0].Event
+	assert.Equal(t, int64(1), event.Timestamp.Unix())
+	assert.Equal(t, "hello", event.Fields["data"])
+	assert.Equal(t, "app", event.Fields["source"])
+	env, _ := event.Fields.GetValue("labels.env")
+	assert.Equal(t, "prod", env)
+	assert.Equal(t, "world", outlet.events[1].Event.Fields["data"])
+}
@@ -0,0 +1,50 @@
+// Tencent is pleased to support the open source community by making bkunifylogbeat 蓝鲸日志采集器 available.
+//
+// Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+//
+// bkunifylogbeat 蓝鲸日志采集器 is licensed under the MIT License.
+//
+// License for bkunifylogbeat 蓝鲸日志采集器:
+// --------------------------------------------------------------------
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+// documentation files (the "Software"), to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software,
+// and to permit persons to whom the Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all copies or substantial
+// portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+// LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN
+// NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+// WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+// SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package httppush
+
+import (
+	"time"
+
+	"github.com/dustin/go-humanize"
+	"github.com/elastic/beats/libbeat/common/transport/tlscommon"
+)
+
+var defaultConfig = config{
+	Host:        ":8080",
+	Path:        "/",
+	Format:      "auto",
+	MaxBodySize: 10 * humanize.MiByte,
+	ReadTimeout: 30 * time.Second,
+}
+
+type config struct {
+	Host string `config:"host"`
+	Path string `config:"path"`
+	// 配置后请求需要携带Authorization: Bearer <token>或X-Token: <token>
+	Token string `config:"token"`
+	// 请求体格式: ndjson每行一个JSON对象，text每行一条日志，auto按Content-Type判断
+	Format      string                  `config:"format"`
+	MaxBodySize int64                   `config:"max_body_size" validate:"min=1"`
+	ReadTimeout time.Duration           `config:"read_timeout"`
+	TLS         *tlscommon.ServerConfig `config:"ssl"`
+}
@@ -0,0 +1,251 @@
+// Tencent is pleased to support the open source community by making bkunifylogbeat 蓝鲸日志采集器 available.
+//
+// Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+//
+// bkunifylogbeat 蓝鲸日志采集器 is licensed under the MIT License.
+//
+// License for bkunifylogbeat 蓝鲸日志采集器:
+// --------------------------------------------------------------------
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+// documentation files (the "Software"), to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software,
+// and to permit persons to whom the Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all copies or substantial
+// portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+// LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN
+// NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+// WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+// SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package httppush
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/TencentBlueKing/collector-go-sdk/v2/bkbeat/bkmonitoring"
+	"github.com/elastic/beats/filebeat/channel"
+	"github.com/elastic/beats/filebeat/input"
+	"github.com/elastic/beats/filebeat/util"
+	"github.com/elastic/beats/libbeat/beat"
+	"github.com/elastic/beats/libbeat/common"
+	"github.com/elastic/beats/libbeat/common/transport/tlscommon"
+	"github.com/elastic/beats/libbeat/logp"
+)
+
+var (
+	httpReceived     = bkmonitoring.NewInt("input_http_received")
+	httpRejected     = bkmonitoring.NewInt("input_http_rejected")
+	httpUnauthorized = bkmonitoring.NewInt("input_http_unauthorized")
+)
+
+func init() {
+	err := input.Register("http", NewInput)
+	if err != nil {
+		panic(err)
+	}
+}
+
+// Input 提供HTTP(S)接口接收应用推送的日志，每行日志生成一个事件进入任务的过滤及处理链
+type Input struct {
+	started bool
+	mutex   sync.Mutex
+	outlet  channel.Outleter
+
+	config config
+	server *http.Server
+	wg     sync.WaitGroup
+}
+
+// NewInput: creates a new http push input
+func NewInput(
+	cfg *common.Config,
+	outletFactory channel.Connector,
+	context input.Context,
+) (input.Input, error) {
+	config := defaultConfig
+	err := cfg.Unpack(&config)
+	if err != nil {
+		return nil, err
+	}
+	switch config.Format {
+	case "auto", "ndjson", "text":
+	default:
+		return nil, fmt.Errorf("http format must be auto, ndjson or text")
+	}
+
+	outlet, err := outletFactory(cfg, context.DynamicFields)
+	if err != nil {
+		return nil, err
+	}
+	p := &Input{
+		outlet: outlet,
+		config: config,
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(config.Path, p.handle)
+	p.server = &http.Server{
+		Addr:        config.Host,
+		Handler:     mux,
+		ReadTimeout: config.ReadTimeout,
+	}
+	if config.TLS != nil {
+		tlsConfig, err := tlscommon.LoadTLSServerConfig(config.TLS)
+		if err != nil {
+			return nil, fmt.Errorf("load http tls config failed, err=>%v", err)
+		}
+		if tlsConfig != nil {
+			p.server.TLSConfig = tlsConfig.BuildModuleConfig(config.Host)
+		}
+	}
+	return p, nil
+}
+
+func (p *Input) Reload() {}
+
+// Run 启动HTTP服务，只在首次调用时生效
+func (p *Input) Run() {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if p.started {
+		return
+	}
+	p.started = true
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		logp.Info("http input listening on %s%s", p.config.Host, p.config.Path)
+		var err error
+		if p.server.TLSConfig != nil {
+			err = p.server.ListenAndServeTLS("", "")
+		} else {
+			err = p.server.ListenAndServe()
+		}
+		if err != nil && err != http.ErrServerClosed {
+			logp.Err("http input serve on %s failed, err=>%v", p.config.Host, err)
+		}
+	}()
+}
+
+// Stop 停止HTTP服务，等待处理中的请求结束
+func (p *Input) Stop() {
+	logp.Info("Stopping http input on %s", p.config.Host)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_ = p.server.Shutdown(ctx)
+	p.wg.Wait()
+	_ = p.outlet.Close()
+}
+
+// Wait stop the current server
+func (p *Input) Wait() {
+	p.Stop()
+}
+
+// authorized: 校验请求携带的token
+func (p *Input) authorized(r *http.Request) bool {
+	if p.config.Token == "" {
+		return true
+	}
+	token := r.Header.Get("X-Token")
+	if auth := r.Header.Get("Authorization"); strings.HasPrefix(auth, "Bearer ") {
+		token = strings.TrimPrefix(auth, "Bearer ")
+	}
+	return subtle.ConstantTimeCompare([]byte(token), []byte(p.config.Token)) == 1
+}
+
+// handle 解析整个请求体后再发送，格式错误时整批拒绝，避免部分写入后客户端重试导致重复
+func (p *Input) handle(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !p.authorized(r) {
+		httpUnauthorized.Add(1)
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	body, err := ioutil.ReadAll(io.LimitReader(r.Body, p.config.MaxBodySize+1))
+	if err != nil {
+		httpRejected.Add(1)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if int64(len(body)) > p.config.MaxBodySize {
+		httpRejected.Add(1)
+		http.Error(w, "request body too large", http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	format := p.config.Format
+	if format == "auto" {
+		format = "text"
+		if strings.Contains(r.Header.Get("Content-Type"), "json") {
+			format = "ndjson"
+		}
+	}
+	events, err := parseBody(body, format)
+	if err != nil {
+		httpRejected.Add(1)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	now := time.Now()
+	for _, fields := range events {
+		_, _ = fields.Put("http.remote_addr", r.RemoteAddr)
+		data := util.NewData()
+		data.Event = beat.Event{Timestamp: now, Fields: fields}
+		if !p.outlet.OnEvent(data) {
+			http.Error(w, "input is stopping", http.StatusServiceUnavailable)
+			return
+		}
+	}
+	httpReceived.Add(int64(len(events)))
+	w.Header().Set("Content-Type", "application/json")
+	fmt.Fprintf(w, `{"accepted":%d}`, len(events))
+}
+
+// parseBody: 按行解析请求体，空行忽略；ndjson的对象作为事件字段，没有data字段时以原始行作为data
+func parseBody(body []byte, format string) ([]common.MapStr, error) {
+	var events []common.MapStr
+	scanner := bufio.NewScanner(bytes.NewReader(body))
+	scanner.Buffer(make([]byte, 64*1024), len(body)+1)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		if format == "text" {
+			events = append(events, common.MapStr{"data": line})
+			continue
+		}
+		fields := common.MapStr{}
+		if err := json.Unmarshal([]byte(line), &fields); err != nil {
+			return nil, fmt.Errorf("line %d is not a json object: %v", lineNo, err)
+		}
+		if _, ok := fields["data"]; !ok {
+			fields["data"] = line
+		}
+		events = append(events, fields)
+	}
+	return events, scanner.Err()
+}
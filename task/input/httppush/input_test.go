@@ -0,0 +1,84 @@
+// Tencent is pleased to support the open source community by making bkunifylogbeat 蓝鲸日志采集器 available.
+//
+// Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+//
+// bkunifylogbeat 蓝鲸日志采集器 is licensed under the MIT License.
+//
+// License for bkunifylogbeat 蓝鲸日志采集器:
+// --------------------------------------------------------------------
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+// documentation files (the "Software"), to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software,
+// and to permit persons to whom the Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all copies or substantial
+// portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+// LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN
+// NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+// WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+// SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package httppush
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/elastic/beats/filebeat/util"
+	"github.com/stretchr/testify/assert"
+)
+
+// mockOutlet: 记录收到的事件
+type mockOutlet struct {
+	events []*util.Data
+}
+
+func (o *mockOutlet) Close() error              { return nil }
+func (o *mockOutlet) Done() <-chan struct{}     { return nil }
+func (o *mockOutlet) OnEvent(d *util.Data) bool { o.events = append(o.events, d); return true }
+
+func post(p *Input, body, contentType, token string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+	req.Header.Set("Content-Type", contentType)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	w := httptest.NewRecorder()
+	p.handle(w, req)
+	return w
+}
+
+// TestHandle: 测试token校验及ndjson、text格式解析
+func TestHandle(t *testing.T) {
+	outlet := &mockOutlet{}
+	config := defaultConfig
+	config.Token = "secret"
+	p := &Input{outlet: outlet, config: config}
+
+	w := post(p, "hello", "text/plain", "")
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	w = post(p, "line1\n\nline2\n", "text/plain", "secret")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `{"accepted":2}`, w.Body.String())
+	assert.Equal(t, "line2", outlet.events[1].Event.Fields["data"])
+
+	w = post(p, `{"data":"a","level":"warn"}`+"\n"+`{"msg":"b"}`, "application/x-ndjson", "secret")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Len(t, outlet.events, 4)
+	assert.Equal(t, "warn", outlet.events[2].Event.Fields["level"])
+	assert.Equal(t, `{"msg":"b"}`, outlet.events[3].Event.Fields["data"])
+
+	// 格式错误时整批拒绝
+	w = post(p, `{"data":"c"}`+"\nnot json", "application/json", "secret")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Len(t, outlet.events, 4)
+
+	p.config.MaxBodySize = 4
+	w = post(p, "too large", "text/plain", "secret")
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+}
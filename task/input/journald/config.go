@@ -0,0 +1,48 @@
+// Tencent is pleased to support the open source community by making bkunifylogbeat 蓝鲸日志采集器 available.
+//
+// Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+//
+// bkunifylogbeat 蓝鲸日志采集器 is licensed under the MIT License.
+//
+// License for bkunifylogbeat 蓝鲸日志采集器:
+// --------------------------------------------------------------------
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+// documentation files (the "Software"), to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software,
+// and to permit persons to whom the Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all copies or substantial
+// portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+// LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN
+// NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+// WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+// SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package journald
+
+import "time"
+
+var defaultConfig = config{
+	Seek:        "tail",
+	Journalctl:  "journalctl",
+	RestartWait: 5 * time.Second,
+}
+
+type config struct {
+	// 只采集指定的systemd unit，为空时采集全部
+	Units []string `config:"units"`
+	// 最大日志级别，0(emerg)~7(debug)或对应名称，为空时不过滤
+	Priority string `config:"priority"`
+	// 额外的journal匹配条件，格式为FIELD=value
+	Matches []string `config:"matches"`
+	// journal目录，为空时读取系统默认journal
+	Directory string `config:"directory"`
+	// 无采集进度时的起始位置: head从最早的日志开始，tail只采集新日志
+	Seek string `config:"seek"`
+	// journalctl命令路径
+	Journalctl string `config:"journalctl"`
+	// journalctl异常退出后的重启间隔
+	RestartWait time.Duration `config:"restart_wait" validate:"min=0"`
+}
@@ -0,0 +1,318 @@
+// Tencent is pleased to support the open source community by making bkunifylogbeat 蓝鲸日志采集器 available.
+//
+// Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+//
+// bkunifylogbeat 蓝鲸日志采集器 is licensed under the MIT License.
+//
+// License for bkunifylogbeat 蓝鲸日志采集器:
+// --------------------------------------------------------------------
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+// documentation files (the "Software"), to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software,
+// and to permit persons to whom the Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all copies or substantial
+// portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+// LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN
+// NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+// WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+// SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package journald
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/TencentBlueKing/collector-go-sdk/v2/bkbeat/bkmonitoring"
+	"github.com/elastic/beats/filebeat/channel"
+	"github.com/elastic/beats/filebeat/input"
+	"github.com/elastic/beats/filebeat/input/file"
+	"github.com/elastic/beats/filebeat/util"
+	"github.com/elastic/beats/libbeat/beat"
+	"github.com/elastic/beats/libbeat/common"
+	"github.com/elastic/beats/libbeat/logp"
+)
+
+const (
+	JournaldFileStateType = "journald"
+)
+
+var (
+	journaldReceived = bkmonitoring.NewInt("input_journald_received")
+	journaldRestart  = bkmonitoring.NewInt("input_journald_restart")
+)
+
+func init() {
+	err := input.Register("journald", NewInput)
+	if err != nil {
+		panic(err)
+	}
+}
+
+// Input 通过journalctl读取systemd journal，采集进度(cursor)保存在registrar中
+type Input struct {
+	started bool
+	mutex   sync.Mutex
+	outlet  channel.Outleter
+
+	config config
+	id     string
+	cursor string
+
+	cmd  *exec.Cmd
+	done chan struct{}
+	wg   sync.WaitGroup
+}
+
+// NewInput: creates a new journald input
+func NewInput(
+	cfg *common.Config,
+	outletFactory channel.Connector,
+	context input.Context,
+) (input.Input, error) {
+	config := defaultConfig
+	err := cfg.Unpack(&config)
+	if err != nil {
+		return nil, err
+	}
+	if config.Seek != "head" && config.Seek != "tail" {
+		return nil, fmt.Errorf("journald seek must be head or tail")
+	}
+
+	outlet, err := outletFactory(cfg, context.DynamicFields)
+	if err != nil {
+		return nil, err
+	}
+
+	// 相同的采集范围共用一个采集进度
+	id := stateID(config)
+	p := &Input{
+		outlet: outlet,
+		config: config,
+		id:     id,
+		done:   make(chan struct{}),
+	}
+	for _, s := range context.States {
+		if s.Type == JournaldFileStateType && s.Id == id {
+			p.cursor = s.Meta["Cursor"]
+		}
+	}
+	return p, nil
+}
+
+func (p *Input) Reload() {}
+
+// Run 启动journalctl读取协程，只在首次调用时生效
+func (p *Input) Run() {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if !p.started {
+		p.wg.Add(1)
+		go p.run()
+		p.started = true
+	}
+}
+
+// Stop 停止journalctl
+func (p *Input) Stop() {
+	logp.Info("Stopping journald input %s", p.id)
+	p.mutex.Lock()
+	select {
+	case <-p.done:
+	default:
+		close(p.done)
+	}
+	if p.cmd != nil && p.cmd.Process != nil {
+		_ = p.cmd.Process.Kill()
+	}
+	p.mutex.Unlock()
+
+	p.wg.Wait()
+	_ = p.outlet.Close()
+}
+
+// Wait stop the current server
+func (p *Input) Wait() {
+	p.Stop()
+}
+
+func (p *Input) run() {
+	defer p.wg.Done()
+	for {
+		err := p.follow()
+		select {
+		case <-p.done:
+			return
+		default:
+		}
+		journaldRestart.Add(1)
+		logp.Err("journald input %s stopped, restart after %v, err=>%v", p.id, p.config.RestartWait, err)
+		select {
+		case <-p.done:
+			return
+		case <-time.After(p.config.RestartWait):
+		}
+	}
+}
+
+// follow: 启动journalctl并逐行读取JSON输出，直到进程退出
+func (p *Input) follow() error {
+	p.mutex.Lock()
+	select {
+	case <-p.done:
+		p.mutex.Unlock()
+		return nil
+	default:
+	}
+	cmd := exec.Command(p.config.Journalctl, buildArgs(p.config, p.cursor)...)
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		p.mutex.Unlock()
+		return err
+	}
+	if err = cmd.Start(); err != nil {
+		p.mutex.Unlock()
+		return err
+	}
+	p.cmd = cmd
+	p.mutex.Unlock()
+	logp.Info("journald input %s started, cursor=%s", p.id, p.cursor)
+
+	scanner := bufio.NewScanner(stdout)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		entry := make(map[string]interface{})
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			logp.Err("journald input %s decode entry failed, err=>%v", p.id, err)
+			continue
+		}
+		event, cursor := toEvent(entry)
+		if cursor != "" {
+			p.cursor = cursor
+		}
+		journaldReceived.Add(1)
+
+		data := util.NewData()
+		data.SetState(p.state())
+		data.Event = event
+		p.outlet.OnEvent(data)
+	}
+	if err = scanner.Err(); err != nil {
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+		return err
+	}
+	return cmd.Wait()
+}
+
+// state: 将cursor保存为采集状态，由registrar持久化
+func (p *Input) state() file.State {
+	return file.State{
+		Id:        p.id,
+		Type:      JournaldFileStateType,
+		Source:    p.id,
+		Timestamp: time.Now(),
+		TTL:       -1,
+		Finished:  false,
+		Meta: map[string]string{
+			"Cursor": p.cursor,
+		},
+	}
+}
+
+// stateID: 按采集范围生成采集状态ID
+func stateID(config config) string {
+	parts := append([]string{config.Directory}, config.Units...)
+	parts = append(parts, config.Matches...)
+	return "journald:" + strings.Join(parts, ",")
+}
+
+// buildArgs: 生成journalctl参数，有cursor时从cursor之后开始读取
+func buildArgs(config config, cursor string) []string {
+	args := []string{"--follow", "--output=json", "--no-pager", "--quiet"}
+	if config.Directory != "" {
+		args = append(args, "--directory="+config.Directory)
+	}
+	if config.Priority != "" {
+		args = append(args, "--priority="+config.Priority)
+	}
+	for _, unit := range config.Units {
+		args = append(args, "--unit="+unit)
+	}
+	switch {
+	case cursor != "":
+		args = append(args, "--after-cursor="+cursor)
+	case config.Seek == "head":
+		args = append(args, "--lines=all")
+	default:
+		args = append(args, "--lines=0")
+	}
+	return append(args, config.Matches...)
+}
+
+// journalFields: journal字段与事件字段的对应关系
+var journalFields = map[string]string{
+	"_SYSTEMD_UNIT":     "unit",
+	"SYSLOG_IDENTIFIER": "identifier",
+	"_PID":              "pid",
+	"_HOSTNAME":         "hostname",
+	"_TRANSPORT":        "transport",
+	"_COMM":             "process",
+}
+
+// toEvent: 将journalctl输出的JSON记录转换为事件，返回事件及记录的cursor
+func toEvent(entry map[string]interface{}) (beat.Event, string) {
+	journal := common.MapStr{}
+	for key, name := range journalFields {
+		if value := journalValue(entry[key]); value != "" {
+			journal[name] = value
+		}
+	}
+	if priority, err := strconv.Atoi(journalValue(entry["PRIORITY"])); err == nil {
+		journal["priority"] = priority
+	}
+
+	timestamp := time.Now()
+	if usec, err := strconv.ParseInt(journalValue(entry["__REALTIME_TIMESTAMP"]), 10, 64); err == nil {
+		timestamp = time.Unix(0, usec*int64(time.Microsecond))
+	}
+	return beat.Event{
+		Timestamp: timestamp,
+		Fields: common.MapStr{
+			"data":     journalValue(entry["MESSAGE"]),
+			"journald": journal,
+		},
+	}, journalValue(entry["__CURSOR"])
+}
+
+// journalValue: 字段值为字符串，包含不可打印字符时journalctl输出为字节数组，重复字段输出为数组时取第一个
+func journalValue(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case []interface{}:
+		if len(v) == 0 {
+			return ""
+		}
+		if _, ok := v[0].(float64); !ok {
+			return journalValue(v[0])
+		}
+		b := make([]byte, 0, len(v))
+		for _, c := range v {
+			n, _ := c.(float64)
+			b = append(b, byte(n))
+		}
+		return string(b)
+	}
+	return ""
+}
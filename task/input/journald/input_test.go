@@ -0,0 +1,77 @@
+// Tencent is pleased to support the open source community by making bkunifylogbeat 蓝鲸日志采集器 available.
+//
+// Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+//
+// bkunifylogbeat 蓝鲸日志采集器 is licensed under the MIT License.
+//
+// License for bkunifylogbeat 蓝鲸日志采集器:
+// --------------------------------------------------------------------
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+// documentation files (the "Software"), to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software,
+// and to permit persons to whom the Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all copies or substantial
+// portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+// LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN
+// NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+// WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+// SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package journald
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/elastic/beats/libbeat/common"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestBuildArgs: 测试journalctl参数及cursor续采
+func TestBuildArgs(t *testing.T) {
+	config := defaultConfig
+	config.Units = []string{"nginx.service"}
+	config.Priority = "warning"
+	config.Matches = []string{"_UID=0"}
+	assert.Equal(t, []string{
+		"--follow", "--output=json", "--no-pager", "--quiet",
+		"--priority=warning", "--unit=nginx.service", "--lines=0", "_UID=0",
+	}, buildArgs(config, ""))
+
+	args := buildArgs(config, "s=abc;i=1")
+	assert.Contains(t, args, "--after-cursor=s=abc;i=1")
+	assert.NotContains(t, args, "--lines=0")
+
+	config.Seek = "head"
+	assert.Contains(t, buildArgs(config, ""), "--lines=all")
+}
+
+// TestToEvent: 测试journal记录转换为事件
+func TestToEvent(t *testing.T) {
+	entry := make(map[string]interface{})
+	err := json.Unmarshal([]byte(`{
+		"__CURSOR": "s=abc;i=2",
+		"__REALTIME_TIMESTAMP": "1600000000000001",
+		"_SYSTEMD_UNIT": "nginx.service",
+		"SYSLOG_IDENTIFIER": "nginx",
+		"_PID": "42",
+		"PRIORITY": "3",
+		"MESSAGE": [104, 105]
+	}`), &entry)
+	assert.NoError(t, err)
+
+	event, cursor := toEvent(entry)
+	assert.Equal(t, "s=abc;i=2", cursor)
+	assert.Equal(t, time.Unix(1600000000, 1000), event.Timestamp)
+	assert.Equal(t, "hi", event.Fields["data"])
+	assert.Equal(t, common.MapStr{
+		"unit":       "nginx.service",
+		"identifier": "nginx",
+		"pid":        "42",
+		"priority":   3,
+	}, event.Fields["journald"])
+}
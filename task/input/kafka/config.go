@@ -0,0 +1,50 @@
+// Tencent is pleased to support the open source community by making bkunifylogbeat 蓝鲸日志采集器 available.
+//
+// Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+//
+// bkunifylogbeat 蓝鲸日志采集器 is licensed under the MIT License.
+//
+// License for bkunifylogbeat 蓝鲸日志采集器:
+// --------------------------------------------------------------------
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+// documentation files (the "Software"), to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software,
+// and to permit persons to whom the Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all copies or substantial
+// portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+// LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN
+// NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+// WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+// SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package kafka
+
+import (
+	"github.com/elastic/beats/libbeat/common/transport/tlscommon"
+)
+
+var defaultConfig = config{
+	ClientID:      "bkunifylogbeat",
+	Version:       "1.0.0",
+	InitialOffset: "newest",
+	SASLMechanism: "PLAIN",
+}
+
+type config struct {
+	Hosts    []string `config:"hosts" validate:"required"`
+	Topics   []string `config:"topics" validate:"required"`
+	GroupID  string   `config:"group_id" validate:"required"`
+	ClientID string   `config:"client_id"`
+	// kafka协议版本，与broker版本一致
+	Version string `config:"version"`
+	// 消费组没有已提交的位点时的起始位置: newest或oldest
+	InitialOffset string `config:"initial_offset"`
+	// 配置Username时开启SASL认证，目前只支持PLAIN
+	Username      string            `config:"username"`
+	Password      string            `config:"password"`
+	SASLMechanism string            `config:"sasl.mechanism"`
+	TLS           *tlscommon.Config `config:"ssl"`
+}
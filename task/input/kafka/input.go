@@ -0,0 +1,271 @@
+// Tencent is pleased to support the open source community by making bkunifylogbeat 蓝鲸日志采集器 available.
+//
+// Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+//
+// bkunifylogbeat 蓝鲸日志采集器 is licensed under the MIT License.
+//
+// License for bkunifylogbeat 蓝鲸日志采集器:
+// --------------------------------------------------------------------
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+// documentation files (the "Software"), to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software,
+// and to permit persons to whom the Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all copies or substantial
+// portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+// LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN
+// NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+// WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+// SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package kafka
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/Shopify/sarama"
+	"github.com/TencentBlueKing/collector-go-sdk/v2/bkbeat/bkmonitoring"
+	"github.com/elastic/beats/filebeat/channel"
+	"github.com/elastic/beats/filebeat/input"
+	"github.com/elastic/beats/filebeat/util"
+	"github.com/elastic/beats/libbeat/beat"
+	"github.com/elastic/beats/libbeat/common"
+	"github.com/elastic/beats/libbeat/common/transport/tlscommon"
+	"github.com/elastic/beats/libbeat/logp"
+)
+
+const retryWait = 5 * time.Second
+
+var (
+	kafkaReceived     = bkmonitoring.NewInt("input_kafka_received")
+	kafkaConsumeError = bkmonitoring.NewInt("input_kafka_consume_error")
+)
+
+func init() {
+	err := input.Register("kafka", NewInput)
+	if err != nil {
+		panic(err)
+	}
+}
+
+// Input 以消费组方式消费kafka topic，消息进入任务的过滤及处理链
+// 位点由消费组提交到kafka，消息交给任务后即标记为已消费
+type Input struct {
+	started bool
+	mutex   sync.Mutex
+	outlet  channel.Outleter
+
+	config       config
+	saramaConfig *sarama.Config
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewInput: creates a new kafka consumer input
+func NewInput(
+	cfg *common.Config,
+	outletFactory channel.Connector,
+	context input.Context,
+) (input.Input, error) {
+	config := defaultConfig
+	err := cfg.Unpack(&config)
+	if err != nil {
+		return nil, err
+	}
+	saramaConfig, err := newSaramaConfig(config)
+	if err != nil {
+		return nil, err
+	}
+
+	outlet, err := outletFactory(cfg, context.DynamicFields)
+	if err != nil {
+		return nil, err
+	}
+	return &Input{
+		outlet:       outlet,
+		config:       config,
+		saramaConfig: saramaConfig,
+	}, nil
+}
+
+// newSaramaConfig: 根据采集配置生成消费者配置
+func newSaramaConfig(config config) (*sarama.Config, error) {
+	version, err := sarama.ParseKafkaVersion(config.Version)
+	if err != nil {
+		return nil, fmt.Errorf("kafka version(%s) is invalid, err=>%v", config.Version, err)
+	}
+	c := sarama.NewConfig()
+	c.ClientID = config.ClientID
+	c.Version = version
+	c.Consumer.Return.Errors = true
+
+	switch config.InitialOffset {
+	case "newest":
+		c.Consumer.Offsets.Initial = sarama.OffsetNewest
+	case "oldest":
+		c.Consumer.Offsets.Initial = sarama.OffsetOldest
+	default:
+		return nil, fmt.Errorf("kafka initial_offset must be newest or oldest")
+	}
+
+	if config.Username != "" {
+		if config.SASLMechanism != sarama.SASLTypePlaintext {
+			return nil, fmt.Errorf("kafka sasl.mechanism(%s) is not supported", config.SASLMechanism)
+		}
+		c.Net.SASL.Enable = true
+		c.Net.SASL.Mechanism = sarama.SASLTypePlaintext
+		c.Net.SASL.User = config.Username
+		c.Net.SASL.Password = config.Password
+	}
+
+	if config.TLS != nil {
+		tlsConfig, err := tlscommon.LoadTLSConfig(config.TLS)
+		if err != nil {
+			return nil, fmt.Errorf("load kafka tls config failed, err=>%v", err)
+		}
+		if tlsConfig != nil {
+			c.Net.TLS.Enable = true
+			c.Net.TLS.Config = tlsConfig.BuildModuleConfig("")
+		}
+	}
+	return c, c.Validate()
+}
+
+func (p *Input) Reload() {}
+
+// Run 启动消费协程，只在首次调用时生效
+func (p *Input) Run() {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if !p.started {
+		p.ctx, p.cancel = context.WithCancel(context.Background())
+		p.wg.Add(1)
+		go p.run()
+		p.started = true
+	}
+}
+
+// Stop 停止消费并退出消费组
+func (p *Input) Stop() {
+	logp.Info("Stopping kafka input, group=%s", p.config.GroupID)
+	p.mutex.Lock()
+	if p.cancel != nil {
+		p.cancel()
+	}
+	p.mutex.Unlock()
+
+	p.wg.Wait()
+	_ = p.outlet.Close()
+}
+
+// Wait stop the current server
+func (p *Input) Wait() {
+	p.Stop()
+}
+
+// run: 加入消费组并持续消费，出错或重平衡后重新加入
+func (p *Input) run() {
+	defer p.wg.Done()
+	var group sarama.ConsumerGroup
+	defer func() {
+		if group != nil {
+			_ = group.Close()
+		}
+	}()
+
+	for p.ctx.Err() == nil {
+		if group == nil {
+			var err error
+			group, err = sarama.NewConsumerGroup(p.config.Hosts, p.config.GroupID, p.saramaConfig)
+			if err != nil {
+				kafkaConsumeError.Add(1)
+				logp.Err("kafka create consumer group(%s) failed, err=>%v", p.config.GroupID, err)
+				p.sleep(retryWait)
+				continue
+			}
+			go p.logErrors(group)
+		}
+		if err := group.Consume(p.ctx, p.config.Topics, p); err != nil {
+			kafkaConsumeError.Add(1)
+			logp.Err("kafka consume topics(%v) failed, err=>%v", p.config.Topics, err)
+			p.sleep(retryWait)
+		}
+	}
+}
+
+func (p *Input) logErrors(group sarama.ConsumerGroup) {
+	for err := range group.Errors() {
+		kafkaConsumeError.Add(1)
+		logp.Err("kafka consumer group(%s) error, err=>%v", p.config.GroupID, err)
+	}
+}
+
+func (p *Input) sleep(d time.Duration) {
+	select {
+	case <-p.ctx.Done():
+	case <-time.After(d):
+	}
+}
+
+// Setup is run at the beginning of a new session, before ConsumeClaim
+func (p *Input) Setup(sarama.ConsumerGroupSession) error {
+	return nil
+}
+
+// Cleanup is run at the end of a session, once all ConsumeClaim goroutines have exited
+func (p *Input) Cleanup(sarama.ConsumerGroupSession) error {
+	return nil
+}
+
+// ConsumeClaim 消费分区消息，交给任务后标记位点
+func (p *Input) ConsumeClaim(session sarama.ConsumerGroupSession, claim sarama.ConsumerGroupClaim) error {
+	for msg := range claim.Messages() {
+		kafkaReceived.Add(1)
+		data := util.NewData()
+		data.Event = toEvent(msg)
+		if !p.outlet.OnEvent(data) {
+			return nil
+		}
+		session.MarkMessage(msg, "")
+	}
+	return nil
+}
+
+// toEvent: 消息内容写入data，topic、分区、位点、key及headers写入kafka
+func toEvent(msg *sarama.ConsumerMessage) beat.Event {
+	meta := common.MapStr{
+		"topic":     msg.Topic,
+		"partition": msg.Partition,
+		"offset":    msg.Offset,
+	}
+	if len(msg.Key) > 0 {
+		meta["key"] = string(msg.Key)
+	}
+	if len(msg.Headers) > 0 {
+		headers := common.MapStr{}
+		for _, header := range msg.Headers {
+			headers[string(header.Key)] = string(header.Value)
+		}
+		meta["headers"] = headers
+	}
+
+	timestamp := msg.Timestamp
+	if timestamp.IsZero() {
+		timestamp = time.Now()
+	}
+	return beat.Event{
+		Timestamp: timestamp,
+		Fields: common.MapStr{
+			"data":  string(msg.Value),
+			"kafka": meta,
+		},
+	}
+}
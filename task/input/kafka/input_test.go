@@ -0,0 +1,77 @@
+// Tencent is pleased to support the open source community by making bkunifylogbeat 蓝鲸日志采集器 available.
+//
+// Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+//
+// bkunifylogbeat 蓝鲸日志采集器 is licensed under the MIT License.
+//
+// License for bkunifylogbeat 蓝鲸日志采集器:
+// --------------------------------------------------------------------
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+// documentation files (the "Software"), to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software,
+// and to permit persons to whom the Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all copies or substantial
+// portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+// LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN
+// NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+// WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+// SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package kafka
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Shopify/sarama"
+	"github.com/elastic/beats/libbeat/common"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestSaramaConfig: 测试消费者配置
+func TestSaramaConfig(t *testing.T) {
+	config := defaultConfig
+	config.InitialOffset = "oldest"
+	config.Username = "user"
+	config.Password = "pass"
+	c, err := newSaramaConfig(config)
+	assert.NoError(t, err)
+	assert.Equal(t, sarama.OffsetOldest, c.Consumer.Offsets.Initial)
+	assert.True(t, c.Net.SASL.Enable)
+	assert.Equal(t, sarama.V1_0_0_0, c.Version)
+
+	config.InitialOffset = "latest"
+	_, err = newSaramaConfig(config)
+	assert.Error(t, err)
+
+	config = defaultConfig
+	config.Version = "x"
+	_, err = newSaramaConfig(config)
+	assert.Error(t, err)
+}
+
+// TestToEvent: 测试消息转换为事件
+func TestToEvent(t *testing.T) {
+	ts := time.Unix(1600000000, 0)
+	event := toEvent(&sarama.ConsumerMessage{
+		Topic:     "logs",
+		Partition: 2,
+		Offset:    10,
+		Key:       []byte("k"),
+		Value:     []byte("hello"),
+		Timestamp: ts,
+		Headers:   []*sarama.RecordHeader{{Key: []byte("env"), Value: []byte("prod")}},
+	})
+	assert.Equal(t, ts, event.Timestamp)
+	assert.Equal(t, "hello", event.Fields["data"])
+	assert.Equal(t, common.MapStr{
+		"topic":     "logs",
+		"partition": int32(2),
+		"offset":    int64(10),
+		"key":       "k",
+		"headers":   common.MapStr{"env": "prod"},
+	}, event.Fields["kafka"])
+}
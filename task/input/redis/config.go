@@ -0,0 +1,52 @@
+// Tencent is pleased to support the open source community by making bkunifylogbeat 蓝鲸日志采集器 available.
+//
+// Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+//
+// bkunifylogbeat 蓝鲸日志采集器 is licensed under the MIT License.
+//
+// License for bkunifylogbeat 蓝鲸日志采集器:
+// --------------------------------------------------------------------
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+// documentation files (the "Software"), to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software,
+// and to permit persons to whom the Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all copies or substantial
+// portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+// LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN
+// NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+// WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+// SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package redis
+
+import "time"
+
+var defaultConfig = config{
+	Mode:      "list",
+	Timeout:   5 * time.Second,
+	Block:     5 * time.Second,
+	BatchSize: 100,
+	StartID:   "$",
+	DataField: "data",
+}
+
+type config struct {
+	Address  string `config:"address" validate:"required"`
+	Password string `config:"password"`
+	DB       int    `config:"db"`
+	// list: BRPOP读取列表，读取后元素即从列表中移除；stream: XREAD读取，已读取的ID保存在registrar中
+	Mode string   `config:"mode"`
+	Keys []string `config:"keys" validate:"required"`
+	// 单次请求超时及阻塞读取的等待时间
+	Timeout time.Duration `config:"timeout" validate:"min=0,nonzero"`
+	Block   time.Duration `config:"block" validate:"min=0,nonzero"`
+	// stream单次读取的最大条数
+	BatchSize int `config:"batch_size" validate:"min=1"`
+	// stream没有采集进度时的起始ID，$只读取新消息，0从头读取
+	StartID string `config:"start_id"`
+	// stream消息中作为data的字段，其余字段写入redis.fields
+	DataField string `config:"data_field"`
+}
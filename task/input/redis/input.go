@@ -0,0 +1,311 @@
+// Tencent is pleased to support the open source community by making bkunifylogbeat 蓝鲸日志采集器 available.
+//
+// Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+//
+// bkunifylogbeat 蓝鲸日志采集器 is licensed under the MIT License.
+//
+// License for bkunifylogbeat 蓝鲸日志采集器:
+// --------------------------------------------------------------------
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+// documentation files (the "Software"), to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software,
+// and to permit persons to whom the Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all copies or substantial
+// portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+// LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN
+// NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+// WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+// SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package redis
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/TencentBlueKing/bkunifylogbeat/utils"
+	"github.com/TencentBlueKing/collector-go-sdk/v2/bkbeat/bkmonitoring"
+	"github.com/elastic/beats/filebeat/channel"
+	"github.com/elastic/beats/filebeat/input"
+	"github.com/elastic/beats/filebeat/input/file"
+	"github.com/elastic/beats/filebeat/util"
+	"github.com/elastic/beats/libbeat/beat"
+	"github.com/elastic/beats/libbeat/common"
+	"github.com/elastic/beats/libbeat/logp"
+)
+
+const (
+	RedisFileStateType = "redis"
+)
+
+const retryWait = 5 * time.Second
+
+var (
+	redisReceived   = bkmonitoring.NewInt("input_redis_received")
+	redisReadFailed = bkmonitoring.NewInt("input_redis_read_failed")
+)
+
+func init() {
+	err := input.Register("redis", NewInput)
+	if err != nil {
+		panic(err)
+	}
+}
+
+// Input 从redis列表或stream读取消息，stream的已读取ID作为采集状态保存在registrar中
+type Input struct {
+	started bool
+	mutex   sync.Mutex
+	outlet  channel.Outleter
+
+	config config
+	client *utils.RedisClient
+	// stream key对应的已读取ID
+	ids map[string]string
+
+	done chan struct{}
+	wg   sync.WaitGroup
+}
+
+// NewInput: creates a new redis input
+func NewInput(
+	cfg *common.Config,
+	outletFactory channel.Connector,
+	context input.Context,
+) (input.Input, error) {
+	config := defaultConfig
+	err := cfg.Unpack(&config)
+	if err != nil {
+		return nil, err
+	}
+	if config.Mode != "list" && config.Mode != "stream" {
+		return nil, fmt.Errorf("redis mode must be list or stream")
+	}
+
+	outlet, err := outletFactory(cfg, context.DynamicFields)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &Input{
+		outlet: outlet,
+		config: config,
+		client: utils.NewRedisClient(config.Address, config.Password, config.DB, config.Timeout),
+		ids:    make(map[string]string),
+		done:   make(chan struct{}),
+	}
+	for _, key := range config.Keys {
+		p.ids[key] = config.StartID
+	}
+	for _, s := range context.States {
+		if s.Type != RedisFileStateType {
+			continue
+		}
+		for _, key := range config.Keys {
+			if s.Id == p.stateID(key) && s.Meta["ID"] != "" {
+				p.ids[key] = s.Meta["ID"]
+			}
+		}
+	}
+	return p, nil
+}
+
+func (p *Input) Reload() {}
+
+// Run 启动读取协程，只在首次调用时生效
+func (p *Input) Run() {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if !p.started {
+		p.wg.Add(1)
+		go p.run()
+		p.started = true
+	}
+}
+
+// Stop 停止读取，阻塞中的请求在Block时间内返回
+func (p *Input) Stop() {
+	logp.Info("Stopping redis input on %s, keys=%v", p.config.Address, p.config.Keys)
+	p.mutex.Lock()
+	select {
+	case <-p.done:
+	default:
+		close(p.done)
+	}
+	p.mutex.Unlock()
+
+	p.wg.Wait()
+	p.client.Close()
+	_ = p.outlet.Close()
+}
+
+// Wait stop the current server
+func (p *Input) Wait() {
+	p.Stop()
+}
+
+func (p *Input) run() {
+	defer p.wg.Done()
+	for {
+		select {
+		case <-p.done:
+			return
+		default:
+		}
+		var err error
+		if p.config.Mode == "list" {
+			err = p.readList()
+		} else {
+			err = p.readStream()
+		}
+		if err != nil {
+			redisReadFailed.Add(1)
+			logp.Err("redis read %v from %s failed, err=>%v", p.config.Keys, p.config.Address, err)
+			select {
+			case <-p.done:
+				return
+			case <-time.After(retryWait):
+			}
+		}
+	}
+}
+
+// readList: BRPOP key... timeout，回复为[key, value]，超时为nil
+func (p *Input) readList() error {
+	// BRPOP的超时单位为秒，0表示一直阻塞
+	seconds := int(p.config.Block / time.Second)
+	if seconds < 1 {
+		seconds = 1
+	}
+	args := append([]string{"BRPOP"}, p.config.Keys...)
+	args = append(args, strconv.Itoa(seconds))
+	reply, err := p.client.DoTimeout(time.Duration(seconds)*time.Second+p.config.Timeout, args...)
+	if err != nil || reply == nil {
+		return err
+	}
+	item, ok := reply.([]interface{})
+	if !ok || len(item) != 2 {
+		return fmt.Errorf("unexpected BRPOP reply %v", reply)
+	}
+	key, _ := item[0].(string)
+	value, _ := item[1].(string)
+	p.publish(beat.Event{
+		Timestamp: time.Now(),
+		Fields: common.MapStr{
+			"data":  value,
+			"redis": common.MapStr{"key": key},
+		},
+	}, nil)
+	return nil
+}
+
+// readStream: XREAD COUNT n BLOCK ms STREAMS key... id...，回复为[[key, [[id, [field, value...]]...]]...]
+func (p *Input) readStream() error {
+	args := []string{"XREAD", "COUNT", strconv.Itoa(p.config.BatchSize),
+		"BLOCK", strconv.FormatInt(int64(p.config.Block/time.Millisecond), 10), "STREAMS"}
+	args = append(args, p.config.Keys...)
+	for _, key := range p.config.Keys {
+		args = append(args, p.ids[key])
+	}
+	reply, err := p.client.DoTimeout(p.config.Block+p.config.Timeout, args...)
+	if err != nil || reply == nil {
+		return err
+	}
+	streams, ok := reply.([]interface{})
+	if !ok {
+		return fmt.Errorf("unexpected XREAD reply %v", reply)
+	}
+	for _, stream := range streams {
+		s, ok := stream.([]interface{})
+		if !ok || len(s) != 2 {
+			continue
+		}
+		key, _ := s[0].(string)
+		entries, _ := s[1].([]interface{})
+		for _, entry := range entries {
+			id, event, ok := p.streamEvent(key, entry)
+			if !ok {
+				continue
+			}
+			p.ids[key] = id
+			state := p.state(key, id)
+			p.publish(event, &state)
+		}
+	}
+	return nil
+}
+
+// streamEvent: 将stream消息转换为事件，DataField字段作为data，其余字段写入redis.fields
+func (p *Input) streamEvent(key string, entry interface{}) (string, beat.Event, bool) {
+	e, ok := entry.([]interface{})
+	if !ok || len(e) != 2 {
+		return "", beat.Event{}, false
+	}
+	id, _ := e[0].(string)
+	values, _ := e[1].([]interface{})
+	fields := common.MapStr{}
+	data := ""
+	for i := 0; i+1 < len(values); i += 2 {
+		name, _ := values[i].(string)
+		value, _ := values[i+1].(string)
+		if name == p.config.DataField {
+			data = value
+			continue
+		}
+		fields[name] = value
+	}
+	meta := common.MapStr{"key": key, "id": id}
+	if len(fields) > 0 {
+		meta["fields"] = fields
+	}
+
+	// ID的毫秒时间戳部分作为事件时间
+	timestamp := time.Now()
+	if ms, err := strconv.ParseInt(strings.SplitN(id, "-", 2)[0], 10, 64); err == nil {
+		timestamp = time.Unix(0, ms*int64(time.Millisecond))
+	}
+	return id, beat.Event{
+		Timestamp: timestamp,
+		Fields: common.MapStr{
+			"data":  data,
+			"redis": meta,
+		},
+	}, true
+}
+
+func (p *Input) publish(event beat.Event, state *file.State) {
+	redisReceived.Add(1)
+	data := util.NewData()
+	if state != nil {
+		data.SetState(*state)
+	}
+	data.Event = event
+	p.outlet.OnEvent(data)
+}
+
+// stateID: 按地址、库及key生成采集状态ID
+func (p *Input) stateID(key string) string {
+	return fmt.Sprintf("redis://%s/%d/%s", p.config.Address, p.config.DB, key)
+}
+
+// state: 将stream已读取的ID保存为采集状态，由registrar持久化
+func (p *Input) state(key, id string) file.State {
+	return file.State{
+		Id:        p.stateID(key),
+		Type:      RedisFileStateType,
+		Source:    p.stateID(key),
+		Timestamp: time.Now(),
+		TTL:       -1,
+		Finished:  false,
+		Meta: map[string]string{
+			"ID": id,
+		},
+	}
+}
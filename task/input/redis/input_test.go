@@ -0,0 +1,85 @@
+// Tencent is pleased to support the open source community by making bkunifylogbeat 蓝鲸日志采集器 available.
+//
+// Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+//
+// bkunifylogbeat 蓝鲸日志采集器 is licensed under the MIT License.
+//
+// License for bkunifylogbeat 蓝鲸日志采集器:
+// --------------------------------------------------------------------
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+// documentation files (the "Software"), to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software,
+// and to permit persons to whom the Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all copies or substantial
+// portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+// LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN
+// NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+// WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+// SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package redis
+
+import (
+	"testing"
+	"time"
+
+	"github.com/elastic/beats/filebeat/channel"
+	"github.com/elastic/beats/filebeat/input"
+	"github.com/elastic/beats/filebeat/input/file"
+	"github.com/elastic/beats/filebeat/util"
+	"github.com/elastic/beats/libbeat/common"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockOutlet struct{}
+
+func (o *mockOutlet) Close() error            { return nil }
+func (o *mockOutlet) Done() <-chan struct{}   { return nil }
+func (o *mockOutlet) OnEvent(*util.Data) bool { return true }
+
+func mockConnector(*common.Config, *common.MapStrPointer) (channel.Outleter, error) {
+	return &mockOutlet{}, nil
+}
+
+// TestStreamInput: 测试stream采集进度恢复及消息转换
+func TestStreamInput(t *testing.T) {
+	cfg := common.MustNewConfigFrom(map[string]interface{}{
+		"address": "127.0.0.1:6379",
+		"mode":    "stream",
+		"keys":    []string{"logs", "audit"},
+	})
+	states := []file.State{{
+		Id:   "redis://127.0.0.1:6379/0/logs",
+		Type: RedisFileStateType,
+		Meta: map[string]string{"ID": "1600000000000-1"},
+	}}
+	in, err := NewInput(cfg, mockConnector, input.Context{States: states})
+	assert.NoError(t, err)
+	p := in.(*Input)
+	assert.Equal(t, map[string]string{"logs": "1600000000000-1", "audit": "$"}, p.ids)
+
+	id, event, ok := p.streamEvent("logs", []interface{}{
+		"1600000000001-0", []interface{}{"data", "hello", "level", "info"},
+	})
+	assert.True(t, ok)
+	assert.Equal(t, "1600000000001-0", id)
+	assert.Equal(t, time.Unix(1600000000, 1000000), event.Timestamp)
+	assert.Equal(t, "hello", event.Fields["data"])
+	assert.Equal(t, common.MapStr{
+		"key":    "logs",
+		"id":     "1600000000001-0",
+		"fields": common.MapStr{"level": "info"},
+	}, event.Fields["redis"])
+
+	state := p.state("logs", id)
+	assert.Equal(t, "redis://127.0.0.1:6379/0/logs", state.Id)
+	assert.Equal(t, id, state.Meta["ID"])
+
+	_, err = NewInput(common.MustNewConfigFrom(map[string]interface{}{
+		"address": "127.0.0.1:6379", "mode": "pubsub", "keys": []string{"a"},
+	}), mockConnector, input.Context{})
+	assert.Error(t, err)
+}
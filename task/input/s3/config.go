@@ -0,0 +1,46 @@
+// Tencent is pleased to support the open source community by making bkunifylogbeat 蓝鲸日志采集器 available.
+//
+// Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+//
+// bkunifylogbeat 蓝鲸日志采集器 is licensed under the MIT License.
+//
+// License for bkunifylogbeat 蓝鲸日志采集器:
+// --------------------------------------------------------------------
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+// documentation files (the "Software"), to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software,
+// and to permit persons to whom the Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all copies or substantial
+// portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+// LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN
+// NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+// WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+// SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package s3
+
+import "time"
+
+var defaultConfig = config{
+	PollInterval: time.Minute,
+}
+
+type config struct {
+	Bucket string `config:"bucket" validate:"required"`
+	Region string `config:"region"`
+	// S3兼容服务的地址，如COS的cos.ap-guangzhou.myqcloud.com，为空时使用AWS S3
+	Endpoint       string `config:"endpoint"`
+	ForcePathStyle bool   `config:"force_path_style"`
+	// 未配置时使用AWS SDK默认凭证链(环境变量、共享配置文件、实例角色等)
+	AccessKeyID     string `config:"access_key_id"`
+	SecretAccessKey string `config:"secret_access_key"`
+	// 只读取Prefix下的对象，Include/Exclude为对象key的正则表达式
+	Prefix  string   `config:"prefix"`
+	Include []string `config:"include"`
+	Exclude []string `config:"exclude"`
+	// 列举新对象的间隔
+	PollInterval time.Duration `config:"poll_interval" validate:"min=0,nonzero"`
+}
@@ -0,0 +1,358 @@
+// Tencent is pleased to support the open source community by making bkunifylogbeat 蓝鲸日志采集器 available.
+//
+// Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+//
+// bkunifylogbeat 蓝鲸日志采集器 is licensed under the MIT License.
+//
+// License for bkunifylogbeat 蓝鲸日志采集器:
+// --------------------------------------------------------------------
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+// documentation files (the "Software"), to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software,
+// and to permit persons to whom the Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all copies or substantial
+// portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+// LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN
+// NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+// WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+// SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package s3
+
+import (
+	"bufio"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/TencentBlueKing/collector-go-sdk/v2/bkbeat/bkmonitoring"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/elastic/beats/filebeat/channel"
+	"github.com/elastic/beats/filebeat/input"
+	"github.com/elastic/beats/filebeat/input/file"
+	"github.com/elastic/beats/filebeat/util"
+	"github.com/elastic/beats/libbeat/beat"
+	"github.com/elastic/beats/libbeat/common"
+	"github.com/elastic/beats/libbeat/logp"
+)
+
+const (
+	S3FileStateType = "s3"
+)
+
+var (
+	s3ObjectsTotal  = bkmonitoring.NewInt("input_s3_objects_total")
+	s3ObjectsFailed = bkmonitoring.NewInt("input_s3_objects_failed")
+	s3ListFailed    = bkmonitoring.NewInt("input_s3_list_failed")
+)
+
+func init() {
+	err := input.Register("s3", NewInput)
+	if err != nil {
+		panic(err)
+	}
+}
+
+// Input 按key的字典序读取S3/COS桶中的日志对象，.gz对象解压后读取
+// 采集进度为当前对象的key及已读取的行数，适用于key按时间递增的场景(如云服务按日期前缀投递的日志)，
+// 早于已读取key的新对象不会被读取
+type Input struct {
+	started bool
+	mutex   sync.Mutex
+	outlet  channel.Outleter
+
+	config  config
+	client  *s3.S3
+	include []*regexp.Regexp
+	exclude []*regexp.Regexp
+
+	// 当前读取的对象及已读取的行数
+	key  string
+	line int
+
+	done chan struct{}
+	wg   sync.WaitGroup
+}
+
+// NewInput: creates a new s3 input
+func NewInput(
+	cfg *common.Config,
+	outletFactory channel.Connector,
+	context input.Context,
+) (input.Input, error) {
+	config := defaultConfig
+	err := cfg.Unpack(&config)
+	if err != nil {
+		return nil, err
+	}
+	p := &Input{
+		config: config,
+		done:   make(chan struct{}),
+	}
+	if p.include, err = compilePatterns(config.Include); err != nil {
+		return nil, err
+	}
+	if p.exclude, err = compilePatterns(config.Exclude); err != nil {
+		return nil, err
+	}
+
+	awsConfig := aws.Config{Region: aws.String(config.Region)}
+	if config.Endpoint != "" {
+		awsConfig.Endpoint = aws.String(config.Endpoint)
+	}
+	if config.ForcePathStyle {
+		awsConfig.S3ForcePathStyle = aws.Bool(true)
+	}
+	if config.AccessKeyID != "" {
+		awsConfig.Credentials = credentials.NewStaticCredentials(config.AccessKeyID, config.SecretAccessKey, "")
+	}
+	sess, err := session.NewSessionWithOptions(session.Options{
+		Config:            awsConfig,
+		SharedConfigState: session.SharedConfigEnable,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("create aws session failed, err=>%v", err)
+	}
+	p.client = s3.New(sess)
+
+	for _, s := range context.States {
+		if s.Type == S3FileStateType && s.Id == p.stateID() {
+			p.key = s.Meta["Key"]
+			p.line, _ = strconv.Atoi(s.Meta["Line"])
+		}
+	}
+
+	p.outlet, err = outletFactory(cfg, context.DynamicFields)
+	if err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func compilePatterns(patterns []string) ([]*regexp.Regexp, error) {
+	result := make([]*regexp.Regexp, 0, len(patterns))
+	for _, pattern := range patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("s3 pattern(%s) is invalid, err=>%v", pattern, err)
+		}
+		result = append(result, re)
+	}
+	return result, nil
+}
+
+func (p *Input) Reload() {}
+
+// Run 启动读取协程，只在首次调用时生效
+func (p *Input) Run() {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if !p.started {
+		p.wg.Add(1)
+		go p.run()
+		p.started = true
+	}
+}
+
+// Stop 停止读取，当前对象的读取进度已保存在采集状态中
+func (p *Input) Stop() {
+	logp.Info("Stopping s3 input, bucket=%s, prefix=%s", p.config.Bucket, p.config.Prefix)
+	p.mutex.Lock()
+	select {
+	case <-p.done:
+	default:
+		close(p.done)
+	}
+	p.mutex.Unlock()
+
+	p.wg.Wait()
+	_ = p.outlet.Close()
+}
+
+// Wait stop the current server
+func (p *Input) Wait() {
+	p.Stop()
+}
+
+func (p *Input) run() {
+	defer p.wg.Done()
+	// 继续读取上次未读完的对象
+	if p.key != "" && p.match(p.key) {
+		p.readObject(p.key, p.line)
+	}
+	ticker := time.NewTicker(p.config.PollInterval)
+	defer ticker.Stop()
+	for {
+		keys, err := p.list()
+		if err != nil {
+			s3ListFailed.Add(1)
+			logp.Err("list s3://%s/%s failed, err=>%v", p.config.Bucket, p.config.Prefix, err)
+		}
+		for _, key := range keys {
+			if p.isDone() {
+				return
+			}
+			p.readObject(key, 0)
+		}
+		select {
+		case <-p.done:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// match 对象key是否满足Include及Exclude
+func (p *Input) match(key string) bool {
+	if len(p.include) > 0 {
+		included := false
+		for _, re := range p.include {
+			if re.MatchString(key) {
+				included = true
+				break
+			}
+		}
+		if !included {
+			return false
+		}
+	}
+	for _, re := range p.exclude {
+		if re.MatchString(key) {
+			return false
+		}
+	}
+	return true
+}
+
+// list: 按字典序列举当前key之后的对象
+func (p *Input) list() ([]string, error) {
+	var keys []string
+	input := &s3.ListObjectsV2Input{
+		Bucket: aws.String(p.config.Bucket),
+		Prefix: aws.String(p.config.Prefix),
+	}
+	if p.key != "" {
+		input.StartAfter = aws.String(p.key)
+	}
+	err := p.client.ListObjectsV2Pages(input, func(output *s3.ListObjectsV2Output, last bool) bool {
+		for _, object := range output.Contents {
+			key := aws.StringValue(object.Key)
+			if !strings.HasSuffix(key, "/") && p.match(key) {
+				keys = append(keys, key)
+			}
+		}
+		return !p.isDone()
+	})
+	return keys, err
+}
+
+// readObject: 从skip行之后读取对象，读取失败时跳过该对象
+func (p *Input) readObject(key string, skip int) {
+	s3ObjectsTotal.Add(1)
+	p.key, p.line = key, skip
+	output, err := p.client.GetObject(&s3.GetObjectInput{
+		Bucket: aws.String(p.config.Bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		s3ObjectsFailed.Add(1)
+		logp.Err("get s3://%s/%s failed, err=>%v", p.config.Bucket, key, err)
+		return
+	}
+	defer output.Body.Close()
+
+	reader, err := p.decompress(key, aws.StringValue(output.ContentEncoding), bufio.NewReader(output.Body))
+	if err != nil {
+		s3ObjectsFailed.Add(1)
+		logp.Err("read s3://%s/%s failed, err=>%v", p.config.Bucket, key, err)
+		return
+	}
+	err = p.readLines(reader, key, skip)
+	if err != nil {
+		s3ObjectsFailed.Add(1)
+		logp.Err("read s3://%s/%s failed, err=>%v", p.config.Bucket, key, err)
+	}
+}
+
+// decompress: 按后缀、Content-Encoding或gzip魔数识别压缩对象
+func (p *Input) decompress(key, encoding string, reader *bufio.Reader) (io.Reader, error) {
+	magic, _ := reader.Peek(2)
+	if strings.HasSuffix(key, ".gz") || encoding == "gzip" || (len(magic) == 2 && magic[0] == 0x1f && magic[1] == 0x8b) {
+		return gzip.NewReader(reader)
+	}
+	return reader, nil
+}
+
+// readLines: 逐行发送，每个事件携带读取到该行时的采集状态
+func (p *Input) readLines(reader io.Reader, key string, skip int) error {
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 64*1024), 10*1024*1024)
+	line := 0
+	for scanner.Scan() {
+		line++
+		if line <= skip {
+			continue
+		}
+		if p.isDone() {
+			return nil
+		}
+		p.line = line
+		text := strings.TrimRight(scanner.Text(), "\r")
+		if text == "" {
+			continue
+		}
+		data := util.NewData()
+		data.SetState(p.state())
+		data.Event = beat.Event{
+			Timestamp: time.Now(),
+			Fields: common.MapStr{
+				"data": text,
+				"s3":   common.MapStr{"bucket": p.config.Bucket, "key": key},
+			},
+		}
+		p.outlet.OnEvent(data)
+	}
+	return scanner.Err()
+}
+
+// stateID: 按桶及前缀生成采集状态ID
+func (p *Input) stateID() string {
+	return fmt.Sprintf("s3://%s/%s", p.config.Bucket, p.config.Prefix)
+}
+
+// state: 当前对象及已读取的行数，由registrar持久化
+func (p *Input) state() file.State {
+	return file.State{
+		Id:        p.stateID(),
+		Type:      S3FileStateType,
+		Source:    p.stateID(),
+		Timestamp: time.Now(),
+		TTL:       -1,
+		Finished:  false,
+		Meta: map[string]string{
+			"Key":  p.key,
+			"Line": strconv.Itoa(p.line),
+		},
+	}
+}
+
+func (p *Input) isDone() bool {
+	select {
+	case <-p.done:
+		return true
+	default:
+		return false
+	}
+}
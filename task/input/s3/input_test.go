@@ -0,0 +1,87 @@
+// Tencent is pleased to support the open source community by making bkunifylogbeat 蓝鲸日志采集器 available.
+//
+// Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+//
+// bkunifylogbeat 蓝鲸日志采集器 is licensed under the MIT License.
+//
+// License for bkunifylogbeat 蓝鲸日志采集器:
+// --------------------------------------------------------------------
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+// documentation files (the "Software"), to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software,
+// and to permit persons to whom the Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all copies or substantial
+// portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+// LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN
+// NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+// WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+// SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package s3
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"strings"
+	"testing"
+
+	"github.com/elastic/beats/filebeat/util"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockOutlet struct {
+	events []*util.Data
+}
+
+func (o *mockOutlet) Close() error              { return nil }
+func (o *mockOutlet) Done() <-chan struct{}     { return nil }
+func (o *mockOutlet) OnEvent(d *util.Data) bool { o.events = append(o.events, d); return true }
+
+// TestMatch: 测试对象key的Include及Exclude过滤
+func TestMatch(t *testing.T) {
+	p := &Input{}
+	p.include, _ = compilePatterns([]string{`\.log(\.gz)?$`})
+	p.exclude, _ = compilePatterns([]string{`/debug/`})
+	assert.True(t, p.match("clb/2021/01/01/access.log.gz"))
+	assert.False(t, p.match("clb/2021/01/01/access.json"))
+	assert.False(t, p.match("clb/debug/access.log"))
+
+	_, err := compilePatterns([]string{"("})
+	assert.Error(t, err)
+}
+
+// TestReadLines: 测试gzip识别、跳过已读取行及采集状态
+func TestReadLines(t *testing.T) {
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	_, _ = w.Write([]byte("line1\r\nline2\n\nline3\n"))
+	_ = w.Close()
+
+	outlet := &mockOutlet{}
+	p := &Input{outlet: outlet, config: config{Bucket: "logs", Prefix: "clb/"}, done: make(chan struct{})}
+	// 按魔数识别未带.gz后缀的压缩对象
+	reader, err := p.decompress("clb/access", "", bufio.NewReader(&buf))
+	assert.NoError(t, err)
+	p.key = "clb/access"
+	assert.NoError(t, p.readLines(reader, "clb/access", 1))
+
+	assert.Len(t, outlet.events, 2)
+	assert.Equal(t, "line2", outlet.events[0].Event.Fields["data"])
+	assert.Equal(t, "line3", outlet.events[1].Event.Fields["data"])
+	s3Fields, _ := outlet.events[1].Event.Fields.GetValue("s3.key")
+	assert.Equal(t, "clb/access", s3Fields)
+	state := outlet.events[1].GetState()
+	assert.Equal(t, "s3://logs/clb/", state.Id)
+	assert.Equal(t, "4", state.Meta["Line"])
+
+	// 非压缩对象原样读取
+	reader, err = p.decompress("clb/plain", "", bufio.NewReader(strings.NewReader("plain\n")))
+	assert.NoError(t, err)
+	outlet.events = nil
+	assert.NoError(t, p.readLines(reader, "clb/plain", 0))
+	assert.Equal(t, "plain", outlet.events[0].Event.Fields["data"])
+}
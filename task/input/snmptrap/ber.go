@@ -0,0 +1,386 @@
+// Tencent is pleased to support the open source community by making bkunifylogbeat 蓝鲸日志采集器 available.
+//
+// Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+//
+// bkunifylogbeat 蓝鲸日志采集器 is licensed under the MIT License.
+//
+// License for bkunifylogbeat 蓝鲸日志采集器:
+// --------------------------------------------------------------------
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+// documentation files (the "Software"), to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software,
+// and to permit persons to whom the Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all copies or substantial
+// portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+// LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN
+// NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+// WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+// SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package snmptrap
+
+import (
+	"fmt"
+	"math/big"
+	"net"
+	"strconv"
+	"strings"
+)
+
+// BER标签，见RFC1157/RFC3416
+const (
+	tagInteger        = 0x02
+	tagOctetString    = 0x04
+	tagNull           = 0x05
+	tagOID            = 0x06
+	tagSequence       = 0x30
+	tagIPAddress      = 0x40
+	tagCounter32      = 0x41
+	tagGauge32        = 0x42
+	tagTimeTicks      = 0x43
+	tagOpaque         = 0x44
+	tagCounter64      = 0x46
+	tagNoSuchObject   = 0x80
+	tagNoSuchInstance = 0x81
+	tagEndOfMibView   = 0x82
+	tagTrapV1         = 0xa4
+	tagTrapV2         = 0xa7
+)
+
+const (
+	oidSysUpTime   = "1.3.6.1.2.1.1.3.0"
+	oidSnmpTrapOID = "1.3.6.1.6.3.1.1.4.1.0"
+	// RFC3584: v1通用trap对应的v2 trap OID前缀
+	oidGenericTraps = "1.3.6.1.6.3.1.1.5"
+)
+
+// varbind 变量绑定，Value为按类型解码后的值
+type varbind struct {
+	OID   string
+	Type  string
+	Value interface{}
+}
+
+// trap 解码后的v1/v2c trap，v1 trap按RFC3584转换出TrapOID
+type trap struct {
+	Version      string
+	Community    string
+	TrapOID      string
+	Uptime       uint64
+	Enterprise   string
+	AgentAddress string
+	GenericTrap  int64
+	SpecificTrap int64
+	Varbinds     []varbind
+}
+
+// element 一个BER编码的TLV
+type element struct {
+	tag   byte
+	value []byte
+}
+
+// readElement: 读取一个TLV，返回剩余数据
+func readElement(data []byte) (element, []byte, error) {
+	if len(data) < 2 {
+		return element{}, nil, fmt.Errorf("ber element is truncated")
+	}
+	tag := data[0]
+	length := int(data[1])
+	offset := 2
+	if length&0x80 != 0 {
+		n := length & 0x7f
+		if n == 0 || n > 4 || len(data) < 2+n {
+			return element{}, nil, fmt.Errorf("ber length is invalid")
+		}
+		length = 0
+		for _, b := range data[2 : 2+n] {
+			length = length<<8 | int(b)
+		}
+		offset += n
+	}
+	if length < 0 || len(data)-offset < length {
+		return element{}, nil, fmt.Errorf("ber element is truncated")
+	}
+	return element{tag: tag, value: data[offset : offset+length]}, data[offset+length:], nil
+}
+
+// readExpected: 读取指定标签的TLV
+func readExpected(data []byte, tag byte) (element, []byte, error) {
+	e, rest, err := readElement(data)
+	if err != nil {
+		return e, nil, err
+	}
+	if e.tag != tag {
+		return e, nil, fmt.Errorf("ber tag 0x%02x is expected, got 0x%02x", tag, e.tag)
+	}
+	return e, rest, nil
+}
+
+func decodeInteger(value []byte) (int64, error) {
+	if len(value) == 0 || len(value) > 8 {
+		return 0, fmt.Errorf("ber integer is invalid")
+	}
+	result := int64(int8(value[0]))
+	for _, b := range value[1:] {
+		result = result<<8 | int64(b)
+	}
+	return result, nil
+}
+
+func decodeUnsigned(value []byte) (uint64, error) {
+	// 无符号数最高位为1时会补一个前导0
+	if len(value) == 0 || len(value) > 9 || (len(value) == 9 && value[0] != 0) {
+		return 0, fmt.Errorf("ber unsigned integer is invalid")
+	}
+	var result uint64
+	for _, b := range value {
+		result = result<<8 | uint64(b)
+	}
+	return result, nil
+}
+
+func decodeOID(value []byte) (string, error) {
+	if len(value) == 0 {
+		return "", fmt.Errorf("ber oid is empty")
+	}
+	var parts []string
+	sub := new(big.Int)
+	first := true
+	for i, b := range value {
+		sub.Lsh(sub, 7)
+		sub.Or(sub, big.NewInt(int64(b&0x7f)))
+		if b&0x80 != 0 {
+			if i == len(value)-1 {
+				return "", fmt.Errorf("ber oid is truncated")
+			}
+			continue
+		}
+		if first {
+			// 首个子标识为40*X+Y
+			x := int64(2)
+			if sub.Cmp(big.NewInt(80)) < 0 {
+				x = sub.Int64() / 40
+			}
+			sub.Sub(sub, big.NewInt(40*x))
+			parts = append(parts, strconv.FormatInt(x, 10))
+			first = false
+		}
+		parts = append(parts, sub.String())
+		sub = new(big.Int)
+	}
+	return strings.Join(parts, "."), nil
+}
+
+// decodeValue: 按类型解码varbind的值，OCTET STRING为可打印文本时转为字符串，否则为十六进制
+func decodeValue(e element) (string, interface{}, error) {
+	switch e.tag {
+	case tagInteger:
+		v, err := decodeInteger(e.value)
+		return "integer", v, err
+	case tagOctetString:
+		if isPrintable(e.value) {
+			return "octet_string", string(e.value), nil
+		}
+		return "octet_string", fmt.Sprintf("%x", e.value), nil
+	case tagNull:
+		return "null", nil, nil
+	case tagOID:
+		v, err := decodeOID(e.value)
+		return "oid", v, err
+	case tagIPAddress:
+		if len(e.value) != 4 {
+			return "ip_address", nil, fmt.Errorf("ber ip address is invalid")
+		}
+		return "ip_address", net.IP(e.value).String(), nil
+	case tagCounter32:
+		v, err := decodeUnsigned(e.value)
+		return "counter32", v, err
+	case tagGauge32:
+		v, err := decodeUnsigned(e.value)
+		return "gauge32", v, err
+	case tagTimeTicks:
+		v, err := decodeUnsigned(e.value)
+		return "timeticks", v, err
+	case tagCounter64:
+		v, err := decodeUnsigned(e.value)
+		return "counter64", v, err
+	case tagOpaque:
+		return "opaque", fmt.Sprintf("%x", e.value), nil
+	case tagNoSuchObject:
+		return "no_such_object", nil, nil
+	case tagNoSuchInstance:
+		return "no_such_instance", nil, nil
+	case tagEndOfMibView:
+		return "end_of_mib_view", nil, nil
+	}
+	return fmt.Sprintf("unknown_0x%02x", e.tag), fmt.Sprintf("%x", e.value), nil
+}
+
+func isPrintable(value []byte) bool {
+	for _, b := range value {
+		if (b < 0x20 || b > 0x7e) && b != '\t' && b != '\r' && b != '\n' {
+			return false
+		}
+	}
+	return true
+}
+
+// parseVarbinds: 解析SEQUENCE OF SEQUENCE { OID, value }
+func parseVarbinds(data []byte) ([]varbind, error) {
+	list, _, err := readExpected(data, tagSequence)
+	if err != nil {
+		return nil, err
+	}
+	var result []varbind
+	rest := list.value
+	for len(rest) > 0 {
+		var item element
+		item, rest, err = readExpected(rest, tagSequence)
+		if err != nil {
+			return nil, err
+		}
+		name, value, err := readExpected(item.value, tagOID)
+		if err != nil {
+			return nil, err
+		}
+		oid, err := decodeOID(name.value)
+		if err != nil {
+			return nil, err
+		}
+		v, _, err := readElement(value)
+		if err != nil {
+			return nil, err
+		}
+		typ, decoded, err := decodeValue(v)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, varbind{OID: oid, Type: typ, Value: decoded})
+	}
+	return result, nil
+}
+
+// parseTrap: 解码SNMPv1/v2c的trap报文，不支持SNMPv3及需要应答的inform
+func parseTrap(packet []byte) (*trap, error) {
+	message, _, err := readExpected(packet, tagSequence)
+	if err != nil {
+		return nil, err
+	}
+	e, rest, err := readExpected(message.value, tagInteger)
+	if err != nil {
+		return nil, err
+	}
+	version, err := decodeInteger(e.value)
+	if err != nil {
+		return nil, err
+	}
+	t := &trap{}
+	switch version {
+	case 0:
+		t.Version = "v1"
+	case 1:
+		t.Version = "v2c"
+	default:
+		return nil, fmt.Errorf("snmp version %d is not supported", version)
+	}
+	e, rest, err = readExpected(rest, tagOctetString)
+	if err != nil {
+		return nil, err
+	}
+	t.Community = string(e.value)
+
+	pdu, _, err := readElement(rest)
+	if err != nil {
+		return nil, err
+	}
+	switch pdu.tag {
+	case tagTrapV1:
+		err = parseTrapV1(t, pdu.value)
+	case tagTrapV2:
+		err = parseTrapV2(t, pdu.value)
+	default:
+		return nil, fmt.Errorf("snmp pdu 0x%02x is not a trap", pdu.tag)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// parseTrapV1: enterprise, agent-addr, generic-trap, specific-trap, time-stamp, varbinds
+func parseTrapV1(t *trap, data []byte) error {
+	e, rest, err := readExpected(data, tagOID)
+	if err != nil {
+		return err
+	}
+	if t.Enterprise, err = decodeOID(e.value); err != nil {
+		return err
+	}
+	if e, rest, err = readExpected(rest, tagIPAddress); err != nil {
+		return err
+	}
+	if len(e.value) == 4 {
+		t.AgentAddress = net.IP(e.value).String()
+	}
+	if e, rest, err = readExpected(rest, tagInteger); err != nil {
+		return err
+	}
+	if t.GenericTrap, err = decodeInteger(e.value); err != nil {
+		return err
+	}
+	if e, rest, err = readExpected(rest, tagInteger); err != nil {
+		return err
+	}
+	if t.SpecificTrap, err = decodeInteger(e.value); err != nil {
+		return err
+	}
+	if e, rest, err = readExpected(rest, tagTimeTicks); err != nil {
+		return err
+	}
+	if t.Uptime, err = decodeUnsigned(e.value); err != nil {
+		return err
+	}
+	if t.Varbinds, err = parseVarbinds(rest); err != nil {
+		return err
+	}
+	if t.GenericTrap >= 0 && t.GenericTrap < 6 {
+		t.TrapOID = fmt.Sprintf("%s.%d", oidGenericTraps, t.GenericTrap+1)
+	} else {
+		t.TrapOID = fmt.Sprintf("%s.0.%d", t.Enterprise, t.SpecificTrap)
+	}
+	return nil
+}
+
+// parseTrapV2: request-id, error-status, error-index, varbinds，前两个varbind为sysUpTime.0及snmpTrapOID.0
+func parseTrapV2(t *trap, data []byte) error {
+	rest := data
+	var err error
+	for i := 0; i < 3; i++ {
+		if _, rest, err = readExpected(rest, tagInteger); err != nil {
+			return err
+		}
+	}
+	varbinds, err := parseVarbinds(rest)
+	if err != nil {
+		return err
+	}
+	for _, v := range varbinds {
+		switch v.OID {
+		case oidSysUpTime:
+			if uptime, ok := v.Value.(uint64); ok {
+				t.Uptime = uptime
+			}
+		case oidSnmpTrapOID:
+			if oid, ok := v.Value.(string); ok {
+				t.TrapOID = oid
+			}
+		default:
+			t.Varbinds = append(t.Varbinds, v)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,131 @@
+// Tencent is pleased to support the open source community by making bkunifylogbeat 蓝鲸日志采集器 available.
+//
+// Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+//
+// bkunifylogbeat 蓝鲸日志采集器 is licensed under the MIT License.
+//
+// License for bkunifylogbeat 蓝鲸日志采集器:
+// --------------------------------------------------------------------
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+// documentation files (the "Software"), to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software,
+// and to permit persons to whom the Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all copies or substantial
+// portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+// LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN
+// NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+// WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+// SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package snmptrap
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// tlv: 编码短长度形式的TLV
+func tlv(tag byte, parts ...[]byte) []byte {
+	var value []byte
+	for _, part := range parts {
+		value = append(value, part...)
+	}
+	if len(value) < 0x80 {
+		return append([]byte{tag, byte(len(value))}, value...)
+	}
+	return append([]byte{tag, 0x81, byte(len(value))}, value...)
+}
+
+func oid(encoded ...byte) []byte {
+	return tlv(tagOID, encoded)
+}
+
+// TestParseTrapV2: 测试v2c trap解码及sysUpTime/snmpTrapOID提取
+func TestParseTrapV2(t *testing.T) {
+	packet := tlv(tagSequence,
+		tlv(tagInteger, []byte{1}),
+		tlv(tagOctetString, []byte("public")),
+		tlv(tagTrapV2,
+			tlv(tagInteger, []byte{0x12, 0x34}),
+			tlv(tagInteger, []byte{0}),
+			tlv(tagInteger, []byte{0}),
+			tlv(tagSequence,
+				// sysUpTime.0 = 12345
+				tlv(tagSequence, oid(0x2b, 6, 1, 2, 1, 1, 3, 0), tlv(tagTimeTicks, []byte{0x30, 0x39})),
+				// snmpTrapOID.0 = linkDown
+				tlv(tagSequence, oid(0x2b, 6, 1, 6, 3, 1, 1, 4, 1, 0), oid(0x2b, 6, 1, 6, 3, 1, 1, 5, 3)),
+				// ifIndex.2 = 2
+				tlv(tagSequence, oid(0x2b, 6, 1, 2, 1, 2, 2, 1, 1, 2), tlv(tagInteger, []byte{2})),
+				// ifDescr.2 = "eth0"
+				tlv(tagSequence, oid(0x2b, 6, 1, 2, 1, 2, 2, 1, 2, 2), tlv(tagOctetString, []byte("eth0"))),
+				// 多字节子标识及Counter32最高位
+				tlv(tagSequence, oid(0x2b, 6, 1, 4, 1, 0x81, 0x80, 0x00, 1), tlv(tagCounter32, []byte{0x00, 0xff, 0xff, 0xff, 0xff})),
+			),
+		),
+	)
+	trap, err := parseTrap(packet)
+	assert.NoError(t, err)
+	assert.Equal(t, "v2c", trap.Version)
+	assert.Equal(t, "public", trap.Community)
+	assert.Equal(t, "1.3.6.1.6.3.1.1.5.3", trap.TrapOID)
+	assert.Equal(t, uint64(12345), trap.Uptime)
+	assert.Equal(t, []varbind{
+		{OID: "1.3.6.1.2.1.2.2.1.1.2", Type: "integer", Value: int64(2)},
+		{OID: "1.3.6.1.2.1.2.2.1.2.2", Type: "octet_string", Value: "eth0"},
+		{OID: "1.3.6.1.4.1.16384.1", Type: "counter32", Value: uint64(0xffffffff)},
+	}, trap.Varbinds)
+
+	fields := toFields(trap, "10.0.0.1:50000")
+	assert.Equal(t, "1.3.6.1.6.3.1.1.5.3 1.3.6.1.2.1.2.2.1.1.2=2 1.3.6.1.2.1.2.2.1.2.2=eth0 1.3.6.1.4.1.16384.1=4294967295", fields["data"])
+}
+
+// TestParseTrapV1: 测试v1 trap解码及按RFC3584转换trap OID
+func TestParseTrapV1(t *testing.T) {
+	pdu := func(generic byte) []byte {
+		return tlv(tagSequence,
+			tlv(tagInteger, []byte{0}),
+			tlv(tagOctetString, []byte("private")),
+			tlv(tagTrapV1,
+				oid(0x2b, 6, 1, 4, 1, 9),
+				tlv(tagIPAddress, []byte{192, 168, 1, 1}),
+				tlv(tagInteger, []byte{generic}),
+				tlv(tagInteger, []byte{0xff}),
+				tlv(tagTimeTicks, []byte{100}),
+				tlv(tagSequence,
+					tlv(tagSequence, oid(0x2b, 6, 1, 4, 1, 9, 1), tlv(tagOctetString, []byte{0x00, 0x01})),
+				),
+			),
+		)
+	}
+	trap, err := parseTrap(pdu(2))
+	assert.NoError(t, err)
+	assert.Equal(t, "v1", trap.Version)
+	assert.Equal(t, "1.3.6.1.6.3.1.1.5.3", trap.TrapOID)
+	assert.Equal(t, "192.168.1.1", trap.AgentAddress)
+	assert.Equal(t, int64(-1), trap.SpecificTrap)
+	assert.Equal(t, uint64(100), trap.Uptime)
+	// 不可打印的OCTET STRING转为十六进制
+	assert.Equal(t, "0001", trap.Varbinds[0].Value)
+
+	// enterpriseSpecific
+	trap, err = parseTrap(pdu(6))
+	assert.NoError(t, err)
+	assert.Equal(t, "1.3.6.1.4.1.9.0.-1", trap.TrapOID)
+}
+
+// TestParseTrapInvalid: 测试截断报文、SNMPv3及非trap PDU
+func TestParseTrapInvalid(t *testing.T) {
+	_, err := parseTrap([]byte{0x30, 0x10, 0x02})
+	assert.Error(t, err)
+
+	_, err = parseTrap(tlv(tagSequence, tlv(tagInteger, []byte{3})))
+	assert.Error(t, err)
+
+	// GetRequest
+	_, err = parseTrap(tlv(tagSequence, tlv(tagInteger, []byte{1}), tlv(tagOctetString, []byte("public")), tlv(0xa0)))
+	assert.Error(t, err)
+}
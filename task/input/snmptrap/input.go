@@ -0,0 +1,178 @@
+// Tencent is pleased to support the open source community by making bkunifylogbeat 蓝鲸日志采集器 available.
+//
+// Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+//
+// bkunifylogbeat 蓝鲸日志采集器 is licensed under the MIT License.
+//
+// License for bkunifylogbeat 蓝鲸日志采集器:
+// --------------------------------------------------------------------
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+// documentation files (the "Software"), to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software,
+// and to permit persons to whom the Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all copies or substantial
+// portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+// LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN
+// NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+// WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+// SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package snmptrap
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/TencentBlueKing/bkunifylogbeat/task/input/socket"
+	"github.com/TencentBlueKing/collector-go-sdk/v2/bkbeat/bkmonitoring"
+	"github.com/elastic/beats/filebeat/channel"
+	"github.com/elastic/beats/filebeat/input"
+	"github.com/elastic/beats/filebeat/util"
+	"github.com/elastic/beats/libbeat/beat"
+	"github.com/elastic/beats/libbeat/common"
+	"github.com/elastic/beats/libbeat/logp"
+)
+
+var (
+	snmpTrapReceived = bkmonitoring.NewInt("input_snmptrap_received")
+	snmpTrapInvalid  = bkmonitoring.NewInt("input_snmptrap_invalid")
+	snmpTrapRejected = bkmonitoring.NewInt("input_snmptrap_rejected")
+)
+
+func init() {
+	err := input.Register("snmptrap", NewInput)
+	if err != nil {
+		panic(err)
+	}
+}
+
+// Input 监听UDP端口接收SNMPv1/v2c trap，解码后的事件进入任务的过滤及处理链
+type Input struct {
+	started bool
+	mutex   sync.Mutex
+	outlet  channel.Outleter
+
+	config      config
+	communities map[string]struct{}
+	server      *socket.Server
+}
+
+// NewInput: creates a new snmp trap input
+func NewInput(
+	cfg *common.Config,
+	outletFactory channel.Connector,
+	context input.Context,
+) (input.Input, error) {
+	config := defaultConfig
+	err := cfg.Unpack(&config)
+	if err != nil {
+		return nil, err
+	}
+
+	outlet, err := outletFactory(cfg, context.DynamicFields)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &Input{
+		outlet:      outlet,
+		config:      config,
+		communities: make(map[string]struct{}),
+	}
+	for _, community := range config.Communities {
+		p.communities[community] = struct{}{}
+	}
+	p.server = socket.NewServer(socket.ServerConfig{
+		Protocol:       "udp",
+		Host:           config.Host,
+		MaxMessageSize: config.MaxMessageSize,
+	}, nil, p.publish)
+	return p, nil
+}
+
+func (p *Input) Reload() {}
+
+// Run 开始监听，只在首次调用时生效
+func (p *Input) Run() {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if p.started {
+		return
+	}
+	if err := p.server.Start(); err != nil {
+		logp.Err("snmptrap listen on udp://%s failed, err=>%v", p.config.Host, err)
+		return
+	}
+	logp.Info("snmptrap listening on udp://%s", p.config.Host)
+	p.started = true
+}
+
+// Stop 关闭监听
+func (p *Input) Stop() {
+	logp.Info("Stopping snmptrap input on udp://%s", p.config.Host)
+	p.server.Stop()
+	_ = p.outlet.Close()
+}
+
+// Wait stop the current server
+func (p *Input) Wait() {
+	p.Stop()
+}
+
+// publish: 解码报文并校验community，事件不带采集状态
+func (p *Input) publish(packet string, remote string) {
+	snmpTrapReceived.Add(1)
+	t, err := parseTrap([]byte(packet))
+	if err != nil {
+		snmpTrapInvalid.Add(1)
+		logp.Debug("snmptrap", "decode trap from %s failed, err=>%v", remote, err)
+		return
+	}
+	if len(p.communities) > 0 {
+		if _, ok := p.communities[t.Community]; !ok {
+			snmpTrapRejected.Add(1)
+			return
+		}
+	}
+
+	data := util.NewData()
+	data.Event = beat.Event{
+		Timestamp: time.Now(),
+		Fields:    toFields(t, remote),
+	}
+	p.outlet.OnEvent(data)
+}
+
+// toFields: trap转换为事件字段，data为"trap OID 变量=值..."格式的文本
+func toFields(t *trap, remote string) common.MapStr {
+	varbinds := make([]common.MapStr, 0, len(t.Varbinds))
+	texts := []string{t.TrapOID}
+	for _, v := range t.Varbinds {
+		varbinds = append(varbinds, common.MapStr{"oid": v.OID, "type": v.Type, "value": v.Value})
+		texts = append(texts, fmt.Sprintf("%s=%v", v.OID, v.Value))
+	}
+	snmp := common.MapStr{
+		"version":   t.Version,
+		"community": t.Community,
+		"oid":       t.TrapOID,
+		"uptime":    t.Uptime,
+		"source":    remote,
+		"varbinds":  varbinds,
+	}
+	if t.Version == "v1" {
+		snmp["enterprise"] = t.Enterprise
+		snmp["agent_address"] = t.AgentAddress
+		snmp["generic_trap"] = t.GenericTrap
+		snmp["specific_trap"] = t.SpecificTrap
+	}
+	return common.MapStr{
+		"data": strings.Join(texts, " "),
+		"snmp": snmp,
+	}
+}
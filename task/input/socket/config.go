@@ -0,0 +1,54 @@
+// Tencent is pleased to support the open source community by making bkunifylogbeat 蓝鲸日志采集器 available.
+//
+// Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+//
+// bkunifylogbeat 蓝鲸日志采集器 is licensed under the MIT License.
+//
+// License for bkunifylogbeat 蓝鲸日志采集器:
+// --------------------------------------------------------------------
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+// documentation files (the "Software"), to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software,
+// and to permit persons to whom the Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all copies or substantial
+// portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+// LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN
+// NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+// WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+// SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package socket
+
+import (
+	"time"
+
+	"github.com/dustin/go-humanize"
+)
+
+var defaultConfig = config{
+	Protocol:       "tcp",
+	Framing:        "newline",
+	LineDelimiter:  "\n",
+	LengthBytes:    4,
+	MaxMessageSize: 64 * humanize.KiByte,
+	Timeout:        5 * time.Minute,
+}
+
+type config struct {
+	// 监听协议: udp或tcp，udp时每个数据报为一条消息
+	Protocol string `config:"protocol"`
+	Host     string `config:"host" validate:"required"`
+	// tcp分帧方式: newline、octet_counting或length_prefix
+	Framing string `config:"framing"`
+	// newline分帧的分隔符，只支持单个字节
+	LineDelimiter string `config:"line_delimiter"`
+	// length_prefix分帧的长度字节数，2或4，大端序
+	LengthBytes int `config:"length_bytes"`
+	// 单条消息的最大字节数，超过时丢弃
+	MaxMessageSize int `config:"max_message_size" validate:"min=1"`
+	// tcp连接空闲超时
+	Timeout time.Duration `config:"timeout" validate:"min=0"`
+}
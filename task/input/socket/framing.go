@@ -0,0 +1,115 @@
+// Tencent is pleased to support the open source community by making bkunifylogbeat 蓝鲸日志采集器 available.
+//
+// Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+//
+// bkunifylogbeat 蓝鲸日志采集器 is licensed under the MIT License.
+//
+// License for bkunifylogbeat 蓝鲸日志采集器:
+// --------------------------------------------------------------------
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+// documentation files (the "Software"), to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software,
+// and to permit persons to whom the Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all copies or substantial
+// portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+// LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN
+// NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+// WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+// SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package socket
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// ErrMessageTooLarge 消息超过最大长度，消息已被跳过，可以继续读取下一条
+var ErrMessageTooLarge = errors.New("message too large")
+
+// Framer 从tcp连接中读取一条消息
+type Framer func(reader *bufio.Reader) (string, error)
+
+// NewFramer 生成分帧方式：
+// newline按delimiter分隔；octet_counting为RFC6587的"长度 消息"格式，不以数字开头时按delimiter分隔；
+// length_prefix为lengthBytes(2或4)字节大端序长度加消息
+func NewFramer(framing string, delimiter byte, lengthBytes int, maxSize int) (Framer, error) {
+	switch framing {
+	case "newline":
+		return func(reader *bufio.Reader) (string, error) {
+			return readDelimited(reader, delimiter)
+		}, nil
+	case "octet_counting":
+		return func(reader *bufio.Reader) (string, error) {
+			first, err := reader.Peek(1)
+			if err != nil {
+				return "", err
+			}
+			if first[0] < '1' || first[0] > '9' {
+				return readDelimited(reader, delimiter)
+			}
+			prefix, err := reader.ReadString(' ')
+			if err != nil {
+				return "", err
+			}
+			size, err := strconv.Atoi(prefix[:len(prefix)-1])
+			if err != nil {
+				return "", fmt.Errorf("invalid octet count %q", prefix)
+			}
+			return readSized(reader, size, maxSize)
+		}, nil
+	case "length_prefix":
+		if lengthBytes != 2 && lengthBytes != 4 {
+			return nil, fmt.Errorf("length_bytes must be 2 or 4")
+		}
+		return func(reader *bufio.Reader) (string, error) {
+			header := make([]byte, lengthBytes)
+			if _, err := io.ReadFull(reader, header); err != nil {
+				return "", err
+			}
+			size := int(binary.BigEndian.Uint16(header[lengthBytes-2:]))
+			if lengthBytes == 4 {
+				size = int(binary.BigEndian.Uint32(header))
+			}
+			return readSized(reader, size, maxSize)
+		}, nil
+	}
+	return nil, fmt.Errorf("framing must be newline, octet_counting or length_prefix")
+}
+
+// readDelimited: 读取到分隔符为止，超过缓冲区大小的消息被跳过；返回的消息包含分隔符
+func readDelimited(reader *bufio.Reader, delimiter byte) (string, error) {
+	line, err := reader.ReadSlice(delimiter)
+	if err == bufio.ErrBufferFull {
+		for err == bufio.ErrBufferFull {
+			_, err = reader.ReadSlice(delimiter)
+		}
+		if err != nil {
+			return "", err
+		}
+		return "", ErrMessageTooLarge
+	}
+	return string(line), err
+}
+
+// readSized: 读取指定长度的消息，超过maxSize时跳过
+func readSized(reader *bufio.Reader, size int, maxSize int) (string, error) {
+	if size > maxSize {
+		if _, err := reader.Discard(size); err != nil {
+			return "", err
+		}
+		return "", ErrMessageTooLarge
+	}
+	buf := make([]byte, size)
+	if _, err := io.ReadFull(reader, buf); err != nil {
+		return "", err
+	}
+	return string(buf), nil
+}
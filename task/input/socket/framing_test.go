@@ -0,0 +1,74 @@
+// Tencent is pleased to support the open source community by making bkunifylogbeat 蓝鲸日志采集器 available.
+//
+// Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+//
+// bkunifylogbeat 蓝鲸日志采集器 is licensed under the MIT License.
+//
+// License for bkunifylogbeat 蓝鲸日志采集器:
+// --------------------------------------------------------------------
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+// documentation files (the "Software"), to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software,
+// and to permit persons to whom the Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all copies or substantial
+// portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+// LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN
+// NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+// WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+// SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package socket
+
+import (
+	"bufio"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestOctetCountingFramer: 测试RFC6587长度前缀及换行混合分帧
+func TestOctetCountingFramer(t *testing.T) {
+	framer, err := NewFramer("octet_counting", '\n', 0, 16)
+	assert.NoError(t, err)
+	reader := bufio.NewReaderSize(strings.NewReader("11 <14>a\nb c d\n"+strings.Repeat("x", 40)+"\n18 "+strings.Repeat("y", 18)+"last"), 16)
+	line, err := framer(reader)
+	assert.NoError(t, err)
+	assert.Equal(t, "<14>a\nb c d", line)
+	line, err = framer(reader)
+	assert.NoError(t, err)
+	assert.Equal(t, "\n", line)
+	_, err = framer(reader)
+	assert.Equal(t, ErrMessageTooLarge, err)
+	_, err = framer(reader)
+	assert.Equal(t, ErrMessageTooLarge, err)
+	line, _ = framer(reader)
+	assert.Equal(t, "last", line)
+}
+
+// TestLengthPrefixFramer: 测试大端序长度前缀及自定义分隔符
+func TestLengthPrefixFramer(t *testing.T) {
+	framer, err := NewFramer("length_prefix", '\n', 2, 16)
+	assert.NoError(t, err)
+	reader := bufio.NewReader(strings.NewReader("\x00\x05hello\x00\x20" + strings.Repeat("z", 32) + "\x00\x02ok"))
+	line, err := framer(reader)
+	assert.NoError(t, err)
+	assert.Equal(t, "hello", line)
+	_, err = framer(reader)
+	assert.Equal(t, ErrMessageTooLarge, err)
+	line, err = framer(reader)
+	assert.NoError(t, err)
+	assert.Equal(t, "ok", line)
+
+	framer, err = NewFramer("newline", '|', 0, 16)
+	assert.NoError(t, err)
+	reader = bufio.NewReader(strings.NewReader("a|b|"))
+	line, _ = framer(reader)
+	assert.Equal(t, "a|", line)
+
+	_, err = NewFramer("length_prefix", '\n', 3, 16)
+	assert.Error(t, err)
+}
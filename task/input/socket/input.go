@@ -0,0 +1,146 @@
+// Tencent is pleased to support the open source community by making bkunifylogbeat 蓝鲸日志采集器 available.
+//
+// Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+//
+// bkunifylogbeat 蓝鲸日志采集器 is licensed under the MIT License.
+//
+// License for bkunifylogbeat 蓝鲸日志采集器:
+// --------------------------------------------------------------------
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+// documentation files (the "Software"), to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software,
+// and to permit persons to whom the Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all copies or substantial
+// portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+// LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN
+// NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+// WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+// SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package socket
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/TencentBlueKing/collector-go-sdk/v2/bkbeat/bkmonitoring"
+	"github.com/elastic/beats/filebeat/channel"
+	"github.com/elastic/beats/filebeat/input"
+	"github.com/elastic/beats/filebeat/util"
+	"github.com/elastic/beats/libbeat/beat"
+	"github.com/elastic/beats/libbeat/common"
+	"github.com/elastic/beats/libbeat/logp"
+)
+
+var (
+	socketReceived = bkmonitoring.NewInt("input_socket_received")
+)
+
+func init() {
+	err := input.Register("socket", NewInput)
+	if err != nil {
+		panic(err)
+	}
+}
+
+// Input 监听TCP/UDP端口接收纯文本日志，每条消息作为data进入任务的过滤及处理链
+type Input struct {
+	started bool
+	mutex   sync.Mutex
+	outlet  channel.Outleter
+
+	config config
+	server *Server
+}
+
+// NewInput: creates a new raw socket input
+func NewInput(
+	cfg *common.Config,
+	outletFactory channel.Connector,
+	context input.Context,
+) (input.Input, error) {
+	config := defaultConfig
+	err := cfg.Unpack(&config)
+	if err != nil {
+		return nil, err
+	}
+	if config.Protocol != "udp" && config.Protocol != "tcp" {
+		return nil, fmt.Errorf("socket protocol must be udp or tcp")
+	}
+	if len(config.LineDelimiter) != 1 {
+		return nil, fmt.Errorf("socket line_delimiter must be a single byte")
+	}
+	framer, err := NewFramer(config.Framing, config.LineDelimiter[0], config.LengthBytes, config.MaxMessageSize)
+	if err != nil {
+		return nil, err
+	}
+
+	outlet, err := outletFactory(cfg, context.DynamicFields)
+	if err != nil {
+		return nil, err
+	}
+	p := &Input{
+		outlet: outlet,
+		config: config,
+	}
+	p.server = NewServer(ServerConfig{
+		Protocol:       config.Protocol,
+		Host:           config.Host,
+		MaxMessageSize: config.MaxMessageSize,
+		Timeout:        config.Timeout,
+	}, framer, p.publish)
+	return p, nil
+}
+
+func (p *Input) Reload() {}
+
+// Run 开始监听，只在首次调用时生效
+func (p *Input) Run() {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if p.started {
+		return
+	}
+	if err := p.server.Start(); err != nil {
+		logp.Err("socket listen on %s://%s failed, err=>%v", p.config.Protocol, p.config.Host, err)
+		return
+	}
+	logp.Info("socket listening on %s://%s", p.config.Protocol, p.config.Host)
+	p.started = true
+}
+
+// Stop 关闭监听及所有连接
+func (p *Input) Stop() {
+	logp.Info("Stopping socket input on %s://%s", p.config.Protocol, p.config.Host)
+	p.server.Stop()
+	_ = p.outlet.Close()
+}
+
+// Wait stop the current server
+func (p *Input) Wait() {
+	p.Stop()
+}
+
+// publish: 去掉消息末尾的分隔符后发送到任务，空消息忽略
+func (p *Input) publish(message string, remote string) {
+	message = strings.TrimRight(message, p.config.LineDelimiter+"\r")
+	if message == "" {
+		return
+	}
+	socketReceived.Add(1)
+	data := util.NewData()
+	data.Event = beat.Event{
+		Timestamp: time.Now(),
+		Fields: common.MapStr{
+			"data":   message,
+			"socket": common.MapStr{"source": remote},
+		},
+	}
+	p.outlet.OnEvent(data)
+}
@@ -0,0 +1,204 @@
+// Tencent is pleased to support the open source community by making bkunifylogbeat 蓝鲸日志采集器 available.
+//
+// Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+//
+// bkunifylogbeat 蓝鲸日志采集器 is licensed under the MIT License.
+//
+// License for bkunifylogbeat 蓝鲸日志采集器:
+// --------------------------------------------------------------------
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+// documentation files (the "Software"), to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software,
+// and to permit persons to whom the Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all copies or substantial
+// portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+// LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN
+// NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+// WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+// SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package socket
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/TencentBlueKing/collector-go-sdk/v2/bkbeat/bkmonitoring"
+	"github.com/elastic/beats/libbeat/logp"
+)
+
+var (
+	socketDropped = bkmonitoring.NewInt("input_socket_dropped")
+)
+
+// ServerConfig: 监听配置，udp时每个数据报为一条消息，tcp时按Framer分帧
+type ServerConfig struct {
+	Protocol       string
+	Host           string
+	MaxMessageSize int
+	// tcp连接空闲超时，0为不超时
+	Timeout time.Duration
+}
+
+// Handler 处理一条消息，remote为对端地址
+type Handler func(message string, remote string)
+
+// Server udp/tcp监听服务，供syslog、socket等网络类采集插件复用
+type Server struct {
+	config  ServerConfig
+	framer  Framer
+	handler Handler
+
+	mutex    sync.Mutex
+	listener io.Closer
+	conns    map[net.Conn]struct{}
+	done     chan struct{}
+	wg       sync.WaitGroup
+}
+
+// NewServer 生成监听服务，framer只用于tcp
+func NewServer(config ServerConfig, framer Framer, handler Handler) *Server {
+	return &Server{
+		config:  config,
+		framer:  framer,
+		handler: handler,
+		conns:   make(map[net.Conn]struct{}),
+		done:    make(chan struct{}),
+	}
+}
+
+// Start 开始监听
+func (s *Server) Start() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.config.Protocol == "udp" {
+		return s.listenUDP()
+	}
+	return s.listenTCP()
+}
+
+// Stop 关闭监听及所有连接，等待处理中的消息结束
+func (s *Server) Stop() {
+	s.mutex.Lock()
+	select {
+	case <-s.done:
+	default:
+		close(s.done)
+	}
+	if s.listener != nil {
+		_ = s.listener.Close()
+	}
+	for conn := range s.conns {
+		_ = conn.Close()
+	}
+	s.mutex.Unlock()
+	s.wg.Wait()
+}
+
+func (s *Server) listenUDP() error {
+	conn, err := net.ListenPacket("udp", s.config.Host)
+	if err != nil {
+		return err
+	}
+	s.listener = conn
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		// 多预留一个字节用于判断消息是否被截断
+		buf := make([]byte, s.config.MaxMessageSize+1)
+		for {
+			n, addr, err := conn.ReadFrom(buf)
+			if err != nil {
+				if !s.isDone() {
+					logp.Err("read udp on %s failed, err=>%v", s.config.Host, err)
+				}
+				return
+			}
+			if n > s.config.MaxMessageSize {
+				socketDropped.Add(1)
+				continue
+			}
+			s.handler(string(buf[:n]), addr.String())
+		}
+	}()
+	return nil
+}
+
+func (s *Server) listenTCP() error {
+	listener, err := net.Listen("tcp", s.config.Host)
+	if err != nil {
+		return err
+	}
+	s.listener = listener
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				if !s.isDone() {
+					logp.Err("accept tcp on %s failed, err=>%v", s.config.Host, err)
+				}
+				return
+			}
+			s.mutex.Lock()
+			if s.isDone() {
+				s.mutex.Unlock()
+				_ = conn.Close()
+				return
+			}
+			s.conns[conn] = struct{}{}
+			s.wg.Add(1)
+			s.mutex.Unlock()
+			go s.handleConn(conn)
+		}
+	}()
+	return nil
+}
+
+// handleConn: 按帧读取tcp连接中的消息，空闲超时或出错时关闭连接
+func (s *Server) handleConn(conn net.Conn) {
+	defer func() {
+		s.mutex.Lock()
+		delete(s.conns, conn)
+		s.mutex.Unlock()
+		_ = conn.Close()
+		s.wg.Done()
+	}()
+	remote := conn.RemoteAddr().String()
+	reader := bufio.NewReaderSize(conn, s.config.MaxMessageSize)
+	for {
+		if s.config.Timeout > 0 {
+			_ = conn.SetReadDeadline(time.Now().Add(s.config.Timeout))
+		}
+		message, err := s.framer(reader)
+		if err == ErrMessageTooLarge {
+			socketDropped.Add(1)
+			continue
+		}
+		if message != "" {
+			s.handler(message, remote)
+		}
+		if err != nil {
+			if err != io.EOF && !s.isDone() {
+				logp.Debug("socket", "connection %s closed, err=>%v", remote, err)
+			}
+			return
+		}
+	}
+}
+
+func (s *Server) isDone() bool {
+	select {
+	case <-s.done:
+		return true
+	default:
+		return false
+	}
+}
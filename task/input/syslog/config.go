@@ -0,0 +1,103 @@
+// Tencent is pleased to support the open source community by making bkunifylogbeat 蓝鲸日志采集器 available.
+//
+// Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+//
+// bkunifylogbeat 蓝鲸日志采集器 is licensed under the MIT License.
+//
+// License for bkunifylogbeat 蓝鲸日志采集器:
+// --------------------------------------------------------------------
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+// documentation files (the "Software"), to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software,
+// and to permit persons to whom the Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all copies or substantial
+// portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+// LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN
+// NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+// WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+// SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package syslog
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/dustin/go-humanize"
+	"github.com/elastic/beats/libbeat/common"
+	"github.com/elastic/beats/libbeat/common/cfgtype"
+)
+
+var defaultConfig = config{
+	Protocol:       protocol{Name: "udp"},
+	Host:           ":514",
+	MaxMessageSize: 64 * humanize.KiByte,
+	Timeout:        5 * time.Minute,
+	Timezone:       "Local",
+}
+
+type config struct {
+	// 监听协议: udp或tcp，tcp同时支持换行分隔及RFC6587长度前缀两种分帧方式
+	Protocol protocol `config:"protocol"`
+	Host     string `config:"host"`
+	// 单条消息的最大字节数，超过时丢弃
+	MaxMessageSize int `config:"max_message_size" validate:"min=1"`
+	// tcp连接空闲超时
+	Timeout time.Duration `config:"timeout" validate:"min=0"`
+	// RFC3164消息时间不带时区，按该时区解析
+	Timezone string `config:"timezone"`
+}
+
+// protocol: 监听协议，除"udp"/"tcp"外，兼容原filebeat syslog输入的protocol.udp/protocol.tcp配置块，
+// 配置块中的host、max_message_size、timeout覆盖外层的同名配置
+type protocol struct {
+	Name    string
+	options protocolOptions
+}
+
+type protocolOptions struct {
+	Host           string           `config:"host"`
+	MaxMessageSize cfgtype.ByteSize `config:"max_message_size"`
+	Timeout        time.Duration    `config:"timeout"`
+}
+
+// Unpack 解析字符串或配置块形式的protocol
+func (p *protocol) Unpack(v interface{}) error {
+	switch value := v.(type) {
+	case string:
+		*p = protocol{Name: value}
+		return nil
+	case map[string]interface{}:
+		if len(value) != 1 {
+			return fmt.Errorf("syslog protocol must set exactly one of udp or tcp")
+		}
+		for name, options := range value {
+			*p = protocol{Name: name}
+			if options == nil {
+				return nil
+			}
+			cfg, err := common.NewConfigFrom(options)
+			if err != nil {
+				return err
+			}
+			return cfg.Unpack(&p.options)
+		}
+	}
+	return fmt.Errorf("syslog protocol must be udp or tcp")
+}
+
+// applyProtocol: 使用protocol配置块中的参数覆盖外层配置
+func (c *config) applyProtocol() {
+	if c.Protocol.options.Host != "" {
+		c.Host = c.Protocol.options.Host
+	}
+	if c.Protocol.options.MaxMessageSize > 0 {
+		c.MaxMessageSize = int(c.Protocol.options.MaxMessageSize)
+	}
+	if c.Protocol.options.Timeout > 0 {
+		c.Timeout = c.Protocol.options.Timeout
+	}
+}
@@ -0,0 +1,68 @@
+// Tencent is pleased to support the open source community by making bkunifylogbeat 蓝鲸日志采集器 available.
+//
+// Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+//
+// bkunifylogbeat 蓝鲸日志采集器 is licensed under the MIT License.
+//
+// License for bkunifylogbeat 蓝鲸日志采集器:
+// --------------------------------------------------------------------
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+// documentation files (the "Software"), to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software,
+// and to permit persons to whom the Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all copies or substantial
+// portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+// LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN
+// NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+// WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+// SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package syslog
+
+import (
+	"testing"
+	"time"
+
+	"github.com/elastic/beats/libbeat/common"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestConfigProtocol: 测试字符串形式及兼容filebeat的配置块形式的protocol
+func TestConfigProtocol(t *testing.T) {
+	unpack := func(values map[string]interface{}) (config, error) {
+		c := defaultConfig
+		err := common.MustNewConfigFrom(values).Unpack(&c)
+		c.applyProtocol()
+		return c, err
+	}
+
+	c, err := unpack(map[string]interface{}{"protocol": "tcp", "host": ":1514"})
+	assert.NoError(t, err)
+	assert.Equal(t, "tcp", c.Protocol.Name)
+	assert.Equal(t, ":1514", c.Host)
+
+	c, err = unpack(map[string]interface{}{
+		"protocol.udp": map[string]interface{}{"host": "127.0.0.1:5514", "max_message_size": "10KiB"},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "udp", c.Protocol.Name)
+	assert.Equal(t, "127.0.0.1:5514", c.Host)
+	assert.Equal(t, 10*1024, c.MaxMessageSize)
+	assert.Equal(t, defaultConfig.Timeout, c.Timeout)
+
+	c, err = unpack(map[string]interface{}{
+		"protocol.tcp": map[string]interface{}{"host": ":6514", "timeout": "1m"},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "tcp", c.Protocol.Name)
+	assert.Equal(t, ":6514", c.Host)
+	assert.Equal(t, time.Minute, c.Timeout)
+
+	_, err = unpack(map[string]interface{}{
+		"protocol": map[string]interface{}{"udp": map[string]interface{}{}, "tcp": map[string]interface{}{}},
+	})
+	assert.Error(t, err)
+}
@@ -0,0 +1,155 @@
+// Tencent is pleased to support the open source community by making bkunifylogbeat 蓝鲸日志采集器 available.
+//
+// Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+//
+// bkunifylogbeat 蓝鲸日志采集器 is licensed under the MIT License.
+//
+// License for bkunifylogbeat 蓝鲸日志采集器:
+// --------------------------------------------------------------------
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+// documentation files (the "Software"), to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software,
+// and to permit persons to whom the Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all copies or substantial
+// portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+// LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN
+// NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+// WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+// SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package syslog
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/TencentBlueKing/bkunifylogbeat/task/input/socket"
+	"github.com/TencentBlueKing/collector-go-sdk/v2/bkbeat/bkmonitoring"
+	"github.com/elastic/beats/filebeat/channel"
+	"github.com/elastic/beats/filebeat/input"
+	"github.com/elastic/beats/filebeat/util"
+	"github.com/elastic/beats/libbeat/beat"
+	"github.com/elastic/beats/libbeat/common"
+	"github.com/elastic/beats/libbeat/logp"
+)
+
+var (
+	syslogReceived = bkmonitoring.NewInt("input_syslog_received")
+)
+
+func init() {
+	err := input.Register("syslog", NewInput)
+	if err != nil {
+		panic(err)
+	}
+}
+
+// Input 监听UDP/TCP端口接收syslog消息，解析后的事件进入任务的过滤及处理链
+type Input struct {
+	started bool
+	mutex   sync.Mutex
+	outlet  channel.Outleter
+
+	config config
+	loc    *time.Location
+	server *socket.Server
+}
+
+// NewInput: creates a new syslog input
+func NewInput(
+	cfg *common.Config,
+	outletFactory channel.Connector,
+	context input.Context,
+) (input.Input, error) {
+	config := defaultConfig
+	err := cfg.Unpack(&config)
+	if err != nil {
+		return nil, err
+	}
+	config.applyProtocol()
+	if config.Protocol.Name != "udp" && config.Protocol.Name != "tcp" {
+		return nil, fmt.Errorf("syslog protocol must be udp or tcp")
+	}
+	loc, err := time.LoadLocation(config.Timezone)
+	if err != nil {
+		return nil, fmt.Errorf("syslog timezone(%s) is invalid, err=>%v", config.Timezone, err)
+	}
+	// tcp同时支持换行分隔及RFC6587长度前缀两种分帧方式
+	framer, err := socket.NewFramer("octet_counting", '\n', 0, config.MaxMessageSize)
+	if err != nil {
+		return nil, err
+	}
+
+	outlet, err := outletFactory(cfg, context.DynamicFields)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &Input{
+		outlet: outlet,
+		config: config,
+		loc:    loc,
+	}
+	p.server = socket.NewServer(socket.ServerConfig{
+		Protocol:       config.Protocol.Name,
+		Host:           config.Host,
+		MaxMessageSize: config.MaxMessageSize,
+		Timeout:        config.Timeout,
+	}, framer, p.publish)
+	return p, nil
+}
+
+func (p *Input) Reload() {}
+
+// Run 开始监听，只在首次调用时生效
+func (p *Input) Run() {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if p.started {
+		return
+	}
+	if err := p.server.Start(); err != nil {
+		logp.Err("syslog listen on %s://%s failed, err=>%v", p.config.Protocol.Name, p.config.Host, err)
+		return
+	}
+	logp.Info("syslog listening on %s://%s", p.config.Protocol.Name, p.config.Host)
+	p.started = true
+}
+
+// Stop 关闭监听及所有连接
+func (p *Input) Stop() {
+	logp.Info("Stopping syslog input on %s://%s", p.config.Protocol.Name, p.config.Host)
+	p.server.Stop()
+	_ = p.outlet.Close()
+}
+
+// Wait stop the current server
+func (p *Input) Wait() {
+	p.Stop()
+}
+
+// publish: 解析消息并发送到任务，事件不带采集状态
+func (p *Input) publish(line string, remote string) {
+	if strings.TrimSpace(line) == "" {
+		return
+	}
+	syslogReceived.Add(1)
+	ts, fields := parseSyslog(line, time.Now(), p.loc)
+	if ts.IsZero() {
+		ts = time.Now()
+	}
+	_, _ = fields.Put("syslog.source", remote)
+
+	data := util.NewData()
+	data.Event = beat.Event{
+		Timestamp: ts,
+		Fields:    fields,
+	}
+	p.outlet.OnEvent(data)
+}
@@ -0,0 +1,203 @@
+// Tencent is pleased to support the open source community by making bkunifylogbeat 蓝鲸日志采集器 available.
+//
+// Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+//
+// bkunifylogbeat 蓝鲸日志采集器 is licensed under the MIT License.
+//
+// License for bkunifylogbeat 蓝鲸日志采集器:
+// --------------------------------------------------------------------
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+// documentation files (the "Software"), to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software,
+// and to permit persons to whom the Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all copies or substantial
+// portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+// LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN
+// NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+// WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+// SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package syslog
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/elastic/beats/libbeat/common"
+)
+
+var severityLabels = []string{
+	"emergency", "alert", "critical", "error", "warning", "notice", "informational", "debug",
+}
+
+var facilityLabels = []string{
+	"kernel", "user", "mail", "daemon", "auth", "syslog", "lpr", "news",
+	"uucp", "cron", "authpriv", "ftp", "ntp", "audit", "alert", "clock",
+	"local0", "local1", "local2", "local3", "local4", "local5", "local6", "local7",
+}
+
+// parseSyslog: 解析RFC5424或RFC3164格式的syslog消息，返回消息时间(解析失败为零值)及事件字段
+// 消息正文写入data，头部信息写入syslog，无法识别PRI时整行作为data
+func parseSyslog(line string, now time.Time, loc *time.Location) (time.Time, common.MapStr) {
+	line = strings.TrimRight(line, "\r\n\x00")
+	pri, rest, ok := parsePriority(line)
+	if !ok {
+		return time.Time{}, common.MapStr{"data": line}
+	}
+	header := common.MapStr{
+		"priority":       pri,
+		"facility":       pri / 8,
+		"severity":       pri % 8,
+		"severity_label": severityLabels[pri%8],
+	}
+	if pri/8 < len(facilityLabels) {
+		header["facility_label"] = facilityLabels[pri/8]
+	}
+	var ts time.Time
+	var msg string
+	if strings.HasPrefix(rest, "1 ") {
+		ts, msg = parseRFC5424(rest[2:], header)
+	} else {
+		ts, msg = parseRFC3164(rest, header, now, loc)
+	}
+	return ts, common.MapStr{"data": msg, "syslog": header}
+}
+
+// parsePriority: 解析<PRI>，PRI取值范围为0~191
+func parsePriority(line string) (int, string, bool) {
+	if len(line) < 3 || line[0] != '<' {
+		return 0, line, false
+	}
+	end := strings.IndexByte(line, '>')
+	if end < 2 || end > 4 {
+		return 0, line, false
+	}
+	pri, err := strconv.Atoi(line[1:end])
+	if err != nil || pri < 0 || pri > 191 {
+		return 0, line, false
+	}
+	return pri, line[end+1:], true
+}
+
+// nextToken: 按空格切分出下一个头部字段
+func nextToken(s string) (string, string) {
+	i := strings.IndexByte(s, ' ')
+	if i < 0 {
+		return s, ""
+	}
+	return s[:i], s[i+1:]
+}
+
+// parseRFC5424: TIMESTAMP HOSTNAME APP-NAME PROCID MSGID STRUCTURED-DATA [MSG]，"-"表示空值
+func parseRFC5424(s string, header common.MapStr) (time.Time, string) {
+	header["version"] = 1
+	var ts time.Time
+	names := []string{"", "hostname", "program", "pid", "msgid"}
+	for _, name := range names {
+		var token string
+		token, s = nextToken(s)
+		if token == "-" || token == "" {
+			continue
+		}
+		if name == "" {
+			ts, _ = time.Parse(time.RFC3339Nano, token)
+			continue
+		}
+		header[name] = token
+	}
+	if strings.HasPrefix(s, "-") {
+		s = strings.TrimPrefix(s[1:], " ")
+	} else if strings.HasPrefix(s, "[") {
+		var data common.MapStr
+		data, s = parseStructuredData(s)
+		if len(data) > 0 {
+			header["structured_data"] = data
+		}
+	}
+	return ts, strings.TrimPrefix(s, "\ufeff")
+}
+
+// parseStructuredData: 解析[id key="value" ...]，值中支持\"、\\、\]转义
+func parseStructuredData(s string) (common.MapStr, string) {
+	data := common.MapStr{}
+	for strings.HasPrefix(s, "[") {
+		end := 1
+		for end < len(s) && s[end] != ' ' && s[end] != ']' {
+			end++
+		}
+		params := common.MapStr{}
+		data[s[1:end]] = params
+		s = s[end:]
+		for strings.HasPrefix(s, " ") {
+			s = strings.TrimLeft(s, " ")
+			eq := strings.Index(s, "=\"")
+			if eq < 0 {
+				return data, s
+			}
+			name := s[:eq]
+			var value strings.Builder
+			i := eq + 2
+			for ; i < len(s) && s[i] != '"'; i++ {
+				if s[i] == '\\' && i+1 < len(s) && strings.IndexByte(`"\]`, s[i+1]) >= 0 {
+					i++
+				}
+				value.WriteByte(s[i])
+			}
+			params[name] = value.String()
+			if i < len(s) {
+				i++
+			}
+			s = s[i:]
+		}
+		if !strings.HasPrefix(s, "]") {
+			return data, s
+		}
+		s = s[1:]
+	}
+	return data, strings.TrimPrefix(s, " ")
+}
+
+// parseRFC3164: Mmm dd hh:mm:ss HOSTNAME TAG[PID]: MSG，时间不带年份，按当前时间补全
+func parseRFC3164(s string, header common.MapStr, now time.Time, loc *time.Location) (time.Time, string) {
+	var ts time.Time
+	if len(s) >= 16 && s[15] == ' ' {
+		t, err := time.ParseInLocation(time.Stamp, s[:15], loc)
+		if err == nil {
+			ts = t.AddDate(now.In(loc).Year(), 0, 0)
+			// 跨年时消息时间会比当前时间晚，归到上一年
+			if ts.Sub(now) > 24*time.Hour {
+				ts = ts.AddDate(-1, 0, 0)
+			}
+			s = s[16:]
+			var host string
+			host, s = nextToken(s)
+			header["hostname"] = host
+		}
+	}
+	// TAG仅由字母数字及部分符号组成，以":"或"["结束
+	end := 0
+	for end < len(s) && end < 48 && strings.IndexByte(" :[", s[end]) < 0 {
+		end++
+	}
+	if end == 0 || end == len(s) {
+		return ts, s
+	}
+	tag, rest := s[:end], s[end:]
+	if strings.HasPrefix(rest, "[") {
+		closing := strings.IndexByte(rest, ']')
+		if closing < 0 {
+			return ts, s
+		}
+		header["pid"] = rest[1:closing]
+		rest = rest[closing+1:]
+	}
+	if !strings.HasPrefix(rest, ":") {
+		return ts, s
+	}
+	header["program"] = tag
+	return ts, strings.TrimPrefix(rest[1:], " ")
+}
@@ -0,0 +1,76 @@
+// Tencent is pleased to support the open source community by making bkunifylogbeat 蓝鲸日志采集器 available.
+//
+// Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+//
+// bkunifylogbeat 蓝鲸日志采集器 is licensed under the MIT License.
+//
+// License for bkunifylogbeat 蓝鲸日志采集器:
+// --------------------------------------------------------------------
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+// documentation files (the "Software"), to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software,
+// and to permit persons to whom the Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all copies or substantial
+// portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+// LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN
+// NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+// WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+// SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package syslog
+
+import (
+	"testing"
+	"time"
+
+	"github.com/elastic/beats/libbeat/common"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestParseRFC3164: 测试BSD syslog格式解析
+func TestParseRFC3164(t *testing.T) {
+	now := time.Date(2021, 1, 2, 0, 0, 0, 0, time.UTC)
+	ts, fields := parseSyslog("<34>Oct 11 22:14:15 mymachine su[123]: 'su root' failed\n", now, time.UTC)
+	assert.Equal(t, time.Date(2020, 10, 11, 22, 14, 15, 0, time.UTC), ts)
+	assert.Equal(t, "'su root' failed", fields["data"])
+	assert.Equal(t, common.MapStr{
+		"priority":       34,
+		"facility":       4,
+		"severity":       2,
+		"facility_label": "auth",
+		"severity_label": "critical",
+		"hostname":       "mymachine",
+		"program":        "su",
+		"pid":            "123",
+	}, fields["syslog"])
+
+	// 无PRI时整行作为data
+	ts, fields = parseSyslog("plain message", now, time.UTC)
+	assert.True(t, ts.IsZero())
+	assert.Equal(t, common.MapStr{"data": "plain message"}, fields)
+}
+
+// TestParseRFC5424: 测试RFC5424格式及结构化数据解析
+func TestParseRFC5424(t *testing.T) {
+	line := `<165>1 2003-10-11T22:14:15.003Z host.example.com evntslog - ID47 [exampleSDID@32473 iut="3" eventID="10\"11"] An application event`
+	ts, fields := parseSyslog(line, time.Now(), time.UTC)
+	assert.Equal(t, time.Date(2003, 10, 11, 22, 14, 15, 3000000, time.UTC), ts)
+	assert.Equal(t, "An application event", fields["data"])
+	header := fields["syslog"].(common.MapStr)
+	assert.Equal(t, 5, header["severity"])
+	assert.Equal(t, "local4", header["facility_label"])
+	assert.Equal(t, "host.example.com", header["hostname"])
+	assert.Equal(t, "evntslog", header["program"])
+	assert.Equal(t, "ID47", header["msgid"])
+	assert.NotContains(t, header, "pid")
+	assert.Equal(t, common.MapStr{
+		"exampleSDID@32473": common.MapStr{"iut": "3", "eventID": `10"11`},
+	}, header["structured_data"])
+
+	_, fields = parseSyslog("<14>1 - - app 42 - - hello", time.Now(), time.UTC)
+	assert.Equal(t, "hello", fields["data"])
+	assert.Equal(t, "42", fields["syslog"].(common.MapStr)["pid"])
+}
@@ -36,9 +36,11 @@ import (
 )
 
 func init() {
-	err := input.Register("winlog", NewInput)
-	if err != nil {
-		panic(err)
+	for _, name := range []string{"winlog", "wineventlog"} {
+		err := input.Register(name, NewInput)
+		if err != nil {
+			panic(err)
+		}
 	}
 }
 
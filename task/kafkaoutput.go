@@ -0,0 +1,285 @@
+// Tencent is pleased to support the open source community by making bkunifylogbeat 蓝鲸日志采集器 available.
+//
+// Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+//
+// bkunifylogbeat 蓝鲸日志采集器 is licensed under the MIT License.
+//
+// License for bkunifylogbeat 蓝鲸日志采集器:
+// --------------------------------------------------------------------
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+// documentation files (the "Software"), to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software,
+// and to permit persons to whom the Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all copies or substantial
+// portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+// LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN
+// NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+// WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+// SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package task
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/Shopify/sarama"
+	cfg "github.com/TencentBlueKing/bkunifylogbeat/config"
+	"github.com/TencentBlueKing/collector-go-sdk/v2/bkbeat/beat"
+	"github.com/TencentBlueKing/collector-go-sdk/v2/bkbeat/bkmonitoring"
+	"github.com/TencentBlueKing/collector-go-sdk/v2/bkbeat/logp"
+	"github.com/elastic/beats/libbeat/common/transport/tlscommon"
+	"github.com/elastic/beats/libbeat/monitoring"
+)
+
+const kafkaRetryBackoff = 1 * time.Second
+
+var (
+	senderKafkaSentTotal  = bkmonitoring.NewInt("sender_kafka_sent_total")
+	senderKafkaErrorTotal = bkmonitoring.NewInt("sender_kafka_error_total")
+)
+
+// kafkaEntry: 待确认的打包事件，msg为空表示仅需更新采集状态
+type kafkaEntry struct {
+	state interface{}
+	msg   *sarama.ProducerMessage
+	done  bool
+}
+
+// kafkaOutput: 替代beat.SendEvent将打包后的事件直接写入Kafka
+// Kafka确认写入后才按发送顺序提交采集状态，写入失败时退避重试，未确认事件数达到buffer_size时阻塞发送
+type kafkaOutput struct {
+	taskID     string
+	config     cfg.KafkaOutputConfig
+	producer   sarama.AsyncProducer
+	ack        PublisherFunc
+	input      chan *kafkaEntry
+	retry      chan *sarama.ProducerMessage
+	done       <-chan struct{}
+	sentTotal  *monitoring.Int
+	errorTotal *monitoring.Int
+}
+
+// newKafkaOutput 连接Kafka并启动发送，ack用于在写入成功后提交采集状态
+func newKafkaOutput(taskConfig *cfg.TaskConfig, done <-chan struct{}, ack PublisherFunc) (*kafkaOutput, error) {
+	saramaConfig, err := newKafkaProducerConfig(taskConfig.KafkaOutput)
+	if err != nil {
+		return nil, err
+	}
+	producer, err := sarama.NewAsyncProducer(taskConfig.KafkaOutput.Hosts, saramaConfig)
+	if err != nil {
+		return nil, fmt.Errorf("create kafka producer failed, err=>%v", err)
+	}
+	output := &kafkaOutput{
+		taskID:     taskConfig.ID,
+		config:     taskConfig.KafkaOutput,
+		producer:   producer,
+		ack:        ack,
+		input:      make(chan *kafkaEntry),
+		retry:      make(chan *sarama.ProducerMessage, taskConfig.KafkaOutput.BufferSize),
+		done:       done,
+		sentTotal:  newIntWithDataID(taskConfig.DataID, "sender_kafka_sent_total"),
+		errorTotal: newIntWithDataID(taskConfig.DataID, "sender_kafka_error_total"),
+	}
+	go output.run()
+	return output, nil
+}
+
+// newKafkaProducerConfig: 生成生产者配置，SASL仅支持PLAIN
+func newKafkaProducerConfig(config cfg.KafkaOutputConfig) (*sarama.Config, error) {
+	version, err := sarama.ParseKafkaVersion(config.Version)
+	if err != nil {
+		return nil, fmt.Errorf("kafka_output version(%s) is invalid, err=>%v", config.Version, err)
+	}
+	c := sarama.NewConfig()
+	c.ClientID = config.ClientID
+	c.Version = version
+	c.Net.DialTimeout = config.Timeout
+	c.Net.ReadTimeout = config.Timeout
+	c.Net.WriteTimeout = config.Timeout
+	c.Producer.RequiredAcks = sarama.RequiredAcks(config.RequiredAcks)
+	c.Producer.Timeout = config.Timeout
+	c.Producer.Return.Successes = true
+	c.Producer.Return.Errors = true
+
+	switch config.Compression {
+	case "", "none":
+		c.Producer.Compression = sarama.CompressionNone
+	case "gzip":
+		c.Producer.Compression = sarama.CompressionGZIP
+	case "snappy":
+		c.Producer.Compression = sarama.CompressionSnappy
+	case "lz4":
+		c.Producer.Compression = sarama.CompressionLZ4
+	case "zstd":
+		c.Producer.Compression = sarama.CompressionZSTD
+	default:
+		return nil, fmt.Errorf("kafka_output compression(%s) is not supported", config.Compression)
+	}
+
+	if config.Username != "" {
+		if config.SASLMechanism != sarama.SASLTypePlaintext {
+			return nil, fmt.Errorf("kafka_output sasl.mechanism(%s) is not supported", config.SASLMechanism)
+		}
+		c.Net.SASL.Enable = true
+		c.Net.SASL.Mechanism = sarama.SASLTypePlaintext
+		c.Net.SASL.User = config.Username
+		c.Net.SASL.Password = config.Password
+	}
+
+	if config.TLS != nil {
+		tlsConfig, err := tlscommon.LoadTLSConfig(config.TLS)
+		if err != nil {
+			return nil, fmt.Errorf("load kafka_output tls config failed, err=>%v", err)
+		}
+		if tlsConfig != nil {
+			c.Net.TLS.Enable = true
+			c.Net.TLS.Config = tlsConfig.BuildModuleConfig("")
+		}
+	}
+	return c, c.Validate()
+}
+
+// publish 作为Sender的PublisherFunc，任务停止时返回false
+func (o *kafkaOutput) publish(event beat.Event) bool {
+	entry := &kafkaEntry{state: event.Private}
+	if event.Fields != nil {
+		value, err := json.Marshal(event.Fields)
+		if err != nil {
+			logp.L.Errorf("marshal kafka event failed, task_id:%s, err=>%v", o.taskID, err)
+			o.addError()
+			entry.done = true
+		} else {
+			entry.msg = &sarama.ProducerMessage{
+				Topic:    o.config.Topic,
+				Value:    sarama.ByteEncoder(value),
+				Metadata: entry,
+			}
+			if key := kafkaMessageKey(event.Fields, o.config.KeyField); key != "" {
+				entry.msg.Key = sarama.StringEncoder(key)
+			}
+		}
+	} else {
+		entry.done = true
+	}
+	select {
+	case <-o.done:
+		return false
+	case o.input <- entry:
+		return true
+	}
+}
+
+// kafkaMessageKey: 按key_field取分区key，打包事件中不存在时从第一条日志中查找
+func kafkaMessageKey(fields beat.MapStr, keyField string) string {
+	if keyField == "" {
+		return ""
+	}
+	value, err := fields.GetValue(keyField)
+	if err != nil {
+		if items, ok := fields["items"].([]beat.MapStr); ok && len(items) > 0 {
+			value, err = items[0].GetValue(keyField)
+		}
+	}
+	if err != nil || value == nil {
+		return ""
+	}
+	return fmt.Sprint(value)
+}
+
+// run 串行处理发送、确认及重试，保证采集状态按发送顺序提交
+func (o *kafkaOutput) run() {
+	var (
+		pending []*kafkaEntry
+		queue   []*sarama.ProducerMessage
+	)
+	for {
+		// 未确认事件数达到上限时不再接收新事件
+		var input chan *kafkaEntry
+		if len(pending) < o.config.BufferSize {
+			input = o.input
+		}
+		var producerInput chan<- *sarama.ProducerMessage
+		var next *sarama.ProducerMessage
+		if len(queue) > 0 {
+			producerInput = o.producer.Input()
+			next = queue[0]
+		}
+
+		select {
+		case <-o.done:
+			o.close()
+			return
+		case entry := <-input:
+			pending = append(pending, entry)
+			if entry.msg != nil {
+				queue = append(queue, entry.msg)
+			}
+		case producerInput <- next:
+			queue = queue[1:]
+		case msg := <-o.retry:
+			queue = append(queue, msg)
+		case msg := <-o.producer.Successes():
+			o.sentTotal.Add(1)
+			senderKafkaSentTotal.Add(1)
+			msg.Metadata.(*kafkaEntry).done = true
+		case produceErr := <-o.producer.Errors():
+			o.addError()
+			logp.L.Errorf("write kafka failed, task_id:%s, topic:%s, retry in %s, err=>%v",
+				o.taskID, o.config.Topic, kafkaRetryBackoff, produceErr.Err)
+			o.retryLater(produceErr.Msg)
+		}
+		pending = o.commit(pending)
+	}
+}
+
+// retryLater: 退避后重新发送，retry的容量与未确认事件上限一致，不会阻塞
+func (o *kafkaOutput) retryLater(failed *sarama.ProducerMessage) {
+	msg := &sarama.ProducerMessage{
+		Topic:    failed.Topic,
+		Key:      failed.Key,
+		Value:    failed.Value,
+		Metadata: failed.Metadata,
+	}
+	time.AfterFunc(kafkaRetryBackoff, func() {
+		select {
+		case <-o.done:
+		case o.retry <- msg:
+		}
+	})
+}
+
+// commit: 按发送顺序提交已完成事件的采集状态，返回剩余未确认的事件
+func (o *kafkaOutput) commit(pending []*kafkaEntry) []*kafkaEntry {
+	n := 0
+	for n < len(pending) && pending[n].done {
+		o.ack(beat.Event{Fields: nil, Private: pending[n].state})
+		n++
+	}
+	if n == 0 {
+		return pending
+	}
+	return append(pending[:0], pending[n:]...)
+}
+
+// close: 任务停止时关闭生产者，未确认的事件在重启后从上次提交的位置重新采集
+func (o *kafkaOutput) close() {
+	o.producer.AsyncClose()
+	go func() {
+		for range o.producer.Successes() {
+		}
+	}()
+	for produceErr := range o.producer.Errors() {
+		logp.L.Errorf("write kafka failed while closing, task_id:%s, err=>%v", o.taskID, produceErr.Err)
+	}
+	logp.L.Infof("kafka output closed, task_id:%s", o.taskID)
+}
+
+func (o *kafkaOutput) addError() {
+	o.errorTotal.Add(1)
+	senderKafkaErrorTotal.Add(1)
+}
@@ -0,0 +1,85 @@
+// Tencent is pleased to support the open source community by making bkunifylogbeat 蓝鲸日志采集器 available.
+//
+// Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+//
+// bkunifylogbeat 蓝鲸日志采集器 is licensed under the MIT License.
+//
+// License for bkunifylogbeat 蓝鲸日志采集器:
+// --------------------------------------------------------------------
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+// documentation files (the "Software"), to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software,
+// and to permit persons to whom the Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all copies or substantial
+// portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+// LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN
+// NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+// WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+// SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package task
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Shopify/sarama"
+	"github.com/Shopify/sarama/mocks"
+	cfg "github.com/TencentBlueKing/bkunifylogbeat/config"
+	"github.com/TencentBlueKing/collector-go-sdk/v2/bkbeat/beat"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestKafkaMessageKey: 分区key先从打包事件中查找，再从第一条日志中查找
+func TestKafkaMessageKey(t *testing.T) {
+	fields := beat.MapStr{
+		"dataid": 1,
+		"items":  []beat.MapStr{{"data": "a", "log": beat.MapStr{"host": "h1"}}},
+	}
+	assert.Equal(t, "1", kafkaMessageKey(fields, "dataid"))
+	assert.Equal(t, "h1", kafkaMessageKey(fields, "log.host"))
+	assert.Equal(t, "", kafkaMessageKey(fields, "missing"))
+	assert.Equal(t, "", kafkaMessageKey(fields, ""))
+}
+
+// TestKafkaOutputCommitOrder: 采集状态在写入成功后按发送顺序提交
+func TestKafkaOutputCommitOrder(t *testing.T) {
+	saramaConfig := sarama.NewConfig()
+	saramaConfig.Producer.Return.Successes = true
+	producer := mocks.NewAsyncProducer(t, saramaConfig)
+	producer.ExpectInputAndSucceed()
+	producer.ExpectInputAndSucceed()
+
+	acked := make(chan interface{}, 3)
+	done := make(chan struct{})
+	output := &kafkaOutput{
+		taskID:     "test",
+		config:     cfg.KafkaOutputConfig{Topic: "logs", BufferSize: 10},
+		producer:   producer,
+		ack:        func(event beat.Event) bool { acked <- event.Private; return true },
+		input:      make(chan *kafkaEntry),
+		retry:      make(chan *sarama.ProducerMessage, 10),
+		done:       done,
+		sentTotal:  newIntWithDataID(0, "sender_kafka_sent_total"),
+		errorTotal: newIntWithDataID(0, "sender_kafka_error_total"),
+	}
+	go output.run()
+
+	assert.True(t, output.publish(beat.Event{Fields: beat.MapStr{"data": "a"}, Private: 1}))
+	assert.True(t, output.publish(beat.Event{Fields: nil, Private: 2}))
+	assert.True(t, output.publish(beat.Event{Fields: beat.MapStr{"data": "b"}, Private: 3}))
+
+	for _, expected := range []interface{}{1, 2, 3} {
+		select {
+		case state := <-acked:
+			assert.Equal(t, expected, state)
+		case <-time.After(5 * time.Second):
+			t.Fatalf("state %v is not acked", expected)
+		}
+	}
+	close(done)
+	assert.False(t, output.publish(beat.Event{Fields: beat.MapStr{"data": "c"}, Private: 4}))
+}
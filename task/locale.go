@@ -0,0 +1,73 @@
+// Tencent is pleased to support the open source community by making bkunifylogbeat 蓝鲸日志采集器 available.
+//
+// Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+//
+// bkunifylogbeat 蓝鲸日志采集器 is licensed under the MIT License.
+//
+// License for bkunifylogbeat 蓝鲸日志采集器:
+// --------------------------------------------------------------------
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+// documentation files (the "Software"), to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software,
+// and to permit persons to whom the Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all copies or substantial
+// portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+// LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN
+// NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+// WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+// SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package task
+
+import (
+	"sync"
+
+	"golang.org/x/text/collate"
+	"golang.org/x/text/language"
+	"golang.org/x/text/search"
+)
+
+// localeCollators: 按locale缓存的比较器，locale => *localeCollator
+var localeCollators sync.Map
+
+// localeCollator: 按语言规则比较字符串，忽略大小写、重音及全半角差异(如德语中ß等同于ss)
+// collate.Collator及search.Matcher内部有缓冲区，不能并发使用，调用时需要加锁
+type localeCollator struct {
+	mutex    sync.Mutex
+	collator *collate.Collator
+	matcher  *search.Matcher
+}
+
+// getLocaleCollator 获取locale对应的比较器，同一locale只创建一次
+func getLocaleCollator(locale string) (*localeCollator, error) {
+	if c, ok := localeCollators.Load(locale); ok {
+		return c.(*localeCollator), nil
+	}
+	tag, err := language.Parse(locale)
+	if err != nil {
+		return nil, err
+	}
+	c, _ := localeCollators.LoadOrStore(locale, &localeCollator{
+		collator: collate.New(tag, collate.Loose),
+		matcher:  search.New(tag, search.Loose),
+	})
+	return c.(*localeCollator), nil
+}
+
+// equal 按语言规则判断是否相等
+func (c *localeCollator) equal(a, b string) bool {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.collator.CompareString(a, b) == 0
+}
+
+// contains 按语言规则判断text是否包含key
+func (c *localeCollator) contains(text, key string) bool {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	start, _ := c.matcher.IndexString(text, key)
+	return start >= 0
+}
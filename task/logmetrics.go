@@ -0,0 +1,243 @@
+// Tencent is pleased to support the open source community by making bkunifylogbeat 蓝鲸日志采集器 available.
+//
+// Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+//
+// bkunifylogbeat 蓝鲸日志采集器 is licensed under the MIT License.
+//
+// License for bkunifylogbeat 蓝鲸日志采集器:
+// --------------------------------------------------------------------
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+// documentation files (the "Software"), to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software,
+// and to permit persons to whom the Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all copies or substantial
+// portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+// LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN
+// NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+// WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+// SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package task
+
+import (
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	cfg "github.com/TencentBlueKing/bkunifylogbeat/config"
+	"github.com/TencentBlueKing/collector-go-sdk/v2/bkbeat/beat"
+	"github.com/TencentBlueKing/collector-go-sdk/v2/bkbeat/bkmonitoring"
+	"github.com/elastic/beats/libbeat/common"
+)
+
+var (
+	logMetricsSeriesOverflow = bkmonitoring.NewInt("log_metrics_series_overflow")
+	logMetricsSendTotal      = bkmonitoring.NewInt("log_metrics_send_total")
+)
+
+// logMetricSeries: 一组维度取值在当前周期内的统计
+type logMetricSeries struct {
+	dimensions map[string]string
+	count      float64
+	sum        float64
+	// 各桶的累计计数，最后一个为+Inf
+	buckets []float64
+}
+
+// logMetric: 编译后的单个指标
+type logMetric struct {
+	config     cfg.LogMetricConfig
+	pattern    *regexp.Regexp
+	valueIndex int
+	// 维度名称及对应的捕获组下标
+	dimensions map[string]int
+	series     map[string]*logMetricSeries
+}
+
+func newLogMetric(config cfg.LogMetricConfig) (*logMetric, error) {
+	pattern, err := regexp.Compile(config.Pattern)
+	if err != nil {
+		return nil, err
+	}
+	m := &logMetric{
+		config:     config,
+		pattern:    pattern,
+		dimensions: make(map[string]int),
+		series:     make(map[string]*logMetricSeries),
+	}
+	if config.Type == "histogram" {
+		m.valueIndex = 1
+	}
+	for i, name := range pattern.SubexpNames() {
+		switch {
+		case name == "":
+		case name == "value" && config.Type == "histogram":
+			m.valueIndex = i
+		default:
+			m.dimensions[name] = i
+		}
+	}
+	return m, nil
+}
+
+// seriesKey: 维度取值按名称排序拼接为key
+func seriesKey(dimensions map[string]string) string {
+	names := make([]string, 0, len(dimensions))
+	for name := range dimensions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var b strings.Builder
+	for _, name := range names {
+		b.WriteString(name)
+		b.WriteByte('=')
+		b.WriteString(dimensions[name])
+		b.WriteByte(',')
+	}
+	return b.String()
+}
+
+// observe: 匹配日志并更新统计，histogram的值无法解析为数字时忽略
+func (m *logMetric) observe(text string, maxSeries int) {
+	match := m.pattern.FindStringSubmatch(text)
+	if match == nil {
+		return
+	}
+	var value float64
+	if m.config.Type == "histogram" {
+		var err error
+		if value, err = strconv.ParseFloat(match[m.valueIndex], 64); err != nil {
+			return
+		}
+	}
+	dimensions := make(map[string]string, len(m.dimensions))
+	for name, index := range m.dimensions {
+		dimensions[name] = match[index]
+	}
+	key := seriesKey(dimensions)
+	series, ok := m.series[key]
+	if !ok {
+		if len(m.series) >= maxSeries {
+			logMetricsSeriesOverflow.Add(1)
+			return
+		}
+		series = &logMetricSeries{dimensions: dimensions}
+		if m.config.Type == "histogram" {
+			series.buckets = make([]float64, len(m.config.Buckets)+1)
+		}
+		m.series[key] = series
+	}
+	series.count++
+	if m.config.Type != "histogram" {
+		return
+	}
+	series.sum += value
+	for i, bound := range m.config.Buckets {
+		if value <= bound {
+			series.buckets[i]++
+		}
+	}
+	series.buckets[len(m.config.Buckets)]++
+}
+
+// collect: 生成当前周期的指标并重置统计
+func (m *logMetric) collect(timestamp int64) []common.MapStr {
+	result := make([]common.MapStr, 0, len(m.series))
+	for _, series := range m.series {
+		metrics := common.MapStr{}
+		if m.config.Type == "histogram" {
+			metrics[m.config.Name+"_count"] = series.count
+			metrics[m.config.Name+"_sum"] = series.sum
+			for i, bound := range m.config.Buckets {
+				metrics[m.config.Name+"_bucket_"+strconv.FormatFloat(bound, 'f', -1, 64)] = series.buckets[i]
+			}
+			metrics[m.config.Name+"_bucket_inf"] = series.buckets[len(m.config.Buckets)]
+		} else {
+			metrics[m.config.Name] = series.count
+		}
+		dimensions := common.MapStr{}
+		for name, value := range series.dimensions {
+			dimensions[name] = value
+		}
+		result = append(result, common.MapStr{
+			"metrics":    metrics,
+			"dimensions": dimensions,
+			"timestamp":  timestamp,
+		})
+	}
+	m.series = make(map[string]*logMetricSeries)
+	return result
+}
+
+// logMetrics: 从日志中提取的指标，按周期发送增量统计，采集插件的多个harvester可以并发调用
+type logMetrics struct {
+	config    cfg.LogMetricsConfig
+	metrics   []*logMetric
+	mutex     sync.Mutex
+	publisher PublisherFunc
+	now       func() time.Time
+}
+
+func newLogMetrics(config cfg.LogMetricsConfig, publisher PublisherFunc) (*logMetrics, error) {
+	m := &logMetrics{config: config, publisher: publisher, now: time.Now}
+	for _, c := range config.Metrics {
+		metric, err := newLogMetric(c)
+		if err != nil {
+			return nil, err
+		}
+		m.metrics = append(m.metrics, metric)
+	}
+	return m, nil
+}
+
+// observe: 对日志内容更新所有指标
+func (m *logMetrics) observe(text string) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	for _, metric := range m.metrics {
+		metric.observe(text, m.config.MaxSeries)
+	}
+}
+
+// flush: 发送当前周期的指标，没有数据时不发送
+func (m *logMetrics) flush() {
+	m.mutex.Lock()
+	timestamp := m.now().UnixNano() / int64(time.Millisecond)
+	var data []common.MapStr
+	for _, metric := range m.metrics {
+		data = append(data, metric.collect(timestamp)...)
+	}
+	m.mutex.Unlock()
+	if len(data) == 0 {
+		return
+	}
+	m.publisher(beat.Event{
+		Fields: common.MapStr{
+			"dataid": m.config.DataID,
+			"time":   timestamp / 1000,
+			"data":   data,
+		},
+	})
+	logMetricsSendTotal.Add(1)
+}
+
+// run: 按周期发送指标，任务结束时发送最后一个周期
+func (m *logMetrics) run(done <-chan struct{}) {
+	ticker := time.NewTicker(m.config.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			m.flush()
+			return
+		case <-ticker.C:
+			m.flush()
+		}
+	}
+}
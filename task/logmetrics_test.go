@@ -0,0 +1,82 @@
+// Tencent is pleased to support the open source community by making bkunifylogbeat 蓝鲸日志采集器 available.
+//
+// Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+//
+// bkunifylogbeat 蓝鲸日志采集器 is licensed under the MIT License.
+//
+// License for bkunifylogbeat 蓝鲸日志采集器:
+// --------------------------------------------------------------------
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+// documentation files (the "Software"), to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software,
+// and to permit persons to whom the Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all copies or substantial
+// portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+// LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN
+// NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+// WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+// SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package task
+
+import (
+	"testing"
+	"time"
+
+	"github.com/TencentBlueKing/bkunifylogbeat/config"
+	"github.com/TencentBlueKing/collector-go-sdk/v2/bkbeat/beat"
+	"github.com/elastic/beats/libbeat/common"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestLogMetrics: 测试按日志统计计数及直方图指标
+func TestLogMetrics(t *testing.T) {
+	var events []beat.Event
+	m, err := newLogMetrics(config.LogMetricsConfig{
+		DataID:    1500001,
+		Interval:  time.Minute,
+		MaxSeries: 2,
+		Metrics: []config.LogMetricConfig{
+			{Name: "requests", Type: "counter", Pattern: `" (?P<status>\d{3}) `},
+			{Name: "latency", Type: "histogram", Pattern: `rt=(?P<value>[\d.]+)`, Buckets: []float64{0.1, 1}},
+		},
+	}, func(event beat.Event) bool {
+		events = append(events, event)
+		return true
+	})
+	assert.NoError(t, err)
+	m.now = func() time.Time { return time.Unix(1600000000, 0) }
+
+	m.observe(`"GET /" 200 rt=0.05`)
+	m.observe(`"GET /" 200 rt=0.5`)
+	m.observe(`"GET /" 500 rt=3`)
+	// 超出max_series的维度组合不统计
+	m.observe(`"GET /" 404 rt=x`)
+	m.flush()
+
+	assert.Len(t, events, 1)
+	assert.Equal(t, 1500001, events[0].Fields["dataid"])
+	data := events[0].Fields["data"].([]common.MapStr)
+	assert.Len(t, data, 3)
+	counts := map[string]interface{}{}
+	for _, item := range data {
+		metrics := item["metrics"].(common.MapStr)
+		if value, ok := metrics["requests"]; ok {
+			counts[item["dimensions"].(common.MapStr)["status"].(string)] = value
+			continue
+		}
+		assert.Equal(t, float64(3), metrics["latency_count"])
+		assert.InDelta(t, 3.55, metrics["latency_sum"], 1e-9)
+		assert.Equal(t, float64(1), metrics["latency_bucket_0.1"])
+		assert.Equal(t, float64(2), metrics["latency_bucket_1"])
+		assert.Equal(t, float64(3), metrics["latency_bucket_inf"])
+	}
+	assert.Equal(t, map[string]interface{}{"200": float64(2), "500": float64(1)}, counts)
+
+	// 统计在发送后重置，没有数据时不发送
+	m.flush()
+	assert.Len(t, events, 1)
+}
@@ -0,0 +1,160 @@
+// Tencent is pleased to support the open source community by making bkunifylogbeat 蓝鲸日志采集器 available.
+//
+// Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+//
+// bkunifylogbeat 蓝鲸日志采集器 is licensed under the MIT License.
+//
+// License for bkunifylogbeat 蓝鲸日志采集器:
+// --------------------------------------------------------------------
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+// documentation files (the "Software"), to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software,
+// and to permit persons to whom the Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all copies or substantial
+// portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+// LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN
+// NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+// WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+// SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+// 与eventpackage.proto对应的消息及服务定义，结构与protoc-gen-go生成的代码兼容，修改时需同步更新proto文件
+
+package logpb
+
+import (
+	"context"
+
+	"github.com/golang/protobuf/proto"
+	"google.golang.org/grpc"
+)
+
+// EventPackage 打包后的采集事件
+type EventPackage struct {
+	Seq                  uint64   `protobuf:"varint,1,opt,name=seq,proto3" json:"seq,omitempty"`
+	DataId               int64    `protobuf:"varint,2,opt,name=data_id,json=dataId,proto3" json:"data_id,omitempty"`
+	Payload              []byte   `protobuf:"bytes,3,opt,name=payload,proto3" json:"payload,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *EventPackage) Reset()         { *m = EventPackage{} }
+func (m *EventPackage) String() string { return proto.CompactTextString(m) }
+func (*EventPackage) ProtoMessage()    {}
+
+// EventAck 服务端已保存的最大序号
+type EventAck struct {
+	Seq                  uint64   `protobuf:"varint,1,opt,name=seq,proto3" json:"seq,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *EventAck) Reset()         { *m = EventAck{} }
+func (m *EventAck) String() string { return proto.CompactTextString(m) }
+func (*EventAck) ProtoMessage()    {}
+
+func init() {
+	proto.RegisterType((*EventPackage)(nil), "bkunifylogbeat.EventPackage")
+	proto.RegisterType((*EventAck)(nil), "bkunifylogbeat.EventAck")
+}
+
+// EventCollectorServer 第三方采集服务端
+type EventCollectorServer interface {
+	Send(EventCollector_SendServer) error
+}
+
+// RegisterEventCollectorServer 注册采集服务
+func RegisterEventCollectorServer(s *grpc.Server, srv EventCollectorServer) {
+	s.RegisterService(&eventCollectorServiceDesc, srv)
+}
+
+func eventCollectorSendHandler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(EventCollectorServer).Send(&eventCollectorSendServer{stream})
+}
+
+var eventCollectorServiceDesc = grpc.ServiceDesc{
+	ServiceName: "bkunifylogbeat.EventCollector",
+	HandlerType: (*EventCollectorServer)(nil),
+	Methods:     []grpc.MethodDesc{},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Send",
+			Handler:       eventCollectorSendHandler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+	},
+	Metadata: "eventpackage.proto",
+}
+
+// EventCollector_SendServer 服务端事件接收流
+type EventCollector_SendServer interface {
+	Send(*EventAck) error
+	Recv() (*EventPackage, error)
+	grpc.ServerStream
+}
+
+type eventCollectorSendServer struct {
+	grpc.ServerStream
+}
+
+func (x *eventCollectorSendServer) Send(m *EventAck) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *eventCollectorSendServer) Recv() (*EventPackage, error) {
+	m := new(EventPackage)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// EventCollectorClient 采集服务客户端
+type EventCollectorClient interface {
+	Send(ctx context.Context, opts ...grpc.CallOption) (EventCollector_SendClient, error)
+}
+
+type eventCollectorClient struct {
+	cc *grpc.ClientConn
+}
+
+// NewEventCollectorClient 创建采集服务客户端
+func NewEventCollectorClient(cc *grpc.ClientConn) EventCollectorClient {
+	return &eventCollectorClient{cc}
+}
+
+func (c *eventCollectorClient) Send(ctx context.Context, opts ...grpc.CallOption) (EventCollector_SendClient, error) {
+	stream, err := c.cc.NewStream(ctx, &eventCollectorServiceDesc.Streams[0], "/bkunifylogbeat.EventCollector/Send", opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &eventCollectorSendClient{stream}, nil
+}
+
+// EventCollector_SendClient 客户端事件发送流
+type EventCollector_SendClient interface {
+	Send(*EventPackage) error
+	Recv() (*EventAck, error)
+	grpc.ClientStream
+}
+
+type eventCollectorSendClient struct {
+	grpc.ClientStream
+}
+
+func (x *eventCollectorSendClient) Send(m *EventPackage) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *eventCollectorSendClient) Recv() (*EventAck, error) {
+	m := new(EventAck)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
@@ -0,0 +1,163 @@
+// Tencent is pleased to support the open source community by making bkunifylogbeat 蓝鲸日志采集器 available.
+//
+// Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+//
+// bkunifylogbeat 蓝鲸日志采集器 is licensed under the MIT License.
+//
+// License for bkunifylogbeat 蓝鲸日志采集器:
+// --------------------------------------------------------------------
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+// documentation files (the "Software"), to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software,
+// and to permit persons to whom the Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all copies or substantial
+// portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+// LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN
+// NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+// WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+// SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+// 与logevent.proto对应的消息及客户端定义，结构与protoc-gen-go生成的代码兼容，修改时需同步更新proto文件
+
+package logpb
+
+import (
+	"context"
+
+	"github.com/golang/protobuf/proto"
+	"google.golang.org/grpc"
+)
+
+// LogEvent 通过过滤的采集事件
+type LogEvent struct {
+	DataId               int64    `protobuf:"varint,1,opt,name=data_id,json=dataId,proto3" json:"data_id,omitempty"`
+	Source               string   `protobuf:"bytes,2,opt,name=source,proto3" json:"source,omitempty"`
+	Timestamp            int64    `protobuf:"varint,3,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	Data                 string   `protobuf:"bytes,4,opt,name=data,proto3" json:"data,omitempty"`
+	Fields               string   `protobuf:"bytes,5,opt,name=fields,proto3" json:"fields,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *LogEvent) Reset()         { *m = LogEvent{} }
+func (m *LogEvent) String() string { return proto.CompactTextString(m) }
+func (*LogEvent) ProtoMessage()    {}
+
+// PushResponse 推送结束后服务端的响应
+type PushResponse struct {
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *PushResponse) Reset()         { *m = PushResponse{} }
+func (m *PushResponse) String() string { return proto.CompactTextString(m) }
+func (*PushResponse) ProtoMessage()    {}
+
+func init() {
+	proto.RegisterType((*LogEvent)(nil), "bkunifylogbeat.LogEvent")
+	proto.RegisterType((*PushResponse)(nil), "bkunifylogbeat.PushResponse")
+}
+
+// LogAggregatorServer 日志汇聚服务端
+type LogAggregatorServer interface {
+	Push(LogAggregator_PushServer) error
+}
+
+// RegisterLogAggregatorServer 注册日志汇聚服务
+func RegisterLogAggregatorServer(s *grpc.Server, srv LogAggregatorServer) {
+	s.RegisterService(&logAggregatorServiceDesc, srv)
+}
+
+func logAggregatorPushHandler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(LogAggregatorServer).Push(&logAggregatorPushServer{stream})
+}
+
+var logAggregatorServiceDesc = grpc.ServiceDesc{
+	ServiceName: "bkunifylogbeat.LogAggregator",
+	HandlerType: (*LogAggregatorServer)(nil),
+	Methods:     []grpc.MethodDesc{},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Push",
+			Handler:       logAggregatorPushHandler,
+			ClientStreams: true,
+		},
+	},
+	Metadata: "logevent.proto",
+}
+
+// LogAggregator_PushServer 服务端事件接收流
+type LogAggregator_PushServer interface {
+	SendAndClose(*PushResponse) error
+	Recv() (*LogEvent, error)
+	grpc.ServerStream
+}
+
+type logAggregatorPushServer struct {
+	grpc.ServerStream
+}
+
+func (x *logAggregatorPushServer) SendAndClose(m *PushResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *logAggregatorPushServer) Recv() (*LogEvent, error) {
+	m := new(LogEvent)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// LogAggregatorClient 日志汇聚服务客户端
+type LogAggregatorClient interface {
+	Push(ctx context.Context, opts ...grpc.CallOption) (LogAggregator_PushClient, error)
+}
+
+type logAggregatorClient struct {
+	cc *grpc.ClientConn
+}
+
+// NewLogAggregatorClient 创建日志汇聚服务客户端
+func NewLogAggregatorClient(cc *grpc.ClientConn) LogAggregatorClient {
+	return &logAggregatorClient{cc}
+}
+
+func (c *logAggregatorClient) Push(ctx context.Context, opts ...grpc.CallOption) (LogAggregator_PushClient, error) {
+	stream, err := c.cc.NewStream(ctx, &logAggregatorServiceDesc.Streams[0], "/bkunifylogbeat.LogAggregator/Push", opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &logAggregatorPushClient{stream}, nil
+}
+
+// LogAggregator_PushClient 事件推送流
+type LogAggregator_PushClient interface {
+	Send(*LogEvent) error
+	CloseAndRecv() (*PushResponse, error)
+	grpc.ClientStream
+}
+
+type logAggregatorPushClient struct {
+	grpc.ClientStream
+}
+
+func (x *logAggregatorPushClient) Send(m *LogEvent) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *logAggregatorPushClient) CloseAndRecv() (*PushResponse, error) {
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	m := new(PushResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
@@ -0,0 +1,175 @@
+// Tencent is pleased to support the open source community by making bkunifylogbeat 蓝鲸日志采集器 available.
+//
+// Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+//
+// bkunifylogbeat 蓝鲸日志采集器 is licensed under the MIT License.
+//
+// License for bkunifylogbeat 蓝鲸日志采集器:
+// --------------------------------------------------------------------
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+// documentation files (the "Software"), to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software,
+// and to permit persons to whom the Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all copies or substantial
+// portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+// LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN
+// NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+// WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+// SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+// 与logrecord.proto对应的消息及服务定义，结构与protoc-gen-go生成的代码兼容，修改时需同步更新proto文件
+
+package logpb
+
+import (
+	"context"
+
+	"github.com/golang/protobuf/proto"
+	"google.golang.org/grpc"
+)
+
+// LogRecord 应用推送的一条日志
+type LogRecord struct {
+	Timestamp            int64             `protobuf:"varint,1,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	Data                 string            `protobuf:"bytes,2,opt,name=data,proto3" json:"data,omitempty"`
+	Source               string            `protobuf:"bytes,3,opt,name=source,proto3" json:"source,omitempty"`
+	Labels               map[string]string `protobuf:"bytes,4,rep,name=labels,proto3" json:"labels,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	XXX_NoUnkeyedLiteral struct{}          `json:"-"`
+	XXX_unrecognized     []byte            `json:"-"`
+	XXX_sizecache        int32             `json:"-"`
+}
+
+func (m *LogRecord) Reset()         { *m = LogRecord{} }
+func (m *LogRecord) String() string { return proto.CompactTextString(m) }
+func (*LogRecord) ProtoMessage()    {}
+
+// LogBatch 一批日志
+type LogBatch struct {
+	Records              []*LogRecord `protobuf:"bytes,1,rep,name=records,proto3" json:"records,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}     `json:"-"`
+	XXX_unrecognized     []byte       `json:"-"`
+	XXX_sizecache        int32        `json:"-"`
+}
+
+func (m *LogBatch) Reset()         { *m = LogBatch{} }
+func (m *LogBatch) String() string { return proto.CompactTextString(m) }
+func (*LogBatch) ProtoMessage()    {}
+
+// PushAck 已接收的日志条数
+type PushAck struct {
+	Accepted             int64    `protobuf:"varint,1,opt,name=accepted,proto3" json:"accepted,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *PushAck) Reset()         { *m = PushAck{} }
+func (m *PushAck) String() string { return proto.CompactTextString(m) }
+func (*PushAck) ProtoMessage()    {}
+
+func init() {
+	proto.RegisterType((*LogRecord)(nil), "bkunifylogbeat.LogRecord")
+	proto.RegisterMapType((map[string]string)(nil), "bkunifylogbeat.LogRecord.LabelsEntry")
+	proto.RegisterType((*LogBatch)(nil), "bkunifylogbeat.LogBatch")
+	proto.RegisterType((*PushAck)(nil), "bkunifylogbeat.PushAck")
+}
+
+// LogCollectorServer 日志接收服务端
+type LogCollectorServer interface {
+	Push(LogCollector_PushServer) error
+}
+
+// RegisterLogCollectorServer 注册日志接收服务
+func RegisterLogCollectorServer(s *grpc.Server, srv LogCollectorServer) {
+	s.RegisterService(&logCollectorServiceDesc, srv)
+}
+
+func logCollectorPushHandler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(LogCollectorServer).Push(&logCollectorPushServer{stream})
+}
+
+var logCollectorServiceDesc = grpc.ServiceDesc{
+	ServiceName: "bkunifylogbeat.LogCollector",
+	HandlerType: (*LogCollectorServer)(nil),
+	Methods:     []grpc.MethodDesc{},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Push",
+			Handler:       logCollectorPushHandler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+	},
+	Metadata: "logrecord.proto",
+}
+
+// LogCollector_PushServer 服务端日志推送流
+type LogCollector_PushServer interface {
+	Send(*PushAck) error
+	Recv() (*LogBatch, error)
+	grpc.ServerStream
+}
+
+type logCollectorPushServer struct {
+	grpc.ServerStream
+}
+
+func (x *logCollectorPushServer) Send(m *PushAck) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *logCollectorPushServer) Recv() (*LogBatch, error) {
+	m := new(LogBatch)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// LogCollectorClient 日志接收服务客户端
+type LogCollectorClient interface {
+	Push(ctx context.Context, opts ...grpc.CallOption) (LogCollector_PushClient, error)
+}
+
+type logCollectorClient struct {
+	cc *grpc.ClientConn
+}
+
+// NewLogCollectorClient 创建日志接收服务客户端
+func NewLogCollectorClient(cc *grpc.ClientConn) LogCollectorClient {
+	return &logCollectorClient{cc}
+}
+
+func (c *logCollectorClient) Push(ctx context.Context, opts ...grpc.CallOption) (LogCollector_PushClient, error) {
+	stream, err := c.cc.NewStream(ctx, &logCollectorServiceDesc.Streams[0], "/bkunifylogbeat.LogCollector/Push", opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &logCollectorPushClient{stream}, nil
+}
+
+// LogCollector_PushClient 客户端日志推送流
+type LogCollector_PushClient interface {
+	Send(*LogBatch) error
+	Recv() (*PushAck, error)
+	grpc.ClientStream
+}
+
+type logCollectorPushClient struct {
+	grpc.ClientStream
+}
+
+func (x *logCollectorPushClient) Send(m *LogBatch) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *logCollectorPushClient) Recv() (*PushAck, error) {
+	m := new(PushAck)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
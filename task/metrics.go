@@ -0,0 +1,74 @@
+// Tencent is pleased to support the open source community by making bkunifylogbeat 蓝鲸日志采集器 available.
+//
+// Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+//
+// bkunifylogbeat 蓝鲸日志采集器 is licensed under the MIT License.
+//
+// License for bkunifylogbeat 蓝鲸日志采集器:
+// --------------------------------------------------------------------
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+// documentation files (the "Software"), to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software,
+// and to permit persons to whom the Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all copies or substantial
+// portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+// LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN
+// NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+// WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+// SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package task
+
+import (
+	"sync"
+
+	"github.com/TencentBlueKing/collector-go-sdk/v2/bkbeat/bkmonitoring"
+	"github.com/TencentBlueKing/collector-go-sdk/v2/bkbeat/logp"
+	"github.com/elastic/beats/libbeat/monitoring"
+)
+
+// overflowDataID: 超出指标基数限制后，新任务的指标统一聚合到该dataid下(即_overflow序列)
+const overflowDataID = -1
+
+var (
+	filterMetricsOverflowTotal = bkmonitoring.NewInt("filter_metrics_overflow_total")
+
+	metricsMutex          sync.Mutex
+	maxMetricsCardinality int                  // 按dataid区分的指标序列上限，小于等于0时不限制
+	metricsDataIDs        = make(map[int]bool) // 已经生成独立指标序列的dataid
+	overflowDataIDs       = make(map[int]bool) // 已经被聚合到_overflow序列的dataid
+	metricsLimitWarned    bool
+)
+
+// SetMetricsCardinality 设置按dataid区分的指标序列上限
+func SetMetricsCardinality(limit int) {
+	metricsMutex.Lock()
+	defer metricsMutex.Unlock()
+	maxMetricsCardinality = limit
+}
+
+// newIntWithDataID: 生成任务级指标，超出基数限制的dataid会被聚合到_overflow序列，避免监控后端标签爆炸
+func newIntWithDataID(dataID int, name string) *monitoring.Int {
+	metricsMutex.Lock()
+	defer metricsMutex.Unlock()
+
+	if !metricsDataIDs[dataID] {
+		if maxMetricsCardinality > 0 && len(metricsDataIDs) >= maxMetricsCardinality {
+			if !metricsLimitWarned {
+				logp.L.Warnf("metrics cardinality limit(%d) reached, new dataid metrics will be aggregated into overflow series",
+					maxMetricsCardinality)
+				metricsLimitWarned = true
+			}
+			if !overflowDataIDs[dataID] {
+				overflowDataIDs[dataID] = true
+				filterMetricsOverflowTotal.Add(1)
+			}
+			return bkmonitoring.NewIntWithDataID(overflowDataID, name)
+		}
+		metricsDataIDs[dataID] = true
+	}
+	return bkmonitoring.NewIntWithDataID(dataID, name)
+}
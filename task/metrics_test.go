@@ -0,0 +1,66 @@
+// Tencent is pleased to support the open source community by making bkunifylogbeat 蓝鲸日志采集器 available.
+//
+// Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+//
+// bkunifylogbeat 蓝鲸日志采集器 is licensed under the MIT License.
+//
+// License for bkunifylogbeat 蓝鲸日志采集器:
+// --------------------------------------------------------------------
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+// documentation files (the "Software"), to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software,
+// and to permit persons to whom the Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all copies or substantial
+// portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+// LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN
+// NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+// WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+// SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package task
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestMetricsCardinality: 测试超出指标基数限制后新dataid的指标聚合到_overflow序列
+func TestMetricsCardinality(t *testing.T) {
+	metricsMutex.Lock()
+	originDataIDs, originOverflow := metricsDataIDs, overflowDataIDs
+	metricsDataIDs, overflowDataIDs = make(map[int]bool), make(map[int]bool)
+	metricsMutex.Unlock()
+	defer func() {
+		metricsMutex.Lock()
+		metricsDataIDs, overflowDataIDs = originDataIDs, originOverflow
+		metricsMutex.Unlock()
+		SetMetricsCardinality(0)
+	}()
+	SetMetricsCardinality(2)
+	overflowTotal := filterMetricsOverflowTotal.Get()
+
+	first := newIntWithDataID(999980001, "test_cardinality")
+	second := newIntWithDataID(999980002, "test_cardinality")
+	assert.NotSame(t, first, second)
+
+	// 第三个dataid超出限制，与其他超限dataid共用_overflow序列
+	third := newIntWithDataID(999980003, "test_cardinality")
+	fourth := newIntWithDataID(999980004, "test_cardinality")
+	assert.Same(t, third, fourth)
+	assert.NotSame(t, first, third)
+	assert.Equal(t, overflowTotal+2, filterMetricsOverflowTotal.Get())
+
+	// 同一dataid重复生成返回同一个指标，超限dataid只计数一次
+	assert.Same(t, first, newIntWithDataID(999980001, "test_cardinality"))
+	assert.Same(t, third, newIntWithDataID(999980003, "test_cardinality"))
+	assert.Equal(t, overflowTotal+2, filterMetricsOverflowTotal.Get())
+
+	// 已有独立序列的dataid新增指标不受限制影响
+	newIntWithDataID(999980002, "test_cardinality_other")
+	assert.Equal(t, map[int]bool{999980001: true, 999980002: true}, metricsDataIDs)
+	assert.Equal(t, map[int]bool{999980003: true, 999980004: true}, overflowDataIDs)
+}
@@ -0,0 +1,170 @@
+// Tencent is pleased to support the open source community by making bkunifylogbeat 蓝鲸日志采集器 available.
+//
+// Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+//
+// bkunifylogbeat 蓝鲸日志采集器 is licensed under the MIT License.
+//
+// License for bkunifylogbeat 蓝鲸日志采集器:
+// --------------------------------------------------------------------
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+// documentation files (the "Software"), to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software,
+// and to permit persons to whom the Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all copies or substantial
+// portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+// LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN
+// NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+// WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+// SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package task
+
+import (
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	cfg "github.com/TencentBlueKing/bkunifylogbeat/config"
+	"github.com/TencentBlueKing/collector-go-sdk/v2/bkbeat/bkmonitoring"
+	"github.com/elastic/beats/filebeat/util"
+)
+
+var (
+	multilineFlushedTimeout  = bkmonitoring.NewInt("multiline_flushed_timeout")
+	multilineFlushedMaxLines = bkmonitoring.NewInt("multiline_flushed_max_lines")
+)
+
+// multilineGroup: 同一来源文件正在合并的多行日志
+type multilineGroup struct {
+	first   *util.Data
+	last    *util.Data
+	lines   []string
+	updated time.Time
+}
+
+// data 合并后的事件：字段及时间取第一行，采集进度取最后一行，保证合并后的事件发送后进度才覆盖所有行
+func (g *multilineGroup) data() *util.Data {
+	data := *g.last
+	data.Event = g.first.Event
+	data.Event.Fields = g.first.Event.Fields.Clone()
+	data.Event.Fields["data"] = strings.Join(g.lines, "\n")
+	return &data
+}
+
+// multiline: 任务管道中的多行合并，按来源文件分组，采集插件的多个harvester可以并发调用
+type multiline struct {
+	config  cfg.MultilineConfig
+	pattern *regexp.Regexp
+	mutex   sync.Mutex
+	groups  map[string]*multilineGroup
+	now     func() time.Time
+}
+
+func newMultiline(config cfg.MultilineConfig) (*multiline, error) {
+	pattern, err := regexp.Compile(config.Pattern)
+	if err != nil {
+		return nil, err
+	}
+	return &multiline{
+		config:  config,
+		pattern: pattern,
+		groups:  make(map[string]*multilineGroup),
+		now:     time.Now,
+	}, nil
+}
+
+// matches 行是否匹配，Negate时取反
+func (m *multiline) matches(text string) bool {
+	return m.pattern.MatchString(text) != m.config.Negate
+}
+
+// feed 处理一个采集事件，返回可以继续处理的事件，被合并暂存的行不返回。
+// 采集进度事件会先发送同一来源暂存的合并事件，避免进度越过未发送的行
+func (m *multiline) feed(data *util.Data) []*util.Data {
+	source := data.GetState().Source
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if data.Event.Fields == nil {
+		return append(m.flush(source), data)
+	}
+	text, ok := data.Event.Fields["data"].(string)
+	if !ok {
+		return append(m.flush(source), data)
+	}
+
+	var result []*util.Data
+	group := m.groups[source]
+	matched := m.matches(text)
+	if group != nil && m.config.Match == "after" && !matched {
+		result = m.flush(source)
+		group = nil
+	}
+	if group == nil {
+		group = &multilineGroup{first: data}
+		m.groups[source] = group
+	}
+	group.last = data
+	group.lines = append(group.lines, text)
+	group.updated = m.now()
+
+	if m.config.Match == "before" && !matched {
+		return append(result, m.flush(source)...)
+	}
+	if len(group.lines) >= m.config.MaxLines {
+		multilineFlushedMaxLines.Add(1)
+		return append(result, m.flush(source)...)
+	}
+	return result
+}
+
+// flush 取出来源的合并事件，调用方需持有锁
+func (m *multiline) flush(source string) []*util.Data {
+	group, ok := m.groups[source]
+	if !ok {
+		return nil
+	}
+	delete(m.groups, source)
+	return []*util.Data{group.data()}
+}
+
+// expire 取出超过Timeout没有新行的合并事件，all为true时取出全部
+func (m *multiline) expire(all bool) []*util.Data {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	var result []*util.Data
+	now := m.now()
+	for source, group := range m.groups {
+		if all || now.Sub(group.updated) >= m.config.Timeout {
+			if !all {
+				multilineFlushedTimeout.Add(1)
+			}
+			result = append(result, m.flush(source)...)
+		}
+	}
+	return result
+}
+
+// run 周期发送超时的合并事件，任务结束时退出
+func (m *multiline) run(done <-chan struct{}, handle func(*util.Data) bool) {
+	interval := m.config.Timeout / 2
+	if interval < 100*time.Millisecond {
+		interval = 100 * time.Millisecond
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			for _, data := range m.expire(false) {
+				handle(data)
+			}
+		}
+	}
+}
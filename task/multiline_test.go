@@ -0,0 +1,81 @@
+// Tencent is pleased to support the open source community by making bkunifylogbeat 蓝鲸日志采集器 available.
+//
+// Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+//
+// bkunifylogbeat 蓝鲸日志采集器 is licensed under the MIT License.
+//
+// License for bkunifylogbeat 蓝鲸日志采集器:
+// --------------------------------------------------------------------
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+// documentation files (the "Software"), to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software,
+// and to permit persons to whom the Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all copies or substantial
+// portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+// LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN
+// NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+// WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+// SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package task
+
+import (
+	"testing"
+	"time"
+
+	"github.com/TencentBlueKing/bkunifylogbeat/config"
+	"github.com/TencentBlueKing/bkunifylogbeat/tests"
+	"github.com/elastic/beats/filebeat/util"
+	"github.com/stretchr/testify/assert"
+)
+
+// multilineTexts: 取出事件的data内容
+func multilineTexts(result []*util.Data) []string {
+	texts := make([]string, 0, len(result))
+	for _, data := range result {
+		texts = append(texts, data.Event.Fields["data"].(string))
+	}
+	return texts
+}
+
+// TestMultilineAfter: 测试Java堆栈按after方式合并及进度事件触发发送
+func TestMultilineAfter(t *testing.T) {
+	m, err := newMultiline(config.MultilineConfig{Pattern: `^\s+at |^Caused by:`, Match: "after", MaxLines: 500, Timeout: time.Second})
+	assert.NoError(t, err)
+
+	assert.Empty(t, m.feed(tests.MockLogEvent("/a.log", "Exception in thread main")))
+	assert.Empty(t, m.feed(tests.MockLogEvent("/a.log", "    at com.example.Main")))
+	assert.Empty(t, m.feed(tests.MockLogEvent("/b.log", "other file")))
+	result := m.feed(tests.MockLogEvent("/a.log", "next line"))
+	assert.Equal(t, []string{"Exception in thread main\n    at com.example.Main"}, multilineTexts(result))
+
+	result = m.feed(tests.MockLogEvent("/b.log", ""))
+	assert.Len(t, result, 2)
+	assert.Equal(t, "other file", result[0].Event.Fields["data"])
+	assert.Nil(t, result[1].Event.Fields)
+}
+
+// TestMultilineBefore: 测试before方式、max_lines及超时发送
+func TestMultilineBefore(t *testing.T) {
+	m, err := newMultiline(config.MultilineConfig{Pattern: `\\$`, Match: "before", MaxLines: 3, Timeout: time.Second})
+	assert.NoError(t, err)
+	now := time.Now()
+	m.now = func() time.Time { return now }
+
+	assert.Empty(t, m.feed(tests.MockLogEvent("/a.log", `a \`)))
+	result := m.feed(tests.MockLogEvent("/a.log", "b"))
+	assert.Equal(t, []string{"a \\\nb"}, multilineTexts(result))
+
+	m.feed(tests.MockLogEvent("/a.log", `1 \`))
+	m.feed(tests.MockLogEvent("/a.log", `2 \`))
+	result = m.feed(tests.MockLogEvent("/a.log", `3 \`))
+	assert.Equal(t, []string{"1 \\\n2 \\\n3 \\"}, multilineTexts(result))
+
+	m.feed(tests.MockLogEvent("/a.log", `4 \`))
+	assert.Empty(t, m.expire(false))
+	now = now.Add(time.Second)
+	assert.Equal(t, []string{`4 \`}, multilineTexts(m.expire(false)))
+}
@@ -22,6 +22,12 @@
 
 package task
 
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
 func equal(a, b string) bool {
 	return a == b
 }
@@ -41,12 +47,117 @@ const (
 	NotEqualOperation
 )
 
+func contains(a, b string) bool {
+	return strings.Contains(a, b)
+}
+
+func notContains(a, b string) bool {
+	return !strings.Contains(a, b)
+}
+
 func getOperation(op string) func(a, b string) bool {
-	if op == "=" {
+	switch op {
+	case "=":
 		return equal
-	} else if op == "!=" {
+	case "!=", "neq":
 		return notEqual
-	} else {
+	case "contains":
+		return contains
+	case "ncontains":
+		return notContains
+	default:
 		return nil
 	}
 }
+
+// getTypedOperation: 按字段类型比较，key在编译条件时解析，列内容解析失败视为不匹配
+// int类型的key及列内容均按base解析，base为0时按Go的前缀规则自动识别(0x为十六进制，0为八进制)
+func getTypedOperation(op, fieldType, key string, base int) (func(word string) bool, error) {
+	var parse func(s string) (interface{}, error)
+	switch fieldType {
+	case "int":
+		parse = func(s string) (interface{}, error) { return strconv.ParseInt(strings.TrimSpace(s), base, 64) }
+	case "float":
+		parse = func(s string) (interface{}, error) { return strconv.ParseFloat(strings.TrimSpace(s), 64) }
+	case "bool":
+		parse = func(s string) (interface{}, error) { return strconv.ParseBool(strings.TrimSpace(s)) }
+	default:
+		return nil, fmt.Errorf("field_type(%s) is not supported", fieldType)
+	}
+
+	expected, err := parse(key)
+	if err != nil {
+		return nil, fmt.Errorf("key(%s) is not a valid %s", key, fieldType)
+	}
+	return func(word string) bool {
+		value, err := parse(word)
+		if err != nil {
+			return false
+		}
+		if op == "!=" {
+			return value != expected
+		}
+		return value == expected
+	}, nil
+}
+
+// getNumericOperation: 数值比较，列内容作为左值，key在编译条件时解析，列内容解析失败视为不匹配
+// int类型按base解析为整数比较，避免大整数转换为浮点数后丢失精度，其余按浮点数比较
+func getNumericOperation(op, fieldType, key string, base int) (func(word string) bool, error) {
+	var compare func(word string) (int, bool)
+	if fieldType == "int" {
+		expected, err := strconv.ParseInt(strings.TrimSpace(key), base, 64)
+		if err != nil {
+			return nil, fmt.Errorf("key(%s) is not a valid int", key)
+		}
+		compare = func(word string) (int, bool) {
+			value, err := strconv.ParseInt(strings.TrimSpace(word), base, 64)
+			if err != nil {
+				return 0, false
+			}
+			switch {
+			case value < expected:
+				return -1, true
+			case value > expected:
+				return 1, true
+			}
+			return 0, true
+		}
+	} else {
+		expected, err := strconv.ParseFloat(strings.TrimSpace(key), 64)
+		if err != nil {
+			return nil, fmt.Errorf("key(%s) is not a valid number", key)
+		}
+		compare = func(word string) (int, bool) {
+			value, err := strconv.ParseFloat(strings.TrimSpace(word), 64)
+			if err != nil {
+				return 0, false
+			}
+			switch {
+			case value < expected:
+				return -1, true
+			case value > expected:
+				return 1, true
+			}
+			return 0, true
+		}
+	}
+
+	var accept func(result int) bool
+	switch op {
+	case "gt":
+		accept = func(result int) bool { return result > 0 }
+	case "gte":
+		accept = func(result int) bool { return result >= 0 }
+	case "lt":
+		accept = func(result int) bool { return result < 0 }
+	case "lte":
+		accept = func(result int) bool { return result <= 0 }
+	default:
+		return nil, fmt.Errorf("op(%s) is not a numeric operation", op)
+	}
+	return func(word string) bool {
+		result, ok := compare(word)
+		return ok && accept(result)
+	}, nil
+}
@@ -23,109 +23,570 @@
 package task
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
+	"hash/fnv"
+	"math/rand"
+	"regexp"
+	"sort"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+	"unicode/utf8"
 
 	"github.com/TencentBlueKing/bkunifylogbeat/config"
+	"github.com/TencentBlueKing/bkunifylogbeat/utils"
+	"github.com/TencentBlueKing/collector-go-sdk/v2/bkbeat/bkmonitoring"
+	"github.com/TencentBlueKing/collector-go-sdk/v2/bkbeat/logp"
 	"github.com/elastic/beats/libbeat/beat"
+	"github.com/elastic/beats/libbeat/common"
+	"github.com/elastic/beats/libbeat/monitoring"
 	process "github.com/elastic/beats/libbeat/processors"
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/htmlindex"
+)
+
+var (
+	filterTruncatedOutputTotal = bkmonitoring.NewInt("filter_truncated_output_total")
+	filterRateLimitDropped     = bkmonitoring.NewInt("filter_ratelimit_dropped")
+	filterSampleDropped        = bkmonitoring.NewInt("filter_sample_dropped")
+	filterDedupDropped         = bkmonitoring.NewInt("filter_dedup_dropped")
+	filterOversizeDropped      = bkmonitoring.NewInt("filter_oversize_dropped")
+	filterOversizeTruncated    = bkmonitoring.NewInt("filter_oversize_truncated")
+	filterEncodingFailed       = bkmonitoring.NewInt("filter_encoding_failed")
+	filterScheduleDropped      = bkmonitoring.NewInt("filter_schedule_dropped")
 )
 
 // Processors: 兼容数据平台过滤规则
 type Processors struct {
-	taskConfig     *config.TaskConfig
-	processors     *process.Processors
-	filterMaxIndex int
+	taskConfig    *config.TaskConfig
+	processors    *process.Processors
+	conditionals  []*conditionalProcessor
+	encoding      encoding.Encoding
+	filters       atomic.Value // *filterSet
+	filtersMutex  sync.Mutex
+	priorityRules []*priorityRule
+	splitClass    *regexp.Regexp
+	done          chan struct{}
+
+	truncatedOutputTotal *monitoring.Int // 超出max_output_bytes的事件数
+	dropSampleTotal      *monitoring.Int // 抽样记录的丢弃事件数
+
+	schedule         *utils.Schedule
+	scheduleDropped  *monitoring.Int // 采集时间计划外丢弃的事件数
+	sampleDropped    *monitoring.Int // 未被采样而丢弃的事件数
+	rateLimiter      *utils.RateLimiter
+	rateLimitDropped *monitoring.Int // 超出max_events_per_second被丢弃的事件数
+	dedup            *utils.DedupCache
+	dedupDropped     *monitoring.Int // 重复而被丢弃的事件数
+
+	oversizeDropped   *monitoring.Int // 超出max_event_bytes被丢弃的事件数
+	oversizeTruncated *monitoring.Int // 超出max_event_bytes被截断的事件数
+	encodingFailed    *monitoring.Int // 字符编码转换失败的事件数
 }
 
 // NewProcessors: 兼容原采集器处理并复用filebeat.processors
 func NewProcessors(config *config.TaskConfig) (*Processors, error) {
 	processors := &Processors{
 		taskConfig: config,
+		done:       make(chan struct{}),
 	}
-
+	processors.truncatedOutputTotal = newIntWithDataID(config.DataID, "filter_truncated_output_total")
+	processors.dropSampleTotal = newIntWithDataID(config.DataID, "filter_drop_sample_total")
 	var err error
+	if config.Schedule.Enabled() {
+		schedule := config.Schedule
+		processors.schedule, err = utils.NewSchedule(schedule.Windows, schedule.Cron, schedule.Timezone)
+		if err != nil {
+			return nil, fmt.Errorf("create schedule failed, err=>%v", err)
+		}
+		processors.scheduleDropped = newIntWithDataID(config.DataID, "filter_schedule_dropped")
+	}
+	processors.sampleDropped = newIntWithDataID(config.DataID, "filter_sample_dropped")
+	if config.MaxEventBytes > 0 {
+		processors.oversizeDropped = newIntWithDataID(config.DataID, "filter_oversize_dropped")
+		processors.oversizeTruncated = newIntWithDataID(config.DataID, "filter_oversize_truncated")
+	}
+	if config.Dedup.Enabled {
+		processors.dedup = utils.NewDedupCache(config.Dedup.Size, config.Dedup.Window)
+		processors.dedupDropped = newIntWithDataID(config.DataID, "filter_dedup_dropped")
+	}
+	if config.MaxEventsPerSecond > 0 {
+		processors.rateLimiter = utils.NewRateLimiter(config.MaxEventsPerSecond, config.MaxEventsPerSecond)
+		processors.rateLimitDropped = newIntWithDataID(config.DataID, "filter_ratelimit_dropped")
+	}
+
 	if config.Processors != nil {
 		processors.processors, err = process.New(config.Processors)
 		if err != nil {
 			return nil, fmt.Errorf("create libbeat.processors faied, err=>%v", err)
 		}
 	}
+	if config.SourceEncoding != "" {
+		processors.encoding, err = htmlindex.Get(config.SourceEncoding)
+		if err != nil {
+			return nil, fmt.Errorf("source_encoding(%s) is not supported", config.SourceEncoding)
+		}
+		processors.encodingFailed = newIntWithDataID(config.DataID, "filter_encoding_failed")
+	}
+	if len(config.ConditionalProcessors) > 0 {
+		processors.conditionals, err = newConditionalProcessors(config, processors.done)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// 字符类、正则及多个分隔符通过正则缓存编译，配置重载时复用
+	if pattern := config.SplitPattern(); pattern != "" {
+		processors.splitClass, err = compileRegex(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("compile split pattern failed, err=>%v", err)
+		}
+	}
 
 	// Filter
-	if config.HasFilter {
-		for _, f := range config.Filters {
-			if len(f.Conditions) != 0 {
-				if processors.filterMaxIndex < f.Conditions[len(f.Conditions)-1].Index {
-					processors.filterMaxIndex = f.Conditions[len(f.Conditions)-1].Index
-				}
-			}
+	set, err := newFilterSet(config)
+	if err != nil {
+		return nil, err
+	}
+	processors.filters.Store(set)
+	if len(config.PriorityRules) > 0 {
+		processors.priorityRules, err = newPriorityRules(config, processors.done)
+		if err != nil {
+			return nil, fmt.Errorf("create priority rules failed, err=>%v", err)
 		}
 	}
 
 	return processors, nil
 }
 
+// UpdateFilters 重新编译过滤条件并原子替换，正在处理的事件继续使用旧条件，之后的事件使用新条件
+// 只替换filters相关的配置，其余配置需要重启任务才能生效
+func (client *Processors) UpdateFilters(config *config.TaskConfig) error {
+	set, err := newFilterSet(config)
+	if err != nil {
+		return err
+	}
+	client.filtersMutex.Lock()
+	defer client.filtersMutex.Unlock()
+	select {
+	case <-client.done:
+		close(set.done)
+		return fmt.Errorf("processors is closed")
+	default:
+	}
+	old := client.loadFilters()
+	client.filters.Store(set)
+	close(old.done)
+	return nil
+}
+
+// loadFilters: 获取当前生效的过滤条件
+func (client *Processors) loadFilters() *filterSet {
+	return client.filters.Load().(*filterSet)
+}
+
+// Close: 停止过滤条件的后台任务
+func (client *Processors) Close() {
+	client.filtersMutex.Lock()
+	defer client.filtersMutex.Unlock()
+	close(client.done)
+	close(client.loadFilters().done)
+}
+
 // Run: 处理采集事件
 func (client *Processors) Run(event *beat.Event) *beat.Event {
+	return client.RunSource(event, "")
+}
+
+// RunSource: 处理采集事件，source为来源文件，用于条件处理器按文件匹配
+func (client *Processors) RunSource(event *beat.Event, source string) *beat.Event {
 	if event.Fields == nil {
 		return event
 	}
+	start := time.Now()
+
+	// 采集时间计划外的事件直接丢弃，单独计数
+	if client.schedule != nil && !client.schedule.Active(start) {
+		client.scheduleDropped.Add(1)
+		filterScheduleDropped.Add(1)
+		return nil
+	}
+
+	// 字符编码转换在所有处理之前进行，过滤条件及下游序列化都基于UTF-8
+	if client.encoding != nil {
+		client.convertEncoding(event)
+	}
+
+	// 超大事件在解析之前处理，避免异常日志拖慢过滤及下游序列化
+	if client.taskConfig.MaxEventBytes > 0 && !client.limitEvent(event) {
+		return nil
+	}
+
+	// 优先级分类在过滤之前进行
+	if len(client.priorityRules) > 0 {
+		client.classify(event)
+	}
 
 	// 原采集器过滤兼容
-	if client.taskConfig.HasFilter {
-		event = client.filter(event)
+	if set := client.loadFilters(); len(set.filters) > 0 {
+		event = client.filter(event, set)
 		if event == nil {
 			return nil
 		}
 	}
 
+	// 去重、采样及限速只针对通过过滤的事件
+	if client.dedup != nil && client.duplicated(event) {
+		client.dedupDropped.Add(1)
+		filterDedupDropped.Add(1)
+		return nil
+	}
+	if client.taskConfig.SampleRate > 0 && client.taskConfig.SampleRate < 1 && !client.sample(event) {
+		client.sampleDropped.Add(1)
+		filterSampleDropped.Add(1)
+		return nil
+	}
+	if client.rateLimiter != nil && !client.allow() {
+		return nil
+	}
+
 	if client.processors != nil {
 		event := client.processors.Run(event)
 		if event == nil {
 			return nil
 		}
 	}
+	for _, p := range client.conditionals {
+		event = p.run(client, event, source)
+		if event == nil {
+			return nil
+		}
+	}
+
+	// 字段投影，移除敏感字段
+	if len(client.taskConfig.ProjectFields) > 0 {
+		client.project(event)
+	}
+
+	// 过滤及处理耗时，便于下游统计延迟分布
+	if client.taskConfig.AnnotateLatency {
+		event.Fields["_filter_latency_us"] = time.Since(start).Microseconds()
+	}
+
+	// 按下游要求调整事件结构
+	switch client.taskConfig.MessageFormat {
+	case "json_wrapped":
+		client.jsonWrap(event)
+	case "kv_pairs":
+		client.kvPairs(event)
+	}
+
+	// 限制输出大小，适配下游协议的消息大小上限
+	if client.taskConfig.MaxOutputBytes > 0 {
+		return client.limitOutput(event)
+	}
 	return event
 }
 
-// filter: 兼容原采集器过滤方式
-func (client *Processors) filter(event *beat.Event) *beat.Event {
-	// index为N时，数组切分最少需要分成N+1段
-	var text string
-	var ok bool
-	if text, ok = event.Fields["data"].(string); !ok {
+// duplicated: 按整行或指定列的哈希值判断事件是否在去重窗口内出现过
+func (client *Processors) duplicated(event *beat.Event) bool {
+	text, ok := event.Fields["data"].(string)
+	if !ok {
+		return false
+	}
+	h := fnv.New64a()
+	indexes := client.taskConfig.Dedup.Indexes
+	if len(indexes) == 0 {
+		h.Write([]byte(text))
+	} else {
+		l := client.parseLine(text)
+		for _, index := range indexes {
+			if len(l.words) >= index {
+				h.Write([]byte(l.words[index-1]))
+			}
+			// 列之间写入分隔，避免不同切分方式的内容拼接后相同
+			h.Write([]byte{0})
+		}
+	}
+	return client.dedup.Seen(h.Sum64())
+}
+
+// sampleBuckets: 按哈希采样时的分桶数
+const sampleBuckets = 10000
+
+// sample: 判断事件是否被采样，按列采样时同一列内容的结果总是相同
+func (client *Processors) sample(event *beat.Event) bool {
+	index := client.taskConfig.SampleByIndex
+	if index <= 0 {
+		return rand.Float64() < client.taskConfig.SampleRate
+	}
+	var key string
+	if text, ok := event.Fields["data"].(string); ok {
+		if l := client.parseLine(text); len(l.words) >= index {
+			key = l.words[index-1]
+		}
+	}
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	return float64(h.Sum32()%sampleBuckets) < client.taskConfig.SampleRate*sampleBuckets
+}
+
+// allow: 按rate_limit_mode处理超速事件，delay模式下阻塞等待直到拿到令牌或任务停止
+func (client *Processors) allow() bool {
+	if client.taskConfig.RateLimitMode == "delay" {
+		if wait := client.rateLimiter.Reserve(); wait > 0 {
+			select {
+			case <-client.done:
+				return false
+			case <-time.After(wait):
+			}
+		}
+		return true
+	}
+	if client.rateLimiter.Allow() {
+		return true
+	}
+	client.rateLimitDropped.Add(1)
+	filterRateLimitDropped.Add(1)
+	return false
+}
+
+// maxConditionIndex: 过滤条件及优先级规则中最大的列序号，决定日志最少需要切分的段数
+func maxConditionIndex(taskConfig *config.TaskConfig) int {
+	maxIndex := 0
+	filters := append([]config.FilterConfig{}, taskConfig.Filters...)
+	for _, rule := range taskConfig.PriorityRules {
+		filters = append(filters, rule.Filter)
+	}
+	for _, f := range filters {
+		if index := maxIndexOf(f.Conditions); maxIndex < index {
+			maxIndex = index
+		}
+		// 最少列数校验需要切分出MinColumnCount段
+		if maxIndex < f.MinColumnCount {
+			maxIndex = f.MinColumnCount
+		}
+		// 表达式已在加载配置时校验，这里只取列序号
+		if f.Expr != "" {
+			if expr, err := utils.CompileExpr(f.Expr); err == nil && maxIndex < expr.MaxColumn() {
+				maxIndex = expr.MaxColumn()
+			}
+		}
+	}
+	if maxIndex < taskConfig.SampleByIndex {
+		maxIndex = taskConfig.SampleByIndex
+	}
+	for _, index := range taskConfig.Dedup.Indexes {
+		if maxIndex < index {
+			maxIndex = index
+		}
+	}
+	return maxIndex
+}
+
+// maxIndexOf: 条件中最大的列序号，包含嵌套条件组中的子条件
+func maxIndexOf(conditions []config.ConditionConfig) int {
+	maxIndex := 0
+	for _, c := range conditions {
+		index := c.Index
+		if c.IsGroup() {
+			index = maxIndexOf(append(c.Any, c.All...))
+		}
+		if maxIndex < index {
+			maxIndex = index
+		}
+	}
+	return maxIndex
+}
+
+// project: 按投影配置生成新的字段集合，不修改原字段map
+func (client *Processors) project(event *beat.Event) {
+	fields := make(common.MapStr, len(event.Fields))
+	if client.taskConfig.ProjectMode == "exclude" {
+		for key, value := range event.Fields {
+			fields[key] = value
+		}
+		for _, key := range client.taskConfig.ProjectFields {
+			delete(fields, key)
+		}
+	} else {
+		for _, key := range client.taskConfig.ProjectFields {
+			if value, ok := event.Fields[key]; ok {
+				fields[key] = value
+			}
+		}
+	}
+	event.Fields = fields
+}
+
+// outputSize: 事件字段序列化后的字节数
+func outputSize(event *beat.Event) int {
+	data, err := json.Marshal(event.Fields)
+	if err != nil {
+		return 0
+	}
+	return len(data)
+}
+
+// convertEncoding: 将data从source_encoding转换为UTF-8，已是有效UTF-8时不转换，失败时保持原样
+func (client *Processors) convertEncoding(event *beat.Event) {
+	data, ok := event.Fields["data"].(string)
+	if !ok || utf8.ValidString(data) {
+		return
+	}
+	// Decoder有内部状态，每次转换单独创建
+	converted, err := client.encoding.NewDecoder().String(data)
+	if err != nil {
+		client.encodingFailed.Add(1)
+		filterEncodingFailed.Add(1)
+		return
+	}
+	event.Fields["data"] = converted
+}
+
+// limitEvent: 按oversize_mode处理data超出max_event_bytes的事件，返回false时丢弃
+func (client *Processors) limitEvent(event *beat.Event) bool {
+	data, ok := event.Fields["data"].(string)
+	maxBytes := client.taskConfig.MaxEventBytes
+	if !ok || len(data) <= maxBytes {
+		return true
+	}
+	if client.taskConfig.OversizeMode != "truncate" {
+		client.oversizeDropped.Add(1)
+		filterOversizeDropped.Add(1)
+		return false
+	}
+	cut := maxBytes
+	for cut > 0 && !utf8.RuneStart(data[cut]) {
+		cut--
+	}
+	event.Fields["data"] = data[:cut]
+	event.Fields["_truncated"] = true
+	client.oversizeTruncated.Add(1)
+	filterOversizeTruncated.Add(1)
+	return true
+}
+
+// limitOutput: 按truncate_strategy处理超出max_output_bytes的事件，处理后仍超出时丢弃
+func (client *Processors) limitOutput(event *beat.Event) *beat.Event {
+	maxBytes := client.taskConfig.MaxOutputBytes
+	size := outputSize(event)
+	if size <= maxBytes {
 		return event
 	}
-	words := strings.SplitN(text, client.taskConfig.Delimiter, client.filterMaxIndex+1)
-
-	for _, f := range client.taskConfig.Filters {
-		access := true
-		for _, condition := range f.Conditions {
-			// 匹配第n列，如果n小于等于0，则变更为整个字符串包含
-			if condition.Index <= 0 {
-				if !strings.Contains(text, condition.Key) {
-					access = false
-					break
-				} else {
-					continue
-				}
+	filterTruncatedOutputTotal.Add(1)
+	client.truncatedOutputTotal.Add(1)
+
+	switch client.taskConfig.TruncateStrategy {
+	case "truncate_data":
+		data, ok := event.Fields["data"].(string)
+		if !ok {
+			return nil
+		}
+		// JSON转义会放大字节数，按超出部分逐步截断
+		for size > maxBytes && data != "" {
+			cut := len(data) - (size - maxBytes)
+			if cut < 0 {
+				cut = 0
 			}
-			operationFunc := getOperation(condition.Op)
-			if operationFunc != nil {
-				if len(words) < condition.Index {
-					access = false
-					break
-				}
-				if !operationFunc(words[condition.Index-1], condition.Key) {
-					access = false
-					break
-				}
+			for cut > 0 && !utf8.RuneStart(data[cut]) {
+				cut--
 			}
+			data = data[:cut]
+			event.Fields["data"] = data
+			size = outputSize(event)
 		}
-		if access {
-			return event
+	case "truncate_fields":
+		for _, key := range client.taskConfig.TruncateFields {
+			if size <= maxBytes {
+				break
+			}
+			if _, ok := event.Fields[key]; ok {
+				event.Fields.Delete(key)
+				size = outputSize(event)
+			}
 		}
 	}
-	return nil
+	if size > maxBytes {
+		return nil
+	}
+	return event
+}
+
+// jsonWrap: 将事件字段序列化为JSON，并统一放到payload字段中
+func (client *Processors) jsonWrap(event *beat.Event) {
+	payload, err := json.Marshal(orderedFields{
+		keys:   client.orderedKeys(event.Fields),
+		fields: event.Fields,
+	})
+	if err != nil {
+		logp.L.Errorf("marshal event fields failed, task_id:%s, err=>%v", client.taskConfig.ID, err)
+		return
+	}
+	event.Fields = common.MapStr{
+		"payload": string(payload),
+	}
+}
+
+// kvPairs: 将事件字段序列化为key1=val1 key2=val2，并写回data字段
+func (client *Processors) kvPairs(event *beat.Event) {
+	keys := client.orderedKeys(event.Fields)
+	pairs := make([]string, 0, len(keys))
+	for _, key := range keys {
+		pairs = append(pairs, fmt.Sprintf("%s=%v", key, event.Fields[key]))
+	}
+	event.Fields["data"] = strings.Join(pairs, " ")
+}
+
+// orderedKeys: 字段输出顺序，field_order中的字段在前，其余字段按key排序
+func (client *Processors) orderedKeys(fields common.MapStr) []string {
+	keys := make([]string, 0, len(fields))
+	ordered := make(map[string]bool, len(client.taskConfig.FieldOrder))
+	for _, key := range client.taskConfig.FieldOrder {
+		if _, ok := fields[key]; ok && !ordered[key] {
+			keys = append(keys, key)
+			ordered[key] = true
+		}
+	}
+	rest := make([]string, 0, len(fields)-len(keys))
+	for key := range fields {
+		if !ordered[key] {
+			rest = append(rest, key)
+		}
+	}
+	sort.Strings(rest)
+	return append(keys, rest...)
+}
+
+// orderedFields: 按指定顺序序列化为JSON对象
+type orderedFields struct {
+	keys   []string
+	fields common.MapStr
+}
+
+// MarshalJSON 按keys顺序输出字段
+func (o orderedFields) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, key := range o.keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		k, err := json.Marshal(key)
+		if err != nil {
+			return nil, err
+		}
+		v, err := json.Marshal(o.fields[key])
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(k)
+		buf.WriteByte(':')
+		buf.Write(v)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
 }
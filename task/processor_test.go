@@ -23,10 +23,18 @@
 package task
 
 import (
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	cfg "github.com/TencentBlueKing/bkunifylogbeat/config"
 	"github.com/TencentBlueKing/bkunifylogbeat/tests"
+	"github.com/TencentBlueKing/bkunifylogbeat/utils"
+	"github.com/elastic/beats/libbeat/common"
+	_ "github.com/elastic/beats/libbeat/processors/add_host_metadata"
+	_ "github.com/elastic/beats/libbeat/processors/dissect"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -99,3 +107,1594 @@ func TestFilter(t *testing.T) {
 	event = processor.Run(&data.Event)
 	assert.Nil(t, event)
 }
+
+//TestMessageFormat: 测试事件结构格式化
+func TestMessageFormat(t *testing.T) {
+	vars := map[string]interface{}{
+		"dataid":         "999990001",
+		"message_format": "json_wrapped",
+	}
+	config, err := cfg.CreateTaskConfig(vars)
+	if err != nil {
+		panic(err)
+	}
+	processor, _ := NewProcessors(config)
+
+	//case 1: 字段统一序列化到payload
+	data := tests.MockLogEvent("/test.log", "test")
+	event := processor.Run(&data.Event)
+	assert.Equal(t, `{"data":"test"}`, event.Fields["payload"])
+	_, ok := event.Fields["data"]
+	assert.False(t, ok)
+
+	//case 2: 按key=value格式写回data
+	vars["message_format"] = "kv_pairs"
+	config, err = cfg.CreateTaskConfig(vars)
+	if err != nil {
+		panic(err)
+	}
+	processor, _ = NewProcessors(config)
+	data = tests.MockLogEvent("/test.log", "test")
+	data.Event.Fields["level"] = "info"
+	event = processor.Run(&data.Event)
+	assert.Equal(t, "data=test level=info", event.Fields["data"])
+
+	//case 3: 不支持的格式
+	vars["message_format"] = "xml"
+	_, err = cfg.CreateTaskConfig(vars)
+	assert.NotNil(t, err)
+}
+
+//TestFilterNormalize: 测试定长字段补齐与截断
+func TestFilterNormalize(t *testing.T) {
+	vars := map[string]interface{}{
+		"dataid":    "999990001",
+		"delimiter": "|",
+		"filters": []cfg.FilterConfig{
+			{
+				Conditions: []cfg.ConditionConfig{
+					{
+						Index:   1,
+						Key:     "0042",
+						Op:      "=",
+						PadTo:   4,
+						PadChar: "0",
+					},
+					{
+						Index:      2,
+						Key:        "ERR",
+						Op:         "=",
+						TruncateTo: 3,
+					},
+				},
+			},
+		},
+	}
+	config, err := cfg.CreateTaskConfig(vars)
+	if err != nil {
+		panic(err)
+	}
+	processor, _ := NewProcessors(config)
+
+	data := tests.MockLogEvent("/test.log", "42|ERROR")
+	assert.NotNil(t, processor.Run(&data.Event))
+
+	data = tests.MockLogEvent("/test.log", "0042|ERR")
+	assert.NotNil(t, processor.Run(&data.Event))
+
+	data = tests.MockLogEvent("/test.log", "43|ERROR")
+	assert.Nil(t, processor.Run(&data.Event))
+}
+
+//TestFilterWindowAgg: 测试滑动窗口聚合过滤
+func TestFilterWindowAgg(t *testing.T) {
+	vars := map[string]interface{}{
+		"dataid":    "999990001",
+		"delimiter": "|",
+		"filters": []cfg.FilterConfig{
+			{
+				Conditions: []cfg.ConditionConfig{
+					{
+						Index: 2,
+						Key:   "100",
+						Op:    "window_agg_gt",
+						WindowAgg: cfg.WindowAggConfig{
+							AggFunc:    "avg",
+							WindowSize: 3,
+						},
+					},
+				},
+			},
+		},
+	}
+	config, err := cfg.CreateTaskConfig(vars)
+	if err != nil {
+		panic(err)
+	}
+	processor, _ := NewProcessors(config)
+
+	// avg: 50
+	data := tests.MockLogEvent("/test.log", "GET|50")
+	assert.Nil(t, processor.Run(&data.Event))
+	// avg: 150
+	data = tests.MockLogEvent("/test.log", "GET|250")
+	assert.NotNil(t, processor.Run(&data.Event))
+	// avg: 110
+	data = tests.MockLogEvent("/test.log", "GET|30")
+	assert.NotNil(t, processor.Run(&data.Event))
+	// 50滑出窗口，avg: 96.67
+	data = tests.MockLogEvent("/test.log", "GET|10")
+	assert.Nil(t, processor.Run(&data.Event))
+}
+
+//TestFilterLogfmt: 测试logfmt格式过滤
+func TestFilterLogfmt(t *testing.T) {
+	vars := map[string]interface{}{
+		"dataid":       "999990001",
+		"logfmt_parse": true,
+		"filters": []cfg.FilterConfig{
+			{
+				Conditions: []cfg.ConditionConfig{
+					{
+						Key: "level:error",
+						Op:  "=",
+					},
+					{
+						Key: "module:health",
+						Op:  "!=",
+					},
+				},
+			},
+		},
+	}
+	config, err := cfg.CreateTaskConfig(vars)
+	if err != nil {
+		panic(err)
+	}
+	processor, _ := NewProcessors(config)
+
+	data := tests.MockLogEvent("/test.log", `level=error module=order msg="create failed"`)
+	assert.NotNil(t, processor.Run(&data.Event))
+
+	data = tests.MockLogEvent("/test.log", `level=error module=health msg="check failed"`)
+	assert.Nil(t, processor.Run(&data.Event))
+
+	data = tests.MockLogEvent("/test.log", `level=info module=order`)
+	assert.Nil(t, processor.Run(&data.Event))
+
+	data = tests.MockLogEvent("/test.log", `module=order`)
+	assert.Nil(t, processor.Run(&data.Event))
+}
+
+//TestPriorityRules: 测试事件优先级标记
+func TestPriorityRules(t *testing.T) {
+	vars := map[string]interface{}{
+		"dataid":    "999990001",
+		"delimiter": "|",
+		"priority_rules": []cfg.PriorityRule{
+			{
+				Priority: 1,
+				Filter: cfg.FilterConfig{
+					Conditions: []cfg.ConditionConfig{{Index: 1, Key: "FATAL", Op: "="}},
+				},
+			},
+			{
+				Priority: 2,
+				Filter: cfg.FilterConfig{
+					Conditions: []cfg.ConditionConfig{{Index: 1, Key: "DEBUG", Op: "!="}},
+				},
+			},
+		},
+	}
+	config, err := cfg.CreateTaskConfig(vars)
+	if err != nil {
+		panic(err)
+	}
+	processor, _ := NewProcessors(config)
+
+	data := tests.MockLogEvent("/test.log", "FATAL|panic")
+	event := processor.Run(&data.Event)
+	assert.Equal(t, 1, event.Fields["_priority"])
+
+	data = tests.MockLogEvent("/test.log", "ERROR|timeout")
+	event = processor.Run(&data.Event)
+	assert.Equal(t, 2, event.Fields["_priority"])
+
+	data = tests.MockLogEvent("/test.log", "DEBUG|trace")
+	event = processor.Run(&data.Event)
+	_, ok := event.Fields["_priority"]
+	assert.False(t, ok)
+}
+
+//TestFilterTokenize: 测试按词匹配
+func TestFilterTokenize(t *testing.T) {
+	vars := map[string]interface{}{
+		"dataid":    "999990001",
+		"delimiter": "|",
+		"filters": []cfg.FilterConfig{
+			{
+				Conditions: []cfg.ConditionConfig{
+					{Index: 2, Key: "timeout", Op: "=", Tokenize: true},
+				},
+			},
+		},
+	}
+	config, err := cfg.CreateTaskConfig(vars)
+	if err != nil {
+		panic(err)
+	}
+	processor, _ := NewProcessors(config)
+
+	data := tests.MockLogEvent("/test.log", "ERROR|request timeout after 3s")
+	assert.NotNil(t, processor.Run(&data.Event))
+
+	data = tests.MockLogEvent("/test.log", "ERROR|request timeouts after 3s")
+	assert.Nil(t, processor.Run(&data.Event))
+}
+
+//TestFilterSplitOnClass: 测试字符类分隔符
+func TestFilterSplitOnClass(t *testing.T) {
+	vars := map[string]interface{}{
+		"dataid":         "999990001",
+		"split_on_class": `[\s|]`,
+		"filters": []cfg.FilterConfig{
+			{
+				Conditions: []cfg.ConditionConfig{
+					{Index: 2, Key: "ERROR", Op: "="},
+				},
+			},
+		},
+	}
+	config, err := cfg.CreateTaskConfig(vars)
+	if err != nil {
+		panic(err)
+	}
+	processor, _ := NewProcessors(config)
+
+	data := tests.MockLogEvent("/test.log", "2021-11-16 ERROR|timeout")
+	assert.NotNil(t, processor.Run(&data.Event))
+
+	data = tests.MockLogEvent("/test.log", "2021-11-16|ERROR timeout")
+	assert.NotNil(t, processor.Run(&data.Event))
+
+	data = tests.MockLogEvent("/test.log", "2021-11-16,ERROR,timeout")
+	assert.Nil(t, processor.Run(&data.Event))
+
+	//不是中括号形式的字符类
+	vars["split_on_class"] = `\s+`
+	_, err = cfg.CreateTaskConfig(vars)
+	assert.NotNil(t, err)
+}
+
+//TestAnnotateLatency: 测试过滤耗时标记
+func TestAnnotateLatency(t *testing.T) {
+	vars := map[string]interface{}{
+		"dataid":           "999990001",
+		"annotate_latency": true,
+	}
+	config, err := cfg.CreateTaskConfig(vars)
+	if err != nil {
+		panic(err)
+	}
+	processor, _ := NewProcessors(config)
+
+	data := tests.MockLogEvent("/test.log", "test")
+	event := processor.Run(&data.Event)
+	_, ok := event.Fields["_filter_latency_us"].(int64)
+	assert.True(t, ok)
+}
+
+//TestFilterScanf: 测试scanf格式提取后比较
+func TestFilterScanf(t *testing.T) {
+	vars := map[string]interface{}{
+		"dataid":    "999990001",
+		"delimiter": "|",
+		"filters": []cfg.FilterConfig{
+			{
+				Conditions: []cfg.ConditionConfig{
+					{Index: 2, Key: "User %s logged in from %s", Op: "scanf", ScanfExpect: "admin"},
+				},
+			},
+		},
+	}
+	config, err := cfg.CreateTaskConfig(vars)
+	if err != nil {
+		panic(err)
+	}
+	processor, _ := NewProcessors(config)
+
+	data := tests.MockLogEvent("/test.log", "INFO|User admin logged in from 10.0.0.1")
+	assert.NotNil(t, processor.Run(&data.Event))
+
+	data = tests.MockLogEvent("/test.log", "INFO|User guest logged in from 10.0.0.1")
+	assert.Nil(t, processor.Run(&data.Event))
+}
+
+//TestFieldOrder: 测试序列化字段顺序
+func TestFieldOrder(t *testing.T) {
+	vars := map[string]interface{}{
+		"dataid":         "999990001",
+		"message_format": "json_wrapped",
+		"field_order":    []string{"level", "data"},
+	}
+	config, err := cfg.CreateTaskConfig(vars)
+	if err != nil {
+		panic(err)
+	}
+	processor, _ := NewProcessors(config)
+
+	data := tests.MockLogEvent("/test.log", "test")
+	data.Event.Fields["level"] = "info"
+	data.Event.Fields["app"] = "order"
+	event := processor.Run(&data.Event)
+	assert.Equal(t, `{"level":"info","data":"test","app":"order"}`, event.Fields["payload"])
+}
+
+//TestFilterFieldType: 测试按字段类型比较
+func TestFilterFieldType(t *testing.T) {
+	vars := map[string]interface{}{
+		"dataid":    "999990001",
+		"delimiter": "|",
+		"filters": []cfg.FilterConfig{
+			{
+				Conditions: []cfg.ConditionConfig{
+					{Index: 1, Key: "200", Op: "=", FieldType: "float"},
+					{Index: 2, Key: "true", Op: "=", FieldType: "bool"},
+				},
+			},
+		},
+	}
+	config, err := cfg.CreateTaskConfig(vars)
+	if err != nil {
+		panic(err)
+	}
+	processor, _ := NewProcessors(config)
+
+	data := tests.MockLogEvent("/test.log", "200.0|1")
+	assert.NotNil(t, processor.Run(&data.Event))
+
+	data = tests.MockLogEvent("/test.log", "200|T")
+	assert.NotNil(t, processor.Run(&data.Event))
+
+	data = tests.MockLogEvent("/test.log", "200|false")
+	assert.Nil(t, processor.Run(&data.Event))
+
+	data = tests.MockLogEvent("/test.log", "ok|true")
+	assert.Nil(t, processor.Run(&data.Event))
+}
+
+//TestFilterBloomFile: 测试布隆过滤器成员判断
+func TestFilterBloomFile(t *testing.T) {
+	path := filepath.Join(os.TempDir(), "bkunifylogbeat_test.bloom")
+	defer os.Remove(path)
+	bloom := utils.NewBloomFilter(1024, 3)
+	bloom.Add([]byte("10.0.0.1"))
+	f, err := os.Create(path)
+	if err != nil {
+		panic(err)
+	}
+	_, err = bloom.WriteTo(f)
+	f.Close()
+	if err != nil {
+		panic(err)
+	}
+
+	vars := map[string]interface{}{
+		"dataid":    "999990001",
+		"delimiter": "|",
+		"filters": []cfg.FilterConfig{
+			{
+				Conditions: []cfg.ConditionConfig{
+					{Index: 2, Key: path, Op: "bloom_file"},
+				},
+			},
+		},
+	}
+	config, err := cfg.CreateTaskConfig(vars)
+	if err != nil {
+		panic(err)
+	}
+	processor, err := NewProcessors(config)
+	if err != nil {
+		panic(err)
+	}
+	defer processor.Close()
+
+	data := tests.MockLogEvent("/test.log", "INFO|10.0.0.1")
+	assert.NotNil(t, processor.Run(&data.Event))
+
+	data = tests.MockLogEvent("/test.log", "INFO|192.168.1.1")
+	assert.Nil(t, processor.Run(&data.Event))
+}
+
+//TestRegexCache: 测试正则缓存复用及淘汰
+func TestRegexCache(t *testing.T) {
+	first, err := compileRegex(`[\s,;]+`)
+	assert.Nil(t, err)
+	second, err := compileRegex(`[\s,;]+`)
+	assert.Nil(t, err)
+	assert.True(t, first == second)
+
+	evictRegexCache(time.Now().Add(defaultRegexCacheTTL + time.Second))
+	third, err := compileRegex(`[\s,;]+`)
+	assert.Nil(t, err)
+	assert.False(t, first == third)
+
+	_, err = compileRegex(`[`)
+	assert.NotNil(t, err)
+}
+
+//TestFilterEagerEval: 测试条件全部求值及命中统计
+func TestFilterEagerEval(t *testing.T) {
+	vars := map[string]interface{}{
+		"dataid":     "999990001",
+		"delimiter":  "|",
+		"eager_eval": true,
+		"filters": []cfg.FilterConfig{
+			{
+				Conditions: []cfg.ConditionConfig{
+					{Index: 1, Key: "ERROR", Op: "="},
+					{Index: 2, Key: "order", Op: "="},
+				},
+			},
+		},
+	}
+	config, err := cfg.CreateTaskConfig(vars)
+	if err != nil {
+		panic(err)
+	}
+	processor, _ := NewProcessors(config)
+	conditions := processor.loadFilters().filters[0].conditions
+	conditions[0].matched.Set(0)
+	conditions[1].matched.Set(0)
+
+	data := tests.MockLogEvent("/test.log", "INFO|order|done")
+	assert.Nil(t, processor.Run(&data.Event))
+	data = tests.MockLogEvent("/test.log", "ERROR|order|failed")
+	assert.NotNil(t, processor.Run(&data.Event))
+
+	assert.Equal(t, int64(1), conditions[0].matched.Get())
+	assert.Equal(t, int64(2), conditions[1].matched.Get())
+}
+
+//TestFilterSoftFail: 测试可选列条件
+func TestFilterSoftFail(t *testing.T) {
+	vars := map[string]interface{}{
+		"dataid":    "999990001",
+		"delimiter": "|",
+		"filters": []cfg.FilterConfig{
+			{
+				Conditions: []cfg.ConditionConfig{
+					{Index: 1, Key: "ERROR", Op: "="},
+					{Index: 3, Key: "order", Op: "=", SoftFail: true},
+				},
+			},
+		},
+	}
+	config, err := cfg.CreateTaskConfig(vars)
+	if err != nil {
+		panic(err)
+	}
+	processor, _ := NewProcessors(config)
+
+	// 第3列缺失时跳过该条件
+	data := tests.MockLogEvent("/test.log", "ERROR|failed")
+	assert.NotNil(t, processor.Run(&data.Event))
+
+	data = tests.MockLogEvent("/test.log", "ERROR|failed|order")
+	assert.NotNil(t, processor.Run(&data.Event))
+
+	data = tests.MockLogEvent("/test.log", "ERROR|failed|user")
+	assert.Nil(t, processor.Run(&data.Event))
+
+	data = tests.MockLogEvent("/test.log", "INFO|done")
+	assert.Nil(t, processor.Run(&data.Event))
+}
+
+//TestFilterMinColumnCount: 测试最少列数校验
+func TestFilterMinColumnCount(t *testing.T) {
+	vars := map[string]interface{}{
+		"dataid":    "999990001",
+		"delimiter": "|",
+		"filters": []cfg.FilterConfig{
+			{
+				MinColumnCount: 4,
+				Conditions: []cfg.ConditionConfig{
+					{Index: 1, Key: "ERROR", Op: "="},
+				},
+			},
+		},
+	}
+	config, err := cfg.CreateTaskConfig(vars)
+	if err != nil {
+		panic(err)
+	}
+	processor, _ := NewProcessors(config)
+
+	data := tests.MockLogEvent("/test.log", "ERROR|order|failed|timeout")
+	assert.NotNil(t, processor.Run(&data.Event))
+
+	data = tests.MockLogEvent("/test.log", "ERROR|order|fail")
+	assert.Nil(t, processor.Run(&data.Event))
+}
+
+//TestProjectFields: 测试字段投影
+func TestProjectFields(t *testing.T) {
+	for _, c := range []struct {
+		mode   string
+		expect []string
+	}{
+		{"include", []string{"data", "level"}},
+		{"exclude", []string{"app"}},
+	} {
+		vars := map[string]interface{}{
+			"dataid":         "999990001",
+			"project_fields": []string{"data", "level"},
+			"project_mode":   c.mode,
+		}
+		config, err := cfg.CreateTaskConfig(vars)
+		if err != nil {
+			panic(err)
+		}
+		processor, _ := NewProcessors(config)
+
+		data := tests.MockLogEvent("/test.log", "test")
+		data.Event.Fields["level"] = "info"
+		data.Event.Fields["app"] = "order"
+		event := processor.Run(&data.Event)
+		keys := make([]string, 0, len(event.Fields))
+		for key := range event.Fields {
+			keys = append(keys, key)
+		}
+		assert.ElementsMatch(t, c.expect, keys)
+	}
+}
+
+//TestMaxOutputBytes: 测试输出大小限制
+func TestMaxOutputBytes(t *testing.T) {
+	newProcessor := func(vars map[string]interface{}) *Processors {
+		vars["dataid"] = "999990001"
+		vars["max_output_bytes"] = 30
+		config, err := cfg.CreateTaskConfig(vars)
+		if err != nil {
+			panic(err)
+		}
+		processor, _ := NewProcessors(config)
+		return processor
+	}
+	text := "0123456789012345678901234567890123456789"
+
+	// drop
+	processor := newProcessor(map[string]interface{}{})
+	data := tests.MockLogEvent("/test.log", "short")
+	assert.NotNil(t, processor.Run(&data.Event))
+	data = tests.MockLogEvent("/test.log", text)
+	assert.Nil(t, processor.Run(&data.Event))
+
+	// truncate_data: {"data":"..."}共11字节额外开销
+	processor = newProcessor(map[string]interface{}{"truncate_strategy": "truncate_data"})
+	data = tests.MockLogEvent("/test.log", text)
+	event := processor.Run(&data.Event)
+	assert.NotNil(t, event)
+	assert.Equal(t, text[:19], event.Fields["data"])
+
+	// truncate_fields
+	processor = newProcessor(map[string]interface{}{
+		"truncate_strategy": "truncate_fields",
+		"truncate_fields":   []string{"trace"},
+	})
+	data = tests.MockLogEvent("/test.log", "short")
+	data.Event.Fields["trace"] = text
+	event = processor.Run(&data.Event)
+	assert.NotNil(t, event)
+	assert.Equal(t, common.MapStr{"data": "short"}, event.Fields)
+}
+
+//TestFilterLocale: 测试按语言规则比较字符串
+func TestFilterLocale(t *testing.T) {
+	newProcessor := func(locale string) *Processors {
+		vars := map[string]interface{}{
+			"dataid":    "999990001",
+			"delimiter": "|",
+			"filters": []cfg.FilterConfig{
+				{
+					Conditions: []cfg.ConditionConfig{
+						{Index: 2, Key: "Strasse", Op: "=", Locale: locale},
+					},
+				},
+			},
+		}
+		config, err := cfg.CreateTaskConfig(vars)
+		if err != nil {
+			panic(err)
+		}
+		processor, _ := NewProcessors(config)
+		return processor
+	}
+
+	// 德语中ß等同于ss
+	processor := newProcessor("de")
+	data := tests.MockLogEvent("/test.log", "INFO|Straße")
+	assert.NotNil(t, processor.Run(&data.Event))
+	processor = newProcessor("")
+	data = tests.MockLogEvent("/test.log", "INFO|Straße")
+	assert.Nil(t, processor.Run(&data.Event))
+
+	// 德语忽略重音时ä等同于a，瑞典语中ä为独立字母
+	de, err := getLocaleCollator("de")
+	assert.Nil(t, err)
+	sv, err := getLocaleCollator("sv")
+	assert.Nil(t, err)
+	assert.True(t, de.equal("Bär", "bar"))
+	assert.False(t, sv.equal("Bär", "bar"))
+	assert.True(t, de.contains("Eingang Straße 5", "STRASSE"))
+}
+
+//TestFilterNumericBase: 测试按进制解析整数
+func TestFilterNumericBase(t *testing.T) {
+	newProcessor := func(key string, base int) *Processors {
+		vars := map[string]interface{}{
+			"dataid":    "999990001",
+			"delimiter": "|",
+			"filters": []cfg.FilterConfig{
+				{
+					Conditions: []cfg.ConditionConfig{
+						{Index: 1, Key: key, Op: "=", FieldType: "int", NumericBase: base},
+					},
+				},
+			},
+		}
+		config, err := cfg.CreateTaskConfig(vars)
+		if err != nil {
+			panic(err)
+		}
+		processor, _ := NewProcessors(config)
+		return processor
+	}
+
+	// 自动识别前缀
+	processor := newProcessor("6719", 0)
+	data := tests.MockLogEvent("/test.log", "0x1A3F|test")
+	assert.NotNil(t, processor.Run(&data.Event))
+	processor = newProcessor("0755", 0)
+	data = tests.MockLogEvent("/test.log", "493|test")
+	assert.NotNil(t, processor.Run(&data.Event))
+
+	// 十六进制
+	processor = newProcessor("1a3f", 16)
+	data = tests.MockLogEvent("/test.log", "1A3F|test")
+	assert.NotNil(t, processor.Run(&data.Event))
+	data = tests.MockLogEvent("/test.log", "6719|test")
+	assert.Nil(t, processor.Run(&data.Event))
+}
+
+//TestFilterWeekdayIn: 测试按星期过滤
+func TestFilterWeekdayIn(t *testing.T) {
+	vars := map[string]interface{}{
+		"dataid":    "999990001",
+		"delimiter": "|",
+		"filters": []cfg.FilterConfig{
+			{
+				Conditions: []cfg.ConditionConfig{
+					{Index: 1, Key: "Mon,Tue,Wed,Thu,Fri", Op: "weekday_in", Timezone: "Asia/Shanghai"},
+				},
+			},
+		},
+	}
+	config, err := cfg.CreateTaskConfig(vars)
+	if err != nil {
+		panic(err)
+	}
+	processor, _ := NewProcessors(config)
+
+	// 2021-06-04为周五
+	data := tests.MockLogEvent("/test.log", "2021-06-04T10:00:00+08:00|login")
+	assert.NotNil(t, processor.Run(&data.Event))
+
+	// UTC周五20点在东八区为周六
+	data = tests.MockLogEvent("/test.log", "2021-06-04T20:00:00Z|login")
+	assert.Nil(t, processor.Run(&data.Event))
+
+	data = tests.MockLogEvent("/test.log", "invalid|login")
+	assert.Nil(t, processor.Run(&data.Event))
+}
+
+//TestDropSample: 测试丢弃事件抽样记录
+func TestDropSample(t *testing.T) {
+	vars := map[string]interface{}{
+		"dataid":           "999990001",
+		"delimiter":        "|",
+		"drop_sample_rate": 1,
+		"drop_log_level":   "info",
+		"filters": []cfg.FilterConfig{
+			{
+				Conditions: []cfg.ConditionConfig{
+					{Index: 1, Key: "ERROR", Op: "="},
+				},
+			},
+		},
+	}
+	config, err := cfg.CreateTaskConfig(vars)
+	if err != nil {
+		panic(err)
+	}
+	processor, _ := NewProcessors(config)
+	processor.dropSampleTotal.Set(0)
+
+	data := tests.MockLogEvent("/test.log", "ERROR|order")
+	assert.NotNil(t, processor.Run(&data.Event))
+	data = tests.MockLogEvent("/test.log", "INFO|order")
+	assert.Nil(t, processor.Run(&data.Event))
+	assert.Equal(t, int64(1), processor.dropSampleTotal.Get())
+}
+
+//TestFilterIPNormalize: 测试IP地址规整后比较
+func TestFilterIPNormalize(t *testing.T) {
+	vars := map[string]interface{}{
+		"dataid":    "999990001",
+		"delimiter": "|",
+		"filters": []cfg.FilterConfig{
+			{
+				Conditions: []cfg.ConditionConfig{
+					{Index: 2, Key: "::ffff:192.0.2.1", Op: "=", IPNormalize: true},
+				},
+			},
+		},
+	}
+	config, err := cfg.CreateTaskConfig(vars)
+	if err != nil {
+		panic(err)
+	}
+	processor, _ := NewProcessors(config)
+
+	data := tests.MockLogEvent("/test.log", "login|192.0.2.1")
+	assert.NotNil(t, processor.Run(&data.Event))
+	data = tests.MockLogEvent("/test.log", "login|0:0:0:0:0:ffff:c000:0201")
+	assert.NotNil(t, processor.Run(&data.Event))
+	data = tests.MockLogEvent("/test.log", "login|192.0.2.2")
+	assert.Nil(t, processor.Run(&data.Event))
+}
+
+//TestFilterRegex: 测试正则匹配
+func TestFilterRegex(t *testing.T) {
+	vars := map[string]interface{}{
+		"dataid":    "999990001",
+		"delimiter": "|",
+		"filters": []cfg.FilterConfig{
+			{
+				Conditions: []cfg.ConditionConfig{
+					{Index: -1, Key: `order_id=\d+`, Op: "regex"},
+					{Index: 1, Key: `^(DEBUG|TRACE)$`, Op: "nregex"},
+				},
+			},
+		},
+	}
+	config, err := cfg.CreateTaskConfig(vars)
+	if err != nil {
+		panic(err)
+	}
+	processor, _ := NewProcessors(config)
+
+	data := tests.MockLogEvent("/test.log", "ERROR|order_id=1024")
+	assert.NotNil(t, processor.Run(&data.Event))
+	data = tests.MockLogEvent("/test.log", "DEBUG|order_id=1024")
+	assert.Nil(t, processor.Run(&data.Event))
+	data = tests.MockLogEvent("/test.log", "ERROR|order_id=none")
+	assert.Nil(t, processor.Run(&data.Event))
+}
+
+//TestFilterNumeric: 测试数值比较
+func TestFilterNumeric(t *testing.T) {
+	vars := map[string]interface{}{
+		"dataid":    "999990001",
+		"delimiter": "|",
+		"filters": []cfg.FilterConfig{
+			{
+				Conditions: []cfg.ConditionConfig{
+					{Index: 2, Key: "0.5", Op: "gt"},
+					{Index: 3, Key: "500", Op: "lte", FieldType: "int"},
+				},
+			},
+		},
+	}
+	config, err := cfg.CreateTaskConfig(vars)
+	if err != nil {
+		panic(err)
+	}
+	processor, _ := NewProcessors(config)
+
+	data := tests.MockLogEvent("/test.log", "cpu|0.75|500")
+	assert.NotNil(t, processor.Run(&data.Event))
+	data = tests.MockLogEvent("/test.log", "cpu|0.5|100")
+	assert.Nil(t, processor.Run(&data.Event))
+	data = tests.MockLogEvent("/test.log", "cpu|0.75|501")
+	assert.Nil(t, processor.Run(&data.Event))
+	data = tests.MockLogEvent("/test.log", "cpu|n/a|100")
+	assert.Nil(t, processor.Run(&data.Event))
+}
+
+//TestFilterRange: 测试同一列配置多个条件组成区间
+func TestFilterRange(t *testing.T) {
+	vars := map[string]interface{}{
+		"dataid":    "999990001",
+		"delimiter": "|",
+		"filters": []cfg.FilterConfig{
+			{
+				Conditions: []cfg.ConditionConfig{
+					{Index: 2, Key: "200", Op: "gte", FieldType: "int"},
+					{Index: 2, Key: "300", Op: "lt", FieldType: "int"},
+				},
+			},
+		},
+	}
+	config, err := cfg.CreateTaskConfig(vars)
+	if err != nil {
+		panic(err)
+	}
+	processor, _ := NewProcessors(config)
+
+	data := tests.MockLogEvent("/test.log", "GET|200")
+	assert.NotNil(t, processor.Run(&data.Event))
+	data = tests.MockLogEvent("/test.log", "GET|299")
+	assert.NotNil(t, processor.Run(&data.Event))
+	data = tests.MockLogEvent("/test.log", "GET|199")
+	assert.Nil(t, processor.Run(&data.Event))
+	data = tests.MockLogEvent("/test.log", "GET|300")
+	assert.Nil(t, processor.Run(&data.Event))
+}
+
+//TestFilterNestedGroup: 测试嵌套条件组
+func TestFilterNestedGroup(t *testing.T) {
+	vars := map[string]interface{}{
+		"dataid":    "999990001",
+		"delimiter": "|",
+		"filters": []cfg.FilterConfig{
+			{
+				Conditions: []cfg.ConditionConfig{
+					{Any: []cfg.ConditionConfig{
+						{Index: 1, Key: "ERROR", Op: "="},
+						{Index: 1, Key: "FATAL", Op: "="},
+					}},
+					{Index: 3, Key: "payment", Op: "="},
+				},
+			},
+		},
+	}
+	config, err := cfg.CreateTaskConfig(vars)
+	if err != nil {
+		panic(err)
+	}
+	processor, _ := NewProcessors(config)
+	assert.Equal(t, 3, processor.loadFilters().maxIndex)
+
+	data := tests.MockLogEvent("/test.log", "ERROR|order|payment")
+	assert.NotNil(t, processor.Run(&data.Event))
+	data = tests.MockLogEvent("/test.log", "FATAL|order|payment")
+	assert.NotNil(t, processor.Run(&data.Event))
+	data = tests.MockLogEvent("/test.log", "INFO|order|payment")
+	assert.Nil(t, processor.Run(&data.Event))
+	data = tests.MockLogEvent("/test.log", "ERROR|order|refund")
+	assert.Nil(t, processor.Run(&data.Event))
+}
+
+//TestFilterNegate: 测试取反条件
+func TestFilterNegate(t *testing.T) {
+	vars := map[string]interface{}{
+		"dataid":    "999990001",
+		"delimiter": "|",
+		"filters": []cfg.FilterConfig{
+			{
+				Conditions: []cfg.ConditionConfig{
+					{Index: -1, Key: "/healthz", Op: "ncontains"},
+					{Index: 1, Key: "DEBUG", Op: "neq"},
+					{Index: 2, Key: "GET", Op: "=", Negate: true},
+					{Index: 3, Key: "internal", Op: "contains", Negate: true},
+				},
+			},
+		},
+	}
+	config, err := cfg.CreateTaskConfig(vars)
+	if err != nil {
+		panic(err)
+	}
+	processor, _ := NewProcessors(config)
+
+	data := tests.MockLogEvent("/test.log", "INFO|POST|/api/order")
+	assert.NotNil(t, processor.Run(&data.Event))
+	data = tests.MockLogEvent("/test.log", "INFO|POST|/healthz")
+	assert.Nil(t, processor.Run(&data.Event))
+	data = tests.MockLogEvent("/test.log", "DEBUG|POST|/api/order")
+	assert.Nil(t, processor.Run(&data.Event))
+	data = tests.MockLogEvent("/test.log", "INFO|GET|/api/order")
+	assert.Nil(t, processor.Run(&data.Event))
+	data = tests.MockLogEvent("/test.log", "INFO|POST|/api/internal/order")
+	assert.Nil(t, processor.Run(&data.Event))
+}
+
+//TestFilterJSONPath: 测试按JSON路径过滤
+func TestFilterJSONPath(t *testing.T) {
+	vars := map[string]interface{}{
+		"dataid":     "999990001",
+		"json_parse": true,
+		"filters": []cfg.FilterConfig{
+			{
+				Conditions: []cfg.ConditionConfig{
+					{Path: "$.level", Key: "error", Op: "="},
+					{Path: "$.kubernetes.namespace", Key: "payment", Op: "="},
+					{Path: "$.latency", Key: "100", Op: "gt"},
+				},
+			},
+		},
+	}
+	config, err := cfg.CreateTaskConfig(vars)
+	if err != nil {
+		panic(err)
+	}
+	processor, _ := NewProcessors(config)
+
+	data := tests.MockLogEvent("/test.log", `{"level":"error","kubernetes":{"namespace":"payment"},"latency":250}`)
+	assert.NotNil(t, processor.Run(&data.Event))
+	data = tests.MockLogEvent("/test.log", `{"level":"error","kubernetes":{"namespace":"order"},"latency":250}`)
+	assert.Nil(t, processor.Run(&data.Event))
+	data = tests.MockLogEvent("/test.log", `{"level":"error","latency":250}`)
+	assert.Nil(t, processor.Run(&data.Event))
+	data = tests.MockLogEvent("/test.log", `not json`)
+	assert.Nil(t, processor.Run(&data.Event))
+}
+
+//TestFilterCIDR: 测试按网段过滤
+func TestFilterCIDR(t *testing.T) {
+	vars := map[string]interface{}{
+		"dataid":    "999990001",
+		"delimiter": "|",
+		"filters": []cfg.FilterConfig{
+			{
+				Conditions: []cfg.ConditionConfig{
+					{Index: 2, Key: "10.0.0.0/8, 192.168.1.1, fd00::/8", Op: "cidr", Negate: true},
+				},
+			},
+		},
+	}
+	config, err := cfg.CreateTaskConfig(vars)
+	if err != nil {
+		panic(err)
+	}
+	processor, _ := NewProcessors(config)
+
+	data := tests.MockLogEvent("/test.log", "GET|8.8.8.8")
+	assert.NotNil(t, processor.Run(&data.Event))
+	data = tests.MockLogEvent("/test.log", "GET|10.1.2.3")
+	assert.Nil(t, processor.Run(&data.Event))
+	data = tests.MockLogEvent("/test.log", "GET|192.168.1.1")
+	assert.Nil(t, processor.Run(&data.Event))
+	data = tests.MockLogEvent("/test.log", "GET|fd12::1")
+	assert.Nil(t, processor.Run(&data.Event))
+}
+
+//TestRateLimit: 测试过滤后事件限速
+func TestRateLimit(t *testing.T) {
+	vars := map[string]interface{}{
+		"dataid":                "999990001",
+		"max_events_per_second": 2,
+	}
+	config, err := cfg.CreateTaskConfig(vars)
+	if err != nil {
+		panic(err)
+	}
+	processor, _ := NewProcessors(config)
+	processor.rateLimitDropped.Set(0)
+
+	passed := 0
+	for i := 0; i < 5; i++ {
+		data := tests.MockLogEvent("/test.log", "test")
+		if processor.Run(&data.Event) != nil {
+			passed++
+		}
+	}
+	assert.Equal(t, 2, passed)
+	assert.Equal(t, int64(3), processor.rateLimitDropped.Get())
+}
+
+//TestSample: 测试按比例及按列采样
+func TestSample(t *testing.T) {
+	vars := map[string]interface{}{
+		"dataid":          "999990001",
+		"delimiter":       "|",
+		"sample_rate":     0.5,
+		"sample_by_index": 2,
+	}
+	config, err := cfg.CreateTaskConfig(vars)
+	if err != nil {
+		panic(err)
+	}
+	processor, _ := NewProcessors(config)
+
+	// 同一trace_id的事件采样结果一致
+	generator := tests.NewSyntheticLogGenerator("/test.log", "|", []tests.SyntheticColumn{
+		{Values: []string{"INFO", "ERROR"}},
+		{Values: []string{"t1", "t2", "t3", "t4", "t5", "t6", "t7", "t8"}},
+	}, 1)
+	sampled := make(map[string]bool)
+	for i := 0; i < 1000; i++ {
+		data := generator.Next()
+		trace := strings.Split(data.Event.Fields["data"].(string), "|")[1]
+		passed := processor.Run(&data.Event) != nil
+		if last, ok := sampled[trace]; ok {
+			assert.Equal(t, last, passed, trace)
+		}
+		sampled[trace] = passed
+	}
+
+	// 随机采样
+	config.SampleByIndex = 0
+	processor, _ = NewProcessors(config)
+	generator = tests.NewSyntheticLogGenerator("/test.log", "|", syntheticColumns, 1)
+	passed := 0
+	for i := 0; i < syntheticEventCount; i++ {
+		data := generator.Next()
+		if processor.Run(&data.Event) != nil {
+			passed++
+		}
+	}
+	assert.InDelta(t, 0.5, float64(passed)/syntheticEventCount, 0.05)
+}
+
+//TestSchedule: 测试采集时间计划
+func TestSchedule(t *testing.T) {
+	now := time.Now()
+	vars := map[string]interface{}{
+		"dataid": "999990001",
+		"schedule": map[string]interface{}{
+			"windows": []string{now.Add(time.Hour).Format("15:04") + "-" + now.Add(2*time.Hour).Format("15:04")},
+		},
+	}
+	config, err := cfg.CreateTaskConfig(vars)
+	if err != nil {
+		panic(err)
+	}
+	processor, _ := NewProcessors(config)
+	data := tests.MockLogEvent("/test.log", "test")
+	assert.Nil(t, processor.Run(&data.Event))
+
+	config.Schedule.Windows = []string{now.Add(-time.Hour).Format("15:04") + "-" + now.Add(time.Hour).Format("15:04")}
+	processor, _ = NewProcessors(config)
+	data = tests.MockLogEvent("/test.log", "test")
+	assert.NotNil(t, processor.Run(&data.Event))
+}
+
+//TestFilterIgnoreCase: 测试忽略大小写
+func TestFilterIgnoreCase(t *testing.T) {
+	vars := map[string]interface{}{
+		"dataid":    "999990001",
+		"delimiter": "|",
+		"filters": []cfg.FilterConfig{
+			{
+				Conditions: []cfg.ConditionConfig{
+					{Index: -1, Key: "Payment", Op: "=", IgnoreCase: true},
+					{Index: 1, Key: "error", Op: "=", IgnoreCase: true},
+				},
+			},
+		},
+	}
+	config, err := cfg.CreateTaskConfig(vars)
+	if err != nil {
+		panic(err)
+	}
+	processor, _ := NewProcessors(config)
+
+	data := tests.MockLogEvent("/test.log", "ERROR|PAYMENT failed")
+	assert.NotNil(t, processor.Run(&data.Event))
+	data = tests.MockLogEvent("/test.log", "Error|payment failed")
+	assert.NotNil(t, processor.Run(&data.Event))
+	data = tests.MockLogEvent("/test.log", "WARN|payment failed")
+	assert.Nil(t, processor.Run(&data.Event))
+}
+
+// TestDryRunFilter: 测试过滤条件试运行
+func TestDryRunFilter(t *testing.T) {
+	vars := map[string]interface{}{
+		"dataid":    "999990001",
+		"delimiter": "|",
+		"filters": []cfg.FilterConfig{
+			{
+				Conditions: []cfg.ConditionConfig{
+					{Index: 1, Key: "ERROR", Op: "="},
+					{Index: 3, Key: "payment", Op: "=", SoftFail: true},
+				},
+			},
+			{
+				Conditions: []cfg.ConditionConfig{
+					{Index: -1, Key: "timeout", Op: "contains"},
+				},
+			},
+		},
+	}
+	config, err := cfg.CreateTaskConfig(vars)
+	if err != nil {
+		panic(err)
+	}
+
+	result, err := DryRunFilter(config, "ERROR|db")
+	assert.NoError(t, err)
+	assert.True(t, result.Matched)
+	assert.Len(t, result.Filters, 2)
+	assert.True(t, result.Filters[0].Matched)
+	assert.Equal(t, "ERROR", result.Filters[0].Conditions[0].Value)
+	assert.True(t, result.Filters[0].Conditions[1].Skipped)
+	assert.False(t, result.Filters[1].Matched)
+
+	result, err = DryRunFilter(config, "WARN|db timeout")
+	assert.NoError(t, err)
+	assert.True(t, result.Matched)
+	assert.False(t, result.Filters[0].Matched)
+	assert.Equal(t, "condition(0) not matched", result.Filters[0].Reason)
+	assert.True(t, result.Filters[1].Matched)
+
+	result, err = DryRunFilter(config, "WARN|db")
+	assert.NoError(t, err)
+	assert.False(t, result.Matched)
+
+	// 按正则切分，且限速等与过滤无关的配置不影响试运行
+	vars["split_regex"] = `\s*\|\s*`
+	vars["max_events_per_second"] = 1
+	config, err = cfg.CreateTaskConfig(vars)
+	if err != nil {
+		panic(err)
+	}
+	for i := 0; i < 3; i++ {
+		result, err = DryRunFilter(config, "ERROR | db | payment")
+		assert.NoError(t, err)
+		assert.True(t, result.Filters[0].Matched)
+		assert.Equal(t, "payment", result.Filters[0].Conditions[1].Value)
+	}
+}
+
+// TestFilterDroppedMetrics: 测试按条件统计丢弃事件
+func TestFilterDroppedMetrics(t *testing.T) {
+	vars := map[string]interface{}{
+		"dataid":    "999990002",
+		"delimiter": "|",
+		"filters": []cfg.FilterConfig{
+			{
+				Conditions: []cfg.ConditionConfig{
+					{Index: 1, Key: "ERROR", Op: "="},
+					{Index: 2, Key: "db", Op: "!="},
+				},
+			},
+		},
+	}
+	config, err := cfg.CreateTaskConfig(vars)
+	if err != nil {
+		panic(err)
+	}
+	processor, _ := NewProcessors(config)
+	first := processor.loadFilters().filters[0].conditions[0].dropped
+	second := processor.loadFilters().filters[0].conditions[1].dropped
+	firstBase, secondBase := first.Get(), second.Get()
+
+	data := tests.MockLogEvent("/test.log", "WARN|db")
+	assert.Nil(t, processor.Run(&data.Event))
+	data = tests.MockLogEvent("/test.log", "ERROR|db")
+	assert.Nil(t, processor.Run(&data.Event))
+	data = tests.MockLogEvent("/test.log", "ERROR|cache")
+	assert.NotNil(t, processor.Run(&data.Event))
+
+	assert.Equal(t, int64(1), first.Get()-firstBase)
+	assert.Equal(t, int64(1), second.Get()-secondBase)
+}
+
+// TestUpdateFilters: 测试过滤条件热更新
+func TestUpdateFilters(t *testing.T) {
+	vars := map[string]interface{}{
+		"dataid":    "999990001",
+		"delimiter": "|",
+		"filters": []cfg.FilterConfig{
+			{Conditions: []cfg.ConditionConfig{{Index: 1, Key: "ERROR", Op: "="}}},
+		},
+	}
+	config, err := cfg.CreateTaskConfig(vars)
+	if err != nil {
+		panic(err)
+	}
+	processor, _ := NewProcessors(config)
+	defer processor.Close()
+
+	data := tests.MockLogEvent("/test.log", "WARN|db|timeout")
+	assert.Nil(t, processor.Run(&data.Event))
+
+	vars["filters"] = []cfg.FilterConfig{
+		{Conditions: []cfg.ConditionConfig{{Index: 3, Key: "timeout", Op: "="}}},
+	}
+	config, err = cfg.CreateTaskConfig(vars)
+	if err != nil {
+		panic(err)
+	}
+	old := processor.loadFilters()
+	assert.NoError(t, processor.UpdateFilters(config))
+	assert.Equal(t, 3, processor.loadFilters().maxIndex)
+	// 旧条件的后台任务随替换停止
+	_, open := <-old.done
+	assert.False(t, open)
+
+	data = tests.MockLogEvent("/test.log", "WARN|db|timeout")
+	assert.NotNil(t, processor.Run(&data.Event))
+	data = tests.MockLogEvent("/test.log", "ERROR|db|ok")
+	assert.Nil(t, processor.Run(&data.Event))
+}
+
+// TestTaskUpdateFilters: 测试热更新后任务ID及速率估算切换到新配置的ID
+func TestTaskUpdateFilters(t *testing.T) {
+	vars := map[string]interface{}{
+		"dataid":    "999990001",
+		"delimiter": "|",
+		"filters": []cfg.FilterConfig{
+			{Conditions: []cfg.ConditionConfig{{Index: 1, Key: "ERROR", Op: "="}}},
+		},
+	}
+	config, err := cfg.CreateTaskConfig(vars)
+	if err != nil {
+		panic(err)
+	}
+	task := NewTask(config, nil)
+	task.processors, _ = NewProcessors(config)
+	defer task.processors.Close()
+	task.eventRate = registerRateEstimator(task.GetID())
+	defer unregisterRateEstimator(config.ID)
+
+	vars["filters"] = []cfg.FilterConfig{
+		{Conditions: []cfg.ConditionConfig{{Index: 1, Key: "WARN", Op: "="}}},
+	}
+	newConfig, err := cfg.CreateTaskConfig(vars)
+	if err != nil {
+		panic(err)
+	}
+	defer unregisterRateEstimator(newConfig.ID)
+	assert.NotEqual(t, config.ID, newConfig.ID)
+	assert.NoError(t, task.UpdateFilters(newConfig))
+	assert.Equal(t, newConfig.ID, task.GetID())
+
+	r, ok := rateEstimators.Load(newConfig.ID)
+	assert.True(t, ok)
+	assert.Same(t, task.eventRate, r)
+	_, ok = rateEstimators.Load(config.ID)
+	assert.False(t, ok)
+}
+
+// TestDedup: 测试重复日志过滤
+func TestDedup(t *testing.T) {
+	vars := map[string]interface{}{
+		"dataid":    "999990001",
+		"delimiter": "|",
+		"dedup": map[string]interface{}{
+			"enabled": true,
+			"indexes": []int{1, 3},
+			"size":    100,
+		},
+	}
+	config, err := cfg.CreateTaskConfig(vars)
+	if err != nil {
+		panic(err)
+	}
+	processor, _ := NewProcessors(config)
+	defer processor.Close()
+
+	data := tests.MockLogEvent("/test.log", "ERROR|10:00:01|db timeout")
+	assert.NotNil(t, processor.Run(&data.Event))
+	// 第2列不参与去重
+	data = tests.MockLogEvent("/test.log", "ERROR|10:00:02|db timeout")
+	assert.Nil(t, processor.Run(&data.Event))
+	data = tests.MockLogEvent("/test.log", "ERROR|10:00:03|cache timeout")
+	assert.NotNil(t, processor.Run(&data.Event))
+
+	vars["dedup"] = map[string]interface{}{"enabled": true, "size": 0}
+	_, err = cfg.CreateTaskConfig(vars)
+	assert.Error(t, err)
+}
+
+// TestFilterExists: 测试按事件字段是否存在过滤
+func TestFilterExists(t *testing.T) {
+	vars := map[string]interface{}{
+		"dataid":    "999990001",
+		"delimiter": "|",
+		"filters": []cfg.FilterConfig{
+			{
+				Conditions: []cfg.ConditionConfig{
+					{Key: "kubernetes.pod", Op: "exists"},
+					{Key: "_priority", Op: "not_exists"},
+					{Index: 1, Key: "ERROR", Op: "="},
+				},
+			},
+		},
+	}
+	config, err := cfg.CreateTaskConfig(vars)
+	if err != nil {
+		panic(err)
+	}
+	processor, _ := NewProcessors(config)
+	defer processor.Close()
+
+	data := tests.MockLogEvent("/test.log", "ERROR|db")
+	data.Event.Fields["kubernetes"] = common.MapStr{"pod": "web-0"}
+	assert.NotNil(t, processor.Run(&data.Event))
+
+	data = tests.MockLogEvent("/test.log", "ERROR|db")
+	assert.Nil(t, processor.Run(&data.Event))
+
+	data = tests.MockLogEvent("/test.log", "ERROR|db")
+	data.Event.Fields["kubernetes"] = common.MapStr{"pod": "web-0"}
+	data.Event.Fields["_priority"] = 1
+	assert.Nil(t, processor.Run(&data.Event))
+
+	vars["filters"] = []cfg.FilterConfig{{Conditions: []cfg.ConditionConfig{{Op: "exists"}}}}
+	_, err = cfg.CreateTaskConfig(vars)
+	assert.Error(t, err)
+}
+
+// TestFilterSplitRegex: 测试正则分隔符及多个分隔符
+func TestFilterSplitRegex(t *testing.T) {
+	vars := map[string]interface{}{
+		"dataid":      "999990001",
+		"split_regex": `\s+`,
+		"filters": []cfg.FilterConfig{
+			{
+				Conditions: []cfg.ConditionConfig{
+					{Index: 2, Key: "ERROR", Op: "="},
+				},
+			},
+		},
+	}
+	config, err := cfg.CreateTaskConfig(vars)
+	if err != nil {
+		panic(err)
+	}
+	processor, _ := NewProcessors(config)
+	defer processor.Close()
+
+	data := tests.MockLogEvent("/test.log", "2021-11-16   ERROR\ttimeout")
+	assert.NotNil(t, processor.Run(&data.Event))
+
+	delete(vars, "split_regex")
+	vars["delimiters"] = []string{"|", "||"}
+	config, err = cfg.CreateTaskConfig(vars)
+	if err != nil {
+		panic(err)
+	}
+	processor, _ = NewProcessors(config)
+	defer processor.Close()
+
+	// 较长的分隔符优先匹配，不会切分出空列
+	data = tests.MockLogEvent("/test.log", "2021-11-16||ERROR|timeout")
+	assert.NotNil(t, processor.Run(&data.Event))
+	data = tests.MockLogEvent("/test.log", "2021-11-16|WARN||timeout")
+	assert.Nil(t, processor.Run(&data.Event))
+
+	vars["split_regex"] = `\s*`
+	_, err = cfg.CreateTaskConfig(vars)
+	assert.Error(t, err)
+	delete(vars, "delimiters")
+	_, err = cfg.CreateTaskConfig(vars)
+	assert.Error(t, err)
+}
+
+// TestFilterSplitCSV: 测试按CSV规则切分
+func TestFilterSplitCSV(t *testing.T) {
+	vars := map[string]interface{}{
+		"dataid":     "999990001",
+		"split_mode": "csv",
+		"filters": []cfg.FilterConfig{
+			{
+				Conditions: []cfg.ConditionConfig{
+					{Index: 3, Key: "ERROR", Op: "="},
+				},
+			},
+		},
+	}
+	config, err := cfg.CreateTaskConfig(vars)
+	if err != nil {
+		panic(err)
+	}
+	processor, _ := NewProcessors(config)
+	defer processor.Close()
+
+	data := tests.MockLogEvent("/test.log", `a,"b,c",ERROR,d`)
+	assert.NotNil(t, processor.Run(&data.Event))
+	data = tests.MockLogEvent("/test.log", `a,b,c,ERROR`)
+	assert.Nil(t, processor.Run(&data.Event))
+
+	vars["split_mode"] = "tsv"
+	_, err = cfg.CreateTaskConfig(vars)
+	assert.Error(t, err)
+}
+
+// TestMaxEventBytes: 测试超大事件丢弃及截断
+func TestMaxEventBytes(t *testing.T) {
+	vars := map[string]interface{}{
+		"dataid":          "999990001",
+		"max_event_bytes": 8,
+	}
+	config, err := cfg.CreateTaskConfig(vars)
+	if err != nil {
+		panic(err)
+	}
+	processor, _ := NewProcessors(config)
+	defer processor.Close()
+
+	data := tests.MockLogEvent("/test.log", "12345678")
+	assert.NotNil(t, processor.Run(&data.Event))
+	data = tests.MockLogEvent("/test.log", "123456789")
+	assert.Nil(t, processor.Run(&data.Event))
+
+	vars["oversize_mode"] = "truncate"
+	config, err = cfg.CreateTaskConfig(vars)
+	if err != nil {
+		panic(err)
+	}
+	processor, _ = NewProcessors(config)
+	defer processor.Close()
+
+	// 按字符边界截断，不产生非法UTF-8
+	data = tests.MockLogEvent("/test.log", "123456日志")
+	event := processor.Run(&data.Event)
+	assert.NotNil(t, event)
+	assert.Equal(t, "123456", event.Fields["data"])
+	assert.Equal(t, true, event.Fields["_truncated"])
+}
+
+// TestFilterExpr: 测试过滤表达式
+func TestFilterExpr(t *testing.T) {
+	vars := map[string]interface{}{
+		"dataid":    "999990001",
+		"delimiter": "|",
+		"filters": []cfg.FilterConfig{
+			{
+				Conditions: []cfg.ConditionConfig{
+					{Index: 1, Key: "db", Op: "="},
+				},
+				Expr: `col(3) == "ERROR" && (strings.Contains(line, "timeout") || col(4) > 500)`,
+			},
+		},
+	}
+	config, err := cfg.CreateTaskConfig(vars)
+	if err != nil {
+		panic(err)
+	}
+	processor, _ := NewProcessors(config)
+	defer processor.Close()
+	assert.Equal(t, 4, processor.loadFilters().maxIndex)
+
+	data := tests.MockLogEvent("/test.log", "db|10:00|ERROR|timeout")
+	assert.NotNil(t, processor.Run(&data.Event))
+	data = tests.MockLogEvent("/test.log", "db|10:00|ERROR|800")
+	assert.NotNil(t, processor.Run(&data.Event))
+	data = tests.MockLogEvent("/test.log", "db|10:00|ERROR|200")
+	assert.Nil(t, processor.Run(&data.Event))
+	data = tests.MockLogEvent("/test.log", "cache|10:00|ERROR|timeout")
+	assert.Nil(t, processor.Run(&data.Event))
+
+	vars["filters"] = []cfg.FilterConfig{{Expr: `col(3) == `}}
+	_, err = cfg.CreateTaskConfig(vars)
+	assert.Error(t, err)
+}
+
+// TestConditionalProcessors: 测试条件处理器按来源文件及过滤条件执行
+func TestConditionalProcessors(t *testing.T) {
+	vars := map[string]interface{}{
+		"dataid":    "999990001",
+		"delimiter": "|",
+		"conditional_processors": []map[string]interface{}{
+			{
+				"path": "*/access.log",
+				"if": map[string]interface{}{
+					"conditions": []map[string]interface{}{{"index": 1, "key": "DEBUG", "op": "="}},
+				},
+				"then": []map[string]interface{}{{"drop_event": map[string]interface{}{}}},
+			},
+		},
+	}
+	config, err := cfg.CreateTaskConfig(vars)
+	if err != nil {
+		panic(err)
+	}
+	processor, err := NewProcessors(config)
+	assert.NoError(t, err)
+	defer processor.Close()
+
+	data := tests.MockLogEvent("/var/log/nginx/access.log", "DEBUG|GET /")
+	assert.Nil(t, processor.RunSource(&data.Event, "/var/log/nginx/access.log"))
+	data = tests.MockLogEvent("/var/log/nginx/access.log", "INFO|GET /")
+	assert.NotNil(t, processor.RunSource(&data.Event, "/var/log/nginx/access.log"))
+	data = tests.MockLogEvent("/var/log/app.log", "DEBUG|started")
+	assert.NotNil(t, processor.RunSource(&data.Event, "/var/log/app.log"))
+
+	vars["conditional_processors"] = []map[string]interface{}{{"path": "*.log"}}
+	_, err = cfg.CreateTaskConfig(vars)
+	assert.Error(t, err)
+}
+
+// TestSourceEncoding: 测试GBK内容在过滤之前转换为UTF-8
+func TestSourceEncoding(t *testing.T) {
+	vars := map[string]interface{}{
+		"dataid":          "999990001",
+		"source_encoding": "gbk",
+		"delimiter":       "|",
+		"filters": []cfg.FilterConfig{
+			{Conditions: []cfg.ConditionConfig{{Index: 1, Key: "中文", Op: "="}}},
+		},
+	}
+	config, err := cfg.CreateTaskConfig(vars)
+	if err != nil {
+		panic(err)
+	}
+	processor, _ := NewProcessors(config)
+	defer processor.Close()
+
+	data := tests.MockLogEvent("/test.log", "\xd6\xd0\xce\xc4|gbk")
+	event := processor.Run(&data.Event)
+	assert.NotNil(t, event)
+	assert.Equal(t, "中文|gbk", event.Fields["data"])
+
+	// 已是UTF-8的内容不转换
+	data = tests.MockLogEvent("/test.log", "中文|utf8")
+	assert.Equal(t, "中文|utf8", processor.Run(&data.Event).Fields["data"])
+
+	vars["source_encoding"] = "unknown"
+	_, err = cfg.CreateTaskConfig(vars)
+	assert.Error(t, err)
+}
+
+// TestDissectProcessor: 测试任务processors中使用dissect按固定结构解析日志
+func TestDissectProcessor(t *testing.T) {
+	vars := map[string]interface{}{
+		"dataid": "999990001",
+		"processors": []map[string]interface{}{
+			{"dissect": map[string]interface{}{
+				"tokenizer":     "%{ts} %{level} [%{thread}] %{msg}",
+				"field":         "data",
+				"target_prefix": "log",
+			}},
+		},
+	}
+	config, err := cfg.CreateTaskConfig(vars)
+	if err != nil {
+		panic(err)
+	}
+	processor, err := NewProcessors(config)
+	assert.NoError(t, err)
+	defer processor.Close()
+
+	data := tests.MockLogEvent("/test.log", "10:00:01 ERROR [main] connect failed")
+	event := processor.Run(&data.Event)
+	assert.NotNil(t, event)
+	level, _ := event.Fields.GetValue("log.level")
+	assert.Equal(t, "ERROR", level)
+	msg, _ := event.Fields.GetValue("log.msg")
+	assert.Equal(t, "connect failed", msg)
+}
+
+// TestHostMetadataProcessor: 测试任务processors中使用add_host_metadata补充主机信息
+func TestHostMetadataProcessor(t *testing.T) {
+	vars := map[string]interface{}{
+		"dataid": "999990001",
+		"processors": []map[string]interface{}{
+			{"add_host_metadata": map[string]interface{}{
+				"netinfo.enabled": true,
+			}},
+		},
+	}
+	config, err := cfg.CreateTaskConfig(vars)
+	if err != nil {
+		panic(err)
+	}
+	processor, err := NewProcessors(config)
+	assert.NoError(t, err)
+	defer processor.Close()
+
+	data := tests.MockLogEvent("/test.log", "test")
+	event := processor.Run(&data.Event)
+	assert.NotNil(t, event)
+	hostname, _ := os.Hostname()
+	value, _ := event.Fields.GetValue("host.hostname")
+	assert.Equal(t, hostname, value)
+	_, err = event.Fields.GetValue("host.os.family")
+	assert.NoError(t, err)
+}
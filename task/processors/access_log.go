@@ -0,0 +1,256 @@
+// Tencent is pleased to support the open source community by making bkunifylogbeat 蓝鲸日志采集器 available.
+//
+// Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+//
+// bkunifylogbeat 蓝鲸日志采集器 is licensed under the MIT License.
+//
+// License for bkunifylogbeat 蓝鲸日志采集器:
+// --------------------------------------------------------------------
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+// documentation files (the "Software"), to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software,
+// and to permit persons to whom the Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all copies or substantial
+// portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+// LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN
+// NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+// WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+// SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package processors
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/TencentBlueKing/collector-go-sdk/v2/bkbeat/bkmonitoring"
+	"github.com/elastic/beats/libbeat/beat"
+	"github.com/elastic/beats/libbeat/common"
+	process "github.com/elastic/beats/libbeat/processors"
+)
+
+var processorAccessLogFailed = bkmonitoring.NewInt("processor_access_log_failed")
+
+func init() {
+	MustRegister("access_log", newAccessLog)
+}
+
+// accessLogPresets: 内置格式，common/combined与apache的同名格式一致，main为nginx默认的log_format
+var accessLogPresets = map[string]string{
+	"common":   `$remote_addr - $remote_user [$time_local] "$request" $status $body_bytes_sent`,
+	"combined": `$remote_addr - $remote_user [$time_local] "$request" $status $body_bytes_sent "$http_referer" "$http_user_agent"`,
+	"main":     `$remote_addr - $remote_user [$time_local] "$request" $status $body_bytes_sent "$http_referer" "$http_user_agent" "$http_x_forwarded_for"`,
+}
+
+// accessLogNginxFields: nginx变量对应的字段名，未列出的变量输出为nginx.<变量名>
+var accessLogNginxFields = map[string]string{
+	"remote_addr":            "source.address",
+	"remote_user":            "user.name",
+	"time_local":             "timestamp",
+	"time_iso8601":           "timestamp",
+	"request":                "request",
+	"request_method":         "http.request.method",
+	"request_uri":            "url.original",
+	"uri":                    "url.path",
+	"args":                   "url.query",
+	"server_protocol":        "http.version",
+	"status":                 "http.response.status_code",
+	"body_bytes_sent":        "http.response.body.bytes",
+	"bytes_sent":             "http.response.bytes",
+	"request_length":         "http.request.bytes",
+	"http_referer":           "http.request.referrer",
+	"http_user_agent":        "user_agent.original",
+	"http_x_forwarded_for":   "http.request.x_forwarded_for",
+	"host":                   "url.domain",
+	"request_time":           "http.request.time",
+	"upstream_addr":          "http.upstream.address",
+	"upstream_status":        "http.upstream.status_code",
+	"upstream_response_time": "http.upstream.response_time",
+}
+
+// accessLogApacheFields: apache LogFormat指令对应的字段名，未列出的指令输出为apache.<指令>
+var accessLogApacheFields = map[string]string{
+	"h":  "source.address",
+	"a":  "source.address",
+	"u":  "user.name",
+	"t":  "timestamp",
+	"r":  "request",
+	"m":  "http.request.method",
+	"U":  "url.path",
+	"q":  "url.query",
+	"H":  "http.version",
+	">s": "http.response.status_code",
+	"s":  "http.response.status_code",
+	"b":  "http.response.body.bytes",
+	"B":  "http.response.body.bytes",
+	"v":  "url.domain",
+	"D":  "http.request.time_us",
+	"T":  "http.request.time",
+}
+
+// accessLogIntFields/accessLogFloatFields: 需要转换为数值的字段
+var (
+	accessLogIntFields = map[string]bool{
+		"http.response.status_code": true,
+		"http.response.body.bytes":  true,
+		"http.response.bytes":       true,
+		"http.request.bytes":        true,
+		"http.request.time_us":      true,
+	}
+	accessLogFloatFields = map[string]bool{
+		"http.request.time": true,
+	}
+)
+
+// accessLogVariable: nginx的$var或${var}，apache的%x、%>s及%{Header}i
+var accessLogVariable = regexp.MustCompile(`\$\{?([a-zA-Z0-9_]+)\}?|%(?:\{([^}]*)\})?(>?[a-zA-Z])`)
+
+// accessLogConfig: 访问日志解析配置
+type accessLogConfig struct {
+	Field string `config:"field"`
+	// 内置格式(common、combined、main)，或自定义的nginx log_format/apache LogFormat
+	Format string `config:"format"`
+	// 解析的字段放在target下，为空时放在事件根节点
+	Target string `config:"target"`
+}
+
+// accessLog: 按nginx/apache的日志格式解析访问日志，值为"-"的字段不输出
+type accessLog struct {
+	config  accessLogConfig
+	pattern *regexp.Regexp
+	fields  []string
+}
+
+func newAccessLog(c *common.Config) (process.Processor, error) {
+	config := accessLogConfig{Field: defaultField, Format: "combined"}
+	if err := c.Unpack(&config); err != nil {
+		return nil, fmt.Errorf("fail to unpack the access_log configuration: %v", err)
+	}
+	format := config.Format
+	if preset, ok := accessLogPresets[format]; ok {
+		format = preset
+	}
+	pattern, fields, err := compileAccessLog(format)
+	if err != nil {
+		return nil, err
+	}
+	return &accessLog{config: config, pattern: pattern, fields: fields}, nil
+}
+
+// compileAccessLog: 将日志格式转换为正则，每个变量对应一个捕获组
+func compileAccessLog(format string) (*regexp.Regexp, []string, error) {
+	var fields []string
+	var builder strings.Builder
+	builder.WriteString("^")
+	last := 0
+	for _, loc := range accessLogVariable.FindAllStringSubmatchIndex(format, -1) {
+		builder.WriteString(regexp.QuoteMeta(format[last:loc[0]]))
+		last = loc[1]
+		var field string
+		if loc[2] >= 0 {
+			name := format[loc[2]:loc[3]]
+			field = accessLogNginxFields[name]
+			if field == "" {
+				field = "nginx." + name
+			}
+		} else {
+			directive := format[loc[6]:loc[7]]
+			if loc[4] >= 0 {
+				// %{Header}i为请求头，其余带参数的指令按原样命名
+				header := format[loc[4]:loc[5]]
+				switch {
+				case directive == "i" && strings.EqualFold(header, "Referer"):
+					field = "http.request.referrer"
+				case directive == "i" && strings.EqualFold(header, "User-Agent"):
+					field = "user_agent.original"
+				case directive == "i":
+					field = "http.request.headers." + strings.ToLower(strings.Replace(header, "-", "_", -1))
+				default:
+					field = "apache." + directive + "." + header
+				}
+			} else {
+				field = accessLogApacheFields[directive]
+				if field == "" {
+					field = "apache." + directive
+				}
+			}
+			// apache的%t自带方括号
+			if field == "timestamp" {
+				builder.WriteString(`\[(.*?)\]`)
+				fields = append(fields, field)
+				continue
+			}
+		}
+		builder.WriteString("(.*?)")
+		fields = append(fields, field)
+	}
+	if len(fields) == 0 {
+		return nil, nil, fmt.Errorf("access_log format(%s) has no variables", format)
+	}
+	builder.WriteString(regexp.QuoteMeta(format[last:]))
+	builder.WriteString("$")
+	pattern, err := regexp.Compile(builder.String())
+	if err != nil {
+		return nil, nil, fmt.Errorf("access_log format(%s) compile failed, err=>%v", format, err)
+	}
+	return pattern, fields, nil
+}
+
+// Run 解析访问日志，不匹配时保持原样并计数
+func (p *accessLog) Run(event *beat.Event) (*beat.Event, error) {
+	text, ok := getString(event, p.config.Field)
+	if !ok {
+		return event, nil
+	}
+	match := p.pattern.FindStringSubmatch(text)
+	if match == nil {
+		processorAccessLogFailed.Add(1)
+		return event, nil
+	}
+	for i, field := range p.fields {
+		value := match[i+1]
+		if value == "-" || value == "" {
+			continue
+		}
+		for key, v := range accessLogValues(field, value) {
+			event.Fields.Put(targetKey(p.config.Target, key), v)
+		}
+	}
+	return event, nil
+}
+
+// accessLogValues: 转换字段值，请求行拆分为方法、URL及协议版本，数值字段转换失败时保留原文
+func accessLogValues(field, value string) map[string]interface{} {
+	switch {
+	case field == "request":
+		parts := strings.Split(value, " ")
+		if len(parts) == 3 && strings.HasPrefix(parts[2], "HTTP/") {
+			return map[string]interface{}{
+				"http.request.method": parts[0],
+				"url.original":        parts[1],
+				"http.version":        strings.TrimPrefix(parts[2], "HTTP/"),
+			}
+		}
+		return map[string]interface{}{"url.original": value}
+	case field == "http.version":
+		return map[string]interface{}{field: strings.TrimPrefix(value, "HTTP/")}
+	case accessLogIntFields[field]:
+		if n, err := strconv.ParseInt(value, 10, 64); err == nil {
+			return map[string]interface{}{field: n}
+		}
+	case accessLogFloatFields[field]:
+		if f, err := strconv.ParseFloat(value, 64); err == nil {
+			return map[string]interface{}{field: f}
+		}
+	}
+	return map[string]interface{}{field: value}
+}
+
+func (p *accessLog) String() string {
+	return fmt.Sprintf("access_log=[field=%s, format=%s, target=%s]", p.config.Field, p.config.Format, p.config.Target)
+}
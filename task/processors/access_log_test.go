@@ -0,0 +1,83 @@
+// Tencent is pleased to support the open source community by making bkunifylogbeat 蓝鲸日志采集器 available.
+//
+// Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+//
+// bkunifylogbeat 蓝鲸日志采集器 is licensed under the MIT License.
+//
+// License for bkunifylogbeat 蓝鲸日志采集器:
+// --------------------------------------------------------------------
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+// documentation files (the "Software"), to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software,
+// and to permit persons to whom the Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all copies or substantial
+// portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+// LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN
+// NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+// WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+// SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package processors
+
+import (
+	"testing"
+
+	"github.com/TencentBlueKing/bkunifylogbeat/tests"
+	"github.com/elastic/beats/libbeat/common"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestAccessLog: 测试内置combined格式、自定义nginx及apache格式
+func TestAccessLog(t *testing.T) {
+	p, err := newAccessLog(common.MustNewConfigFrom(map[string]interface{}{"target": "nginx"}))
+	assert.NoError(t, err)
+	data := tests.MockLogEvent("/access.log", `127.0.0.1 - - [10/Oct/2021:13:55:36 +0800] "GET /index.html?a=1 HTTP/1.1" 200 612 "-" "curl/7.29.0"`)
+	event, err := p.Run(&data.Event)
+	assert.NoError(t, err)
+	nginx, _ := event.Fields.GetValue("nginx")
+	assert.Equal(t, common.MapStr{
+		"source":     common.MapStr{"address": "127.0.0.1"},
+		"timestamp":  "10/Oct/2021:13:55:36 +0800",
+		"http":       common.MapStr{"version": "1.1", "request": common.MapStr{"method": "GET"}, "response": common.MapStr{"status_code": int64(200), "body": common.MapStr{"bytes": int64(612)}}},
+		"url":        common.MapStr{"original": "/index.html?a=1"},
+		"user_agent": common.MapStr{"original": "curl/7.29.0"},
+	}, nginx)
+
+	// 不匹配时保持原样
+	data = tests.MockLogEvent("/access.log", "not an access log")
+	event, err = p.Run(&data.Event)
+	assert.NoError(t, err)
+	assert.NotContains(t, event.Fields, "nginx")
+
+	p, err = newAccessLog(common.MustNewConfigFrom(map[string]interface{}{
+		"format": `$remote_addr [$time_local] "$request" $status $request_time "$http_x_request_id"`,
+	}))
+	assert.NoError(t, err)
+	data = tests.MockLogEvent("/access.log", `10.0.0.1 [10/Oct/2021:13:55:36 +0800] "POST /api HTTP/2.0" 502 0.005 "abc"`)
+	event, _ = p.Run(&data.Event)
+	cost, _ := event.Fields.GetValue("http.request.time")
+	assert.Equal(t, 0.005, cost)
+	requestID, _ := event.Fields.GetValue("nginx.http_x_request_id")
+	assert.Equal(t, "abc", requestID)
+
+	p, err = newAccessLog(common.MustNewConfigFrom(map[string]interface{}{
+		"format": `%h %l %u %t "%r" %>s %b "%{Referer}i" "%{User-Agent}i" %D`,
+	}))
+	assert.NoError(t, err)
+	data = tests.MockLogEvent("/access.log", `1.2.3.4 - bob [10/Oct/2000:13:55:36 -0700] "GET /a.gif HTTP/1.0" 200 - "http://x/" "Mozilla/5.0 (X11)" 1234`)
+	event, _ = p.Run(&data.Event)
+	user, _ := event.Fields.GetValue("user.name")
+	assert.Equal(t, "bob", user)
+	referrer, _ := event.Fields.GetValue("http.request.referrer")
+	assert.Equal(t, "http://x/", referrer)
+	duration, _ := event.Fields.GetValue("http.request.time_us")
+	assert.Equal(t, int64(1234), duration)
+	_, err = event.Fields.GetValue("http.response.body.bytes")
+	assert.Error(t, err)
+
+	_, err = newAccessLog(common.MustNewConfigFrom(map[string]interface{}{"format": "no variables"}))
+	assert.Error(t, err)
+}
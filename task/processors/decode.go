@@ -0,0 +1,112 @@
+// Tencent is pleased to support the open source community by making bkunifylogbeat 蓝鲸日志采集器 available.
+//
+// Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+//
+// bkunifylogbeat 蓝鲸日志采集器 is licensed under the MIT License.
+//
+// License for bkunifylogbeat 蓝鲸日志采集器:
+// --------------------------------------------------------------------
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+// documentation files (the "Software"), to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software,
+// and to permit persons to whom the Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all copies or substantial
+// portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+// LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN
+// NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+// WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+// SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package processors
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/TencentBlueKing/collector-go-sdk/v2/bkbeat/bkmonitoring"
+	"github.com/elastic/beats/libbeat/beat"
+	"github.com/elastic/beats/libbeat/common"
+	process "github.com/elastic/beats/libbeat/processors"
+)
+
+var processorDecodeFailed = bkmonitoring.NewInt("processor_decode_failed")
+
+func init() {
+	MustRegister("decode", newDecode)
+}
+
+// decoders: 支持的编码，base64兼容带或不带填充的标准及URL编码
+var decoders = map[string]func(string) ([]byte, error){
+	"base64": func(s string) ([]byte, error) {
+		s = strings.TrimRight(s, "=")
+		if strings.ContainsAny(s, "-_") {
+			return base64.RawURLEncoding.DecodeString(s)
+		}
+		return base64.RawStdEncoding.DecodeString(s)
+	},
+	"hex": hex.DecodeString,
+}
+
+// decodeConfig: 解码配置
+type decodeConfig struct {
+	Field string `config:"field"`
+	// 解码结果写入的字段，为空时替换原字段
+	Target string `config:"target"`
+	// 编码：base64(默认)、hex
+	Encoding string `config:"encoding"`
+	// 解码失败或结果不是有效的UTF-8时，在ErrorField中记录错误，为空时只计数，原字段保持不变
+	ErrorField string `config:"error_field"`
+}
+
+// decode: 对字段内容做base64或hex解码
+type decode struct {
+	config decodeConfig
+	decode func(string) ([]byte, error)
+}
+
+func newDecode(c *common.Config) (process.Processor, error) {
+	config := decodeConfig{Field: defaultField, Encoding: "base64"}
+	if err := c.Unpack(&config); err != nil {
+		return nil, fmt.Errorf("fail to unpack the decode configuration: %v", err)
+	}
+	f, ok := decoders[config.Encoding]
+	if !ok {
+		return nil, fmt.Errorf("decode encoding must be base64 or hex")
+	}
+	return &decode{config: config, decode: f}, nil
+}
+
+// Run 解码字段内容，失败时保持原样
+func (p *decode) Run(event *beat.Event) (*beat.Event, error) {
+	text, ok := getString(event, p.config.Field)
+	if !ok {
+		return event, nil
+	}
+	decoded, err := p.decode(strings.TrimSpace(text))
+	if err == nil && !utf8.Valid(decoded) {
+		err = fmt.Errorf("decoded content is not valid utf-8")
+	}
+	if err != nil {
+		processorDecodeFailed.Add(1)
+		if p.config.ErrorField != "" {
+			event.Fields.Put(p.config.ErrorField, err.Error())
+		}
+		return event, nil
+	}
+	target := p.config.Target
+	if target == "" {
+		target = p.config.Field
+	}
+	event.Fields.Put(target, string(decoded))
+	return event, nil
+}
+
+func (p *decode) String() string {
+	return fmt.Sprintf("decode=[field=%s, encoding=%s, target=%s]", p.config.Field, p.config.Encoding, p.config.Target)
+}
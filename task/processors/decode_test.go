@@ -0,0 +1,61 @@
+// Tencent is pleased to support the open source community by making bkunifylogbeat 蓝鲸日志采集器 available.
+//
+// Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+//
+// bkunifylogbeat 蓝鲸日志采集器 is licensed under the MIT License.
+//
+// License for bkunifylogbeat 蓝鲸日志采集器:
+// --------------------------------------------------------------------
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+// documentation files (the "Software"), to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software,
+// and to permit persons to whom the Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all copies or substantial
+// portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+// LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN
+// NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+// WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+// SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package processors
+
+import (
+	"testing"
+
+	"github.com/TencentBlueKing/bkunifylogbeat/tests"
+	"github.com/elastic/beats/libbeat/common"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestDecode: 测试base64、hex解码及非法内容处理
+func TestDecode(t *testing.T) {
+	p, err := newDecode(common.MustNewConfigFrom(map[string]interface{}{"target": "payload", "error_field": "_decode_error"}))
+	assert.NoError(t, err)
+
+	data := tests.MockLogEvent("/test.log", "aGVsbG8gd29ybGQ=")
+	event, _ := p.Run(&data.Event)
+	assert.Equal(t, "hello world", event.Fields["payload"])
+	assert.Equal(t, "aGVsbG8gd29ybGQ=", event.Fields["data"])
+
+	data = tests.MockLogEvent("/test.log", "not base64!")
+	event, _ = p.Run(&data.Event)
+	assert.NotContains(t, event.Fields, "payload")
+	assert.Contains(t, event.Fields, "_decode_error")
+
+	p, err = newDecode(common.MustNewConfigFrom(map[string]interface{}{"encoding": "hex"}))
+	assert.NoError(t, err)
+	data = tests.MockLogEvent("/test.log", "68690a")
+	event, _ = p.Run(&data.Event)
+	assert.Equal(t, "hi\n", event.Fields["data"])
+
+	// 解码结果不是UTF-8时保持原样
+	data = tests.MockLogEvent("/test.log", "fffe")
+	event, _ = p.Run(&data.Event)
+	assert.Equal(t, "fffe", event.Fields["data"])
+
+	_, err = newDecode(common.MustNewConfigFrom(map[string]interface{}{"encoding": "rot13"}))
+	assert.Error(t, err)
+}
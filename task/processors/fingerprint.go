@@ -0,0 +1,129 @@
+// Tencent is pleased to support the open source community by making bkunifylogbeat 蓝鲸日志采集器 available.
+//
+// Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+//
+// bkunifylogbeat 蓝鲸日志采集器 is licensed under the MIT License.
+//
+// License for bkunifylogbeat 蓝鲸日志采集器:
+// --------------------------------------------------------------------
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+// documentation files (the "Software"), to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software,
+// and to permit persons to whom the Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all copies or substantial
+// portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+// LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN
+// NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+// WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+// SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package processors
+
+import (
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"hash/fnv"
+	"sort"
+
+	"github.com/elastic/beats/libbeat/beat"
+	"github.com/elastic/beats/libbeat/common"
+	process "github.com/elastic/beats/libbeat/processors"
+)
+
+func init() {
+	MustRegister("fingerprint", newFingerprint)
+}
+
+// fingerprintMethods: 支持的哈希算法，fnv64a为非加密哈希，速度较快
+var fingerprintMethods = map[string]func() hash.Hash{
+	"md5":    md5.New,
+	"sha1":   sha1.New,
+	"sha256": sha256.New,
+	"fnv64a": func() hash.Hash { return fnv.New64a() },
+}
+
+// fingerprintConfig: 指纹配置
+type fingerprintConfig struct {
+	Fields      []string `config:"fields" validate:"required"`
+	TargetField string   `config:"target_field"`
+	// 哈希算法：sha1(默认)、sha256、md5、fnv64a
+	Method string `config:"method"`
+	// 输出编码：hex(默认)、base64
+	Encoding string `config:"encoding"`
+	// 字段不存在时是否忽略，否则返回错误且不添加指纹
+	IgnoreMissing bool `config:"ignore_missing"`
+}
+
+// fingerprint: 对指定字段计算哈希作为事件指纹，用于下游去重及幂等写入
+type fingerprint struct {
+	config  fingerprintConfig
+	newHash func() hash.Hash
+}
+
+func newFingerprint(c *common.Config) (process.Processor, error) {
+	config := fingerprintConfig{TargetField: "fingerprint", Method: "sha1", Encoding: "hex"}
+	if err := c.Unpack(&config); err != nil {
+		return nil, fmt.Errorf("fail to unpack the fingerprint configuration: %v", err)
+	}
+	newHash, ok := fingerprintMethods[config.Method]
+	if !ok {
+		return nil, fmt.Errorf("fingerprint method(%s) is not supported", config.Method)
+	}
+	switch config.Encoding {
+	case "hex", "base64":
+	default:
+		return nil, fmt.Errorf("fingerprint encoding must be hex or base64")
+	}
+	// 字段按名称排序，配置顺序不影响指纹
+	fields := append([]string(nil), config.Fields...)
+	sort.Strings(fields)
+	config.Fields = fields
+	return &fingerprint{config: config, newHash: newHash}, nil
+}
+
+// Run 按"字段名|值|"的形式依次写入哈希，非字符串值按JSON序列化
+func (p *fingerprint) Run(event *beat.Event) (*beat.Event, error) {
+	h := p.newHash()
+	for _, field := range p.config.Fields {
+		value, err := event.Fields.GetValue(field)
+		if err != nil {
+			if p.config.IgnoreMissing {
+				continue
+			}
+			return event, fmt.Errorf("fingerprint field(%s) is missing", field)
+		}
+		h.Write([]byte(field))
+		h.Write([]byte{'|'})
+		if s, ok := value.(string); ok {
+			h.Write([]byte(s))
+		} else {
+			b, err := json.Marshal(value)
+			if err != nil {
+				return event, fmt.Errorf("fingerprint field(%s) marshal failed, err=>%v", field, err)
+			}
+			h.Write(b)
+		}
+		h.Write([]byte{'|'})
+	}
+	sum := h.Sum(nil)
+	if p.config.Encoding == "base64" {
+		event.Fields.Put(p.config.TargetField, base64.StdEncoding.EncodeToString(sum))
+	} else {
+		event.Fields.Put(p.config.TargetField, hex.EncodeToString(sum))
+	}
+	return event, nil
+}
+
+func (p *fingerprint) String() string {
+	return fmt.Sprintf("fingerprint=[fields=%v, method=%s, target_field=%s]",
+		p.config.Fields, p.config.Method, p.config.TargetField)
+}
@@ -0,0 +1,65 @@
+// Tencent is pleased to support the open source community by making bkunifylogbeat 蓝鲸日志采集器 available.
+//
+// Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+//
+// bkunifylogbeat 蓝鲸日志采集器 is licensed under the MIT License.
+//
+// License for bkunifylogbeat 蓝鲸日志采集器:
+// --------------------------------------------------------------------
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+// documentation files (the "Software"), to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software,
+// and to permit persons to whom the Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all copies or substantial
+// portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+// LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN
+// NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+// WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+// SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package processors
+
+import (
+	"testing"
+
+	"github.com/TencentBlueKing/bkunifylogbeat/tests"
+	"github.com/elastic/beats/libbeat/common"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestFingerprint: 测试指纹计算与字段顺序无关及字段缺失处理
+func TestFingerprint(t *testing.T) {
+	p1, err := newFingerprint(common.MustNewConfigFrom(map[string]interface{}{"fields": []string{"data", "level"}}))
+	assert.NoError(t, err)
+	p2, err := newFingerprint(common.MustNewConfigFrom(map[string]interface{}{"fields": []string{"level", "data"}}))
+	assert.NoError(t, err)
+
+	data1 := tests.MockLogEvent("/test.log", "hello")
+	data1.Event.Fields["level"] = "info"
+	event1, err := p1.Run(&data1.Event)
+	assert.NoError(t, err)
+	data2 := tests.MockLogEvent("/test.log", "hello")
+	data2.Event.Fields["level"] = "info"
+	event2, _ := p2.Run(&data2.Event)
+	assert.Len(t, event1.Fields["fingerprint"], 40)
+	assert.Equal(t, event1.Fields["fingerprint"], event2.Fields["fingerprint"])
+
+	data3 := tests.MockLogEvent("/test.log", "hello")
+	_, err = p1.Run(&data3.Event)
+	assert.Error(t, err)
+	assert.NotContains(t, data3.Event.Fields, "fingerprint")
+
+	p3, err := newFingerprint(common.MustNewConfigFrom(map[string]interface{}{
+		"fields": []string{"data", "level"}, "method": "fnv64a", "ignore_missing": true,
+	}))
+	assert.NoError(t, err)
+	event3, err := p3.Run(&data3.Event)
+	assert.NoError(t, err)
+	assert.Len(t, event3.Fields["fingerprint"], 16)
+
+	_, err = newFingerprint(common.MustNewConfigFrom(map[string]interface{}{"fields": []string{"data"}, "method": "crc"}))
+	assert.Error(t, err)
+}
@@ -0,0 +1,235 @@
+// Tencent is pleased to support the open source community by making bkunifylogbeat 蓝鲸日志采集器 available.
+//
+// Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+//
+// bkunifylogbeat 蓝鲸日志采集器 is licensed under the MIT License.
+//
+// License for bkunifylogbeat 蓝鲸日志采集器:
+// --------------------------------------------------------------------
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+// documentation files (the "Software"), to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software,
+// and to permit persons to whom the Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all copies or substantial
+// portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+// LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN
+// NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+// WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+// SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package processors
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/TencentBlueKing/bkunifylogbeat/utils"
+	"github.com/TencentBlueKing/collector-go-sdk/v2/bkbeat/bkmonitoring"
+	"github.com/TencentBlueKing/collector-go-sdk/v2/bkbeat/logp"
+	"github.com/elastic/beats/libbeat/beat"
+	"github.com/elastic/beats/libbeat/common"
+	process "github.com/elastic/beats/libbeat/processors"
+)
+
+var processorGeoIPFailed = bkmonitoring.NewInt("processor_geoip_failed")
+
+func init() {
+	MustRegister("geoip", newGeoIP)
+}
+
+// mmdbReloadInterval: 数据库文件变更检查周期
+var mmdbReloadInterval = 30 * time.Second
+
+// mmdbWatcher: 数据库文件变更后重新加载并原子替换，同一文件在所有任务间共享。
+// libbeat处理器没有关闭回调，因此不启动后台协程，而是在查询时按周期检查文件
+type mmdbWatcher struct {
+	path      string
+	mutex     sync.Mutex
+	modTime   time.Time
+	nextCheck int64
+	db        atomic.Value
+}
+
+var (
+	mmdbWatchers      = map[string]*mmdbWatcher{}
+	mmdbWatchersMutex sync.Mutex
+)
+
+// getMMDBWatcher: 获取共享的数据库，首次使用时加载
+func getMMDBWatcher(path string) (*mmdbWatcher, error) {
+	mmdbWatchersMutex.Lock()
+	defer mmdbWatchersMutex.Unlock()
+	if w, ok := mmdbWatchers[path]; ok {
+		return w, nil
+	}
+	w := &mmdbWatcher{path: path}
+	if err := w.load(); err != nil {
+		return nil, err
+	}
+	mmdbWatchers[path] = w
+	return w, nil
+}
+
+// load: 文件修改时间变化时重新加载，旧的映射在不再被引用后释放
+func (w *mmdbWatcher) load() error {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	atomic.StoreInt64(&w.nextCheck, time.Now().Add(mmdbReloadInterval).UnixNano())
+	info, err := os.Stat(w.path)
+	if err != nil {
+		return err
+	}
+	if info.ModTime().Equal(w.modTime) {
+		return nil
+	}
+	db, err := utils.OpenMMDB(w.path)
+	if err != nil {
+		return err
+	}
+	w.db.Store(db)
+	w.modTime = info.ModTime()
+	return nil
+}
+
+// get: 获取当前数据库，到达检查周期时检查文件变更，加载失败时继续使用旧的数据库
+func (w *mmdbWatcher) get() *utils.MMDB {
+	if time.Now().UnixNano() >= atomic.LoadInt64(&w.nextCheck) {
+		if err := w.load(); err != nil {
+			logp.L.Errorf("reload mmdb(%s) failed, err=>%v", w.path, err)
+		}
+	}
+	return w.db.Load().(*utils.MMDB)
+}
+
+// geoIPConfig: GeoIP配置，database为City或Country数据库，asn_database为ASN数据库，至少配置一个
+type geoIPConfig struct {
+	Field       string `config:"field" validate:"required"`
+	Database    string `config:"database"`
+	ASNDatabase string `config:"asn_database"`
+	Target      string `config:"target"`
+}
+
+// geoIP: 根据IP字段查询本地MaxMind/GeoLite数据库，添加国家、城市及ASN字段
+type geoIP struct {
+	config geoIPConfig
+	geo    *mmdbWatcher
+	asn    *mmdbWatcher
+}
+
+func newGeoIP(c *common.Config) (process.Processor, error) {
+	config := geoIPConfig{Target: "geoip"}
+	if err := c.Unpack(&config); err != nil {
+		return nil, fmt.Errorf("fail to unpack the geoip configuration: %v", err)
+	}
+	if config.Database == "" && config.ASNDatabase == "" {
+		return nil, fmt.Errorf("geoip requires database or asn_database")
+	}
+	p := &geoIP{config: config}
+	var err error
+	if config.Database != "" {
+		if p.geo, err = getMMDBWatcher(config.Database); err != nil {
+			return nil, fmt.Errorf("geoip load database(%s) failed, err=>%v", config.Database, err)
+		}
+	}
+	if config.ASNDatabase != "" {
+		if p.asn, err = getMMDBWatcher(config.ASNDatabase); err != nil {
+			return nil, fmt.Errorf("geoip load asn_database(%s) failed, err=>%v", config.ASNDatabase, err)
+		}
+	}
+	return p, nil
+}
+
+// lookupPath: 按路径获取记录中的值，数组取第一个元素
+func lookupPath(record map[string]interface{}, path ...string) interface{} {
+	var value interface{} = record
+	for _, key := range path {
+		if list, ok := value.([]interface{}); ok {
+			if len(list) == 0 {
+				return nil
+			}
+			value = list[0]
+		}
+		m, ok := value.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		value = m[key]
+	}
+	return value
+}
+
+// geoFields: City/Country数据库记录转换为事件字段
+func geoFields(record map[string]interface{}) common.MapStr {
+	fields := common.MapStr{}
+	mapping := map[string][]string{
+		"country_iso_code": {"country", "iso_code"},
+		"country_name":     {"country", "names", "en"},
+		"region_name":      {"subdivisions", "names", "en"},
+		"city_name":        {"city", "names", "en"},
+	}
+	for key, path := range mapping {
+		if value := lookupPath(record, path...); value != nil {
+			fields[key] = value
+		}
+	}
+	lat, latOK := lookupPath(record, "location", "latitude").(float64)
+	lon, lonOK := lookupPath(record, "location", "longitude").(float64)
+	if latOK && lonOK {
+		fields["location"] = common.MapStr{"lat": lat, "lon": lon}
+	}
+	return fields
+}
+
+// Run 查询IP并写入target，IP无效或查询失败时计数并保持事件不变
+func (p *geoIP) Run(event *beat.Event) (*beat.Event, error) {
+	text, ok := getString(event, p.config.Field)
+	if !ok {
+		return event, nil
+	}
+	ip := net.ParseIP(text)
+	if ip == nil {
+		processorGeoIPFailed.Add(1)
+		return event, nil
+	}
+	fields := common.MapStr{}
+	if p.geo != nil {
+		record, err := p.geo.get().Lookup(ip)
+		if err != nil {
+			processorGeoIPFailed.Add(1)
+		} else if record != nil {
+			fields.Update(geoFields(record))
+		}
+	}
+	if p.asn != nil {
+		record, err := p.asn.get().Lookup(ip)
+		if err != nil {
+			processorGeoIPFailed.Add(1)
+		} else if record != nil {
+			if value, ok := record["autonomous_system_number"]; ok {
+				fields["asn"] = value
+			}
+			if value, ok := record["autonomous_system_organization"]; ok {
+				fields["as_org"] = value
+			}
+		}
+	}
+	if len(fields) == 0 {
+		return event, nil
+	}
+	for key, value := range fields {
+		event.Fields.Put(targetKey(p.config.Target, key), value)
+	}
+	return event, nil
+}
+
+func (p *geoIP) String() string {
+	return fmt.Sprintf("geoip=[field=%s, database=%s, asn_database=%s]",
+		p.config.Field, p.config.Database, p.config.ASNDatabase)
+}
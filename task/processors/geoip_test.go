@@ -0,0 +1,52 @@
+// Tencent is pleased to support the open source community by making bkunifylogbeat 蓝鲸日志采集器 available.
+//
+// Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+//
+// bkunifylogbeat 蓝鲸日志采集器 is licensed under the MIT License.
+//
+// License for bkunifylogbeat 蓝鲸日志采集器:
+// --------------------------------------------------------------------
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+// documentation files (the "Software"), to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software,
+// and to permit persons to whom the Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all copies or substantial
+// portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+// LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN
+// NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+// WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+// SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package processors
+
+import (
+	"testing"
+
+	"github.com/elastic/beats/libbeat/common"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestGeoFields: 测试City数据库记录转换
+func TestGeoFields(t *testing.T) {
+	record := map[string]interface{}{
+		"country":      map[string]interface{}{"iso_code": "CN", "names": map[string]interface{}{"en": "China"}},
+		"subdivisions": []interface{}{map[string]interface{}{"names": map[string]interface{}{"en": "Guangdong"}}},
+		"city":         map[string]interface{}{"names": map[string]interface{}{"en": "Shenzhen"}},
+		"location":     map[string]interface{}{"latitude": 22.5, "longitude": 114.1},
+	}
+	assert.Equal(t, common.MapStr{
+		"country_iso_code": "CN",
+		"country_name":     "China",
+		"region_name":      "Guangdong",
+		"city_name":        "Shenzhen",
+		"location":         common.MapStr{"lat": 22.5, "lon": 114.1},
+	}, geoFields(record))
+
+	_, err := newGeoIP(common.MustNewConfigFrom(map[string]interface{}{"field": "client_ip"}))
+	assert.Error(t, err)
+	_, err = newGeoIP(common.MustNewConfigFrom(map[string]interface{}{"field": "client_ip", "database": "/not/exists.mmdb"}))
+	assert.Error(t, err)
+}
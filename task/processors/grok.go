@@ -0,0 +1,102 @@
+// Tencent is pleased to support the open source community by making bkunifylogbeat 蓝鲸日志采集器 available.
+//
+// Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+//
+// bkunifylogbeat 蓝鲸日志采集器 is licensed under the MIT License.
+//
+// License for bkunifylogbeat 蓝鲸日志采集器:
+// --------------------------------------------------------------------
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+// documentation files (the "Software"), to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software,
+// and to permit persons to whom the Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all copies or substantial
+// portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+// LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN
+// NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+// WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+// SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package processors
+
+import (
+	"fmt"
+
+	"github.com/elastic/beats/libbeat/beat"
+	"github.com/elastic/beats/libbeat/common"
+	process "github.com/elastic/beats/libbeat/processors"
+)
+
+func init() {
+	MustRegister("grok", newGrok)
+}
+
+// grokConfig: grok处理配置，patterns按顺序匹配，第一个匹配的模式生效
+type grokConfig struct {
+	Field              string            `config:"field"`
+	Patterns           []string          `config:"patterns"`
+	PatternDefinitions map[string]string `config:"pattern_definitions"`
+	// 捕获的字段放在target下，为空时放在事件根节点
+	Target string `config:"target"`
+	// 字段不存在时不报错
+	IgnoreMissing bool `config:"ignore_missing"`
+	// 所有模式都不匹配时不报错
+	IgnoreFailure bool `config:"ignore_failure"`
+}
+
+// grok: 按grok模式解析字段，命名捕获组输出为结构化字段
+type grok struct {
+	config   grokConfig
+	patterns []*grokPattern
+}
+
+func newGrok(c *common.Config) (process.Processor, error) {
+	config := grokConfig{Field: defaultField}
+	if err := c.Unpack(&config); err != nil {
+		return nil, fmt.Errorf("fail to unpack the grok configuration: %v", err)
+	}
+	if len(config.Patterns) == 0 {
+		return nil, fmt.Errorf("grok patterns is required")
+	}
+	p := &grok{config: config}
+	for _, pattern := range config.Patterns {
+		compiled, err := compileGrok(pattern, config.PatternDefinitions)
+		if err != nil {
+			return nil, err
+		}
+		p.patterns = append(p.patterns, compiled)
+	}
+	return p, nil
+}
+
+// Run 按顺序尝试各模式，将第一个匹配模式的捕获结果写入事件
+func (p *grok) Run(event *beat.Event) (*beat.Event, error) {
+	text, ok := getString(event, p.config.Field)
+	if !ok {
+		if p.config.IgnoreMissing {
+			return event, nil
+		}
+		return event, fmt.Errorf("grok field(%s) is missing or not a string", p.config.Field)
+	}
+	for _, pattern := range p.patterns {
+		fields, matched := pattern.match(text)
+		if !matched {
+			continue
+		}
+		for key, value := range fields {
+			event.Fields.Put(targetKey(p.config.Target, key), value)
+		}
+		return event, nil
+	}
+	if p.config.IgnoreFailure {
+		return event, nil
+	}
+	return event, fmt.Errorf("grok field(%s) does not match any pattern", p.config.Field)
+}
+
+func (p *grok) String() string {
+	return fmt.Sprintf("grok=[field=%s, patterns=%v]", p.config.Field, p.config.Patterns)
+}
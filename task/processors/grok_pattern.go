@@ -0,0 +1,192 @@
+// Tencent is pleased to support the open source community by making bkunifylogbeat 蓝鲸日志采集器 available.
+//
+// Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+//
+// bkunifylogbeat 蓝鲸日志采集器 is licensed under the MIT License.
+//
+// License for bkunifylogbeat 蓝鲸日志采集器:
+// --------------------------------------------------------------------
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+// documentation files (the "Software"), to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software,
+// and to permit persons to whom the Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all copies or substantial
+// portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+// LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN
+// NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+// WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+// SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package processors
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+)
+
+// grokBuiltins: 内置grok模式，参考logstash模式库，改写为RE2语法(不支持环视)
+// 包含通用模式及nginx(NGINXACCESS)、syslog(SYSLOGLINE)、java(JAVALOG、JAVASTACKTRACEPART)日志模式
+var grokBuiltins = map[string]string{
+	"USERNAME":          `[a-zA-Z0-9._-]+`,
+	"USER":              `%{USERNAME}`,
+	"INT":               `[+-]?[0-9]+`,
+	"BASE10NUM":         `[+-]?(?:[0-9]+(?:\.[0-9]+)?|\.[0-9]+)`,
+	"NUMBER":            `%{BASE10NUM}`,
+	"POSINT":            `[1-9][0-9]*`,
+	"NONNEGINT":         `[0-9]+`,
+	"WORD":              `\b\w+\b`,
+	"NOTSPACE":          `\S+`,
+	"SPACE":             `\s*`,
+	"DATA":              `.*?`,
+	"GREEDYDATA":        `.*`,
+	"QUOTEDSTRING":      `"(?:[^"\\]|\\.)*"|'(?:[^'\\]|\\.)*'`,
+	"QS":                `%{QUOTEDSTRING}`,
+	"UUID":              `[A-Fa-f0-9]{8}-(?:[A-Fa-f0-9]{4}-){3}[A-Fa-f0-9]{12}`,
+	"IPV4":              `(?:(?:25[0-5]|2[0-4][0-9]|1?[0-9]?[0-9])\.){3}(?:25[0-5]|2[0-4][0-9]|1?[0-9]?[0-9])`,
+	"IPV6":              `(?:[0-9A-Fa-f]{0,4}:){2,7}[0-9A-Fa-f]{0,4}`,
+	"IP":                `%{IPV6}|%{IPV4}`,
+	"HOSTNAME":          `\b[0-9A-Za-z][0-9A-Za-z-]{0,62}(?:\.[0-9A-Za-z][0-9A-Za-z-]{0,62})*\.?\b`,
+	"IPORHOST":          `%{IP}|%{HOSTNAME}`,
+	"HOSTPORT":          `%{IPORHOST}:%{POSINT}`,
+	"PATH":              `(?:/[^/\s]*)+`,
+	"URIPATHPARAM":      `/[^\s?#]*(?:\?[^\s#]*)?`,
+	"MONTH":             `\b(?:[Jj]an(?:uary)?|[Ff]eb(?:ruary)?|[Mm]ar(?:ch)?|[Aa]pr(?:il)?|[Mm]ay|[Jj]une?|[Jj]uly?|[Aa]ug(?:ust)?|[Ss]ep(?:tember)?|[Oo]ct(?:ober)?|[Nn]ov(?:ember)?|[Dd]ec(?:ember)?)\b`,
+	"MONTHNUM":          `0?[1-9]|1[0-2]`,
+	"MONTHDAY":          `0[1-9]|[12][0-9]|3[01]|[1-9]`,
+	"YEAR":              `\d\d(?:\d\d)?`,
+	"HOUR":              `2[0123]|[01]?[0-9]`,
+	"MINUTE":            `[0-5][0-9]`,
+	"SECOND":            `(?:[0-5]?[0-9]|60)(?:[:.,][0-9]+)?`,
+	"TIME":              `%{HOUR}:%{MINUTE}:%{SECOND}`,
+	"ISO8601_TIMEZONE":  `Z|[+-]%{HOUR}(?::?%{MINUTE})`,
+	"TIMESTAMP_ISO8601": `%{YEAR}-%{MONTHNUM}-%{MONTHDAY}[T ]%{HOUR}:?%{MINUTE}(?::?%{SECOND})?%{ISO8601_TIMEZONE}?`,
+	"HTTPDATE":          `%{MONTHDAY}/%{MONTH}/%{YEAR}:%{TIME} %{INT}`,
+	"LOGLEVEL":          `[Aa]lert|ALERT|[Tt]race|TRACE|[Dd]ebug|DEBUG|[Nn]otice|NOTICE|[Ii]nfo|INFO|[Ww]arn(?:ing)?|WARN(?:ING)?|[Ee]rr(?:or)?|ERR(?:OR)?|[Cc]rit(?:ical)?|CRIT(?:ICAL)?|[Ff]atal|FATAL|[Ss]evere|SEVERE|EMERG(?:ENCY)?|[Ee]merg(?:ency)?`,
+
+	// nginx默认的combined格式
+	"NGINXACCESS": `%{IPORHOST:source.address} - %{DATA:user.name} \[%{HTTPDATE:timestamp}\] "(?:%{WORD:http.request.method} %{DATA:url.original} HTTP/%{NUMBER:http.version}|%{DATA})" %{INT:http.response.status_code:int} (?:%{INT:http.response.body.bytes:int}|-)(?: "%{DATA:http.request.referrer}" "%{DATA:user_agent.original}")?`,
+
+	// syslog(RFC3164)
+	"SYSLOGTIMESTAMP": `%{MONTH} +%{MONTHDAY} %{TIME}`,
+	"SYSLOGHOST":      `%{IPORHOST}`,
+	"PROG":            `[\x21-\x5a\x5c\x5e-\x7e]+`,
+	"SYSLOGPROG":      `%{PROG:process.name}(?:\[%{POSINT:process.pid:int}\])?`,
+	"SYSLOGLINE":      `(?:<%{NONNEGINT:log.syslog.priority:int}>)?%{SYSLOGTIMESTAMP:timestamp} %{SYSLOGHOST:host.hostname} %{SYSLOGPROG}: %{GREEDYDATA:message}`,
+
+	// java应用日志及异常堆栈
+	"JAVACLASS":          `(?:[a-zA-Z$_][a-zA-Z$_0-9]*\.)*[a-zA-Z$_][a-zA-Z$_0-9]*`,
+	"JAVATHREAD":         `[^\]]+`,
+	"JAVALOG":            `%{TIMESTAMP_ISO8601:timestamp}\s+%{LOGLEVEL:log.level}\s+(?:\[%{JAVATHREAD:process.thread.name}\]\s+)?%{JAVACLASS:log.logger}\s*[-:]?\s*%{GREEDYDATA:message}`,
+	"JAVASTACKTRACEPART": `\s*at %{JAVACLASS:java.class}\.%{WORD:java.method}\(%{DATA:java.file}(?::%{INT:java.line:int})?\)`,
+}
+
+// grokReference: %{NAME}、%{NAME:field}或%{NAME:field:type}，type支持int、float
+var grokReference = regexp.MustCompile(`%\{(\w+)(?::([\w.@-]+))?(?::(int|float))?\}`)
+
+// grokMaxDepth: 模式展开的最大嵌套层数，避免模式之间循环引用
+const grokMaxDepth = 32
+
+// grokField: 捕获组对应的字段
+type grokField struct {
+	name string
+	kind string
+}
+
+// grokPattern: 编译后的grok模式
+type grokPattern struct {
+	re     *regexp.Regexp
+	fields map[int]grokField // 捕获组下标 => 字段
+}
+
+// compileGrok: 展开模式引用并编译为正则，definitions中的自定义模式优先于内置模式
+func compileGrok(pattern string, definitions map[string]string) (*grokPattern, error) {
+	names := make(map[string]grokField)
+	expanded, err := expandGrok(pattern, definitions, names, 0)
+	if err != nil {
+		return nil, err
+	}
+	re, err := regexp.Compile(expanded)
+	if err != nil {
+		return nil, fmt.Errorf("grok pattern(%s) compile failed, err=>%v", pattern, err)
+	}
+	compiled := &grokPattern{re: re, fields: make(map[int]grokField)}
+	for i, name := range re.SubexpNames() {
+		if name == "" {
+			continue
+		}
+		// 正则中直接使用的命名捕获组按组名作为字段
+		field, ok := names[name]
+		if !ok {
+			field = grokField{name: name}
+		}
+		compiled.fields[i] = field
+	}
+	return compiled, nil
+}
+
+// expandGrok: 递归展开模式引用，带字段名的引用转换为命名捕获组
+func expandGrok(pattern string, definitions map[string]string, names map[string]grokField, depth int) (string, error) {
+	if depth > grokMaxDepth {
+		return "", fmt.Errorf("grok pattern nested too deep, pattern=>%s", pattern)
+	}
+	var err error
+	expanded := grokReference.ReplaceAllStringFunc(pattern, func(ref string) string {
+		if err != nil {
+			return ""
+		}
+		parts := grokReference.FindStringSubmatch(ref)
+		definition, ok := definitions[parts[1]]
+		if !ok {
+			definition, ok = grokBuiltins[parts[1]]
+		}
+		if !ok {
+			err = fmt.Errorf("grok pattern(%s) is not defined", parts[1])
+			return ""
+		}
+		var sub string
+		sub, err = expandGrok(definition, definitions, names, depth+1)
+		if err != nil {
+			return ""
+		}
+		if parts[2] == "" {
+			return "(?:" + sub + ")"
+		}
+		group := "grok" + strconv.Itoa(len(names))
+		names[group] = grokField{name: parts[2], kind: parts[3]}
+		return "(?P<" + group + ">" + sub + ")"
+	})
+	return expanded, err
+}
+
+// match: 匹配内容并返回字段，未匹配时返回false，空捕获组(可选部分未出现)不输出
+func (p *grokPattern) match(text string) (map[string]interface{}, bool) {
+	matches := p.re.FindStringSubmatch(text)
+	if matches == nil {
+		return nil, false
+	}
+	fields := make(map[string]interface{}, len(p.fields))
+	for i, field := range p.fields {
+		value := matches[i]
+		if value == "" {
+			continue
+		}
+		switch field.kind {
+		case "int":
+			if n, err := strconv.ParseInt(value, 10, 64); err == nil {
+				fields[field.name] = n
+				continue
+			}
+		case "float":
+			if n, err := strconv.ParseFloat(value, 64); err == nil {
+				fields[field.name] = n
+				continue
+			}
+		}
+		fields[field.name] = value
+	}
+	return fields, true
+}
@@ -0,0 +1,55 @@
+// Tencent is pleased to support the open source community by making bkunifylogbeat 蓝鲸日志采集器 available.
+//
+// Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+//
+// bkunifylogbeat 蓝鲸日志采集器 is licensed under the MIT License.
+//
+// License for bkunifylogbeat 蓝鲸日志采集器:
+// --------------------------------------------------------------------
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+// documentation files (the "Software"), to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software,
+// and to permit persons to whom the Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all copies or substantial
+// portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+// LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN
+// NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+// WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+// SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package processors
+
+import (
+	"testing"
+
+	"github.com/TencentBlueKing/bkunifylogbeat/tests"
+	"github.com/elastic/beats/libbeat/common"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestGrok: 测试grok解析内置nginx模式
+func TestGrok(t *testing.T) {
+	p, err := newGrok(common.MustNewConfigFrom(map[string]interface{}{
+		"patterns": []string{"%{SYSLOGLINE}", "%{NGINXACCESS}"},
+		"target":   "nginx",
+	}))
+	assert.NoError(t, err)
+
+	data := tests.MockLogEvent("/access.log", `127.0.0.1 - - [10/Oct/2021:13:55:36 +0800] "GET /index.html?a=1 HTTP/1.1" 200 612 "-" "curl/7.29.0"`)
+	event, err := p.Run(&data.Event)
+	assert.NoError(t, err)
+	status, _ := event.Fields.GetValue("nginx.http.response.status_code")
+	assert.Equal(t, int64(200), status)
+	method, _ := event.Fields.GetValue("nginx.http.request.method")
+	assert.Equal(t, "GET", method)
+
+	data = tests.MockLogEvent("/access.log", "not an access log")
+	_, err = p.Run(&data.Event)
+	assert.Error(t, err)
+
+	_, err = newGrok(common.MustNewConfigFrom(map[string]interface{}{"patterns": []string{"%{UNKNOWN}"}}))
+	assert.Error(t, err)
+}
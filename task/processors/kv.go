@@ -0,0 +1,140 @@
+// Tencent is pleased to support the open source community by making bkunifylogbeat 蓝鲸日志采集器 available.
+//
+// Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+//
+// bkunifylogbeat 蓝鲸日志采集器 is licensed under the MIT License.
+//
+// License for bkunifylogbeat 蓝鲸日志采集器:
+// --------------------------------------------------------------------
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+// documentation files (the "Software"), to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software,
+// and to permit persons to whom the Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all copies or substantial
+// portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+// LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN
+// NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+// WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+// SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package processors
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/elastic/beats/libbeat/beat"
+	"github.com/elastic/beats/libbeat/common"
+	process "github.com/elastic/beats/libbeat/processors"
+)
+
+func init() {
+	MustRegister("kv", newKV)
+}
+
+// kvConfig: key=value解析配置
+type kvConfig struct {
+	Field  string `config:"field"`
+	Target string `config:"target"`
+	// 键值对之间的分隔符，默认为空格
+	FieldSplit string `config:"field_split"`
+	// 键与值之间的分隔符，默认为=
+	ValueSplit string `config:"value_split"`
+	// 值的引号字符，被引号包围的值可以包含分隔符，引号内支持\转义，默认为"和'
+	Quotes string `config:"quotes"`
+}
+
+// kv: 将key=value key2="v 2"形式的内容解析为字段
+type kv struct {
+	config kvConfig
+}
+
+func newKV(c *common.Config) (process.Processor, error) {
+	config := kvConfig{Field: defaultField, FieldSplit: " ", ValueSplit: "=", Quotes: `"'`}
+	if err := c.Unpack(&config); err != nil {
+		return nil, fmt.Errorf("fail to unpack the kv configuration: %v", err)
+	}
+	if config.FieldSplit == "" || config.ValueSplit == "" {
+		return nil, fmt.Errorf("kv field_split and value_split can not be empty")
+	}
+	return &kv{config: config}, nil
+}
+
+// readQuoted 读取引号包围的值，返回去除引号及转义后的值与剩余内容，引号未闭合时取到结尾
+func readQuoted(text string, quote byte) (string, string) {
+	var b strings.Builder
+	for i := 1; i < len(text); i++ {
+		switch text[i] {
+		case '\\':
+			if i+1 < len(text) {
+				i++
+			}
+			b.WriteByte(text[i])
+		case quote:
+			return b.String(), text[i+1:]
+		default:
+			b.WriteByte(text[i])
+		}
+	}
+	return b.String(), ""
+}
+
+// parse 解析所有键值对，没有值分隔符的片段会被忽略
+func (p *kv) parse(text string) common.MapStr {
+	fields := common.MapStr{}
+	for text != "" {
+		if strings.HasPrefix(text, p.config.FieldSplit) {
+			text = text[len(p.config.FieldSplit):]
+			continue
+		}
+		end := strings.Index(text, p.config.FieldSplit)
+		if end < 0 {
+			end = len(text)
+		}
+		index := strings.Index(text[:end], p.config.ValueSplit)
+		if index <= 0 {
+			text = text[end:]
+			continue
+		}
+		key := text[:index]
+		text = text[index+len(p.config.ValueSplit):]
+		var value string
+		if text != "" && strings.IndexByte(p.config.Quotes, text[0]) >= 0 {
+			value, text = readQuoted(text, text[0])
+		} else {
+			end = strings.Index(text, p.config.FieldSplit)
+			if end < 0 {
+				end = len(text)
+			}
+			value, text = text[:end], text[end:]
+		}
+		fields[key] = value
+	}
+	return fields
+}
+
+// Run 解析字段内容并写入target，键中的.不作为层级分隔
+func (p *kv) Run(event *beat.Event) (*beat.Event, error) {
+	text, ok := getString(event, p.config.Field)
+	if !ok {
+		return event, nil
+	}
+	fields := p.parse(text)
+	if len(fields) == 0 {
+		return event, nil
+	}
+	if p.config.Target == "" {
+		event.Fields.Update(fields)
+		return event, nil
+	}
+	event.Fields.Put(p.config.Target, fields)
+	return event, nil
+}
+
+func (p *kv) String() string {
+	return fmt.Sprintf("kv=[field=%s, field_split=%q, value_split=%q]",
+		p.config.Field, p.config.FieldSplit, p.config.ValueSplit)
+}
@@ -0,0 +1,55 @@
+// Tencent is pleased to support the open source community by making bkunifylogbeat 蓝鲸日志采集器 available.
+//
+// Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+//
+// bkunifylogbeat 蓝鲸日志采集器 is licensed under the MIT License.
+//
+// License for bkunifylogbeat 蓝鲸日志采集器:
+// --------------------------------------------------------------------
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+// documentation files (the "Software"), to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software,
+// and to permit persons to whom the Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all copies or substantial
+// portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+// LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN
+// NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+// WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+// SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package processors
+
+import (
+	"testing"
+
+	"github.com/TencentBlueKing/bkunifylogbeat/tests"
+	"github.com/elastic/beats/libbeat/common"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestKV: 测试键值对解析及引号处理
+func TestKV(t *testing.T) {
+	p, err := newKV(common.MustNewConfigFrom(map[string]interface{}{"target": "kv"}))
+	assert.NoError(t, err)
+
+	data := tests.MockLogEvent("/test.log", `level=info  msg="hello \"world\"" user='a b' orphan empty=`)
+	event, err := p.Run(&data.Event)
+	assert.NoError(t, err)
+	kvFields, _ := event.Fields.GetValue("kv")
+	assert.Equal(t, common.MapStr{
+		"level": "info",
+		"msg":   `hello "world"`,
+		"user":  "a b",
+		"empty": "",
+	}, kvFields)
+
+	p, err = newKV(common.MustNewConfigFrom(map[string]interface{}{"field_split": "&", "value_split": ":"}))
+	assert.NoError(t, err)
+	data = tests.MockLogEvent("/test.log", "a:1&b:2")
+	event, _ = p.Run(&data.Event)
+	assert.Equal(t, "1", event.Fields["a"])
+	assert.Equal(t, "2", event.Fields["b"])
+}
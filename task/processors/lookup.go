@@ -0,0 +1,296 @@
+// Tencent is pleased to support the open source community by making bkunifylogbeat 蓝鲸日志采集器 available.
+//
+// Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+//
+// bkunifylogbeat 蓝鲸日志采集器 is licensed under the MIT License.
+//
+// License for bkunifylogbeat 蓝鲸日志采集器:
+// --------------------------------------------------------------------
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+// documentation files (the "Software"), to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software,
+// and to permit persons to whom the Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all copies or substantial
+// portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+// LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN
+// NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+// WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+// SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package processors
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/TencentBlueKing/bkunifylogbeat/utils"
+	"github.com/TencentBlueKing/collector-go-sdk/v2/bkbeat/bkmonitoring"
+	"github.com/elastic/beats/libbeat/beat"
+	"github.com/elastic/beats/libbeat/common"
+	process "github.com/elastic/beats/libbeat/processors"
+)
+
+var (
+	processorLookupFailed   = bkmonitoring.NewInt("processor_lookup_failed")
+	processorLookupSkipped  = bkmonitoring.NewInt("processor_lookup_skipped")
+	processorLookupCacheHit = bkmonitoring.NewInt("processor_lookup_cache_hit")
+)
+
+func init() {
+	MustRegister("lookup", newLookup)
+}
+
+// lookupConfig: 外部查询配置，source为http、redis或file
+type lookupConfig struct {
+	Field  string `config:"field" validate:"required"`
+	Target string `config:"target"`
+	Source string `config:"source"`
+	// http: 查询地址，{key}替换为转义后的字段内容，返回JSON对象，404视为不存在
+	URL string `config:"url"`
+	// redis: 按KeyPrefix+字段内容执行GET，值为JSON对象时展开，否则写入target.value
+	Address   string `config:"address"`
+	Password  string `config:"password"`
+	DB        int    `config:"db"`
+	KeyPrefix string `config:"key_prefix"`
+	// file: 本地表格，csv第一行为表头、第一列为key；json为以key为键的对象
+	Path   string `config:"path"`
+	Format string `config:"format"`
+	// 单次查询超时
+	Timeout time.Duration `config:"timeout"`
+	// 查询结果(包括不存在)的缓存
+	CacheSize int           `config:"cache_size"`
+	CacheTTL  time.Duration `config:"cache_ttl"`
+	// 连续失败BreakerFailures次后熔断BreakerCooldown，期间不再查询，事件不做补充
+	BreakerFailures int           `config:"breaker_failures"`
+	BreakerCooldown time.Duration `config:"breaker_cooldown"`
+}
+
+// lookupSource: 查询来源，不存在时返回nil
+type lookupSource interface {
+	lookup(key string) (common.MapStr, error)
+}
+
+// lookupBreaker: 连续失败计数熔断器
+type lookupBreaker struct {
+	mutex     sync.Mutex
+	threshold int
+	cooldown  time.Duration
+	failures  int
+	openUntil time.Time
+	now       func() time.Time
+}
+
+// allow: 熔断期间返回false，熔断结束后允许重试
+func (b *lookupBreaker) allow() bool {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return !b.now().Before(b.openUntil)
+}
+
+// done: 记录查询结果，连续失败达到阈值时熔断
+func (b *lookupBreaker) done(err error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if err == nil {
+		b.failures = 0
+		return
+	}
+	b.failures++
+	if b.failures >= b.threshold {
+		b.failures = 0
+		b.openUntil = b.now().Add(b.cooldown)
+	}
+}
+
+// lookup: 按字段内容查询外部数据补充到事件，结果带TTL缓存，外部服务异常时熔断，避免阻塞处理链
+type lookup struct {
+	config  lookupConfig
+	source  lookupSource
+	cache   *utils.TTLCache
+	breaker *lookupBreaker
+}
+
+func newLookup(c *common.Config) (process.Processor, error) {
+	config := lookupConfig{
+		Target:          "lookup",
+		Source:          "http",
+		Format:          "csv",
+		Timeout:         200 * time.Millisecond,
+		CacheSize:       10000,
+		CacheTTL:        5 * time.Minute,
+		BreakerFailures: 5,
+		BreakerCooldown: 30 * time.Second,
+	}
+	if err := c.Unpack(&config); err != nil {
+		return nil, fmt.Errorf("fail to unpack the lookup configuration: %v", err)
+	}
+	if config.CacheSize <= 0 || config.BreakerFailures <= 0 {
+		return nil, fmt.Errorf("lookup cache_size and breaker_failures must be greater than 0")
+	}
+	p := &lookup{
+		config:  config,
+		cache:   utils.NewTTLCache(config.CacheSize, config.CacheTTL),
+		breaker: &lookupBreaker{threshold: config.BreakerFailures, cooldown: config.BreakerCooldown, now: time.Now},
+	}
+	var err error
+	switch config.Source {
+	case "http":
+		if !strings.Contains(config.URL, "{key}") {
+			return nil, fmt.Errorf("lookup url must contain {key}")
+		}
+		p.source = &httpLookupSource{url: config.URL, client: &http.Client{Timeout: config.Timeout}}
+	case "redis":
+		if config.Address == "" {
+			return nil, fmt.Errorf("lookup address is required for redis source")
+		}
+		p.source = &redisLookupSource{
+			client:    utils.NewRedisClient(config.Address, config.Password, config.DB, config.Timeout),
+			keyPrefix: config.KeyPrefix,
+		}
+	case "file":
+		p.source, err = loadTableLookupSource(config.Path, config.Format)
+		if err != nil {
+			return nil, fmt.Errorf("lookup load %s failed, err=>%v", config.Path, err)
+		}
+	default:
+		return nil, fmt.Errorf("lookup source must be http, redis or file")
+	}
+	return p, nil
+}
+
+// Run 查询字段内容并写入target，查询失败或熔断时事件保持不变
+func (p *lookup) Run(event *beat.Event) (*beat.Event, error) {
+	key, ok := getString(event, p.config.Field)
+	if !ok || key == "" {
+		return event, nil
+	}
+	var fields common.MapStr
+	if value, ok := p.cache.Get(key); ok {
+		processorLookupCacheHit.Add(1)
+		fields = value.(common.MapStr)
+	} else {
+		if !p.breaker.allow() {
+			processorLookupSkipped.Add(1)
+			return event, nil
+		}
+		var err error
+		fields, err = p.source.lookup(key)
+		p.breaker.done(err)
+		if err != nil {
+			processorLookupFailed.Add(1)
+			return event, nil
+		}
+		p.cache.Set(key, fields)
+	}
+	if fields != nil {
+		event.Fields.Put(p.config.Target, fields.Clone())
+	}
+	return event, nil
+}
+
+func (p *lookup) String() string {
+	return fmt.Sprintf("lookup=[field=%s, source=%s, target=%s]", p.config.Field, p.config.Source, p.config.Target)
+}
+
+// httpLookupSource: 通过HTTP接口查询
+type httpLookupSource struct {
+	url    string
+	client *http.Client
+}
+
+func (s *httpLookupSource) lookup(key string) (common.MapStr, error) {
+	resp, err := s.client.Get(strings.Replace(s.url, "{key}", url.QueryEscape(key), -1))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("lookup http status %d", resp.StatusCode)
+	}
+	var fields common.MapStr
+	if err = json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&fields); err != nil {
+		return nil, err
+	}
+	return fields, nil
+}
+
+// redisLookupSource: 通过Redis GET查询
+type redisLookupSource struct {
+	client    *utils.RedisClient
+	keyPrefix string
+}
+
+func (s *redisLookupSource) lookup(key string) (common.MapStr, error) {
+	reply, err := s.client.Do("GET", s.keyPrefix+key)
+	if err != nil {
+		return nil, err
+	}
+	value, ok := reply.(string)
+	if !ok {
+		return nil, nil
+	}
+	var fields common.MapStr
+	if json.Unmarshal([]byte(value), &fields) == nil {
+		return fields, nil
+	}
+	return common.MapStr{"value": value}, nil
+}
+
+// tableLookupSource: 启动时加载的本地表格
+type tableLookupSource struct {
+	rows map[string]common.MapStr
+}
+
+func loadTableLookupSource(path, format string) (*tableLookupSource, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	s := &tableLookupSource{rows: make(map[string]common.MapStr)}
+	switch format {
+	case "json":
+		var rows map[string]common.MapStr
+		if err = json.NewDecoder(f).Decode(&rows); err != nil {
+			return nil, err
+		}
+		s.rows = rows
+	case "csv":
+		records, err := csv.NewReader(f).ReadAll()
+		if err != nil {
+			return nil, err
+		}
+		if len(records) == 0 {
+			return nil, fmt.Errorf("csv header is required")
+		}
+		header := records[0]
+		for _, record := range records[1:] {
+			row := common.MapStr{}
+			for i := 1; i < len(header) && i < len(record); i++ {
+				row[header[i]] = record[i]
+			}
+			s.rows[record[0]] = row
+		}
+	default:
+		return nil, fmt.Errorf("format must be csv or json")
+	}
+	return s, nil
+}
+
+func (s *tableLookupSource) lookup(key string) (common.MapStr, error) {
+	return s.rows[key], nil
+}
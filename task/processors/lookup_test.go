@@ -0,0 +1,97 @@
+// Tencent is pleased to support the open source community by making bkunifylogbeat 蓝鲸日志采集器 available.
+//
+// Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+//
+// bkunifylogbeat 蓝鲸日志采集器 is licensed under the MIT License.
+//
+// License for bkunifylogbeat 蓝鲸日志采集器:
+// --------------------------------------------------------------------
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+// documentation files (the "Software"), to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software,
+// and to permit persons to whom the Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all copies or substantial
+// portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+// LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN
+// NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+// WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+// SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package processors
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/TencentBlueKing/bkunifylogbeat/tests"
+	"github.com/elastic/beats/libbeat/common"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestLookupFile: 测试本地CSV表格查询
+func TestLookupFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "lookup")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "hosts.csv")
+	assert.NoError(t, ioutil.WriteFile(path, []byte("ip,app,owner\n10.0.0.1,web,alice\n"), 0644))
+
+	p, err := newLookup(common.MustNewConfigFrom(map[string]interface{}{
+		"field": "data", "source": "file", "path": path, "target": "host",
+	}))
+	assert.NoError(t, err)
+
+	data := tests.MockLogEvent("/test.log", "10.0.0.1")
+	event, err := p.Run(&data.Event)
+	assert.NoError(t, err)
+	host, _ := event.Fields.GetValue("host")
+	assert.Equal(t, common.MapStr{"app": "web", "owner": "alice"}, host)
+
+	data = tests.MockLogEvent("/test.log", "10.0.0.2")
+	event, _ = p.Run(&data.Event)
+	assert.NotContains(t, event.Fields, "host")
+}
+
+// TestLookupHTTP: 测试HTTP查询的缓存及熔断
+func TestLookupHTTP(t *testing.T) {
+	requests := 0
+	fail := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if fail {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Write([]byte(`{"user":"` + r.URL.Query().Get("id") + `"}`))
+	}))
+	defer server.Close()
+
+	p, err := newLookup(common.MustNewConfigFrom(map[string]interface{}{
+		"field": "data", "url": server.URL + "/?id={key}", "breaker_failures": 2,
+	}))
+	assert.NoError(t, err)
+
+	for i := 0; i < 2; i++ {
+		data := tests.MockLogEvent("/test.log", "u1")
+		event, _ := p.Run(&data.Event)
+		user, _ := event.Fields.GetValue("lookup.user")
+		assert.Equal(t, "u1", user)
+	}
+	assert.Equal(t, 1, requests)
+
+	// 连续失败后熔断，不再请求
+	fail = true
+	for i := 0; i < 4; i++ {
+		data := tests.MockLogEvent("/test.log", "u2")
+		event, _ := p.Run(&data.Event)
+		assert.NotContains(t, event.Fields, "lookup")
+	}
+	assert.Equal(t, 3, requests)
+}
@@ -0,0 +1,172 @@
+// Tencent is pleased to support the open source community by making bkunifylogbeat 蓝鲸日志采集器 available.
+//
+// Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+//
+// bkunifylogbeat 蓝鲸日志采集器 is licensed under the MIT License.
+//
+// License for bkunifylogbeat 蓝鲸日志采集器:
+// --------------------------------------------------------------------
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+// documentation files (the "Software"), to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software,
+// and to permit persons to whom the Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all copies or substantial
+// portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+// LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN
+// NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+// WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+// SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package processors
+
+import (
+	"fmt"
+	"regexp"
+	"sync"
+
+	"github.com/TencentBlueKing/collector-go-sdk/v2/bkbeat/bkmonitoring"
+	"github.com/elastic/beats/libbeat/beat"
+	"github.com/elastic/beats/libbeat/common"
+	"github.com/elastic/beats/libbeat/monitoring"
+	process "github.com/elastic/beats/libbeat/processors"
+)
+
+var processorMaskedTotal = bkmonitoring.NewInt("processor_masked_total")
+
+var (
+	maskedCounters      = map[string]*monitoring.Int{}
+	maskedCountersMutex sync.Mutex
+	// 规则名用于拼接指标名，"."会被libbeat拆分为子registry，只允许字母、数字、下划线及中划线
+	maskRuleNamePattern = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+)
+
+// getMaskedCounter: 获取规则的脱敏计数，同名规则在所有任务间共享，避免重复注册指标
+func getMaskedCounter(name string) *monitoring.Int {
+	maskedCountersMutex.Lock()
+	defer maskedCountersMutex.Unlock()
+	if counter, ok := maskedCounters[name]; ok {
+		return counter
+	}
+	counter := bkmonitoring.NewInt("processor_masked_" + name)
+	maskedCounters[name] = counter
+	return counter
+}
+
+func init() {
+	MustRegister("mask", newMask)
+}
+
+// maskBuiltins: 内置脱敏规则，按顺序匹配，身份证号需要在银行卡号、手机号之前处理
+var maskBuiltins = map[string]maskRuleConfig{
+	"id_card":   {Pattern: `\b(\d{6})\d{8}(\d{3}[\dXx])\b`, Replacement: "$1********$2"},
+	"bank_card": {Pattern: `\b(\d{4})\d{8,11}(\d{4})\b`, Replacement: "$1********$2"},
+	"phone":     {Pattern: `\b(1[3-9]\d)\d{4}(\d{4})\b`, Replacement: "$1****$2"},
+	"email":     {Pattern: `\b([A-Za-z0-9._%+-])[A-Za-z0-9._%+-]*@([A-Za-z0-9.-]+\.[A-Za-z]{2,})\b`, Replacement: "$1***@$2"},
+	"token":     {Pattern: `(?i)\b(bearer\s+|access_token[=:]\s*|token[=:]\s*)[A-Za-z0-9._~+/=-]{8,}`, Replacement: "${1}***"},
+}
+
+// maskRuleConfig: 脱敏规则，builtin为内置规则名，否则使用pattern及replacement(支持$1形式的捕获组引用)
+type maskRuleConfig struct {
+	Name        string `config:"name"`
+	Builtin     string `config:"builtin"`
+	Pattern     string `config:"pattern"`
+	Replacement string `config:"replacement"`
+}
+
+// maskConfig: 脱敏配置，fields中的字符串字段依次应用所有规则
+type maskConfig struct {
+	Fields []string         `config:"fields"`
+	Rules  []maskRuleConfig `config:"rules"`
+}
+
+// maskRule: 编译后的脱敏规则
+type maskRule struct {
+	re          *regexp.Regexp
+	replacement string
+	masked      *monitoring.Int
+}
+
+// mask: 在事件离开主机前按正则脱敏敏感信息
+type mask struct {
+	config maskConfig
+	rules  []maskRule
+}
+
+func newMask(c *common.Config) (process.Processor, error) {
+	config := maskConfig{Fields: []string{defaultField}}
+	if err := c.Unpack(&config); err != nil {
+		return nil, fmt.Errorf("fail to unpack the mask configuration: %v", err)
+	}
+	if len(config.Rules) == 0 {
+		return nil, fmt.Errorf("mask rules is required")
+	}
+	p := &mask{config: config}
+	for i, rule := range config.Rules {
+		name := rule.Name
+		if rule.Builtin != "" {
+			builtin, ok := maskBuiltins[rule.Builtin]
+			if !ok {
+				return nil, fmt.Errorf("mask builtin rule(%s) is not supported", rule.Builtin)
+			}
+			if name == "" {
+				name = rule.Builtin
+			}
+			rule.Pattern = builtin.Pattern
+			if rule.Replacement == "" {
+				rule.Replacement = builtin.Replacement
+			}
+		}
+		if rule.Pattern == "" {
+			return nil, fmt.Errorf("mask rules[%d] requires builtin or pattern", i)
+		}
+		re, err := regexp.Compile(rule.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("mask rules[%d] pattern(%s) compile failed, err=>%v", i, rule.Pattern, err)
+		}
+		if name == "" {
+			name = fmt.Sprintf("rule%d", i)
+		}
+		// processor_masked_total已被总计数占用
+		if !maskRuleNamePattern.MatchString(name) || name == "total" {
+			return nil, fmt.Errorf("mask rules[%d] name(%s) must match %s and not be total", i, name, maskRuleNamePattern)
+		}
+		p.rules = append(p.rules, maskRule{
+			re:          re,
+			replacement: rule.Replacement,
+			masked:      getMaskedCounter(name),
+		})
+	}
+	return p, nil
+}
+
+// Run 对配置的字段依次应用脱敏规则，按规则统计脱敏次数
+func (p *mask) Run(event *beat.Event) (*beat.Event, error) {
+	for _, field := range p.config.Fields {
+		text, ok := getString(event, field)
+		if !ok {
+			continue
+		}
+		changed := false
+		for _, rule := range p.rules {
+			count := len(rule.re.FindAllStringIndex(text, -1))
+			if count == 0 {
+				continue
+			}
+			text = rule.re.ReplaceAllString(text, rule.replacement)
+			rule.masked.Add(int64(count))
+			processorMaskedTotal.Add(int64(count))
+			changed = true
+		}
+		if changed {
+			event.Fields.Put(field, text)
+		}
+	}
+	return event, nil
+}
+
+func (p *mask) String() string {
+	return fmt.Sprintf("mask=[fields=%v, rules=%d]", p.config.Fields, len(p.rules))
+}
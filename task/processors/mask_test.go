@@ -0,0 +1,74 @@
+// Tencent is pleased to support the open source community by making bkunifylogbeat 蓝鲸日志采集器 available.
+//
+// Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+//
+// bkunifylogbeat 蓝鲸日志采集器 is licensed under the MIT License.
+//
+// License for bkunifylogbeat 蓝鲸日志采集器:
+// --------------------------------------------------------------------
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+// documentation files (the "Software"), to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software,
+// and to permit persons to whom the Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all copies or substantial
+// portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+// LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN
+// NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+// WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+// SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package processors
+
+import (
+	"testing"
+
+	"github.com/TencentBlueKing/bkunifylogbeat/tests"
+	"github.com/elastic/beats/libbeat/common"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestMask: 测试内置及自定义脱敏规则
+func TestMask(t *testing.T) {
+	p, err := newMask(common.MustNewConfigFrom(map[string]interface{}{
+		"rules": []map[string]interface{}{
+			{"builtin": "id_card"},
+			{"builtin": "phone"},
+			{"builtin": "email"},
+			{"name": "password", "pattern": `password=\S+`, "replacement": "password=***"},
+		},
+	}))
+	assert.NoError(t, err)
+
+	data := tests.MockLogEvent("/test.log", "user 440301199001011234 phone 13812345678 mail alice@example.com password=secret")
+	event, err := p.Run(&data.Event)
+	assert.NoError(t, err)
+	assert.Equal(t, "user 440301********1234 phone 138****5678 mail a***@example.com password=***", event.Fields["data"])
+
+	_, err = newMask(common.MustNewConfigFrom(map[string]interface{}{
+		"rules": []map[string]interface{}{{"builtin": "unknown"}},
+	}))
+	assert.Error(t, err)
+
+	// 规则名与总计数冲突或包含"."时创建失败，而不是注册指标时panic
+	for _, name := range []string{"total", "a.b", "a b"} {
+		_, err = newMask(common.MustNewConfigFrom(map[string]interface{}{
+			"rules": []map[string]interface{}{{"name": name, "pattern": `\d+`}},
+		}))
+		assert.Error(t, err, name)
+	}
+}
+
+// TestMaskRebuild: 测试重复创建使用相同规则的处理器，计数在处理器间共享
+func TestMaskRebuild(t *testing.T) {
+	config := common.MustNewConfigFrom(map[string]interface{}{
+		"rules": []map[string]interface{}{{"builtin": "phone"}},
+	})
+	p1, err := newMask(config)
+	assert.NoError(t, err)
+	p2, err := newMask(config)
+	assert.NoError(t, err)
+	assert.Same(t, p1.(*mask).rules[0].masked, p2.(*mask).rules[0].masked)
+}
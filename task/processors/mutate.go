@@ -0,0 +1,114 @@
+// Tencent is pleased to support the open source community by making bkunifylogbeat 蓝鲸日志采集器 available.
+//
+// Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+//
+// bkunifylogbeat 蓝鲸日志采集器 is licensed under the MIT License.
+//
+// License for bkunifylogbeat 蓝鲸日志采集器:
+// --------------------------------------------------------------------
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+// documentation files (the "Software"), to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software,
+// and to permit persons to whom the Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all copies or substantial
+// portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+// LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN
+// NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+// WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+// SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package processors
+
+import (
+	"fmt"
+
+	"github.com/elastic/beats/libbeat/beat"
+	"github.com/elastic/beats/libbeat/common"
+	process "github.com/elastic/beats/libbeat/processors"
+)
+
+func init() {
+	MustRegister("mutate", newMutate)
+}
+
+// mutateFieldPair: 源字段及目标字段
+type mutateFieldPair struct {
+	From string `config:"from" validate:"required"`
+	To   string `config:"to" validate:"required"`
+}
+
+// mutateConfig: 字段变更配置，按add、rename、copy、remove的顺序执行
+type mutateConfig struct {
+	// 添加静态字段，已存在时覆盖
+	Add common.MapStr `config:"add"`
+	// 重命名字段，源字段不存在时跳过
+	Rename []mutateFieldPair `config:"rename"`
+	// 复制字段，源字段不存在时跳过
+	Copy []mutateFieldPair `config:"copy"`
+	// 删除字段
+	Remove []string `config:"remove"`
+}
+
+// mutate: 在处理链中添加、重命名、复制及删除字段
+type mutate struct {
+	config mutateConfig
+}
+
+func newMutate(c *common.Config) (process.Processor, error) {
+	config := mutateConfig{}
+	if err := c.Unpack(&config); err != nil {
+		return nil, fmt.Errorf("fail to unpack the mutate configuration: %v", err)
+	}
+	if len(config.Add) == 0 && len(config.Rename) == 0 && len(config.Copy) == 0 && len(config.Remove) == 0 {
+		return nil, fmt.Errorf("mutate requires at least one of add, rename, copy or remove")
+	}
+	return &mutate{config: config}, nil
+}
+
+// cloneValue: 复制map类型的值，避免多个字段共享同一个对象
+func cloneValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case common.MapStr:
+		return v.Clone()
+	case map[string]interface{}:
+		return common.MapStr(v).Clone()
+	}
+	return value
+}
+
+// Run 依次执行字段变更
+func (p *mutate) Run(event *beat.Event) (*beat.Event, error) {
+	if event.Fields == nil {
+		event.Fields = common.MapStr{}
+	}
+	if len(p.config.Add) > 0 {
+		event.Fields.DeepUpdate(p.config.Add.Clone())
+	}
+	for _, pair := range p.config.Rename {
+		value, err := event.Fields.GetValue(pair.From)
+		if err != nil {
+			continue
+		}
+		event.Fields.Delete(pair.From)
+		event.Fields.Put(pair.To, value)
+	}
+	for _, pair := range p.config.Copy {
+		value, err := event.Fields.GetValue(pair.From)
+		if err != nil {
+			continue
+		}
+		event.Fields.Put(pair.To, cloneValue(value))
+	}
+	for _, field := range p.config.Remove {
+		event.Fields.Delete(field)
+	}
+	return event, nil
+}
+
+func (p *mutate) String() string {
+	return fmt.Sprintf("mutate=[add=%v, rename=%v, copy=%v, remove=%v]",
+		p.config.Add, p.config.Rename, p.config.Copy, p.config.Remove)
+}
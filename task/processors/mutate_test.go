@@ -0,0 +1,55 @@
+// Tencent is pleased to support the open source community by making bkunifylogbeat 蓝鲸日志采集器 available.
+//
+// Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+//
+// bkunifylogbeat 蓝鲸日志采集器 is licensed under the MIT License.
+//
+// License for bkunifylogbeat 蓝鲸日志采集器:
+// --------------------------------------------------------------------
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+// documentation files (the "Software"), to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software,
+// and to permit persons to whom the Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all copies or substantial
+// portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+// LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN
+// NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+// WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+// SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package processors
+
+import (
+	"testing"
+
+	"github.com/TencentBlueKing/bkunifylogbeat/tests"
+	"github.com/elastic/beats/libbeat/common"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestMutate: 测试添加、重命名、复制及删除字段
+func TestMutate(t *testing.T) {
+	p, err := newMutate(common.MustNewConfigFrom(map[string]interface{}{
+		"add":    map[string]interface{}{"env": "prod", "meta": map[string]interface{}{"team": "ops"}},
+		"rename": []map[string]interface{}{{"from": "data", "to": "message"}, {"from": "missing", "to": "other"}},
+		"copy":   []map[string]interface{}{{"from": "message", "to": "raw"}},
+		"remove": []string{"meta.team"},
+	}))
+	assert.NoError(t, err)
+
+	data := tests.MockLogEvent("/test.log", "hello")
+	event, err := p.Run(&data.Event)
+	assert.NoError(t, err)
+	assert.Equal(t, common.MapStr{
+		"env":     "prod",
+		"meta":    common.MapStr{},
+		"message": "hello",
+		"raw":     "hello",
+	}, event.Fields)
+
+	_, err = newMutate(common.MustNewConfigFrom(map[string]interface{}{}))
+	assert.Error(t, err)
+}
@@ -0,0 +1,107 @@
+// Tencent is pleased to support the open source community by making bkunifylogbeat 蓝鲸日志采集器 available.
+//
+// Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+//
+// bkunifylogbeat 蓝鲸日志采集器 is licensed under the MIT License.
+//
+// License for bkunifylogbeat 蓝鲸日志采集器:
+// --------------------------------------------------------------------
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+// documentation files (the "Software"), to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software,
+// and to permit persons to whom the Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all copies or substantial
+// portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+// LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN
+// NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+// WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+// SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package processors
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/elastic/beats/libbeat/beat"
+	"github.com/elastic/beats/libbeat/common"
+	process "github.com/elastic/beats/libbeat/processors"
+)
+
+func init() {
+	MustRegister("parse_json", newParseJSON)
+}
+
+// parseJSONConfig: JSON解析配置
+type parseJSONConfig struct {
+	Field string `config:"field"`
+	// 解析结果放在target下，为空时合并到事件根节点，此时内容必须为JSON对象
+	Target string `config:"target"`
+	// 合并到根节点时是否覆盖已存在的字段
+	Overwrite bool `config:"overwrite"`
+	// 解析失败时的处理：keep(默认)保持原样，drop丢弃事件，error_field在ErrorField中记录错误
+	OnFailure  string `config:"on_failure"`
+	ErrorField string `config:"error_field"`
+}
+
+// parseJSON: 将字段内容按JSON解析为结构化字段，数字保留原始精度
+type parseJSON struct {
+	config parseJSONConfig
+}
+
+func newParseJSON(c *common.Config) (process.Processor, error) {
+	config := parseJSONConfig{Field: defaultField, OnFailure: "keep", ErrorField: "_json_error"}
+	if err := c.Unpack(&config); err != nil {
+		return nil, fmt.Errorf("fail to unpack the parse_json configuration: %v", err)
+	}
+	switch config.OnFailure {
+	case "keep", "drop", "error_field":
+	default:
+		return nil, fmt.Errorf("parse_json on_failure must be keep, drop or error_field")
+	}
+	return &parseJSON{config: config}, nil
+}
+
+// Run 解析字段内容并写入事件，失败时按on_failure处理
+func (p *parseJSON) Run(event *beat.Event) (*beat.Event, error) {
+	text, ok := getString(event, p.config.Field)
+	if !ok {
+		return event, nil
+	}
+	var value interface{}
+	decoder := json.NewDecoder(bytes.NewReader([]byte(text)))
+	decoder.UseNumber()
+	err := decoder.Decode(&value)
+	if err == nil && p.config.Target == "" {
+		if object, ok := value.(map[string]interface{}); ok {
+			for key, v := range object {
+				if _, exists := event.Fields[key]; exists && !p.config.Overwrite {
+					continue
+				}
+				event.Fields[key] = v
+			}
+			return event, nil
+		}
+		err = fmt.Errorf("json value is not an object")
+	}
+	if err == nil {
+		event.Fields.Put(p.config.Target, value)
+		return event, nil
+	}
+
+	switch p.config.OnFailure {
+	case "drop":
+		return nil, nil
+	case "error_field":
+		event.Fields.Put(p.config.ErrorField, err.Error())
+	}
+	return event, nil
+}
+
+func (p *parseJSON) String() string {
+	return fmt.Sprintf("parse_json=[field=%s, target=%s]", p.config.Field, p.config.Target)
+}
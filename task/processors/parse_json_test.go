@@ -0,0 +1,60 @@
+// Tencent is pleased to support the open source community by making bkunifylogbeat 蓝鲸日志采集器 available.
+//
+// Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+//
+// bkunifylogbeat 蓝鲸日志采集器 is licensed under the MIT License.
+//
+// License for bkunifylogbeat 蓝鲸日志采集器:
+// --------------------------------------------------------------------
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+// documentation files (the "Software"), to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software,
+// and to permit persons to whom the Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all copies or substantial
+// portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+// LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN
+// NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+// WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+// SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package processors
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/TencentBlueKing/bkunifylogbeat/tests"
+	"github.com/elastic/beats/libbeat/common"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestParseJSON: 测试JSON解析及失败处理
+func TestParseJSON(t *testing.T) {
+	p, err := newParseJSON(common.MustNewConfigFrom(map[string]interface{}{}))
+	assert.NoError(t, err)
+
+	data := tests.MockLogEvent("/test.log", `{"level":"error","data":"override","latency":12}`)
+	event, err := p.Run(&data.Event)
+	assert.NoError(t, err)
+	assert.Equal(t, "error", event.Fields["level"])
+	assert.Equal(t, json.Number("12"), event.Fields["latency"])
+	// 默认不覆盖已存在的字段
+	assert.Equal(t, `{"level":"error","data":"override","latency":12}`, event.Fields["data"])
+
+	p, err = newParseJSON(common.MustNewConfigFrom(map[string]interface{}{"target": "json", "on_failure": "error_field"}))
+	assert.NoError(t, err)
+	data = tests.MockLogEvent("/test.log", `{"level":`)
+	event, err = p.Run(&data.Event)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, event.Fields["_json_error"])
+
+	p, err = newParseJSON(common.MustNewConfigFrom(map[string]interface{}{"on_failure": "drop"}))
+	assert.NoError(t, err)
+	data = tests.MockLogEvent("/test.log", `[1, 2]`)
+	event, err = p.Run(&data.Event)
+	assert.NoError(t, err)
+	assert.Nil(t, event)
+}
@@ -0,0 +1,51 @@
+// Tencent is pleased to support the open source community by making bkunifylogbeat 蓝鲸日志采集器 available.
+//
+// Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+//
+// bkunifylogbeat 蓝鲸日志采集器 is licensed under the MIT License.
+//
+// License for bkunifylogbeat 蓝鲸日志采集器:
+// --------------------------------------------------------------------
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+// documentation files (the "Software"), to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software,
+// and to permit persons to whom the Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all copies or substantial
+// portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+// LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN
+// NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+// WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+// SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+// Package processors 采集任务使用的事件处理插件，通过libbeat processors注册，
+// 在任务配置的processors中按名称使用，在过滤之后、发送之前执行。
+// 自定义处理器通过Register注册，在include中引入所在的包即可使用
+package processors
+
+import (
+	"github.com/elastic/beats/libbeat/beat"
+)
+
+// defaultField: 未配置字段时处理的日志内容字段
+const defaultField = "data"
+
+// getString: 获取字符串字段，字段不存在或不是字符串时返回false
+func getString(event *beat.Event, field string) (string, bool) {
+	value, err := event.Fields.GetValue(field)
+	if err != nil {
+		return "", false
+	}
+	s, ok := value.(string)
+	return s, ok
+}
+
+// targetKey: 拼接目标字段名，target为空时放在事件根节点
+func targetKey(target, key string) string {
+	if target == "" {
+		return key
+	}
+	return target + "." + key
+}
@@ -0,0 +1,84 @@
+// Tencent is pleased to support the open source community by making bkunifylogbeat 蓝鲸日志采集器 available.
+//
+// Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+//
+// bkunifylogbeat 蓝鲸日志采集器 is licensed under the MIT License.
+//
+// License for bkunifylogbeat 蓝鲸日志采集器:
+// --------------------------------------------------------------------
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+// documentation files (the "Software"), to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software,
+// and to permit persons to whom the Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all copies or substantial
+// portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+// LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN
+// NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+// WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+// SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package processors
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/elastic/beats/libbeat/common"
+	process "github.com/elastic/beats/libbeat/processors"
+)
+
+// Processor 处理器接口，Run返回nil时丢弃事件
+type Processor = process.Processor
+
+// Factory 根据任务配置中处理器名称下的配置创建处理器
+type Factory func(c *common.Config) (Processor, error)
+
+var (
+	registered      = map[string]bool{}
+	registeredMutex sync.Mutex
+)
+
+// Register 注册自定义处理器，注册后可以在任务的processors及conditional_processors中按名称使用。
+// 需要在任务创建之前调用(通常在init中)，名称与已有处理器(包括libbeat内置处理器)重复时返回错误
+func Register(name string, factory Factory) (err error) {
+	if name == "" || factory == nil {
+		return fmt.Errorf("processor name and factory are required")
+	}
+	registeredMutex.Lock()
+	defer registeredMutex.Unlock()
+	if registered[name] {
+		return fmt.Errorf("processor(%s) is already registered", name)
+	}
+	// libbeat在名称重复时panic，这里转换为错误返回
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("register processor(%s) failed: %v", name, r)
+		}
+	}()
+	process.RegisterPlugin(name, process.Constructor(factory))
+	registered[name] = true
+	return nil
+}
+
+// MustRegister 注册处理器，失败时panic
+func MustRegister(name string, factory Factory) {
+	if err := Register(name, factory); err != nil {
+		panic(err)
+	}
+}
+
+// Registered 通过Register注册的处理器名称
+func Registered() []string {
+	registeredMutex.Lock()
+	defer registeredMutex.Unlock()
+	names := make([]string, 0, len(registered))
+	for name := range registered {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
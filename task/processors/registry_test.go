@@ -0,0 +1,69 @@
+// Tencent is pleased to support the open source community by making bkunifylogbeat 蓝鲸日志采集器 available.
+//
+// Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+//
+// bkunifylogbeat 蓝鲸日志采集器 is licensed under the MIT License.
+//
+// License for bkunifylogbeat 蓝鲸日志采集器:
+// --------------------------------------------------------------------
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+// documentation files (the "Software"), to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software,
+// and to permit persons to whom the Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all copies or substantial
+// portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+// LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN
+// NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+// WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+// SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package processors
+
+import (
+	"testing"
+
+	"github.com/TencentBlueKing/bkunifylogbeat/tests"
+	"github.com/elastic/beats/libbeat/beat"
+	"github.com/elastic/beats/libbeat/common"
+	process "github.com/elastic/beats/libbeat/processors"
+	"github.com/stretchr/testify/assert"
+)
+
+// customTestProcessor: 测试用的自定义处理器
+type customTestProcessor struct{}
+
+func (customTestProcessor) Run(event *beat.Event) (*beat.Event, error) {
+	event.Fields["custom"] = true
+	return event, nil
+}
+
+func (customTestProcessor) String() string {
+	return "custom_test"
+}
+
+// TestRegister: 测试注册自定义处理器并在处理链中使用
+func TestRegister(t *testing.T) {
+	err := Register("custom_test", func(c *common.Config) (Processor, error) {
+		return customTestProcessor{}, nil
+	})
+	assert.NoError(t, err)
+	assert.Contains(t, Registered(), "custom_test")
+	assert.Contains(t, Registered(), "grok")
+
+	// 名称重复或构造函数为空
+	factory := func(c *common.Config) (Processor, error) { return customTestProcessor{}, nil }
+	assert.Error(t, Register("custom_test", factory))
+	assert.Error(t, Register("grok", factory))
+	assert.Error(t, Register("custom_nil", nil))
+
+	chain, err := process.New(process.PluginConfig{
+		{"custom_test": common.NewConfig()},
+	})
+	assert.NoError(t, err)
+	data := tests.MockLogEvent("/test.log", "hello")
+	event := chain.Run(&data.Event)
+	assert.Equal(t, true, event.Fields["custom"])
+}
@@ -0,0 +1,246 @@
+// Tencent is pleased to support the open source community by making bkunifylogbeat 蓝鲸日志采集器 available.
+//
+// Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+//
+// bkunifylogbeat 蓝鲸日志采集器 is licensed under the MIT License.
+//
+// License for bkunifylogbeat 蓝鲸日志采集器:
+// --------------------------------------------------------------------
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+// documentation files (the "Software"), to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software,
+// and to permit persons to whom the Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all copies or substantial
+// portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+// LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN
+// NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+// WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+// SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package processors
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/TencentBlueKing/bkunifylogbeat/utils"
+	"github.com/TencentBlueKing/collector-go-sdk/v2/bkbeat/bkmonitoring"
+	"github.com/elastic/beats/libbeat/beat"
+	"github.com/elastic/beats/libbeat/common"
+	process "github.com/elastic/beats/libbeat/processors"
+)
+
+var processorScriptTimeout = bkmonitoring.NewInt("processor_script_timeout")
+
+func init() {
+	MustRegister("script", newScript)
+}
+
+// 脚本按行解析，空行及#开头的行被忽略，每行一条语句：
+//
+//	set 字段 = 表达式    表达式语法见utils.CompileValueExpr，结果为字符串、数字或布尔值
+//	del 字段             删除字段
+//	drop                 丢弃事件，之后的语句不再执行
+//	if 条件 then 语句    条件为布尔表达式，满足时执行then之后的单条语句
+//
+// 表达式中line为执行前的字段内容，col(n)为按delimiter切分后的第n列，field("字段名")为任意字段的当前内容。
+// 脚本没有循环，执行的语句数不超过max_statements，单个事件执行超过timeout时中止剩余语句并返回错误
+// 例如：
+//
+//	if col(3) == "DEBUG" then drop
+//	set level = strings.ToLower(col(3))
+//	if strings.Contains(line, "timeout") then set tags.timeout = true
+
+// scriptConfig: 脚本配置
+type scriptConfig struct {
+	Field         string        `config:"field"`
+	Delimiter     string        `config:"delimiter"`
+	Source        string        `config:"source" validate:"required"`
+	MaxStatements int           `config:"max_statements"`
+	Timeout       time.Duration `config:"timeout"`
+}
+
+// scriptStatement: 编译后的语句
+type scriptStatement struct {
+	cond  *utils.Expr
+	op    string
+	field string
+	value *utils.Expr
+	then  *scriptStatement
+}
+
+// script: 执行用户脚本处理事件，脚本在创建时编译一次，任务内复用
+type script struct {
+	config     scriptConfig
+	statements []*scriptStatement
+}
+
+func newScript(c *common.Config) (process.Processor, error) {
+	config := scriptConfig{Field: defaultField, Delimiter: " ", MaxStatements: 100, Timeout: 10 * time.Millisecond}
+	if err := c.Unpack(&config); err != nil {
+		return nil, fmt.Errorf("fail to unpack the script configuration: %v", err)
+	}
+	p := &script{config: config}
+	for i, text := range strings.Split(config.Source, "\n") {
+		text = strings.TrimSpace(text)
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		statement, err := compileStatement(text)
+		if err != nil {
+			return nil, fmt.Errorf("script line %d is not valid, err=>%v", i+1, err)
+		}
+		p.statements = append(p.statements, statement)
+	}
+	if len(p.statements) > config.MaxStatements {
+		return nil, fmt.Errorf("script has %d statements, exceeds max_statements(%d)", len(p.statements), config.MaxStatements)
+	}
+	return p, nil
+}
+
+// splitThen: 查找引号之外的" then "
+func splitThen(text string) (string, string, bool) {
+	var quote byte
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		switch {
+		case quote != 0:
+			if c == '\\' && quote == '"' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '`':
+			quote = c
+		case strings.HasPrefix(text[i:], " then "):
+			return text[:i], text[i+len(" then "):], true
+		}
+	}
+	return "", "", false
+}
+
+// compileStatement: 编译单条语句
+func compileStatement(text string) (*scriptStatement, error) {
+	keyword := text
+	rest := ""
+	if index := strings.IndexByte(text, ' '); index >= 0 {
+		keyword, rest = text[:index], strings.TrimSpace(text[index+1:])
+	}
+	switch keyword {
+	case "drop":
+		if rest != "" {
+			return nil, fmt.Errorf("drop takes no arguments")
+		}
+		return &scriptStatement{op: keyword}, nil
+	case "del":
+		if rest == "" || strings.ContainsAny(rest, " \t") {
+			return nil, fmt.Errorf("del expects a field name")
+		}
+		return &scriptStatement{op: keyword, field: rest}, nil
+	case "set":
+		index := strings.Index(rest, "=")
+		if index <= 0 {
+			return nil, fmt.Errorf("set expects 'set field = expr'")
+		}
+		field := strings.TrimSpace(rest[:index])
+		value, err := utils.CompileValueExpr(strings.TrimSpace(rest[index+1:]))
+		if err != nil {
+			return nil, err
+		}
+		return &scriptStatement{op: keyword, field: field, value: value}, nil
+	case "if":
+		cond, then, ok := splitThen(rest)
+		if !ok {
+			return nil, fmt.Errorf("if expects 'if cond then statement'")
+		}
+		expr, err := utils.CompileExpr(cond)
+		if err != nil {
+			return nil, err
+		}
+		statement, err := compileStatement(strings.TrimSpace(then))
+		if err != nil {
+			return nil, err
+		}
+		return &scriptStatement{op: keyword, cond: expr, then: statement}, nil
+	}
+	return nil, fmt.Errorf("unknown statement %q", keyword)
+}
+
+// scriptEnv: 表达式求值时的事件内容，列在首次使用时切分
+type scriptEnv struct {
+	event     *beat.Event
+	line      string
+	delimiter string
+	columns   []string
+}
+
+func (e *scriptEnv) Line() string {
+	return e.line
+}
+
+func (e *scriptEnv) Column(index int) string {
+	if e.columns == nil {
+		e.columns = strings.Split(e.line, e.delimiter)
+	}
+	if index > len(e.columns) {
+		return ""
+	}
+	return e.columns[index-1]
+}
+
+func (e *scriptEnv) Field(name string) string {
+	value, err := e.event.Fields.GetValue(name)
+	if err != nil {
+		return ""
+	}
+	switch v := value.(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	return fmt.Sprint(value)
+}
+
+// exec 执行语句，返回false时丢弃事件
+func (s *scriptStatement) exec(env *scriptEnv) bool {
+	switch s.op {
+	case "drop":
+		return false
+	case "del":
+		env.event.Fields.Delete(s.field)
+	case "set":
+		env.event.Fields.Put(s.field, s.value.Value(env))
+	case "if":
+		if s.cond.Eval(env) {
+			return s.then.exec(env)
+		}
+	}
+	return true
+}
+
+// Run 依次执行语句，执行超时时中止并返回错误，事件保持已执行语句的结果
+func (p *script) Run(event *beat.Event) (*beat.Event, error) {
+	line, _ := getString(event, p.config.Field)
+	env := &scriptEnv{event: event, line: line, delimiter: p.config.Delimiter}
+	start := time.Now()
+	for _, statement := range p.statements {
+		if time.Since(start) > p.config.Timeout {
+			processorScriptTimeout.Add(1)
+			return event, fmt.Errorf("script execution exceeds timeout(%s)", p.config.Timeout)
+		}
+		if !statement.exec(env) {
+			return nil, nil
+		}
+	}
+	return event, nil
+}
+
+func (p *script) String() string {
+	return fmt.Sprintf("script=[field=%s, statements=%d]", p.config.Field, len(p.statements))
+}
@@ -0,0 +1,68 @@
+// Tencent is pleased to support the open source community by making bkunifylogbeat 蓝鲸日志采集器 available.
+//
+// Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+//
+// bkunifylogbeat 蓝鲸日志采集器 is licensed under the MIT License.
+//
+// License for bkunifylogbeat 蓝鲸日志采集器:
+// --------------------------------------------------------------------
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+// documentation files (the "Software"), to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software,
+// and to permit persons to whom the Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all copies or substantial
+// portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+// LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN
+// NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+// WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+// SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package processors
+
+import (
+	"testing"
+
+	"github.com/TencentBlueKing/bkunifylogbeat/tests"
+	"github.com/elastic/beats/libbeat/common"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestScript: 测试脚本语句执行及编译错误
+func TestScript(t *testing.T) {
+	p, err := newScript(common.MustNewConfigFrom(map[string]interface{}{
+		"delimiter": "|",
+		"source": `
+# 丢弃调试日志
+if col(2) == "DEBUG" then drop
+set level = strings.ToLower(col(2))
+set summary = concat(field("level"), ": ", col(3))
+if strings.Contains(line, "timeout then") then set tags.timeout = true
+del data
+`,
+	}))
+	assert.NoError(t, err)
+
+	data := tests.MockLogEvent("/test.log", "2020|ERROR|request timeout then retry")
+	event, err := p.Run(&data.Event)
+	assert.NoError(t, err)
+	assert.Equal(t, common.MapStr{
+		"level":   "error",
+		"summary": "error: request timeout then retry",
+		"tags":    common.MapStr{"timeout": true},
+	}, event.Fields)
+
+	data = tests.MockLogEvent("/test.log", "2020|DEBUG|noise")
+	event, err = p.Run(&data.Event)
+	assert.NoError(t, err)
+	assert.Nil(t, event)
+
+	for _, source := range []string{"loop forever", "set = 1", `if col(1) then drop`, "if true drop"} {
+		_, err = newScript(common.MustNewConfigFrom(map[string]interface{}{"source": source}))
+		assert.Error(t, err, source)
+	}
+	_, err = newScript(common.MustNewConfigFrom(map[string]interface{}{"source": "drop\ndrop", "max_statements": 1}))
+	assert.Error(t, err)
+}
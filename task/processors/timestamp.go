@@ -0,0 +1,186 @@
+// Tencent is pleased to support the open source community by making bkunifylogbeat 蓝鲸日志采集器 available.
+//
+// Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+//
+// bkunifylogbeat 蓝鲸日志采集器 is licensed under the MIT License.
+//
+// License for bkunifylogbeat 蓝鲸日志采集器:
+// --------------------------------------------------------------------
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+// documentation files (the "Software"), to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software,
+// and to permit persons to whom the Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all copies or substantial
+// portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+// LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN
+// NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+// WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+// SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package processors
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/TencentBlueKing/collector-go-sdk/v2/bkbeat/bkmonitoring"
+	"github.com/elastic/beats/libbeat/beat"
+	"github.com/elastic/beats/libbeat/common"
+	process "github.com/elastic/beats/libbeat/processors"
+)
+
+var processorTimestampFailed = bkmonitoring.NewInt("processor_timestamp_failed")
+
+func init() {
+	MustRegister("parse_timestamp", newParseTimestamp)
+}
+
+// strptimeLayouts: strptime格式符与Go时间格式的对应关系
+var strptimeLayouts = map[byte]string{
+	'Y': "2006", 'y': "06", 'm': "01", 'd': "02", 'e': "_2", 'j': "002",
+	'H': "15", 'I': "03", 'M': "04", 'S': "05", 'f': "000000", 'p': "PM",
+	'b': "Jan", 'h': "Jan", 'B': "January", 'a': "Mon", 'A': "Monday",
+	'z': "-0700", 'Z': "MST", 'T': "15:04:05", 'F': "2006-01-02", '%': "%",
+}
+
+// strptimeToLayout: 将strptime格式转换为Go时间格式
+func strptimeToLayout(format string) (string, error) {
+	var b strings.Builder
+	for i := 0; i < len(format); i++ {
+		if format[i] != '%' {
+			b.WriteByte(format[i])
+			continue
+		}
+		i++
+		if i >= len(format) {
+			return "", fmt.Errorf("strptime format(%s) ends with %%", format)
+		}
+		layout, ok := strptimeLayouts[format[i]]
+		if !ok {
+			return "", fmt.Errorf("strptime format(%s) directive %%%c is not supported", format, format[i])
+		}
+		b.WriteString(layout)
+	}
+	return b.String(), nil
+}
+
+// parseTimestampConfig: 时间解析配置
+type parseTimestampConfig struct {
+	Field string `config:"field"`
+	// 从字段中提取时间的正则，存在捕获组时取第一个捕获组，为空时使用整个字段内容
+	Pattern string `config:"pattern"`
+	// 依次尝试的时间格式，支持Go时间格式、包含%的strptime格式以及UNIX、UNIX_MS
+	Layouts []string `config:"layouts"`
+	// 时间内容不带时区时使用的时区，默认为本地时区
+	Timezone string `config:"timezone"`
+}
+
+// parseTimestamp: 从日志内容中提取事件时间，解析失败时保留采集时间
+type parseTimestamp struct {
+	config   parseTimestampConfig
+	pattern  *regexp.Regexp
+	layouts  []string
+	location *time.Location
+}
+
+func newParseTimestamp(c *common.Config) (process.Processor, error) {
+	config := parseTimestampConfig{Field: defaultField}
+	if err := c.Unpack(&config); err != nil {
+		return nil, fmt.Errorf("fail to unpack the parse_timestamp configuration: %v", err)
+	}
+	if len(config.Layouts) == 0 {
+		return nil, fmt.Errorf("parse_timestamp layouts is required")
+	}
+	p := &parseTimestamp{config: config, location: time.Local}
+	if config.Pattern != "" {
+		re, err := regexp.Compile(config.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("parse_timestamp pattern(%s) compile failed, err=>%v", config.Pattern, err)
+		}
+		p.pattern = re
+	}
+	if config.Timezone != "" {
+		location, err := time.LoadLocation(config.Timezone)
+		if err != nil {
+			return nil, fmt.Errorf("parse_timestamp timezone(%s) is invalid, err=>%v", config.Timezone, err)
+		}
+		p.location = location
+	}
+	for _, layout := range config.Layouts {
+		if strings.Contains(layout, "%") {
+			converted, err := strptimeToLayout(layout)
+			if err != nil {
+				return nil, err
+			}
+			layout = converted
+		}
+		p.layouts = append(p.layouts, layout)
+	}
+	return p, nil
+}
+
+// extract 获取待解析的时间内容
+func (p *parseTimestamp) extract(text string) (string, bool) {
+	if p.pattern == nil {
+		return strings.TrimSpace(text), true
+	}
+	match := p.pattern.FindStringSubmatch(text)
+	if match == nil {
+		return "", false
+	}
+	if len(match) > 1 {
+		return match[1], true
+	}
+	return match[0], true
+}
+
+// parse 依次尝试所有时间格式
+func (p *parseTimestamp) parse(value string) (time.Time, bool) {
+	for _, layout := range p.layouts {
+		switch layout {
+		case "UNIX", "UNIX_MS":
+			n, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				continue
+			}
+			if layout == "UNIX_MS" {
+				return time.Unix(0, int64(n*float64(time.Millisecond))), true
+			}
+			return time.Unix(0, int64(n*float64(time.Second))), true
+		default:
+			t, err := time.ParseInLocation(layout, value, p.location)
+			if err == nil {
+				return t, true
+			}
+		}
+	}
+	return time.Time{}, false
+}
+
+// Run 解析成功时设置event.Timestamp，失败时保留采集时间并计数
+func (p *parseTimestamp) Run(event *beat.Event) (*beat.Event, error) {
+	text, ok := getString(event, p.config.Field)
+	if !ok {
+		return event, nil
+	}
+	value, ok := p.extract(text)
+	if ok {
+		var t time.Time
+		if t, ok = p.parse(value); ok {
+			event.Timestamp = t
+			return event, nil
+		}
+	}
+	processorTimestampFailed.Add(1)
+	return event, nil
+}
+
+func (p *parseTimestamp) String() string {
+	return fmt.Sprintf("parse_timestamp=[field=%s, layouts=%v]", p.config.Field, p.config.Layouts)
+}
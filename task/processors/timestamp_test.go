@@ -0,0 +1,59 @@
+// Tencent is pleased to support the open source community by making bkunifylogbeat 蓝鲸日志采集器 available.
+//
+// Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+//
+// bkunifylogbeat 蓝鲸日志采集器 is licensed under the MIT License.
+//
+// License for bkunifylogbeat 蓝鲸日志采集器:
+// --------------------------------------------------------------------
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+// documentation files (the "Software"), to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software,
+// and to permit persons to whom the Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all copies or substantial
+// portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+// LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN
+// NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+// WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+// SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package processors
+
+import (
+	"testing"
+	"time"
+
+	"github.com/TencentBlueKing/bkunifylogbeat/tests"
+	"github.com/elastic/beats/libbeat/common"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestParseTimestamp: 测试按格式列表提取事件时间及失败回退
+func TestParseTimestamp(t *testing.T) {
+	p, err := newParseTimestamp(common.MustNewConfigFrom(map[string]interface{}{
+		"pattern":  `^\[([^\]]+)\]`,
+		"layouts":  []string{time.RFC3339, "%Y-%m-%d %H:%M:%S.%f"},
+		"timezone": "UTC",
+	}))
+	assert.NoError(t, err)
+
+	data := tests.MockLogEvent("/test.log", "[2020-01-02 03:04:05.123456] INFO started")
+	event, err := p.Run(&data.Event)
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2020, 1, 2, 3, 4, 5, 123456000, time.UTC), event.Timestamp)
+
+	data = tests.MockLogEvent("/test.log", "[2020-01-02T03:04:05+08:00] INFO started")
+	event, _ = p.Run(&data.Event)
+	assert.Equal(t, int64(1577905445), event.Timestamp.Unix())
+
+	data = tests.MockLogEvent("/test.log", "[yesterday] INFO started")
+	readTime := data.Event.Timestamp
+	event, _ = p.Run(&data.Event)
+	assert.Equal(t, readTime, event.Timestamp)
+
+	_, err = newParseTimestamp(common.MustNewConfigFrom(map[string]interface{}{"layouts": []string{"%Q"}}))
+	assert.Error(t, err)
+}
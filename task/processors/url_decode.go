@@ -0,0 +1,106 @@
+// Tencent is pleased to support the open source community by making bkunifylogbeat 蓝鲸日志采集器 available.
+//
+// Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+//
+// bkunifylogbeat 蓝鲸日志采集器 is licensed under the MIT License.
+//
+// License for bkunifylogbeat 蓝鲸日志采集器:
+// --------------------------------------------------------------------
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+// documentation files (the "Software"), to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software,
+// and to permit persons to whom the Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all copies or substantial
+// portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+// LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN
+// NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+// WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+// SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package processors
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/TencentBlueKing/collector-go-sdk/v2/bkbeat/bkmonitoring"
+	"github.com/elastic/beats/libbeat/beat"
+	"github.com/elastic/beats/libbeat/common"
+	process "github.com/elastic/beats/libbeat/processors"
+)
+
+var processorURLDecodeFailed = bkmonitoring.NewInt("processor_url_decode_failed")
+
+func init() {
+	MustRegister("url_decode", newURLDecode)
+}
+
+// urlDecodeConfig: URL解码配置
+type urlDecodeConfig struct {
+	Field string `config:"field"`
+	// 解码结果写入的字段，为空时替换原字段
+	Target string `config:"target"`
+	// 是否将?之后的查询参数解析到QueryTarget下，同名参数出现多次时为数组
+	ParseQuery  bool   `config:"parse_query"`
+	QueryTarget string `config:"query_target"`
+}
+
+// urlDecode: 对字段做URL解码，并可解析查询参数
+type urlDecode struct {
+	config urlDecodeConfig
+}
+
+func newURLDecode(c *common.Config) (process.Processor, error) {
+	config := urlDecodeConfig{Field: defaultField, QueryTarget: "query"}
+	if err := c.Unpack(&config); err != nil {
+		return nil, fmt.Errorf("fail to unpack the url_decode configuration: %v", err)
+	}
+	return &urlDecode{config: config}, nil
+}
+
+// Run 解码字段内容，包含非法转义时保持原样并计数；+不作为空格处理，查询参数中的+按空格解码
+func (p *urlDecode) Run(event *beat.Event) (*beat.Event, error) {
+	text, ok := getString(event, p.config.Field)
+	if !ok {
+		return event, nil
+	}
+	if p.config.ParseQuery {
+		if index := strings.IndexByte(text, '?'); index >= 0 {
+			values, err := url.ParseQuery(text[index+1:])
+			if err != nil {
+				processorURLDecodeFailed.Add(1)
+			}
+			// 解析失败时仍保留可以解析的参数
+			query := common.MapStr{}
+			for key, value := range values {
+				if len(value) == 1 {
+					query[key] = value[0]
+				} else {
+					query[key] = value
+				}
+			}
+			if len(query) > 0 {
+				event.Fields.Put(p.config.QueryTarget, query)
+			}
+		}
+	}
+	decoded, err := url.PathUnescape(text)
+	if err != nil {
+		processorURLDecodeFailed.Add(1)
+		return event, nil
+	}
+	target := p.config.Target
+	if target == "" {
+		target = p.config.Field
+	}
+	event.Fields.Put(target, decoded)
+	return event, nil
+}
+
+func (p *urlDecode) String() string {
+	return fmt.Sprintf("url_decode=[field=%s, target=%s, parse_query=%v]", p.config.Field, p.config.Target, p.config.ParseQuery)
+}
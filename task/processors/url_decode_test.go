@@ -0,0 +1,50 @@
+// Tencent is pleased to support the open source community by making bkunifylogbeat 蓝鲸日志采集器 available.
+//
+// Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+//
+// bkunifylogbeat 蓝鲸日志采集器 is licensed under the MIT License.
+//
+// License for bkunifylogbeat 蓝鲸日志采集器:
+// --------------------------------------------------------------------
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+// documentation files (the "Software"), to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software,
+// and to permit persons to whom the Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all copies or substantial
+// portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+// LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN
+// NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+// WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+// SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package processors
+
+import (
+	"testing"
+
+	"github.com/TencentBlueKing/bkunifylogbeat/tests"
+	"github.com/elastic/beats/libbeat/common"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestURLDecode: 测试URL解码及查询参数解析
+func TestURLDecode(t *testing.T) {
+	p, err := newURLDecode(common.MustNewConfigFrom(map[string]interface{}{"target": "uri", "parse_query": true}))
+	assert.NoError(t, err)
+
+	data := tests.MockLogEvent("/test.log", "/search/%E6%97%A5%E5%BF%97?q=a+b&tag=x&tag=y")
+	event, err := p.Run(&data.Event)
+	assert.NoError(t, err)
+	assert.Equal(t, "/search/日志?q=a+b&tag=x&tag=y", event.Fields["uri"])
+	query, _ := event.Fields.GetValue("query")
+	assert.Equal(t, common.MapStr{"q": "a b", "tag": []string{"x", "y"}}, query)
+
+	// 非法转义保持原样
+	data = tests.MockLogEvent("/test.log", "/bad%zz")
+	event, _ = p.Run(&data.Event)
+	assert.NotContains(t, event.Fields, "uri")
+	assert.Equal(t, "/bad%zz", event.Fields["data"])
+}
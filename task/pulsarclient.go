@@ -0,0 +1,448 @@
+// Tencent is pleased to support the open source community by making bkunifylogbeat 蓝鲸日志采集器 available.
+//
+// Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+//
+// bkunifylogbeat 蓝鲸日志采集器 is licensed under the MIT License.
+//
+// License for bkunifylogbeat 蓝鲸日志采集器:
+// --------------------------------------------------------------------
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+// documentation files (the "Software"), to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software,
+// and to permit persons to whom the Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all copies or substantial
+// portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+// LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN
+// NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+// WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+// SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package task
+
+import (
+	"crypto/tls"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"net"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/TencentBlueKing/bkunifylogbeat/task/pulsarpb"
+	"github.com/elastic/beats/libbeat/common/transport/tlscommon"
+	"github.com/golang/protobuf/proto"
+)
+
+const (
+	pulsarClientVersion   = "bkunifylogbeat"
+	pulsarProtocolVersion = 13
+	pulsarMagicCRC32C     = 0x0e01
+	// broker未返回max_message_size时的默认值，单个帧的上限额外预留命令及元数据的空间
+	pulsarDefaultMaxMessageSize = 5 * 1024 * 1024
+	pulsarMaxFrameSize          = pulsarDefaultMaxMessageSize + 10*1024
+	pulsarMaxLookupRedirects    = 20
+)
+
+var pulsarCRC32CTable = crc32.MakeTable(crc32.Castagnoli)
+
+// pulsarReceipt: broker对批量消息的确认，err不为空表示连接已不可用
+type pulsarReceipt struct {
+	conn       *pulsarConn
+	sequenceID uint64
+	err        error
+}
+
+// pulsarConn: 与broker(或proxy)之间的一条连接，每条连接上最多创建一个生产者
+// 命令写入加锁串行，响应由后台协程读取后按request_id分发，发送确认及连接断开通过receipts通知
+type pulsarConn struct {
+	conn           net.Conn
+	timeout        time.Duration
+	maxMessageSize int
+	producerName   string
+	requestID      uint64
+	writeMutex     sync.Mutex
+	mutex          sync.Mutex
+	requests       map[uint64]chan *pulsarpb.BaseCommand
+	receipts       chan<- pulsarReceipt
+	done           <-chan struct{}
+	closed         chan struct{}
+	closeOnce      sync.Once
+	err            error
+}
+
+// pulsarDialer: 建立连接所需的参数，useTLS时未配置ssl则使用系统默认证书校验
+type pulsarDialer struct {
+	useTLS    bool
+	tlsConfig *tlscommon.TLSConfig
+	token     string
+	timeout   time.Duration
+	receipts  chan<- pulsarReceipt
+	done      <-chan struct{}
+}
+
+// parsePulsarURL: 将pulsar://host:port或pulsar+ssl://host:port转换为连接地址
+func parsePulsarURL(rawURL string) (addr string, useTLS bool, err error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", false, err
+	}
+	switch u.Scheme {
+	case "pulsar":
+	case "pulsar+ssl":
+		useTLS = true
+	default:
+		return "", false, fmt.Errorf("pulsar url(%s) scheme is not supported", rawURL)
+	}
+	if u.Port() == "" {
+		return "", false, fmt.Errorf("pulsar url(%s) port is required", rawURL)
+	}
+	return u.Host, useTLS, nil
+}
+
+// dial 连接addr并完成CONNECT握手，proxyToBroker不为空时由addr所在的proxy转发到对应broker
+func (d *pulsarDialer) dial(addr string, proxyToBroker string) (*pulsarConn, error) {
+	netDialer := &net.Dialer{Timeout: d.timeout}
+	var conn net.Conn
+	var err error
+	if d.useTLS {
+		host, _, _ := net.SplitHostPort(addr)
+		tlsConfig := &tls.Config{ServerName: host}
+		if d.tlsConfig != nil {
+			tlsConfig = d.tlsConfig.BuildModuleConfig(host)
+		}
+		conn, err = tls.DialWithDialer(netDialer, "tcp", addr, tlsConfig)
+	} else {
+		conn, err = netDialer.Dial("tcp", addr)
+	}
+	if err != nil {
+		return nil, err
+	}
+	c := &pulsarConn{
+		conn:           conn,
+		timeout:        d.timeout,
+		maxMessageSize: pulsarDefaultMaxMessageSize,
+		requests:       make(map[uint64]chan *pulsarpb.BaseCommand),
+		receipts:       d.receipts,
+		done:           d.done,
+		closed:         make(chan struct{}),
+	}
+	if err = c.handshake(d.token, proxyToBroker); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("pulsar handshake with %s failed, err=>%v", addr, err)
+	}
+	go c.readLoop()
+	return c, nil
+}
+
+// handshake: 发送CONNECT并同步等待CONNECTED，此时读协程尚未启动
+func (c *pulsarConn) handshake(token string, proxyToBroker string) error {
+	connect := &pulsarpb.CommandConnect{
+		ClientVersion:   proto.String(pulsarClientVersion),
+		ProtocolVersion: proto.Int32(pulsarProtocolVersion),
+	}
+	if token != "" {
+		connect.AuthMethodName = proto.String("token")
+		connect.AuthData = []byte(token)
+	}
+	if proxyToBroker != "" {
+		connect.ProxyToBrokerUrl = proto.String(proxyToBroker)
+	}
+	err := c.writeCommand(&pulsarpb.BaseCommand{Type: pulsarpb.BaseCommand_CONNECT.Enum(), Connect: connect})
+	if err != nil {
+		return err
+	}
+	c.conn.SetReadDeadline(time.Now().Add(c.timeout))
+	defer c.conn.SetReadDeadline(time.Time{})
+	cmd, _, err := readPulsarFrame(c.conn)
+	if err != nil {
+		return err
+	}
+	switch cmd.GetType() {
+	case pulsarpb.BaseCommand_CONNECTED:
+		if size := cmd.GetConnected().GetMaxMessageSize(); size > 0 {
+			c.maxMessageSize = int(size)
+		}
+		return nil
+	case pulsarpb.BaseCommand_ERROR:
+		return pulsarServerError(cmd.GetError().GetError(), cmd.GetError().GetMessage())
+	default:
+		return fmt.Errorf("unexpected command(%s)", cmd.GetType())
+	}
+}
+
+// lookup: 查询topic所在的broker，返回需要连接的地址及经proxy转发时的broker地址
+func (c *pulsarConn) lookup(topic string, authoritative bool, useTLS bool) (*pulsarpb.CommandLookupTopicResponse, string, error) {
+	requestID := c.nextRequestID()
+	resp, err := c.request(requestID, &pulsarpb.BaseCommand{
+		Type: pulsarpb.BaseCommand_LOOKUP.Enum(),
+		LookupTopic: &pulsarpb.CommandLookupTopic{
+			Topic:         proto.String(topic),
+			RequestId:     proto.Uint64(requestID),
+			Authoritative: proto.Bool(authoritative),
+		},
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	lookup := resp.GetLookupTopicResponse()
+	if lookup.GetResponse() == pulsarpb.CommandLookupTopicResponse_Failed {
+		return nil, "", pulsarServerError(lookup.GetError(), lookup.GetMessage())
+	}
+	brokerURL := lookup.GetBrokerServiceUrl()
+	if useTLS {
+		brokerURL = lookup.GetBrokerServiceUrlTls()
+	}
+	if brokerURL == "" {
+		return nil, "", fmt.Errorf("lookup topic(%s) returns no broker url", topic)
+	}
+	return lookup, brokerURL, nil
+}
+
+// partitions: 查询topic的分区数，非分区topic返回0
+func (c *pulsarConn) partitions(topic string) (int, error) {
+	requestID := c.nextRequestID()
+	resp, err := c.request(requestID, &pulsarpb.BaseCommand{
+		Type: pulsarpb.BaseCommand_PARTITIONED_METADATA.Enum(),
+		PartitionMetadata: &pulsarpb.CommandPartitionedTopicMetadata{
+			Topic:     proto.String(topic),
+			RequestId: proto.Uint64(requestID),
+		},
+	})
+	if err != nil {
+		return 0, err
+	}
+	metadata := resp.GetPartitionMetadataResponse()
+	if metadata.GetResponse() == pulsarpb.CommandPartitionedTopicMetadataResponse_Failed {
+		return 0, pulsarServerError(metadata.GetError(), metadata.GetMessage())
+	}
+	return int(metadata.GetPartitions()), nil
+}
+
+// createProducer: 在当前连接上创建生产者，producer_id固定为0
+func (c *pulsarConn) createProducer(topic string) error {
+	requestID := c.nextRequestID()
+	resp, err := c.request(requestID, &pulsarpb.BaseCommand{
+		Type: pulsarpb.BaseCommand_PRODUCER.Enum(),
+		Producer: &pulsarpb.CommandProducer{
+			Topic:      proto.String(topic),
+			ProducerId: proto.Uint64(0),
+			RequestId:  proto.Uint64(requestID),
+		},
+	})
+	if err != nil {
+		return err
+	}
+	c.producerName = resp.GetProducerSuccess().GetProducerName()
+	return nil
+}
+
+// send: 发送一条批量消息，payload由多条带SingleMessageMetadata的单条消息拼接而成
+func (c *pulsarConn) send(sequenceID uint64, numMessages int, payload []byte) error {
+	cmd, err := proto.Marshal(&pulsarpb.BaseCommand{
+		Type: pulsarpb.BaseCommand_SEND.Enum(),
+		Send: &pulsarpb.CommandSend{
+			ProducerId:  proto.Uint64(0),
+			SequenceId:  proto.Uint64(sequenceID),
+			NumMessages: proto.Int32(int32(numMessages)),
+		},
+	})
+	if err != nil {
+		return err
+	}
+	metadata, err := proto.Marshal(&pulsarpb.MessageMetadata{
+		ProducerName:       proto.String(c.producerName),
+		SequenceId:         proto.Uint64(sequenceID),
+		PublishTime:        proto.Uint64(uint64(time.Now().UnixNano() / int64(time.Millisecond))),
+		NumMessagesInBatch: proto.Int32(int32(numMessages)),
+		UncompressedSize:   proto.Uint32(uint32(len(payload))),
+	})
+	if err != nil {
+		return err
+	}
+	if len(metadata)+len(payload) > c.maxMessageSize {
+		return fmt.Errorf("message size(%d) exceeds max_message_size(%d)", len(metadata)+len(payload), c.maxMessageSize)
+	}
+
+	// [totalSize][commandSize][command][magic][checksum][metadataSize][metadata][payload]，checksum覆盖其后的全部内容
+	checksumOffset := 4 + 4 + len(cmd) + 2
+	frame := make([]byte, checksumOffset+4+4+len(metadata)+len(payload))
+	binary.BigEndian.PutUint32(frame, uint32(len(frame)-4))
+	binary.BigEndian.PutUint32(frame[4:], uint32(len(cmd)))
+	copy(frame[8:], cmd)
+	binary.BigEndian.PutUint16(frame[8+len(cmd):], pulsarMagicCRC32C)
+	binary.BigEndian.PutUint32(frame[checksumOffset+4:], uint32(len(metadata)))
+	copy(frame[checksumOffset+8:], metadata)
+	copy(frame[checksumOffset+8+len(metadata):], payload)
+	binary.BigEndian.PutUint32(frame[checksumOffset:], crc32.Checksum(frame[checksumOffset+4:], pulsarCRC32CTable))
+	return c.write(frame)
+}
+
+// request 发送带request_id的命令并等待响应，broker返回ERROR时转换为error
+func (c *pulsarConn) request(requestID uint64, cmd *pulsarpb.BaseCommand) (*pulsarpb.BaseCommand, error) {
+	ch := make(chan *pulsarpb.BaseCommand, 1)
+	c.mutex.Lock()
+	c.requests[requestID] = ch
+	c.mutex.Unlock()
+	defer func() {
+		c.mutex.Lock()
+		delete(c.requests, requestID)
+		c.mutex.Unlock()
+	}()
+
+	if err := c.writeCommand(cmd); err != nil {
+		return nil, err
+	}
+	timer := time.NewTimer(c.timeout)
+	defer timer.Stop()
+	select {
+	case resp := <-ch:
+		if resp.GetType() == pulsarpb.BaseCommand_ERROR {
+			return nil, pulsarServerError(resp.GetError().GetError(), resp.GetError().GetMessage())
+		}
+		return resp, nil
+	case <-c.closed:
+		return nil, c.err
+	case <-timer.C:
+		return nil, fmt.Errorf("%s request timeout", cmd.GetType())
+	}
+}
+
+func (c *pulsarConn) nextRequestID() uint64 {
+	return atomic.AddUint64(&c.requestID, 1)
+}
+
+// readLoop 读取broker下发的命令，连接异常时关闭连接并通知发送方
+func (c *pulsarConn) readLoop() {
+	var err error
+	for err == nil {
+		var cmd *pulsarpb.BaseCommand
+		cmd, _, err = readPulsarFrame(c.conn)
+		if err != nil {
+			break
+		}
+		switch cmd.GetType() {
+		case pulsarpb.BaseCommand_PING:
+			err = c.writeCommand(&pulsarpb.BaseCommand{Type: pulsarpb.BaseCommand_PONG.Enum(), Pong: &pulsarpb.CommandPong{}})
+		case pulsarpb.BaseCommand_SEND_RECEIPT:
+			c.notify(pulsarReceipt{conn: c, sequenceID: cmd.GetSendReceipt().GetSequenceId()})
+		case pulsarpb.BaseCommand_SEND_ERROR:
+			// broker返回发送失败后该生产者上的后续消息均不可用，需重建连接后重发
+			sendError := cmd.GetSendError()
+			err = fmt.Errorf("send sequence_id(%d) failed, err=>%v",
+				sendError.GetSequenceId(), pulsarServerError(sendError.GetError(), sendError.GetMessage()))
+		case pulsarpb.BaseCommand_CLOSE_PRODUCER:
+			err = errors.New("producer closed by broker")
+		case pulsarpb.BaseCommand_PRODUCER_SUCCESS:
+			c.response(cmd.GetProducerSuccess().GetRequestId(), cmd)
+		case pulsarpb.BaseCommand_SUCCESS:
+			c.response(cmd.GetSuccess().GetRequestId(), cmd)
+		case pulsarpb.BaseCommand_ERROR:
+			c.response(cmd.GetError().GetRequestId(), cmd)
+		case pulsarpb.BaseCommand_LOOKUP_RESPONSE:
+			c.response(cmd.GetLookupTopicResponse().GetRequestId(), cmd)
+		case pulsarpb.BaseCommand_PARTITIONED_METADATA_RESPONSE:
+			c.response(cmd.GetPartitionMetadataResponse().GetRequestId(), cmd)
+		}
+	}
+
+	// 主动关闭的连接无需通知
+	select {
+	case <-c.closed:
+		return
+	default:
+	}
+	c.closeWithError(err)
+	c.notify(pulsarReceipt{conn: c, err: err})
+}
+
+func (c *pulsarConn) response(requestID uint64, cmd *pulsarpb.BaseCommand) {
+	c.mutex.Lock()
+	ch, ok := c.requests[requestID]
+	c.mutex.Unlock()
+	if !ok {
+		return
+	}
+	select {
+	case ch <- cmd:
+	default:
+	}
+}
+
+func (c *pulsarConn) notify(receipt pulsarReceipt) {
+	if c.receipts == nil {
+		return
+	}
+	select {
+	case <-c.done:
+	case c.receipts <- receipt:
+	}
+}
+
+func (c *pulsarConn) writeCommand(cmd *pulsarpb.BaseCommand) error {
+	data, err := proto.Marshal(cmd)
+	if err != nil {
+		return err
+	}
+	frame := make([]byte, 8+len(data))
+	binary.BigEndian.PutUint32(frame, uint32(4+len(data)))
+	binary.BigEndian.PutUint32(frame[4:], uint32(len(data)))
+	copy(frame[8:], data)
+	return c.write(frame)
+}
+
+func (c *pulsarConn) write(frame []byte) error {
+	c.writeMutex.Lock()
+	defer c.writeMutex.Unlock()
+	c.conn.SetWriteDeadline(time.Now().Add(c.timeout))
+	_, err := c.conn.Write(frame)
+	return err
+}
+
+// close 主动关闭连接
+func (c *pulsarConn) close() {
+	c.closeWithError(errors.New("connection closed"))
+}
+
+func (c *pulsarConn) closeWithError(err error) {
+	c.closeOnce.Do(func() {
+		c.err = err
+		close(c.closed)
+		c.conn.Close()
+	})
+}
+
+// readPulsarFrame 读取一个帧，返回命令及其后的消息内容(仅SEND等命令携带)
+func readPulsarFrame(r io.Reader) (*pulsarpb.BaseCommand, []byte, error) {
+	var header [4]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return nil, nil, err
+	}
+	totalSize := binary.BigEndian.Uint32(header[:])
+	if totalSize < 4 || totalSize > pulsarMaxFrameSize {
+		return nil, nil, fmt.Errorf("invalid frame size(%d)", totalSize)
+	}
+	frame := make([]byte, totalSize)
+	if _, err := io.ReadFull(r, frame); err != nil {
+		return nil, nil, err
+	}
+	cmdSize := binary.BigEndian.Uint32(frame)
+	if cmdSize > totalSize-4 {
+		return nil, nil, fmt.Errorf("invalid command size(%d)", cmdSize)
+	}
+	cmd := &pulsarpb.BaseCommand{}
+	if err := proto.Unmarshal(frame[4:4+cmdSize], cmd); err != nil {
+		return nil, nil, err
+	}
+	return cmd, frame[4+cmdSize:], nil
+}
+
+func pulsarServerError(code pulsarpb.ServerError, message string) error {
+	return fmt.Errorf("pulsar server error(%s): %s", code, message)
+}
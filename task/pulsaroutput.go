@@ -0,0 +1,447 @@
+// Tencent is pleased to support the open source community by making bkunifylogbeat 蓝鲸日志采集器 available.
+//
+// Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+//
+// bkunifylogbeat 蓝鲸日志采集器 is licensed under the MIT License.
+//
+// License for bkunifylogbeat 蓝鲸日志采集器:
+// --------------------------------------------------------------------
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+// documentation files (the "Software"), to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software,
+// and to permit persons to whom the Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all copies or substantial
+// portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+// LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN
+// NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+// WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+// SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package task
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	cfg "github.com/TencentBlueKing/bkunifylogbeat/config"
+	"github.com/TencentBlueKing/bkunifylogbeat/task/pulsarpb"
+	"github.com/TencentBlueKing/collector-go-sdk/v2/bkbeat/beat"
+	"github.com/TencentBlueKing/collector-go-sdk/v2/bkbeat/bkmonitoring"
+	"github.com/TencentBlueKing/collector-go-sdk/v2/bkbeat/logp"
+	"github.com/elastic/beats/libbeat/common/transport/tlscommon"
+	"github.com/elastic/beats/libbeat/monitoring"
+	"github.com/golang/protobuf/proto"
+)
+
+const (
+	pulsarMinBackoff = 1 * time.Second
+	pulsarMaxBackoff = 60 * time.Second
+	// 批量消息的大小上限，超过时提前发送，单条打包事件超过时单独成批
+	pulsarBatchingMaxSize = 128 * 1024
+)
+
+var (
+	senderPulsarSentTotal  = bkmonitoring.NewInt("sender_pulsar_sent_total")
+	senderPulsarErrorTotal = bkmonitoring.NewInt("sender_pulsar_error_total")
+)
+
+// pulsarEntry: 待确认的打包事件，payload为空表示仅需更新采集状态
+type pulsarEntry struct {
+	state   interface{}
+	payload []byte
+	done    bool
+}
+
+// pulsarBatch: 合并发送的一条批量消息，sequenceID在每次发送时按所在分区的生产者重新分配
+type pulsarBatch struct {
+	entries    []*pulsarEntry
+	payload    []byte
+	sequenceID uint64
+}
+
+// pulsarPartition: 分区(非分区topic视为一个分区)对应的生产者连接，conn为空表示正在重连
+// batches为已分配到该分区、尚未确认的批次，重连后按原顺序重发
+type pulsarPartition struct {
+	topic          string
+	conn           *pulsarConn
+	backoff        time.Duration
+	nextSequenceID uint64
+	batches        []*pulsarBatch
+}
+
+// pulsarConnected: 后台建立生产者连接的结果
+type pulsarConnected struct {
+	partition *pulsarPartition
+	conn      *pulsarConn
+	err       error
+}
+
+// pulsarOutput: 替代beat.SendEvent将打包后的事件直接写入Pulsar
+// 打包事件合并为批量消息后按批次轮询写入各分区，broker确认后才按发送顺序提交采集状态，
+// 连接异常时退避重连并重发未确认的批次，未确认事件数达到buffer_size时阻塞发送
+type pulsarOutput struct {
+	taskID      string
+	config      cfg.PulsarOutputConfig
+	dialer      *pulsarDialer
+	serviceAddr string
+	partitions  []*pulsarPartition
+	next        int
+	ack         PublisherFunc
+	input       chan *pulsarEntry
+	receipts    chan pulsarReceipt
+	connected   chan pulsarConnected
+	done        <-chan struct{}
+	sentTotal   *monitoring.Int
+	errorTotal  *monitoring.Int
+}
+
+// newPulsarOutput 查询topic分区并启动发送，ack用于在写入成功后提交采集状态
+func newPulsarOutput(taskConfig *cfg.TaskConfig, done <-chan struct{}, ack PublisherFunc) (*pulsarOutput, error) {
+	config := taskConfig.PulsarOutput
+	serviceAddr, useTLS, err := parsePulsarURL(config.URL)
+	if err != nil {
+		return nil, err
+	}
+	receipts := make(chan pulsarReceipt, config.BufferSize)
+	dialer := &pulsarDialer{
+		useTLS:   useTLS,
+		token:    config.Token,
+		timeout:  config.Timeout,
+		receipts: receipts,
+		done:     done,
+	}
+	if config.TLS != nil {
+		tlsConfig, err := tlscommon.LoadTLSConfig(config.TLS)
+		if err != nil {
+			return nil, fmt.Errorf("load pulsar_output tls config failed, err=>%v", err)
+		}
+		dialer.tlsConfig = tlsConfig
+	}
+
+	// 启动时同步查询分区数，服务不可达或认证失败时任务启动失败
+	conn, err := dialer.dial(serviceAddr, "")
+	if err != nil {
+		return nil, fmt.Errorf("connect pulsar failed, err=>%v", err)
+	}
+	numPartitions, err := conn.partitions(config.Topic)
+	conn.close()
+	if err != nil {
+		return nil, fmt.Errorf("get pulsar topic(%s) partitions failed, err=>%v", config.Topic, err)
+	}
+	partitions := []*pulsarPartition{{topic: config.Topic}}
+	if numPartitions > 0 {
+		partitions = make([]*pulsarPartition, numPartitions)
+		for i := range partitions {
+			partitions[i] = &pulsarPartition{topic: config.Topic + "-partition-" + strconv.Itoa(i)}
+		}
+	}
+
+	output := &pulsarOutput{
+		taskID:      taskConfig.ID,
+		config:      config,
+		dialer:      dialer,
+		serviceAddr: serviceAddr,
+		partitions:  partitions,
+		ack:         ack,
+		input:       make(chan *pulsarEntry),
+		receipts:    receipts,
+		connected:   make(chan pulsarConnected),
+		done:        done,
+		sentTotal:   newIntWithDataID(taskConfig.DataID, "sender_pulsar_sent_total"),
+		errorTotal:  newIntWithDataID(taskConfig.DataID, "sender_pulsar_error_total"),
+	}
+	go output.run()
+	return output, nil
+}
+
+// publish 作为Sender的PublisherFunc，任务停止时返回false
+func (o *pulsarOutput) publish(event beat.Event) bool {
+	entry := &pulsarEntry{state: event.Private}
+	if event.Fields != nil {
+		value, err := json.Marshal(event.Fields)
+		if err != nil {
+			logp.L.Errorf("marshal pulsar event failed, task_id:%s, err=>%v", o.taskID, err)
+			o.addError()
+			entry.done = true
+		} else if len(value) > pulsarDefaultMaxMessageSize-1024 {
+			logp.L.Errorf("pulsar event is too large, task_id:%s, size:%d", o.taskID, len(value))
+			o.addError()
+			entry.done = true
+		} else {
+			entry.payload = value
+		}
+	} else {
+		entry.done = true
+	}
+	select {
+	case <-o.done:
+		return false
+	case o.input <- entry:
+		return true
+	}
+}
+
+// run 串行处理合并、发送、确认及重连，保证采集状态按发送顺序提交
+func (o *pulsarOutput) run() {
+	var (
+		pending []*pulsarEntry
+		batch   *pulsarBatch
+		timer   *time.Timer
+		flush   <-chan time.Time
+	)
+	for _, p := range o.partitions {
+		o.connect(p, 0)
+	}
+	for {
+		// 未确认事件数达到上限时不再接收新事件
+		var input chan *pulsarEntry
+		if len(pending) < o.config.BufferSize {
+			input = o.input
+		}
+
+		select {
+		case <-o.done:
+			if timer != nil {
+				timer.Stop()
+			}
+			o.close()
+			return
+		case entry := <-input:
+			pending = append(pending, entry)
+			if entry.payload == nil {
+				break
+			}
+			if batch != nil && len(batch.payload)+len(entry.payload) > pulsarBatchingMaxSize {
+				timer.Stop()
+				o.dispatch(batch)
+				batch, flush = nil, nil
+			}
+			if batch == nil {
+				batch = &pulsarBatch{}
+				timer = time.NewTimer(o.config.BatchingMaxPublishDelay)
+				flush = timer.C
+			}
+			batch.add(entry)
+			if len(batch.entries) >= o.config.BatchingMaxMessages {
+				timer.Stop()
+				o.dispatch(batch)
+				batch, flush = nil, nil
+			}
+		case <-flush:
+			o.dispatch(batch)
+			batch, flush = nil, nil
+		case receipt := <-o.receipts:
+			o.handleReceipt(receipt)
+		case connected := <-o.connected:
+			o.handleConnected(connected)
+		}
+		pending = o.commit(pending)
+	}
+}
+
+// add: 追加一条单条消息，格式为[SingleMessageMetadata长度][SingleMessageMetadata][消息内容]
+func (b *pulsarBatch) add(entry *pulsarEntry) {
+	metadata, _ := proto.Marshal(&pulsarpb.SingleMessageMetadata{PayloadSize: proto.Int32(int32(len(entry.payload)))})
+	var size [4]byte
+	binary.BigEndian.PutUint32(size[:], uint32(len(metadata)))
+	b.payload = append(b.payload, size[:]...)
+	b.payload = append(b.payload, metadata...)
+	b.payload = append(b.payload, entry.payload...)
+	b.entries = append(b.entries, entry)
+}
+
+// dispatch: 按批次轮询分区，跳过正在重连的分区，全部不可用时排队等待重连
+func (o *pulsarOutput) dispatch(batch *pulsarBatch) {
+	index := o.next
+	for i := range o.partitions {
+		j := (o.next + i) % len(o.partitions)
+		if o.partitions[j].conn != nil {
+			index = j
+			break
+		}
+	}
+	o.next = (index + 1) % len(o.partitions)
+
+	p := o.partitions[index]
+	p.batches = append(p.batches, batch)
+	if p.conn != nil {
+		o.send(p, batch)
+	}
+}
+
+// send 在分区当前的生产者上发送批次，失败时重连，返回是否发送成功
+func (o *pulsarOutput) send(p *pulsarPartition, batch *pulsarBatch) bool {
+	batch.sequenceID = p.nextSequenceID
+	p.nextSequenceID++
+	if err := p.conn.send(batch.sequenceID, len(batch.entries), batch.payload); err != nil {
+		o.retry(p, err)
+		return false
+	}
+	return true
+}
+
+// handleReceipt: 标记已确认批次中的事件，连接异常时重连
+func (o *pulsarOutput) handleReceipt(receipt pulsarReceipt) {
+	var p *pulsarPartition
+	for _, partition := range o.partitions {
+		if partition.conn != nil && partition.conn == receipt.conn {
+			p = partition
+			break
+		}
+	}
+	// 已被替换的连接
+	if p == nil {
+		return
+	}
+	if receipt.err != nil {
+		o.retry(p, receipt.err)
+		return
+	}
+	for i, batch := range p.batches {
+		if batch.sequenceID != receipt.sequenceID {
+			continue
+		}
+		for _, entry := range batch.entries {
+			entry.done = true
+		}
+		o.sentTotal.Add(int64(len(batch.entries)))
+		senderPulsarSentTotal.Add(int64(len(batch.entries)))
+		p.batches = append(p.batches[:i], p.batches[i+1:]...)
+		p.backoff = 0
+		return
+	}
+}
+
+// handleConnected: 生产者建立后序列号重新从0开始，按原顺序重发未确认的批次
+func (o *pulsarOutput) handleConnected(connected pulsarConnected) {
+	p := connected.partition
+	if connected.err != nil {
+		o.retry(p, connected.err)
+		return
+	}
+	p.conn = connected.conn
+	p.nextSequenceID = 0
+	for _, batch := range p.batches {
+		if !o.send(p, batch) {
+			return
+		}
+	}
+}
+
+// retry: 关闭分区当前连接并按指数退避重连
+func (o *pulsarOutput) retry(p *pulsarPartition, err error) {
+	o.addError()
+	if p.conn != nil {
+		p.conn.close()
+		p.conn = nil
+	}
+	p.backoff *= 2
+	if p.backoff == 0 {
+		p.backoff = pulsarMinBackoff
+	} else if p.backoff > pulsarMaxBackoff {
+		p.backoff = pulsarMaxBackoff
+	}
+	logp.L.Errorf("write pulsar failed, task_id:%s, topic:%s, retry in %s, err=>%v", o.taskID, p.topic, p.backoff, err)
+	o.connect(p, p.backoff)
+}
+
+// connect 延迟delay后在后台查询分区所在broker并创建生产者，结果交由run处理
+func (o *pulsarOutput) connect(p *pulsarPartition, delay time.Duration) {
+	topic := p.topic
+	go func() {
+		select {
+		case <-o.done:
+			return
+		case <-time.After(delay):
+		}
+		conn, err := o.connectTopic(topic)
+		select {
+		case o.connected <- pulsarConnected{partition: p, conn: conn, err: err}:
+		case <-o.done:
+			if conn != nil {
+				conn.close()
+			}
+		}
+	}()
+}
+
+// connectTopic: 从服务地址开始查询topic所在broker，跟随重定向后在对应broker上创建生产者
+func (o *pulsarOutput) connectTopic(topic string) (*pulsarConn, error) {
+	addr, proxyToBroker := o.serviceAddr, ""
+	conn, err := o.dialer.dial(addr, proxyToBroker)
+	if err != nil {
+		return nil, err
+	}
+	authoritative := false
+	for redirects := 0; ; redirects++ {
+		lookup, brokerURL, err := conn.lookup(topic, authoritative, o.dialer.useTLS)
+		if err != nil {
+			conn.close()
+			return nil, fmt.Errorf("lookup topic(%s) failed, err=>%v", topic, err)
+		}
+		brokerAddr, _, err := parsePulsarURL(brokerURL)
+		if err != nil {
+			conn.close()
+			return nil, err
+		}
+		targetAddr, targetProxy := brokerAddr, ""
+		if lookup.GetProxyThroughServiceUrl() {
+			targetAddr, targetProxy = o.serviceAddr, brokerAddr
+		}
+		if targetAddr != addr || targetProxy != proxyToBroker {
+			conn.close()
+			addr, proxyToBroker = targetAddr, targetProxy
+			if conn, err = o.dialer.dial(addr, proxyToBroker); err != nil {
+				return nil, err
+			}
+		}
+		if lookup.GetResponse() == pulsarpb.CommandLookupTopicResponse_Connect {
+			break
+		}
+		if redirects >= pulsarMaxLookupRedirects {
+			conn.close()
+			return nil, fmt.Errorf("lookup topic(%s) failed, too many redirects", topic)
+		}
+		authoritative = lookup.GetAuthoritative()
+	}
+	if err = conn.createProducer(topic); err != nil {
+		conn.close()
+		return nil, fmt.Errorf("create producer for topic(%s) failed, err=>%v", topic, err)
+	}
+	return conn, nil
+}
+
+// commit: 按发送顺序提交已完成事件的采集状态，返回剩余未确认的事件
+func (o *pulsarOutput) commit(pending []*pulsarEntry) []*pulsarEntry {
+	n := 0
+	for n < len(pending) && pending[n].done {
+		o.ack(beat.Event{Fields: nil, Private: pending[n].state})
+		n++
+	}
+	if n == 0 {
+		return pending
+	}
+	return append(pending[:0], pending[n:]...)
+}
+
+// close: 任务停止时关闭生产者连接，未确认的事件在重启后从上次提交的位置重新采集
+func (o *pulsarOutput) close() {
+	for _, p := range o.partitions {
+		if p.conn != nil {
+			p.conn.close()
+			p.conn = nil
+		}
+	}
+	logp.L.Infof("pulsar output closed, task_id:%s", o.taskID)
+}
+
+func (o *pulsarOutput) addError() {
+	o.errorTotal.Add(1)
+	senderPulsarErrorTotal.Add(1)
+}
@@ -0,0 +1,255 @@
+// Tencent is pleased to support the open source community by making bkunifylogbeat 蓝鲸日志采集器 available.
+//
+// Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+//
+// bkunifylogbeat 蓝鲸日志采集器 is licensed under the MIT License.
+//
+// License for bkunifylogbeat 蓝鲸日志采集器:
+// --------------------------------------------------------------------
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+// documentation files (the "Software"), to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software,
+// and to permit persons to whom the Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all copies or substantial
+// portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+// LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN
+// NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+// WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+// SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package task
+
+import (
+	"encoding/binary"
+	"hash/crc32"
+	"net"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	cfg "github.com/TencentBlueKing/bkunifylogbeat/config"
+	"github.com/TencentBlueKing/bkunifylogbeat/task/pulsarpb"
+	"github.com/TencentBlueKing/collector-go-sdk/v2/bkbeat/beat"
+	"github.com/golang/protobuf/proto"
+	"github.com/stretchr/testify/assert"
+)
+
+// mockPulsarBroker: 模拟broker，查询时先重定向一次，dropSends大于0时收到SEND后直接断开连接
+type mockPulsarBroker struct {
+	t          *testing.T
+	listener   net.Listener
+	token      string
+	partitions uint32
+	mutex      sync.Mutex
+	dropSends  int
+	producers  []string
+	messages   chan string
+}
+
+func newMockPulsarBroker(t *testing.T, token string, partitions uint32, dropSends int) *mockPulsarBroker {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	broker := &mockPulsarBroker{
+		t:          t,
+		listener:   listener,
+		token:      token,
+		partitions: partitions,
+		dropSends:  dropSends,
+		messages:   make(chan string, 100),
+	}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go broker.serve(conn)
+		}
+	}()
+	return broker
+}
+
+func (b *mockPulsarBroker) url() string {
+	return "pulsar://" + b.listener.Addr().String()
+}
+
+func (b *mockPulsarBroker) serve(conn net.Conn) {
+	defer conn.Close()
+	writer := &pulsarConn{conn: conn, timeout: time.Second}
+	for {
+		cmd, payload, err := readPulsarFrame(conn)
+		if err != nil {
+			return
+		}
+		var resp *pulsarpb.BaseCommand
+		switch cmd.GetType() {
+		case pulsarpb.BaseCommand_CONNECT:
+			if string(cmd.GetConnect().GetAuthData()) != b.token {
+				writer.writeCommand(&pulsarpb.BaseCommand{
+					Type: pulsarpb.BaseCommand_ERROR.Enum(),
+					Error: &pulsarpb.CommandError{
+						RequestId: proto.Uint64(0),
+						Error:     pulsarpb.ServerError_AuthenticationError.Enum(),
+						Message:   proto.String("invalid token"),
+					},
+				})
+				return
+			}
+			resp = &pulsarpb.BaseCommand{
+				Type:      pulsarpb.BaseCommand_CONNECTED.Enum(),
+				Connected: &pulsarpb.CommandConnected{ServerVersion: proto.String("mock")},
+			}
+		case pulsarpb.BaseCommand_PARTITIONED_METADATA:
+			resp = &pulsarpb.BaseCommand{
+				Type: pulsarpb.BaseCommand_PARTITIONED_METADATA_RESPONSE.Enum(),
+				PartitionMetadataResponse: &pulsarpb.CommandPartitionedTopicMetadataResponse{
+					RequestId:  cmd.GetPartitionMetadata().RequestId,
+					Partitions: proto.Uint32(b.partitions),
+				},
+			}
+		case pulsarpb.BaseCommand_LOOKUP:
+			lookupType := pulsarpb.CommandLookupTopicResponse_Redirect
+			if cmd.GetLookupTopic().GetAuthoritative() {
+				lookupType = pulsarpb.CommandLookupTopicResponse_Connect
+			}
+			resp = &pulsarpb.BaseCommand{
+				Type: pulsarpb.BaseCommand_LOOKUP_RESPONSE.Enum(),
+				LookupTopicResponse: &pulsarpb.CommandLookupTopicResponse{
+					RequestId:        cmd.GetLookupTopic().RequestId,
+					BrokerServiceUrl: proto.String(b.url()),
+					Response:         lookupType.Enum(),
+					Authoritative:    proto.Bool(true),
+				},
+			}
+		case pulsarpb.BaseCommand_PRODUCER:
+			b.mutex.Lock()
+			b.producers = append(b.producers, cmd.GetProducer().GetTopic())
+			b.mutex.Unlock()
+			resp = &pulsarpb.BaseCommand{
+				Type: pulsarpb.BaseCommand_PRODUCER_SUCCESS.Enum(),
+				ProducerSuccess: &pulsarpb.CommandProducerSuccess{
+					RequestId:    cmd.GetProducer().RequestId,
+					ProducerName: proto.String("mock-producer"),
+				},
+			}
+		case pulsarpb.BaseCommand_SEND:
+			b.mutex.Lock()
+			drop := b.dropSends > 0
+			b.dropSends--
+			b.mutex.Unlock()
+			if drop {
+				return
+			}
+			b.decodeBatch(cmd.GetSend(), payload)
+			resp = &pulsarpb.BaseCommand{
+				Type: pulsarpb.BaseCommand_SEND_RECEIPT.Enum(),
+				SendReceipt: &pulsarpb.CommandSendReceipt{
+					ProducerId: cmd.GetSend().ProducerId,
+					SequenceId: cmd.GetSend().SequenceId,
+				},
+			}
+		}
+		if resp != nil {
+			writer.writeCommand(resp)
+		}
+	}
+}
+
+// decodeBatch: 校验checksum后拆分批量消息
+func (b *mockPulsarBroker) decodeBatch(send *pulsarpb.CommandSend, data []byte) {
+	assert.Equal(b.t, uint16(pulsarMagicCRC32C), binary.BigEndian.Uint16(data))
+	assert.Equal(b.t, binary.BigEndian.Uint32(data[2:]), crc32.Checksum(data[6:], pulsarCRC32CTable))
+	data = data[6:]
+	metadataSize := binary.BigEndian.Uint32(data)
+	metadata := &pulsarpb.MessageMetadata{}
+	assert.NoError(b.t, proto.Unmarshal(data[4:4+metadataSize], metadata))
+	assert.Equal(b.t, send.GetSequenceId(), metadata.GetSequenceId())
+	assert.Equal(b.t, send.GetNumMessages(), metadata.GetNumMessagesInBatch())
+	data = data[4+metadataSize:]
+	for i := int32(0); i < metadata.GetNumMessagesInBatch(); i++ {
+		size := binary.BigEndian.Uint32(data)
+		single := &pulsarpb.SingleMessageMetadata{}
+		assert.NoError(b.t, proto.Unmarshal(data[4:4+size], single))
+		data = data[4+size:]
+		b.messages <- string(data[:single.GetPayloadSize()])
+		data = data[single.GetPayloadSize():]
+	}
+	assert.Empty(b.t, data)
+}
+
+// TestPulsarOutput: 批量写入分区topic，连接断开后重发，采集状态按发送顺序提交
+func TestPulsarOutput(t *testing.T) {
+	broker := newMockPulsarBroker(t, "secret", 2, 1)
+	defer broker.listener.Close()
+
+	acked := make(chan interface{}, 4)
+	done := make(chan struct{})
+	defer close(done)
+	output, err := newPulsarOutput(&cfg.TaskConfig{
+		ID: "test",
+		PulsarOutput: cfg.PulsarOutputConfig{
+			URL:                     broker.url(),
+			Topic:                   "logs",
+			Token:                   "secret",
+			BatchingMaxMessages:     2,
+			BatchingMaxPublishDelay: 10 * time.Millisecond,
+			Timeout:                 5 * time.Second,
+			BufferSize:              10,
+		},
+	}, done, func(event beat.Event) bool { acked <- event.Private; return true })
+	assert.NoError(t, err)
+	assert.Len(t, output.partitions, 2)
+
+	assert.True(t, output.publish(beat.Event{Fields: beat.MapStr{"data": "a"}, Private: 1}))
+	assert.True(t, output.publish(beat.Event{Fields: nil, Private: 2}))
+	assert.True(t, output.publish(beat.Event{Fields: beat.MapStr{"data": "b"}, Private: 3}))
+	assert.True(t, output.publish(beat.Event{Fields: beat.MapStr{"data": "c"}, Private: 4}))
+
+	for _, expected := range []interface{}{1, 2, 3, 4} {
+		select {
+		case state := <-acked:
+			assert.Equal(t, expected, state)
+		case <-time.After(10 * time.Second):
+			t.Fatalf("state %v is not acked", expected)
+		}
+	}
+
+	var messages []string
+	for len(messages) < 3 {
+		messages = append(messages, <-broker.messages)
+	}
+	sort.Strings(messages)
+	assert.Equal(t, []string{`{"data":"a"}`, `{"data":"b"}`, `{"data":"c"}`}, messages)
+
+	broker.mutex.Lock()
+	producers := append([]string(nil), broker.producers...)
+	broker.mutex.Unlock()
+	assert.Contains(t, producers, "logs-partition-0")
+	assert.Contains(t, producers, "logs-partition-1")
+	assert.True(t, output.errorTotal.Get() > 0)
+}
+
+// TestPulsarOutputAuth: token错误时启动失败
+func TestPulsarOutputAuth(t *testing.T) {
+	broker := newMockPulsarBroker(t, "secret", 0, 0)
+	defer broker.listener.Close()
+
+	done := make(chan struct{})
+	defer close(done)
+	_, err := newPulsarOutput(&cfg.TaskConfig{
+		ID: "test",
+		PulsarOutput: cfg.PulsarOutputConfig{
+			URL:        broker.url(),
+			Topic:      "logs",
+			Token:      "wrong",
+			Timeout:    5 * time.Second,
+			BufferSize: 10,
+		},
+	}, done, func(event beat.Event) bool { return true })
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "AuthenticationError")
+}
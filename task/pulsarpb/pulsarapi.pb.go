@@ -0,0 +1,1012 @@
+// Tencent is pleased to support the open source community by making bkunifylogbeat 蓝鲸日志采集器 available.
+//
+// Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+//
+// bkunifylogbeat 蓝鲸日志采集器 is licensed under the MIT License.
+//
+// License for bkunifylogbeat 蓝鲸日志采集器:
+// --------------------------------------------------------------------
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+// documentation files (the "Software"), to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software,
+// and to permit persons to whom the Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all copies or substantial
+// portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+// LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN
+// NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+// WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+// SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+// 与pulsarapi.proto对应的消息定义，结构与protoc-gen-go生成的代码兼容，修改时需同步更新proto文件
+
+package pulsarpb
+
+import (
+	"github.com/golang/protobuf/proto"
+)
+
+type BaseCommand_Type int32
+
+const (
+	BaseCommand_CONNECT                       BaseCommand_Type = 2
+	BaseCommand_CONNECTED                     BaseCommand_Type = 3
+	BaseCommand_PRODUCER                      BaseCommand_Type = 5
+	BaseCommand_SEND                          BaseCommand_Type = 6
+	BaseCommand_SEND_RECEIPT                  BaseCommand_Type = 7
+	BaseCommand_SEND_ERROR                    BaseCommand_Type = 8
+	BaseCommand_SUCCESS                       BaseCommand_Type = 13
+	BaseCommand_ERROR                         BaseCommand_Type = 14
+	BaseCommand_CLOSE_PRODUCER                BaseCommand_Type = 15
+	BaseCommand_PRODUCER_SUCCESS              BaseCommand_Type = 17
+	BaseCommand_PING                          BaseCommand_Type = 18
+	BaseCommand_PONG                          BaseCommand_Type = 19
+	BaseCommand_PARTITIONED_METADATA          BaseCommand_Type = 21
+	BaseCommand_PARTITIONED_METADATA_RESPONSE BaseCommand_Type = 22
+	BaseCommand_LOOKUP                        BaseCommand_Type = 23
+	BaseCommand_LOOKUP_RESPONSE               BaseCommand_Type = 24
+)
+
+var BaseCommand_Type_name = map[int32]string{
+	2:  "CONNECT",
+	3:  "CONNECTED",
+	5:  "PRODUCER",
+	6:  "SEND",
+	7:  "SEND_RECEIPT",
+	8:  "SEND_ERROR",
+	13: "SUCCESS",
+	14: "ERROR",
+	15: "CLOSE_PRODUCER",
+	17: "PRODUCER_SUCCESS",
+	18: "PING",
+	19: "PONG",
+	21: "PARTITIONED_METADATA",
+	22: "PARTITIONED_METADATA_RESPONSE",
+	23: "LOOKUP",
+	24: "LOOKUP_RESPONSE",
+}
+
+func (x BaseCommand_Type) Enum() *BaseCommand_Type {
+	p := new(BaseCommand_Type)
+	*p = x
+	return p
+}
+
+func (x BaseCommand_Type) String() string {
+	return proto.EnumName(BaseCommand_Type_name, int32(x))
+}
+
+type CommandLookupTopicResponse_LookupType int32
+
+const (
+	CommandLookupTopicResponse_Redirect CommandLookupTopicResponse_LookupType = 0
+	CommandLookupTopicResponse_Connect  CommandLookupTopicResponse_LookupType = 1
+	CommandLookupTopicResponse_Failed   CommandLookupTopicResponse_LookupType = 2
+)
+
+var CommandLookupTopicResponse_LookupType_name = map[int32]string{
+	0: "Redirect",
+	1: "Connect",
+	2: "Failed",
+}
+
+func (x CommandLookupTopicResponse_LookupType) Enum() *CommandLookupTopicResponse_LookupType {
+	p := new(CommandLookupTopicResponse_LookupType)
+	*p = x
+	return p
+}
+
+func (x CommandLookupTopicResponse_LookupType) String() string {
+	return proto.EnumName(CommandLookupTopicResponse_LookupType_name, int32(x))
+}
+
+type CommandPartitionedTopicMetadataResponse_LookupType int32
+
+const (
+	CommandPartitionedTopicMetadataResponse_Success CommandPartitionedTopicMetadataResponse_LookupType = 0
+	CommandPartitionedTopicMetadataResponse_Failed  CommandPartitionedTopicMetadataResponse_LookupType = 1
+)
+
+var CommandPartitionedTopicMetadataResponse_LookupType_name = map[int32]string{
+	0: "Success",
+	1: "Failed",
+}
+
+func (x CommandPartitionedTopicMetadataResponse_LookupType) Enum() *CommandPartitionedTopicMetadataResponse_LookupType {
+	p := new(CommandPartitionedTopicMetadataResponse_LookupType)
+	*p = x
+	return p
+}
+
+func (x CommandPartitionedTopicMetadataResponse_LookupType) String() string {
+	return proto.EnumName(CommandPartitionedTopicMetadataResponse_LookupType_name, int32(x))
+}
+
+type ServerError int32
+
+const (
+	ServerError_UnknownError                          ServerError = 0
+	ServerError_MetadataError                         ServerError = 1
+	ServerError_PersistenceError                      ServerError = 2
+	ServerError_AuthenticationError                   ServerError = 3
+	ServerError_AuthorizationError                    ServerError = 4
+	ServerError_ConsumerBusy                          ServerError = 5
+	ServerError_ServiceNotReady                       ServerError = 6
+	ServerError_ProducerBlockedQuotaExceededError     ServerError = 7
+	ServerError_ProducerBlockedQuotaExceededException ServerError = 8
+	ServerError_ChecksumError                         ServerError = 9
+	ServerError_UnsupportedVersionError               ServerError = 10
+	ServerError_TopicNotFound                         ServerError = 11
+	ServerError_SubscriptionNotFound                  ServerError = 12
+	ServerError_ConsumerNotFound                      ServerError = 13
+	ServerError_TooManyRequests                       ServerError = 14
+	ServerError_TopicTerminatedError                  ServerError = 15
+	ServerError_ProducerBusy                          ServerError = 16
+	ServerError_InvalidTopicName                      ServerError = 17
+)
+
+var ServerError_name = map[int32]string{
+	0:  "UnknownError",
+	1:  "MetadataError",
+	2:  "PersistenceError",
+	3:  "AuthenticationError",
+	4:  "AuthorizationError",
+	5:  "ConsumerBusy",
+	6:  "ServiceNotReady",
+	7:  "ProducerBlockedQuotaExceededError",
+	8:  "ProducerBlockedQuotaExceededException",
+	9:  "ChecksumError",
+	10: "UnsupportedVersionError",
+	11: "TopicNotFound",
+	12: "SubscriptionNotFound",
+	13: "ConsumerNotFound",
+	14: "TooManyRequests",
+	15: "TopicTerminatedError",
+	16: "ProducerBusy",
+	17: "InvalidTopicName",
+}
+
+func (x ServerError) Enum() *ServerError {
+	p := new(ServerError)
+	*p = x
+	return p
+}
+
+func (x ServerError) String() string {
+	return proto.EnumName(ServerError_name, int32(x))
+}
+
+type MessageIdData struct {
+	LedgerId             *uint64  `protobuf:"varint,1,req,name=ledgerId" json:"ledgerId,omitempty"`
+	EntryId              *uint64  `protobuf:"varint,2,req,name=entryId" json:"entryId,omitempty"`
+	Partition            *int32   `protobuf:"varint,3,opt,name=partition,def=-1" json:"partition,omitempty"`
+	BatchIndex           *int32   `protobuf:"varint,4,opt,name=batch_index,def=-1" json:"batch_index,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *MessageIdData) Reset()         { *m = MessageIdData{} }
+func (m *MessageIdData) String() string { return proto.CompactTextString(m) }
+func (*MessageIdData) ProtoMessage()    {}
+
+func (m *MessageIdData) GetLedgerId() uint64 {
+	if m != nil && m.LedgerId != nil {
+		return *m.LedgerId
+	}
+	return 0
+}
+
+func (m *MessageIdData) GetEntryId() uint64 {
+	if m != nil && m.EntryId != nil {
+		return *m.EntryId
+	}
+	return 0
+}
+
+func (m *MessageIdData) GetPartition() int32 {
+	if m != nil && m.Partition != nil {
+		return *m.Partition
+	}
+	return -1
+}
+
+func (m *MessageIdData) GetBatchIndex() int32 {
+	if m != nil && m.BatchIndex != nil {
+		return *m.BatchIndex
+	}
+	return -1
+}
+
+type MessageMetadata struct {
+	ProducerName         *string  `protobuf:"bytes,1,req,name=producer_name" json:"producer_name,omitempty"`
+	SequenceId           *uint64  `protobuf:"varint,2,req,name=sequence_id" json:"sequence_id,omitempty"`
+	PublishTime          *uint64  `protobuf:"varint,3,req,name=publish_time" json:"publish_time,omitempty"`
+	UncompressedSize     *uint32  `protobuf:"varint,9,opt,name=uncompressed_size" json:"uncompressed_size,omitempty"`
+	NumMessagesInBatch   *int32   `protobuf:"varint,11,opt,name=num_messages_in_batch,def=1" json:"num_messages_in_batch,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *MessageMetadata) Reset()         { *m = MessageMetadata{} }
+func (m *MessageMetadata) String() string { return proto.CompactTextString(m) }
+func (*MessageMetadata) ProtoMessage()    {}
+
+func (m *MessageMetadata) GetProducerName() string {
+	if m != nil && m.ProducerName != nil {
+		return *m.ProducerName
+	}
+	return ""
+}
+
+func (m *MessageMetadata) GetSequenceId() uint64 {
+	if m != nil && m.SequenceId != nil {
+		return *m.SequenceId
+	}
+	return 0
+}
+
+func (m *MessageMetadata) GetPublishTime() uint64 {
+	if m != nil && m.PublishTime != nil {
+		return *m.PublishTime
+	}
+	return 0
+}
+
+func (m *MessageMetadata) GetUncompressedSize() uint32 {
+	if m != nil && m.UncompressedSize != nil {
+		return *m.UncompressedSize
+	}
+	return 0
+}
+
+func (m *MessageMetadata) GetNumMessagesInBatch() int32 {
+	if m != nil && m.NumMessagesInBatch != nil {
+		return *m.NumMessagesInBatch
+	}
+	return 1
+}
+
+type SingleMessageMetadata struct {
+	PayloadSize          *int32   `protobuf:"varint,3,req,name=payload_size" json:"payload_size,omitempty"`
+	EventTime            *uint64  `protobuf:"varint,5,opt,name=event_time,def=0" json:"event_time,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *SingleMessageMetadata) Reset()         { *m = SingleMessageMetadata{} }
+func (m *SingleMessageMetadata) String() string { return proto.CompactTextString(m) }
+func (*SingleMessageMetadata) ProtoMessage()    {}
+
+func (m *SingleMessageMetadata) GetPayloadSize() int32 {
+	if m != nil && m.PayloadSize != nil {
+		return *m.PayloadSize
+	}
+	return 0
+}
+
+func (m *SingleMessageMetadata) GetEventTime() uint64 {
+	if m != nil && m.EventTime != nil {
+		return *m.EventTime
+	}
+	return 0
+}
+
+type CommandConnect struct {
+	ClientVersion        *string  `protobuf:"bytes,1,req,name=client_version" json:"client_version,omitempty"`
+	AuthData             []byte   `protobuf:"bytes,3,opt,name=auth_data" json:"auth_data,omitempty"`
+	ProtocolVersion      *int32   `protobuf:"varint,4,opt,name=protocol_version,def=0" json:"protocol_version,omitempty"`
+	AuthMethodName       *string  `protobuf:"bytes,5,opt,name=auth_method_name" json:"auth_method_name,omitempty"`
+	ProxyToBrokerUrl     *string  `protobuf:"bytes,6,opt,name=proxy_to_broker_url" json:"proxy_to_broker_url,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *CommandConnect) Reset()         { *m = CommandConnect{} }
+func (m *CommandConnect) String() string { return proto.CompactTextString(m) }
+func (*CommandConnect) ProtoMessage()    {}
+
+func (m *CommandConnect) GetClientVersion() string {
+	if m != nil && m.ClientVersion != nil {
+		return *m.ClientVersion
+	}
+	return ""
+}
+
+func (m *CommandConnect) GetAuthData() []byte {
+	if m != nil {
+		return m.AuthData
+	}
+	return nil
+}
+
+func (m *CommandConnect) GetProtocolVersion() int32 {
+	if m != nil && m.ProtocolVersion != nil {
+		return *m.ProtocolVersion
+	}
+	return 0
+}
+
+func (m *CommandConnect) GetAuthMethodName() string {
+	if m != nil && m.AuthMethodName != nil {
+		return *m.AuthMethodName
+	}
+	return ""
+}
+
+func (m *CommandConnect) GetProxyToBrokerUrl() string {
+	if m != nil && m.ProxyToBrokerUrl != nil {
+		return *m.ProxyToBrokerUrl
+	}
+	return ""
+}
+
+type CommandConnected struct {
+	ServerVersion        *string  `protobuf:"bytes,1,req,name=server_version" json:"server_version,omitempty"`
+	ProtocolVersion      *int32   `protobuf:"varint,2,opt,name=protocol_version,def=0" json:"protocol_version,omitempty"`
+	MaxMessageSize       *int32   `protobuf:"varint,3,opt,name=max_message_size" json:"max_message_size,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *CommandConnected) Reset()         { *m = CommandConnected{} }
+func (m *CommandConnected) String() string { return proto.CompactTextString(m) }
+func (*CommandConnected) ProtoMessage()    {}
+
+func (m *CommandConnected) GetServerVersion() string {
+	if m != nil && m.ServerVersion != nil {
+		return *m.ServerVersion
+	}
+	return ""
+}
+
+func (m *CommandConnected) GetProtocolVersion() int32 {
+	if m != nil && m.ProtocolVersion != nil {
+		return *m.ProtocolVersion
+	}
+	return 0
+}
+
+func (m *CommandConnected) GetMaxMessageSize() int32 {
+	if m != nil && m.MaxMessageSize != nil {
+		return *m.MaxMessageSize
+	}
+	return 0
+}
+
+type CommandPartitionedTopicMetadata struct {
+	Topic                *string  `protobuf:"bytes,1,req,name=topic" json:"topic,omitempty"`
+	RequestId            *uint64  `protobuf:"varint,2,req,name=request_id" json:"request_id,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *CommandPartitionedTopicMetadata) Reset()         { *m = CommandPartitionedTopicMetadata{} }
+func (m *CommandPartitionedTopicMetadata) String() string { return proto.CompactTextString(m) }
+func (*CommandPartitionedTopicMetadata) ProtoMessage()    {}
+
+func (m *CommandPartitionedTopicMetadata) GetTopic() string {
+	if m != nil && m.Topic != nil {
+		return *m.Topic
+	}
+	return ""
+}
+
+func (m *CommandPartitionedTopicMetadata) GetRequestId() uint64 {
+	if m != nil && m.RequestId != nil {
+		return *m.RequestId
+	}
+	return 0
+}
+
+type CommandPartitionedTopicMetadataResponse struct {
+	Partitions           *uint32                                             `protobuf:"varint,1,opt,name=partitions" json:"partitions,omitempty"`
+	RequestId            *uint64                                             `protobuf:"varint,2,req,name=request_id" json:"request_id,omitempty"`
+	Response             *CommandPartitionedTopicMetadataResponse_LookupType `protobuf:"varint,3,opt,name=response,enum=pulsar.proto.CommandPartitionedTopicMetadataResponse.LookupType" json:"response,omitempty"`
+	Error                *ServerError                                        `protobuf:"varint,4,opt,name=error,enum=pulsar.proto.ServerError" json:"error,omitempty"`
+	Message              *string                                             `protobuf:"bytes,5,opt,name=message" json:"message,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}                                            `json:"-"`
+	XXX_unrecognized     []byte                                              `json:"-"`
+	XXX_sizecache        int32                                               `json:"-"`
+}
+
+func (m *CommandPartitionedTopicMetadataResponse) Reset() {
+	*m = CommandPartitionedTopicMetadataResponse{}
+}
+func (m *CommandPartitionedTopicMetadataResponse) String() string { return proto.CompactTextString(m) }
+func (*CommandPartitionedTopicMetadataResponse) ProtoMessage()    {}
+
+func (m *CommandPartitionedTopicMetadataResponse) GetPartitions() uint32 {
+	if m != nil && m.Partitions != nil {
+		return *m.Partitions
+	}
+	return 0
+}
+
+func (m *CommandPartitionedTopicMetadataResponse) GetRequestId() uint64 {
+	if m != nil && m.RequestId != nil {
+		return *m.RequestId
+	}
+	return 0
+}
+
+func (m *CommandPartitionedTopicMetadataResponse) GetResponse() CommandPartitionedTopicMetadataResponse_LookupType {
+	if m != nil && m.Response != nil {
+		return *m.Response
+	}
+	return CommandPartitionedTopicMetadataResponse_Success
+}
+
+func (m *CommandPartitionedTopicMetadataResponse) GetError() ServerError {
+	if m != nil && m.Error != nil {
+		return *m.Error
+	}
+	return ServerError_UnknownError
+}
+
+func (m *CommandPartitionedTopicMetadataResponse) GetMessage() string {
+	if m != nil && m.Message != nil {
+		return *m.Message
+	}
+	return ""
+}
+
+type CommandLookupTopic struct {
+	Topic                *string  `protobuf:"bytes,1,req,name=topic" json:"topic,omitempty"`
+	RequestId            *uint64  `protobuf:"varint,2,req,name=request_id" json:"request_id,omitempty"`
+	Authoritative        *bool    `protobuf:"varint,3,opt,name=authoritative,def=false" json:"authoritative,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *CommandLookupTopic) Reset()         { *m = CommandLookupTopic{} }
+func (m *CommandLookupTopic) String() string { return proto.CompactTextString(m) }
+func (*CommandLookupTopic) ProtoMessage()    {}
+
+func (m *CommandLookupTopic) GetTopic() string {
+	if m != nil && m.Topic != nil {
+		return *m.Topic
+	}
+	return ""
+}
+
+func (m *CommandLookupTopic) GetRequestId() uint64 {
+	if m != nil && m.RequestId != nil {
+		return *m.RequestId
+	}
+	return 0
+}
+
+func (m *CommandLookupTopic) GetAuthoritative() bool {
+	if m != nil && m.Authoritative != nil {
+		return *m.Authoritative
+	}
+	return false
+}
+
+type CommandLookupTopicResponse struct {
+	BrokerServiceUrl       *string                                `protobuf:"bytes,1,opt,name=brokerServiceUrl" json:"brokerServiceUrl,omitempty"`
+	BrokerServiceUrlTls    *string                                `protobuf:"bytes,2,opt,name=brokerServiceUrlTls" json:"brokerServiceUrlTls,omitempty"`
+	Response               *CommandLookupTopicResponse_LookupType `protobuf:"varint,3,opt,name=response,enum=pulsar.proto.CommandLookupTopicResponse.LookupType" json:"response,omitempty"`
+	RequestId              *uint64                                `protobuf:"varint,4,req,name=request_id" json:"request_id,omitempty"`
+	Authoritative          *bool                                  `protobuf:"varint,5,opt,name=authoritative,def=false" json:"authoritative,omitempty"`
+	Error                  *ServerError                           `protobuf:"varint,6,opt,name=error,enum=pulsar.proto.ServerError" json:"error,omitempty"`
+	Message                *string                                `protobuf:"bytes,7,opt,name=message" json:"message,omitempty"`
+	ProxyThroughServiceUrl *bool                                  `protobuf:"varint,8,opt,name=proxy_through_service_url,def=false" json:"proxy_through_service_url,omitempty"`
+	XXX_NoUnkeyedLiteral   struct{}                               `json:"-"`
+	XXX_unrecognized       []byte                                 `json:"-"`
+	XXX_sizecache          int32                                  `json:"-"`
+}
+
+func (m *CommandLookupTopicResponse) Reset()         { *m = CommandLookupTopicResponse{} }
+func (m *CommandLookupTopicResponse) String() string { return proto.CompactTextString(m) }
+func (*CommandLookupTopicResponse) ProtoMessage()    {}
+
+func (m *CommandLookupTopicResponse) GetBrokerServiceUrl() string {
+	if m != nil && m.BrokerServiceUrl != nil {
+		return *m.BrokerServiceUrl
+	}
+	return ""
+}
+
+func (m *CommandLookupTopicResponse) GetBrokerServiceUrlTls() string {
+	if m != nil && m.BrokerServiceUrlTls != nil {
+		return *m.BrokerServiceUrlTls
+	}
+	return ""
+}
+
+func (m *CommandLookupTopicResponse) GetResponse() CommandLookupTopicResponse_LookupType {
+	if m != nil && m.Response != nil {
+		return *m.Response
+	}
+	return CommandLookupTopicResponse_Redirect
+}
+
+func (m *CommandLookupTopicResponse) GetRequestId() uint64 {
+	if m != nil && m.RequestId != nil {
+		return *m.RequestId
+	}
+	return 0
+}
+
+func (m *CommandLookupTopicResponse) GetAuthoritative() bool {
+	if m != nil && m.Authoritative != nil {
+		return *m.Authoritative
+	}
+	return false
+}
+
+func (m *CommandLookupTopicResponse) GetError() ServerError {
+	if m != nil && m.Error != nil {
+		return *m.Error
+	}
+	return ServerError_UnknownError
+}
+
+func (m *CommandLookupTopicResponse) GetMessage() string {
+	if m != nil && m.Message != nil {
+		return *m.Message
+	}
+	return ""
+}
+
+func (m *CommandLookupTopicResponse) GetProxyThroughServiceUrl() bool {
+	if m != nil && m.ProxyThroughServiceUrl != nil {
+		return *m.ProxyThroughServiceUrl
+	}
+	return false
+}
+
+type CommandProducer struct {
+	Topic                *string  `protobuf:"bytes,1,req,name=topic" json:"topic,omitempty"`
+	ProducerId           *uint64  `protobuf:"varint,2,req,name=producer_id" json:"producer_id,omitempty"`
+	RequestId            *uint64  `protobuf:"varint,3,req,name=request_id" json:"request_id,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *CommandProducer) Reset()         { *m = CommandProducer{} }
+func (m *CommandProducer) String() string { return proto.CompactTextString(m) }
+func (*CommandProducer) ProtoMessage()    {}
+
+func (m *CommandProducer) GetTopic() string {
+	if m != nil && m.Topic != nil {
+		return *m.Topic
+	}
+	return ""
+}
+
+func (m *CommandProducer) GetProducerId() uint64 {
+	if m != nil && m.ProducerId != nil {
+		return *m.ProducerId
+	}
+	return 0
+}
+
+func (m *CommandProducer) GetRequestId() uint64 {
+	if m != nil && m.RequestId != nil {
+		return *m.RequestId
+	}
+	return 0
+}
+
+type CommandProducerSuccess struct {
+	RequestId            *uint64  `protobuf:"varint,1,req,name=request_id" json:"request_id,omitempty"`
+	ProducerName         *string  `protobuf:"bytes,2,req,name=producer_name" json:"producer_name,omitempty"`
+	LastSequenceId       *int64   `protobuf:"varint,3,opt,name=last_sequence_id,def=-1" json:"last_sequence_id,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *CommandProducerSuccess) Reset()         { *m = CommandProducerSuccess{} }
+func (m *CommandProducerSuccess) String() string { return proto.CompactTextString(m) }
+func (*CommandProducerSuccess) ProtoMessage()    {}
+
+func (m *CommandProducerSuccess) GetRequestId() uint64 {
+	if m != nil && m.RequestId != nil {
+		return *m.RequestId
+	}
+	return 0
+}
+
+func (m *CommandProducerSuccess) GetProducerName() string {
+	if m != nil && m.ProducerName != nil {
+		return *m.ProducerName
+	}
+	return ""
+}
+
+func (m *CommandProducerSuccess) GetLastSequenceId() int64 {
+	if m != nil && m.LastSequenceId != nil {
+		return *m.LastSequenceId
+	}
+	return -1
+}
+
+type CommandSend struct {
+	ProducerId           *uint64  `protobuf:"varint,1,req,name=producer_id" json:"producer_id,omitempty"`
+	SequenceId           *uint64  `protobuf:"varint,2,req,name=sequence_id" json:"sequence_id,omitempty"`
+	NumMessages          *int32   `protobuf:"varint,3,opt,name=num_messages,def=1" json:"num_messages,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *CommandSend) Reset()         { *m = CommandSend{} }
+func (m *CommandSend) String() string { return proto.CompactTextString(m) }
+func (*CommandSend) ProtoMessage()    {}
+
+func (m *CommandSend) GetProducerId() uint64 {
+	if m != nil && m.ProducerId != nil {
+		return *m.ProducerId
+	}
+	return 0
+}
+
+func (m *CommandSend) GetSequenceId() uint64 {
+	if m != nil && m.SequenceId != nil {
+		return *m.SequenceId
+	}
+	return 0
+}
+
+func (m *CommandSend) GetNumMessages() int32 {
+	if m != nil && m.NumMessages != nil {
+		return *m.NumMessages
+	}
+	return 1
+}
+
+type CommandSendReceipt struct {
+	ProducerId           *uint64        `protobuf:"varint,1,req,name=producer_id" json:"producer_id,omitempty"`
+	SequenceId           *uint64        `protobuf:"varint,2,req,name=sequence_id" json:"sequence_id,omitempty"`
+	MessageId            *MessageIdData `protobuf:"bytes,3,opt,name=message_id" json:"message_id,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}       `json:"-"`
+	XXX_unrecognized     []byte         `json:"-"`
+	XXX_sizecache        int32          `json:"-"`
+}
+
+func (m *CommandSendReceipt) Reset()         { *m = CommandSendReceipt{} }
+func (m *CommandSendReceipt) String() string { return proto.CompactTextString(m) }
+func (*CommandSendReceipt) ProtoMessage()    {}
+
+func (m *CommandSendReceipt) GetProducerId() uint64 {
+	if m != nil && m.ProducerId != nil {
+		return *m.ProducerId
+	}
+	return 0
+}
+
+func (m *CommandSendReceipt) GetSequenceId() uint64 {
+	if m != nil && m.SequenceId != nil {
+		return *m.SequenceId
+	}
+	return 0
+}
+
+func (m *CommandSendReceipt) GetMessageId() *MessageIdData {
+	if m != nil {
+		return m.MessageId
+	}
+	return nil
+}
+
+type CommandSendError struct {
+	ProducerId           *uint64      `protobuf:"varint,1,req,name=producer_id" json:"producer_id,omitempty"`
+	SequenceId           *uint64      `protobuf:"varint,2,req,name=sequence_id" json:"sequence_id,omitempty"`
+	Error                *ServerError `protobuf:"varint,3,req,name=error,enum=pulsar.proto.ServerError" json:"error,omitempty"`
+	Message              *string      `protobuf:"bytes,4,req,name=message" json:"message,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}     `json:"-"`
+	XXX_unrecognized     []byte       `json:"-"`
+	XXX_sizecache        int32        `json:"-"`
+}
+
+func (m *CommandSendError) Reset()         { *m = CommandSendError{} }
+func (m *CommandSendError) String() string { return proto.CompactTextString(m) }
+func (*CommandSendError) ProtoMessage()    {}
+
+func (m *CommandSendError) GetProducerId() uint64 {
+	if m != nil && m.ProducerId != nil {
+		return *m.ProducerId
+	}
+	return 0
+}
+
+func (m *CommandSendError) GetSequenceId() uint64 {
+	if m != nil && m.SequenceId != nil {
+		return *m.SequenceId
+	}
+	return 0
+}
+
+func (m *CommandSendError) GetError() ServerError {
+	if m != nil && m.Error != nil {
+		return *m.Error
+	}
+	return ServerError_UnknownError
+}
+
+func (m *CommandSendError) GetMessage() string {
+	if m != nil && m.Message != nil {
+		return *m.Message
+	}
+	return ""
+}
+
+type CommandSuccess struct {
+	RequestId            *uint64  `protobuf:"varint,1,req,name=request_id" json:"request_id,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *CommandSuccess) Reset()         { *m = CommandSuccess{} }
+func (m *CommandSuccess) String() string { return proto.CompactTextString(m) }
+func (*CommandSuccess) ProtoMessage()    {}
+
+func (m *CommandSuccess) GetRequestId() uint64 {
+	if m != nil && m.RequestId != nil {
+		return *m.RequestId
+	}
+	return 0
+}
+
+type CommandError struct {
+	RequestId            *uint64      `protobuf:"varint,1,req,name=request_id" json:"request_id,omitempty"`
+	Error                *ServerError `protobuf:"varint,2,req,name=error,enum=pulsar.proto.ServerError" json:"error,omitempty"`
+	Message              *string      `protobuf:"bytes,3,req,name=message" json:"message,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}     `json:"-"`
+	XXX_unrecognized     []byte       `json:"-"`
+	XXX_sizecache        int32        `json:"-"`
+}
+
+func (m *CommandError) Reset()         { *m = CommandError{} }
+func (m *CommandError) String() string { return proto.CompactTextString(m) }
+func (*CommandError) ProtoMessage()    {}
+
+func (m *CommandError) GetRequestId() uint64 {
+	if m != nil && m.RequestId != nil {
+		return *m.RequestId
+	}
+	return 0
+}
+
+func (m *CommandError) GetError() ServerError {
+	if m != nil && m.Error != nil {
+		return *m.Error
+	}
+	return ServerError_UnknownError
+}
+
+func (m *CommandError) GetMessage() string {
+	if m != nil && m.Message != nil {
+		return *m.Message
+	}
+	return ""
+}
+
+type CommandCloseProducer struct {
+	ProducerId           *uint64  `protobuf:"varint,1,req,name=producer_id" json:"producer_id,omitempty"`
+	RequestId            *uint64  `protobuf:"varint,2,req,name=request_id" json:"request_id,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *CommandCloseProducer) Reset()         { *m = CommandCloseProducer{} }
+func (m *CommandCloseProducer) String() string { return proto.CompactTextString(m) }
+func (*CommandCloseProducer) ProtoMessage()    {}
+
+func (m *CommandCloseProducer) GetProducerId() uint64 {
+	if m != nil && m.ProducerId != nil {
+		return *m.ProducerId
+	}
+	return 0
+}
+
+func (m *CommandCloseProducer) GetRequestId() uint64 {
+	if m != nil && m.RequestId != nil {
+		return *m.RequestId
+	}
+	return 0
+}
+
+type CommandPing struct {
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *CommandPing) Reset()         { *m = CommandPing{} }
+func (m *CommandPing) String() string { return proto.CompactTextString(m) }
+func (*CommandPing) ProtoMessage()    {}
+
+type CommandPong struct {
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *CommandPong) Reset()         { *m = CommandPong{} }
+func (m *CommandPong) String() string { return proto.CompactTextString(m) }
+func (*CommandPong) ProtoMessage()    {}
+
+type BaseCommand struct {
+	Type                      *BaseCommand_Type                        `protobuf:"varint,1,req,name=type,enum=pulsar.proto.BaseCommand.Type" json:"type,omitempty"`
+	Connect                   *CommandConnect                          `protobuf:"bytes,2,opt,name=connect" json:"connect,omitempty"`
+	Connected                 *CommandConnected                        `protobuf:"bytes,3,opt,name=connected" json:"connected,omitempty"`
+	Producer                  *CommandProducer                         `protobuf:"bytes,5,opt,name=producer" json:"producer,omitempty"`
+	Send                      *CommandSend                             `protobuf:"bytes,6,opt,name=send" json:"send,omitempty"`
+	SendReceipt               *CommandSendReceipt                      `protobuf:"bytes,7,opt,name=send_receipt" json:"send_receipt,omitempty"`
+	SendError                 *CommandSendError                        `protobuf:"bytes,8,opt,name=send_error" json:"send_error,omitempty"`
+	Success                   *CommandSuccess                          `protobuf:"bytes,13,opt,name=success" json:"success,omitempty"`
+	Error                     *CommandError                            `protobuf:"bytes,14,opt,name=error" json:"error,omitempty"`
+	CloseProducer             *CommandCloseProducer                    `protobuf:"bytes,15,opt,name=close_producer" json:"close_producer,omitempty"`
+	ProducerSuccess           *CommandProducerSuccess                  `protobuf:"bytes,17,opt,name=producer_success" json:"producer_success,omitempty"`
+	Ping                      *CommandPing                             `protobuf:"bytes,18,opt,name=ping" json:"ping,omitempty"`
+	Pong                      *CommandPong                             `protobuf:"bytes,19,opt,name=pong" json:"pong,omitempty"`
+	PartitionMetadata         *CommandPartitionedTopicMetadata         `protobuf:"bytes,21,opt,name=partitionMetadata" json:"partitionMetadata,omitempty"`
+	PartitionMetadataResponse *CommandPartitionedTopicMetadataResponse `protobuf:"bytes,22,opt,name=partitionMetadataResponse" json:"partitionMetadataResponse,omitempty"`
+	LookupTopic               *CommandLookupTopic                      `protobuf:"bytes,23,opt,name=lookupTopic" json:"lookupTopic,omitempty"`
+	LookupTopicResponse       *CommandLookupTopicResponse              `protobuf:"bytes,24,opt,name=lookupTopicResponse" json:"lookupTopicResponse,omitempty"`
+	XXX_NoUnkeyedLiteral      struct{}                                 `json:"-"`
+	XXX_unrecognized          []byte                                   `json:"-"`
+	XXX_sizecache             int32                                    `json:"-"`
+}
+
+func (m *BaseCommand) Reset()         { *m = BaseCommand{} }
+func (m *BaseCommand) String() string { return proto.CompactTextString(m) }
+func (*BaseCommand) ProtoMessage()    {}
+
+func (m *BaseCommand) GetType() BaseCommand_Type {
+	if m != nil && m.Type != nil {
+		return *m.Type
+	}
+	return BaseCommand_CONNECT
+}
+
+func (m *BaseCommand) GetConnect() *CommandConnect {
+	if m != nil {
+		return m.Connect
+	}
+	return nil
+}
+
+func (m *BaseCommand) GetConnected() *CommandConnected {
+	if m != nil {
+		return m.Connected
+	}
+	return nil
+}
+
+func (m *BaseCommand) GetProducer() *CommandProducer {
+	if m != nil {
+		return m.Producer
+	}
+	return nil
+}
+
+func (m *BaseCommand) GetSend() *CommandSend {
+	if m != nil {
+		return m.Send
+	}
+	return nil
+}
+
+func (m *BaseCommand) GetSendReceipt() *CommandSendReceipt {
+	if m != nil {
+		return m.SendReceipt
+	}
+	return nil
+}
+
+func (m *BaseCommand) GetSendError() *CommandSendError {
+	if m != nil {
+		return m.SendError
+	}
+	return nil
+}
+
+func (m *BaseCommand) GetSuccess() *CommandSuccess {
+	if m != nil {
+		return m.Success
+	}
+	return nil
+}
+
+func (m *BaseCommand) GetError() *CommandError {
+	if m != nil {
+		return m.Error
+	}
+	return nil
+}
+
+func (m *BaseCommand) GetCloseProducer() *CommandCloseProducer {
+	if m != nil {
+		return m.CloseProducer
+	}
+	return nil
+}
+
+func (m *BaseCommand) GetProducerSuccess() *CommandProducerSuccess {
+	if m != nil {
+		return m.ProducerSuccess
+	}
+	return nil
+}
+
+func (m *BaseCommand) GetPing() *CommandPing {
+	if m != nil {
+		return m.Ping
+	}
+	return nil
+}
+
+func (m *BaseCommand) GetPong() *CommandPong {
+	if m != nil {
+		return m.Pong
+	}
+	return nil
+}
+
+func (m *BaseCommand) GetPartitionMetadata() *CommandPartitionedTopicMetadata {
+	if m != nil {
+		return m.PartitionMetadata
+	}
+	return nil
+}
+
+func (m *BaseCommand) GetPartitionMetadataResponse() *CommandPartitionedTopicMetadataResponse {
+	if m != nil {
+		return m.PartitionMetadataResponse
+	}
+	return nil
+}
+
+func (m *BaseCommand) GetLookupTopic() *CommandLookupTopic {
+	if m != nil {
+		return m.LookupTopic
+	}
+	return nil
+}
+
+func (m *BaseCommand) GetLookupTopicResponse() *CommandLookupTopicResponse {
+	if m != nil {
+		return m.LookupTopicResponse
+	}
+	return nil
+}
+
+func init() {
+	proto.RegisterType((*MessageIdData)(nil), "pulsar.proto.MessageIdData")
+	proto.RegisterType((*MessageMetadata)(nil), "pulsar.proto.MessageMetadata")
+	proto.RegisterType((*SingleMessageMetadata)(nil), "pulsar.proto.SingleMessageMetadata")
+	proto.RegisterType((*CommandConnect)(nil), "pulsar.proto.CommandConnect")
+	proto.RegisterType((*CommandConnected)(nil), "pulsar.proto.CommandConnected")
+	proto.RegisterType((*CommandPartitionedTopicMetadata)(nil), "pulsar.proto.CommandPartitionedTopicMetadata")
+	proto.RegisterType((*CommandPartitionedTopicMetadataResponse)(nil), "pulsar.proto.CommandPartitionedTopicMetadataResponse")
+	proto.RegisterType((*CommandLookupTopic)(nil), "pulsar.proto.CommandLookupTopic")
+	proto.RegisterType((*CommandLookupTopicResponse)(nil), "pulsar.proto.CommandLookupTopicResponse")
+	proto.RegisterType((*CommandProducer)(nil), "pulsar.proto.CommandProducer")
+	proto.RegisterType((*CommandProducerSuccess)(nil), "pulsar.proto.CommandProducerSuccess")
+	proto.RegisterType((*CommandSend)(nil), "pulsar.proto.CommandSend")
+	proto.RegisterType((*CommandSendReceipt)(nil), "pulsar.proto.CommandSendReceipt")
+	proto.RegisterType((*CommandSendError)(nil), "pulsar.proto.CommandSendError")
+	proto.RegisterType((*CommandSuccess)(nil), "pulsar.proto.CommandSuccess")
+	proto.RegisterType((*CommandError)(nil), "pulsar.proto.CommandError")
+	proto.RegisterType((*CommandCloseProducer)(nil), "pulsar.proto.CommandCloseProducer")
+	proto.RegisterType((*CommandPing)(nil), "pulsar.proto.CommandPing")
+	proto.RegisterType((*CommandPong)(nil), "pulsar.proto.CommandPong")
+	proto.RegisterType((*BaseCommand)(nil), "pulsar.proto.BaseCommand")
+}
@@ -0,0 +1,151 @@
+// Tencent is pleased to support the open source community by making bkunifylogbeat 蓝鲸日志采集器 available.
+//
+// Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+//
+// bkunifylogbeat 蓝鲸日志采集器 is licensed under the MIT License.
+//
+// License for bkunifylogbeat 蓝鲸日志采集器:
+// --------------------------------------------------------------------
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+// documentation files (the "Software"), to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software,
+// and to permit persons to whom the Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all copies or substantial
+// portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+// LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN
+// NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+// WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+// SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package task
+
+import (
+	"math"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// 事件速率估算：每秒用本周期事件数更新一次指数移动平均(EMA)
+//
+//	ema = alpha * current + (1 - alpha) * ema
+//
+// alpha越大，对速率突变越敏感，但曲线抖动也越明显；alpha越小，曲线越平滑，但对突增/突降的反应越迟缓。
+// 用于趋势观察时建议0.1~0.3，用于异常检测时可适当调大。
+const defaultEventRateAlpha = 0.3
+
+var (
+	eventRateAlpha = math.Float64bits(defaultEventRateAlpha)
+	rateEstimators sync.Map // taskID => *rateEstimator
+	// 后台更新协程随第一个任务注册启动，最后一个任务注销时退出
+	rateEstimatorMutex sync.Mutex
+	rateEstimatorCount int
+	rateEstimatorDone  chan struct{}
+)
+
+// rateEstimator: 单个任务的事件速率，ema为每秒事件数*1000，便于原子读写
+type rateEstimator struct {
+	count int64
+	ema   int64
+}
+
+// add: 记录一个事件
+func (r *rateEstimator) add() {
+	atomic.AddInt64(&r.count, 1)
+}
+
+// update: 按当前alpha用本周期事件数更新EMA
+func (r *rateEstimator) update(alpha float64) {
+	current := float64(atomic.SwapInt64(&r.count, 0))
+	last := float64(atomic.LoadInt64(&r.ema)) / 1000
+	atomic.StoreInt64(&r.ema, int64((alpha*current+(1-alpha)*last)*1000))
+}
+
+// SetEventRateAlpha 设置EMA平滑系数，取值范围(0, 1]，不合法时使用默认值
+func SetEventRateAlpha(alpha float64) {
+	if alpha <= 0 || alpha > 1 {
+		alpha = defaultEventRateAlpha
+	}
+	atomic.StoreUint64(&eventRateAlpha, math.Float64bits(alpha))
+}
+
+// GetEventRateEMA 获取任务平滑后的事件速率(条/秒)，任务不存在时返回0
+func GetEventRateEMA(taskID string) float64 {
+	r, ok := rateEstimators.Load(taskID)
+	if !ok {
+		return 0
+	}
+	return float64(atomic.LoadInt64(&r.(*rateEstimator).ema)) / 1000
+}
+
+// registerRateEstimator: 注册任务速率估算，没有运行中的后台更新协程时启动
+func registerRateEstimator(taskID string) *rateEstimator {
+	rateEstimatorMutex.Lock()
+	defer rateEstimatorMutex.Unlock()
+	r := &rateEstimator{}
+	if _, loaded := rateEstimators.Load(taskID); !loaded {
+		rateEstimatorCount++
+	}
+	rateEstimators.Store(taskID, r)
+	if rateEstimatorDone == nil {
+		rateEstimatorDone = make(chan struct{})
+		go runRateEstimators(rateEstimatorDone)
+	}
+	return r
+}
+
+// unregisterRateEstimator: 任务停止时移除速率估算，没有任务时停止后台更新协程
+func unregisterRateEstimator(taskID string) {
+	rateEstimatorMutex.Lock()
+	defer rateEstimatorMutex.Unlock()
+	if _, loaded := rateEstimators.Load(taskID); !loaded {
+		return
+	}
+	rateEstimators.Delete(taskID)
+	rateEstimatorCount--
+	if rateEstimatorCount == 0 && rateEstimatorDone != nil {
+		close(rateEstimatorDone)
+		rateEstimatorDone = nil
+	}
+}
+
+// renameRateEstimator: 任务ID变化时保留已有的速率估算
+func renameRateEstimator(oldTaskID, newTaskID string) {
+	rateEstimatorMutex.Lock()
+	defer rateEstimatorMutex.Unlock()
+	r, ok := rateEstimators.Load(oldTaskID)
+	if !ok {
+		return
+	}
+	rateEstimators.Delete(oldTaskID)
+	if _, loaded := rateEstimators.Load(newTaskID); loaded {
+		rateEstimatorCount--
+	}
+	rateEstimators.Store(newTaskID, r)
+}
+
+// runRateEstimators: 每秒更新所有任务的EMA，done关闭后退出
+func runRateEstimators(done <-chan struct{}) {
+	ticker := time.NewTicker(1 * time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			updateRateEstimators()
+		}
+	}
+}
+
+// updateRateEstimators: 按当前alpha更新所有任务的EMA
+func updateRateEstimators() {
+	alpha := math.Float64frombits(atomic.LoadUint64(&eventRateAlpha))
+	rateEstimators.Range(func(key, value interface{}) bool {
+		value.(*rateEstimator).update(alpha)
+		return true
+	})
+}
@@ -0,0 +1,85 @@
+// Tencent is pleased to support the open source community by making bkunifylogbeat 蓝鲸日志采集器 available.
+//
+// Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+//
+// bkunifylogbeat 蓝鲸日志采集器 is licensed under the MIT License.
+//
+// License for bkunifylogbeat 蓝鲸日志采集器:
+// --------------------------------------------------------------------
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+// documentation files (the "Software"), to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software,
+// and to permit persons to whom the Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all copies or substantial
+// portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+// LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN
+// NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+// WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+// SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package task
+
+import (
+	"math"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestRateEstimatorUpdate: 测试按alpha更新EMA
+func TestRateEstimatorUpdate(t *testing.T) {
+	r := &rateEstimator{}
+	for i := 0; i < 10; i++ {
+		r.add()
+	}
+	r.update(0.5)
+	assert.Equal(t, int64(5000), atomic.LoadInt64(&r.ema))
+	// 本周期计数已清零
+	r.update(0.5)
+	assert.Equal(t, int64(2500), atomic.LoadInt64(&r.ema))
+
+	SetEventRateAlpha(2)
+	assert.Equal(t, defaultEventRateAlpha, math.Float64frombits(atomic.LoadUint64(&eventRateAlpha)))
+}
+
+// TestRateEstimatorLifetime: 测试后台更新协程随任务注册启动，所有任务注销后停止
+func TestRateEstimatorLifetime(t *testing.T) {
+	a := registerRateEstimator("rate-a")
+	registerRateEstimator("rate-b")
+	// 重复注册不重复计数
+	registerRateEstimator("rate-b")
+	done := rateEstimatorDone
+	assert.NotNil(t, done)
+	assert.Equal(t, 2, rateEstimatorCount)
+
+	a.add()
+	a.add()
+	updateRateEstimators()
+	assert.Equal(t, defaultEventRateAlpha*2, GetEventRateEMA("rate-a"))
+	assert.Equal(t, float64(0), GetEventRateEMA("rate-unknown"))
+
+	unregisterRateEstimator("rate-a")
+	unregisterRateEstimator("rate-unknown")
+	assert.Equal(t, 1, rateEstimatorCount)
+	assert.Equal(t, done, rateEstimatorDone)
+
+	// 改名不影响计数
+	renameRateEstimator("rate-b", "rate-c")
+	assert.Equal(t, 1, rateEstimatorCount)
+
+	unregisterRateEstimator("rate-c")
+	assert.Equal(t, 0, rateEstimatorCount)
+	assert.Nil(t, rateEstimatorDone)
+	_, open := <-done
+	assert.False(t, open)
+
+	// 再次注册时重新启动
+	registerRateEstimator("rate-a")
+	assert.NotNil(t, rateEstimatorDone)
+	unregisterRateEstimator("rate-a")
+	assert.Nil(t, rateEstimatorDone)
+}
@@ -0,0 +1,93 @@
+// Tencent is pleased to support the open source community by making bkunifylogbeat 蓝鲸日志采集器 available.
+//
+// Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+//
+// bkunifylogbeat 蓝鲸日志采集器 is licensed under the MIT License.
+//
+// License for bkunifylogbeat 蓝鲸日志采集器:
+// --------------------------------------------------------------------
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+// documentation files (the "Software"), to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software,
+// and to permit persons to whom the Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all copies or substantial
+// portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+// LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN
+// NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+// WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+// SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package task
+
+import (
+	"regexp"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// 正则缓存：按表达式缓存编译结果，配置重载重建任务时复用已编译的正则，新表达式在创建任务时预编译。
+// 运行中的任务持有编译结果，缓存淘汰只影响之后新建的任务，不影响正在处理的事件。
+const defaultRegexCacheTTL = 10 * time.Minute
+
+var (
+	regexCacheTTL     = int64(defaultRegexCacheTTL)
+	regexCache        sync.Map // pattern => *regexCacheEntry
+	regexCacheStarted sync.Once
+)
+
+// regexCacheEntry: 编译结果及最后一次使用时间(UnixNano)
+type regexCacheEntry struct {
+	regex    *regexp.Regexp
+	lastUsed int64
+}
+
+// SetRegexCacheTTL 设置正则缓存的淘汰时间，小于等于0时使用默认值
+func SetRegexCacheTTL(ttl time.Duration) {
+	if ttl <= 0 {
+		ttl = defaultRegexCacheTTL
+	}
+	atomic.StoreInt64(&regexCacheTTL, int64(ttl))
+}
+
+// compileRegex: 优先从缓存获取编译结果，首次使用时启动后台清理协程
+func compileRegex(pattern string) (*regexp.Regexp, error) {
+	regexCacheStarted.Do(func() {
+		go runRegexCacheCleanup()
+	})
+	now := time.Now().UnixNano()
+	if value, ok := regexCache.Load(pattern); ok {
+		entry := value.(*regexCacheEntry)
+		atomic.StoreInt64(&entry.lastUsed, now)
+		return entry.regex, nil
+	}
+	regex, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	value, _ := regexCache.LoadOrStore(pattern, &regexCacheEntry{regex: regex, lastUsed: now})
+	return value.(*regexCacheEntry).regex, nil
+}
+
+// runRegexCacheCleanup: 定期淘汰超过TTL未使用的正则
+func runRegexCacheCleanup() {
+	ticker := time.NewTicker(1 * time.Minute)
+	defer ticker.Stop()
+	for range ticker.C {
+		evictRegexCache(time.Now())
+	}
+}
+
+// evictRegexCache: 淘汰在now之前超过TTL未使用的正则
+func evictRegexCache(now time.Time) {
+	expire := now.UnixNano() - atomic.LoadInt64(&regexCacheTTL)
+	regexCache.Range(func(key, value interface{}) bool {
+		if atomic.LoadInt64(&value.(*regexCacheEntry).lastUsed) < expire {
+			regexCache.Delete(key)
+		}
+		return true
+	})
+}
@@ -0,0 +1,142 @@
+// Tencent is pleased to support the open source community by making bkunifylogbeat 蓝鲸日志采集器 available.
+//
+// Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+//
+// bkunifylogbeat 蓝鲸日志采集器 is licensed under the MIT License.
+//
+// License for bkunifylogbeat 蓝鲸日志采集器:
+// --------------------------------------------------------------------
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+// documentation files (the "Software"), to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software,
+// and to permit persons to whom the Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all copies or substantial
+// portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+// LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN
+// NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+// WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+// SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package task
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/TencentBlueKing/bkunifylogbeat/config"
+	"github.com/TencentBlueKing/collector-go-sdk/v2/bkbeat/bkmonitoring"
+	"github.com/TencentBlueKing/collector-go-sdk/v2/bkbeat/logp"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+)
+
+const defaultS3RefreshInterval = 5 * time.Minute
+
+var (
+	filterS3RefreshTotal      = bkmonitoring.NewInt("filter_s3_refresh_total")
+	filterS3RefreshErrorTotal = bkmonitoring.NewInt("filter_s3_refresh_error_total")
+)
+
+// s3Fetcher: 下载名单文件内容
+type s3Fetcher func() (io.ReadCloser, error)
+
+// newS3Fetcher: 按配置生成S3下载函数，凭证使用AWS SDK默认凭证链(环境变量、共享配置文件、实例角色等)
+// 单元测试中替换为本地内容
+var newS3Fetcher = func(c config.S3ListConfig) (s3Fetcher, error) {
+	sess, err := session.NewSessionWithOptions(session.Options{
+		Config:            aws.Config{Region: aws.String(c.Region)},
+		SharedConfigState: session.SharedConfigEnable,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("create aws session failed, err=>%v", err)
+	}
+	client := s3.New(sess)
+	return func() (io.ReadCloser, error) {
+		output, err := client.GetObject(&s3.GetObjectInput{
+			Bucket: aws.String(c.Bucket),
+			Key:    aws.String(c.Key),
+		})
+		if err != nil {
+			return nil, err
+		}
+		return output.Body, nil
+	}, nil
+}
+
+// s3List: 存放在S3上的黑白名单，文件每行一个值，定期刷新并原子替换，匹配过程无锁
+type s3List struct {
+	config config.S3ListConfig
+	fetch  s3Fetcher
+	values atomic.Value // map[string]struct{}
+}
+
+func newS3List(c config.S3ListConfig, done <-chan struct{}) (*s3List, error) {
+	fetch, err := newS3Fetcher(c)
+	if err != nil {
+		return nil, err
+	}
+	l := &s3List{config: c, fetch: fetch}
+	if err = l.refresh(); err != nil {
+		return nil, fmt.Errorf("load s3 list(s3://%s/%s) failed, err=>%v", c.Bucket, c.Key, err)
+	}
+	go l.watch(done)
+	return l, nil
+}
+
+// refresh: 下载名单文件并替换当前名单
+func (l *s3List) refresh() error {
+	filterS3RefreshTotal.Add(1)
+	body, err := l.fetch()
+	if err != nil {
+		filterS3RefreshErrorTotal.Add(1)
+		return err
+	}
+	defer body.Close()
+
+	values := make(map[string]struct{})
+	scanner := bufio.NewScanner(body)
+	for scanner.Scan() {
+		if value := strings.TrimSpace(scanner.Text()); value != "" {
+			values[value] = struct{}{}
+		}
+	}
+	if err = scanner.Err(); err != nil {
+		filterS3RefreshErrorTotal.Add(1)
+		return err
+	}
+	l.values.Store(values)
+	return nil
+}
+
+// watch: 按RefreshInterval刷新，刷新失败时继续使用旧名单
+func (l *s3List) watch(done <-chan struct{}) {
+	interval := l.config.RefreshInterval
+	if interval <= 0 {
+		interval = defaultS3RefreshInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			if err := l.refresh(); err != nil {
+				logp.L.Errorf("refresh s3 list(s3://%s/%s) failed, err=>%v", l.config.Bucket, l.config.Key, err)
+			}
+		}
+	}
+}
+
+func (l *s3List) contains(word string) bool {
+	_, ok := l.values.Load().(map[string]struct{})[word]
+	return ok
+}
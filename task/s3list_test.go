@@ -0,0 +1,129 @@
+// Tencent is pleased to support the open source community by making bkunifylogbeat 蓝鲸日志采集器 available.
+//
+// Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+//
+// bkunifylogbeat 蓝鲸日志采集器 is licensed under the MIT License.
+//
+// License for bkunifylogbeat 蓝鲸日志采集器:
+// --------------------------------------------------------------------
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+// documentation files (the "Software"), to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software,
+// and to permit persons to whom the Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all copies or substantial
+// portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+// LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN
+// NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+// WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+// SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package task
+
+import (
+	"errors"
+	"io"
+	"io/ioutil"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/TencentBlueKing/bkunifylogbeat/config"
+	"github.com/stretchr/testify/assert"
+)
+
+// mockS3Object: 替换S3下载，返回当前设置的名单内容或错误
+type mockS3Object struct {
+	mutex   sync.Mutex
+	content string
+	err     error
+	fetched int
+}
+
+func (o *mockS3Object) set(content string, err error) {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+	o.content, o.err = content, err
+}
+
+func (o *mockS3Object) fetch() (io.ReadCloser, error) {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+	o.fetched++
+	if o.err != nil {
+		return nil, o.err
+	}
+	return ioutil.NopCloser(strings.NewReader(o.content)), nil
+}
+
+func (o *mockS3Object) fetchedTimes() int {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+	return o.fetched
+}
+
+func mockS3Fetcher(object *mockS3Object) func() {
+	origin := newS3Fetcher
+	newS3Fetcher = func(c config.S3ListConfig) (s3Fetcher, error) {
+		return object.fetch, nil
+	}
+	return func() { newS3Fetcher = origin }
+}
+
+// TestS3ListCondition: 测试s3_allow/s3_deny按名单匹配，首次下载失败时创建条件失败
+func TestS3ListCondition(t *testing.T) {
+	object := &mockS3Object{content: "10.0.0.1\n  10.0.0.2  \n\n"}
+	defer mockS3Fetcher(object)()
+	done := make(chan struct{})
+	defer close(done)
+
+	list := config.S3ListConfig{Bucket: "bucket", Key: "allow.txt", RefreshInterval: time.Hour}
+	allow, err := newCondition(config.ConditionConfig{Index: 1, Op: "s3_allow", S3List: list}, done)
+	assert.NoError(t, err)
+	deny, err := newCondition(config.ConditionConfig{Index: 1, Op: "s3_deny", S3List: list}, done)
+	assert.NoError(t, err)
+	for word, inList := range map[string]bool{"10.0.0.1": true, "10.0.0.2": true, "10.0.0.3": false, "": false} {
+		assert.Equal(t, inList, allow.operation(word), word)
+		assert.Equal(t, !inList, deny.operation(word), word)
+	}
+
+	object.set("", errors.New("access denied"))
+	_, err = newCondition(config.ConditionConfig{Index: 1, Op: "s3_allow", S3List: list}, done)
+	assert.Error(t, err)
+}
+
+// TestS3ListRefresh: 测试定期刷新后原子替换名单，刷新失败时继续使用旧名单
+func TestS3ListRefresh(t *testing.T) {
+	object := &mockS3Object{content: "alice\nbob\n"}
+	defer mockS3Fetcher(object)()
+	done := make(chan struct{})
+	defer close(done)
+
+	l, err := newS3List(config.S3ListConfig{Bucket: "bucket", Key: "users.txt", RefreshInterval: 10 * time.Millisecond}, done)
+	assert.NoError(t, err)
+	assert.True(t, l.contains("alice"))
+	assert.False(t, l.contains("carol"))
+
+	object.set("carol\n", nil)
+	for i := 0; !l.contains("carol"); i++ {
+		if i >= 500 {
+			t.Fatal("s3 list is not refreshed")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	// 整体替换，旧名单中的值不再匹配
+	assert.False(t, l.contains("alice"))
+
+	object.set("", errors.New("timeout"))
+	fetched := object.fetchedTimes()
+	for i := 0; object.fetchedTimes() < fetched+2; i++ {
+		if i >= 500 {
+			t.Fatal("s3 list is not refreshed")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.True(t, l.contains("carol"))
+}
@@ -48,6 +48,7 @@ type Sender struct {
 	taskConfig      *config.TaskConfig
 	taskDone        chan struct{}
 	cache           map[string][]*util.Data
+	cacheBytes      map[string]int // 按source统计已缓存事件的data字节数
 	input           chan *util.Data
 	wg              sync.WaitGroup
 	publisher       PublisherFunc
@@ -66,6 +67,7 @@ func NewSender(config *cfg.TaskConfig, taskDone chan struct{}, publisher Publish
 		taskConfig: config,
 		taskDone:   taskDone,
 		cache:      make(map[string][]*util.Data),
+		cacheBytes: make(map[string]int),
 		input:      make(chan *util.Data),
 		publisher:  publisher,
 	}
@@ -85,9 +87,9 @@ func NewSender(config *cfg.TaskConfig, taskDone chan struct{}, publisher Publish
 	}
 
 	//sender metrics
-	sender.senderReceive = bkmonitoring.NewIntWithDataID(config.DataID, "sender_received")
-	sender.senderState = bkmonitoring.NewIntWithDataID(config.DataID, "sender_state")
-	sender.senderSendTotal = bkmonitoring.NewIntWithDataID(config.DataID, "sender_send_total")
+	sender.senderReceive = newIntWithDataID(config.DataID, "sender_received")
+	sender.senderState = newIntWithDataID(config.DataID, "sender_state")
+	sender.senderSendTotal = newIntWithDataID(config.DataID, "sender_send_total")
 	return sender, nil
 }
 
@@ -133,6 +135,7 @@ func (client *Sender) run() error {
 				}
 			}
 			client.cache = make(map[string][]*util.Data)
+			client.cacheBytes = make(map[string]int)
 
 		case event := <-client.input:
 			err := client.cacheSend(event)
@@ -170,6 +173,7 @@ func (client *Sender) cacheSend(event *util.Data) error {
 			buffer = append(buffer, event)
 			client.send(buffer)
 			client.cache[source] = []*util.Data{}
+			client.cacheBytes[source] = 0
 			return nil
 		}
 		client.send([]*util.Data{event})
@@ -183,11 +187,19 @@ func (client *Sender) cacheSend(event *util.Data) error {
 		client.cache[source] = []*util.Data{event}
 	}
 
+	// 单行日志较大时，按字节数提前发送，避免打包后的事件过大
+	if data, ok := event.Event.Fields["data"].(string); ok {
+		client.cacheBytes[source] += len(data)
+	}
+	flushBytes := client.taskConfig.PackageFlushBytes
+
 	// if msg count reach max count, clear cache
-	if len(client.cache[source]) >= client.taskConfig.PackageCount {
+	if len(client.cache[source]) >= client.taskConfig.PackageCount ||
+		(flushBytes > 0 && client.cacheBytes[source] >= flushBytes) {
 		client.send(client.cache[source])
 		// clear cache
 		client.cache[source] = []*util.Data{}
+		client.cacheBytes[source] = 0
 	}
 	return nil
 }
@@ -23,6 +23,7 @@
 package task
 
 import (
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -41,7 +42,7 @@ func init() {
 	initMockFormatter()
 }
 
-var sendNums int
+var sendNums int64
 var fileSource1 string = "/tmp/test1.log"
 var fileSource2 string = "/tmp/test2.log"
 var fileText string = "test"
@@ -50,7 +51,7 @@ var packageCount int = 10
 var outputFormat = "v2"
 
 func mockPublisher(event beat.Event) bool {
-	sendNums += 1
+	atomic.AddInt64(&sendNums, 1)
 	return true
 }
 
@@ -121,13 +122,13 @@ func TestSend(t *testing.T) {
 	}
 
 	// Send
-	sendNums = 0
+	atomic.StoreInt64(&sendNums, 0)
 	sender.OnEvent(tests.MockLogEvent(fileSource1, fileText))
 	sender.OnEvent(tests.MockLogEvent(fileSource1, fileText))
 	sender.OnEvent(tests.MockLogEvent(fileSource2, fileText))
 
 	time.Sleep(500 * time.Millisecond)
-	assert.Equal(t, sendNums, 3)
+	assert.Equal(t, int64(3), atomic.LoadInt64(&sendNums))
 
 	// Package send
 	close(taskDone)
@@ -137,23 +138,23 @@ func TestSend(t *testing.T) {
 		panic(err)
 		return
 	}
-	sendNums = 0
+	atomic.StoreInt64(&sendNums, 0)
 	sender.OnEvent(tests.MockLogEvent(fileSource1, fileText))
 	sender.OnEvent(tests.MockLogEvent(fileSource1, fileText))
 	sender.OnEvent(tests.MockLogEvent(fileSource1, fileText))
 	time.Sleep(1200 * time.Millisecond)
-	assert.Equal(t, sendNums, 1)
+	assert.Equal(t, int64(1), atomic.LoadInt64(&sendNums))
 
 	// Package send: diff file
-	sendNums = 0
+	atomic.StoreInt64(&sendNums, 0)
 	sender.OnEvent(tests.MockLogEvent(fileSource1, fileText))
 	sender.OnEvent(tests.MockLogEvent(fileSource2, fileText))
 	sender.OnEvent(tests.MockLogEvent(fileSource1, fileText))
 	time.Sleep(1200 * time.Millisecond)
-	assert.Equal(t, sendNums, 2)
+	assert.Equal(t, int64(2), atomic.LoadInt64(&sendNums))
 
 	// Filter event
-	sendNums = 0
+	atomic.StoreInt64(&sendNums, 0)
 	// No.1 event
 	sender.OnEvent(tests.MockLogEvent(fileSource1, fileTextNull))
 	// No.2 event
@@ -168,7 +169,7 @@ func TestSend(t *testing.T) {
 	sender.OnEvent(tests.MockLogEvent(fileSource1, fileText))
 	sender.OnEvent(tests.MockLogEvent(fileSource1, fileText))
 	time.Sleep(1200 * time.Millisecond)
-	assert.Equal(t, sendNums, 5)
+	assert.Equal(t, int64(5), atomic.LoadInt64(&sendNums))
 
 	// Package Count
 	close(taskDone)
@@ -178,10 +179,42 @@ func TestSend(t *testing.T) {
 		panic(err)
 		return
 	}
-	sendNums = 0
+	atomic.StoreInt64(&sendNums, 0)
 	for i := 0; i < 8; i++ {
 		sender.OnEvent(tests.MockLogEvent(fileSource1, fileText))
 	}
 	time.Sleep(200 * time.Millisecond)
-	assert.Equal(t, sendNums, 4)
+	assert.Equal(t, int64(4), atomic.LoadInt64(&sendNums))
+}
+
+//TestSendFlushBytes: 测试按字节数提前发送
+func TestSendFlushBytes(t *testing.T) {
+	vars, err := common.NewConfigFrom(map[string]interface{}{
+		"dataid":              "999990001",
+		"package":             true,
+		"package_count":       packageCount,
+		"package_flush_bytes": 8,
+	})
+	if err != nil {
+		panic(err)
+	}
+	taskConfig, err := config.NewTaskConfig(vars)
+	if err != nil {
+		panic(err)
+	}
+	taskDone := make(chan struct{})
+	defer close(taskDone)
+	sender, err := NewSender(taskConfig, taskDone, mockPublisher)
+	if err != nil {
+		panic(err)
+	}
+	sender.Start()
+
+	// 每条4字节，每2条提前发送一次
+	atomic.StoreInt64(&sendNums, 0)
+	for i := 0; i < 4; i++ {
+		sender.OnEvent(tests.MockLogEvent(fileSource1, fileText))
+	}
+	time.Sleep(200 * time.Millisecond)
+	assert.Equal(t, int64(2), atomic.LoadInt64(&sendNums))
 }
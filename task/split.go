@@ -0,0 +1,79 @@
+// Tencent is pleased to support the open source community by making bkunifylogbeat 蓝鲸日志采集器 available.
+//
+// Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+//
+// bkunifylogbeat 蓝鲸日志采集器 is licensed under the MIT License.
+//
+// License for bkunifylogbeat 蓝鲸日志采集器:
+// --------------------------------------------------------------------
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+// documentation files (the "Software"), to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software,
+// and to permit persons to whom the Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all copies or substantial
+// portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+// LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN
+// NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+// WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+// SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package task
+
+import (
+	"fmt"
+	"unicode/utf8"
+
+	"github.com/TencentBlueKing/bkunifylogbeat/utils"
+	"github.com/TencentBlueKing/collector-go-sdk/v2/bkbeat/bkmonitoring"
+	"github.com/elastic/beats/filebeat/util"
+)
+
+var filterOversizeSplit = bkmonitoring.NewInt("filter_oversize_split")
+
+// splitOversize: 将data超出maxBytes的事件按UTF-8字符边界切分为多个事件，
+// 分片附加_part_id、_part_index(从0开始)及_part_total，下游可按_part_id重组；
+// 所有分片共享原事件的采集进度，未超出时返回原事件
+func splitOversize(data *util.Data, maxBytes int) []*util.Data {
+	if data.Event.Fields == nil {
+		return []*util.Data{data}
+	}
+	text, ok := data.Event.Fields["data"].(string)
+	if !ok || len(text) <= maxBytes {
+		return []*util.Data{data}
+	}
+	filterOversizeSplit.Add(1)
+
+	var parts []string
+	for len(text) > maxBytes {
+		cut := maxBytes
+		for cut > 0 && !utf8.RuneStart(text[cut]) {
+			cut--
+		}
+		// maxBytes小于单个字符的长度时按整个字符切分
+		if cut == 0 {
+			_, cut = utf8.DecodeRuneInString(text)
+		}
+		parts = append(parts, text[:cut])
+		text = text[cut:]
+	}
+	if text != "" {
+		parts = append(parts, text)
+	}
+
+	state := data.GetState()
+	partID := utils.Md5(fmt.Sprintf("%s:%d", state.Source, state.Offset))
+	result := make([]*util.Data, 0, len(parts))
+	for i, part := range parts {
+		chunk := *data
+		chunk.Event.Fields = data.Event.Fields.Clone()
+		chunk.Event.Fields["data"] = part
+		chunk.Event.Fields["_part_id"] = partID
+		chunk.Event.Fields["_part_index"] = i
+		chunk.Event.Fields["_part_total"] = len(parts)
+		result = append(result, &chunk)
+	}
+	return result
+}
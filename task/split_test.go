@@ -0,0 +1,48 @@
+// Tencent is pleased to support the open source community by making bkunifylogbeat 蓝鲸日志采集器 available.
+//
+// Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+//
+// bkunifylogbeat 蓝鲸日志采集器 is licensed under the MIT License.
+//
+// License for bkunifylogbeat 蓝鲸日志采集器:
+// --------------------------------------------------------------------
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+// documentation files (the "Software"), to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software,
+// and to permit persons to whom the Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all copies or substantial
+// portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+// LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN
+// NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+// WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+// SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package task
+
+import (
+	"testing"
+
+	"github.com/TencentBlueKing/bkunifylogbeat/tests"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestSplitOversize: 测试超大事件按字符边界切分及分片字段
+func TestSplitOversize(t *testing.T) {
+	result := splitOversize(tests.MockLogEvent("/a.log", "short"), 8)
+	assert.Len(t, result, 1)
+	assert.NotContains(t, result[0].Event.Fields, "_part_id")
+
+	result = splitOversize(tests.MockLogEvent("/a.log", "abcdef日志内容"), 8)
+	var texts []string
+	for i, data := range result {
+		texts = append(texts, data.Event.Fields["data"].(string))
+		assert.Equal(t, i, data.Event.Fields["_part_index"])
+		assert.Equal(t, len(result), data.Event.Fields["_part_total"])
+		assert.Equal(t, result[0].Event.Fields["_part_id"], data.Event.Fields["_part_id"])
+		assert.Equal(t, "/a.log", data.GetState().Source)
+	}
+	assert.Equal(t, []string{"abcdef", "日志", "内容"}, texts)
+}
@@ -27,6 +27,7 @@
 package task
 
 import (
+	"encoding/json"
 	"fmt"
 	"github.com/TencentBlueKing/bkunifylogbeat/utils"
 	"runtime"
@@ -55,6 +56,8 @@ var (
 	crawlerSendTotal = bkmonitoring.NewInt("crawler_send_total")
 	crawlerDropped   = bkmonitoring.NewInt("crawler_dropped")
 
+	filterMMapOverflowTotal = bkmonitoring.NewInt("filter_mmap_overflow_total")
+
 	isEnableRateLimiter bool            // 是否开启速率限制
 	cpuLimiter          *utils.CPULimit // CPU使用率限制
 )
@@ -89,7 +92,8 @@ func SetResourceLimit(maxCpuLimit, checkTimes int) {
 
 // Task： 采集任务具体实现，负责filebeat采集事件处理、过滤、打包，并发送到采集框架
 type Task struct {
-	ID               string
+	id               string // 只调整过滤条件热更新时会变化，通过GetID读取
+	idMutex          sync.RWMutex
 	config           *cfg.TaskConfig
 	beatDone         chan struct{}
 	runner           *input.Runner
@@ -101,20 +105,27 @@ type Task struct {
 	crawlerState     *monitoring.Int //state事件
 	crawlerSendTotal *monitoring.Int //正常事件总数
 	crawlerDropped   *monitoring.Int //过滤掉的事件总数
+	eventRate        *rateEstimator  //平滑后的事件速率
+	ringBuffer       *utils.MMRingBuffer
+	grpcOutput       *grpcOutput
+	multiline        *multiline
+	logMetrics       *logMetrics
+	containers       *containers
+	auditd           *auditd
 }
 
 // NewTask 生成采集任务实例
 func NewTask(config *cfg.TaskConfig, beatDone chan struct{}) *Task {
 	task := &Task{
-		ID:       config.ID,
+		id:       config.ID,
 		config:   config,
 		beatDone: beatDone,
 		done:     make(chan struct{}),
 	}
-	task.crawlerReceived = bkmonitoring.NewIntWithDataID(config.DataID, "crawler_received")
-	task.crawlerState = bkmonitoring.NewIntWithDataID(config.DataID, "crawler_state")
-	task.crawlerSendTotal = bkmonitoring.NewIntWithDataID(config.DataID, "crawler_send_total")
-	task.crawlerDropped = bkmonitoring.NewIntWithDataID(config.DataID, "crawler_dropped")
+	task.crawlerReceived = newIntWithDataID(config.DataID, "crawler_received")
+	task.crawlerState = newIntWithDataID(config.DataID, "crawler_state")
+	task.crawlerSendTotal = newIntWithDataID(config.DataID, "crawler_send_total")
+	task.crawlerDropped = newIntWithDataID(config.DataID, "crawler_dropped")
 	return task
 }
 
@@ -122,14 +133,73 @@ func NewTask(config *cfg.TaskConfig, beatDone chan struct{}) *Task {
 func (task *Task) Start(lastStates []file.State) error {
 	var err error
 
-	// init sender
-	sender, err := NewSender(task.config, task.done, beat.SendEvent)
+	// init sender，配置kafka_output、pulsar_output、elasticsearch_output或grpc_sender时打包事件直接发送到对应服务
+	publisher := PublisherFunc(beat.SendEvent)
+	if task.config.KafkaOutput.Enabled() {
+		kafkaOutput, err := newKafkaOutput(task.config, task.done, beat.SendEvent)
+		if err != nil {
+			senderFailed.Add(1)
+			return fmt.Errorf("[%s] error while initializing kafka output: %s", task.GetID(), err)
+		}
+		publisher = kafkaOutput.publish
+	}
+	if task.config.PulsarOutput.Enabled() {
+		pulsarOutput, err := newPulsarOutput(task.config, task.done, beat.SendEvent)
+		if err != nil {
+			senderFailed.Add(1)
+			return fmt.Errorf("[%s] error while initializing pulsar output: %s", task.GetID(), err)
+		}
+		publisher = pulsarOutput.publish
+	}
+	if task.config.ElasticsearchOutput.Enabled() {
+		esOutput, err := newESOutput(task.config, task.done, beat.SendEvent)
+		if err != nil {
+			senderFailed.Add(1)
+			return fmt.Errorf("[%s] error while initializing elasticsearch output: %s", task.GetID(), err)
+		}
+		publisher = esOutput.publish
+	}
+	if task.config.GRPCSender.Enabled() {
+		grpcSender, err := newGRPCSender(task.config, task.done, beat.SendEvent)
+		if err != nil {
+			senderFailed.Add(1)
+			return fmt.Errorf("[%s] error while initializing grpc sender: %s", task.GetID(), err)
+		}
+		publisher = grpcSender.publish
+	}
+	sender, err := NewSender(task.config, task.done, publisher)
 	if err != nil {
 		senderFailed.Add(1)
-		return fmt.Errorf("[%s] error while initializing sender: %s", task.ID, err)
+		return fmt.Errorf("[%s] error while initializing sender: %s", task.GetID(), err)
 	}
 	task.sender = sender
 	task.sender.Start()
+	task.eventRate = registerRateEstimator(task.GetID())
+
+	// init log metrics
+	if len(task.config.LogMetrics.Metrics) > 0 {
+		task.logMetrics, err = newLogMetrics(task.config.LogMetrics, beat.SendEvent)
+		if err != nil {
+			return fmt.Errorf("[%s] error while initializing log metrics: %s", task.GetID(), err)
+		}
+		go task.logMetrics.run(task.done)
+	}
+
+	// init mmap ring buffer
+	if task.config.MMRingBuffer.FilePath != "" {
+		task.ringBuffer, err = utils.NewMMRingBuffer(task.config.MMRingBuffer.FilePath, task.config.MMRingBuffer.BufferSize)
+		if err != nil {
+			return fmt.Errorf("[%s] error while initializing mmap ring buffer: %s", task.GetID(), err)
+		}
+	}
+
+	// init grpc output
+	if task.config.GRPCOutput.Target != "" {
+		task.grpcOutput, err = newGRPCOutput(task.config, task.done)
+		if err != nil {
+			return fmt.Errorf("[%s] error while initializing grpc output: %s", task.GetID(), err)
+		}
+	}
 
 	// init input processors
 	task.processors, err = NewProcessors(task.config)
@@ -137,14 +207,39 @@ func (task *Task) Start(lastStates []file.State) error {
 		processorsFailed.Add(1)
 		return fmt.Errorf(": %s", err)
 	}
+
+	// init docker json-file decoding
+	if task.config.Type == "docker" {
+		task.containers = newContainers(task.config.Containers)
+	}
+
+	// init auditd record reassembly
+	if task.config.Type == "auditd" {
+		task.auditd = newAuditd(task.config.Auditd)
+		go task.auditd.run(task.done, task.dispatch)
+	}
+
+	// init pipeline multiline
+	if task.config.Multiline.Enabled() {
+		task.multiline, err = newMultiline(task.config.Multiline)
+		if err != nil {
+			return fmt.Errorf("[%s] error while initializing multiline: %s", task.GetID(), err)
+		}
+		go task.multiline.run(task.done, task.dispatch)
+	}
 	task.wg.Add(1)
 	p, err := input.New(task.config.RawConfig, ConnectToTask(task), task.beatDone, lastStates, nil)
 	if err != nil {
 		inputFailed.Add(1)
-		return fmt.Errorf("[%s] error while initializing input: %s", task.ID, err)
+		return fmt.Errorf("[%s] error while initializing input: %s", task.GetID(), err)
 	}
 	task.runner = p
 	task.runner.Start()
+
+	// init gzip backfill, 与采集插件并行读取历史文件
+	if task.config.GzipBackfill.Enabled {
+		go newGzipBackfill(task.config.GzipBackfill, lastStates).run(task.done, task.OnEvent)
+	}
 	return nil
 }
 
@@ -152,20 +247,63 @@ func (task *Task) Start(lastStates []file.State) error {
 func (task *Task) Stop() error {
 	task.runner.Stop()
 	task.wg.Wait()
+	unregisterRateEstimator(task.GetID())
+	if task.processors != nil {
+		task.processors.Close()
+	}
+	if task.ringBuffer != nil {
+		task.ringBuffer.Close()
+	}
 	task.crawlerState.Set(0)
 	task.crawlerSendTotal.Set(0)
 	task.crawlerDropped.Set(0)
 	return nil
 }
 
+// GetID 获取任务ID
+func (task *Task) GetID() string {
+	task.idMutex.RLock()
+	defer task.idMutex.RUnlock()
+	return task.id
+}
+
+// UpdateFilters 热更新任务的过滤条件，采集插件及发送模块不受影响
+// 过滤条件变化后任务ID随之变化，速率估算同步切换到新ID，保证与manager中的任务ID一致
+func (task *Task) UpdateFilters(config *cfg.TaskConfig) error {
+	if task.processors == nil {
+		return fmt.Errorf("[%s] processors is not initialized", task.GetID())
+	}
+	err := task.processors.UpdateFilters(config)
+	if err != nil {
+		return err
+	}
+	task.idMutex.Lock()
+	defer task.idMutex.Unlock()
+	if task.id != config.ID {
+		renameRateEstimator(task.id, config.ID)
+		task.id = config.ID
+	}
+	return nil
+}
+
 // Reload 通知各采集模块针对重载操作进行适配
 func (task *Task) Reload() error {
 	task.runner.Reload()
 	return nil
 }
 
-// Close 由Filebeat在停止采集插件后调用
+// Close 由Filebeat在停止采集插件后调用，暂存的多行合并事件在发送模块退出前发送
 func (task *Task) Close() error {
+	if task.auditd != nil {
+		for _, data := range task.auditd.expire(true) {
+			task.dispatch(data)
+		}
+	}
+	if task.multiline != nil {
+		for _, data := range task.multiline.expire(true) {
+			task.dispatch(data)
+		}
+	}
 	task.wg.Done()
 	close(task.done)
 	return nil
@@ -179,10 +317,9 @@ func (task *Task) Done() <-chan struct{} {
 //处理input runner发送的事件
 func (task *Task) OnEvent(data *util.Data) bool {
 	if data == nil {
-		logp.L.Errorf("task get event nil, task_id:%s", task.ID)
+		logp.L.Errorf("task get event nil, task_id:%s", task.GetID())
 		return false
 	}
-	event := &data.Event
 	select {
 	case <-task.beatDone:
 	case <-task.done:
@@ -194,11 +331,53 @@ func (task *Task) OnEvent(data *util.Data) bool {
 	task.crawlerReceived.Add(1)
 	crawlerReceived.Add(1)
 
+	// 不满足容器选择条件的事件只更新采集进度
+	if task.containers != nil && !task.containers.decode(data) {
+		data.Event.Fields = nil
+		task.crawlerDropped.Add(1)
+		crawlerDropped.Add(1)
+	}
+
+	if task.auditd != nil {
+		ok := true
+		for _, d := range task.auditd.feed(data) {
+			ok = task.dispatch(d) && ok
+		}
+		return ok
+	}
+
+	if task.multiline != nil {
+		ok := true
+		for _, d := range task.multiline.feed(data) {
+			ok = task.dispatch(d) && ok
+		}
+		return ok
+	}
+	return task.dispatch(data)
+}
+
+// dispatch 按oversize_mode为split时切分超大事件，再逐个处理
+func (task *Task) dispatch(data *util.Data) bool {
+	if task.config.MaxEventBytes <= 0 || task.config.OversizeMode != "split" {
+		return task.handle(data)
+	}
+	ok := true
+	for _, d := range splitOversize(data, task.config.MaxEventBytes) {
+		ok = task.handle(d) && ok
+	}
+	return ok
+}
+
+// handle 处理、过滤事件并交给发送模块
+func (task *Task) handle(data *util.Data) bool {
+	event := &data.Event
 	if event.Fields == nil {
 		//采集进度类事件
 		task.crawlerState.Add(1)
 		crawlerState.Add(1)
 	} else {
+		task.eventRate.add()
+
 		// 处理速率限制，可在一定层度上限制CPU的使用率
 		if isEnableRateLimiter && cpuLimiter != nil {
 			checkInterval := cpuLimiter.GetCheckInterval()
@@ -211,12 +390,28 @@ func (task *Task) OnEvent(data *util.Data) bool {
 			}
 		}
 
-		event = task.processors.Run(event)
+		// 日志指标基于过滤之前的原始日志统计，drop_raw时原始日志只更新采集进度
+		if task.logMetrics != nil {
+			if text, ok := event.Fields["data"].(string); ok {
+				task.logMetrics.observe(text)
+			}
+		}
+		if task.logMetrics != nil && task.config.LogMetrics.DropRaw {
+			event = nil
+		} else {
+			event = task.processors.RunSource(event, data.GetState().Source)
+		}
 		if event != nil {
 			//正常事件
 			task.crawlerSendTotal.Add(1)
 			crawlerSendTotal.Add(1)
 			data.Event = *event
+			if task.ringBuffer != nil {
+				task.writeRingBuffer(event)
+			}
+			if task.grpcOutput != nil {
+				task.grpcOutput.publish(data.GetState().Source, event)
+			}
 		} else {
 			//需要丢弃的事件
 			data.Event.Fields = nil
@@ -227,9 +422,35 @@ func (task *Task) OnEvent(data *util.Data) bool {
 	return task.sender.OnEvent(data)
 }
 
+// writeRingBuffer 按output_formats中mmap_ring_buffer的格式写入环形缓冲区，读端过慢时丢弃
+func (task *Task) writeRingBuffer(event *beat.Event) {
+	record, err := ringBufferRecord(event, task.config.OutputFormats["mmap_ring_buffer"])
+	if err != nil {
+		logp.L.Errorf("marshal event fields failed, task_id:%s, err=>%v", task.GetID(), err)
+		return
+	}
+	err = task.ringBuffer.Write(record)
+	switch err {
+	case nil, utils.ErrRingBufferClosed:
+	case utils.ErrRingBufferFull:
+		filterMMapOverflowTotal.Add(1)
+	default:
+		logp.L.Errorf("write mmap ring buffer failed, task_id:%s, err=>%v", task.GetID(), err)
+	}
+}
+
+// ringBufferRecord: text只写入data，raw(默认)及json写入序列化后的事件字段
+func ringBufferRecord(event *beat.Event, format string) ([]byte, error) {
+	if format == "text" {
+		data, _ := event.Fields["data"].(string)
+		return []byte(data), nil
+	}
+	return json.Marshal(event.Fields)
+}
+
 // String 任务实例名称
 func (task *Task) String() string {
-	return fmt.Sprintf("task [type=>%s, ID=>%s]", task.config.Type, task.ID)
+	return fmt.Sprintf("task [type=>%s, ID=>%s]", task.config.Type, task.GetID())
 }
 
 // ConnectToTask 返回任务实例，用于接收采集事件 OnEvent
@@ -0,0 +1,91 @@
+// Tencent is pleased to support the open source community by making bkunifylogbeat 蓝鲸日志采集器 available.
+//
+// Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+//
+// bkunifylogbeat 蓝鲸日志采集器 is licensed under the MIT License.
+//
+// License for bkunifylogbeat 蓝鲸日志采集器:
+// --------------------------------------------------------------------
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+// documentation files (the "Software"), to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software,
+// and to permit persons to whom the Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all copies or substantial
+// portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+// LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN
+// NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+// WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+// SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package tests
+
+import (
+	"math/rand"
+	"strings"
+	"sync"
+
+	"github.com/elastic/beats/filebeat/util"
+)
+
+// SyntheticColumn: 合成日志的列定义，按Weights的比例从Values中取值，Weights为空时均匀分布
+type SyntheticColumn struct {
+	Values  []string
+	Weights []float64
+}
+
+// SyntheticLogGenerator: 按列模板生成合成日志事件，用于过滤规则的集成测试，可并发调用
+type SyntheticLogGenerator struct {
+	source    string
+	delimiter string
+	columns   []SyntheticColumn
+	mutex     sync.Mutex
+	rand      *rand.Rand
+}
+
+// NewSyntheticLogGenerator 生成合成日志生成器，相同seed生成的事件序列相同
+func NewSyntheticLogGenerator(source, delimiter string, columns []SyntheticColumn, seed int64) *SyntheticLogGenerator {
+	return &SyntheticLogGenerator{
+		source:    source,
+		delimiter: delimiter,
+		columns:   columns,
+		rand:      rand.New(rand.NewSource(seed)),
+	}
+}
+
+// NextLine 生成一行日志
+func (g *SyntheticLogGenerator) NextLine() string {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	words := make([]string, len(g.columns))
+	for i, column := range g.columns {
+		words[i] = column.Values[g.pick(column)]
+	}
+	return strings.Join(words, g.delimiter)
+}
+
+// Next 生成一个日志采集事件
+func (g *SyntheticLogGenerator) Next() *util.Data {
+	return MockLogEvent(g.source, g.NextLine())
+}
+
+// pick: 按权重选择取值下标
+func (g *SyntheticLogGenerator) pick(column SyntheticColumn) int {
+	if len(column.Weights) != len(column.Values) {
+		return g.rand.Intn(len(column.Values))
+	}
+	total := 0.0
+	for _, weight := range column.Weights {
+		total += weight
+	}
+	r := g.rand.Float64() * total
+	for i, weight := range column.Weights {
+		if r < weight {
+			return i
+		}
+		r -= weight
+	}
+	return len(column.Values) - 1
+}
@@ -0,0 +1,127 @@
+// Tencent is pleased to support the open source community by making bkunifylogbeat 蓝鲸日志采集器 available.
+//
+// Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+//
+// bkunifylogbeat 蓝鲸日志采集器 is licensed under the MIT License.
+//
+// License for bkunifylogbeat 蓝鲸日志采集器:
+// --------------------------------------------------------------------
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+// documentation files (the "Software"), to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software,
+// and to permit persons to whom the Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all copies or substantial
+// portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+// LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN
+// NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+// WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+// SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package utils
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"os"
+)
+
+// 布隆过滤器文件格式(数值均为小端):
+//
+//	[0, 8)    magic，固定为"BKBLOOM1"
+//	[8, 16)   m，位数组长度(uint64)
+//	[16, 20)  k，哈希函数个数(uint32)
+//	[20, ...) 位数组，共(m+7)/8字节，第i位为第i/8个字节的第i%8位(低位在前)
+//
+// 第i个哈希位置: h = FNV-1a-64(value)，h1 = 低32位，h2 = 高32位，pos = (h1 + i*h2) % m
+const bloomMagic = "BKBLOOM1"
+
+// BloomFilter 只读布隆过滤器，用于大规模黑白名单的成员判断
+type BloomFilter struct {
+	m    uint64
+	k    uint32
+	bits []byte
+}
+
+// NewBloomFilter 创建空的布隆过滤器
+func NewBloomFilter(m uint64, k uint32) *BloomFilter {
+	return &BloomFilter{m: m, k: k, bits: make([]byte, (m+7)/8)}
+}
+
+// LoadBloomFilter 从文件加载布隆过滤器
+func LoadBloomFilter(path string) (*BloomFilter, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ReadBloomFilter(bufio.NewReader(f))
+}
+
+// ReadBloomFilter 按文件格式读取布隆过滤器
+func ReadBloomFilter(r io.Reader) (*BloomFilter, error) {
+	header := make([]byte, 20)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return nil, fmt.Errorf("read bloom filter header failed, err=>%v", err)
+	}
+	if string(header[:8]) != bloomMagic {
+		return nil, fmt.Errorf("invalid bloom filter magic")
+	}
+	m := binary.LittleEndian.Uint64(header[8:16])
+	k := binary.LittleEndian.Uint32(header[16:20])
+	if m == 0 || k == 0 {
+		return nil, fmt.Errorf("invalid bloom filter, m=>%d, k=>%d", m, k)
+	}
+	b := NewBloomFilter(m, k)
+	if _, err := io.ReadFull(r, b.bits); err != nil {
+		return nil, fmt.Errorf("read bloom filter bits failed, err=>%v", err)
+	}
+	return b, nil
+}
+
+// WriteTo 按文件格式写出布隆过滤器
+func (b *BloomFilter) WriteTo(w io.Writer) (int64, error) {
+	header := make([]byte, 20)
+	copy(header, bloomMagic)
+	binary.LittleEndian.PutUint64(header[8:16], b.m)
+	binary.LittleEndian.PutUint32(header[16:20], b.k)
+	n, err := w.Write(header)
+	if err != nil {
+		return int64(n), err
+	}
+	m, err := w.Write(b.bits)
+	return int64(n + m), err
+}
+
+// Add 加入成员
+func (b *BloomFilter) Add(value []byte) {
+	h1, h2 := bloomHash(value)
+	for i := uint64(0); i < uint64(b.k); i++ {
+		pos := (h1 + i*h2) % b.m
+		b.bits[pos/8] |= 1 << (pos % 8)
+	}
+}
+
+// Test 判断是否可能为成员，返回false时一定不是成员
+func (b *BloomFilter) Test(value []byte) bool {
+	h1, h2 := bloomHash(value)
+	for i := uint64(0); i < uint64(b.k); i++ {
+		pos := (h1 + i*h2) % b.m
+		if b.bits[pos/8]&(1<<(pos%8)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func bloomHash(value []byte) (uint64, uint64) {
+	h := fnv.New64a()
+	h.Write(value)
+	sum := h.Sum64()
+	return sum & 0xffffffff, sum >> 32
+}
@@ -0,0 +1,50 @@
+// Tencent is pleased to support the open source community by making bkunifylogbeat 蓝鲸日志采集器 available.
+//
+// Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+//
+// bkunifylogbeat 蓝鲸日志采集器 is licensed under the MIT License.
+//
+// License for bkunifylogbeat 蓝鲸日志采集器:
+// --------------------------------------------------------------------
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+// documentation files (the "Software"), to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software,
+// and to permit persons to whom the Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all copies or substantial
+// portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+// LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN
+// NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+// WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+// SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package utils
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+//TestBloomFilter: 测试布隆过滤器读写及成员判断
+func TestBloomFilter(t *testing.T) {
+	b := NewBloomFilter(1024, 3)
+	b.Add([]byte("10.0.0.1"))
+	b.Add([]byte("10.0.0.2"))
+
+	var buf bytes.Buffer
+	_, err := b.WriteTo(&buf)
+	assert.Nil(t, err)
+
+	loaded, err := ReadBloomFilter(&buf)
+	assert.Nil(t, err)
+	assert.True(t, loaded.Test([]byte("10.0.0.1")))
+	assert.True(t, loaded.Test([]byte("10.0.0.2")))
+	assert.False(t, loaded.Test([]byte("192.168.1.1")))
+
+	_, err = ReadBloomFilter(bytes.NewReader([]byte("BADMAGIC")))
+	assert.NotNil(t, err)
+}
@@ -26,6 +26,7 @@ import (
 	"os"
 	"runtime"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/TencentBlueKing/collector-go-sdk/v2/bkbeat/logp"
@@ -37,7 +38,7 @@ type CPULimit struct {
 	checkTimes    int // check cpu usage in one second
 	checkInterval time.Duration
 
-	isAllowRun bool
+	isAllowRun int32 // 1为允许运行，由检查协程原子更新
 
 	closeOnce sync.Once
 	done      chan struct{}
@@ -58,7 +59,7 @@ func NewCPULimit(l, checkTimes int) *CPULimit {
 		checkTimes:    checkTimes,
 		checkInterval: time.Duration(float64(time.Second) / float64(checkTimes)),
 
-		isAllowRun: true,
+		isAllowRun: 1,
 		done:       make(chan struct{}, 1),
 	}
 	cpuLimit.Start()
@@ -104,7 +105,7 @@ func (c *CPULimit) Start() {
 		for {
 			select {
 			case <-c.done:
-				c.isAllowRun = true
+				atomic.StoreInt32(&c.isAllowRun, 1)
 				return
 			case <-ticker.C:
 				now := time.Now()
@@ -112,15 +113,15 @@ func (c *CPULimit) Start() {
 				delta := now.Sub(lastCPUTime).Seconds()
 				deltaCPUTime := curCpuTimes.Total() - lastCPUTimes.Total()
 				if deltaCPUTime > timeToRunSeconds-tickInterval {
-					c.isAllowRun = false
+					atomic.StoreInt32(&c.isAllowRun, 0)
 				} else {
-					c.isAllowRun = true
+					atomic.StoreInt32(&c.isAllowRun, 1)
 				}
 
 				tickCount++
 				if tickCount == c.checkTimes {
 					tickCount = 0
-					c.isAllowRun = true
+					atomic.StoreInt32(&c.isAllowRun, 1)
 					lastCPUTimes = curCpuTimes
 					lastCPUTime = now
 				}
@@ -131,7 +132,7 @@ func (c *CPULimit) Start() {
 					"time allow run in seconds =>(%.2f), "+
 					"isAllowRun(%v)\n",
 					tickCount, deltaCPUTime/delta*100, deltaCPUTime,
-					delta, timeToRunSeconds, c.isAllowRun)
+					delta, timeToRunSeconds, c.Allow())
 			}
 		}
 	}()
@@ -147,7 +148,7 @@ func (c *CPULimit) Stop() {
 
 // Allow: judge current
 func (c *CPULimit) Allow() bool {
-	return c.isAllowRun
+	return atomic.LoadInt32(&c.isAllowRun) == 1
 }
 
 // GetCheckInterval: get cpu check interval
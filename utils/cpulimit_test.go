@@ -20,6 +20,9 @@
 // WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
 // SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
 
+// 依赖真实CPU占用的计时测试，竞态检测下运行速度变慢，结果不可信
+// +build !race
+
 package utils
 
 import (
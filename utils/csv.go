@@ -0,0 +1,65 @@
+// Tencent is pleased to support the open source community by making bkunifylogbeat 蓝鲸日志采集器 available.
+//
+// Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+//
+// bkunifylogbeat 蓝鲸日志采集器 is licensed under the MIT License.
+//
+// License for bkunifylogbeat 蓝鲸日志采集器:
+// --------------------------------------------------------------------
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+// documentation files (the "Software"), to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software,
+// and to permit persons to whom the Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all copies or substantial
+// portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+// LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN
+// NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+// WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+// SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package utils
+
+import "strings"
+
+// SplitCSV 按CSV规则切分单行内容：以引号开头的列中的分隔符不参与切分，两个连续引号表示一个引号，
+// 结果中去掉列两端的引号；n大于0时最多切分为n段，最后一段保留原始内容
+func SplitCSV(text string, sep byte, n int) []string {
+	var columns []string
+	var column strings.Builder
+	i := 0
+	for {
+		if n > 0 && len(columns) == n-1 {
+			return append(columns, text[i:])
+		}
+		column.Reset()
+		if i < len(text) && text[i] == '"' {
+			// 引号列：直到单独的引号结束
+			i++
+			for i < len(text) {
+				if text[i] == '"' {
+					if i+1 < len(text) && text[i+1] == '"' {
+						column.WriteByte('"')
+						i += 2
+						continue
+					}
+					i++
+					break
+				}
+				column.WriteByte(text[i])
+				i++
+			}
+		}
+		// 非引号列，或引号结束后到分隔符之前的内容
+		end := strings.IndexByte(text[i:], sep)
+		if end < 0 {
+			column.WriteString(text[i:])
+			return append(columns, column.String())
+		}
+		column.WriteString(text[i : i+end])
+		columns = append(columns, column.String())
+		i += end + 1
+	}
+}
@@ -0,0 +1,41 @@
+// Tencent is pleased to support the open source community by making bkunifylogbeat 蓝鲸日志采集器 available.
+//
+// Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+//
+// bkunifylogbeat 蓝鲸日志采集器 is licensed under the MIT License.
+//
+// License for bkunifylogbeat 蓝鲸日志采集器:
+// --------------------------------------------------------------------
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+// documentation files (the "Software"), to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software,
+// and to permit persons to whom the Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all copies or substantial
+// portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+// LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN
+// NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+// WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+// SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+//TestSplitCSV: 测试CSV切分
+func TestSplitCSV(t *testing.T) {
+	assert.Equal(t, []string{"a", "b,c", "d"}, SplitCSV(`a,"b,c",d`, ',', -1))
+	assert.Equal(t, []string{"a", `say "hi"`, ""}, SplitCSV(`a,"say ""hi""",`, ',', -1))
+	assert.Equal(t, []string{""}, SplitCSV("", ',', -1))
+	// 引号未闭合时取剩余全部内容
+	assert.Equal(t, []string{"a", "b,c"}, SplitCSV(`a,"b,c`, ',', -1))
+	// 限制段数时最后一段保留原始内容
+	assert.Equal(t, []string{"a", `"b,c",d`}, SplitCSV(`a,"b,c",d`, ',', 2))
+	assert.Equal(t, []string{"a", "b|c", "d"}, SplitCSV(`a|"b|c"|d`, '|', 3))
+}
@@ -0,0 +1,88 @@
+// Tencent is pleased to support the open source community by making bkunifylogbeat 蓝鲸日志采集器 available.
+//
+// Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+//
+// bkunifylogbeat 蓝鲸日志采集器 is licensed under the MIT License.
+//
+// License for bkunifylogbeat 蓝鲸日志采集器:
+// --------------------------------------------------------------------
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+// documentation files (the "Software"), to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software,
+// and to permit persons to whom the Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all copies or substantial
+// portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+// LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN
+// NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+// WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+// SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package utils
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// DedupCache 有容量上限的LRU去重缓存，记录key首次出现的时间，超出容量时淘汰最久未出现的key
+type DedupCache struct {
+	mutex  sync.Mutex
+	size   int
+	window time.Duration
+	items  map[uint64]*list.Element
+	order  *list.List
+	now    func() time.Time
+}
+
+// dedupEntry: 缓存项，seen为窗口开始时间
+type dedupEntry struct {
+	key  uint64
+	seen time.Time
+}
+
+// NewDedupCache 生成去重缓存，window小于等于0时key只会因容量不足被淘汰
+func NewDedupCache(size int, window time.Duration) *DedupCache {
+	return &DedupCache{
+		size:   size,
+		window: window,
+		items:  make(map[uint64]*list.Element, size),
+		order:  list.New(),
+		now:    time.Now,
+	}
+}
+
+// Seen 记录key，key在窗口内出现过时返回true；窗口从首次出现开始计算，过期后重新开始
+func (c *DedupCache) Seen(key uint64) bool {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	now := c.now()
+	if elem, ok := c.items[key]; ok {
+		c.order.MoveToFront(elem)
+		entry := elem.Value.(*dedupEntry)
+		if c.window <= 0 || now.Sub(entry.seen) < c.window {
+			return true
+		}
+		entry.seen = now
+		return false
+	}
+
+	c.items[key] = c.order.PushFront(&dedupEntry{key: key, seen: now})
+	if c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.items, oldest.Value.(*dedupEntry).key)
+	}
+	return false
+}
+
+// Len 当前缓存的key数量
+func (c *DedupCache) Len() int {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.order.Len()
+}
@@ -0,0 +1,52 @@
+// Tencent is pleased to support the open source community by making bkunifylogbeat 蓝鲸日志采集器 available.
+//
+// Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+//
+// bkunifylogbeat 蓝鲸日志采集器 is licensed under the MIT License.
+//
+// License for bkunifylogbeat 蓝鲸日志采集器:
+// --------------------------------------------------------------------
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+// documentation files (the "Software"), to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software,
+// and to permit persons to whom the Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all copies or substantial
+// portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+// LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN
+// NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+// WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+// SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package utils
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+//TestDedupCache: 测试LRU去重缓存
+func TestDedupCache(t *testing.T) {
+	now := time.Unix(0, 0)
+	c := NewDedupCache(2, time.Second)
+	c.now = func() time.Time { return now }
+
+	assert.False(t, c.Seen(1))
+	assert.True(t, c.Seen(1))
+	assert.False(t, c.Seen(2))
+
+	// 超出容量时淘汰最久未出现的key(1)
+	assert.False(t, c.Seen(3))
+	assert.Equal(t, 2, c.Len())
+	assert.True(t, c.Seen(2))
+	assert.False(t, c.Seen(1))
+
+	// 窗口过期后重新开始计算
+	now = now.Add(time.Second)
+	assert.False(t, c.Seen(2))
+	assert.True(t, c.Seen(2))
+}
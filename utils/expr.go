@@ -0,0 +1,561 @@
+// Tencent is pleased to support the open source community by making bkunifylogbeat 蓝鲸日志采集器 available.
+//
+// Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+//
+// bkunifylogbeat 蓝鲸日志采集器 is licensed under the MIT License.
+//
+// License for bkunifylogbeat 蓝鲸日志采集器:
+// --------------------------------------------------------------------
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+// documentation files (the "Software"), to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software,
+// and to permit persons to whom the Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all copies or substantial
+// portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+// LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN
+// NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+// WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+// SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package utils
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// 过滤表达式，在加载配置时编译为闭包，语法如下：
+//
+//	逻辑运算：&&、||、!，比较运算：==、!=、<、<=、>、>=，支持括号
+//	line为整行内容，col(n)为第n列内容(n从1开始)，列不存在时为空字符串
+//	字符串使用双引号或反引号，数字按浮点数处理，true/false为布尔值
+//	函数：strings.Contains/HasPrefix/HasSuffix(s, sub)、strings.ToLower/ToUpper/TrimSpace(s)、
+//	     len(s)、num(s)、matches(s, "正则表达式")、concat(s1, s2, ...)、field("字段名")
+//	field仅在ExprEnv实现ExprFieldEnv时有效，否则为空字符串
+//
+// 字符串与数字比较时字符串按数字解析，解析失败时比较结果为false(!=为true)
+// 例如：col(3) == "ERROR" && strings.Contains(line, "timeout") && col(5) > 500
+
+// ExprEnv 表达式求值时的日志内容
+type ExprEnv interface {
+	// Line 整行内容
+	Line() string
+	// Column 第index列内容，index从1开始，不存在时返回空字符串
+	Column(index int) string
+}
+
+// ExprFieldEnv 支持按字段名取值的日志内容，用于field函数
+type ExprFieldEnv interface {
+	ExprEnv
+	// Field 字段内容，不存在时返回空字符串
+	Field(name string) string
+}
+
+// Expr 编译后的过滤表达式
+type Expr struct {
+	root      *exprNode
+	maxColumn int
+}
+
+// CompileExpr 编译过滤表达式，表达式结果必须为布尔值
+func CompileExpr(src string) (*Expr, error) {
+	e, err := CompileValueExpr(src)
+	if err == nil && e.root.kind != exprBool {
+		err = fmt.Errorf("expr(%s) is not valid, err=>result must be bool", src)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// CompileValueExpr 编译任意结果类型的表达式，通过Value求值
+func CompileValueExpr(src string) (*Expr, error) {
+	tokens, err := lexExpr(src)
+	if err != nil {
+		return nil, fmt.Errorf("expr(%s) is not valid, err=>%v", src, err)
+	}
+	p := &exprParser{tokens: tokens}
+	root, err := p.parseOr()
+	if err == nil && p.peek().kind != exprTokenEOF {
+		err = fmt.Errorf("unexpected %q at %d", p.peek().text, p.peek().pos)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("expr(%s) is not valid, err=>%v", src, err)
+	}
+	return &Expr{root: root, maxColumn: p.maxColumn}, nil
+}
+
+// Eval 对日志内容求值，结果不是布尔值时返回false
+func (e *Expr) Eval(env ExprEnv) bool {
+	if e.root.kind != exprBool {
+		return false
+	}
+	return e.root.boolean(env)
+}
+
+// Value 对日志内容求值，结果为string、float64或bool
+func (e *Expr) Value(env ExprEnv) interface{} {
+	switch e.root.kind {
+	case exprString:
+		return e.root.str(env)
+	case exprNumber:
+		return e.root.num(env)
+	}
+	return e.root.boolean(env)
+}
+
+// MaxColumn 表达式中最大的列序号，用于确定切分的列数
+func (e *Expr) MaxColumn() int {
+	return e.maxColumn
+}
+
+type exprKind int
+
+const (
+	exprString exprKind = iota
+	exprNumber
+	exprBool
+)
+
+var exprKindNames = map[exprKind]string{exprString: "string", exprNumber: "number", exprBool: "bool"}
+
+// exprNode: 编译后的表达式节点，按kind使用对应的求值函数
+type exprNode struct {
+	kind    exprKind
+	str     func(env ExprEnv) string
+	num     func(env ExprEnv) float64
+	boolean func(env ExprEnv) bool
+}
+
+func stringNode(f func(env ExprEnv) string) *exprNode {
+	return &exprNode{kind: exprString, str: f}
+}
+
+func numberNode(f func(env ExprEnv) float64) *exprNode {
+	return &exprNode{kind: exprNumber, num: f}
+}
+
+func boolNode(f func(env ExprEnv) bool) *exprNode {
+	return &exprNode{kind: exprBool, boolean: f}
+}
+
+// toNumber: 字符串节点按数字解析，解析失败时为NaN，使比较结果为false
+func toNumber(n *exprNode) *exprNode {
+	if n.kind != exprString {
+		return n
+	}
+	return numberNode(func(env ExprEnv) float64 {
+		value, err := strconv.ParseFloat(strings.TrimSpace(n.str(env)), 64)
+		if err != nil {
+			return math.NaN()
+		}
+		return value
+	})
+}
+
+type exprTokenKind int
+
+const (
+	exprTokenEOF exprTokenKind = iota
+	exprTokenIdent
+	exprTokenString
+	exprTokenNumber
+	exprTokenOp
+)
+
+type exprToken struct {
+	kind exprTokenKind
+	text string
+	pos  int
+}
+
+// lexExpr: 将表达式切分为token
+func lexExpr(src string) ([]exprToken, error) {
+	var tokens []exprToken
+	for i := 0; i < len(src); {
+		c := src[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z'):
+			start := i
+			for i < len(src) && (src[i] == '_' || src[i] == '.' || (src[i] >= 'a' && src[i] <= 'z') ||
+				(src[i] >= 'A' && src[i] <= 'Z') || (src[i] >= '0' && src[i] <= '9')) {
+				i++
+			}
+			tokens = append(tokens, exprToken{kind: exprTokenIdent, text: src[start:i], pos: start})
+		case c >= '0' && c <= '9':
+			start := i
+			for i < len(src) && (src[i] == '.' || (src[i] >= '0' && src[i] <= '9')) {
+				i++
+			}
+			tokens = append(tokens, exprToken{kind: exprTokenNumber, text: src[start:i], pos: start})
+		case c == '"':
+			start := i
+			for i++; i < len(src) && src[i] != '"'; i++ {
+				if src[i] == '\\' {
+					i++
+				}
+			}
+			if i >= len(src) {
+				return nil, fmt.Errorf("unterminated string at %d", start)
+			}
+			i++
+			text, err := strconv.Unquote(src[start:i])
+			if err != nil {
+				return nil, fmt.Errorf("invalid string at %d", start)
+			}
+			tokens = append(tokens, exprToken{kind: exprTokenString, text: text, pos: start})
+		case c == '`':
+			end := strings.IndexByte(src[i+1:], '`')
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated string at %d", i)
+			}
+			tokens = append(tokens, exprToken{kind: exprTokenString, text: src[i+1 : i+1+end], pos: i})
+			i += end + 2
+		default:
+			op := ""
+			if i+1 < len(src) {
+				switch src[i : i+2] {
+				case "==", "!=", "<=", ">=", "&&", "||":
+					op = src[i : i+2]
+				}
+			}
+			if op == "" && strings.IndexByte("(),<>!", c) >= 0 {
+				op = string(c)
+			}
+			if op == "" {
+				return nil, fmt.Errorf("unexpected character %q at %d", c, i)
+			}
+			tokens = append(tokens, exprToken{kind: exprTokenOp, text: op, pos: i})
+			i += len(op)
+		}
+	}
+	return append(tokens, exprToken{kind: exprTokenEOF, text: "EOF", pos: len(src)}), nil
+}
+
+// exprParser: 递归下降解析，优先级从低到高为 ||、&&、比较运算、!
+type exprParser struct {
+	tokens    []exprToken
+	pos       int
+	maxColumn int
+}
+
+func (p *exprParser) peek() exprToken {
+	return p.tokens[p.pos]
+}
+
+func (p *exprParser) next() exprToken {
+	token := p.tokens[p.pos]
+	if token.kind != exprTokenEOF {
+		p.pos++
+	}
+	return token
+}
+
+// isOp: 下一个token是否为指定的运算符
+func (p *exprParser) isOp(op string) bool {
+	token := p.peek()
+	return token.kind == exprTokenOp && token.text == op
+}
+
+func (p *exprParser) expectOp(op string) error {
+	if !p.isOp(op) {
+		return fmt.Errorf("expect %q but got %q at %d", op, p.peek().text, p.peek().pos)
+	}
+	p.next()
+	return nil
+}
+
+// expectKind: 校验节点类型
+func expectKind(n *exprNode, kind exprKind, what string) error {
+	if n.kind != kind {
+		return fmt.Errorf("%s expects %s but got %s", what, exprKindNames[kind], exprKindNames[n.kind])
+	}
+	return nil
+}
+
+func (p *exprParser) parseOr() (*exprNode, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.isOp("||") {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		if err = expectKind(left, exprBool, "||"); err != nil {
+			return nil, err
+		}
+		if err = expectKind(right, exprBool, "||"); err != nil {
+			return nil, err
+		}
+		l, r := left.boolean, right.boolean
+		left = boolNode(func(env ExprEnv) bool { return l(env) || r(env) })
+	}
+	return left, nil
+}
+
+func (p *exprParser) parseAnd() (*exprNode, error) {
+	left, err := p.parseCompare()
+	if err != nil {
+		return nil, err
+	}
+	for p.isOp("&&") {
+		p.next()
+		right, err := p.parseCompare()
+		if err != nil {
+			return nil, err
+		}
+		if err = expectKind(left, exprBool, "&&"); err != nil {
+			return nil, err
+		}
+		if err = expectKind(right, exprBool, "&&"); err != nil {
+			return nil, err
+		}
+		l, r := left.boolean, right.boolean
+		left = boolNode(func(env ExprEnv) bool { return l(env) && r(env) })
+	}
+	return left, nil
+}
+
+func (p *exprParser) parseCompare() (*exprNode, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for _, op := range []string{"==", "!=", "<=", ">=", "<", ">"} {
+		if p.isOp(op) {
+			p.next()
+			right, err := p.parseUnary()
+			if err != nil {
+				return nil, err
+			}
+			return compareNodes(op, left, right)
+		}
+	}
+	return left, nil
+}
+
+func (p *exprParser) parseUnary() (*exprNode, error) {
+	if !p.isOp("!") {
+		return p.parsePrimary()
+	}
+	p.next()
+	operand, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	if err = expectKind(operand, exprBool, "!"); err != nil {
+		return nil, err
+	}
+	f := operand.boolean
+	return boolNode(func(env ExprEnv) bool { return !f(env) }), nil
+}
+
+func (p *exprParser) parsePrimary() (*exprNode, error) {
+	token := p.next()
+	switch token.kind {
+	case exprTokenString:
+		value := token.text
+		return stringNode(func(ExprEnv) string { return value }), nil
+	case exprTokenNumber:
+		value, err := strconv.ParseFloat(token.text, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %q at %d", token.text, token.pos)
+		}
+		return numberNode(func(ExprEnv) float64 { return value }), nil
+	case exprTokenOp:
+		if token.text != "(" {
+			break
+		}
+		n, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		return n, p.expectOp(")")
+	case exprTokenIdent:
+		switch token.text {
+		case "true", "false":
+			value := token.text == "true"
+			return boolNode(func(ExprEnv) bool { return value }), nil
+		case "line":
+			return stringNode(func(env ExprEnv) string { return env.Line() }), nil
+		}
+		if !p.isOp("(") {
+			return nil, fmt.Errorf("unknown identifier %q at %d", token.text, token.pos)
+		}
+		p.next()
+		return p.parseCall(token)
+	}
+	return nil, fmt.Errorf("unexpected %q at %d", token.text, token.pos)
+}
+
+// parseCall: 解析函数调用，左括号已被读取
+func (p *exprParser) parseCall(name exprToken) (*exprNode, error) {
+	switch name.text {
+	case "col":
+		token := p.next()
+		index, err := strconv.Atoi(token.text)
+		if token.kind != exprTokenNumber || err != nil || index <= 0 {
+			return nil, fmt.Errorf("col expects a positive integer at %d", token.pos)
+		}
+		if index > p.maxColumn {
+			p.maxColumn = index
+		}
+		return stringNode(func(env ExprEnv) string { return env.Column(index) }), p.expectOp(")")
+	case "matches":
+		arg, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if err = expectKind(arg, exprString, "matches"); err != nil {
+			return nil, err
+		}
+		if err = p.expectOp(","); err != nil {
+			return nil, err
+		}
+		token := p.next()
+		if token.kind != exprTokenString {
+			return nil, fmt.Errorf("matches expects a regex string at %d", token.pos)
+		}
+		re, err := regexp.Compile(token.text)
+		if err != nil {
+			return nil, fmt.Errorf("invalid regex %q at %d", token.text, token.pos)
+		}
+		s := arg.str
+		return boolNode(func(env ExprEnv) bool { return re.MatchString(s(env)) }), p.expectOp(")")
+	case "field":
+		token := p.next()
+		if token.kind != exprTokenString {
+			return nil, fmt.Errorf("field expects a field name string at %d", token.pos)
+		}
+		name := token.text
+		return stringNode(func(env ExprEnv) string {
+			if fieldEnv, ok := env.(ExprFieldEnv); ok {
+				return fieldEnv.Field(name)
+			}
+			return ""
+		}), p.expectOp(")")
+	}
+
+	var args []*exprNode
+	for !p.isOp(")") {
+		if len(args) > 0 {
+			if err := p.expectOp(","); err != nil {
+				return nil, err
+			}
+		}
+		arg, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if err = expectKind(arg, exprString, name.text); err != nil {
+			return nil, err
+		}
+		args = append(args, arg)
+	}
+	p.next()
+
+	if name.text == "concat" {
+		return stringNode(func(env ExprEnv) string {
+			var b strings.Builder
+			for _, arg := range args {
+				b.WriteString(arg.str(env))
+			}
+			return b.String()
+		}), nil
+	}
+	if f, ok := exprPredicates[name.text]; ok {
+		if len(args) != 2 {
+			return nil, fmt.Errorf("%s expects 2 arguments at %d", name.text, name.pos)
+		}
+		a, b := args[0].str, args[1].str
+		return boolNode(func(env ExprEnv) bool { return f(a(env), b(env)) }), nil
+	}
+	if len(args) != 1 {
+		return nil, fmt.Errorf("%s expects 1 argument at %d", name.text, name.pos)
+	}
+	s := args[0].str
+	if f, ok := exprTransforms[name.text]; ok {
+		return stringNode(func(env ExprEnv) string { return f(s(env)) }), nil
+	}
+	switch name.text {
+	case "len":
+		return numberNode(func(env ExprEnv) float64 { return float64(len(s(env))) }), nil
+	case "num":
+		return toNumber(args[0]), nil
+	}
+	return nil, fmt.Errorf("unknown function %q at %d", name.text, name.pos)
+}
+
+// exprPredicates: 两个字符串参数、返回布尔值的函数
+var exprPredicates = map[string]func(s, sub string) bool{
+	"strings.Contains":  strings.Contains,
+	"strings.HasPrefix": strings.HasPrefix,
+	"strings.HasSuffix": strings.HasSuffix,
+}
+
+// exprTransforms: 一个字符串参数、返回字符串的函数
+var exprTransforms = map[string]func(s string) string{
+	"strings.ToLower":   strings.ToLower,
+	"strings.ToUpper":   strings.ToUpper,
+	"strings.TrimSpace": strings.TrimSpace,
+}
+
+// compareNodes: 生成比较运算节点，任意一侧为数字时按数字比较
+func compareNodes(op string, left, right *exprNode) (*exprNode, error) {
+	if left.kind == exprBool || right.kind == exprBool {
+		if left.kind != right.kind || (op != "==" && op != "!=") {
+			return nil, fmt.Errorf("can not compare %s %s %s", exprKindNames[left.kind], op, exprKindNames[right.kind])
+		}
+		l, r := left.boolean, right.boolean
+		equal := op == "=="
+		return boolNode(func(env ExprEnv) bool { return (l(env) == r(env)) == equal }), nil
+	}
+	if left.kind == exprString && right.kind == exprString {
+		l, r := left.str, right.str
+		return boolNode(func(env ExprEnv) bool { return compareResult(op, strings.Compare(l(env), r(env))) }), nil
+	}
+	l, r := toNumber(left).num, toNumber(right).num
+	return boolNode(func(env ExprEnv) bool {
+		a, b := l(env), r(env)
+		// NaN与任何值比较都不相等
+		if math.IsNaN(a) || math.IsNaN(b) {
+			return op == "!="
+		}
+		switch {
+		case a < b:
+			return compareResult(op, -1)
+		case a > b:
+			return compareResult(op, 1)
+		}
+		return compareResult(op, 0)
+	}), nil
+}
+
+// compareResult: 按比较运算符判断比较结果(-1、0、1)
+func compareResult(op string, result int) bool {
+	switch op {
+	case "==":
+		return result == 0
+	case "!=":
+		return result != 0
+	case "<":
+		return result < 0
+	case "<=":
+		return result <= 0
+	case ">":
+		return result > 0
+	}
+	return result >= 0
+}
@@ -0,0 +1,112 @@
+// Tencent is pleased to support the open source community by making bkunifylogbeat 蓝鲸日志采集器 available.
+//
+// Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+//
+// bkunifylogbeat 蓝鲸日志采集器 is licensed under the MIT License.
+//
+// License for bkunifylogbeat 蓝鲸日志采集器:
+// --------------------------------------------------------------------
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+// documentation files (the "Software"), to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software,
+// and to permit persons to whom the Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all copies or substantial
+// portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+// LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN
+// NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+// WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+// SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// exprTestEnv: 测试用日志内容
+type exprTestEnv struct {
+	line    string
+	columns []string
+}
+
+func (e exprTestEnv) Line() string {
+	return e.line
+}
+
+func (e exprTestEnv) Column(index int) string {
+	if index > len(e.columns) {
+		return ""
+	}
+	return e.columns[index-1]
+}
+
+// TestCompileExpr: 测试过滤表达式编译及求值
+func TestCompileExpr(t *testing.T) {
+	env := exprTestEnv{line: "a|ERROR|timeout 600", columns: []string{"a", "ERROR", "timeout 600"}}
+	cases := map[string]bool{
+		`col(2) == "ERROR" && strings.Contains(line, "timeout")`: true,
+		`col(2) != "ERROR" || !strings.HasPrefix(line, "a")`:     false,
+		`!(col(2) == "WARN")`:                true,
+		`len(col(1)) == 1 && col(9) == ""`:   true,
+		`num(col(1)) > 3`:                    false,
+		`num(col(1)) != 3`:                   true,
+		"matches(col(3), `^timeout \\d+$`)":  true,
+		`strings.ToLower(col(2)) == "error"`: true,
+	}
+	for src, expected := range cases {
+		expr, err := CompileExpr(src)
+		assert.NoError(t, err, src)
+		assert.Equal(t, expected, expr.Eval(env), src)
+	}
+
+	expr, err := CompileExpr(`col(3) == "a" || col(7) == "b"`)
+	assert.NoError(t, err)
+	assert.Equal(t, 7, expr.MaxColumn())
+
+	// 语法及类型错误在编译时返回
+	for _, src := range []string{`col(2)`, `col(0) == "a"`, `foo == 1`, `strings.Contains(line)`, `"a" && true`, `(true`} {
+		_, err = CompileExpr(src)
+		assert.Error(t, err, src)
+	}
+}
+
+// exprFieldTestEnv: 支持字段取值的测试用日志内容
+type exprFieldTestEnv struct {
+	exprTestEnv
+	fields map[string]string
+}
+
+func (e exprFieldTestEnv) Field(name string) string {
+	return e.fields[name]
+}
+
+// TestCompileValueExpr: 测试任意类型表达式求值及field、concat函数
+func TestCompileValueExpr(t *testing.T) {
+	env := exprFieldTestEnv{exprTestEnv: exprTestEnv{line: "a|b"}, fields: map[string]string{"level": "ERROR"}}
+	cases := map[string]interface{}{
+		`concat(field("level"), ":", line)`: "ERROR:a|b",
+		`strings.ToLower(field("missing"))`: "",
+		`len(line) + 0 == 3`:                nil,
+		`len(line)`:                         float64(3),
+		`field("level") == "ERROR"`:         true,
+	}
+	for src, expected := range cases {
+		expr, err := CompileValueExpr(src)
+		if expected == nil {
+			assert.Error(t, err, src)
+			continue
+		}
+		assert.NoError(t, err, src)
+		assert.Equal(t, expected, expr.Value(env), src)
+	}
+
+	// 不支持字段取值的内容field为空字符串
+	expr, err := CompileExpr(`field("level") == ""`)
+	assert.NoError(t, err)
+	assert.True(t, expr.Eval(exprTestEnv{}))
+}
@@ -32,8 +32,16 @@ import (
 
 //HashRawConfig: 获取配置hash值
 func HashRawConfig(config *beat.Config) (error, string) {
+	return HashRawConfigExcept(config)
+}
+
+// HashRawConfigExcept 忽略指定的顶层配置项后获取配置hash值
+func HashRawConfigExcept(config *beat.Config, keys ...string) (error, string) {
 	source := map[string]interface{}{}
 	config.Unpack(source)
+	for _, key := range keys {
+		delete(source, key)
+	}
 	b1, err := json.Marshal(source)
 	if err != nil {
 		return err, ""
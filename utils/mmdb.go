@@ -0,0 +1,356 @@
+// Tencent is pleased to support the open source community by making bkunifylogbeat 蓝鲸日志采集器 available.
+//
+// Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+//
+// bkunifylogbeat 蓝鲸日志采集器 is licensed under the MIT License.
+//
+// License for bkunifylogbeat 蓝鲸日志采集器:
+// --------------------------------------------------------------------
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+// documentation files (the "Software"), to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software,
+// and to permit persons to whom the Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all copies or substantial
+// portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+// LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN
+// NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+// WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+// SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package utils
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"math/big"
+	"net"
+	"runtime"
+)
+
+// MaxMind DB(mmdb)格式见https://maxmind.github.io/MaxMind-DB/，文件由三部分组成:
+//
+//	搜索树      node_count个节点，每个节点包含左右两条record_size位的记录
+//	数据区      搜索树之后16个字节的0分隔，记录值大于node_count时指向数据区
+//	元数据      文件末尾最后一个mmdbMetadataMarker之后，为一个map
+var mmdbMetadataMarker = []byte("\xAB\xCD\xEFMaxMind.com")
+
+// MMDB 只读的MaxMind DB，文件通过mmap映射(不支持的平台读入内存)，
+// 解码出的字符串均为拷贝，不再被引用后由finalizer释放映射
+type MMDB struct {
+	data       []byte
+	dataStart  uint
+	nodeCount  uint
+	recordSize uint
+	ipVersion  uint
+	ipv4Start  uint
+	// DatabaseType 元数据中的数据库类型，如GeoLite2-City、GeoLite2-ASN
+	DatabaseType string
+}
+
+// OpenMMDB 打开mmdb文件
+func OpenMMDB(path string) (*MMDB, error) {
+	data, unmap, err := mapFile(path)
+	if err != nil {
+		return nil, err
+	}
+	db, err := NewMMDB(data)
+	if err != nil {
+		unmap()
+		return nil, fmt.Errorf("open mmdb(%s) failed, err=>%v", path, err)
+	}
+	runtime.SetFinalizer(db, func(*MMDB) { unmap() })
+	return db, nil
+}
+
+// NewMMDB 解析内存中的mmdb内容
+func NewMMDB(data []byte) (*MMDB, error) {
+	index := bytes.LastIndex(data, mmdbMetadataMarker)
+	if index < 0 {
+		return nil, fmt.Errorf("mmdb metadata marker not found")
+	}
+	metaStart := uint(index + len(mmdbMetadataMarker))
+	value, _, err := (&mmdbDecoder{data: data[metaStart:]}).decode(0, 0)
+	if err != nil {
+		return nil, fmt.Errorf("decode mmdb metadata failed, err=>%v", err)
+	}
+	metadata, ok := value.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("mmdb metadata is not a map")
+	}
+	db := &MMDB{data: data[:index]}
+	db.nodeCount = mmdbUint(metadata["node_count"])
+	db.recordSize = mmdbUint(metadata["record_size"])
+	db.ipVersion = mmdbUint(metadata["ip_version"])
+	db.DatabaseType, _ = metadata["database_type"].(string)
+	switch db.recordSize {
+	case 24, 28, 32:
+	default:
+		return nil, fmt.Errorf("mmdb record size(%d) is not supported", db.recordSize)
+	}
+	if db.ipVersion != 4 && db.ipVersion != 6 {
+		return nil, fmt.Errorf("mmdb ip version(%d) is not supported", db.ipVersion)
+	}
+	db.dataStart = db.nodeCount*db.recordSize/4 + 16
+	if db.dataStart > uint(len(db.data)) {
+		return nil, fmt.Errorf("mmdb search tree is out of range")
+	}
+	// IPv6数据库中IPv4地址位于::/96之下
+	if db.ipVersion == 6 {
+		for i := 0; i < 96 && db.ipv4Start < db.nodeCount; i++ {
+			db.ipv4Start = db.record(db.ipv4Start, 0)
+		}
+	}
+	return db, nil
+}
+
+// record 读取节点的左(bit=0)或右(bit=1)记录
+func (db *MMDB) record(node uint, bit uint) uint {
+	offset := node * db.recordSize / 4
+	b := db.data[offset:]
+	switch db.recordSize {
+	case 24:
+		b = b[bit*3:]
+		return uint(b[0])<<16 | uint(b[1])<<8 | uint(b[2])
+	case 28:
+		if bit == 0 {
+			return uint(b[3]&0xF0)<<20 | uint(b[0])<<16 | uint(b[1])<<8 | uint(b[2])
+		}
+		return uint(b[3]&0x0F)<<24 | uint(b[4])<<16 | uint(b[5])<<8 | uint(b[6])
+	default:
+		return uint(binary.BigEndian.Uint32(b[bit*4:]))
+	}
+}
+
+// Lookup 查询IP所在网段的记录，未找到时返回nil
+func (db *MMDB) Lookup(ip net.IP) (map[string]interface{}, error) {
+	node := uint(0)
+	ipv4 := ip.To4()
+	if ipv4 != nil {
+		ip = ipv4
+		node = db.ipv4Start
+	} else if ip = ip.To16(); ip == nil {
+		return nil, fmt.Errorf("invalid ip")
+	} else if db.ipVersion == 4 {
+		return nil, fmt.Errorf("ipv6 address is not supported by ipv4 database")
+	}
+	for i := 0; i < len(ip)*8 && node < db.nodeCount; i++ {
+		bit := uint(ip[i/8]>>(7-uint(i%8))) & 1
+		node = db.record(node, bit)
+	}
+	if node == db.nodeCount {
+		return nil, nil
+	}
+	if node < db.nodeCount {
+		return nil, fmt.Errorf("mmdb search tree is invalid")
+	}
+	offset := node - db.nodeCount - 16
+	decoder := &mmdbDecoder{data: db.data[db.dataStart:]}
+	value, _, err := decoder.decode(offset, 0)
+	if err != nil {
+		return nil, err
+	}
+	record, ok := value.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("mmdb record is not a map")
+	}
+	return record, nil
+}
+
+// mmdbUint 元数据中的无符号整数
+func mmdbUint(value interface{}) uint {
+	switch v := value.(type) {
+	case uint16:
+		return uint(v)
+	case uint32:
+		return uint(v)
+	case uint64:
+		return uint(v)
+	}
+	return 0
+}
+
+// mmdbMaxDepth 指针及map、array的最大嵌套层数，防止损坏的数据库中的循环引用导致栈溢出
+const mmdbMaxDepth = 32
+
+// mmdbDecoder 数据区解码，指针偏移相对于data起始位置
+type mmdbDecoder struct {
+	data []byte
+}
+
+const (
+	mmdbExtended = iota
+	mmdbPointer
+	mmdbString
+	mmdbDouble
+	mmdbBytes
+	mmdbUint16
+	mmdbUint32
+	mmdbMap
+	mmdbInt32
+	mmdbUint64
+	mmdbUint128
+	mmdbArray
+	mmdbContainer
+	mmdbEndMarker
+	mmdbBool
+	mmdbFloat
+)
+
+// read 读取offset开始的n个字节
+func (d *mmdbDecoder) read(offset, n uint) ([]byte, error) {
+	if offset+n > uint(len(d.data)) {
+		return nil, fmt.Errorf("mmdb data is out of range")
+	}
+	return d.data[offset : offset+n], nil
+}
+
+// control 解析控制字节，返回类型、长度及数据偏移
+func (d *mmdbDecoder) control(offset uint) (int, uint, uint, error) {
+	b, err := d.read(offset, 1)
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	offset++
+	kind := int(b[0] >> 5)
+	if kind == mmdbExtended {
+		if b, err = d.read(offset, 1); err != nil {
+			return 0, 0, 0, err
+		}
+		kind = 7 + int(b[0])
+		offset++
+		b = d.data[offset-2 : offset]
+	}
+	size := uint(b[0] & 0x1f)
+	if kind == mmdbPointer || size < 29 {
+		return kind, size, offset, nil
+	}
+	n := size - 28
+	extra, err := d.read(offset, n)
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	offset += n
+	switch n {
+	case 1:
+		size = 29 + uint(extra[0])
+	case 2:
+		size = 285 + (uint(extra[0])<<8 | uint(extra[1]))
+	default:
+		size = 65821 + (uint(extra[0])<<16 | uint(extra[1])<<8 | uint(extra[2]))
+	}
+	return kind, size, offset, nil
+}
+
+// mmdbBigEndian 大端无符号整数
+func mmdbBigEndian(b []byte) uint64 {
+	var v uint64
+	for _, c := range b {
+		v = v<<8 | uint64(c)
+	}
+	return v
+}
+
+// decode 解码offset处的值，返回值及下一个值的偏移，depth为当前嵌套层数
+func (d *mmdbDecoder) decode(offset uint, depth int) (interface{}, uint, error) {
+	if depth > mmdbMaxDepth {
+		return nil, 0, fmt.Errorf("mmdb data exceeds max depth(%d)", mmdbMaxDepth)
+	}
+	kind, size, offset, err := d.control(offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	if kind == mmdbPointer {
+		n := (size>>3)&3 + 1
+		b, err := d.read(offset, n)
+		if err != nil {
+			return nil, 0, err
+		}
+		var pointer uint
+		switch n {
+		case 1:
+			pointer = (size&7)<<8 | uint(b[0])
+		case 2:
+			pointer = ((size&7)<<16 | uint(mmdbBigEndian(b))) + 2048
+		case 3:
+			pointer = ((size&7)<<24 | uint(mmdbBigEndian(b))) + 526336
+		default:
+			pointer = uint(mmdbBigEndian(b))
+		}
+		// 指针不能指向另一个指针
+		if target, _, _, err := d.control(pointer); err != nil {
+			return nil, 0, err
+		} else if target == mmdbPointer {
+			return nil, 0, fmt.Errorf("mmdb pointer(%d) points to another pointer", pointer)
+		}
+		value, _, err := d.decode(pointer, depth+1)
+		return value, offset + n, err
+	}
+	switch kind {
+	case mmdbMap:
+		result := make(map[string]interface{}, size)
+		for i := uint(0); i < size; i++ {
+			var key, value interface{}
+			if key, offset, err = d.decode(offset, depth+1); err != nil {
+				return nil, 0, err
+			}
+			name, ok := key.(string)
+			if !ok {
+				return nil, 0, fmt.Errorf("mmdb map key is not a string")
+			}
+			if value, offset, err = d.decode(offset, depth+1); err != nil {
+				return nil, 0, err
+			}
+			result[name] = value
+		}
+		return result, offset, nil
+	case mmdbArray:
+		result := make([]interface{}, 0, size)
+		for i := uint(0); i < size; i++ {
+			var value interface{}
+			if value, offset, err = d.decode(offset, depth+1); err != nil {
+				return nil, 0, err
+			}
+			result = append(result, value)
+		}
+		return result, offset, nil
+	case mmdbBool:
+		return size != 0, offset, nil
+	}
+	b, err := d.read(offset, size)
+	if err != nil {
+		return nil, 0, err
+	}
+	offset += size
+	switch kind {
+	case mmdbString:
+		return string(b), offset, nil
+	case mmdbBytes:
+		return append([]byte(nil), b...), offset, nil
+	case mmdbDouble:
+		if size != 8 {
+			return nil, 0, fmt.Errorf("mmdb double size(%d) is invalid", size)
+		}
+		return math.Float64frombits(binary.BigEndian.Uint64(b)), offset, nil
+	case mmdbFloat:
+		if size != 4 {
+			return nil, 0, fmt.Errorf("mmdb float size(%d) is invalid", size)
+		}
+		return float64(math.Float32frombits(binary.BigEndian.Uint32(b))), offset, nil
+	case mmdbUint16:
+		return uint16(mmdbBigEndian(b)), offset, nil
+	case mmdbUint32:
+		return uint32(mmdbBigEndian(b)), offset, nil
+	case mmdbUint64:
+		return mmdbBigEndian(b), offset, nil
+	case mmdbInt32:
+		return int32(uint32(mmdbBigEndian(b))), offset, nil
+	case mmdbUint128:
+		return new(big.Int).SetBytes(b), offset, nil
+	}
+	return nil, 0, fmt.Errorf("mmdb data type(%d) is not supported", kind)
+}
@@ -0,0 +1,141 @@
+// Tencent is pleased to support the open source community by making bkunifylogbeat 蓝鲸日志采集器 available.
+//
+// Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+//
+// bkunifylogbeat 蓝鲸日志采集器 is licensed under the MIT License.
+//
+// License for bkunifylogbeat 蓝鲸日志采集器:
+// --------------------------------------------------------------------
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+// documentation files (the "Software"), to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software,
+// and to permit persons to whom the Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all copies or substantial
+// portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+// LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN
+// NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+// WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+// SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package utils
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"net"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// mmdbEncode: 测试用的mmdb数据编码，仅支持map、string、double、uint16、uint32
+func mmdbEncode(buf *bytes.Buffer, value interface{}) {
+	var kind int
+	var payload []byte
+	switch v := value.(type) {
+	case map[string]interface{}:
+		buf.WriteByte(byte(mmdbMap<<5 | len(v)))
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			mmdbEncode(buf, key)
+			mmdbEncode(buf, v[key])
+		}
+		return
+	case string:
+		kind, payload = mmdbString, []byte(v)
+	case float64:
+		kind, payload = mmdbDouble, make([]byte, 8)
+		binary.BigEndian.PutUint64(payload, math.Float64bits(v))
+	case uint16:
+		kind, payload = mmdbUint16, []byte{byte(v >> 8), byte(v)}
+	case uint32:
+		kind, payload = mmdbUint32, make([]byte, 4)
+		binary.BigEndian.PutUint32(payload, v)
+	}
+	buf.WriteByte(byte(kind<<5 | len(payload)))
+	buf.Write(payload)
+}
+
+// buildMMDB: 构造只包含一个IPv4网段的数据库，record_size为24
+func buildMMDB(network *net.IPNet, record map[string]interface{}) []byte {
+	ones, _ := network.Mask.Size()
+	nodeCount := uint32(ones)
+	ip := network.IP.To4()
+	var buf bytes.Buffer
+	for i := 0; i < ones; i++ {
+		bit := (ip[i/8] >> (7 - uint(i%8))) & 1
+		next := uint32(i + 1)
+		if i == ones-1 {
+			next = nodeCount + 16
+		}
+		records := [2]uint32{nodeCount, nodeCount}
+		records[bit] = next
+		for _, r := range records {
+			buf.Write([]byte{byte(r >> 16), byte(r >> 8), byte(r)})
+		}
+	}
+	buf.Write(make([]byte, 16))
+	mmdbEncode(&buf, record)
+	buf.Write(mmdbMetadataMarker)
+	mmdbEncode(&buf, map[string]interface{}{
+		"node_count":    nodeCount,
+		"record_size":   uint16(24),
+		"ip_version":    uint16(4),
+		"database_type": "Test-City",
+	})
+	return buf.Bytes()
+}
+
+// TestMMDB: 测试mmdb解析及查询
+func TestMMDB(t *testing.T) {
+	_, network, _ := net.ParseCIDR("1.2.3.0/24")
+	db, err := NewMMDB(buildMMDB(network, map[string]interface{}{
+		"country":  map[string]interface{}{"iso_code": "CN"},
+		"location": map[string]interface{}{"latitude": 22.5, "longitude": 114.1},
+	}))
+	assert.NoError(t, err)
+	assert.Equal(t, "Test-City", db.DatabaseType)
+
+	record, err := db.Lookup(net.ParseIP("1.2.3.4"))
+	assert.NoError(t, err)
+	assert.Equal(t, "CN", record["country"].(map[string]interface{})["iso_code"])
+	assert.Equal(t, 22.5, record["location"].(map[string]interface{})["latitude"])
+
+	record, err = db.Lookup(net.ParseIP("1.2.4.4"))
+	assert.NoError(t, err)
+	assert.Nil(t, record)
+
+	_, err = db.Lookup(net.ParseIP("::1"))
+	assert.Error(t, err)
+
+	_, err = NewMMDB([]byte("invalid"))
+	assert.Error(t, err)
+}
+
+// TestMMDBDecodePointer: 指针指向指针或循环引用时返回错误
+func TestMMDBDecodePointer(t *testing.T) {
+	// 指向自身的指针
+	_, _, err := (&mmdbDecoder{data: []byte{0x20, 0x00}}).decode(0, 0)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "another pointer")
+
+	// 只含一个元素的array，元素为指向该array的指针
+	_, _, err = (&mmdbDecoder{data: []byte{0x01, 0x04, 0x20, 0x00}}).decode(0, 0)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "max depth")
+
+	// 指向string的指针
+	value, offset, err := (&mmdbDecoder{data: []byte{0x20, 0x02, 0x41, 'a'}}).decode(0, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, "a", value)
+	assert.Equal(t, uint(2), offset)
+}
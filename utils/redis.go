@@ -0,0 +1,190 @@
+// Tencent is pleased to support the open source community by making bkunifylogbeat 蓝鲸日志采集器 available.
+//
+// Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+//
+// bkunifylogbeat 蓝鲸日志采集器 is licensed under the MIT License.
+//
+// License for bkunifylogbeat 蓝鲸日志采集器:
+// --------------------------------------------------------------------
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+// documentation files (the "Software"), to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software,
+// and to permit persons to whom the Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all copies or substantial
+// portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+// LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN
+// NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+// WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+// SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package utils
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RedisError redis返回的错误回复
+type RedisError string
+
+func (e RedisError) Error() string {
+	return "redis error: " + string(e)
+}
+
+// RedisClient 最小化的redis客户端，只支持单连接的请求-回复模式，连接出错时下次请求自动重连
+// 回复类型：状态及bulk为string，空回复为nil，整数为int64，数组为[]interface{}，错误回复为RedisError
+type RedisClient struct {
+	address  string
+	password string
+	db       int
+	timeout  time.Duration
+
+	mutex  sync.Mutex
+	conn   net.Conn
+	reader *bufio.Reader
+}
+
+// NewRedisClient 生成redis客户端，timeout为连接及单次请求的超时时间
+func NewRedisClient(address, password string, db int, timeout time.Duration) *RedisClient {
+	return &RedisClient{
+		address:  address,
+		password: password,
+		db:       db,
+		timeout:  timeout,
+	}
+}
+
+// Do 执行命令
+func (c *RedisClient) Do(args ...string) (interface{}, error) {
+	return c.DoTimeout(c.timeout, args...)
+}
+
+// DoTimeout 以指定超时时间执行命令，用于BRPOP、XREAD BLOCK等阻塞命令
+func (c *RedisClient) DoTimeout(timeout time.Duration, args ...string) (interface{}, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.conn == nil {
+		if err := c.connect(); err != nil {
+			return nil, err
+		}
+	}
+	_ = c.conn.SetDeadline(time.Now().Add(timeout))
+	reply, err := c.command(args...)
+	if _, ok := err.(RedisError); err != nil && !ok {
+		c.close()
+	}
+	return reply, err
+}
+
+// Close 关闭连接
+func (c *RedisClient) Close() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.close()
+}
+
+// connect: 建立连接并完成认证及选库
+func (c *RedisClient) connect() error {
+	conn, err := net.DialTimeout("tcp", c.address, c.timeout)
+	if err != nil {
+		return err
+	}
+	c.conn, c.reader = conn, bufio.NewReader(conn)
+	_ = conn.SetDeadline(time.Now().Add(c.timeout))
+	if c.password != "" {
+		if _, err = c.command("AUTH", c.password); err != nil {
+			c.close()
+			return err
+		}
+	}
+	if c.db != 0 {
+		if _, err = c.command("SELECT", strconv.Itoa(c.db)); err != nil {
+			c.close()
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *RedisClient) close() {
+	if c.conn != nil {
+		_ = c.conn.Close()
+		c.conn, c.reader = nil, nil
+	}
+}
+
+// command: 发送RESP命令并读取回复
+func (c *RedisClient) command(args ...string) (interface{}, error) {
+	var b strings.Builder
+	fmt.Fprintf(&b, "*%d\r\n", len(args))
+	for _, arg := range args {
+		fmt.Fprintf(&b, "$%d\r\n%s\r\n", len(arg), arg)
+	}
+	if _, err := io.WriteString(c.conn, b.String()); err != nil {
+		return nil, err
+	}
+	return readRedisReply(c.reader)
+}
+
+// readRedisReply: 读取一个RESP回复
+func readRedisReply(reader *bufio.Reader) (interface{}, error) {
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	line = strings.TrimRight(line, "\r\n")
+	if line == "" {
+		return nil, fmt.Errorf("redis empty reply")
+	}
+	switch line[0] {
+	case '+':
+		return line[1:], nil
+	case '-':
+		return nil, RedisError(line[1:])
+	case ':':
+		return strconv.ParseInt(line[1:], 10, 64)
+	case '$':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return nil, fmt.Errorf("redis invalid reply %q", line)
+		}
+		if n < 0 {
+			return nil, nil
+		}
+		buf := make([]byte, n+2)
+		if _, err = io.ReadFull(reader, buf); err != nil {
+			return nil, err
+		}
+		return string(buf[:n]), nil
+	case '*':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return nil, fmt.Errorf("redis invalid reply %q", line)
+		}
+		if n < 0 {
+			return nil, nil
+		}
+		items := make([]interface{}, n)
+		for i := range items {
+			item, err := readRedisReply(reader)
+			// 数组中的错误回复作为元素返回
+			if e, ok := err.(RedisError); ok {
+				item = e
+			} else if err != nil {
+				return nil, err
+			}
+			items[i] = item
+		}
+		return items, nil
+	}
+	return nil, fmt.Errorf("redis unsupported reply %q", line)
+}
@@ -0,0 +1,90 @@
+// Tencent is pleased to support the open source community by making bkunifylogbeat 蓝鲸日志采集器 available.
+//
+// Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+//
+// bkunifylogbeat 蓝鲸日志采集器 is licensed under the MIT License.
+//
+// License for bkunifylogbeat 蓝鲸日志采集器:
+// --------------------------------------------------------------------
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+// documentation files (the "Software"), to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software,
+// and to permit persons to whom the Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all copies or substantial
+// portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+// LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN
+// NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+// WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+// SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package utils
+
+import (
+	"bufio"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// mockRedisServer: 按收到的命令顺序返回预设的回复
+func mockRedisServer(t *testing.T, replies ...string) (string, chan []interface{}) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	commands := make(chan []interface{}, len(replies))
+	go func() {
+		defer listener.Close()
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		reader := bufio.NewReader(conn)
+		for _, reply := range replies {
+			command, err := readRedisReply(reader)
+			if err != nil {
+				return
+			}
+			commands <- command.([]interface{})
+			conn.Write([]byte(reply))
+		}
+	}()
+	return listener.Addr().String(), commands
+}
+
+//TestRedisClient: 测试认证、选库及各类回复的解析
+func TestRedisClient(t *testing.T) {
+	address, commands := mockRedisServer(t,
+		"+OK\r\n", "+OK\r\n",
+		"$5\r\nhello\r\n",
+		"$-1\r\n",
+		"*2\r\n*2\r\n$1\r\nk\r\n:3\r\n-ERR bad\r\n",
+		"-WRONGTYPE\r\n",
+	)
+	c := NewRedisClient(address, "pass", 2, time.Second)
+	defer c.Close()
+
+	reply, err := c.Do("GET", "a")
+	assert.NoError(t, err)
+	assert.Equal(t, "hello", reply)
+	assert.Equal(t, []interface{}{"AUTH", "pass"}, <-commands)
+	assert.Equal(t, []interface{}{"SELECT", "2"}, <-commands)
+	assert.Equal(t, []interface{}{"GET", "a"}, <-commands)
+
+	reply, err = c.Do("GET", "b")
+	assert.NoError(t, err)
+	assert.Nil(t, reply)
+
+	reply, err = c.Do("X")
+	assert.NoError(t, err)
+	assert.Equal(t, []interface{}{[]interface{}{"k", int64(3)}, RedisError("ERR bad")}, reply)
+
+	// 错误回复不影响连接
+	_, err = c.Do("Y")
+	assert.Equal(t, RedisError("WRONGTYPE"), err)
+	assert.NotNil(t, c.conn)
+}
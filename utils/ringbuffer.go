@@ -0,0 +1,159 @@
+// Tencent is pleased to support the open source community by making bkunifylogbeat 蓝鲸日志采集器 available.
+//
+// Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+//
+// bkunifylogbeat 蓝鲸日志采集器 is licensed under the MIT License.
+//
+// License for bkunifylogbeat 蓝鲸日志采集器:
+// --------------------------------------------------------------------
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+// documentation files (the "Software"), to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software,
+// and to permit persons to whom the Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all copies or substantial
+// portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+// LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN
+// NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+// WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+// SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package utils
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math/bits"
+	"sync"
+	"sync/atomic"
+	"unsafe"
+)
+
+// 基于内存映射文件的环形缓冲区，用于和非Go进程(Python、C++等)交换事件
+//
+// 文件布局:
+//   [0, 8)    magic，固定为"BKRING01"
+//   [8, 16)   capacity，数据区大小(uint64)
+//   [16, 24)  write position，已写入的总字节数(uint64)，由写端在记录写完后原子更新
+//   [24, 32)  read position，已读取的总字节数(uint64)，由读端原子更新
+//   [32, 64)  保留
+//   [64, ...) 数据区
+// 数值字段均为小端字节序，与读端所在平台无关。
+// 每条记录为4字节小端长度 + 内容，记录可在数据区尾部回绕到头部；
+// 读端从read position开始，读到write position为止，读取位置对capacity取模即为数据区偏移。
+const (
+	ringBufferMagic      = "BKRING01"
+	ringBufferHeaderSize = 64
+	ringBufferRecordHead = 4
+)
+
+// ErrRingBufferFull 读端消费过慢，缓冲区剩余空间不足
+var ErrRingBufferFull = fmt.Errorf("ring buffer is full")
+
+// ErrRingBufferClosed 缓冲区已关闭，内存映射已解除
+var ErrRingBufferClosed = fmt.Errorf("ring buffer is closed")
+
+// hostLittleEndian: 本机是否为小端，大端平台读写位置字段时需要转换字节序
+var hostLittleEndian = func() bool {
+	x := uint16(1)
+	return *(*byte)(unsafe.Pointer(&x)) == 1
+}()
+
+// MMRingBuffer 内存映射环形缓冲区写端
+type MMRingBuffer struct {
+	mutex    sync.Mutex
+	data     []byte
+	capacity uint64
+	closer   func() error
+	closed   bool
+}
+
+// newRingBuffer: 在映射好的内存上初始化环形缓冲区
+func newRingBuffer(data []byte, closer func() error) *MMRingBuffer {
+	capacity := uint64(len(data) - ringBufferHeaderSize)
+	if string(data[:8]) != ringBufferMagic || binary.LittleEndian.Uint64(data[8:16]) != capacity {
+		copy(data[:8], ringBufferMagic)
+		binary.LittleEndian.PutUint64(data[8:16], capacity)
+		storeRingBufferPosition(data, 16, 0)
+		storeRingBufferPosition(data, 24, 0)
+	}
+	return &MMRingBuffer{
+		data:     data,
+		capacity: capacity,
+		closer:   closer,
+	}
+}
+
+// ringBufferPosition: 头部位置字段，mmap按页对齐，可以直接做原子操作
+func ringBufferPosition(data []byte, offset int) *uint64 {
+	return (*uint64)(unsafe.Pointer(&data[offset]))
+}
+
+// loadRingBufferPosition: 原子读取小端存储的位置字段
+func loadRingBufferPosition(data []byte, offset int) uint64 {
+	pos := atomic.LoadUint64(ringBufferPosition(data, offset))
+	if !hostLittleEndian {
+		pos = bits.ReverseBytes64(pos)
+	}
+	return pos
+}
+
+// storeRingBufferPosition: 原子写入位置字段，按小端存储
+func storeRingBufferPosition(data []byte, offset int, pos uint64) {
+	if !hostLittleEndian {
+		pos = bits.ReverseBytes64(pos)
+	}
+	atomic.StoreUint64(ringBufferPosition(data, offset), pos)
+}
+
+// Write 写入一条记录，剩余空间不足时返回ErrRingBufferFull，关闭后返回ErrRingBufferClosed
+func (r *MMRingBuffer) Write(record []byte) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if r.closed {
+		return ErrRingBufferClosed
+	}
+
+	size := uint64(ringBufferRecordHead + len(record))
+	writePos := loadRingBufferPosition(r.data, 16)
+	readPos := loadRingBufferPosition(r.data, 24)
+	if writePos-readPos+size > r.capacity {
+		return ErrRingBufferFull
+	}
+
+	var head [ringBufferRecordHead]byte
+	binary.LittleEndian.PutUint32(head[:], uint32(len(record)))
+	r.copyAt(writePos, head[:])
+	r.copyAt(writePos+ringBufferRecordHead, record)
+	storeRingBufferPosition(r.data, 16, writePos+size)
+	return nil
+}
+
+// copyAt: 按环形方式写入数据区
+func (r *MMRingBuffer) copyAt(pos uint64, b []byte) {
+	area := r.data[ringBufferHeaderSize:]
+	offset := pos % r.capacity
+	n := copy(area[offset:], b)
+	if n < len(b) {
+		copy(area, b[n:])
+	}
+}
+
+// Close 解除内存映射并关闭文件
+func (r *MMRingBuffer) Close() error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if r.closed {
+		return nil
+	}
+	r.closed = true
+	r.data = nil
+	if r.closer == nil {
+		return nil
+	}
+	err := r.closer()
+	r.closer = nil
+	return err
+}
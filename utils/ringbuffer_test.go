@@ -0,0 +1,68 @@
+// Tencent is pleased to support the open source community by making bkunifylogbeat 蓝鲸日志采集器 available.
+//
+// Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+//
+// bkunifylogbeat 蓝鲸日志采集器 is licensed under the MIT License.
+//
+// License for bkunifylogbeat 蓝鲸日志采集器:
+// --------------------------------------------------------------------
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+// documentation files (the "Software"), to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software,
+// and to permit persons to whom the Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all copies or substantial
+// portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+// LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN
+// NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+// WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+// SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package utils
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+//TestRingBuffer: 测试环形缓冲区写入、回绕及溢出
+func TestRingBuffer(t *testing.T) {
+	data := make([]byte, ringBufferHeaderSize+16)
+	r := newRingBuffer(data, nil)
+	assert.Equal(t, ringBufferMagic, string(data[:8]))
+
+	// 4字节长度 + 6字节内容
+	assert.Nil(t, r.Write([]byte("hello!")))
+	assert.Equal(t, uint64(10), binary.LittleEndian.Uint64(data[16:24]))
+	assert.Equal(t, uint32(6), binary.LittleEndian.Uint32(data[ringBufferHeaderSize:]))
+
+	// 剩余6字节，无法再写入10字节的记录
+	assert.Equal(t, ErrRingBufferFull, r.Write([]byte("world!")))
+
+	// 读端消费后可以回绕写入
+	binary.LittleEndian.PutUint64(data[24:32], 10)
+	assert.Nil(t, r.Write([]byte("world!")))
+	assert.Equal(t, uint64(20), binary.LittleEndian.Uint64(data[16:24]))
+	area := data[ringBufferHeaderSize:]
+	assert.Equal(t, "world!", string(area[14:16])+string(area[:4]))
+}
+
+//TestRingBufferClose: 测试关闭后写入返回ErrRingBufferClosed，且不再访问已解除映射的内存
+func TestRingBufferClose(t *testing.T) {
+	closeTimes := 0
+	r := newRingBuffer(make([]byte, ringBufferHeaderSize+16), func() error {
+		closeTimes++
+		return nil
+	})
+	assert.Nil(t, r.Write([]byte("a")))
+	assert.Nil(t, r.Close())
+	assert.Equal(t, ErrRingBufferClosed, r.Write([]byte("b")))
+
+	// 重复关闭只解除一次映射
+	assert.Nil(t, r.Close())
+	assert.Equal(t, 1, closeTimes)
+}
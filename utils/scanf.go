@@ -0,0 +1,97 @@
+// Tencent is pleased to support the open source community by making bkunifylogbeat 蓝鲸日志采集器 available.
+//
+// Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+//
+// bkunifylogbeat 蓝鲸日志采集器 is licensed under the MIT License.
+//
+// License for bkunifylogbeat 蓝鲸日志采集器:
+// --------------------------------------------------------------------
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+// documentation files (the "Software"), to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software,
+// and to permit persons to whom the Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all copies or substantial
+// portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+// LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN
+// NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+// WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+// SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package utils
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ScanfFormat 编译后的scanf格式，仅支持%s占位符及%%转义，不依赖反射
+// 例如: "User %s logged in from %s" => literals: ["User ", " logged in from ", ""]
+type ScanfFormat struct {
+	literals []string
+}
+
+// NewScanfFormat 解析scanf格式字符串
+func NewScanfFormat(format string) (*ScanfFormat, error) {
+	var literals []string
+	var literal strings.Builder
+	for i := 0; i < len(format); i++ {
+		if format[i] != '%' {
+			literal.WriteByte(format[i])
+			continue
+		}
+		if i+1 >= len(format) {
+			return nil, fmt.Errorf("scanf format(%s) ends with %%", format)
+		}
+		i++
+		switch format[i] {
+		case '%':
+			literal.WriteByte('%')
+		case 's':
+			// 相邻的占位符无法确定边界
+			if len(literals) > 0 && literal.Len() == 0 {
+				return nil, fmt.Errorf("scanf format(%s) has adjacent placeholders", format)
+			}
+			literals = append(literals, literal.String())
+			literal.Reset()
+		default:
+			return nil, fmt.Errorf("scanf format(%s) only supports %%s", format)
+		}
+	}
+	if len(literals) == 0 {
+		return nil, fmt.Errorf("scanf format(%s) has no placeholder", format)
+	}
+	literals = append(literals, literal.String())
+	return &ScanfFormat{literals: literals}, nil
+}
+
+// Scan 按格式提取占位符内容，格式不匹配时返回false
+// 每个占位符匹配到其后第一个字面量为止，最后一个占位符后无字面量时匹配到末尾
+func (f *ScanfFormat) Scan(text string) ([]string, bool) {
+	if !strings.HasPrefix(text, f.literals[0]) {
+		return nil, false
+	}
+	text = text[len(f.literals[0]):]
+
+	captures := make([]string, 0, len(f.literals)-1)
+	for _, literal := range f.literals[1:] {
+		if literal == "" {
+			captures = append(captures, text)
+			text = ""
+			continue
+		}
+		idx := strings.Index(text, literal)
+		if idx < 0 {
+			return nil, false
+		}
+		captures = append(captures, text[:idx])
+		text = text[idx+len(literal):]
+	}
+	// 最后一个字面量之后不允许有多余内容
+	if text != "" {
+		return nil, false
+	}
+	return captures, true
+}
@@ -0,0 +1,53 @@
+// Tencent is pleased to support the open source community by making bkunifylogbeat 蓝鲸日志采集器 available.
+//
+// Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+//
+// bkunifylogbeat 蓝鲸日志采集器 is licensed under the MIT License.
+//
+// License for bkunifylogbeat 蓝鲸日志采集器:
+// --------------------------------------------------------------------
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+// documentation files (the "Software"), to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software,
+// and to permit persons to whom the Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all copies or substantial
+// portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+// LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN
+// NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+// WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+// SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+//TestScanfFormat: 测试scanf格式提取
+func TestScanfFormat(t *testing.T) {
+	f, err := NewScanfFormat("User %s logged in from %s")
+	assert.Nil(t, err)
+
+	captures, ok := f.Scan("User admin logged in from 10.0.0.1")
+	assert.True(t, ok)
+	assert.Equal(t, []string{"admin", "10.0.0.1"}, captures)
+
+	_, ok = f.Scan("User admin logged out")
+	assert.False(t, ok)
+
+	f, err = NewScanfFormat("cost %s%%")
+	assert.Nil(t, err)
+	captures, ok = f.Scan("cost 15%")
+	assert.True(t, ok)
+	assert.Equal(t, []string{"15"}, captures)
+
+	for _, format := range []string{"no placeholder", "%s%s", "%d items", "end %"} {
+		_, err = NewScanfFormat(format)
+		assert.NotNil(t, err)
+	}
+}
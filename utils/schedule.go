@@ -0,0 +1,174 @@
+// Tencent is pleased to support the open source community by making bkunifylogbeat 蓝鲸日志采集器 available.
+//
+// Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+//
+// bkunifylogbeat 蓝鲸日志采集器 is licensed under the MIT License.
+//
+// License for bkunifylogbeat 蓝鲸日志采集器:
+// --------------------------------------------------------------------
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+// documentation files (the "Software"), to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software,
+// and to permit persons to whom the Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all copies or substantial
+// portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+// LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN
+// NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+// WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+// SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package utils
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule 采集时间计划，当前时间落在任意时间窗口内，或满足cron表达式时视为生效
+type Schedule struct {
+	windows []dailyWindow
+	cron    []map[int]bool // 分 时 日 月 周
+	// 日、周都不以*开头时，与标准cron一致，满足其中一个即可
+	cronDayOr bool
+	location  *time.Location
+}
+
+// dailyWindow: 每日时间窗口，单位为当天的分钟数，end小于begin时表示跨越零点，end等于begin时表示全天
+type dailyWindow struct {
+	begin int
+	end   int
+}
+
+// cronFieldRanges: cron各字段的取值范围，周的0和7都表示周日
+var cronFieldRanges = [][2]int{{0, 59}, {0, 23}, {1, 31}, {1, 12}, {0, 7}}
+
+// NewSchedule 解析时间计划
+// windows形如"00:00-06:00"、"22:00-02:00"(跨零点)，开始与结束相同时表示全天；
+// cron为5段式表达式(分 时 日 月 周，周日为0或7)，支持*、a-b、a,b及*/n、a-b/n，日和周都有限制时满足任一即可，
+// timezone为空时使用本地时区
+func NewSchedule(windows []string, cron, timezone string) (*Schedule, error) {
+	s := &Schedule{location: time.Local}
+	if timezone != "" {
+		location, err := time.LoadLocation(timezone)
+		if err != nil {
+			return nil, fmt.Errorf("timezone(%s) is not valid, err=>%v", timezone, err)
+		}
+		s.location = location
+	}
+	for _, window := range windows {
+		w, err := parseDailyWindow(window)
+		if err != nil {
+			return nil, err
+		}
+		s.windows = append(s.windows, w)
+	}
+	if cron != "" {
+		fields := strings.Fields(cron)
+		if len(fields) != len(cronFieldRanges) {
+			return nil, fmt.Errorf("cron(%s) must have 5 fields", cron)
+		}
+		for i, field := range fields {
+			values, err := parseCronField(field, cronFieldRanges[i][0], cronFieldRanges[i][1])
+			if err != nil {
+				return nil, fmt.Errorf("cron(%s) is not valid, err=>%v", cron, err)
+			}
+			if i == 4 && values[7] {
+				delete(values, 7)
+				values[0] = true
+			}
+			s.cron = append(s.cron, values)
+		}
+		s.cronDayOr = !strings.HasPrefix(fields[2], "*") && !strings.HasPrefix(fields[4], "*")
+	}
+	return s, nil
+}
+
+// Active 判断时间是否在计划内，未配置任何窗口及cron时总是生效
+func (s *Schedule) Active(t time.Time) bool {
+	if len(s.windows) == 0 && s.cron == nil {
+		return true
+	}
+	t = t.In(s.location)
+	minute := t.Hour()*60 + t.Minute()
+	for _, w := range s.windows {
+		switch {
+		case w.begin == w.end:
+			return true
+		case w.begin < w.end && minute >= w.begin && minute < w.end:
+			return true
+		case w.begin > w.end && (minute >= w.begin || minute < w.end):
+			return true
+		}
+	}
+	if s.cron != nil {
+		if !s.cron[0][t.Minute()] || !s.cron[1][t.Hour()] || !s.cron[3][int(t.Month())] {
+			return false
+		}
+		dom, dow := s.cron[2][t.Day()], s.cron[4][int(t.Weekday())]
+		if s.cronDayOr {
+			return dom || dow
+		}
+		return dom && dow
+	}
+	return false
+}
+
+// parseDailyWindow: 解析"HH:MM-HH:MM"
+func parseDailyWindow(window string) (dailyWindow, error) {
+	parts := strings.Split(window, "-")
+	if len(parts) != 2 {
+		return dailyWindow{}, fmt.Errorf("window(%s) must be HH:MM-HH:MM", window)
+	}
+	var minutes [2]int
+	for i, part := range parts {
+		t, err := time.Parse("15:04", strings.TrimSpace(part))
+		if err != nil {
+			return dailyWindow{}, fmt.Errorf("window(%s) must be HH:MM-HH:MM", window)
+		}
+		minutes[i] = t.Hour()*60 + t.Minute()
+	}
+	return dailyWindow{begin: minutes[0], end: minutes[1]}, nil
+}
+
+// parseCronField: 解析cron的单个字段
+func parseCronField(field string, min, max int) (map[int]bool, error) {
+	values := make(map[int]bool)
+	for _, item := range strings.Split(field, ",") {
+		step := 1
+		if i := strings.Index(item, "/"); i >= 0 {
+			var err error
+			if step, err = strconv.Atoi(item[i+1:]); err != nil || step <= 0 {
+				return nil, fmt.Errorf("step(%s) is not valid", item)
+			}
+			item = item[:i]
+		}
+		begin, end := min, max
+		if item != "*" {
+			bounds := strings.SplitN(item, "-", 2)
+			var err error
+			if begin, err = strconv.Atoi(bounds[0]); err != nil {
+				return nil, fmt.Errorf("value(%s) is not valid", item)
+			}
+			end = begin
+			if len(bounds) == 2 {
+				if end, err = strconv.Atoi(bounds[1]); err != nil {
+					return nil, fmt.Errorf("value(%s) is not valid", item)
+				}
+			} else if step > 1 {
+				end = max
+			}
+		}
+		if begin < min || end > max || begin > end {
+			return nil, fmt.Errorf("value(%s) out of range [%d, %d]", item, min, max)
+		}
+		for v := begin; v <= end; v += step {
+			values[v] = true
+		}
+	}
+	return values, nil
+}
@@ -0,0 +1,99 @@
+// Tencent is pleased to support the open source community by making bkunifylogbeat 蓝鲸日志采集器 available.
+//
+// Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+//
+// bkunifylogbeat 蓝鲸日志采集器 is licensed under the MIT License.
+//
+// License for bkunifylogbeat 蓝鲸日志采集器:
+// --------------------------------------------------------------------
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+// documentation files (the "Software"), to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software,
+// and to permit persons to whom the Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all copies or substantial
+// portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+// LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN
+// NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+// WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+// SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package utils
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+//TestSchedule: 测试时间窗口及cron计划
+func TestSchedule(t *testing.T) {
+	at := func(value string) time.Time {
+		v, _ := time.Parse(time.RFC3339, value)
+		return v
+	}
+
+	s, err := NewSchedule([]string{"22:00-06:00"}, "", "UTC")
+	assert.Nil(t, err)
+	assert.True(t, s.Active(at("2021-06-04T23:30:00Z")))
+	assert.True(t, s.Active(at("2021-06-04T05:59:00Z")))
+	assert.False(t, s.Active(at("2021-06-04T06:00:00Z")))
+
+	// 工作日0点到6点，每隔10分钟
+	s, err = NewSchedule(nil, "*/10 0-5 * * 1-5", "UTC")
+	assert.Nil(t, err)
+	assert.True(t, s.Active(at("2021-06-04T03:20:00Z")))
+	assert.False(t, s.Active(at("2021-06-04T03:21:00Z")))
+	assert.False(t, s.Active(at("2021-06-05T03:20:00Z")))
+
+	// 开始与结束相同表示全天
+	s, err = NewSchedule([]string{"08:00-08:00"}, "", "UTC")
+	assert.Nil(t, err)
+	assert.True(t, s.Active(at("2021-06-04T07:59:00Z")))
+	assert.True(t, s.Active(at("2021-06-04T08:00:00Z")))
+
+	// 日和周都有限制时满足任一即可：每月1号或每周一
+	s, err = NewSchedule(nil, "0 0 1 * 1", "UTC")
+	assert.Nil(t, err)
+	assert.True(t, s.Active(at("2021-06-01T00:00:00Z")))
+	assert.True(t, s.Active(at("2021-06-07T00:00:00Z")))
+	assert.False(t, s.Active(at("2021-06-08T00:00:00Z")))
+	assert.False(t, s.Active(at("2021-06-07T00:01:00Z")))
+
+	// 任一字段以*开头(包括*/n)时两者需同时满足：奇数日且为周一
+	s, err = NewSchedule(nil, "0 0 */2 * 1", "UTC")
+	assert.Nil(t, err)
+	assert.True(t, s.Active(at("2021-06-07T00:00:00Z")))
+	assert.False(t, s.Active(at("2021-06-14T00:00:00Z")))
+	assert.False(t, s.Active(at("2021-06-09T00:00:00Z")))
+	s, err = NewSchedule(nil, "0 0 1 * *", "UTC")
+	assert.Nil(t, err)
+	assert.True(t, s.Active(at("2021-06-01T00:00:00Z")))
+	assert.False(t, s.Active(at("2021-06-07T00:00:00Z")))
+
+	// 周的7与0相同，表示周日
+	s, err = NewSchedule(nil, "* * * * 1-7", "UTC")
+	assert.Nil(t, err)
+	assert.True(t, s.Active(at("2021-06-06T00:00:00Z")))
+	assert.True(t, s.Active(at("2021-06-07T00:00:00Z")))
+	s, err = NewSchedule(nil, "* * * * 7", "UTC")
+	assert.Nil(t, err)
+	assert.True(t, s.Active(at("2021-06-06T00:00:00Z")))
+	assert.False(t, s.Active(at("2021-06-05T00:00:00Z")))
+	_, err = NewSchedule(nil, "* * * * 8", "UTC")
+	assert.NotNil(t, err)
+
+	s, err = NewSchedule(nil, "", "")
+	assert.Nil(t, err)
+	assert.True(t, s.Active(time.Now()))
+
+	_, err = NewSchedule([]string{"25:00-06:00"}, "", "")
+	assert.NotNil(t, err)
+	_, err = NewSchedule(nil, "* * *", "")
+	assert.NotNil(t, err)
+	_, err = NewSchedule(nil, "60 * * * *", "")
+	assert.NotNil(t, err)
+}
@@ -23,6 +23,8 @@
 package utils
 
 import (
+	"fmt"
+	"strings"
 	"time"
 
 	bkcommon "github.com/TencentBlueKing/collector-go-sdk/v2/bkbeat/common"
@@ -35,3 +37,24 @@ func GetDateTime() (localTime string, utcTime string, unixTime int64) {
 	unixTime = time.Now().Unix()
 	return
 }
+
+// ParseWeekdays 解析逗号分隔的星期列表，支持全称及三字母缩写，不区分大小写，如"Mon,Tue,Friday"
+func ParseWeekdays(days string) (map[time.Weekday]bool, error) {
+	weekdays := make(map[time.Weekday]bool)
+	for _, day := range strings.Split(days, ",") {
+		day = strings.ToLower(strings.TrimSpace(day))
+		found := false
+		for weekday := time.Sunday; weekday <= time.Saturday; weekday++ {
+			name := strings.ToLower(weekday.String())
+			if day == name || day == name[:3] {
+				weekdays[weekday] = true
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("weekday(%s) is not valid", day)
+		}
+	}
+	return weekdays, nil
+}
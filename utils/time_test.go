@@ -36,3 +36,13 @@ func TestGetDateTime(t *testing.T) {
 	st, _ := time.Parse(bkcommon.TimeFormat, utcTime) //string转time
 	assert.Equal(t, st.Unix(), timestamp)
 }
+
+//TestParseWeekdays: 测试星期列表解析
+func TestParseWeekdays(t *testing.T) {
+	weekdays, err := ParseWeekdays("Mon, tue,FRIDAY")
+	assert.Nil(t, err)
+	assert.Equal(t, map[time.Weekday]bool{time.Monday: true, time.Tuesday: true, time.Friday: true}, weekdays)
+
+	_, err = ParseWeekdays("Mon,Funday")
+	assert.NotNil(t, err)
+}